@@ -23,6 +23,7 @@ package db
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 )
 
@@ -36,6 +37,105 @@ type Adapter interface {
 	Open(ConnectionURL) (Session, error)
 }
 
+// Capability represents an optional feature that an adapter may support.
+// Capabilities can be combined with the bitwise OR operator.
+type Capability uint
+
+// Adapter capabilities.
+const (
+	// CapabilityTransactions means the adapter supports transactions.
+	CapabilityTransactions Capability = 1 << iota
+
+	// CapabilityReturning means the adapter can return values from the rows
+	// affected by an INSERT statement (e.g.: RETURNING or OUTPUT clauses).
+	CapabilityReturning
+
+	// CapabilityCompositeKeys means the adapter supports primary keys made of
+	// more than one column.
+	CapabilityCompositeKeys
+
+	// CapabilitySchemas means the adapter supports schema-qualified collection
+	// names, like "schema.table".
+	CapabilitySchemas
+
+	// CapabilitySavepoints means the adapter supports savepoints, which are
+	// used to nest transactions.
+	CapabilitySavepoints
+
+	// CapabilityReadPreferences means the adapter honours the read preference
+	// of a result set, see Result.ReadPreference.
+	CapabilityReadPreferences
+
+	// CapabilityNone means the adapter does not declare any capabilities.
+	CapabilityNone Capability = 0
+)
+
+var capabilityNames = []struct {
+	c    Capability
+	name string
+}{
+	{CapabilityTransactions, "transactions"},
+	{CapabilityReturning, "returning"},
+	{CapabilityCompositeKeys, "composite keys"},
+	{CapabilitySchemas, "schemas"},
+	{CapabilitySavepoints, "savepoints"},
+	{CapabilityReadPreferences, "read preferences"},
+}
+
+// Has returns true if all the capabilities in c2 are also present in c.
+func (c Capability) Has(c2 Capability) bool {
+	return c&c2 == c2
+}
+
+func (c Capability) String() string {
+	names := []string{}
+	for _, cn := range capabilityNames {
+		if c.Has(cn.c) {
+			names = append(names, cn.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%v", names)
+}
+
+// AdapterCapabilities is an optional interface for adapters that want to
+// declare which features they support.
+type AdapterCapabilities interface {
+	Capabilities() Capability
+}
+
+// AdapterCompiler is an optional interface for SQL adapters that can compile
+// statements without being connected to a database.
+type AdapterCompiler interface {
+	// SQL returns a builder that compiles statements with the syntax of the
+	// adapter. Statements can be turned into text with String and Arguments,
+	// but can't be executed.
+	SQL() SQL
+}
+
+// AdapterURLParser is an optional interface for adapters that can turn a
+// connection URL string into their ConnectionURL, see OpenProfile.
+type AdapterURLParser interface {
+	ParseURL(s string) (ConnectionURL, error)
+}
+
+// AdapterInfo describes a registered adapter.
+type AdapterInfo struct {
+	// Name is the name the adapter was registered with.
+	Name string
+
+	// Capabilities holds the features the adapter declared to support.
+	Capabilities Capability
+}
+
+// Supports returns true if the adapter declared support for the given
+// capabilities.
+func (ai AdapterInfo) Supports(c Capability) bool {
+	return ai.Capabilities.Has(c)
+}
+
 type missingAdapter struct {
 	name string
 }
@@ -69,6 +169,60 @@ func LookupAdapter(name string) Adapter {
 	return &missingAdapter{name: name}
 }
 
+// Adapters returns information about all registered adapters, sorted by name.
+func Adapters() []AdapterInfo {
+	adapterMapMu.RLock()
+	defer adapterMapMu.RUnlock()
+
+	adapters := make([]AdapterInfo, 0, len(adapterMap))
+	for name, adapter := range adapterMap {
+		adapters = append(adapters, newAdapterInfo(name, adapter))
+	}
+
+	sort.Slice(adapters, func(i, j int) bool {
+		return adapters[i].Name < adapters[j].Name
+	})
+
+	return adapters
+}
+
+// LookupAdapterInfo returns information about a previously registered adapter.
+// The second returned value is false if no adapter was registered with the
+// given name.
+func LookupAdapterInfo(name string) (AdapterInfo, bool) {
+	adapterMapMu.RLock()
+	defer adapterMapMu.RUnlock()
+
+	if adapter, ok := adapterMap[name]; ok {
+		return newAdapterInfo(name, adapter), true
+	}
+	return AdapterInfo{Name: name}, false
+}
+
+func newAdapterInfo(name string, adapter Adapter) AdapterInfo {
+	info := AdapterInfo{Name: name}
+	if ac, ok := adapter.(AdapterCapabilities); ok {
+		info.Capabilities = ac.Capabilities()
+	}
+	return info
+}
+
+// CompilerFor returns a builder that compiles statements with the syntax of
+// the adapter registered with the given name, without connecting to any
+// database, see AdapterCompiler. It returns ErrUnsupported if the adapter
+// can't compile statements.
+func CompilerFor(adapterName string) (SQL, error) {
+	if _, ok := LookupAdapterInfo(adapterName); !ok {
+		return nil, fmt.Errorf("upper: Missing adapter %q, did you forget to import it?", adapterName)
+	}
+	if c, ok := LookupAdapter(adapterName).(AdapterCompiler); ok {
+		if sqlb := c.SQL(); sqlb != nil {
+			return sqlb, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: compiling statements with adapter %q", ErrUnsupported, adapterName)
+}
+
 // Open attempts to stablish a connection with a database.
 func Open(adapterName string, settings ConnectionURL) (Session, error) {
 	return LookupAdapter(adapterName).Open(settings)
@@ -0,0 +1,84 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package clickhouse
+
+import (
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/sqladapter"
+	"github.com/upper/db/v4/internal/sqlbuilder"
+)
+
+type collectionAdapter struct {
+}
+
+// Insert appends the item to the table. ClickHouse does not generate IDs, so
+// the returned ID is always nil.
+func (*collectionAdapter) Insert(col sqladapter.Collection, item interface{}) (interface{}, error) {
+	columnNames, columnValues, err := sqlbuilder.Map(item, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	q := col.SQL().InsertInto(col.Name()).
+		Columns(columnNames...).
+		Values(columnValues...)
+
+	if _, err := q.Exec(); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// InsertBatch appends all the rows with a single INSERT statement, ClickHouse
+// works best with few large inserts.
+func (*collectionAdapter) InsertBatch(col sqladapter.Collection, columnNames []string, rows [][]interface{}) ([]interface{}, error) {
+	q := col.SQL().InsertInto(col.Name()).Columns(columnNames...)
+	for i := range rows {
+		q = q.Values(rows[i]...)
+	}
+
+	if _, err := q.Exec(); err != nil {
+		return nil, err
+	}
+
+	return make([]interface{}, len(rows)), nil
+}
+
+// Stats sums the rows and the storage of the active parts of the table. Parts
+// are written with exact row counts, ClickHouse does not analyze tables and
+// LastAnalyzed is always nil. IndexSize is the size of the primary index.
+func (*collectionAdapter) Stats(col sqladapter.Collection) (*db.CollectionStats, error) {
+	row, err := col.SQL().QueryRow(`
+		SELECT sum(rows), sum(bytes_on_disk), sum(primary_key_bytes_in_memory)
+		FROM system.parts
+		WHERE database = currentDatabase() AND table = ? AND active`, col.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var stats db.CollectionStats
+	if err := row.Scan(&stats.Rows, &stats.DataSize, &stats.IndexSize); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package clickhouse provides a ClickHouse adapter for analytics workloads.
+// The adapter talks to the server through database/sql using the "clickhouse"
+// driver, which must be registered by importing a driver package, such as
+// github.com/ClickHouse/clickhouse-go/v2.
+package clickhouse
+
+import (
+	"database/sql"
+	"strings"
+
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/sqladapter"
+	"github.com/upper/db/v4/internal/sqladapter/exql"
+)
+
+// database is the actual implementation of Database
+type database struct {
+}
+
+func (*database) Template() *exql.Template {
+	return template
+}
+
+func (*database) OpenDSN(sess sqladapter.Session, dsn string) (*sql.DB, error) {
+	return sql.Open("clickhouse", dsn)
+}
+
+func (*database) Collections(sess sqladapter.Session) (collections []string, err error) {
+	q := sess.SQL().
+		Select("name").
+		From("system.tables").
+		Where("database = currentDatabase()")
+
+	iter := q.Iterator()
+	defer iter.Close()
+
+	for iter.Next() {
+		var tableName string
+		if err := iter.Scan(&tableName); err != nil {
+			return nil, err
+		}
+		collections = append(collections, tableName)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return collections, nil
+}
+
+func (*database) Err(err error) error {
+	if err != nil {
+		// The driver reports server exceptions by code and message, we check
+		// them by their string value.
+		s := err.Error()
+		if strings.Contains(s, `Too many simultaneous queries`) || strings.Contains(s, `Too many connections`) {
+			return db.ErrTooManyClients
+		}
+	}
+	return err
+}
+
+// Capabilities declares no optional features: ClickHouse has no RETURNING,
+// no unique primary keys and no transactions.
+func (*database) Capabilities() db.Capability {
+	return db.CapabilityNone
+}
+
+// ParseURL parses a connection URL of the adapter.
+func (*database) ParseURL(s string) (db.ConnectionURL, error) {
+	return ParseURL(s)
+}
+
+func (*database) NewCollection() sqladapter.CollectionAdapter {
+	return &collectionAdapter{}
+}
+
+func (*database) LookupName(sess sqladapter.Session) (string, error) {
+	q := sess.SQL().
+		Select(db.Raw("currentDatabase() AS name"))
+
+	iter := q.Iterator()
+	defer iter.Close()
+
+	if iter.Next() {
+		var name string
+		if err := iter.Scan(&name); err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+
+	return "", iter.Err()
+}
+
+func (*database) TableExists(sess sqladapter.Session, name string) error {
+	q := sess.SQL().
+		Select("name").
+		From("system.tables").
+		Where("database = currentDatabase() AND name = ?", name)
+
+	iter := q.Iterator()
+	defer iter.Close()
+
+	if iter.Next() {
+		var name string
+		if err := iter.Scan(&name); err != nil {
+			return err
+		}
+		return nil
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	return db.ErrCollectionDoesNotExist
+}
+
+// PrimaryKeys returns no keys. The primary key of a ClickHouse table only
+// defines how rows are sorted, it does not identify rows, so items can't be
+// looked up, updated or deleted by key.
+func (*database) PrimaryKeys(sess sqladapter.Session, tableName string) ([]string, error) {
+	return []string{}, nil
+}
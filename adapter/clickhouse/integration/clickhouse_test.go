@@ -0,0 +1,225 @@
+// Copyright (c) 2012-today The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/testsuite"
+)
+
+type artistType struct {
+	ID   uint64 `db:"id"`
+	Name string `db:"name"`
+}
+
+type pageView struct {
+	ID       uint64    `db:"id"`
+	Path     string    `db:"path"`
+	Duration float64   `db:"duration"`
+	Created  time.Time `db:"created"`
+}
+
+type AdapterTests struct {
+	testsuite.Suite
+}
+
+func (s *AdapterTests) SetupSuite() {
+	s.Helper = &Helper{}
+}
+
+func (s *AdapterTests) insertArtists(n int) {
+	items := make([]artistType, n)
+	for i := range items {
+		items[i] = artistType{ID: uint64(i + 1), Name: fmt.Sprintf("artist-%d", i+1)}
+	}
+	_, err := s.Session().Collection("artist").InsertMany(items, 0)
+	s.NoError(err)
+}
+
+func (s *AdapterTests) TestInsert() {
+	artist := s.Session().Collection("artist")
+
+	res, err := artist.Insert(artistType{ID: 1, Name: "Ozzie"})
+	s.NoError(err)
+	s.Nil(res.ID())
+
+	var item artistType
+	s.NoError(artist.Find(db.Cond{"id": 1}).One(&item))
+	s.Equal("Ozzie", item.Name)
+}
+
+func (s *AdapterTests) TestInsertManySingleStatement() {
+	sess := s.Session().WithContext(context.Background())
+
+	inserts := 0
+	sess.Use(func(next db.QueryHandler) db.QueryHandler {
+		return func(ctx context.Context, query string, args []interface{}) error {
+			if strings.HasPrefix(strings.TrimSpace(query), "INSERT") {
+				inserts++
+			}
+			return next(ctx, query, args)
+		}
+	})
+
+	items := make([]pageView, 100)
+	for i := range items {
+		items[i] = pageView{
+			ID:       uint64(i + 1),
+			Path:     fmt.Sprintf("/page/%d", i%5),
+			Duration: float64(i),
+			Created:  time.Date(2020, 1, 1, 0, 0, i, 0, time.UTC),
+		}
+	}
+
+	res, err := sess.Collection("page_views").InsertMany(items, 0)
+	s.NoError(err)
+	s.Equal(1, inserts)
+	s.Len(res, len(items))
+
+	count, err := sess.Collection("page_views").Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(len(items)), count)
+}
+
+func (s *AdapterTests) TestFind() {
+	s.insertArtists(10)
+
+	artist := s.Session().Collection("artist")
+
+	var items []artistType
+	err := artist.Find(db.Cond{"id >": 3}).OrderBy("-id").Limit(3).Offset(1).All(&items)
+	s.NoError(err)
+	s.Equal([]artistType{
+		{ID: 9, Name: "artist-9"},
+		{ID: 8, Name: "artist-8"},
+		{ID: 7, Name: "artist-7"},
+	}, items)
+
+	count, err := artist.Find(db.Cond{"id >": 3}).Count()
+	s.NoError(err)
+	s.Equal(uint64(7), count)
+
+	exists, err := artist.Find(db.Cond{"name": "artist-11"}).Exists()
+	s.NoError(err)
+	s.False(exists)
+
+	var item artistType
+	err = artist.Find(db.Cond{"name": "artist-11"}).One(&item)
+	s.Equal(db.ErrNoMoreRows, err)
+}
+
+func (s *AdapterTests) TestUpdateAndDelete() {
+	s.insertArtists(3)
+
+	artist := s.Session().Collection("artist")
+
+	// Mutations are applied before returning, see mutations_sync in the
+	// settings.
+	err := artist.Find(db.Cond{"id": 2}).Update(map[string]interface{}{"name": "Flea"})
+	s.NoError(err)
+
+	var item artistType
+	s.NoError(artist.Find(db.Cond{"id": 2}).One(&item))
+	s.Equal("Flea", item.Name)
+
+	s.NoError(artist.Find(db.Cond{"id": 3}).Delete())
+
+	count, err := artist.Count()
+	s.NoError(err)
+	s.Equal(uint64(2), count)
+}
+
+func (s *AdapterTests) TestGroupBy() {
+	sess := s.Session()
+
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	items := []pageView{
+		{ID: 1, Path: "/", Duration: 1, Created: created},
+		{ID: 2, Path: "/", Duration: 3, Created: created},
+		{ID: 3, Path: "/about", Duration: 5, Created: created},
+	}
+	_, err := sess.Collection("page_views").InsertMany(items, 0)
+	s.NoError(err)
+
+	var rows []struct {
+		Path  string  `db:"path"`
+		Views uint64  `db:"views"`
+		Avg   float64 `db:"avg"`
+	}
+	err = sess.SQL().
+		Select("path", db.Raw("count() AS views"), db.Raw("avg(duration) AS avg")).
+		From("page_views").
+		GroupBy("path").
+		Having("count() > ?", 1).
+		All(&rows)
+	s.NoError(err)
+	s.Len(rows, 1)
+	s.Equal("/", rows[0].Path)
+	s.Equal(uint64(2), rows[0].Views)
+	s.Equal(2.0, rows[0].Avg)
+}
+
+func (s *AdapterTests) TestCollections() {
+	sess := s.Session()
+
+	collections, err := sess.Collections()
+	s.NoError(err)
+
+	names := []string{}
+	for _, col := range collections {
+		names = append(names, col.Name())
+	}
+	s.Contains(names, "artist")
+	s.Contains(names, "page_views")
+
+	exists, err := sess.Collection("artist").Exists()
+	s.NoError(err)
+	s.True(exists)
+
+	exists, err = sess.Collection("no_such_table").Exists()
+	s.NoError(err)
+	s.False(exists)
+
+	name := sess.Name()
+	s.Equal(settings.Database, name)
+}
+
+func (s *AdapterTests) TestStats() {
+	s.insertArtists(10)
+
+	stats, err := s.Session().Collection("artist").Stats()
+	s.NoError(err)
+	s.Equal(uint64(10), stats.Rows)
+	s.NotZero(stats.DataSize)
+	s.Nil(stats.LastAnalyzed)
+}
+
+func TestAdapter(t *testing.T) {
+	suite.Run(t, &AdapterTests{})
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2012-today The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package integration
+
+import (
+	"database/sql"
+	"os"
+
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/clickhouse"
+	"github.com/upper/db/v4/internal/testsuite"
+
+	_ "github.com/ClickHouse/clickhouse-go/v2"
+)
+
+var settings = clickhouse.ConnectionURL{
+	Database: os.Getenv("DB_NAME"),
+	User:     os.Getenv("DB_USERNAME"),
+	Password: os.Getenv("DB_PASSWORD"),
+	Host:     os.Getenv("DB_HOST") + ":" + os.Getenv("DB_PORT"),
+	Options: map[string]string{
+		// Wait for ALTER TABLE ... UPDATE/DELETE mutations to be applied.
+		"mutations_sync": "2",
+	},
+}
+
+type Helper struct {
+	sess db.Session
+}
+
+func (h *Helper) Session() db.Session {
+	return h.sess
+}
+
+func (h *Helper) Adapter() string {
+	return clickhouse.Adapter
+}
+
+func (h *Helper) TearDown() error {
+	return h.sess.Close()
+}
+
+func (h *Helper) TearUp() error {
+	var err error
+
+	h.sess, err = clickhouse.Open(settings)
+	if err != nil {
+		return err
+	}
+
+	batch := []string{
+		`DROP TABLE IF EXISTS artist`,
+		`CREATE TABLE artist (
+			id UInt64,
+			name String
+		) ENGINE = MergeTree ORDER BY id`,
+
+		`DROP TABLE IF EXISTS page_views`,
+		`CREATE TABLE page_views (
+			id UInt64,
+			path String,
+			duration Float64,
+			created DateTime
+		) ENGINE = MergeTree ORDER BY (path, id)`,
+	}
+
+	// ClickHouse has no transactions, statements are sent one by one.
+	driver := h.sess.Driver().(*sql.DB)
+	for _, query := range batch {
+		if _, err := driver.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var _ testsuite.Helper = &Helper{}
@@ -0,0 +1,101 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package clickhouse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/sqlbuilder"
+)
+
+func TestTemplateSelect(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		"SELECT * FROM `events`",
+		b.SelectFrom("events").String(),
+	)
+
+	assert.Equal(
+		"SELECT * FROM `events` ORDER BY `created_at` DESC LIMIT 10 OFFSET 20",
+		b.SelectFrom("events").OrderBy("-created_at").Limit(10).Offset(20).String(),
+	)
+
+	assert.Equal(
+		"SELECT `kind`, count() AS total FROM `events` WHERE (`day` = $1) GROUP BY `kind`",
+		b.Select("kind", db.Raw("count() AS total")).From("events").Where(db.Cond{"day": "2020-01-01"}).GroupBy("kind").String(),
+	)
+
+	assert.Equal(
+		"SELECT * FROM `events` AS `e` JOIN `users` AS `u` USING (`user_id`)",
+		b.SelectFrom("events AS e").Join("users AS u").Using("user_id").String(),
+	)
+
+	// Row locks are not supported and ignored.
+	assert.Equal(
+		"SELECT * FROM `events`",
+		b.SelectFrom("events").ForUpdate().String(),
+	)
+}
+
+func TestTemplateInsert(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		"INSERT INTO `events` (`kind`, `user_id`) VALUES ($1, $2), ($3, $4)",
+		b.InsertInto("events").Columns("kind", "user_id").Values("click", 1).Values("view", 2).String(),
+	)
+}
+
+func TestTemplateUpdate(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		"ALTER TABLE `events` UPDATE `kind` = $1 WHERE (`user_id` = $2)",
+		b.Update("events").Set("kind", "click").Where(db.Cond{"user_id": 1}).String(),
+	)
+
+	assert.Equal(
+		"ALTER TABLE `events` UPDATE `kind` = $1 WHERE 1",
+		b.Update("events").Set("kind", "click").String(),
+	)
+}
+
+func TestTemplateDelete(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		"ALTER TABLE `events` DELETE WHERE (`user_id` = $1)",
+		b.DeleteFrom("events").Where(db.Cond{"user_id": 1}).String(),
+	)
+
+	assert.Equal(
+		"ALTER TABLE `events` DELETE WHERE 1",
+		b.DeleteFrom("events").String(),
+	)
+}
@@ -769,6 +769,53 @@ func (s *AdapterTests) TestOptionTypeJsonbStruct() {
 	s.Equal(int64(123), item1Chk.Settings.Num)
 }
 
+func (s *AdapterTests) TestModifyJSONB() {
+	sess := s.Session()
+
+	optionTypes := sess.Collection("option_types")
+
+	err := optionTypes.Truncate()
+	s.NoError(err)
+
+	record, err := optionTypes.Insert(map[string]interface{}{
+		"name":     "Hi",
+		"settings": JSONBMap{"name": "a", "tmp": true},
+	})
+	s.NoError(err)
+
+	res := optionTypes.Find(record)
+
+	err = res.Modify(
+		db.SetPath("settings.prefs.theme", "dark"),
+		db.Unset("settings.tmp"),
+		db.Push("settings.tags", "x"),
+		db.Push("settings.tags", "y"),
+		db.SetPath("name", "Hello"),
+	)
+	s.NoError(err)
+
+	var item struct {
+		Name     string   `db:"name"`
+		Settings JSONBMap `db:"settings"`
+	}
+	err = res.One(&item)
+	s.NoError(err)
+
+	s.Equal("Hello", item.Name)
+	s.Equal(JSONBMap{
+		"name":  "a",
+		"prefs": map[string]interface{}{"theme": "dark"},
+		"tags":  []interface{}{"x", "y"},
+	}, item.Settings)
+
+	err = res.Modify(db.Unset("settings"))
+	s.NoError(err)
+
+	err = res.One(&item)
+	s.NoError(err)
+	s.Nil(item.Settings)
+}
+
 func (s *AdapterTests) SkipTestSchemaCollection() {
 	// Unsupported?
 	sess := s.Session()
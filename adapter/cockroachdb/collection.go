@@ -22,8 +22,14 @@
 package cockroachdb
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
 	db "github.com/upper/db/v4"
 	"github.com/upper/db/v4/internal/sqladapter"
+	"github.com/upper/db/v4/internal/sqlbuilder"
 )
 
 type collectionAdapter struct {
@@ -66,3 +72,217 @@ func (*collectionAdapter) Insert(col sqladapter.Collection, item interface{}) (i
 	// This was a compound key and no interface matched it, let's return a map.
 	return keyMap, nil
 }
+
+func (*collectionAdapter) InsertBatch(col sqladapter.Collection, columnNames []string, rows [][]interface{}) ([]interface{}, error) {
+	pKey := col.PrimaryKeys()
+
+	q := col.SQL().InsertInto(col.Name()).Columns(columnNames...)
+	for i := range rows {
+		q = q.Values(rows[i]...)
+	}
+
+	ids := make([]interface{}, len(rows))
+
+	if len(pKey) == 0 {
+		// There is no primary key.
+		if _, err := q.Exec(); err != nil {
+			return nil, err
+		}
+		return ids, nil
+	}
+
+	// Asking the database to return the primary keys of all the inserted rows,
+	// those are returned in the same order the rows were given.
+	q = q.Returning(pKey...)
+
+	var keyMaps []map[string]interface{}
+	if err := q.Iterator().All(&keyMaps); err != nil {
+		return nil, err
+	}
+
+	for i := range keyMaps {
+		if i >= len(ids) {
+			break
+		}
+		if len(pKey) == 1 {
+			ids[i] = keyMaps[i][pKey[0]]
+			continue
+		}
+		keyMap := db.Cond{}
+		for j := range pKey {
+			keyMap[pKey[j]] = keyMaps[i][pKey[j]]
+		}
+		ids[i] = keyMap
+	}
+
+	return ids, nil
+}
+
+// InsertOrIgnore inserts item with ON CONFLICT DO NOTHING and returns its
+// primary key, or nil if the row was skipped.
+func (*collectionAdapter) InsertOrIgnore(col sqladapter.Collection, item interface{}) (interface{}, error) {
+	pKey := col.PrimaryKeys()
+
+	q := col.SQL().InsertInto(col.Name()).Values(item).Ignore()
+
+	if len(pKey) == 0 {
+		// There is no primary key.
+		if _, err := q.Exec(); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	var keyMap db.Cond
+	if err := q.Returning(pKey...).Iterator().One(&keyMap); err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			// The row conflicted with an existing one.
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if len(keyMap) == 1 {
+		return keyMap[pKey[0]], nil
+	}
+	return keyMap, nil
+}
+
+func (*collectionAdapter) Upsert(col sqladapter.Collection, item interface{}, conflictColumns ...string) (interface{}, error) {
+	columnNames, columnValues, err := sqlbuilder.Map(item, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pKey := col.PrimaryKeys()
+
+	q := col.SQL().InsertInto(col.Name()).
+		Columns(columnNames...).
+		Values(columnValues...).
+		OnConflict(conflictColumns...).
+		DoUpdate(sqladapter.UpsertColumns(columnNames, conflictColumns)...)
+
+	if len(pKey) == 0 {
+		// There is no primary key.
+		if _, err := q.Exec(); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	// Asking the database to return the primary key of the inserted or updated
+	// row.
+	q = q.Returning(pKey...)
+
+	var keyMap db.Cond
+	if err := q.Iterator().One(&keyMap); err != nil {
+		return nil, err
+	}
+
+	if len(keyMap) == 1 {
+		return keyMap[pKey[0]], nil
+	}
+
+	return keyMap, nil
+}
+
+// Stats returns the estimated number of rows of the table, as reported by
+// the table statistics CockroachDB collects automatically. The storage of a
+// table is spread across ranges and is not reported.
+func (*collectionAdapter) Stats(col sqladapter.Collection) (*db.CollectionStats, error) {
+	name := col.Name()
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+
+	row, err := col.SQL().QueryRow(`
+		SELECT COALESCE(MAX(estimated_row_count), 0)
+		FROM crdb_internal.table_row_statistics
+		WHERE table_name = ?`, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats db.CollectionStats
+	if err := row.Scan(&stats.Rows); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// ModifyColumn returns an expression that applies the given modifiers to the
+// jsonb document stored in column.
+func (*collectionAdapter) ModifyColumn(column string, mods []*db.Modifier) (*db.RawExpr, error) {
+	expr := `"` + strings.Replace(column, `"`, `""`, -1) + `"`
+	args := []interface{}{}
+
+	for _, mod := range mods {
+		_, path := mod.Field()
+
+		var value string
+		if mod.Op != db.ModifierUnset {
+			v := mod.Value
+			if mod.Op == db.ModifierPush {
+				v = []interface{}{v}
+			}
+			buf, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			value = string(buf)
+		}
+
+		if len(path) == 0 {
+			switch mod.Op {
+			case db.ModifierSet:
+				expr, args = `?::jsonb`, []interface{}{value}
+			case db.ModifierUnset:
+				expr, args = `NULL`, []interface{}{}
+			case db.ModifierPush:
+				expr, args = `COALESCE(`+expr+`, '[]'::jsonb) || ?::jsonb`, append(args, value)
+			default:
+				return nil, fmt.Errorf("%w: modifier %d", db.ErrUnsupported, mod.Op)
+			}
+			continue
+		}
+
+		if mod.Op == db.ModifierUnset {
+			expr, args = `(`+expr+` #- ?::text[])`, append(args, jsonbPath(path))
+			continue
+		}
+
+		// jsonb_set() does not create missing parents, so every parent is set
+		// to its current value or to an empty object first. The current document
+		// is bound to "v" to avoid repeating its expression.
+		doc, docArgs := `COALESCE(v, '{}'::jsonb)`, []interface{}{}
+		for i := 1; i < len(path); i++ {
+			doc = `jsonb_set(` + doc + `, ?::text[], COALESCE(v #> ?::text[], '{}'::jsonb), true)`
+			docArgs = append(docArgs, jsonbPath(path[:i]), jsonbPath(path[:i]))
+		}
+
+		switch mod.Op {
+		case db.ModifierSet:
+			doc = `jsonb_set(` + doc + `, ?::text[], ?::jsonb, true)`
+			docArgs = append(docArgs, jsonbPath(path), value)
+		case db.ModifierPush:
+			doc = `jsonb_set(` + doc + `, ?::text[], COALESCE(v #> ?::text[], '[]'::jsonb) || ?::jsonb, true)`
+			docArgs = append(docArgs, jsonbPath(path), jsonbPath(path), value)
+		default:
+			return nil, fmt.Errorf("%w: modifier %d", db.ErrUnsupported, mod.Op)
+		}
+
+		expr = `(SELECT ` + doc + ` FROM (SELECT ` + expr + ` AS v) AS t)`
+		args = append(docArgs, args...)
+	}
+
+	return db.Raw(expr, args...), nil
+}
+
+// jsonbPath returns the text[] literal that represents the given path.
+func jsonbPath(path []string) string {
+	elems := make([]string, len(path))
+	for i := range path {
+		elems[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(path[i]) + `"`
+	}
+	return "{" + strings.Join(elems, ",") + "}"
+}
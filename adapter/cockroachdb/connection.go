@@ -28,6 +28,7 @@ import (
 	"unicode"
 
 	"github.com/lib/pq"
+	db "github.com/upper/db/v4"
 )
 
 // scanner implements a tokenizer for libpq-style option strings.
@@ -154,12 +155,56 @@ func (c ConnectionURL) String() (s string) {
 	}
 
 	for k, v := range c.Options {
+		if db.IsPoolOption(k) {
+			continue
+		}
 		u = append(u, escaper.Replace(k)+"="+escaper.Replace(v))
 	}
 
 	return strings.Join(u, " ")
 }
 
+// PoolOptions returns the connection pool options, which are not passed to
+// the driver.
+func (c ConnectionURL) PoolOptions() map[string]string {
+	return db.PoolOptions(c.Options)
+}
+
+// Validate checks the connection settings before connecting.
+func (c ConnectionURL) Validate() error {
+	if c.Host != "" && c.Socket != "" {
+		return &db.ConnectionURLError{Field: "Socket", Reason: "can't be used along with Host", Err: db.ErrSockerOrHost}
+	}
+	if c.Host != "" {
+		if err := db.ValidateAddress("Host", c.Host); err != nil {
+			return err
+		}
+	}
+	switch c.Options["sslmode"] {
+	case "", "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		return db.NewConnectionURLError("Options[sslmode]", "must be one of disable, allow, prefer, require, verify-ca or verify-full")
+	}
+	return nil
+}
+
+// Redacted returns the DSN with the password replaced by db.RedactedPassword,
+// it's safe to log.
+func (c ConnectionURL) Redacted() string {
+	if c.Password != "" {
+		c.Password = db.RedactedPassword
+	}
+	if _, ok := c.Options["password"]; ok {
+		options := make(map[string]string, len(c.Options))
+		for k, v := range c.Options {
+			options[k] = v
+		}
+		options["password"] = db.RedactedPassword
+		c.Options = options
+	}
+	return c.String()
+}
+
 // ParseURL parses the given DSN into a ConnectionURL struct.
 func ParseURL(s string) (u ConnectionURL, err error) {
 	o := make(values)
@@ -22,9 +22,11 @@
 package cockroachdb
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	db "github.com/upper/db/v4"
 )
 
 func TestConnectionURL(t *testing.T) {
@@ -124,3 +126,35 @@ func TestParseConnectionURL(t *testing.T) {
 		assert.Equal(t, "UTC", u.Options["timezone"])
 	}
 }
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, ConnectionURL{Host: "localhost:5432", Database: "test"}.Validate())
+
+	var urlErr *db.ConnectionURLError
+
+	err := ConnectionURL{Host: "localhost", Socket: "/var/run/postgresql"}.Validate()
+	assert.True(t, errors.As(err, &urlErr))
+	assert.Equal(t, "Socket", urlErr.Field)
+	assert.True(t, errors.Is(err, db.ErrSockerOrHost))
+
+	err = ConnectionURL{Host: "localhost:port"}.Validate()
+	assert.True(t, errors.As(err, &urlErr))
+	assert.Equal(t, "Host", urlErr.Field)
+
+	err = ConnectionURL{Host: "localhost", Options: map[string]string{"sslmode": "maybe"}}.Validate()
+	assert.True(t, errors.As(err, &urlErr))
+	assert.Equal(t, "Options[sslmode]", urlErr.Field)
+
+	_, err = Open(ConnectionURL{Host: "localhost", Options: map[string]string{"max_open_conns": "many"}})
+	assert.True(t, errors.Is(err, db.ErrInvalidConnectionURL))
+}
+
+func TestRedacted(t *testing.T) {
+	c := ConnectionURL{User: "anakin", Password: "skywalker", Database: "jedis"}
+	assert.Equal(t, "user=anakin password=xxxxx host=127.0.0.1 port=26257 dbname=jedis sslmode=require", c.Redacted())
+	assert.Equal(t, "skywalker", c.Password)
+
+	c = ConnectionURL{User: "anakin", Database: "jedis", Options: map[string]string{"password": "skywalker", "sslmode": "disable"}}
+	assert.Contains(t, c.Redacted(), "password=xxxxx")
+	assert.Equal(t, "skywalker", c.Options["password"])
+}
@@ -28,6 +28,7 @@ package cockroachdb
 import (
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
@@ -134,16 +135,55 @@ func (*database) Err(err error) error {
 		if strings.Contains(s, `too many clients`) || strings.Contains(s, `remaining connection slots are reserved`) || strings.Contains(s, `too many open`) {
 			return db.ErrTooManyClients
 		}
-		if pqErr, ok := err.(*pq.Error); ok {
+		// Look into wrapped errors too, transaction callbacks often add context
+		// to the errors they return and serialization failures (40001) must
+		// still be retried.
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
 			switch pqErr.Code {
 			case "25P02", "40001":
 				return db.ErrTransactionAborted
+			case "23505":
+				return db.NewConstraintError(db.ErrDuplicateKey, err)
+			case "23503":
+				return db.NewConstraintError(db.ErrForeignKeyViolation, err)
+			case "23502":
+				return db.NewConstraintError(db.ErrNotNullViolation, err)
+			case "23514":
+				return db.NewConstraintError(db.ErrCheckViolation, err)
+			case "55P03":
+				return db.NewContentionError(db.ErrLockTimeout, err)
 			}
 		}
 	}
 	return err
 }
 
+func (*database) Capabilities() db.Capability {
+	return db.CapabilityTransactions |
+		db.CapabilityReturning |
+		db.CapabilityCompositeKeys |
+		db.CapabilitySchemas |
+		db.CapabilitySavepoints
+}
+
+// ParseURL parses a connection URL of the adapter.
+func (*database) ParseURL(s string) (db.ConnectionURL, error) {
+	return ParseURL(s)
+}
+
+// SnapshotTransaction opens snapshots as read-only SERIALIZABLE transactions,
+// the only isolation level CockroachDB provides.
+func (*database) SnapshotTransaction() (*sql.TxOptions, string) {
+	return &sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}, ""
+}
+
+// RecursiveWith returns the keyword that starts recursive common table
+// expressions.
+func (*database) RecursiveWith() string {
+	return "WITH RECURSIVE"
+}
+
 func (*database) NewCollection() sqladapter.CollectionAdapter {
 	return &collectionAdapter{}
 }
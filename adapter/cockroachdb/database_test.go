@@ -0,0 +1,48 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package cockroachdb
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	pq "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	db "github.com/upper/db/v4"
+)
+
+func TestErr(t *testing.T) {
+	d := &database{}
+
+	serializationFailure := &pq.Error{Code: "40001", Message: "restart transaction"}
+
+	assert.True(t, errors.Is(d.Err(serializationFailure), db.ErrTransactionAborted))
+	assert.True(t, errors.Is(d.Err(fmt.Errorf("transfer: %w", serializationFailure)), db.ErrTransactionAborted))
+
+	err := d.Err(fmt.Errorf("insert: %w", &pq.Error{Code: "23505"}))
+	assert.True(t, errors.Is(err, db.ErrDuplicateKey))
+
+	plain := errors.New("plain")
+	assert.Equal(t, plain, d.Err(plain))
+	assert.Nil(t, d.Err(nil))
+}
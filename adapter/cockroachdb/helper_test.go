@@ -115,6 +115,7 @@ func (h *Helper) TearUp() error {
 			`DROP TABLE IF EXISTS data_types`,
 			`DROP TABLE IF EXISTS stats_test`,
 			`DROP TABLE IF EXISTS composite_keys`,
+			`DROP TABLE IF EXISTS bool_flags`,
 			`DROP TABLE IF EXISTS option_types`,
 			`DROP TABLE IF EXISTS pg_types`,
 			`DROP TABLE IF EXISTS issue_370`,
@@ -174,6 +175,11 @@ func (h *Helper) TearUp() error {
 			id serial primary key,
 			numeric integer,
 			value integer
+		)`,
+			`CREATE TABLE IF NOT EXISTS bool_flags (
+			name varchar(60) primary key,
+			active char(1),
+			verified char(1)
 		)`,
 			`CREATE TABLE IF NOT EXISTS composite_keys (
 			code varchar(255) default '',
@@ -86,6 +86,8 @@ const (
   `
 
 	adapterSelectLayout = `
+    {{.With | compile}}
+
     SELECT
       {{if .Distinct}}
         DISTINCT
@@ -109,6 +111,10 @@ const (
         {{.GroupBy | compile}}
       {{end}}
 
+      {{if defined .Having}}
+        {{.Having | compile}}
+      {{end}}
+
       {{.OrderBy | compile}}
 
       {{if .Limit}}
@@ -118,17 +124,27 @@ const (
       {{if .Offset}}
         OFFSET {{.Offset}}
       {{end}}
+
+      {{if defined .Lock}}
+        {{.Lock | compile}}
+      {{end}}
   `
 	adapterDeleteLayout = `
     DELETE
       FROM {{.Table | compile}}
       {{.Where | compile}}
+    {{if defined .Returning}}
+      RETURNING {{.Returning | compile}}
+    {{end}}
   `
 	adapterUpdateLayout = `
     UPDATE
       {{.Table | compile}}
     SET {{.ColumnValues | compile}}
       {{.Where | compile}}
+    {{if defined .Returning}}
+      RETURNING {{.Returning | compile}}
+    {{end}}
   `
 
 	adapterSelectCountLayout = `
@@ -147,11 +163,34 @@ const (
     {{else}}
       (default)
     {{end}}
+    {{if defined .OnConflict}}
+      {{.OnConflict | compile}}
+    {{else if .Ignore}}
+      ON CONFLICT DO NOTHING
+    {{end}}
     {{if defined .Returning}}
       RETURNING {{.Returning | compile}}
     {{end}}
   `
 
+	adapterLockLayout = `
+    FOR {{.Mode}}
+    {{if .Wait}}
+      {{.Wait}}
+    {{end}}
+  `
+
+	adapterOnConflictLayout = `
+    ON CONFLICT {{if .Columns}}({{.Columns}}){{end}}
+    {{if .Update}}
+      DO UPDATE SET {{.Update}}
+    {{else}}
+      DO NOTHING
+    {{end}}
+  `
+
+	adapterOnConflictUpdateLayout = `{{.Column}} = EXCLUDED.{{.Column}}`
+
 	adapterTruncateLayout = `
     DELETE FROM {{.Table | compile}}
   `
@@ -164,47 +203,81 @@ const (
     DROP TABLE {{.Table | compile}}
   `
 
+	adapterCreateTableAsLayout = `
+    CREATE TABLE {{.Table | compile}} AS {{.Query | compile}}
+  `
+
 	adapterGroupByLayout = `
     {{if .GroupColumns}}
       GROUP BY {{.GroupColumns}}
     {{end}}
+  `
+
+	adapterHavingLayout = `
+    {{if .Conds}}
+      HAVING {{.Conds}}
+    {{end}}
+  `
+
+	adapterWithLayout = `
+    {{if .Tables}}
+      WITH {{if .Recursive}}RECURSIVE{{end}} {{.Tables}}
+    {{end}}
+  `
+
+	adapterValuesTableLayout = `
+    (VALUES {{range $i, $row := .Rows}}{{if $i}}, {{end}}{{$row}}{{end}}) AS {{.Name}} ({{.Columns}})
+  `
+
+	adapterCallLayout = `
+    CALL {{.Name}}({{range $i, $arg := .Arguments}}{{if $i}}, {{end}}{{$arg.Value}}{{end}})
   `
 )
 
 var template = &exql.Template{
-	ColumnSeparator:     adapterColumnSeparator,
-	IdentifierSeparator: adapterIdentifierSeparator,
-	IdentifierQuote:     adapterIdentifierQuote,
-	ValueSeparator:      adapterValueSeparator,
-	ValueQuote:          adapterValueQuote,
-	AndKeyword:          adapterAndKeyword,
-	OrKeyword:           adapterOrKeyword,
-	DescKeyword:         adapterDescKeyword,
-	AscKeyword:          adapterAscKeyword,
-	AssignmentOperator:  adapterAssignmentOperator,
-	ClauseGroup:         adapterClauseGroup,
-	ClauseOperator:      adapterClauseOperator,
-	ColumnValue:         adapterColumnValue,
-	TableAliasLayout:    adapterTableAliasLayout,
-	ColumnAliasLayout:   adapterColumnAliasLayout,
-	SortByColumnLayout:  adapterSortByColumnLayout,
-	WhereLayout:         adapterWhereLayout,
-	JoinLayout:          adapterJoinLayout,
-	OnLayout:            adapterOnLayout,
-	UsingLayout:         adapterUsingLayout,
-	OrderByLayout:       adapterOrderByLayout,
-	InsertLayout:        adapterInsertLayout,
-	SelectLayout:        adapterSelectLayout,
-	UpdateLayout:        adapterUpdateLayout,
-	DeleteLayout:        adapterDeleteLayout,
-	TruncateLayout:      adapterTruncateLayout,
-	DropDatabaseLayout:  adapterDropDatabaseLayout,
-	DropTableLayout:     adapterDropTableLayout,
-	CountLayout:         adapterSelectCountLayout,
-	GroupByLayout:       adapterGroupByLayout,
-	Cache:               cache.NewCache(),
+	ColumnSeparator:        adapterColumnSeparator,
+	IdentifierSeparator:    adapterIdentifierSeparator,
+	IdentifierQuote:        adapterIdentifierQuote,
+	ValueSeparator:         adapterValueSeparator,
+	ValuesTableLayout:      adapterValuesTableLayout,
+	ValueQuote:             adapterValueQuote,
+	AndKeyword:             adapterAndKeyword,
+	OrKeyword:              adapterOrKeyword,
+	DescKeyword:            adapterDescKeyword,
+	AscKeyword:             adapterAscKeyword,
+	AssignmentOperator:     adapterAssignmentOperator,
+	ClauseGroup:            adapterClauseGroup,
+	ClauseOperator:         adapterClauseOperator,
+	ColumnValue:            adapterColumnValue,
+	TableAliasLayout:       adapterTableAliasLayout,
+	ColumnAliasLayout:      adapterColumnAliasLayout,
+	SortByColumnLayout:     adapterSortByColumnLayout,
+	WhereLayout:            adapterWhereLayout,
+	JoinLayout:             adapterJoinLayout,
+	LockLayout:             adapterLockLayout,
+	OnConflictLayout:       adapterOnConflictLayout,
+	OnConflictUpdateLayout: adapterOnConflictUpdateLayout,
+	OnLayout:               adapterOnLayout,
+	UsingLayout:            adapterUsingLayout,
+	OrderByLayout:          adapterOrderByLayout,
+	InsertLayout:           adapterInsertLayout,
+	SelectLayout:           adapterSelectLayout,
+	UpdateLayout:           adapterUpdateLayout,
+	DeleteLayout:           adapterDeleteLayout,
+	TruncateLayout:         adapterTruncateLayout,
+	DropDatabaseLayout:     adapterDropDatabaseLayout,
+	DropTableLayout:        adapterDropTableLayout,
+	CreateTableAsLayout:    adapterCreateTableAsLayout,
+	CountLayout:            adapterSelectCountLayout,
+	GroupByLayout:          adapterGroupByLayout,
+	HavingLayout:           adapterHavingLayout,
+	WithLayout:             adapterWithLayout,
+	CallLayout:             adapterCallLayout,
+	Cache:                  cache.NewCache(),
 	ComparisonOperator: map[adapter.ComparisonOperator]string{
 		adapter.ComparisonOperatorRegExp:    "~",
 		adapter.ComparisonOperatorNotRegExp: "!~",
+		adapter.ComparisonOperatorILike:     "ILIKE",
+		adapter.ComparisonOperatorNotILike:  "NOT ILIKE",
 	},
 }
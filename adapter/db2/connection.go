@@ -0,0 +1,190 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db2
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	db "github.com/upper/db/v4"
+)
+
+// ConnectionURL implements a Db2 connection struct, which is turned into a
+// CLI connection string like:
+//
+//	DATABASE=sample;HOSTNAME=127.0.0.1;PORT=50000;PROTOCOL=TCPIP;UID=user;PWD=pass
+type ConnectionURL struct {
+	User     string
+	Password string
+	Database string
+	Host     string
+	Schema   string
+	Options  map[string]string
+}
+
+const defaultPort = "50000"
+
+// reservedKeywords are the keywords that are set by the fields of
+// ConnectionURL.
+var reservedKeywords = map[string]bool{
+	"DATABASE":      true,
+	"HOSTNAME":      true,
+	"PORT":          true,
+	"PROTOCOL":      true,
+	"UID":           true,
+	"PWD":           true,
+	"CURRENTSCHEMA": true,
+}
+
+func (c ConnectionURL) String() (s string) {
+	if c.Host == "" && c.Database == "" && c.User == "" && c.Password == "" {
+		return ""
+	}
+
+	if c.Host == "" {
+		c.Host = "127.0.0.1"
+	}
+
+	host, port, err := net.SplitHostPort(c.Host)
+	if err != nil {
+		host, port = c.Host, defaultPort
+	}
+
+	params := []string{
+		"DATABASE=" + c.Database,
+		"HOSTNAME=" + host,
+		"PORT=" + port,
+		"PROTOCOL=TCPIP",
+	}
+
+	if c.User != "" {
+		params = append(params, "UID="+c.User)
+	}
+	if c.Password != "" {
+		params = append(params, "PWD="+c.Password)
+	}
+	if c.Schema != "" {
+		params = append(params, "CURRENTSCHEMA="+c.Schema)
+	}
+
+	options := make([]string, 0, len(c.Options))
+	for k, v := range c.Options {
+		if db.IsPoolOption(k) || reservedKeywords[strings.ToUpper(k)] {
+			continue
+		}
+		options = append(options, k+"="+v)
+	}
+	sort.Strings(options)
+
+	return strings.Join(append(params, options...), ";")
+}
+
+// PoolOptions returns the connection pool options, which are not passed to
+// the driver.
+func (c ConnectionURL) PoolOptions() map[string]string {
+	return db.PoolOptions(c.Options)
+}
+
+// Validate checks the connection settings before connecting.
+func (c ConnectionURL) Validate() error {
+	if c.Host != "" {
+		if err := db.ValidateAddress("Host", c.Host); err != nil {
+			return err
+		}
+	}
+	// Keywords are separated by semicolons, which can't be escaped.
+	for field, value := range map[string]string{"User": c.User, "Password": c.Password, "Database": c.Database, "Schema": c.Schema} {
+		if strings.ContainsRune(value, ';') {
+			return db.NewConnectionURLError(field, "can't contain semicolons")
+		}
+	}
+	for k, v := range c.Options {
+		if strings.ContainsRune(k+v, ';') {
+			return db.NewConnectionURLError("Options", "can't contain semicolons")
+		}
+	}
+	return nil
+}
+
+// Redacted returns the DSN with the password replaced by db.RedactedPassword,
+// it's safe to log.
+func (c ConnectionURL) Redacted() string {
+	if c.Password != "" {
+		c.Password = db.RedactedPassword
+	}
+	return c.String()
+}
+
+// ParseURL parses a CLI connection string into a ConnectionURL struct.
+func ParseURL(s string) (conn ConnectionURL, err error) {
+	var host, port string
+
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return conn, fmt.Errorf("malformed keyword %q", part)
+		}
+		k, v := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+
+		switch strings.ToUpper(k) {
+		case "DATABASE":
+			conn.Database = v
+		case "HOSTNAME":
+			host = v
+		case "PORT":
+			port = v
+		case "UID":
+			conn.User = v
+		case "PWD":
+			conn.Password = v
+		case "CURRENTSCHEMA":
+			conn.Schema = v
+		case "PROTOCOL":
+			if !strings.EqualFold(v, "TCPIP") {
+				return conn, errors.New(`Expecting "TCPIP" protocol`)
+			}
+		default:
+			if conn.Options == nil {
+				conn.Options = make(map[string]string)
+			}
+			conn.Options[k] = v
+		}
+	}
+
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	if port == "" {
+		port = defaultPort
+	}
+	conn.Host = net.JoinHostPort(host, port)
+
+	return
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db2
+
+import (
+	"testing"
+)
+
+func TestConnectionURL(t *testing.T) {
+
+	c := ConnectionURL{}
+
+	// Zero value equals to an empty string.
+	if c.String() != "" {
+		t.Fatal(`Expecting default connectiong string to be empty, got:`, c.String())
+	}
+
+	// Adding a database name.
+	c.Database = "sample"
+
+	if c.String() != "DATABASE=sample;HOSTNAME=127.0.0.1;PORT=50000;PROTOCOL=TCPIP" {
+		t.Fatal(`Test failed, got:`, c.String())
+	}
+
+	// Adding options, keywords set by fields are ignored.
+	c.Options = map[string]string{
+		"Security": "SSL",
+		"UID":      "other",
+	}
+
+	if c.String() != "DATABASE=sample;HOSTNAME=127.0.0.1;PORT=50000;PROTOCOL=TCPIP;Security=SSL" {
+		t.Fatal(`Test failed, got:`, c.String())
+	}
+
+	// Setting default options
+	c.Options = nil
+
+	// Setting user, password and schema.
+	c.User = "db2inst1"
+	c.Password = "pass"
+	c.Schema = "MYSCHEMA"
+
+	if c.String() != `DATABASE=sample;HOSTNAME=127.0.0.1;PORT=50000;PROTOCOL=TCPIP;UID=db2inst1;PWD=pass;CURRENTSCHEMA=MYSCHEMA` {
+		t.Fatal(`Test failed, got:`, c.String())
+	}
+
+	// Setting host.
+	c.Host = "1.2.3.4:50001"
+
+	if c.String() != `DATABASE=sample;HOSTNAME=1.2.3.4;PORT=50001;PROTOCOL=TCPIP;UID=db2inst1;PWD=pass;CURRENTSCHEMA=MYSCHEMA` {
+		t.Fatal(`Test failed, got:`, c.String())
+	}
+
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Password = "pa;ss"
+	if err := c.Validate(); err == nil {
+		t.Fatal("Expecting an error.")
+	}
+}
+
+func TestParseConnectionURL(t *testing.T) {
+	var u ConnectionURL
+	var s string
+	var err error
+
+	s = "DATABASE=sample; HOSTNAME=db.example.com;PORT=50001;PROTOCOL=TCPIP;UID=db2inst1;PWD=pass;CURRENTSCHEMA=MYSCHEMA;Security=SSL"
+
+	if u, err = ParseURL(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if u.User != "db2inst1" {
+		t.Fatal("Expecting username.")
+	}
+
+	if u.Password != "pass" {
+		t.Fatal("Expecting password.")
+	}
+
+	if u.Host != "db.example.com:50001" {
+		t.Fatal("Expecting host.")
+	}
+
+	if u.Database != "sample" {
+		t.Fatal("Expecting database.")
+	}
+
+	if u.Schema != "MYSCHEMA" {
+		t.Fatal("Expecting schema.")
+	}
+
+	if u.Options["Security"] != "SSL" {
+		t.Fatal("Expecting options.")
+	}
+
+	if u.String() != "DATABASE=sample;HOSTNAME=db.example.com;PORT=50001;PROTOCOL=TCPIP;UID=db2inst1;PWD=pass;CURRENTSCHEMA=MYSCHEMA;Security=SSL" {
+		t.Fatal("Expecting the same connection string, got:", u.String())
+	}
+
+	if _, err = ParseURL("DATABASE=sample;PROTOCOL=IPC"); err == nil {
+		t.Fatal("Expecting an error.")
+	}
+
+	if _, err = ParseURL("DATABASE"); err == nil {
+		t.Fatal("Expecting an error.")
+	}
+}
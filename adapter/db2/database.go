@@ -0,0 +1,215 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package db2 is an adapter for IBM Db2 on top of the github.com/ibmdb/go_ibm_db
+// driver, which is not imported by this package because it needs the Db2 CLI
+// libraries to build. Programs using this adapter must import it:
+//
+//	import _ "github.com/ibmdb/go_ibm_db"
+package db2
+
+import (
+	"database/sql"
+	"strings"
+
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/sqladapter"
+	"github.com/upper/db/v4/internal/sqladapter/exql"
+)
+
+// driverName is the name github.com/ibmdb/go_ibm_db registers its driver
+// with.
+const driverName = `go_ibm_db`
+
+type database struct {
+}
+
+func (*database) Template() *exql.Template {
+	return template
+}
+
+func (*database) OpenDSN(sess sqladapter.Session, dsn string) (*sql.DB, error) {
+	return sql.Open(driverName, dsn)
+}
+
+// splitName splits a schema-qualified table name, the schema is empty when the
+// name is not qualified.
+func splitName(name string) (schema string, table string) {
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// schemaCond returns a condition on the given schema column that matches
+// schema, or the current schema if schema is empty.
+func schemaCond(column string, schema string) (string, []interface{}) {
+	if schema == "" {
+		return column + ` = CURRENT SCHEMA`, nil
+	}
+	return column + ` = ?`, []interface{}{schema}
+}
+
+func (*database) Collections(sess sqladapter.Session) (collections []string, err error) {
+	rows, err := sess.SQL().Query(`SELECT TABNAME FROM SYSCAT.TABLES WHERE TYPE = 'T' AND TABSCHEMA = CURRENT SCHEMA ORDER BY TABNAME`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tableName string
+		if err := rows.Scan(&tableName); err != nil {
+			return nil, err
+		}
+		collections = append(collections, tableName)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return collections, nil
+}
+
+func (*database) Err(err error) error {
+	if err != nil {
+		// The driver reports the SQLSTATE of the error between braces, like
+		// "SQLExecute: {23505} [IBM][CLI Driver][DB2/LINUX] SQL0803N ...".
+		s := err.Error()
+		switch {
+		case strings.Contains(s, `{23505}`):
+			return db.NewConstraintError(db.ErrDuplicateKey, err)
+		case strings.Contains(s, `{23503}`):
+			return db.NewConstraintError(db.ErrForeignKeyViolation, err)
+		case strings.Contains(s, `{23502}`):
+			return db.NewConstraintError(db.ErrNotNullViolation, err)
+		case strings.Contains(s, `{23513}`):
+			return db.NewConstraintError(db.ErrCheckViolation, err)
+		case strings.Contains(s, `{40001}`):
+			// SQL0911N, reason code 68 is a lock timeout and 2 a deadlock.
+			if strings.Contains(s, `"68"`) {
+				return db.NewContentionError(db.ErrLockTimeout, err)
+			}
+			return db.NewContentionError(db.ErrDeadlock, err)
+		case strings.Contains(s, `{57033}`):
+			return db.NewContentionError(db.ErrLockTimeout, err)
+		case strings.Contains(s, `SQL1040N`):
+			return db.ErrTooManyClients
+		}
+	}
+	return err
+}
+
+func (*database) Capabilities() db.Capability {
+	return db.CapabilityTransactions |
+		db.CapabilityReturning |
+		db.CapabilityCompositeKeys |
+		db.CapabilitySchemas |
+		db.CapabilitySavepoints
+}
+
+// ParseURL parses a connection URL of the adapter.
+func (*database) ParseURL(s string) (db.ConnectionURL, error) {
+	return ParseURL(s)
+}
+
+// SavepointStatements returns the Db2 statements for savepoints, which must
+// say what happens to open cursors on rollback.
+func (*database) SavepointStatements(name string) (string, string, string) {
+	return "SAVEPOINT " + name + " ON ROLLBACK RETAIN CURSORS",
+		"RELEASE SAVEPOINT " + name,
+		"ROLLBACK TO SAVEPOINT " + name
+}
+
+// RecursiveWith returns the keyword that starts recursive common table
+// expressions.
+func (*database) RecursiveWith() string {
+	return "WITH"
+}
+
+func (*database) NewCollection() sqladapter.CollectionAdapter {
+	return &collectionAdapter{}
+}
+
+func (*database) LookupName(sess sqladapter.Session) (string, error) {
+	row, err := sess.SQL().QueryRow(`SELECT CURRENT SERVER FROM SYSIBM.SYSDUMMY1`)
+	if err != nil {
+		return "", err
+	}
+
+	var name string
+	if err := row.Scan(&name); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(name), nil
+}
+
+func (*database) TableExists(sess sqladapter.Session, name string) error {
+	schema, table := splitName(name)
+	cond, args := schemaCond(`TABSCHEMA`, schema)
+
+	row, err := sess.SQL().QueryRow(`SELECT COUNT(1) FROM SYSCAT.TABLES WHERE TABNAME = ? AND `+cond, append([]interface{}{table}, args...)...)
+	if err != nil {
+		return err
+	}
+
+	var n int
+	if err := row.Scan(&n); err != nil {
+		return err
+	}
+	if n < 1 {
+		return db.ErrCollectionDoesNotExist
+	}
+
+	return nil
+}
+
+func (*database) PrimaryKeys(sess sqladapter.Session, tableName string) ([]string, error) {
+	schema, table := splitName(tableName)
+	cond, args := schemaCond(`c.TABSCHEMA`, schema)
+
+	rows, err := sess.SQL().Query(`
+		SELECT k.COLNAME
+		FROM SYSCAT.TABCONST c
+		JOIN SYSCAT.KEYCOLUSE k
+			ON k.CONSTNAME = c.CONSTNAME AND k.TABSCHEMA = c.TABSCHEMA AND k.TABNAME = c.TABNAME
+		WHERE c.TYPE = 'P' AND c.TABNAME = ? AND `+cond+`
+		ORDER BY k.COLSEQ`, append([]interface{}{table}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pk := []string{}
+
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		pk = append(pk, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pk, nil
+}
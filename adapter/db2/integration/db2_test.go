@@ -0,0 +1,232 @@
+// Copyright (c) 2012-today The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package integration
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/testsuite"
+)
+
+type artistType struct {
+	ID   int64  `db:"id,omitempty"`
+	Name string `db:"name"`
+}
+
+type publicationType struct {
+	ID       int64  `db:"id,omitempty"`
+	Title    string `db:"title"`
+	AuthorID int64  `db:"author_id"`
+}
+
+type AdapterTests struct {
+	testsuite.Suite
+}
+
+func (s *AdapterTests) SetupSuite() {
+	s.Helper = &Helper{}
+}
+
+func (s *AdapterTests) insertArtists(n int) {
+	items := make([]artistType, n)
+	for i := range items {
+		items[i] = artistType{Name: fmt.Sprintf("artist-%d", i+1)}
+	}
+	_, err := s.Session().Collection("artist").InsertMany(items, 0)
+	s.NoError(err)
+}
+
+func (s *AdapterTests) TestInsertReturnsID() {
+	artist := s.Session().Collection("artist")
+
+	res, err := artist.Insert(artistType{Name: "Ozzie"})
+	s.NoError(err)
+	s.EqualValues(1, res.ID())
+
+	res, err = artist.Insert(artistType{Name: "Flea"})
+	s.NoError(err)
+	s.EqualValues(2, res.ID())
+
+	var item artistType
+	s.NoError(artist.Find(res.ID()).One(&item))
+	s.Equal(artistType{ID: 2, Name: "Flea"}, item)
+
+	_, err = artist.Insert(artistType{Name: "Flea"})
+	s.True(errors.Is(err, db.ErrDuplicateKey))
+}
+
+func (s *AdapterTests) TestFind() {
+	s.insertArtists(10)
+
+	artist := s.Session().Collection("artist")
+
+	var items []artistType
+	err := artist.Find(db.Cond{"id >": 3}).OrderBy("-id").Limit(3).Offset(1).All(&items)
+	s.NoError(err)
+	s.Equal([]artistType{
+		{ID: 9, Name: "artist-9"},
+		{ID: 8, Name: "artist-8"},
+		{ID: 7, Name: "artist-7"},
+	}, items)
+
+	count, err := artist.Find(db.Cond{"id >": 3}).Count()
+	s.NoError(err)
+	s.Equal(uint64(7), count)
+
+	var item artistType
+	err = artist.Find(db.Cond{"name": "artist-11"}).One(&item)
+	s.Equal(db.ErrNoMoreRows, err)
+}
+
+func (s *AdapterTests) TestUpdateAndDelete() {
+	s.insertArtists(3)
+
+	artist := s.Session().Collection("artist")
+
+	err := artist.Find(db.Cond{"id": 2}).Update(map[string]interface{}{"name": "Flea"})
+	s.NoError(err)
+
+	var item artistType
+	s.NoError(artist.Find(db.Cond{"id": 2}).One(&item))
+	s.Equal("Flea", item.Name)
+
+	s.NoError(artist.Find(db.Cond{"id": 3}).Delete())
+
+	count, err := artist.Count()
+	s.NoError(err)
+	s.Equal(uint64(2), count)
+}
+
+func (s *AdapterTests) TestJoin() {
+	sess := s.Session()
+
+	s.insertArtists(2)
+	_, err := sess.Collection("publication").InsertMany([]publicationType{
+		{Title: "First", AuthorID: 1},
+		{Title: "Second", AuthorID: 2},
+		{Title: "Third", AuthorID: 2},
+	}, 0)
+	s.NoError(err)
+
+	var rows []struct {
+		Name   string `db:"name"`
+		Titles int64  `db:"titles"`
+	}
+	// Raw SQL must quote names as well, unquoted ones are folded to upper
+	// case.
+	err = sess.SQL().
+		Select("a.name", db.Raw(`COUNT("p"."id") AS "titles"`)).
+		From("artist AS a").
+		Join("publication AS p").On(`"p"."author_id" = "a"."id"`).
+		GroupBy("a.name").
+		Having(db.Raw(`COUNT("p"."id") > ?`, 1)).
+		All(&rows)
+	s.NoError(err)
+	s.Len(rows, 1)
+	s.Equal("artist-2", rows[0].Name)
+	s.Equal(int64(2), rows[0].Titles)
+}
+
+func (s *AdapterTests) TestSchemaQualified() {
+	sess := s.Session()
+
+	other := sess.Collection(otherSchema + ".artist")
+
+	exists, err := other.Exists()
+	s.NoError(err)
+	s.True(exists)
+
+	res, err := other.Insert(artistType{Name: "Elsewhere"})
+	s.NoError(err)
+	s.EqualValues(100, res.ID())
+
+	count, err := other.Count()
+	s.NoError(err)
+	s.Equal(uint64(1), count)
+
+	// The table of the current schema is a different one.
+	count, err = sess.Collection("artist").Count()
+	s.NoError(err)
+	s.Zero(count)
+
+	exists, err = sess.Collection(otherSchema + ".publication").Exists()
+	s.NoError(err)
+	s.False(exists)
+}
+
+func (s *AdapterTests) TestTx() {
+	sess := s.Session()
+
+	err := sess.Tx(func(tx db.Session) error {
+		if _, err := tx.Collection("artist").Insert(artistType{Name: "Rolled back"}); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	s.Error(err)
+
+	err = sess.Tx(func(tx db.Session) error {
+		if _, err := tx.Collection("artist").Insert(artistType{Name: "Committed"}); err != nil {
+			return err
+		}
+		// Nested transactions are savepoints.
+		_ = tx.Tx(func(tx db.Session) error {
+			if _, err := tx.Collection("artist").Insert(artistType{Name: "Released"}); err != nil {
+				return err
+			}
+			return errors.New("rollback to savepoint")
+		})
+		return nil
+	})
+	s.NoError(err)
+
+	var items []artistType
+	s.NoError(sess.Collection("artist").Find().All(&items))
+	s.Len(items, 1)
+	s.Equal("Committed", items[0].Name)
+}
+
+func (s *AdapterTests) TestCollections() {
+	sess := s.Session()
+
+	collections, err := sess.Collections()
+	s.NoError(err)
+
+	names := []string{}
+	for _, col := range collections {
+		names = append(names, col.Name())
+	}
+	s.Contains(names, "artist")
+	s.Contains(names, "publication")
+
+	// CURRENT SERVER is the name of the database, in upper case.
+	s.Equal(strings.ToUpper(settings.Database), sess.Name())
+}
+
+func TestAdapter(t *testing.T) {
+	suite.Run(t, &AdapterTests{})
+}
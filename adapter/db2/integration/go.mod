@@ -0,0 +1,22 @@
+module github.com/upper/db/v4/adapter/db2/integration
+
+go 1.22.1
+
+require (
+	github.com/ibmdb/go_ibm_db v0.5.2
+	github.com/stretchr/testify v1.6.1
+	github.com/upper/db/v4 v4.0.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/ibmruntimes/go-recordio/v2 v2.0.0-20240416213906-ae0ad556db70 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.3 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/sirupsen/logrus v1.6.0 // indirect
+	go.mongodb.org/mongo-driver v1.17.6 // indirect
+	golang.org/x/sys v0.23.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
+
+replace github.com/upper/db/v4 => ../../../
@@ -0,0 +1,130 @@
+// Copyright (c) 2012-today The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package integration
+
+import (
+	"database/sql"
+	"os"
+	"strings"
+
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/db2"
+	"github.com/upper/db/v4/internal/testsuite"
+
+	_ "github.com/ibmdb/go_ibm_db"
+)
+
+var settings = db2.ConnectionURL{
+	Database: os.Getenv("DB_NAME"),
+	User:     os.Getenv("DB_USERNAME"),
+	Password: os.Getenv("DB_PASSWORD"),
+	Host:     os.Getenv("DB_HOST") + ":" + os.Getenv("DB_PORT"),
+}
+
+// otherSchema holds a table with the same name as one in the current schema,
+// to test schema-qualified collection names.
+const otherSchema = "upperio_other"
+
+type Helper struct {
+	sess db.Session
+}
+
+func (h *Helper) Session() db.Session {
+	return h.sess
+}
+
+func (h *Helper) Adapter() string {
+	return db2.Adapter
+}
+
+func (h *Helper) TearDown() error {
+	return h.sess.Close()
+}
+
+// exec runs query, errors with any of the given SQLSTATEs are ignored. Db2
+// has no DROP ... IF EXISTS, a missing object is reported as 42704.
+func exec(driver *sql.DB, query string, ignore ...string) error {
+	_, err := driver.Exec(query)
+	if err != nil {
+		for _, state := range ignore {
+			if strings.Contains(err.Error(), "{"+state+"}") {
+				return nil
+			}
+		}
+	}
+	return err
+}
+
+func (h *Helper) TearUp() error {
+	var err error
+
+	h.sess, err = db2.Open(settings)
+	if err != nil {
+		return err
+	}
+
+	driver := h.sess.Driver().(*sql.DB)
+
+	// Names are quoted by the adapter, so they are created in lower case.
+	drops := []string{
+		`DROP TABLE "artist"`,
+		`DROP TABLE "publication"`,
+		`DROP TABLE "` + otherSchema + `"."artist"`,
+	}
+	for _, query := range drops {
+		if err := exec(driver, query, `42704`); err != nil {
+			return err
+		}
+	}
+
+	// The schema is kept between tests, 42710 means it already exists.
+	if err := exec(driver, `CREATE SCHEMA "`+otherSchema+`"`, `42710`); err != nil {
+		return err
+	}
+
+	batch := []string{
+		`CREATE TABLE "artist" (
+			"id" BIGINT NOT NULL GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+			"name" VARCHAR(60) NOT NULL UNIQUE
+		)`,
+
+		`CREATE TABLE "publication" (
+			"id" BIGINT NOT NULL GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+			"title" VARCHAR(80),
+			"author_id" BIGINT
+		)`,
+
+		`CREATE TABLE "` + otherSchema + `"."artist" (
+			"id" BIGINT NOT NULL GENERATED ALWAYS AS IDENTITY (START WITH 100) PRIMARY KEY,
+			"name" VARCHAR(60) NOT NULL
+		)`,
+	}
+	for _, query := range batch {
+		if err := exec(driver, query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var _ testsuite.Helper = &Helper{}
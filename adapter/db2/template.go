@@ -0,0 +1,265 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db2
+
+import (
+	"github.com/upper/db/v4/internal/cache"
+	"github.com/upper/db/v4/internal/sqladapter/exql"
+)
+
+const (
+	adapterColumnSeparator     = `.`
+	adapterIdentifierSeparator = `, `
+	adapterIdentifierQuote     = `"{{.Value}}"`
+	adapterValueSeparator      = `, `
+	adapterValueQuote          = `'{{.}}'`
+	adapterAndKeyword          = `AND`
+	adapterOrKeyword           = `OR`
+	adapterDescKeyword         = `DESC`
+	adapterAscKeyword          = `ASC`
+	adapterAssignmentOperator  = `=`
+	adapterClauseGroup         = `({{.}})`
+	adapterClauseOperator      = ` {{.}} `
+	adapterColumnValue         = `{{.Column}} {{.Operator}} {{.Value}}`
+	adapterTableAliasLayout    = `{{.Name}}{{if .Alias}} AS {{.Alias}}{{end}}`
+	adapterColumnAliasLayout   = `{{.Name}}{{if .Alias}} AS {{.Alias}}{{end}}`
+	adapterSortByColumnLayout  = `{{.Column}} {{.Order}}`
+
+	adapterOrderByLayout = `
+    {{if .SortColumns}}
+      ORDER BY {{.SortColumns}}
+    {{end}}
+  `
+
+	adapterWhereLayout = `
+    {{if .Conds}}
+      WHERE {{.Conds}}
+    {{end}}
+  `
+
+	adapterUsingLayout = `
+    {{if .Columns}}
+      USING ({{.Columns}})
+    {{end}}
+  `
+
+	adapterJoinLayout = `
+    {{if .Table}}
+      {{ if .On }}
+        {{.Type}} JOIN {{.Table}}
+        {{.On}}
+      {{ else if .Using }}
+        {{.Type}} JOIN {{.Table}}
+        {{.Using}}
+      {{ else if .Type | eq "CROSS" }}
+        {{.Type}} JOIN {{.Table}}
+      {{else}}
+        NATURAL {{.Type}} JOIN {{.Table}}
+      {{end}}
+    {{end}}
+  `
+
+	adapterOnLayout = `
+    {{if .Conds}}
+      ON {{.Conds}}
+    {{end}}
+  `
+
+	// Db2 requires a FROM clause, SYSIBM.SYSDUMMY1 is its one row table.
+	adapterSelectLayout = `
+    SELECT
+      {{if .Distinct}}
+        DISTINCT
+      {{end}}
+
+      {{if defined .Columns}}
+        {{.Columns | compile}}
+      {{else}}
+        *
+      {{end}}
+
+      {{if defined .Table}}
+        FROM {{.Table | compile}}
+      {{else}}
+        FROM SYSIBM.SYSDUMMY1
+      {{end}}
+
+      {{.Joins | compile}}
+
+      {{.Where | compile}}
+
+      {{if defined .GroupBy}}
+        {{.GroupBy | compile}}
+      {{end}}
+
+      {{if defined .Having}}
+        {{.Having | compile}}
+      {{end}}
+
+      {{.OrderBy | compile}}
+
+      {{if gt .Offset 0}}
+        OFFSET {{.Offset}} ROWS
+      {{end}}
+
+      {{if gt .Limit 0}}
+        FETCH FIRST {{.Limit}} ROWS ONLY
+      {{end}}
+
+      {{if defined .Lock}}
+        {{.Lock | compile}}
+      {{end}}
+  `
+
+	// Db2 has no NOWAIT clause, the lock timeout is set with
+	// SET CURRENT LOCK TIMEOUT instead.
+	adapterLockLayout = `
+    {{if eq .Mode "SHARE"}}
+      FOR READ ONLY WITH RS USE AND KEEP SHARE LOCKS
+    {{else}}
+      FOR UPDATE
+    {{end}}
+    {{if eq .Wait "SKIP LOCKED"}}
+      SKIP LOCKED DATA
+    {{end}}
+  `
+
+	adapterDeleteLayout = `
+    DELETE
+      FROM {{.Table | compile}}
+      {{.Where | compile}}
+  `
+
+	// Db2 has no RETURNING clause, the changed rows are read from the FINAL
+	// TABLE of the statement.
+	adapterUpdateLayout = `
+    {{if defined .Returning}}
+      SELECT {{.Returning | compile}} FROM FINAL TABLE (
+    {{end}}
+    UPDATE
+      {{.Table | compile}}
+    SET {{.ColumnValues | compile}}
+      {{.Where | compile}}
+    {{if defined .Returning}}
+      )
+    {{end}}
+  `
+
+	adapterSelectCountLayout = `
+    SELECT
+      COUNT(1) AS "_t"
+    FROM {{.Table | compile}}
+      {{.Where | compile}}
+  `
+
+	adapterInsertLayout = `
+    {{if defined .Returning}}
+      SELECT {{.Returning | compile}} FROM FINAL TABLE (
+    {{end}}
+    INSERT INTO {{.Table | compile}}
+      {{if defined .Columns}}({{.Columns | compile}}){{end}}
+    VALUES
+    {{if defined .Values}}
+      {{.Values | compile}}
+    {{else}}
+      (DEFAULT)
+    {{end}}
+    {{if defined .Returning}}
+      )
+    {{end}}
+  `
+
+	adapterTruncateLayout = `
+    TRUNCATE TABLE {{.Table | compile}} IMMEDIATE
+  `
+
+	adapterDropDatabaseLayout = `
+    DROP DATABASE {{.Database | compile}}
+  `
+
+	adapterDropTableLayout = `
+    DROP TABLE {{.Table | compile}}
+  `
+
+	adapterCreateTableAsLayout = `
+    CREATE TABLE {{.Table | compile}} AS ({{.Query | compile}}) WITH DATA
+  `
+
+	adapterGroupByLayout = `
+    {{if .GroupColumns}}
+      GROUP BY {{.GroupColumns}}
+    {{end}}
+  `
+
+	adapterHavingLayout = `
+    {{if .Conds}}
+      HAVING {{.Conds}}
+    {{end}}
+  `
+
+	adapterValuesTableLayout = `
+    (VALUES {{range $i, $row := .Rows}}{{if $i}}, {{end}}{{$row}}{{end}}) AS {{.Name}} ({{.Columns}})
+  `
+
+	adapterCallLayout = `
+    CALL {{.Name}}({{range $i, $arg := .Arguments}}{{if $i}}, {{end}}{{$arg.Value}}{{end}})
+  `
+)
+
+var template = &exql.Template{
+	ColumnSeparator:     adapterColumnSeparator,
+	IdentifierSeparator: adapterIdentifierSeparator,
+	IdentifierQuote:     adapterIdentifierQuote,
+	ValueSeparator:      adapterValueSeparator,
+	ValuesTableLayout:   adapterValuesTableLayout,
+	ValueQuote:          adapterValueQuote,
+	AndKeyword:          adapterAndKeyword,
+	OrKeyword:           adapterOrKeyword,
+	DescKeyword:         adapterDescKeyword,
+	AscKeyword:          adapterAscKeyword,
+	AssignmentOperator:  adapterAssignmentOperator,
+	ClauseGroup:         adapterClauseGroup,
+	ClauseOperator:      adapterClauseOperator,
+	ColumnValue:         adapterColumnValue,
+	TableAliasLayout:    adapterTableAliasLayout,
+	ColumnAliasLayout:   adapterColumnAliasLayout,
+	SortByColumnLayout:  adapterSortByColumnLayout,
+	WhereLayout:         adapterWhereLayout,
+	JoinLayout:          adapterJoinLayout,
+	LockLayout:          adapterLockLayout,
+	OnLayout:            adapterOnLayout,
+	UsingLayout:         adapterUsingLayout,
+	OrderByLayout:       adapterOrderByLayout,
+	InsertLayout:        adapterInsertLayout,
+	SelectLayout:        adapterSelectLayout,
+	UpdateLayout:        adapterUpdateLayout,
+	DeleteLayout:        adapterDeleteLayout,
+	TruncateLayout:      adapterTruncateLayout,
+	DropDatabaseLayout:  adapterDropDatabaseLayout,
+	DropTableLayout:     adapterDropTableLayout,
+	CreateTableAsLayout: adapterCreateTableAsLayout,
+	CountLayout:         adapterSelectCountLayout,
+	GroupByLayout:       adapterGroupByLayout,
+	HavingLayout:        adapterHavingLayout,
+	CallLayout:          adapterCallLayout,
+	Cache:               cache.NewCache(),
+}
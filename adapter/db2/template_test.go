@@ -0,0 +1,132 @@
+package db2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/sqlbuilder"
+)
+
+func TestTemplateSelect(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		`SELECT * FROM "artist"`,
+		b.SelectFrom("artist").String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "myschema"."artist"`,
+		b.SelectFrom("myschema.artist").String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" ORDER BY "name" DESC`,
+		b.Select().From("artist").OrderBy("-name").String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" FETCH FIRST 10 ROWS ONLY`,
+		b.SelectFrom("artist").Limit(10).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" ORDER BY "id" ASC OFFSET 5 ROWS FETCH FIRST 10 ROWS ONLY`,
+		b.SelectFrom("artist").OrderBy("id").Limit(10).Offset(5).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" OFFSET 5 ROWS`,
+		b.SelectFrom("artist").Limit(-1).Offset(5).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" WHERE ("name" = $1)`,
+		b.SelectFrom("artist").Where("name", "Haruki").String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" WHERE ("id" IN ($1, $2, $3, $4))`,
+		b.SelectFrom("artist").Where("id IN", []int{1, 9, 8, 7}).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" AS "a" JOIN "publication" AS "p" ON (p.author_id = a.id) FETCH FIRST 1 ROWS ONLY`,
+		b.SelectFrom("artist a").Join("publication p").On("p.author_id = a.id").Limit(1).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" WHERE ("id" = $1) FOR UPDATE`,
+		b.SelectFrom("artist").Where("id", 1).ForUpdate().String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" FOR UPDATE SKIP LOCKED DATA`,
+		b.SelectFrom("artist").ForUpdate(db.SkipLocked).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" FOR READ ONLY WITH RS USE AND KEEP SHARE LOCKS`,
+		b.SelectFrom("artist").ForShare().String(),
+	)
+
+	assert.Equal(
+		`SELECT CURRENT DATE FROM SYSIBM.SYSDUMMY1`,
+		b.Select(db.Raw("CURRENT DATE")).String(),
+	)
+}
+
+func TestTemplateInsert(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		`INSERT INTO "artist" VALUES ($1, $2), ($3, $4)`,
+		b.InsertInto("artist").
+			Values(10, "Ryuichi Sakamoto").
+			Values(11, "Alondra de la Parra").
+			String(),
+	)
+
+	assert.Equal(
+		`INSERT INTO "artist" ("id", "name") VALUES ($1, $2)`,
+		b.InsertInto("artist").Values(map[string]string{"id": "12", "name": "Chavela Vargas"}).String(),
+	)
+
+	assert.Equal(
+		`SELECT "id" FROM FINAL TABLE ( INSERT INTO "artist" ("name") VALUES ($1) )`,
+		b.InsertInto("artist").Values(map[string]string{"name": "Chavela Vargas"}).Returning("id").String(),
+	)
+
+	assert.Equal(
+		`INSERT INTO "myschema"."artist" VALUES (DEFAULT)`,
+		b.InsertInto("myschema.artist").String(),
+	)
+}
+
+func TestTemplateUpdate(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		`UPDATE "artist" SET "name" = $1 WHERE ("id" < $2)`,
+		b.Update("artist").Set("name", "Artist").Where("id <", 5).String(),
+	)
+
+	assert.Equal(
+		`SELECT "id", "name" FROM FINAL TABLE ( UPDATE "artist" SET "name" = $1 WHERE ("id" = $2) )`,
+		b.Update("artist").Set("name", "Artist").Where("id", 5).Returning("id", "name").String(),
+	)
+}
+
+func TestTemplateDelete(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		`DELETE FROM "artist" WHERE (name = $1)`,
+		b.DeleteFrom("artist").Where("name = ?", "Chavela Vargas").String(),
+	)
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package mongo
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/sqladapter"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Aggregation is an aggregation pipeline on a collection, see
+// Collection.Aggregate.
+type Aggregation struct {
+	col          *Collection
+	pipeline     interface{}
+	allowDiskUse bool
+}
+
+// Aggregate returns an aggregation that runs the given pipeline on the
+// collection, this makes grouping and accumulating documents possible:
+//
+//	var totals []struct {
+//		Numeric int `bson:"_id"`
+//		Sum     int `bson:"sum"`
+//	}
+//	err := col.(*mongo.Collection).Aggregate([]bson.M{
+//		{"$group": bson.M{"_id": "$numeric", "sum": bson.M{"$sum": "$value"}}},
+//		{"$sort": bson.M{"_id": 1}},
+//	}).All(&totals)
+//
+// The pipeline is a slice of stages, any value that can be marshaled into BSON
+// is accepted.
+func (col *Collection) Aggregate(pipeline interface{}) *Aggregation {
+	return &Aggregation{col: col, pipeline: pipeline}
+}
+
+// AllowDiskUse returns a copy of the aggregation whose stages can write
+// temporary files, which is required by pipelines that exceed the memory
+// limit of the server.
+func (a *Aggregation) AllowDiskUse() *Aggregation {
+	clone := *a
+	clone.allowDiskUse = true
+	return &clone
+}
+
+// All stores every document produced by the pipeline into dst, which must be
+// a pointer to a slice of structs or maps.
+func (a *Aggregation) All(dst interface{}) (err error) {
+	defer a.log("aggregate", time.Now(), &err)
+
+	cur, err := a.cursor()
+	if err != nil {
+		return err
+	}
+	return cur.All(a.col.parent.ctx, dst)
+}
+
+// One stores the first document produced by the pipeline into dst, it returns
+// db.ErrNoMoreRows if the pipeline produced no documents.
+func (a *Aggregation) One(dst interface{}) (err error) {
+	defer a.log("aggregate.one", time.Now(), &err)
+
+	cur, err := a.cursor()
+	if err != nil {
+		return err
+	}
+	defer cur.Close(a.col.parent.ctx)
+
+	if !cur.Next(a.col.parent.ctx) {
+		if err = cur.Err(); err != nil {
+			return err
+		}
+		return db.ErrNoMoreRows
+	}
+	return cur.Decode(dst)
+}
+
+func (a *Aggregation) cursor() (*mongo.Cursor, error) {
+	opts := options.Aggregate()
+	if a.allowDiskUse {
+		opts.SetAllowDiskUse(true)
+	}
+	return a.col.collection.Aggregate(a.col.parent.ctx, a.pipeline, opts)
+}
+
+func (a *Aggregation) log(action string, start time.Time, err *error) {
+	pipeline, jsonErr := json.Marshal(a.pipeline)
+	if jsonErr != nil {
+		pipeline = []byte(fmt.Sprintf("%v", a.pipeline))
+	}
+
+	queryLog(&sqladapter.QueryStatus{
+		Query: fmt.Sprintf("db.%s.%s(%s)", a.col.Name(), action, pipeline),
+		Err:   *err,
+		Start: start,
+		End:   time.Now(),
+	})
+}
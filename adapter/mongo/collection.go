@@ -30,16 +30,37 @@ import (
 
 	db "github.com/upper/db/v4"
 	"github.com/upper/db/v4/internal/adapter"
-	mgo "gopkg.in/mgo.v2"
-	"gopkg.in/mgo.v2/bson"
+	"github.com/upper/db/v4/internal/sqlbuilder"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // Collection represents a mongodb collection.
 type Collection struct {
 	parent     *Source
-	collection *mgo.Collection
+	collection *mongo.Collection
+
+	validator   db.ValidatorFunc
+	validatorMu sync.RWMutex
+
+	// ttlField holds the expiration time of the documents, expired documents
+	// are skipped by every operation when it's set.
+	ttlField string
+	// base is the collection that was copied by WithTTL, if any.
+	base *Collection
+
+	// ttlIndexes holds the fields whose TTL index was already created by
+	// ensureTTLIndex.
+	ttlIndexes sync.Map
 }
 
+var (
+	_ = db.ValidatingCollection(&Collection{})
+	_ = db.CappedCreator(&Collection{})
+)
+
 var (
 	// idCache should be a struct if we're going to cache more than just
 	// _id field here
@@ -51,10 +72,13 @@ var (
 func (col *Collection) Find(terms ...interface{}) db.Result {
 	fields := []string{"*"}
 
-	conditions := col.compileQuery(terms...)
+	conditions, err := col.compileQuery(terms...)
 
 	res := &result{}
 	res = res.frame(func(r *resultQuery) error {
+		if err != nil {
+			return err
+		}
 		r.c = col
 		r.conditions = conditions
 		r.fields = fields
@@ -108,9 +132,13 @@ func compare(field string, cmp *adapter.Comparison) (string, interface{}) {
 		}
 		return field, bson.M{"$ne": value}
 	case adapter.ComparisonOperatorRegExp, adapter.ComparisonOperatorLike:
-		return field, bson.RegEx{Pattern: value.(string), Options: ""}
+		return field, primitive.Regex{Pattern: value.(string), Options: ""}
 	case adapter.ComparisonOperatorNotRegExp, adapter.ComparisonOperatorNotLike:
-		return field, bson.M{"$not": bson.RegEx{Pattern: value.(string), Options: ""}}
+		return field, bson.M{"$not": primitive.Regex{Pattern: value.(string), Options: ""}}
+	case adapter.ComparisonOperatorILike:
+		return field, primitive.Regex{Pattern: value.(string), Options: "i"}
+	case adapter.ComparisonOperatorNotILike:
+		return field, bson.M{"$not": primitive.Regex{Pattern: value.(string), Options: "i"}}
 	}
 
 	if cmpOp, ok := comparisonOperators[op]; ok {
@@ -122,7 +150,7 @@ func compare(field string, cmp *adapter.Comparison) (string, interface{}) {
 	panic(fmt.Sprintf("Unsupported operator %v", op))
 }
 
-// compileStatement transforms conditions into something *mgo.Session can
+// compileStatement transforms conditions into a query document MongoDB can
 // understand.
 func compileStatement(cond db.Cond) bson.M {
 	conds := bson.M{}
@@ -170,83 +198,122 @@ func compileStatement(cond db.Cond) bson.M {
 	return conds
 }
 
-// compileConditions compiles terms into something *mgo.Session can
+// compileConditions compiles terms into a query document MongoDB can
 // understand.
-func (col *Collection) compileConditions(term interface{}) interface{} {
+func (col *Collection) compileConditions(term interface{}) (interface{}, error) {
 
 	switch t := term.(type) {
 	case []interface{}:
 		values := []interface{}{}
 		for i := range t {
-			value := col.compileConditions(t[i])
+			value, err := col.compileConditions(t[i])
+			if err != nil {
+				return nil, err
+			}
 			if value != nil {
 				values = append(values, value)
 			}
 		}
 		if len(values) > 0 {
-			return values
+			return values, nil
 		}
 	case db.Cond:
-		return compileStatement(t)
+		return compileStatement(t), nil
+	case *adapter.RawExpr:
+		// Raw expressions are query documents written in extended JSON.
+		if len(t.Arguments()) > 0 {
+			return nil, fmt.Errorf("%w: arguments for raw expressions", db.ErrUnsupported)
+		}
+		var doc bson.M
+		if err := bson.UnmarshalExtJSON([]byte(t.Raw()), false, &doc); err != nil {
+			return nil, fmt.Errorf("raw expression is not a JSON document: %w", err)
+		}
+		return doc, nil
 	case adapter.LogicalExpr:
 		values := []interface{}{}
 
 		for _, s := range t.Expressions() {
-			values = append(values, col.compileConditions(s))
+			value, err := col.compileConditions(s)
+			if err != nil {
+				return nil, err
+			}
+			if value != nil {
+				values = append(values, value)
+			}
+		}
+		if len(values) < 1 {
+			return nil, nil
 		}
 
-		var op string
 		switch t.Operator() {
 		case adapter.LogicalOperatorOr:
-			op = `$or`
-		default:
-			op = `$and`
+			return bson.M{`$or`: values}, nil
+		case adapter.LogicalOperatorNot:
+			// $nor negates each one of its terms, so the terms are joined
+			// first.
+			if len(values) > 1 {
+				values = []interface{}{bson.M{`$and`: values}}
+			}
+			return bson.M{`$nor`: values}, nil
 		}
-
-		return bson.M{op: values}
+		return bson.M{`$and`: values}, nil
 	}
-	return nil
+	return nil, nil
 }
 
-// compileQuery compiles terms into something that *mgo.Session can
+// compileQuery compiles terms into a query document that MongoDB can
 // understand.
-func (col *Collection) compileQuery(terms ...interface{}) interface{} {
-	compiled := col.compileConditions(terms)
-	if compiled == nil {
-		return nil
+func (col *Collection) compileQuery(terms ...interface{}) (interface{}, error) {
+	compiled, err := col.compileConditions(terms)
+	if compiled == nil || err != nil {
+		return nil, err
 	}
 
 	conditions := compiled.([]interface{})
 	if len(conditions) == 1 {
-		return conditions[0]
+		return conditions[0], nil
 	}
 	// this should be correct.
 	// query = map[string]interface{}{"$and": conditions}
 
 	// attempt to workaround https://jira.mongodb.org/browse/SERVER-4572
-	mapped := map[string]interface{}{}
+	mapped := bson.M{}
 	for _, v := range conditions {
-		for kk := range v.(map[string]interface{}) {
-			mapped[kk] = v.(map[string]interface{})[kk]
+		m, ok := v.(bson.M)
+		if !ok {
+			return bson.M{`$and`: conditions}, nil
+		}
+		for kk, vv := range m {
+			if _, ok := mapped[kk]; ok {
+				// Two terms use the same key, like two $or groups.
+				return bson.M{`$and`: conditions}, nil
+			}
+			mapped[kk] = vv
 		}
 	}
 
-	return mapped
+	return mapped, nil
 }
 
 // Name returns the name of the table or tables that form the collection.
 func (col *Collection) Name() string {
-	return col.collection.Name
+	return col.collection.Name()
 }
 
 // Truncate deletes all rows from the table.
 func (col *Collection) Truncate() error {
-	err := col.collection.DropCollection()
+	err := col.collection.Drop(col.parent.ctx)
 
 	if err != nil {
 		return err
 	}
 
+	// Dropping the collection drops its indexes too.
+	col.root().ttlIndexes.Range(func(key, _ interface{}) bool {
+		col.root().ttlIndexes.Delete(key)
+		return true
+	})
+
 	return nil
 }
 
@@ -266,41 +333,319 @@ func (col *Collection) UpdateReturning(item interface{}) error {
 	return db.ErrUnsupported
 }
 
-// Insert inserts a record (map or struct) into the collection.
-func (col *Collection) Insert(item interface{}) (*db.InsertResult, error) {
-	var err error
+// CompileInsert is not supported by the MongoDB adapter.
+func (col *Collection) CompileInsert(interface{}) (string, []interface{}, error) {
+	return "", nil, db.ErrUnsupported
+}
 
-	id := getID(item)
+// FindDescendants looks up the descendants of the document with the given
+// _id one level at a time.
+func (col *Collection) FindDescendants(rootID interface{}, parentField string) ([]*db.Node, error) {
+	return db.Descendants(col, "_id", rootID, parentField)
+}
+
+// FindAncestors looks up the ancestors of the document with the given _id one
+// level at a time.
+func (col *Collection) FindAncestors(id interface{}, parentField string) ([]*db.Node, error) {
+	return db.Ancestors(col, "_id", id, parentField)
+}
 
-	if col.parent.versionAtLeast(2, 6, 0, 0) {
-		// this breaks MongoDb older than 2.6
-		if _, err = col.collection.Upsert(bson.M{"_id": id}, item); err != nil {
-			return nil, err
+// ExistingIDs returns which of the given _id values exist in the collection.
+func (col *Collection) ExistingIDs(ids []interface{}) (map[interface{}]bool, error) {
+	return db.ExistingIDs(col, "_id", ids)
+}
+
+// Stats returns the document count and storage sizes reported by the
+// collStats command. MongoDB does not analyze collections, LastAnalyzed is
+// always nil.
+func (col *Collection) Stats() (*db.CollectionStats, error) {
+	res, err := col.collStats()
+	if err != nil {
+		return nil, err
+	}
+	return &db.CollectionStats{
+		Rows:      statsNumber(res["count"]),
+		DataSize:  statsNumber(res["size"]),
+		IndexSize: statsNumber(res["totalIndexSize"]),
+	}, nil
+}
+
+// Analyze is not supported by MongoDB.
+func (col *Collection) Analyze() error {
+	return db.ErrUnsupported
+}
+
+// Vacuum is not supported by MongoDB.
+func (col *Collection) Vacuum() error {
+	return db.ErrUnsupported
+}
+
+// Optimize is not supported by MongoDB.
+func (col *Collection) Optimize() error {
+	return db.ErrUnsupported
+}
+
+// Triggers is not supported by MongoDB.
+func (col *Collection) Triggers() ([]*db.Trigger, error) {
+	return nil, db.ErrUnsupported
+}
+
+// CreateTrigger is not supported by MongoDB.
+func (col *Collection) CreateTrigger(*db.Trigger) error {
+	return db.ErrUnsupported
+}
+
+// DropTrigger is not supported by MongoDB.
+func (col *Collection) DropTrigger(string) error {
+	return db.ErrUnsupported
+}
+
+// defaultCappedMaxBytes is the size of capped collections created with no
+// size limit, MongoDB requires one.
+const defaultCappedMaxBytes = 1 << 30
+
+// CreateCapped creates the collection as a native capped collection that
+// keeps at most maxItems documents and maxBytes bytes, see db.Capped. If the
+// collection already exists it must be capped already.
+func (col *Collection) CreateCapped(maxItems uint64, maxBytes uint64) error {
+	exists, err := col.Exists()
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		if maxBytes == 0 {
+			maxBytes = defaultCappedMaxBytes
 		}
-	} else {
-		// Allocating a new ID.
-		if err = col.collection.Insert(bson.M{"_id": id}); err != nil {
-			return nil, err
+		opts := options.CreateCollection().
+			SetCapped(true).
+			SetSizeInBytes(int64(maxBytes))
+		if maxItems > 0 {
+			opts.SetMaxDocuments(int64(maxItems))
 		}
+		return col.collection.Database().CreateCollection(col.parent.ctx, col.Name(), opts)
+	}
 
-		// Now append data the user wants to append.
-		if err = col.collection.Update(bson.M{"_id": id}, item); err != nil {
-			// Cleanup allocated ID
-			if err := col.collection.Remove(bson.M{"_id": id}); err != nil {
-				return nil, err
-			}
-			return nil, err
+	res, err := col.collStats()
+	if err != nil {
+		return err
+	}
+	if capped, _ := res["capped"].(bool); !capped {
+		return fmt.Errorf("%w: %q exists and is not a capped collection", db.ErrUnsupported, col.Name())
+	}
+	return nil
+}
+
+// collStats returns the result of the collStats command.
+func (col *Collection) collStats() (bson.M, error) {
+	var res bson.M
+	cmd := bson.D{{Key: "collStats", Value: col.Name()}}
+	if err := col.collection.Database().RunCommand(col.parent.ctx, cmd).Decode(&res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// statsNumber converts a number returned by a command, which may be encoded
+// as any numeric type, to an uint64.
+func statsNumber(v interface{}) uint64 {
+	switch n := v.(type) {
+	case int:
+		return uint64(n)
+	case int32:
+		return uint64(n)
+	case int64:
+		return uint64(n)
+	case float64:
+		return uint64(n)
+	}
+	return 0
+}
+
+// InsertMany inserts all the items of the given slice into the collection, one
+// by one, with the same semantics as Insert. batchSize is ignored, MongoDB
+// has no multi-document equivalent of the upsert Insert relies on.
+func (col *Collection) InsertMany(items interface{}, batchSize int) ([]*db.InsertResult, error) {
+	itemsV := reflect.ValueOf(items)
+	if itemsV.Kind() != reflect.Slice && itemsV.Kind() != reflect.Array {
+		return nil, fmt.Errorf("Expecting a slice but got %T", items)
+	}
+
+	results := make([]*db.InsertResult, 0, itemsV.Len())
+	for i := 0; i < itemsV.Len(); i++ {
+		res, err := col.Insert(itemsV.Index(i).Interface())
+		if err != nil {
+			return results, err
 		}
+		results = append(results, res)
+	}
+
+	return results, nil
+}
+
+func (col *Collection) Upsert(item interface{}, conflictColumns ...string) (*db.InsertResult, error) {
+	return nil, db.ErrUnsupported
+}
+
+// InsertOrIgnore is not supported by MongoDB.
+func (col *Collection) InsertOrIgnore(item interface{}) (*db.InsertResult, error) {
+	return nil, db.ErrUnsupported
+}
+
+// Insert inserts a record (map or struct) into the collection.
+func (col *Collection) Insert(item interface{}) (*db.InsertResult, error) {
+	var err error
+
+	if err = col.validate(item, false); err != nil {
+		return nil, err
+	}
+
+	if err = col.ensureTTLIndex(item); err != nil {
+		return nil, err
+	}
+
+	id := getID(item)
+
+	opts := options.Replace().SetUpsert(true)
+	if _, err = col.collection.ReplaceOne(col.parent.ctx, bson.M{"_id": id}, item, opts); err != nil {
+		return nil, err
 	}
 
 	return db.NewInsertResult(id), nil
 }
 
+// SetValidator sets the function that validates documents before they're
+// inserted or updated, a nil fn removes it.
+func (col *Collection) SetValidator(fn db.ValidatorFunc) {
+	root := col.root()
+
+	root.validatorMu.Lock()
+	defer root.validatorMu.Unlock()
+
+	root.validator = fn
+}
+
+// validate checks item with the validator of the collection, if any.
+func (col *Collection) validate(item interface{}, partial bool) error {
+	root := col.root()
+
+	root.validatorMu.RLock()
+	fn := root.validator
+	root.validatorMu.RUnlock()
+
+	if fn == nil {
+		return nil
+	}
+
+	doc, err := toDocument(item)
+	if err != nil {
+		return err
+	}
+	if err := fn(doc, partial); err != nil {
+		return db.NewValidationError(col.Name(), err)
+	}
+	return nil
+}
+
+// ensureTTLIndex creates a TTL index on the field of item with the ttl tag
+// option, if any, so the server deletes expired documents on its own. The
+// field must have the same name on its db and bson tags. MongoDB expects a
+// positive expireAfterSeconds, so documents are deleted one second after
+// their expiration time at the earliest.
+func (col *Collection) ensureTTLIndex(item interface{}) error {
+	column, ok := sqlbuilder.TTLColumn(item)
+	if !ok {
+		return nil
+	}
+
+	root := col.root()
+	if _, ok := root.ttlIndexes.Load(column); ok {
+		return nil
+	}
+	_, err := col.collection.Indexes().CreateOne(col.parent.ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: column, Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(1),
+	})
+	if err != nil {
+		return err
+	}
+	root.ttlIndexes.Store(column, true)
+	return nil
+}
+
+// WithTTL returns a copy of the collection whose operations skip expired
+// documents when item has a field with the ttl tag option, or the collection
+// itself otherwise.
+func (col *Collection) WithTTL(item interface{}) db.Collection {
+	field, ok := sqlbuilder.TTLColumn(item)
+	if !ok {
+		return col
+	}
+	return &Collection{
+		parent:     col.parent,
+		collection: col.collection,
+		ttlField:   field,
+		base:       col.root(),
+	}
+}
+
+// root returns the collection that holds the validator.
+func (col *Collection) root() *Collection {
+	if col.base != nil {
+		return col.base
+	}
+	return col
+}
+
+// PurgeExpired deletes the documents whose expiration time, stored in field,
+// is not later than the current time of the server. The TTL index deletes
+// them too, but only once a minute.
+func (col *Collection) PurgeExpired(field string) error {
+	_, err := col.collection.DeleteMany(col.parent.ctx, bson.M{
+		field:   bson.M{"$ne": nil},
+		"$expr": bson.M{"$lte": []interface{}{"$" + field, "$$NOW"}},
+	})
+	return err
+}
+
+// unexpiredDocuments returns a query document that matches the documents
+// whose expiration time, stored in field, is either undefined or later than
+// the current time of the server.
+func unexpiredDocuments(field string) bson.M {
+	return bson.M{
+		"$or": []interface{}{
+			bson.M{field: nil},
+			bson.M{"$expr": bson.M{"$gt": []interface{}{"$" + field, "$$NOW"}}},
+		},
+	}
+}
+
+// toDocument converts a map or struct into the document that is going to be
+// stored, using the same field names.
+func toDocument(item interface{}) (map[string]interface{}, error) {
+	if doc, ok := item.(map[string]interface{}); ok {
+		return doc, nil
+	}
+
+	data, err := bson.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
 // Exists returns true if the collection exists.
 func (col *Collection) Exists() (bool, error) {
-	query := col.parent.database.C(`system.namespaces`).Find(map[string]string{`name`: fmt.Sprintf(`%s.%s`, col.parent.database.Name, col.collection.Name)})
-	count, err := query.Count()
-	return count > 0, err
+	names, err := col.collection.Database().ListCollectionNames(col.parent.ctx, bson.M{"name": col.Name()})
+	if err != nil {
+		return false, err
+	}
+	return len(names) > 0, nil
 }
 
 // Fetches object _id or generates a new one if object doesn't have one or the one it has is invalid
@@ -312,7 +657,7 @@ func getID(item interface{}) interface{} {
 	case reflect.Map:
 		if inItem, ok := item.(map[string]interface{}); ok {
 			if id, ok := inItem["_id"]; ok {
-				bsonID, ok := id.(bson.ObjectId)
+				bsonID, ok := id.(primitive.ObjectID)
 				if ok {
 					return bsonID
 				}
@@ -345,16 +690,16 @@ func getID(item interface{}) interface{} {
 
 				if parts[0] == "_id" {
 					fieldName = field.Name
-					idCacheMutex.RLock()
+					idCacheMutex.Lock()
 					idCache[t] = fieldName
-					idCacheMutex.RUnlock()
+					idCacheMutex.Unlock()
 					break
 				}
 			}
 		}
 		if fieldName != "" {
-			if bsonID, ok := v.FieldByName(fieldName).Interface().(bson.ObjectId); ok {
-				if bsonID.Valid() {
+			if bsonID, ok := v.FieldByName(fieldName).Interface().(primitive.ObjectID); ok {
+				if !bsonID.IsZero() {
 					return bsonID
 				}
 			} else {
@@ -363,5 +708,5 @@ func getID(item interface{}) interface{} {
 		}
 	}
 
-	return bson.NewObjectId()
+	return primitive.NewObjectID()
 }
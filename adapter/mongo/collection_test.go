@@ -0,0 +1,94 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package mongo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	db "github.com/upper/db/v4"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCompileQuery(t *testing.T) {
+	col := &Collection{}
+
+	query, err := col.compileQuery(
+		db.Or(
+			db.Cond{"name": "Ozzie"},
+			db.And(
+				db.Cond{"age >": 18},
+				db.Raw(`{"group": {"$in": [1, 2]}}`),
+				db.Not(db.Cond{"name": "Flea"}),
+			),
+		),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{
+		"$or": []interface{}{
+			bson.M{"$and": []interface{}{bson.M{"name": "Ozzie"}}},
+			bson.M{"$and": []interface{}{
+				bson.M{"age": bson.M{"$gt": 18}},
+				bson.M{"group": bson.M{"$in": bson.A{int32(1), int32(2)}}},
+				bson.M{"$nor": []interface{}{bson.M{"name": "Flea"}}},
+			}},
+		},
+	}, query)
+
+	query, err = col.compileQuery(db.Not(db.Cond{"name": "Flea"}, db.Cond{"age": 20}))
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{
+		"$nor": []interface{}{
+			bson.M{"$and": []interface{}{bson.M{"name": "Flea"}, bson.M{"age": 20}}},
+		},
+	}, query)
+
+	// Terms with the same key are not merged.
+	query, err = col.compileQuery(
+		db.Or(db.Cond{"a": 1}, db.Cond{"b": 2}),
+		db.Or(db.Cond{"c": 3}, db.Cond{"d": 4}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{
+		"$and": []interface{}{
+			bson.M{"$or": []interface{}{
+				bson.M{"$and": []interface{}{bson.M{"a": 1}}},
+				bson.M{"$and": []interface{}{bson.M{"b": 2}}},
+			}},
+			bson.M{"$or": []interface{}{
+				bson.M{"$and": []interface{}{bson.M{"c": 3}}},
+				bson.M{"$and": []interface{}{bson.M{"d": 4}}},
+			}},
+		},
+	}, query)
+
+	query, err = col.compileQuery(db.And())
+	assert.NoError(t, err)
+	assert.Nil(t, query)
+
+	_, err = col.compileQuery(db.Raw("name = ?", "Flea"))
+	assert.True(t, errors.Is(err, db.ErrUnsupported))
+
+	_, err = col.compileQuery(db.Raw("name = 'Flea'"))
+	assert.Error(t, err)
+}
@@ -25,6 +25,8 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+
+	db "github.com/upper/db/v4"
 )
 
 const connectionScheme = `mongodb`
@@ -79,6 +81,31 @@ func (c ConnectionURL) String() (s string) {
 	return u.String()
 }
 
+// Validate checks the connection settings before connecting.
+func (c ConnectionURL) Validate() error {
+	if c.Database == "" {
+		return db.NewConnectionURLError("Database", "is required")
+	}
+	if c.Host != "" {
+		// Replica sets are given as a comma-separated list of hosts.
+		for _, host := range strings.Split(c.Host, ",") {
+			if err := db.ValidateAddress("Host", host); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Redacted returns the DSN with the password replaced by db.RedactedPassword,
+// it's safe to log.
+func (c ConnectionURL) Redacted() string {
+	if c.Password != "" {
+		c.Password = db.RedactedPassword
+	}
+	return c.String()
+}
+
 // ParseURL parses s into a ConnectionURL struct.
 func ParseURL(s string) (conn ConnectionURL, err error) {
 	var u *url.URL
@@ -19,7 +19,7 @@
 // OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
 // WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
 
-// Package mongo wraps the gopkg.in/mgo.v2 MongoDB driver. See
+// Package mongo wraps the go.mongodb.org/mongo-driver MongoDB driver. See
 // https://github.com/upper/db/adapter/mongo for documentation, particularities and usage
 // examples.
 package mongo
@@ -32,7 +32,9 @@ import (
 	"time"
 
 	db "github.com/upper/db/v4"
-	mgo "gopkg.in/mgo.v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // Adapter holds the name of the mongodb adapter.
@@ -48,13 +50,42 @@ type Source struct {
 
 	name          string
 	connURL       db.ConnectionURL
-	session       *mgo.Session
-	database      *mgo.Database
-	version       []int
+	client        *client
+	database      *mongo.Database
 	collections   map[string]*Collection
 	collectionsMu sync.Mutex
 }
 
+// client is the connection pool shared by a session and its clones, it's
+// disconnected when the last of them is closed.
+type client struct {
+	*mongo.Client
+
+	mu   sync.Mutex
+	refs int
+}
+
+func (c *client) acquire() *client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.refs++
+	return c
+}
+
+func (c *client) release(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.refs == 0 {
+		return nil
+	}
+	if c.refs--; c.refs > 0 {
+		return nil
+	}
+	return c.Disconnect(ctx)
+}
+
 type mongoAdapter struct {
 }
 
@@ -62,6 +93,14 @@ func (mongoAdapter) Open(dsn db.ConnectionURL) (db.Session, error) {
 	return Open(dsn)
 }
 
+func (mongoAdapter) Capabilities() db.Capability {
+	return db.CapabilityReadPreferences
+}
+
+func (mongoAdapter) ParseURL(s string) (db.ConnectionURL, error) {
+	return ParseURL(s)
+}
+
 func init() {
 	db.RegisterAdapter(Adapter, db.Adapter(&mongoAdapter{}))
 }
@@ -79,10 +118,35 @@ func (s *Source) TxContext(context.Context, func(tx db.Session) error, *sql.TxOp
 	return db.ErrNotSupportedByAdapter
 }
 
+// Conn is not supported by MongoDB.
+func (s *Source) Conn(func(db.Session) error) error {
+	return db.ErrUnsupported
+}
+
+// ConnContext is not supported by MongoDB.
+func (s *Source) ConnContext(context.Context, func(db.Session) error) error {
+	return db.ErrUnsupported
+}
+
 func (s *Source) Tx(func(db.Session) error) error {
 	return db.ErrNotSupportedByAdapter
 }
 
+// SnapshotRead is not supported by MongoDB.
+func (s *Source) SnapshotRead(func(db.Session) error) error {
+	return db.ErrUnsupported
+}
+
+// Query is not supported by MongoDB.
+func (s *Source) Query(interface{}, interface{}, ...interface{}) error {
+	return db.ErrUnsupported
+}
+
+// Exec is not supported by MongoDB.
+func (s *Source) Exec(interface{}, ...interface{}) (sql.Result, error) {
+	return nil, db.ErrUnsupported
+}
+
 func (s *Source) SQL() db.SQL {
 	// Not supported
 	panic("sql builder is not supported by mongodb")
@@ -118,17 +182,17 @@ func (s *Source) Open(connURL db.ConnectionURL) error {
 	return s.open()
 }
 
-// Clone returns a cloned db.Session session.
+// Clone returns a cloned db.Session session, which shares the connection pool
+// of s and can be closed independently.
 func (s *Source) Clone() (db.Session, error) {
-	newSession := s.session.Copy()
 	clone := &Source{
 		Settings: db.NewSettings(),
+		ctx:      s.ctx,
 
 		name:        s.name,
 		connURL:     s.connURL,
-		session:     newSession,
-		database:    newSession.DB(s.database.Name),
-		version:     s.version,
+		client:      s.client.acquire(),
+		database:    s.database,
 		collections: map[string]*Collection{},
 	}
 	return clone, nil
@@ -137,7 +201,7 @@ func (s *Source) Clone() (db.Session, error) {
 // Ping checks whether a connection to the database is still alive by pinging
 // it, establishing a connection if necessary.
 func (s *Source) Ping() error {
-	return s.session.Ping()
+	return s.client.Ping(s.ctx, nil)
 }
 
 func (s *Source) Reset() {
@@ -146,30 +210,56 @@ func (s *Source) Reset() {
 	s.collections = make(map[string]*Collection)
 }
 
-// Driver returns the underlying *mgo.Session instance.
+// Driver returns the underlying *mongo.Client instance.
 func (s *Source) Driver() interface{} {
-	return s.session
+	return s.client.Client
 }
 
 func (s *Source) open() error {
-	var err error
-
-	if s.session, err = mgo.DialWithTimeout(s.connURL.String(), connTimeout); err != nil {
+	if err := db.ValidateConnectionURL(s.connURL); err != nil {
 		return err
 	}
 
+	dsn := s.connURL.String()
+	conn, err := ParseURL(dsn)
+	if err != nil {
+		return db.RedactError(err, s.connURL)
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, connTimeout)
+	defer cancel()
+
+	opts := options.Client().
+		ApplyURI(dsn).
+		SetConnectTimeout(connTimeout).
+		SetServerSelectionTimeout(connTimeout)
+	mc, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		return db.RedactError(err, s.connURL)
+	}
+	// Connect doesn't talk to the server, make sure it can be reached.
+	if err := mc.Ping(ctx, nil); err != nil {
+		_ = mc.Disconnect(s.ctx)
+		return db.RedactError(err, s.connURL)
+	}
+
+	s.client = (&client{Client: mc}).acquire()
+	s.name = conn.Database
 	s.collections = map[string]*Collection{}
-	s.database = s.session.DB("")
+	s.database = mc.Database(conn.Database)
 
 	return nil
 }
 
-// Close terminates the current database session.
+// Close terminates the current database session, the connection pool is
+// closed along with the last session that uses it.
 func (s *Source) Close() error {
-	if s.session != nil {
-		s.session.Close()
+	if s.client == nil {
+		return nil
 	}
-	return nil
+	c := s.client
+	s.client = nil
+	return c.release(s.ctx)
 }
 
 // Collections returns a list of non-system tables from the database.
@@ -177,7 +267,7 @@ func (s *Source) Collections() (cols []db.Collection, err error) {
 	var rawcols []string
 	var col string
 
-	if rawcols, err = s.database.CollectionNames(); err != nil {
+	if rawcols, err = s.database.ListCollectionNames(s.ctx, bson.D{}); err != nil {
 		return nil, err
 	}
 
@@ -204,19 +294,34 @@ func (s *Source) Save(db.Record) error {
 	return db.ErrNotImplemented
 }
 
+// Use is not supported by the MongoDB adapter, which doesn't send SQL
+// statements, the middleware is ignored.
+func (s *Source) Use(...db.Middleware) {
+}
+
+// SetBoolMapping is not supported by the MongoDB adapter, which stores bool
+// values as they are.
+func (s *Source) SetBoolMapping(*db.BoolMapping) {
+}
+
+// BoolMapping returns nil.
+func (s *Source) BoolMapping() *db.BoolMapping {
+	return nil
+}
+
 func (s *Source) Context() context.Context {
 	return s.ctx
 }
 
 func (s *Source) WithContext(ctx context.Context) db.Session {
 	return &Source{
-		ctx:      ctx,
-		Settings: s.Settings,
-		name:     s.name,
-		connURL:  s.connURL,
-		session:  s.session,
-		database: s.database,
-		version:  s.version,
+		ctx:         ctx,
+		Settings:    s.Settings,
+		name:        s.name,
+		connURL:     s.connURL,
+		client:      s.client,
+		database:    s.database,
+		collections: map[string]*Collection{},
 	}
 }
 
@@ -231,36 +336,10 @@ func (s *Source) Collection(name string) db.Collection {
 	if col, ok = s.collections[name]; !ok {
 		col = &Collection{
 			parent:     s,
-			collection: s.database.C(name),
+			collection: s.database.Collection(name),
 		}
 		s.collections[name] = col
 	}
 
 	return col
 }
-
-func (s *Source) versionAtLeast(version ...int) bool {
-	// only fetch this once - it makes a db call
-	if len(s.version) == 0 {
-		buildInfo, err := s.database.Session.BuildInfo()
-		if err != nil {
-			return false
-		}
-		s.version = buildInfo.VersionArray
-	}
-
-	// Check major version first
-	if s.version[0] > version[0] {
-		return true
-	}
-
-	for i := range version {
-		if i == len(s.version) {
-			return false
-		}
-		if s.version[i] < version[i] {
-			return false
-		}
-	}
-	return true
-}
@@ -22,10 +22,11 @@
 package mongo
 
 import (
+	"context"
 	"fmt"
 	"os"
 
-	mgo "gopkg.in/mgo.v2"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	db "github.com/upper/db/v4"
 	"github.com/upper/db/v4/internal/testsuite"
@@ -62,23 +63,25 @@ func (h *Helper) TearUp() error {
 		return err
 	}
 
-	mgod, ok := h.sess.Driver().(*mgo.Session)
+	mc, ok := h.sess.Driver().(*mongo.Client)
 	if !ok {
-		panic("expecting mgo.Session")
+		panic("expecting mongo.Client")
 	}
 
-	var col *mgo.Collection
-	col = mgod.DB(settings.Database).C("birthdays")
-	_ = col.DropCollection()
+	ctx := context.Background()
 
-	col = mgod.DB(settings.Database).C("fibonacci")
-	_ = col.DropCollection()
+	var col *mongo.Collection
+	col = mc.Database(settings.Database).Collection("birthdays")
+	_ = col.Drop(ctx)
 
-	col = mgod.DB(settings.Database).C("is_even")
-	_ = col.DropCollection()
+	col = mc.Database(settings.Database).Collection("fibonacci")
+	_ = col.Drop(ctx)
 
-	col = mgod.DB(settings.Database).C("CaSe_TesT")
-	_ = col.DropCollection()
+	col = mc.Database(settings.Database).Collection("is_even")
+	_ = col.Drop(ctx)
+
+	col = mc.Database(settings.Database).Collection("CaSe_TesT")
+	_ = col.Drop(ctx)
 
 	// Getting a pointer to the "artist" collection.
 	artist := h.sess.Collection("artist")
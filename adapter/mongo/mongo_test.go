@@ -23,6 +23,8 @@
 package mongo
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
@@ -33,12 +35,14 @@ import (
 	"github.com/stretchr/testify/suite"
 	db "github.com/upper/db/v4"
 	"github.com/upper/db/v4/internal/testsuite"
-	"gopkg.in/mgo.v2/bson"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type artistType struct {
-	ID   bson.ObjectId `bson:"_id,omitempty"`
-	Name string        `bson:"name"`
+	ID   primitive.ObjectID `bson:"_id,omitempty"`
+	Name string             `bson:"name"`
 }
 
 // Structure for testing conversions and datatypes.
@@ -186,10 +190,10 @@ func (s *AdapterTests) TestInsert() {
 	id := record.ID()
 	s.NotZero(record.ID())
 
-	_, ok := id.(bson.ObjectId)
+	_, ok := id.(primitive.ObjectID)
 	s.True(ok)
 
-	s.True(id.(bson.ObjectId).Valid())
+	s.False(id.(primitive.ObjectID).IsZero())
 
 	// Inserting a struct.
 	record, err = artist.Insert(struct {
@@ -202,9 +206,9 @@ func (s *AdapterTests) TestInsert() {
 	id = record.ID()
 	s.NotZero(id)
 
-	_, ok = id.(bson.ObjectId)
+	_, ok = id.(primitive.ObjectID)
 	s.True(ok)
-	s.True(id.(bson.ObjectId).Valid())
+	s.False(id.(primitive.ObjectID).IsZero())
 
 	// Inserting a struct (using tags to specify the field name).
 	record, err = artist.Insert(struct {
@@ -217,10 +221,10 @@ func (s *AdapterTests) TestInsert() {
 	id = record.ID()
 	s.NotNil(id)
 
-	_, ok = id.(bson.ObjectId)
+	_, ok = id.(primitive.ObjectID)
 
 	s.True(ok)
-	s.True(id.(bson.ObjectId).Valid())
+	s.False(id.(primitive.ObjectID).IsZero())
 
 	// Inserting a pointer to a struct
 	record, err = artist.Insert(&struct {
@@ -233,9 +237,9 @@ func (s *AdapterTests) TestInsert() {
 	id = record.ID()
 	s.NotNil(id)
 
-	_, ok = id.(bson.ObjectId)
+	_, ok = id.(primitive.ObjectID)
 	s.True(ok)
-	s.True(id.(bson.ObjectId).Valid())
+	s.False(id.(primitive.ObjectID).IsZero())
 
 	// Inserting a pointer to a map
 	record, err = artist.Insert(&map[string]string{
@@ -244,13 +248,13 @@ func (s *AdapterTests) TestInsert() {
 	s.NoError(err)
 	s.NotZero(id)
 
-	_, ok = id.(bson.ObjectId)
+	_, ok = id.(primitive.ObjectID)
 	s.True(ok)
 
 	id = record.ID()
 	s.NotNil(id)
 
-	s.True(id.(bson.ObjectId).Valid())
+	s.False(id.(primitive.ObjectID).IsZero())
 
 	// Counting elements, must be exactly 6 elements.
 	total, err := artist.Find().Count()
@@ -276,7 +280,7 @@ func (s *AdapterTests) TestGetNonExistentRow_Issue426() {
 	var all []artistType
 	err = artist.Find(db.Cond{"name": "nothing"}).All(&all)
 
-	s.Zero(err, "All should not return mgo.ErrNotFound")
+	s.Zero(err, "All should not return mongo.ErrNoDocuments")
 	s.Equal(0, len(all))
 }
 
@@ -341,6 +345,54 @@ func (s *AdapterTests) TestGroup() {
 	s.Equal(db.ErrUnsupported, err)
 }
 
+func (s *AdapterTests) TestAggregate() {
+	sess, err := Open(settings)
+	s.NoError(err)
+
+	defer sess.Close()
+
+	type statsT struct {
+		Numeric int `bson:"numeric"`
+		Value   int `bson:"value"`
+	}
+
+	stats := sess.Collection("statsTest")
+	_ = stats.Truncate()
+
+	sums := map[int]int{}
+	for i := 0; i < 100; i++ {
+		numeric, value := i%5, rand.Intn(100)
+		_, err = stats.Insert(statsT{numeric, value})
+		s.NoError(err)
+		sums[numeric] += value
+	}
+
+	var totals []struct {
+		Numeric int `bson:"_id"`
+		Sum     int `bson:"sum"`
+	}
+	err = stats.(*Collection).Aggregate([]bson.M{
+		{"$group": bson.M{"_id": "$numeric", "sum": bson.M{"$sum": "$value"}}},
+		{"$sort": bson.M{"_id": 1}},
+	}).AllowDiskUse().All(&totals)
+	s.NoError(err)
+
+	s.Len(totals, 5)
+	for i := range totals {
+		s.Equal(i, totals[i].Numeric)
+		s.Equal(sums[i], totals[i].Sum)
+	}
+
+	var total struct {
+		Sum int `bson:"sum"`
+	}
+	err = stats.(*Collection).Aggregate([]bson.M{
+		{"$match": bson.M{"numeric": 99}},
+		{"$group": bson.M{"_id": nil, "sum": bson.M{"$sum": "$value"}}},
+	}).One(&total)
+	s.Equal(db.ErrNoMoreRows, err)
+}
+
 func (s *AdapterTests) TestResultNonExistentCount() {
 	sess, err := Open(settings)
 	s.NoError(err)
@@ -371,10 +423,10 @@ func (s *AdapterTests) TestResultFetch() {
 	for res.Next(&rowM) {
 		s.NotZero(rowM["_id"])
 
-		_, ok := rowM["_id"].(bson.ObjectId)
+		_, ok := rowM["_id"].(primitive.ObjectID)
 		s.True(ok)
 
-		s.True(rowM["_id"].(bson.ObjectId).Valid())
+		s.False(rowM["_id"].(primitive.ObjectID).IsZero())
 
 		name, ok := rowM["name"].(string)
 		s.True(ok)
@@ -386,14 +438,14 @@ func (s *AdapterTests) TestResultFetch() {
 
 	// Testing struct
 	rowS := struct {
-		ID   bson.ObjectId `bson:"_id"`
-		Name string        `bson:"name"`
+		ID   primitive.ObjectID `bson:"_id"`
+		Name string             `bson:"name"`
 	}{}
 
 	res = artist.Find()
 
 	for res.Next(&rowS) {
-		s.True(rowS.ID.Valid())
+		s.False(rowS.ID.IsZero())
 		s.NotZero(rowS.Name)
 	}
 
@@ -402,14 +454,14 @@ func (s *AdapterTests) TestResultFetch() {
 
 	// Testing tagged struct
 	rowT := struct {
-		Value1 bson.ObjectId `bson:"_id"`
-		Value2 string        `bson:"name"`
+		Value1 primitive.ObjectID `bson:"_id"`
+		Value2 string             `bson:"name"`
 	}{}
 
 	res = artist.Find()
 
 	for res.Next(&rowT) {
-		s.True(rowT.Value1.Valid())
+		s.False(rowT.Value1.IsZero())
 		s.NotZero(rowT.Value2)
 	}
 
@@ -431,28 +483,28 @@ func (s *AdapterTests) TestResultFetch() {
 	res = artist.Find()
 
 	allRowsS := []struct {
-		ID   bson.ObjectId `bson:"_id"`
+		ID   primitive.ObjectID `bson:"_id"`
 		Name string
 	}{}
 	err = res.All(&allRowsS)
 	s.NoError(err)
 
 	for _, singleRowS := range allRowsS {
-		s.True(singleRowS.ID.Valid())
+		s.False(singleRowS.ID.IsZero())
 	}
 
 	// Testing Result.All() with a slice of tagged structs.
 	res = artist.Find()
 
 	allRowsT := []struct {
-		Value1 bson.ObjectId `bson:"_id"`
-		Value2 string        `bson:"name"`
+		Value1 primitive.ObjectID `bson:"_id"`
+		Value2 string             `bson:"name"`
 	}{}
 	err = res.All(&allRowsT)
 	s.NoError(err)
 
 	for _, singleRowT := range allRowsT {
-		s.True(singleRowT.Value1.Valid())
+		s.False(singleRowT.Value1.IsZero())
 	}
 }
 
@@ -469,7 +521,7 @@ func (s *AdapterTests) TestUpdate() {
 
 	// Value
 	value := struct {
-		ID   bson.ObjectId `bson:"_id"`
+		ID   primitive.ObjectID `bson:"_id"`
 		Name string
 	}{}
 
@@ -519,6 +571,223 @@ func (s *AdapterTests) TestUpdate() {
 	s.Equal(value.Name, rowT.Value1)
 }
 
+func (s *AdapterTests) TestValidator() {
+	sess, err := Open(settings)
+	s.NoError(err)
+
+	defer sess.Close()
+
+	validate, err := db.JSONSchema([]byte(`{
+		"type": "object",
+		"required": ["name"],
+		"properties": {
+			"name": {"type": "string", "minLength": 1}
+		}
+	}`))
+	s.NoError(err)
+
+	artist := sess.Collection("artist")
+	s.NoError(db.SetValidator(artist, validate))
+	defer func() {
+		s.NoError(db.SetValidator(artist, nil))
+	}()
+
+	_, err = artist.Insert(map[string]interface{}{"name": "Nirvana"})
+	s.NoError(err)
+
+	_, err = artist.Insert(map[string]interface{}{"name": ""})
+	s.True(errors.Is(err, db.ErrInvalidDocument))
+
+	_, err = artist.Insert(struct {
+		Other string `bson:"other"`
+	}{"Nirvana"})
+	s.True(errors.Is(err, db.ErrInvalidDocument))
+
+	// Required fields are not enforced on updates.
+	res := artist.Find(db.Cond{"name": "Nirvana"})
+
+	err = res.Update(map[string]interface{}{"other": "grunge"})
+	s.NoError(err)
+
+	err = res.Update(map[string]interface{}{"name": 1})
+	s.True(errors.Is(err, db.ErrInvalidDocument))
+
+	s.NoError(res.Delete())
+}
+
+func (s *AdapterTests) TestModify() {
+	sess, err := Open(settings)
+	s.NoError(err)
+
+	defer sess.Close()
+
+	artist := sess.Collection("artist")
+
+	_, err = artist.Insert(map[string]interface{}{"name": "Modify", "tmp": true})
+	s.NoError(err)
+
+	res := artist.Find(db.Cond{"name": "Modify"})
+
+	err = res.Modify(db.SetPath("prefs.theme", "dark"), db.Unset("tmp"), db.Push("tags", "x"))
+	s.NoError(err)
+
+	var item map[string]interface{}
+	err = res.One(&item)
+	s.NoError(err)
+
+	s.Equal(map[string]interface{}{"theme": "dark"}, item["prefs"])
+	s.Equal(bson.A{"x"}, item["tags"])
+	s.NotContains(item, "tmp")
+
+	s.NoError(res.Delete())
+}
+
+func (s *AdapterTests) TestTTL() {
+	sess, err := Open(settings)
+	s.NoError(err)
+
+	defer sess.Close()
+
+	type session struct {
+		Token     string     `db:"token" bson:"token"`
+		ExpiresAt *time.Time `db:"expires_at,ttl" bson:"expires_at"`
+	}
+
+	mc := sess.Driver().(*mongo.Client)
+	_ = mc.Database(settings.Database).Collection("sessions").Drop(context.Background())
+
+	sessions := sess.Collection("sessions")
+
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	for _, item := range []session{{"a", &past}, {"b", &future}, {"c", nil}} {
+		_, err = sessions.Insert(item)
+		s.NoError(err)
+	}
+
+	var items []session
+	err = sessions.Find().OrderBy("token").All(&items)
+	s.NoError(err)
+	s.Len(items, 2)
+	s.Equal("b", items[0].Token)
+	s.Equal("c", items[1].Token)
+
+	cur, err := mc.Database(settings.Database).Collection("sessions").Indexes().List(context.Background())
+	s.NoError(err)
+
+	var indexes []struct {
+		Key         bson.D `bson:"key"`
+		ExpireAfter int32  `bson:"expireAfterSeconds"`
+	}
+	s.NoError(cur.All(context.Background(), &indexes))
+
+	hasTTL := false
+	for _, index := range indexes {
+		if len(index.Key) == 1 && index.Key[0].Key == "expires_at" {
+			hasTTL = index.ExpireAfter > 0
+		}
+	}
+	s.True(hasTTL)
+
+	s.NoError(db.PurgeExpired(sessions, "expires_at"))
+
+	count, err := sessions.Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(2), count)
+}
+
+func (s *AdapterTests) TestCapped() {
+	sess, err := Open(settings)
+	s.NoError(err)
+
+	defer sess.Close()
+
+	events := sess.Driver().(*mongo.Client).Database(settings.Database).Collection("events")
+	_ = events.Drop(context.Background())
+
+	capped, err := db.Capped(sess.Collection("events"), db.CappedOptions{MaxItems: 3})
+	s.NoError(err)
+
+	for i := 0; i < 5; i++ {
+		_, err = capped.Insert(map[string]interface{}{"n": i})
+		s.NoError(err)
+	}
+
+	var items []map[string]interface{}
+	err = capped.Find().All(&items)
+	s.NoError(err)
+	s.Len(items, 3)
+	s.Equal(int32(2), items[0]["n"])
+
+	// Collections that exist and are not capped need an order column.
+	_ = events.Drop(context.Background())
+	_, err = sess.Collection("events").Insert(map[string]interface{}{"n": 0})
+	s.NoError(err)
+
+	_, err = db.Capped(sess.Collection("events"), db.CappedOptions{MaxItems: 3})
+	s.Error(err)
+
+	capped, err = db.Capped(sess.Collection("events"), db.CappedOptions{MaxItems: 3, OrderColumn: "_id"})
+	s.NoError(err)
+
+	for i := 1; i < 5; i++ {
+		_, err = capped.Insert(map[string]interface{}{"n": i})
+		s.NoError(err)
+	}
+
+	count, err := capped.Count()
+	s.NoError(err)
+	s.Equal(uint64(3), count)
+}
+
+func (s *AdapterTests) TestInto() {
+	sess, err := Open(settings)
+	s.NoError(err)
+
+	defer sess.Close()
+
+	artist := sess.Collection("artist")
+
+	_, err = artist.Insert(map[string]interface{}{"name": "Into"})
+	s.NoError(err)
+
+	report, err := artist.Find(db.Cond{"name": "Into"}).Into("artist_report")
+	s.NoError(err)
+
+	defer func() {
+		report := sess.Driver().(*mongo.Client).Database(settings.Database).Collection("artist_report")
+		s.NoError(report.Drop(context.Background()))
+	}()
+
+	count, err := report.Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(1), count)
+
+	s.NoError(artist.Find(db.Cond{"name": "Into"}).Delete())
+}
+
+func (s *AdapterTests) TestExistingIDs() {
+	sess, err := Open(settings)
+	s.NoError(err)
+
+	defer sess.Close()
+
+	artist := sess.Collection("artist")
+
+	res, err := artist.Insert(map[string]interface{}{"name": "Existing"})
+	s.NoError(err)
+
+	missing := primitive.NewObjectID()
+
+	exists, err := artist.ExistingIDs([]interface{}{res.ID(), missing})
+	s.NoError(err)
+	s.Len(exists, 2)
+	s.True(exists[res.ID()])
+	s.False(exists[missing])
+
+	s.NoError(artist.Find(db.Cond{"_id": res.ID()}).Delete())
+}
+
 func (s *AdapterTests) TestOperators() {
 	// Opening database.
 	sess, err := Open(settings)
@@ -559,7 +828,7 @@ func (s *AdapterTests) TestDelete() {
 	res := artist.Find(db.Cond{"_id": db.NotEq(nil)}).Limit(1)
 
 	var first struct {
-		ID bson.ObjectId `bson:"_id"`
+		ID primitive.ObjectID `bson:"_id"`
 	}
 
 	err = res.One(&first)
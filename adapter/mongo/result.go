@@ -22,9 +22,12 @@
 package mongo
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"math"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
@@ -32,11 +35,15 @@ import (
 	"encoding/json"
 
 	db "github.com/upper/db/v4"
-	mgo "gopkg.in/mgo.v2"
-	"gopkg.in/mgo.v2/bson"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 
 	"github.com/upper/db/v4/internal/immutable"
 	"github.com/upper/db/v4/internal/sqladapter"
+	"github.com/upper/db/v4/internal/sqlbuilder"
 )
 
 type resultQuery struct {
@@ -48,6 +55,9 @@ type resultQuery struct {
 	sort       []string
 	conditions interface{}
 	groupBy    []interface{}
+	joins      bool
+	lock       bool
+	readMode   *readpref.Mode
 
 	pageSize           uint
 	pageNumber         uint
@@ -55,10 +65,15 @@ type resultQuery struct {
 	cursorValue        interface{}
 	cursorCond         db.Cond
 	cursorReverseOrder bool
+
+	ttlColumn string
 }
 
 type result struct {
-	iter  *mgo.Iter
+	iter    *mongo.Cursor
+	iterCtx context.Context
+	iterMu  sync.Mutex
+
 	err   error
 	errMu sync.Mutex
 
@@ -68,6 +83,18 @@ type result struct {
 
 var _ = immutable.Immutable(&result{})
 
+// readModes maps read preferences to the modes of the driver, which has no
+// monotonic and eventual modes: they're served by the closest modes instead.
+var readModes = map[db.ReadPreference]readpref.Mode{
+	db.ReadPrimary:            readpref.PrimaryMode,
+	db.ReadPrimaryPreferred:   readpref.PrimaryPreferredMode,
+	db.ReadSecondary:          readpref.SecondaryMode,
+	db.ReadSecondaryPreferred: readpref.SecondaryPreferredMode,
+	db.ReadNearest:            readpref.NearestMode,
+	db.ReadMonotonic:          readpref.PrimaryPreferredMode,
+	db.ReadEventual:           readpref.NearestMode,
+}
+
 func (res *result) frame(fn func(*resultQuery) error) *result {
 	return &result{prev: res, fn: fn}
 }
@@ -77,17 +104,29 @@ func (r *resultQuery) and(terms ...interface{}) error {
 		return r.where(terms...)
 	}
 
+	conditions, err := r.c.compileQuery(terms...)
+	if err != nil {
+		return err
+	}
+	if conditions == nil {
+		return nil
+	}
+
 	r.conditions = map[string]interface{}{
 		"$and": []interface{}{
 			r.conditions,
-			r.c.compileQuery(terms...),
+			conditions,
 		},
 	}
 	return nil
 }
 
 func (r *resultQuery) where(terms ...interface{}) error {
-	r.conditions = r.c.compileQuery(terms...)
+	conditions, err := r.c.compileQuery(terms...)
+	if err != nil {
+		return err
+	}
+	r.conditions = conditions
 	return nil
 }
 
@@ -97,6 +136,19 @@ func (res *result) And(terms ...interface{}) db.Result {
 	})
 }
 
+// unexpired returns a result set that skips expired documents when dst has a
+// field with the ttl tag option.
+func (res *result) unexpired(dst interface{}) *result {
+	column, ok := sqlbuilder.TTLColumn(dst)
+	if !ok {
+		return res
+	}
+	return res.frame(func(r *resultQuery) error {
+		r.ttlColumn = column
+		return nil
+	})
+}
+
 func (res *result) Where(terms ...interface{}) db.Result {
 	return res.frame(func(r *resultQuery) error {
 		return r.where(terms...)
@@ -186,6 +238,34 @@ func (res *result) Offset(n int) db.Result {
 	})
 }
 
+// ForUpdate is not supported by the MongoDB adapter.
+func (res *result) ForUpdate(...db.LockOption) db.Result {
+	return res.frame(func(r *resultQuery) error {
+		r.lock = true
+		return nil
+	})
+}
+
+// ForShare is not supported by the MongoDB adapter.
+func (res *result) ForShare(...db.LockOption) db.Result {
+	return res.frame(func(r *resultQuery) error {
+		r.lock = true
+		return nil
+	})
+}
+
+// ReadPreference sets the consistency mode used to read the matching items.
+func (res *result) ReadPreference(pref db.ReadPreference) db.Result {
+	return res.frame(func(r *resultQuery) error {
+		mode, ok := readModes[pref]
+		if !ok {
+			return fmt.Errorf("%w: read preference %d", db.ErrUnsupported, pref)
+		}
+		r.readMode = &mode
+		return nil
+	})
+}
+
 // OrderBy determines sorting of results according to the provided names. Fields
 // may be prefixed by - (minus) which means descending order, ascending order
 // would be used otherwise.
@@ -219,12 +299,7 @@ func (res *result) Select(fields ...interface{}) db.Result {
 
 // All dumps all results into a pointer to an slice of structs or maps.
 func (res *result) All(dst interface{}) error {
-	rq, err := res.build()
-	if err != nil {
-		return err
-	}
-
-	q, err := rq.query()
+	rq, err := res.unexpired(dst).build()
 	if err != nil {
 		return err
 	}
@@ -238,13 +313,24 @@ func (res *result) All(dst interface{}) error {
 		})
 	}(time.Now())
 
-	err = q.All(dst)
-	if errors.Is(err, mgo.ErrNotFound) {
-		return db.ErrNoMoreRows
+	cur, err := rq.find()
+	if err != nil {
+		return err
 	}
+	err = cur.All(rq.c.parent.ctx, dst)
 	return err
 }
 
+// Scanner is not supported by MongoDB.
+func (res *result) Scanner(column string) (io.ReadCloser, error) {
+	return nil, db.ErrUnsupported
+}
+
+// AllColumns is not supported by MongoDB.
+func (res *result) AllColumns(dst interface{}) error {
+	return db.ErrUnsupported
+}
+
 // GroupBy is used to group results that have the same value in the same column
 // or columns.
 func (res *result) GroupBy(fields ...interface{}) db.Result {
@@ -254,16 +340,63 @@ func (res *result) GroupBy(fields ...interface{}) db.Result {
 	})
 }
 
+// Having is not supported by MongoDB.
+func (res *result) Having(...interface{}) db.Result {
+	return res.frame(func(r *resultQuery) error {
+		return fmt.Errorf("%w: HAVING clauses", db.ErrUnsupported)
+	})
+}
+
+func (res *result) join() db.Result {
+	return res.frame(func(r *resultQuery) error {
+		r.joins = true
+		return nil
+	})
+}
+
+// Join is not supported by the MongoDB adapter.
+func (res *result) Join(...interface{}) db.Result {
+	return res.join()
+}
+
+// InnerJoin is not supported by the MongoDB adapter.
+func (res *result) InnerJoin(...interface{}) db.Result {
+	return res.join()
+}
+
+// LeftJoin is not supported by the MongoDB adapter.
+func (res *result) LeftJoin(...interface{}) db.Result {
+	return res.join()
+}
+
+// RightJoin is not supported by the MongoDB adapter.
+func (res *result) RightJoin(...interface{}) db.Result {
+	return res.join()
+}
+
+// FullJoin is not supported by the MongoDB adapter.
+func (res *result) FullJoin(...interface{}) db.Result {
+	return res.join()
+}
+
+// On is not supported by the MongoDB adapter.
+func (res *result) On(...interface{}) db.Result {
+	return res.join()
+}
+
+// Using is not supported by the MongoDB adapter.
+func (res *result) Using(...interface{}) db.Result {
+	return res.join()
+}
+
 // One fetches only one result from the resultset.
 func (res *result) One(dst interface{}) error {
-	rq, err := res.build()
+	rq, err := res.unexpired(dst).build()
 	if err != nil {
 		return err
 	}
-
-	q, err := rq.query()
-	if err != nil {
-		return err
+	if rq.limit == 0 {
+		rq.limit = 1
 	}
 
 	defer func(start time.Time) {
@@ -275,10 +408,19 @@ func (res *result) One(dst interface{}) error {
 		})
 	}(time.Now())
 
-	err = q.One(dst)
-	if errors.Is(err, mgo.ErrNotFound) {
+	cur, err := rq.find()
+	if err != nil {
+		return err
+	}
+	defer cur.Close(rq.c.parent.ctx)
+
+	if !cur.Next(rq.c.parent.ctx) {
+		if err = cur.Err(); err != nil {
+			return err
+		}
 		return db.ErrNoMoreRows
 	}
+	err = cur.Decode(dst)
 	return err
 }
 
@@ -296,13 +438,11 @@ func (res *result) setErr(err error) {
 }
 
 func (res *result) Next(dst interface{}) bool {
-	if res.iter == nil {
-		rq, err := res.build()
-		if err != nil {
-			return false
-		}
+	res.iterMu.Lock()
+	defer res.iterMu.Unlock()
 
-		q, err := rq.query()
+	if res.iter == nil {
+		rq, err := res.unexpired(dst).build()
 		if err != nil {
 			return false
 		}
@@ -316,22 +456,155 @@ func (res *result) Next(dst interface{}) bool {
 			})
 		}(time.Now())
 
-		res.iter = q.Iter()
+		if res.iter, err = rq.find(); err != nil {
+			res.setErr(err)
+			return false
+		}
+		res.iterCtx = rq.c.parent.ctx
 	}
 
-	if !res.iter.Next(dst) {
+	if !res.iter.Next(res.iterCtx) {
 		res.setErr(res.iter.Err())
 		return false
 	}
+	if err := res.iter.Decode(dst); err != nil {
+		res.setErr(err)
+		return false
+	}
 
 	return true
 }
 
+// NextContext is like Next but it closes the result set and stops as soon as
+// the given context is done.
+func (res *result) NextContext(ctx context.Context, dst interface{}) bool {
+	if err := ctx.Err(); err != nil {
+		_ = res.Close()
+		res.setErr(err)
+		return false
+	}
+	return res.Next(dst)
+}
+
+// Iterate returns a function that streams the matching items into dst, each
+// call of the function uses its own cursor.
+func (res *result) Iterate(dst interface{}) func(yield func(int, error) bool) {
+	return func(yield func(int, error) bool) {
+		rq, err := res.unexpired(dst).build()
+		if err != nil {
+			yield(0, err)
+			return
+		}
+		ctx := rq.c.parent.ctx
+
+		start := time.Now()
+		iter, err := rq.find()
+
+		queryLog(&sqladapter.QueryStatus{
+			Query: rq.debugQuery("Find.Iter"),
+			Err:   err,
+			Start: start,
+			End:   time.Now(),
+		})
+
+		if err != nil {
+			yield(0, err)
+			return
+		}
+		defer iter.Close(ctx)
+
+		i := 0
+		for ; iter.Next(ctx); i++ {
+			if err := iter.Decode(dst); err != nil {
+				res.setErr(err)
+				yield(i, err)
+				return
+			}
+			if !yield(i, nil) {
+				return
+			}
+		}
+
+		if err := iter.Err(); err != nil {
+			res.setErr(err)
+			yield(i, err)
+		}
+	}
+}
+
+// Batches returns a function that loads the matching documents into dst in
+// batches of at most size documents, paging by the cursor field or by _id.
+func (res *result) Batches(size uint, dst interface{}) func(yield func(int, error) bool) {
+	return func(yield func(int, error) bool) {
+		if size == 0 {
+			yield(0, fmt.Errorf("upper: batch size must be greater than zero"))
+			return
+		}
+		dstv := reflect.ValueOf(dst)
+		if dstv.Kind() != reflect.Ptr || dstv.IsNil() || dstv.Elem().Kind() != reflect.Slice {
+			yield(0, db.ErrUnsupportedDestination)
+			return
+		}
+
+		rq, err := res.build()
+		if err != nil {
+			yield(0, err)
+			return
+		}
+		field := rq.cursorColumn
+		if field == "" {
+			field = "_id"
+		}
+
+		slicev := dstv.Elem()
+		page := res.Paginate(size).Cursor(field)
+		for i := 0; ; i++ {
+			if err := page.All(dst); err != nil {
+				yield(i, err)
+				return
+			}
+			n := slicev.Len()
+			if n == 0 {
+				return
+			}
+			if !yield(i, nil) || n < int(size) {
+				return
+			}
+
+			var last bson.M
+			raw, err := bson.Marshal(slicev.Index(n - 1).Interface())
+			if err == nil {
+				err = bson.Unmarshal(raw, &last)
+			}
+			if err != nil {
+				res.setErr(err)
+				yield(i+1, err)
+				return
+			}
+			value, ok := last[field]
+			if !ok {
+				err := fmt.Errorf("upper: batch documents have no value for cursor field %q", field)
+				res.setErr(err)
+				yield(i+1, err)
+				return
+			}
+			page = res.Paginate(size).Cursor(field).NextPage(value)
+		}
+	}
+}
+
 // Delete remove the matching items from the collection.
 func (res *result) Delete() error {
+	_, err := res.DeleteCount()
+	return err
+}
+
+// DeleteCount removes all matching items from the collection and returns the
+// number of removed items.
+func (res *result) DeleteCount() (count uint64, err error) {
 	rq, err := res.build()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	defer func(start time.Time) {
@@ -343,19 +616,128 @@ func (res *result) Delete() error {
 		})
 	}(time.Now())
 
-	_, err = rq.c.collection.RemoveAll(rq.conditions)
+	info, err := rq.c.collection.DeleteMany(rq.c.parent.ctx, rq.filter())
 	if err != nil {
+		return 0, err
+	}
+
+	return uint64(info.DeletedCount), nil
+}
+
+// DeleteReturning removes the matching documents and stores them into dst. A
+// single document is found and removed atomically, a slice of documents is
+// removed by _id after being read.
+func (res *result) DeleteReturning(dst interface{}) (err error) {
+	return res.applyReturning("Remove", nil, dst)
+}
+
+// UpdateReturning modifies the matching documents with the values of the
+// given map or struct and stores the updated documents into dst, see
+// DeleteReturning.
+func (res *result) UpdateReturning(src interface{}, dst interface{}) (err error) {
+	rq, err := res.build()
+	if err != nil {
+		return err
+	}
+	if err = rq.c.validate(src, true); err != nil {
 		return err
 	}
+	update := map[string]interface{}{"$set": src}
+	return res.applyReturning("Update", update, dst)
+}
 
-	return nil
+// applyReturning applies update to the matching documents, or removes them if
+// update is nil, and stores the resulting documents into dst.
+func (res *result) applyReturning(action string, update interface{}, dst interface{}) (err error) {
+	rq, err := res.build()
+	if err != nil {
+		return err
+	}
+
+	dstv := reflect.ValueOf(dst)
+	if dstv.Kind() != reflect.Ptr || dstv.IsNil() {
+		return db.ErrUnsupportedDestination
+	}
+	many := dstv.Elem().Kind() == reflect.Slice
+
+	if !many && rq.limit == 0 {
+		rq.limit = 1
+	}
+	if len(rq.groupBy) > 0 {
+		return db.ErrUnsupported
+	}
+	ctx := rq.c.parent.ctx
+
+	defer func(start time.Time) {
+		queryLog(&sqladapter.QueryStatus{
+			Query: rq.debugQuery(action),
+			Err:   err,
+			Start: start,
+			End:   time.Now(),
+		})
+	}(time.Now())
+
+	if !many {
+		var single *mongo.SingleResult
+		if update == nil {
+			opts := options.FindOneAndDelete().SetSort(rq.sortDocument())
+			single = rq.c.collection.FindOneAndDelete(ctx, rq.filter(), opts)
+		} else {
+			opts := options.FindOneAndUpdate().
+				SetSort(rq.sortDocument()).
+				SetReturnDocument(options.After)
+			single = rq.c.collection.FindOneAndUpdate(ctx, rq.filter(), update, opts)
+		}
+		if err = single.Decode(dst); errors.Is(err, mongo.ErrNoDocuments) {
+			return db.ErrNoMoreRows
+		}
+		return err
+	}
+
+	rq.fields = []string{"_id"}
+	cur, err := rq.find()
+	if err != nil {
+		return err
+	}
+	var items []bson.M
+	if err = cur.All(ctx, &items); err != nil {
+		return err
+	}
+	ids := make([]interface{}, len(items))
+	for i := range items {
+		ids[i] = items[i]["_id"]
+	}
+	byID := bson.M{"_id": bson.M{"$in": ids}}
+
+	all := func() error {
+		cur, err := rq.c.collection.Find(ctx, byID)
+		if err != nil {
+			return err
+		}
+		return cur.All(ctx, dst)
+	}
+
+	if update == nil {
+		if err = all(); err != nil {
+			return err
+		}
+		_, err = rq.c.collection.DeleteMany(ctx, byID)
+		return err
+	}
+	if _, err = rq.c.collection.UpdateMany(ctx, byID, update); err != nil {
+		return err
+	}
+	return all()
 }
 
 // Close closes the result set.
 func (r *result) Close() error {
+	r.iterMu.Lock()
+	defer r.iterMu.Unlock()
+
 	var err error
 	if r.iter != nil {
-		err = r.iter.Close()
+		err = r.iter.Close(r.iterCtx)
 		r.iter = nil
 	}
 	return err
@@ -364,11 +746,22 @@ func (r *result) Close() error {
 // Update modified matching items from the collection with values of the given
 // map or struct.
 func (res *result) Update(src interface{}) (err error) {
+	_, err = res.UpdateCount(src)
+	return err
+}
+
+// UpdateCount modifies matching items from the collection with values of the
+// given map or struct and returns the number of modified items.
+func (res *result) UpdateCount(src interface{}) (count uint64, err error) {
 	updateSet := map[string]interface{}{"$set": src}
 
 	rq, err := res.build()
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	if err = rq.c.validate(src, true); err != nil {
+		return 0, err
 	}
 
 	defer func(start time.Time) {
@@ -380,11 +773,113 @@ func (res *result) Update(src interface{}) (err error) {
 		})
 	}(time.Now())
 
-	_, err = rq.c.collection.UpdateAll(rq.conditions, updateSet)
+	info, err := rq.c.collection.UpdateMany(rq.c.parent.ctx, rq.filter(), updateSet)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(info.MatchedCount), nil
+}
+
+// Modify applies the given modifiers to all the documents in the result set
+// with the $set, $unset and $push operators.
+func (res *result) Modify(mods ...*db.Modifier) (err error) {
+	if len(mods) == 0 {
+		return nil
+	}
+
+	set, unset, push := bson.M{}, bson.M{}, bson.M{}
+	for _, mod := range mods {
+		switch mod.Op {
+		case db.ModifierSet:
+			set[mod.Path] = mod.Value
+		case db.ModifierUnset:
+			unset[mod.Path] = ""
+		case db.ModifierPush:
+			push[mod.Path] = mod.Value
+		default:
+			return fmt.Errorf("%w: modifier %d", db.ErrUnsupported, mod.Op)
+		}
+	}
+
+	update := bson.M{}
+	for op, fields := range map[string]bson.M{"$set": set, "$unset": unset, "$push": push} {
+		if len(fields) > 0 {
+			update[op] = fields
+		}
+	}
+
+	rq, err := res.build()
 	if err != nil {
 		return err
 	}
-	return nil
+
+	if len(set) > 0 {
+		if err = rq.c.validate(map[string]interface{}(set), true); err != nil {
+			return err
+		}
+	}
+
+	defer func(start time.Time) {
+		queryLog(&sqladapter.QueryStatus{
+			Query: rq.debugQuery("Modify"),
+			Err:   err,
+			Start: start,
+			End:   time.Now(),
+		})
+	}(time.Now())
+
+	_, err = rq.c.collection.UpdateMany(rq.c.parent.ctx, rq.filter(), update)
+	return err
+}
+
+// Compile is not supported by the MongoDB adapter.
+func (res *result) Compile() (string, []interface{}, error) {
+	return "", nil, db.ErrUnsupported
+}
+
+// CompileUpdate is not supported by the MongoDB adapter.
+func (res *result) CompileUpdate(interface{}) (string, []interface{}, error) {
+	return "", nil, db.ErrUnsupported
+}
+
+// CompileDelete is not supported by the MongoDB adapter.
+func (res *result) CompileDelete() (string, []interface{}, error) {
+	return "", nil, db.ErrUnsupported
+}
+
+// Into stores the documents of the result set into the collection with the
+// given name using an aggregation with a $out stage, which replaces the
+// collection if it already exists.
+func (res *result) Into(name string) (db.Collection, error) {
+	rq, err := res.build()
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline, err := rq.pipeline()
+	if err != nil {
+		return nil, err
+	}
+	pipeline = append(pipeline, bson.M{"$out": name})
+
+	defer func(start time.Time) {
+		queryLog(&sqladapter.QueryStatus{
+			Query: rq.debugQuery("Into"),
+			Err:   err,
+			Start: start,
+			End:   time.Now(),
+		})
+	}(time.Now())
+
+	cur, err := rq.c.collection.Aggregate(rq.c.parent.ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	if err = cur.Close(rq.c.parent.ctx); err != nil {
+		return nil, err
+	}
+
+	return rq.c.parent.Collection(name), nil
 }
 
 func (res *result) build() (*resultQuery, error) {
@@ -394,12 +889,34 @@ func (res *result) build() (*resultQuery, error) {
 	}
 
 	rq := rqi.(*resultQuery)
+	if rq.joins {
+		return nil, db.ErrUnsupported
+	}
+	if rq.lock {
+		return nil, fmt.Errorf("%w: row locking", db.ErrUnsupported)
+	}
+
 	if !rq.cursorCond.Empty() {
 		if err := rq.and(rq.cursorCond); err != nil {
 			return nil, err
 		}
 	}
 
+	ttlColumn := rq.ttlColumn
+	if ttlColumn == "" && rq.c != nil {
+		ttlColumn = rq.c.ttlField
+	}
+	if ttlColumn != "" {
+		unexpired := unexpiredDocuments(ttlColumn)
+		if rq.conditions == nil {
+			rq.conditions = unexpired
+		} else {
+			rq.conditions = map[string]interface{}{
+				"$and": []interface{}{rq.conditions, unexpired},
+			}
+		}
+	}
+
 	if rq.cursorColumn != "" {
 		if rq.cursorReverseOrder {
 			rq.sort = append(rq.sort, "-"+rq.cursorColumn)
@@ -410,62 +927,132 @@ func (res *result) build() (*resultQuery, error) {
 	return rq, nil
 }
 
-// query executes a mgo query.
-func (r *resultQuery) query() (*mgo.Query, error) {
+// collection returns the collection to read from, with the read preference
+// of the query if one was set.
+func (r *resultQuery) collection() *mongo.Collection {
+	if r.readMode == nil {
+		return r.c.collection
+	}
+	pref, err := readpref.New(*r.readMode)
+	if err != nil {
+		return r.c.collection
+	}
+	col, err := r.c.collection.Clone(options.Collection().SetReadPreference(pref))
+	if err != nil {
+		return r.c.collection
+	}
+	return col
+}
+
+// filter returns the query document that matches the documents of the query.
+func (r *resultQuery) filter() interface{} {
+	if r.conditions == nil {
+		return bson.M{}
+	}
+	return r.conditions
+}
+
+// sortDocument returns the sort order of the query.
+func (r *resultQuery) sortDocument() bson.D {
+	sort := bson.D{}
+	for _, field := range r.sort {
+		if strings.HasPrefix(field, "-") {
+			sort = append(sort, bson.E{Key: field[1:], Value: -1})
+			continue
+		}
+		sort = append(sort, bson.E{Key: field, Value: 1})
+	}
+	return sort
+}
+
+// projection returns the fields selected by the query, or nil if every field
+// is selected.
+func (r *resultQuery) projection() bson.M {
+	selectedFields := bson.M{}
+	for _, field := range r.fields {
+		if field == `*` {
+			break
+		}
+		selectedFields[field] = true
+	}
+	if len(selectedFields) == 0 {
+		return nil
+	}
+	return selectedFields
+}
+
+// find runs the query and returns a cursor over the matching documents.
+func (r *resultQuery) find() (*mongo.Cursor, error) {
 	if len(r.groupBy) > 0 {
 		return nil, db.ErrUnsupported
 	}
-
-	q := r.c.collection.Find(r.conditions)
+	ctx := r.c.parent.ctx
 
 	if r.pageSize > 0 {
 		r.offset = int(r.pageSize * r.pageNumber)
 		r.limit = int(r.pageSize)
 	}
 
+	opts := options.Find()
 	if r.offset > 0 {
-		q.Skip(r.offset)
+		opts.SetSkip(int64(r.offset))
 	}
-
 	if r.limit > 0 {
-		q.Limit(r.limit)
+		opts.SetLimit(int64(r.limit))
 	}
-
 	if len(r.sort) > 0 {
-		q.Sort(r.sort...)
-	}
-
-	selectedFields := bson.M{}
-	if len(r.fields) > 0 {
-		for _, field := range r.fields {
-			if field == `*` {
-				break
-			}
-			selectedFields[field] = true
-		}
+		opts.SetSort(r.sortDocument())
 	}
 
+	filter := r.filter()
 	if r.cursorReverseOrder {
-		ids := make([]bson.ObjectId, 0, r.limit)
-
-		iter := q.Select(bson.M{"_id": true}).Iter()
-		defer iter.Close()
+		cur, err := r.collection().Find(ctx, filter, opts.SetProjection(bson.M{"_id": true}))
+		if err != nil {
+			return nil, err
+		}
+		var items []bson.M
+		if err := cur.All(ctx, &items); err != nil {
+			return nil, err
+		}
 
-		var item map[string]bson.ObjectId
-		for iter.Next(&item) {
-			ids = append(ids, item["_id"])
+		ids := make([]interface{}, 0, len(items))
+		for i := range items {
+			ids = append(ids, items[i]["_id"])
 		}
 
 		r.conditions = bson.M{"_id": bson.M{"$in": ids}}
 
-		q = r.c.collection.Find(r.conditions)
+		filter = r.conditions
+		opts = options.Find()
+	}
+
+	if fields := r.projection(); fields != nil {
+		opts.SetProjection(fields)
 	}
 
-	if len(selectedFields) > 0 {
-		q.Select(selectedFields)
+	return r.collection().Find(ctx, filter, opts)
+}
+
+// First stores the first matching document into dst, documents are sorted by
+// _id if no order was given.
+func (res *result) First(dst interface{}) error {
+	rq, err := res.build()
+	if err != nil {
+		return err
+	}
+	if len(rq.sort) > 0 {
+		return res.Limit(1).One(dst)
 	}
+	return res.OrderBy("_id").Limit(1).One(dst)
+}
 
-	return q, nil
+// Last stores the document with the greatest value of the given field into
+// dst, or the one with the greatest _id if field is empty.
+func (res *result) Last(dst interface{}, field string) error {
+	if field == "" {
+		field = "_id"
+	}
+	return res.OrderBy("-" + field).Limit(1).One(dst)
 }
 
 func (res *result) Exists() (bool, error) {
@@ -495,14 +1082,83 @@ func (res *result) Count() (total uint64, err error) {
 		})
 	}(time.Now())
 
-	q := rq.c.collection.Find(rq.conditions)
-
-	var c int
-	c, err = q.Count()
+	var c int64
+	c, err = rq.collection().CountDocuments(rq.c.parent.ctx, rq.filter())
 
 	return uint64(c), err
 }
 
+// aggregate groups the documents that match the conditions of the result set,
+// after passing them through the given stages, and stores the value of the
+// accumulator into dst.
+func (res *result) aggregate(stages []bson.M, accumulator bson.M, dst interface{}) error {
+	rq, err := res.build()
+	if err != nil {
+		return err
+	}
+
+	pipeline := append([]bson.M{{"$match": rq.filter()}}, stages...)
+	pipeline = append(pipeline, bson.M{"$group": bson.M{"_id": nil, "v": accumulator}})
+
+	var out struct {
+		V bson.RawValue `bson:"v"`
+	}
+	if err := rq.c.Aggregate(pipeline).One(&out); err != nil {
+		return err
+	}
+	if out.V.Type == 0x00 || out.V.Type == bsontype.Null {
+		return db.ErrNoMoreRows
+	}
+	return out.V.Unmarshal(dst)
+}
+
+func (res *result) aggregateFloat(accumulator bson.M) (float64, error) {
+	var value float64
+	if err := res.aggregate(nil, accumulator, &value); err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return value, nil
+}
+
+// Sum returns the sum of the values of the given field.
+func (res *result) Sum(field string) (float64, error) {
+	return res.aggregateFloat(bson.M{"$sum": "$" + field})
+}
+
+// Avg returns the average of the values of the given field.
+func (res *result) Avg(field string) (float64, error) {
+	return res.aggregateFloat(bson.M{"$avg": "$" + field})
+}
+
+// Min stores the smallest value of the given field into dst.
+func (res *result) Min(field string, dst interface{}) error {
+	return res.aggregate(nil, bson.M{"$min": "$" + field}, dst)
+}
+
+// Max stores the largest value of the given field into dst.
+func (res *result) Max(field string, dst interface{}) error {
+	return res.aggregate(nil, bson.M{"$max": "$" + field}, dst)
+}
+
+// CountDistinct returns the number of distinct values of the given field.
+func (res *result) CountDistinct(field string) (uint64, error) {
+	stages := []bson.M{
+		{"$group": bson.M{"_id": "$" + field}},
+		{"$match": bson.M{"_id": bson.M{"$ne": nil}}},
+	}
+	var count int64
+	if err := res.aggregate(stages, bson.M{"$sum": 1}, &count); err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return uint64(count), nil
+}
+
 func (res *result) Prev() immutable.Immutable {
 	if res == nil {
 		return nil
@@ -521,8 +1177,40 @@ func (res *result) Base() interface{} {
 	return &resultQuery{}
 }
 
+// pipeline returns the aggregation stages that match the documents of the
+// query.
+func (r *resultQuery) pipeline() ([]bson.M, error) {
+	if len(r.groupBy) > 0 {
+		return nil, db.ErrUnsupported
+	}
+
+	pipeline := []bson.M{{"$match": r.filter()}}
+
+	if len(r.sort) > 0 {
+		pipeline = append(pipeline, bson.M{"$sort": r.sortDocument()})
+	}
+
+	offset, limit := r.offset, r.limit
+	if r.pageSize > 0 {
+		offset = int(r.pageSize * r.pageNumber)
+		limit = int(r.pageSize)
+	}
+	if offset > 0 {
+		pipeline = append(pipeline, bson.M{"$skip": offset})
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": limit})
+	}
+
+	if fields := r.projection(); fields != nil {
+		pipeline = append(pipeline, bson.M{"$project": fields})
+	}
+
+	return pipeline, nil
+}
+
 func (r *resultQuery) debugQuery(action string) string {
-	query := fmt.Sprintf("db.%s.%s", r.c.collection.Name, action)
+	query := fmt.Sprintf("db.%s.%s", r.c.Name(), action)
 
 	if r.conditions != nil {
 		query = fmt.Sprintf("%s.conds(%v)", query, r.conditions)
@@ -533,17 +1221,8 @@ func (r *resultQuery) debugQuery(action string) string {
 	if r.offset > 0 {
 		query = fmt.Sprintf("%s.offset(%d)", query, r.offset)
 	}
-	if len(r.fields) > 0 {
-		selectedFields := bson.M{}
-		for _, field := range r.fields {
-			if field == `*` {
-				break
-			}
-			selectedFields[field] = true
-		}
-		if len(selectedFields) > 0 {
-			query = fmt.Sprintf("%s.select(%v)", query, selectedFields)
-		}
+	if fields := r.projection(); fields != nil {
+		query = fmt.Sprintf("%s.select(%v)", query, fields)
 	}
 	if len(r.groupBy) > 0 {
 		escaped := make([]string, len(r.groupBy))
@@ -571,18 +1250,5 @@ func mustJSON(in interface{}) (out []byte) {
 }
 
 func queryLog(status *sqladapter.QueryStatus) {
-	diff := status.End.Sub(status.Start)
-
-	slowQuery := false
-	if diff >= time.Millisecond*100 {
-		status.Err = db.ErrWarnSlowQuery
-		slowQuery = true
-	}
-
-	if status.Err != nil || slowQuery {
-		db.LC().Warn(status)
-		return
-	}
-
-	db.LC().Debug(status)
+	db.LogQuery(nil, status.Event())
 }
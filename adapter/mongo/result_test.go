@@ -0,0 +1,61 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package mongo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	db "github.com/upper/db/v4"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func TestReadPreference(t *testing.T) {
+	res := (&result{}).ReadPreference(db.ReadSecondaryPreferred).OrderBy("name")
+
+	rq, err := res.(*result).build()
+	assert.NoError(t, err)
+	if assert.NotNil(t, rq.readMode) {
+		assert.Equal(t, readpref.SecondaryPreferredMode, *rq.readMode)
+	}
+
+	// The last preference wins.
+	rq, err = res.ReadPreference(db.ReadEventual).(*result).build()
+	assert.NoError(t, err)
+	if assert.NotNil(t, rq.readMode) {
+		assert.Equal(t, readpref.NearestMode, *rq.readMode)
+	}
+
+	// Other results are left untouched.
+	rq, err = (&result{}).OrderBy("name").(*result).build()
+	assert.NoError(t, err)
+	assert.Nil(t, rq.readMode)
+
+	for pref := db.ReadPrimary; pref <= db.ReadEventual; pref++ {
+		_, ok := readModes[pref]
+		assert.True(t, ok, "missing read mode for %v", pref)
+	}
+
+	_, err = (&result{}).ReadPreference(db.ReadPreference(0)).(*result).build()
+	assert.True(t, errors.Is(err, db.ErrUnsupported))
+}
@@ -22,6 +22,8 @@
 package mssql
 
 import (
+	"database/sql"
+
 	db "github.com/upper/db/v4"
 	"github.com/upper/db/v4/internal/sqladapter"
 	"github.com/upper/db/v4/internal/sqlbuilder"
@@ -111,3 +113,31 @@ func (adt *collectionAdapter) Insert(col sqladapter.Collection, item interface{}
 	// This was a compound key and no interface matched it, let's return a map.
 	return keyMap, nil
 }
+
+// Stats reads the statistics of the table from the partition stats of its
+// heap or clustered index and of its other indexes, sizes are computed from
+// the pages in use. LastAnalyzed is the date of the most recent update of any
+// statistics object of the table.
+func (*collectionAdapter) Stats(col sqladapter.Collection) (*db.CollectionStats, error) {
+	row, err := col.SQL().QueryRow(`
+		SELECT
+			COALESCE(SUM(CASE WHEN ps.index_id < 2 THEN ps.row_count ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN ps.index_id < 2 THEN ps.used_page_count ELSE 0 END), 0) * 8192,
+			COALESCE(SUM(CASE WHEN ps.index_id >= 2 THEN ps.used_page_count ELSE 0 END), 0) * 8192,
+			(SELECT MAX(STATS_DATE(st.object_id, st.stats_id)) FROM sys.stats st WHERE st.object_id = OBJECT_ID(?))
+		FROM sys.dm_db_partition_stats ps
+		WHERE ps.object_id = OBJECT_ID(?)`, col.Name(), col.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var stats db.CollectionStats
+	var lastAnalyzed sql.NullTime
+	if err := row.Scan(&stats.Rows, &stats.DataSize, &stats.IndexSize, &lastAnalyzed); err != nil {
+		return nil, err
+	}
+	if lastAnalyzed.Valid {
+		stats.LastAnalyzed = &lastAnalyzed.Time
+	}
+	return &stats, nil
+}
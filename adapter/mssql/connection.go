@@ -24,6 +24,8 @@ package mssql
 import (
 	"errors"
 	"net/url"
+
+	db "github.com/upper/db/v4"
 )
 
 // ConnectionURL implements a MSSQL connection struct.
@@ -51,7 +53,7 @@ func (c ConnectionURL) String() (s string) {
 
 	params := url.Values{}
 	for k, v := range c.Options {
-		if k == "instance" {
+		if k == "instance" || db.IsPoolOption(k) {
 			continue
 		}
 		params.Add(k, v)
@@ -73,6 +75,31 @@ func (c ConnectionURL) String() (s string) {
 	return u.String()
 }
 
+// PoolOptions returns the connection pool options, which are not passed to
+// the driver.
+func (c ConnectionURL) PoolOptions() map[string]string {
+	return db.PoolOptions(c.Options)
+}
+
+// Validate checks the connection settings before connecting.
+func (c ConnectionURL) Validate() error {
+	if c.Host != "" {
+		if err := db.ValidateAddress("Host", c.Host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Redacted returns the DSN with the password replaced by db.RedactedPassword,
+// it's safe to log.
+func (c ConnectionURL) Redacted() string {
+	if c.Password != "" {
+		c.Password = db.RedactedPassword
+	}
+	return c.String()
+}
+
 // ParseURL parses s into a ConnectionURL struct.
 func ParseURL(s string) (conn ConnectionURL, err error) {
 	var u *url.URL
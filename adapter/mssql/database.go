@@ -29,7 +29,7 @@ import (
 
 	"database/sql"
 
-	_ "github.com/denisenkom/go-mssqldb" // MSSQL driver
+	mssql "github.com/denisenkom/go-mssqldb" // MSSQL driver
 	db "github.com/upper/db/v4"
 	"github.com/upper/db/v4/internal/sqladapter"
 	"github.com/upper/db/v4/internal/sqladapter/exql"
@@ -77,10 +77,70 @@ func (*database) Err(err error) error {
 		if strings.Contains(s, `many connections`) {
 			return db.ErrTooManyClients
 		}
+		if msErr, ok := err.(mssql.Error); ok {
+			switch msErr.Number {
+			case 2601, 2627:
+				return db.NewConstraintError(db.ErrDuplicateKey, err)
+			case 547:
+				return db.NewConstraintError(db.ErrForeignKeyViolation, err)
+			case 515:
+				return db.NewConstraintError(db.ErrNotNullViolation, err)
+			case 1222:
+				return db.NewContentionError(db.ErrLockTimeout, err)
+			case 1205:
+				return db.NewContentionError(db.ErrDeadlock, err)
+			}
+		}
 	}
 	return err
 }
 
+// BlockedTransactions counts the requests that are blocked by another
+// session.
+func (*database) BlockedTransactions(sess sqladapter.Session) (int, error) {
+	row, err := sess.SQL().QueryRow(`SELECT COUNT(*) FROM sys.dm_exec_requests WHERE blocking_session_id <> 0`)
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	if err := row.Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (*database) Capabilities() db.Capability {
+	return db.CapabilityTransactions |
+		db.CapabilityReturning |
+		db.CapabilityCompositeKeys |
+		db.CapabilitySchemas |
+		db.CapabilitySavepoints
+}
+
+// ParseURL parses a connection URL of the adapter.
+func (*database) ParseURL(s string) (db.ConnectionURL, error) {
+	return ParseURL(s)
+}
+
+// SnapshotTransaction opens snapshots with the SNAPSHOT isolation level, which
+// must be enabled on the database with ALLOW_SNAPSHOT_ISOLATION. SQL Server
+// has no read-only transactions.
+func (*database) SnapshotTransaction() (*sql.TxOptions, string) {
+	return &sql.TxOptions{Isolation: sql.LevelSnapshot}, ""
+}
+
+// SavepointStatements returns the SQL Server statements for savepoints, which
+// can't be released.
+func (*database) SavepointStatements(name string) (string, string, string) {
+	return "SAVE TRANSACTION " + name, "", "ROLLBACK TRANSACTION " + name
+}
+
+// RecursiveWith returns the keyword that starts recursive common table
+// expressions.
+func (*database) RecursiveWith() string {
+	return "WITH"
+}
+
 func (*database) NewCollection() sqladapter.CollectionAdapter {
 	return &collectionAdapter{}
 }
@@ -105,7 +165,7 @@ func (*database) TableExists(sess sqladapter.Session, name string) error {
 	q := sess.SQL().
 		Select(`table_name`).
 		From(`information_schema.tables`).
-		Where(`table_schema`, sess.Name()).
+		Where(`table_catalog`, sess.Name()).
 		And(`table_name`, name)
 
 	iter := q.Iterator()
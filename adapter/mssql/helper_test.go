@@ -118,6 +118,13 @@ func (h *Helper) TearUp() error {
 			[value] INT
 		)`,
 
+		`DROP TABLE IF EXISTS bool_flags`,
+		`CREATE TABLE bool_flags (
+			name VARCHAR(60) PRIMARY KEY,
+			active CHAR(1),
+			verified CHAR(1)
+		)`,
+
 		`DROP TABLE IF EXISTS composite_keys`,
 		`CREATE TABLE composite_keys (
 			code VARCHAR(255) default '',
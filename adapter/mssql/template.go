@@ -111,6 +111,9 @@ const (
 
         {{if defined .Table}}
           FROM {{.Table | compile}}
+          {{if defined .Lock}}
+            {{.Lock | compile}}
+          {{end}}
         {{end}}
 
         {{.Joins | compile}}
@@ -121,12 +124,17 @@ const (
           {{.GroupBy | compile}}
         {{end}}
 
+        {{if defined .Having}}
+          {{.Having | compile}}
+        {{end}}
+
         {{.OrderBy | compile}}
 
     {{if or .Limit .Offset}}
       ) __q1)  __q0 WHERE rnum > {{if gt .Offset 0}}{{.Offset}}{{else}}0{{end}}
     {{end}}
   `
+	adapterLockLayout   = `WITH ({{if eq .Mode "SHARE"}}HOLDLOCK{{else}}UPDLOCK{{end}}, ROWLOCK{{if eq .Wait "NOWAIT"}}, NOWAIT{{else if .Wait}}, READPAST{{end}})`
 	adapterDeleteLayout = `
     DELETE
       FROM {{.Table | compile}}
@@ -176,10 +184,28 @@ const (
     DROP TABLE {{.Table | compile}}
   `
 
+	adapterCreateTableAsLayout = `
+    SELECT * INTO {{.Table | compile}} FROM ({{.Query | compile}}) AS q
+  `
+
 	adapterGroupByLayout = `
     {{if .GroupColumns}}
       GROUP BY {{.GroupColumns}}
     {{end}}
+  `
+
+	adapterHavingLayout = `
+    {{if .Conds}}
+      HAVING {{.Conds}}
+    {{end}}
+  `
+
+	adapterValuesTableLayout = `
+    (VALUES {{range $i, $row := .Rows}}{{if $i}}, {{end}}{{$row}}{{end}}) AS {{.Name}} ({{.Columns}})
+  `
+
+	adapterCallLayout = `
+    EXEC {{.Name}}{{range $i, $arg := .Arguments}}{{if $i}},{{end}} {{$arg.Value}}{{if $arg.Output}} OUTPUT{{end}}{{end}}
   `
 )
 
@@ -188,6 +214,7 @@ var template = &exql.Template{
 	IdentifierSeparator: adapterIdentifierSeparator,
 	IdentifierQuote:     adapterIdentifierQuote,
 	ValueSeparator:      adapterValueSeparator,
+	ValuesTableLayout:   adapterValuesTableLayout,
 	ValueQuote:          adapterValueQuote,
 	AndKeyword:          adapterAndKeyword,
 	OrKeyword:           adapterOrKeyword,
@@ -202,6 +229,7 @@ var template = &exql.Template{
 	SortByColumnLayout:  adapterSortByColumnLayout,
 	WhereLayout:         adapterWhereLayout,
 	JoinLayout:          adapterJoinLayout,
+	LockLayout:          adapterLockLayout,
 	OnLayout:            adapterOnLayout,
 	UsingLayout:         adapterUsingLayout,
 	OrderByLayout:       adapterOrderByLayout,
@@ -212,7 +240,10 @@ var template = &exql.Template{
 	TruncateLayout:      adapterTruncateLayout,
 	DropDatabaseLayout:  adapterDropDatabaseLayout,
 	DropTableLayout:     adapterDropTableLayout,
+	CreateTableAsLayout: adapterCreateTableAsLayout,
 	CountLayout:         adapterSelectCountLayout,
 	GroupByLayout:       adapterGroupByLayout,
+	HavingLayout:        adapterHavingLayout,
+	CallLayout:          adapterCallLayout,
 	Cache:               cache.NewCache(),
 }
@@ -0,0 +1,124 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	db "github.com/upper/db/v4"
+)
+
+// Lock is a named lock acquired with AdvisoryLock or TryAdvisoryLock.
+type Lock struct {
+	name string
+	conn *sql.Conn
+
+	unlockOnce sync.Once
+	unlockErr  error
+}
+
+// Name returns the name of the lock.
+func (l *Lock) Name() string {
+	return l.name
+}
+
+// Unlock releases the lock.
+func (l *Lock) Unlock() error {
+	l.unlockOnce.Do(func() {
+		defer l.conn.Close()
+
+		var released sql.NullInt64
+		err := l.conn.QueryRowContext(context.Background(), `SELECT RELEASE_LOCK(?)`, l.name).Scan(&released)
+		if err == nil && released.Int64 != 1 {
+			err = fmt.Errorf("named lock %q was not held", l.name)
+		}
+		l.unlockErr = err
+	})
+	return l.unlockErr
+}
+
+// AdvisoryLock acquires the named lock with GET_LOCK, waiting until it's
+// released by any other session that holds it or until the context of the
+// session is done:
+//
+//	lock, err := mysql.AdvisoryLock(sess, "reports")
+//	if err != nil {
+//		return err
+//	}
+//	defer lock.Unlock()
+//
+// The lock is held by a dedicated connection of the pool until it's unlocked.
+// Named locks are not bound to transactions, they can't be acquired with a
+// transaction session.
+func AdvisoryLock(sess db.Session, name string) (*Lock, error) {
+	lock, err := advisoryLock(sess, name, -1)
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil {
+		return nil, fmt.Errorf("could not acquire named lock %q", name)
+	}
+	return lock, nil
+}
+
+// TryAdvisoryLock is like AdvisoryLock but it does not wait for the lock to be
+// released, it returns a nil Lock if it's held by another session.
+func TryAdvisoryLock(sess db.Session, name string) (*Lock, error) {
+	return advisoryLock(sess, name, 0)
+}
+
+// advisoryLock calls GET_LOCK with the given timeout in seconds, a negative
+// timeout waits forever. It returns a nil Lock if the timeout expired.
+func advisoryLock(sess db.Session, name string, timeout int) (*Lock, error) {
+	driver, ok := sess.Driver().(*sql.DB)
+	if !ok {
+		return nil, fmt.Errorf("%w: advisory locks on %T", db.ErrUnsupported, sess.Driver())
+	}
+
+	ctx := sess.Context()
+
+	conn, err := driver.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// GET_LOCK returns 1 if the lock was acquired, 0 if the timeout expired
+	// and NULL on errors.
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, ?)`, name, timeout).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !acquired.Valid {
+		conn.Close()
+		return nil, fmt.Errorf("could not acquire named lock %q", name)
+	}
+	if acquired.Int64 != 1 {
+		conn.Close()
+		return nil, nil
+	}
+
+	return &Lock{name: name, conn: conn}, nil
+}
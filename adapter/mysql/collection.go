@@ -22,8 +22,14 @@
 package mysql
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
 	db "github.com/upper/db/v4"
 	"github.com/upper/db/v4/internal/sqladapter"
+	"github.com/upper/db/v4/internal/sqladapter/exql"
 	"github.com/upper/db/v4/internal/sqlbuilder"
 )
 
@@ -47,21 +53,25 @@ func (*collectionAdapter) Insert(col sqladapter.Collection, item interface{}) (i
 		return nil, err
 	}
 
-	lastID, err := res.LastInsertId()
-	if err == nil && len(pKey) <= 1 {
+	// LastInsertId() is zero (or fails) if there are no auto columns.
+	lastID, _ := res.LastInsertId()
+
+	if len(pKey) == 0 {
 		return lastID, nil
 	}
 
+	// Keys given by the item are returned as they are, LastInsertId() is only
+	// meaningful for the auto column.
 	keyMap := db.Cond{}
 	for i := range columnNames {
 		for j := 0; j < len(pKey); j++ {
-			if pKey[j] == columnNames[i] {
+			if pKey[j] == columnNames[i] && columnValues[i] != nil {
 				keyMap[pKey[j]] = columnValues[i]
 			}
 		}
 	}
 
-	// There was an auto column among primary keys, let's search for it.
+	// There was an auto column among primary keys, let's fill it in.
 	if lastID > 0 {
 		for j := 0; j < len(pKey); j++ {
 			if keyMap[pKey[j]] == nil {
@@ -70,5 +80,349 @@ func (*collectionAdapter) Insert(col sqladapter.Collection, item interface{}) (i
 		}
 	}
 
+	if len(pKey) == 1 {
+		return keyMap[pKey[0]], nil
+	}
 	return keyMap, nil
 }
+
+// InsertBatch inserts rows with a single INSERT and returns their IDs.
+// LastInsertId() returns the ID generated for the first row without a key,
+// the IDs of the rows after it are derived from it: InnoDB generates all the
+// IDs of a statement at once, auto_increment_increment apart. With the
+// interleaved lock mode (2), the default since MySQL 8.0, that doesn't hold
+// for statements that give the key of some of their rows, so those rows are
+// inserted with a statement of their own first.
+func (*collectionAdapter) InsertBatch(col sqladapter.Collection, columnNames []string, rows [][]interface{}) ([]interface{}, error) {
+	pKey := col.PrimaryKeys()
+	if len(pKey) == 0 {
+		return insertRows(col, pKey, columnNames, rows, 1)
+	}
+
+	ai := autoIncrementOf(col)
+	if ai.lockMode < 2 {
+		return insertRows(col, pKey, columnNames, rows, ai.increment)
+	}
+
+	var given, missing []int
+	for i := range rows {
+		if countMissingKeys(pKey, columnNames, rows[i:i+1]) > 0 {
+			missing = append(missing, i)
+		} else {
+			given = append(given, i)
+		}
+	}
+	if len(given) == 0 || len(missing) < 2 {
+		return insertRows(col, pKey, columnNames, rows, ai.increment)
+	}
+
+	ids := make([]interface{}, len(rows))
+	for n, group := range [][]int{given, missing} {
+		groupRows := make([][]interface{}, len(group))
+		for i := range group {
+			groupRows[i] = rows[group[i]]
+		}
+		groupIDs, err := insertRows(col, pKey, columnNames, groupRows, ai.increment)
+		if err != nil {
+			if n == 0 {
+				return nil, err
+			}
+			// The rows before the first one without a key were inserted by
+			// the first statement.
+			return ids[:missing[0]], err
+		}
+		for i := range group {
+			ids[group[i]] = groupIDs[i]
+		}
+	}
+	return ids, nil
+}
+
+// insertRows inserts rows with a single INSERT and returns their IDs, the ID
+// generated for each row is step apart from the previous one.
+func insertRows(col sqladapter.Collection, pKey []string, columnNames []string, rows [][]interface{}, step int64) ([]interface{}, error) {
+	q := col.SQL().InsertInto(col.Name()).Columns(columnNames...)
+	for i := range rows {
+		q = q.Values(rows[i]...)
+	}
+
+	res, err := q.Exec()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]interface{}, len(rows))
+	if len(pKey) == 0 {
+		return ids, nil
+	}
+
+	// LastInsertId() returns the first ID generated by the statement, only
+	// rows without a key get one. It is zero (or fails) if there are no auto
+	// columns.
+	lastID, _ := res.LastInsertId()
+
+	generated := int64(0)
+	for i := range rows {
+		keyMap := db.Cond{}
+		for j := range columnNames {
+			for k := 0; k < len(pKey); k++ {
+				if pKey[k] == columnNames[j] && rows[i][j] != nil {
+					keyMap[pKey[k]] = rows[i][j]
+				}
+			}
+		}
+
+		// There was an auto column among primary keys, let's fill it in.
+		if lastID > 0 && len(keyMap) < len(pKey) {
+			for k := 0; k < len(pKey); k++ {
+				if keyMap[pKey[k]] == nil {
+					keyMap[pKey[k]] = lastID + generated*step
+				}
+			}
+			generated++
+		}
+
+		if len(pKey) == 1 {
+			ids[i] = keyMap[pKey[0]]
+			continue
+		}
+		ids[i] = keyMap
+	}
+
+	return ids, nil
+}
+
+// countMissingKeys returns the number of rows that don't give a value for
+// every primary key.
+func countMissingKeys(pKey []string, columnNames []string, rows [][]interface{}) int {
+	n := 0
+	for i := range rows {
+		given := 0
+		for j := range columnNames {
+			for k := range pKey {
+				if pKey[k] == columnNames[j] && rows[i][j] != nil {
+					given++
+				}
+			}
+		}
+		if given < len(pKey) {
+			n++
+		}
+	}
+	return n
+}
+
+// autoIncrement holds the server variables that tell how InnoDB generates the
+// IDs of a multi-row INSERT.
+type autoIncrement struct {
+	increment int64
+	lockMode  int64
+}
+
+// autoIncrementOf reads @@auto_increment_increment and
+// @@innodb_autoinc_lock_mode once per session. If they can't be read the
+// defaults of MySQL 8.0 are assumed.
+func autoIncrementOf(col sqladapter.Collection) autoIncrement {
+	ai := autoIncrement{increment: 1, lockMode: 2}
+
+	sess, ok := col.Session().(sqladapter.Session)
+	if !ok {
+		return ai
+	}
+	value, err := sess.CachedValue("mysql.autoIncrement", func() (interface{}, error) {
+		row, err := col.SQL().QueryRow("SELECT @@auto_increment_increment, @@innodb_autoinc_lock_mode")
+		if err != nil {
+			return nil, err
+		}
+		var value autoIncrement
+		if err := row.Scan(&value.increment, &value.lockMode); err != nil {
+			return nil, err
+		}
+		return value, nil
+	})
+	if err != nil {
+		return ai
+	}
+	if value := value.(autoIncrement); value.increment > 0 {
+		ai = value
+	}
+	return ai
+}
+
+// InsertOrIgnore inserts item using INSERT IGNORE and returns its primary key,
+// or nil if the row was skipped.
+func (*collectionAdapter) InsertOrIgnore(col sqladapter.Collection, item interface{}) (interface{}, error) {
+	columnNames, columnValues, err := sqlbuilder.Map(item, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pKey := col.PrimaryKeys()
+
+	res, err := col.SQL().InsertInto(col.Name()).
+		Columns(columnNames...).
+		Values(columnValues...).
+		Ignore().
+		Exec()
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		// The row conflicted with an existing one.
+		return nil, nil
+	}
+
+	if len(pKey) <= 1 {
+		return res.LastInsertId()
+	}
+
+	keyMap := db.Cond{}
+	for i := range columnNames {
+		for j := 0; j < len(pKey); j++ {
+			if pKey[j] == columnNames[i] {
+				keyMap[pKey[j]] = columnValues[i]
+			}
+		}
+	}
+	return keyMap, nil
+}
+
+// Upsert compiles OnConflict into ON DUPLICATE KEY UPDATE, which is triggered
+// by a conflict on any unique key and not only on conflictColumns.
+func (*collectionAdapter) Upsert(col sqladapter.Collection, item interface{}, conflictColumns ...string) (interface{}, error) {
+	return sqladapter.UpsertUsingLastInsertID(col, item, conflictColumns, func(res sql.Result) bool {
+		// MySQL reports 1 affected row for an insert, and 2 (or 0 if nothing
+		// changed) for an update.
+		affected, err := res.RowsAffected()
+		return err == nil && affected == 1
+	})
+}
+
+// Analyze refreshes the key distribution statistics of the table.
+func (*collectionAdapter) Analyze(col sqladapter.Collection) error {
+	return maintainTable(col, "ANALYZE TABLE")
+}
+
+// Optimize rebuilds the table to reclaim unused storage and defragment its
+// data, InnoDB tables are recreated and analyzed.
+func (*collectionAdapter) Optimize(col sqladapter.Collection) error {
+	return maintainTable(col, "OPTIMIZE TABLE")
+}
+
+// maintainTable runs a table maintenance statement, which reports failures as
+// rows of its result instead of returning an error.
+func maintainTable(col sqladapter.Collection, statement string) error {
+	table, err := exql.TableWithName(col.Name()).Compile(template)
+	if err != nil {
+		return err
+	}
+
+	rows, err := col.SQL().Query(statement + " " + table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, op, msgType, msgText string
+		if err := rows.Scan(&name, &op, &msgType, &msgText); err != nil {
+			return err
+		}
+		if strings.EqualFold(msgType, "error") {
+			return errors.New(msgText)
+		}
+	}
+	return rows.Err()
+}
+
+// Stats reads the statistics of the table from information_schema. For
+// InnoDB tables Rows is an estimate, refreshed when the table is analyzed.
+// MySQL does not expose when that happened, LastAnalyzed is always nil.
+func (*collectionAdapter) Stats(col sqladapter.Collection) (*db.CollectionStats, error) {
+	row, err := col.SQL().QueryRow(`
+		SELECT COALESCE(TABLE_ROWS, 0), COALESCE(DATA_LENGTH, 0), COALESCE(INDEX_LENGTH, 0)
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?`, col.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var stats db.CollectionStats
+	if err := row.Scan(&stats.Rows, &stats.DataSize, &stats.IndexSize); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Triggers reads the triggers of the table from information_schema.
+func (*collectionAdapter) Triggers(col sqladapter.Collection) ([]*db.Trigger, error) {
+	rows, err := col.SQL().Query(`
+		SELECT TRIGGER_NAME, ACTION_TIMING, EVENT_MANIPULATION, ACTION_STATEMENT
+		FROM information_schema.TRIGGERS
+		WHERE EVENT_OBJECT_SCHEMA = DATABASE() AND EVENT_OBJECT_TABLE = ?
+		ORDER BY TRIGGER_NAME`, col.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var triggers []*db.Trigger
+	for rows.Next() {
+		var trigger db.Trigger
+		var timing, event, body string
+		if err := rows.Scan(&trigger.Name, &timing, &event, &body); err != nil {
+			return nil, err
+		}
+		trigger.Timing = db.TriggerTiming(strings.ToUpper(timing))
+		trigger.Events = db.ParseTriggerEvent(event)
+		trigger.Body = sqladapter.TriggerBody(body)
+		triggers = append(triggers, &trigger)
+	}
+	return triggers, rows.Err()
+}
+
+// CreateTrigger creates a trigger on the table. MySQL triggers have a single
+// event and can't run instead of the statement.
+func (*collectionAdapter) CreateTrigger(col sqladapter.Collection, trigger *db.Trigger) error {
+	if ev := trigger.Events; ev&(ev-1) != 0 {
+		return fmt.Errorf("%w: triggers with more than one event", db.ErrUnsupported)
+	}
+	timing := trigger.Timing
+	switch timing {
+	case "":
+		timing = db.TriggerBefore
+	case db.TriggerInsteadOf:
+		return fmt.Errorf("%w: INSTEAD OF triggers", db.ErrUnsupported)
+	}
+
+	name, err := exql.ColumnWithName(trigger.Name).Compile(template)
+	if err != nil {
+		return err
+	}
+	table, err := exql.TableWithName(col.Name()).Compile(template)
+	if err != nil {
+		return err
+	}
+
+	_, err = col.SQL().Exec(fmt.Sprintf(
+		"CREATE TRIGGER %s %s %s ON %s FOR EACH ROW %s",
+		name, timing, trigger.Events, table, sqladapter.TriggerBlock(trigger.Body),
+	))
+	return err
+}
+
+// DropTrigger drops a trigger of the table, trigger names are unique within
+// the database.
+func (*collectionAdapter) DropTrigger(col sqladapter.Collection, name string) error {
+	quoted, err := exql.ColumnWithName(name).Compile(template)
+	if err != nil {
+		return err
+	}
+	_, err = col.SQL().Exec("DROP TRIGGER " + quoted)
+	return err
+}
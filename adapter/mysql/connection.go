@@ -27,6 +27,8 @@ import (
 	"net"
 	"net/url"
 	"strings"
+
+	db "github.com/upper/db/v4"
 )
 
 // From https://github.com/go-sql-driver/mysql/blob/master/utils.go
@@ -105,6 +107,9 @@ func (c ConnectionURL) String() (s string) {
 	vv := url.Values{}
 
 	for k, v := range c.Options {
+		if db.IsPoolOption(k) {
+			continue
+		}
 		vv.Set(k, v)
 	}
 
@@ -116,6 +121,37 @@ func (c ConnectionURL) String() (s string) {
 	return s
 }
 
+// PoolOptions returns the connection pool options, which are not passed to
+// the driver.
+func (c ConnectionURL) PoolOptions() map[string]string {
+	return db.PoolOptions(c.Options)
+}
+
+// Validate checks the connection settings before connecting.
+func (c ConnectionURL) Validate() error {
+	if c.Database == "" {
+		return db.NewConnectionURLError("Database", "is required")
+	}
+	if c.Host != "" && c.Socket != "" {
+		return &db.ConnectionURLError{Field: "Socket", Reason: "can't be used along with Host", Err: db.ErrSockerOrHost}
+	}
+	if c.Host != "" {
+		if err := db.ValidateAddress("Host", c.Host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Redacted returns the DSN with the password replaced by db.RedactedPassword,
+// it's safe to log.
+func (c ConnectionURL) Redacted() string {
+	if c.Password != "" {
+		c.Password = db.RedactedPassword
+	}
+	return c.String()
+}
+
 // ParseURL parses s into a ConnectionURL struct.
 func ParseURL(s string) (conn ConnectionURL, err error) {
 	var cfg *config
@@ -22,7 +22,10 @@
 package mysql
 
 import (
+	"errors"
 	"testing"
+
+	db "github.com/upper/db/v4"
 )
 
 func TestConnectionURL(t *testing.T) {
@@ -136,3 +139,34 @@ func TestParseConnectionURL(t *testing.T) {
 	}
 
 }
+
+func TestValidate(t *testing.T) {
+	if err := (ConnectionURL{Database: "test", Host: "localhost:3306"}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var urlErr *db.ConnectionURLError
+
+	err := ConnectionURL{Host: "localhost"}.Validate()
+	if !errors.As(err, &urlErr) || urlErr.Field != "Database" {
+		t.Fatal("Expecting an error about the database, got:", err)
+	}
+
+	err = ConnectionURL{Database: "test", Host: "localhost", Socket: "/var/run/mysqld/mysqld.sock"}.Validate()
+	if !errors.As(err, &urlErr) || urlErr.Field != "Socket" {
+		t.Fatal("Expecting an error about the socket, got:", err)
+	}
+
+	_, err = Open(ConnectionURL{Database: "test", Host: "localhost:port"})
+	if !errors.As(err, &urlErr) || urlErr.Field != "Host" {
+		t.Fatal("Expecting an error about the host, got:", err)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	c := ConnectionURL{User: "user", Password: "pass", Database: "test"}
+
+	if s := c.Redacted(); s != "user:xxxxx@/test?charset=utf8&parseTime=true" {
+		t.Fatal("Test failed, got:", s)
+	}
+}
@@ -32,7 +32,7 @@ import (
 
 	"database/sql"
 
-	_ "github.com/go-sql-driver/mysql" // MySQL driver.
+	mysql "github.com/go-sql-driver/mysql" // MySQL driver.
 	db "github.com/upper/db/v4"
 	"github.com/upper/db/v4/internal/sqladapter"
 	"github.com/upper/db/v4/internal/sqladapter/exql"
@@ -102,10 +102,57 @@ func (*database) Err(err error) error {
 		if strings.Contains(s, `many connections`) {
 			return db.ErrTooManyClients
 		}
+		if myErr, ok := err.(*mysql.MySQLError); ok {
+			switch myErr.Number {
+			case 1062:
+				return db.NewConstraintError(db.ErrDuplicateKey, err)
+			case 1451, 1452:
+				return db.NewConstraintError(db.ErrForeignKeyViolation, err)
+			case 1048, 1364:
+				return db.NewConstraintError(db.ErrNotNullViolation, err)
+			case 3819:
+				return db.NewConstraintError(db.ErrCheckViolation, err)
+			case 1205:
+				return db.NewContentionError(db.ErrLockTimeout, err)
+			case 1213:
+				return db.NewContentionError(db.ErrDeadlock, err)
+			}
+		}
 	}
 	return err
 }
 
+// BlockedTransactions counts the InnoDB transactions that are waiting for a
+// lock.
+func (*database) BlockedTransactions(sess sqladapter.Session) (int, error) {
+	row, err := sess.SQL().QueryRow(`SELECT COUNT(*) FROM information_schema.INNODB_TRX WHERE trx_state = 'LOCK WAIT'`)
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	if err := row.Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (*database) Capabilities() db.Capability {
+	return db.CapabilityTransactions |
+		db.CapabilityCompositeKeys |
+		db.CapabilitySavepoints
+}
+
+// ParseURL parses a connection URL of the adapter.
+func (*database) ParseURL(s string) (db.ConnectionURL, error) {
+	return ParseURL(s)
+}
+
+// RecursiveWith returns the keyword that starts recursive common table
+// expressions.
+func (*database) RecursiveWith() string {
+	return "WITH RECURSIVE"
+}
+
 func (*database) NewCollection() sqladapter.CollectionAdapter {
 	return &collectionAdapter{}
 }
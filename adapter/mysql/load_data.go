@@ -0,0 +1,255 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package mysql
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/sqladapter/exql"
+	"github.com/upper/db/v4/internal/sqlbuilder"
+)
+
+var loadDataReaderSeq uint64
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// LoadData imports a slice of structs or maps into the given collection with
+// LOAD DATA LOCAL INFILE, which is much faster than inserting rows one
+// statement at a time:
+//
+//	n, err := mysql.LoadData(sess, "events", events)
+//
+// Items are mapped to columns the same way Insert does. Rows are streamed to
+// the server as they're mapped, consecutive items with the same columns are
+// imported by the same statement. Time values are sent in UTC.
+//
+// The server must allow local files (local_infile=ON). Rows that can't be
+// converted are reported by the server as warnings rather than errors, like
+// any other LOAD DATA statement. LoadData returns the number of imported
+// rows.
+func LoadData(sess db.Session, collectionName string, items interface{}) (int64, error) {
+	driver, ok := sess.Driver().(execer)
+	if !ok {
+		return 0, fmt.Errorf("%w: LOAD DATA on %T", db.ErrUnsupported, sess.Driver())
+	}
+
+	itemsV := reflect.ValueOf(items)
+	if itemsV.Kind() != reflect.Slice && itemsV.Kind() != reflect.Array {
+		return 0, fmt.Errorf("Expecting a slice but got %T", items)
+	}
+
+	table, err := exql.TableWithName(collectionName).Compile(template)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for i, n := 0, itemsV.Len(); i < n; {
+		columnNames, _, err := sqlbuilder.Map(itemsV.Index(i).Interface(), nil)
+		if err != nil {
+			return total, err
+		}
+
+		next, affected, err := loadData(sess.Context(), driver, table, columnNames, itemsV, i)
+		total += affected
+		if err != nil {
+			return total, err
+		}
+		i = next
+	}
+
+	return total, nil
+}
+
+// loadData imports the items starting at index start with a single LOAD DATA
+// statement, until it finds an item that does not map to columnNames. It
+// returns the index of that item.
+func loadData(ctx context.Context, driver execer, table string, columnNames []string, itemsV reflect.Value, start int) (int, int64, error) {
+	columns := make([]exql.Fragment, len(columnNames))
+	for i := range columnNames {
+		columns[i] = exql.ColumnWithName(columnNames[i])
+	}
+	compiledColumns, err := exql.JoinColumns(columns...).Compile(template)
+	if err != nil {
+		return start, 0, err
+	}
+
+	pr, pw := io.Pipe()
+
+	readerName := fmt.Sprintf("upper-db-load-data-%d", atomic.AddUint64(&loadDataReaderSeq, 1))
+	mysqldriver.RegisterReaderHandler(readerName, func() io.Reader {
+		return pr
+	})
+	defer mysqldriver.DeregisterReaderHandler(readerName)
+
+	next := start
+	done := make(chan error, 1)
+
+	go func() {
+		w := bufio.NewWriter(pw)
+		err := func() error {
+			for n := itemsV.Len(); next < n; next++ {
+				itemColumns, itemValues, err := sqlbuilder.Map(itemsV.Index(next).Interface(), nil)
+				if err != nil {
+					return err
+				}
+				if !sameColumns(columnNames, itemColumns) {
+					break
+				}
+				if err := writeLoadDataRow(w, itemValues); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		}()
+		pw.CloseWithError(err)
+		done <- err
+	}()
+
+	query := fmt.Sprintf("LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE %s %s", readerName, table, compiledColumns)
+	res, err := driver.ExecContext(ctx, query)
+
+	// Unblocks the writer in case the server stopped reading.
+	pr.CloseWithError(io.ErrClosedPipe)
+	if writeErr := <-done; writeErr != nil && writeErr != io.ErrClosedPipe {
+		// Mapping errors are more useful than the error of the aborted statement.
+		return next, 0, writeErr
+	}
+	if err != nil {
+		return next, 0, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return next, 0, err
+	}
+	return next, affected, nil
+}
+
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// writeLoadDataRow writes values as a line of tab-separated fields, using the
+// default escaping rules of LOAD DATA.
+func writeLoadDataRow(w *bufio.Writer, values []interface{}) error {
+	for i := range values {
+		if i > 0 {
+			if err := w.WriteByte('\t'); err != nil {
+				return err
+			}
+		}
+		if err := writeLoadDataField(w, values[i]); err != nil {
+			return err
+		}
+	}
+	return w.WriteByte('\n')
+}
+
+func writeLoadDataField(w *bufio.Writer, value interface{}) error {
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return err
+		}
+		value = v
+	}
+
+	switch v := value.(type) {
+	case nil:
+		_, err := w.WriteString(`\N`)
+		return err
+	case []byte:
+		return writeLoadDataEscaped(w, string(v))
+	case string:
+		return writeLoadDataEscaped(w, v)
+	case bool:
+		if v {
+			return w.WriteByte('1')
+		}
+		return w.WriteByte('0')
+	case time.Time:
+		_, err := w.WriteString(v.UTC().Format("2006-01-02 15:04:05.999999"))
+		return err
+	case int64:
+		_, err := w.WriteString(strconv.FormatInt(v, 10))
+		return err
+	case float64:
+		_, err := w.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+		return err
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return writeLoadDataField(w, nil)
+		}
+		return writeLoadDataField(w, rv.Elem().Interface())
+	}
+
+	return writeLoadDataEscaped(w, fmt.Sprintf("%v", value))
+}
+
+func writeLoadDataEscaped(w *bufio.Writer, s string) error {
+	for i := 0; i < len(s); i++ {
+		var err error
+		switch c := s[i]; c {
+		case '\\':
+			_, err = w.WriteString(`\\`)
+		case '\t':
+			_, err = w.WriteString(`\t`)
+		case '\n':
+			_, err = w.WriteString(`\n`)
+		case '\r':
+			_, err = w.WriteString(`\r`)
+		case 0:
+			_, err = w.WriteString(`\0`)
+		default:
+			err = w.WriteByte(c)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package mysql
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadDataRow(t *testing.T) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+
+	name := "Ozzy"
+	var missing *string
+
+	err := writeLoadDataRow(w, []interface{}{
+		int64(1),
+		"a\tb\nc\\d\re\x00",
+		[]byte("bytes"),
+		nil,
+		true,
+		3.5,
+		&name,
+		missing,
+		time.Date(2020, 1, 2, 3, 4, 5, 600000000, time.UTC),
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, w.Flush())
+
+	assert.Equal(t, "1\ta\\tb\\nc\\\\d\\re\\0\tbytes\t\\N\t1\t3.5\tOzzy\t\\N\t2020-01-02 03:04:05.6\n", buf.String())
+}
@@ -22,11 +22,14 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"math/rand"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -394,6 +397,139 @@ func (s *AdapterTests) TestMySQLTypes() {
 	}
 }
 
+func (s *AdapterTests) TestAdvisoryLock() {
+	sess := s.Session()
+
+	lock, err := AdvisoryLock(sess, "upper_jobs")
+	s.NoError(err)
+	s.Equal("upper_jobs", lock.Name())
+
+	// The lock is held by another connection.
+	other, err := TryAdvisoryLock(sess, "upper_jobs")
+	s.NoError(err)
+	s.Nil(other)
+
+	s.NoError(lock.Unlock())
+
+	other, err = TryAdvisoryLock(sess, "upper_jobs")
+	s.NoError(err)
+	if s.NotNil(other) {
+		s.NoError(other.Unlock())
+	}
+
+	err = sess.Tx(func(tx db.Session) error {
+		_, err := AdvisoryLock(tx, "upper_jobs")
+		return err
+	})
+	s.True(errors.Is(err, db.ErrUnsupported))
+}
+
+func (s *AdapterTests) TestLoadData() {
+	sess := s.Session()
+
+	// LOAD DATA LOCAL is disabled by default on MySQL 8.
+	_, _ = sess.SQL().Exec("SET GLOBAL local_infile = 1")
+
+	artist := sess.Collection("artist")
+	s.NoError(artist.Truncate())
+
+	type artistType struct {
+		ID   int64  `db:"id,omitempty"`
+		Name string `db:"name"`
+	}
+
+	items := []interface{}{
+		artistType{Name: "Ozzy"},
+		artistType{Name: "Tab\tand\nnewline \\ back\\slash"},
+		map[string]interface{}{"id": 100, "name": "Flea"},
+		artistType{Name: "Frusciante"},
+	}
+
+	n, err := LoadData(sess, "artist", items)
+	s.NoError(err)
+	s.Equal(int64(4), n)
+
+	count, err := artist.Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(4), count)
+
+	var flea artistType
+	s.NoError(artist.Find(100).One(&flea))
+	s.Equal("Flea", flea.Name)
+
+	var escaped artistType
+	s.NoError(artist.Find(db.Cond{"name": items[1].(artistType).Name}).One(&escaped))
+	s.NotZero(escaped.ID)
+}
+
+func (s *AdapterTests) TestInsertManyAutoIncrement() {
+	sess := s.Session().WithContext(context.Background())
+
+	inserts := 0
+	sess.Use(func(next db.QueryHandler) db.QueryHandler {
+		return func(ctx context.Context, query string, args []interface{}) error {
+			if strings.HasPrefix(query, "INSERT") {
+				inserts++
+			}
+			return next(ctx, query, args)
+		}
+	})
+
+	row, err := sess.SQL().QueryRow("SELECT @@innodb_autoinc_lock_mode")
+	s.NoError(err)
+	var lockMode int
+	s.NoError(row.Scan(&lockMode))
+
+	artist := sess.Collection("artist")
+	s.NoError(artist.Truncate())
+
+	type artistType struct {
+		ID   int64  `db:"id,omitempty"`
+		Name string `db:"name"`
+	}
+
+	items := make([]artistType, 10)
+	for i := range items {
+		items[i].Name = fmt.Sprintf("artist-%d", i)
+	}
+
+	// Rows that give no key are inserted with one statement in every lock
+	// mode.
+	res, err := artist.InsertMany(items, 0)
+	s.NoError(err)
+	s.Equal(1, inserts)
+	s.Equal(len(items), len(res))
+	for i := range res {
+		var item artistType
+		s.NoError(artist.Find(res[i].ID()).One(&item))
+		s.Equal(items[i].Name, item.Name)
+	}
+
+	// Rows that give the key of some rows take one statement more with
+	// interleaved lock mode (2).
+	inserts = 0
+	rows := []map[string]interface{}{
+		{"id": nil, "name": "Ozzie"},
+		{"id": 1000, "name": "Flea"},
+		{"id": nil, "name": "Slash"},
+		{"id": nil, "name": "Frusciante"},
+	}
+	res, err = artist.InsertMany(rows, 0)
+	s.NoError(err)
+	if lockMode == 2 {
+		s.Equal(2, inserts)
+	} else {
+		s.Equal(1, inserts)
+	}
+	s.Equal(len(rows), len(res))
+	s.Equal(int64(1000), res[1].ID())
+	for i := range res {
+		var item artistType
+		s.NoError(artist.Find(res[i].ID()).One(&item))
+		s.Equal(rows[i]["name"], item.Name)
+	}
+}
+
 func TestAdapter(t *testing.T) {
 	suite.Run(t, &AdapterTests{})
 }
@@ -85,6 +85,8 @@ const (
   `
 
 	adapterSelectLayout = `
+    {{.With | compile}}
+
     SELECT
       {{if .Distinct}}
         DISTINCT
@@ -108,6 +110,10 @@ const (
         {{.GroupBy | compile}}
       {{end}}
 
+      {{if defined .Having}}
+        {{.Having | compile}}
+      {{end}}
+
       {{.OrderBy | compile}}
 
       {{if .Limit}}
@@ -131,6 +137,10 @@ const (
         {{end}}
         OFFSET {{.Offset}}
       {{end}}
+
+      {{if defined .Lock}}
+        {{.Lock | compile}}
+      {{end}}
   `
 	adapterDeleteLayout = `
     DELETE
@@ -152,7 +162,7 @@ const (
   `
 
 	adapterInsertLayout = `
-    INSERT INTO {{.Table | compile}}
+    INSERT {{if .Ignore}}IGNORE {{end}}INTO {{.Table | compile}}
       {{if defined .Columns}}({{.Columns | compile}}){{end}}
     VALUES
     {{if defined .Values}}
@@ -160,11 +170,29 @@ const (
     {{else}}
       ()
     {{end}}
+    {{if defined .OnConflict}}
+      {{.OnConflict | compile}}
+    {{end}}
     {{if defined .Returning}}
       RETURNING {{.Returning | compile}}
     {{end}}
   `
 
+	adapterLockLayout = `
+    FOR {{.Mode}}
+    {{if .Wait}}
+      {{.Wait}}
+    {{end}}
+  `
+
+	adapterOnConflictLayout = `
+    {{if .Update}}
+      ON DUPLICATE KEY UPDATE {{.Update}}
+    {{end}}
+  `
+
+	adapterOnConflictUpdateLayout = `{{.Column}} = VALUES({{.Column}})`
+
 	adapterTruncateLayout = `
     TRUNCATE TABLE {{.Table | compile}}
   `
@@ -177,43 +205,75 @@ const (
     DROP TABLE {{.Table | compile}}
   `
 
+	adapterCreateTableAsLayout = `
+    CREATE TABLE {{.Table | compile}} AS {{.Query | compile}}
+  `
+
 	adapterGroupByLayout = `
     {{if .GroupColumns}}
       GROUP BY {{.GroupColumns}}
     {{end}}
+  `
+
+	adapterHavingLayout = `
+    {{if .Conds}}
+      HAVING {{.Conds}}
+    {{end}}
+  `
+
+	adapterWithLayout = `
+    {{if .Tables}}
+      WITH {{if .Recursive}}RECURSIVE{{end}} {{.Tables}}
+    {{end}}
+  `
+
+	adapterValuesTableLayout = `
+    (VALUES {{range $i, $row := .Rows}}{{if $i}}, {{end}}ROW{{$row}}{{end}}) AS {{.Name}} ({{.Columns}})
+  `
+
+	adapterCallLayout = `
+    CALL {{.Name}}({{range $i, $arg := .Arguments}}{{if $i}}, {{end}}{{$arg.Value}}{{end}})
   `
 )
 
 var template = &exql.Template{
-	ColumnSeparator:     adapterColumnSeparator,
-	IdentifierSeparator: adapterIdentifierSeparator,
-	IdentifierQuote:     adapterIdentifierQuote,
-	ValueSeparator:      adapterValueSeparator,
-	ValueQuote:          adapterValueQuote,
-	AndKeyword:          adapterAndKeyword,
-	OrKeyword:           adapterOrKeyword,
-	DescKeyword:         adapterDescKeyword,
-	AscKeyword:          adapterAscKeyword,
-	AssignmentOperator:  adapterAssignmentOperator,
-	ClauseGroup:         adapterClauseGroup,
-	ClauseOperator:      adapterClauseOperator,
-	ColumnValue:         adapterColumnValue,
-	TableAliasLayout:    adapterTableAliasLayout,
-	ColumnAliasLayout:   adapterColumnAliasLayout,
-	SortByColumnLayout:  adapterSortByColumnLayout,
-	WhereLayout:         adapterWhereLayout,
-	JoinLayout:          adapterJoinLayout,
-	OnLayout:            adapterOnLayout,
-	UsingLayout:         adapterUsingLayout,
-	OrderByLayout:       adapterOrderByLayout,
-	InsertLayout:        adapterInsertLayout,
-	SelectLayout:        adapterSelectLayout,
-	UpdateLayout:        adapterUpdateLayout,
-	DeleteLayout:        adapterDeleteLayout,
-	TruncateLayout:      adapterTruncateLayout,
-	DropDatabaseLayout:  adapterDropDatabaseLayout,
-	DropTableLayout:     adapterDropTableLayout,
-	CountLayout:         adapterSelectCountLayout,
-	GroupByLayout:       adapterGroupByLayout,
-	Cache:               cache.NewCache(),
+	ColumnSeparator:        adapterColumnSeparator,
+	IdentifierSeparator:    adapterIdentifierSeparator,
+	IdentifierQuote:        adapterIdentifierQuote,
+	ValueSeparator:         adapterValueSeparator,
+	ValuesTableLayout:      adapterValuesTableLayout,
+	ValueQuote:             adapterValueQuote,
+	AndKeyword:             adapterAndKeyword,
+	OrKeyword:              adapterOrKeyword,
+	DescKeyword:            adapterDescKeyword,
+	AscKeyword:             adapterAscKeyword,
+	AssignmentOperator:     adapterAssignmentOperator,
+	ClauseGroup:            adapterClauseGroup,
+	ClauseOperator:         adapterClauseOperator,
+	ColumnValue:            adapterColumnValue,
+	TableAliasLayout:       adapterTableAliasLayout,
+	ColumnAliasLayout:      adapterColumnAliasLayout,
+	SortByColumnLayout:     adapterSortByColumnLayout,
+	WhereLayout:            adapterWhereLayout,
+	JoinLayout:             adapterJoinLayout,
+	LockLayout:             adapterLockLayout,
+	OnConflictLayout:       adapterOnConflictLayout,
+	OnConflictUpdateLayout: adapterOnConflictUpdateLayout,
+	OnLayout:               adapterOnLayout,
+	UsingLayout:            adapterUsingLayout,
+	OrderByLayout:          adapterOrderByLayout,
+	InsertLayout:           adapterInsertLayout,
+	SelectLayout:           adapterSelectLayout,
+	UpdateLayout:           adapterUpdateLayout,
+	DeleteLayout:           adapterDeleteLayout,
+	TruncateLayout:         adapterTruncateLayout,
+	DropDatabaseLayout:     adapterDropDatabaseLayout,
+	DropTableLayout:        adapterDropTableLayout,
+	CreateTableAsLayout:    adapterCreateTableAsLayout,
+	CountLayout:            adapterSelectCountLayout,
+	GroupByLayout:          adapterGroupByLayout,
+	HavingLayout:           adapterHavingLayout,
+	WithLayout:             adapterWithLayout,
+	CallLayout:             adapterCallLayout,
+	Cache:                  cache.NewCache(),
 }
@@ -182,6 +182,11 @@ func TestTemplateInsert(t *testing.T) {
 		b.InsertInto("artist").Values(map[string]string{"id": "12", "name": "Chavela Vargas"}).String(),
 	)
 
+	assert.Equal(
+		"INSERT IGNORE INTO `artist` (`id`, `name`) VALUES ($1, $2)",
+		b.InsertInto("artist").Values(map[string]string{"id": "12", "name": "Chavela Vargas"}).Ignore().String(),
+	)
+
 	assert.Equal(
 		"INSERT INTO `artist` (`id`, `name`) VALUES ($1, $2) RETURNING `id`",
 		b.InsertInto("artist").Values(map[string]string{"id": "12", "name": "Chavela Vargas"}).Returning("id").String(),
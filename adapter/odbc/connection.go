@@ -0,0 +1,193 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package odbc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	db "github.com/upper/db/v4"
+)
+
+// ConnectionURL implements an ODBC connection struct, which is turned into a
+// connection string for the driver manager. Either DSN, the name of a data
+// source configured in the driver manager, or Driver must be set:
+//
+//	DSN=legacy;UID=user;PWD=pass
+//	DRIVER={Microsoft Access Driver (*.mdb, *.accdb)};DBQ=C:\data\legacy.accdb
+//
+// Options are passed to the driver as they are, like DBQ above.
+type ConnectionURL struct {
+	DSN      string
+	Driver   string
+	User     string
+	Password string
+	Options  map[string]string
+}
+
+// reservedKeywords are the keywords that are set by the fields of
+// ConnectionURL.
+var reservedKeywords = map[string]bool{
+	"DSN":    true,
+	"DRIVER": true,
+	"UID":    true,
+	"PWD":    true,
+}
+
+// quoteValue encloses values that have characters with a meaning in
+// connection strings between braces, the closing brace is escaped by
+// doubling it.
+func quoteValue(v string) string {
+	if strings.ContainsAny(v, ";{}=") || strings.TrimSpace(v) != v {
+		return "{" + strings.Replace(v, "}", "}}", -1) + "}"
+	}
+	return v
+}
+
+func (c ConnectionURL) String() (s string) {
+	if c.DSN == "" && c.Driver == "" && c.User == "" && c.Password == "" && len(c.Options) == 0 {
+		return ""
+	}
+
+	params := []string{}
+
+	if c.DSN != "" {
+		params = append(params, "DSN="+quoteValue(c.DSN))
+	}
+	if c.Driver != "" {
+		// Driver names are always enclosed, they often have spaces and
+		// parentheses.
+		params = append(params, "DRIVER={"+strings.Replace(c.Driver, "}", "}}", -1)+"}")
+	}
+	if c.User != "" {
+		params = append(params, "UID="+quoteValue(c.User))
+	}
+	if c.Password != "" {
+		params = append(params, "PWD="+quoteValue(c.Password))
+	}
+
+	options := make([]string, 0, len(c.Options))
+	for k, v := range c.Options {
+		if db.IsPoolOption(k) || reservedKeywords[strings.ToUpper(k)] {
+			continue
+		}
+		options = append(options, k+"="+quoteValue(v))
+	}
+	sort.Strings(options)
+
+	return strings.Join(append(params, options...), ";")
+}
+
+// PoolOptions returns the connection pool options, which are not passed to
+// the driver.
+func (c ConnectionURL) PoolOptions() map[string]string {
+	return db.PoolOptions(c.Options)
+}
+
+// Validate checks the connection settings before connecting.
+func (c ConnectionURL) Validate() error {
+	if c.DSN == "" && c.Driver == "" {
+		return db.NewConnectionURLError("DSN", "is required when Driver is not set")
+	}
+	for k := range c.Options {
+		if k == "" || strings.ContainsAny(k, ";{}=") {
+			return db.NewConnectionURLError("Options", fmt.Sprintf("invalid keyword %q", k))
+		}
+	}
+	return nil
+}
+
+// Redacted returns the connection string with the password replaced by
+// db.RedactedPassword, it's safe to log.
+func (c ConnectionURL) Redacted() string {
+	if c.Password != "" {
+		c.Password = db.RedactedPassword
+	}
+	return c.String()
+}
+
+// ParseURL parses an ODBC connection string into a ConnectionURL struct.
+func ParseURL(s string) (conn ConnectionURL, err error) {
+	for {
+		var k, v string
+
+		if s = strings.TrimLeft(s, " ;"); s == "" {
+			break
+		}
+
+		i := strings.IndexByte(s, '=')
+		if i < 0 {
+			return conn, fmt.Errorf("malformed keyword %q", s)
+		}
+		k, s = strings.TrimSpace(s[:i]), strings.TrimLeft(s[i+1:], " ")
+
+		if strings.HasPrefix(s, "{") {
+			// Braced values end at the first closing brace that is not
+			// doubled.
+			var b strings.Builder
+			j := 1
+			for ; j < len(s); j++ {
+				if s[j] == '}' {
+					if j+1 < len(s) && s[j+1] == '}' {
+						b.WriteByte('}')
+						j++
+						continue
+					}
+					break
+				}
+				b.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return conn, fmt.Errorf("unterminated value of keyword %q", k)
+			}
+			v, s = b.String(), s[j+1:]
+			if i := strings.IndexByte(s, ';'); i >= 0 {
+				s = s[i+1:]
+			} else {
+				s = ""
+			}
+		} else if i := strings.IndexByte(s, ';'); i >= 0 {
+			v, s = strings.TrimSpace(s[:i]), s[i+1:]
+		} else {
+			v, s = strings.TrimSpace(s), ""
+		}
+
+		switch strings.ToUpper(k) {
+		case "DSN":
+			conn.DSN = v
+		case "DRIVER":
+			conn.Driver = v
+		case "UID":
+			conn.User = v
+		case "PWD":
+			conn.Password = v
+		default:
+			if conn.Options == nil {
+				conn.Options = make(map[string]string)
+			}
+			conn.Options[k] = v
+		}
+	}
+
+	return
+}
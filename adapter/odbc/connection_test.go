@@ -0,0 +1,122 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package odbc
+
+import (
+	"testing"
+)
+
+func TestConnectionURL(t *testing.T) {
+
+	c := ConnectionURL{}
+
+	// Zero value equals to an empty string.
+	if c.String() != "" {
+		t.Fatal(`Expecting default connectiong string to be empty, got:`, c.String())
+	}
+
+	// Adding a data source name.
+	c.DSN = "legacy"
+
+	if c.String() != "DSN=legacy" {
+		t.Fatal(`Test failed, got:`, c.String())
+	}
+
+	// Setting user and password, values with semicolons are enclosed.
+	c.User = "reader"
+	c.Password = "pa;ss}"
+
+	if c.String() != "DSN=legacy;UID=reader;PWD={pa;ss}}}" {
+		t.Fatal(`Test failed, got:`, c.String())
+	}
+
+	// Opening a file with a driver, keywords set by fields are ignored.
+	c = ConnectionURL{
+		Driver: "Microsoft Access Driver (*.mdb, *.accdb)",
+		Options: map[string]string{
+			"DBQ": `C:\data\legacy.accdb`,
+			"UID": "other",
+		},
+	}
+
+	if c.String() != `DRIVER={Microsoft Access Driver (*.mdb, *.accdb)};DBQ=C:\data\legacy.accdb` {
+		t.Fatal(`Test failed, got:`, c.String())
+	}
+}
+
+func TestParseConnectionURL(t *testing.T) {
+	s := `DRIVER={Microsoft Access Driver (*.mdb, *.accdb)}; DBQ=C:\data\legacy.accdb;UID=reader;PWD={pa;ss}}};`
+
+	u, err := ParseURL(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Driver != "Microsoft Access Driver (*.mdb, *.accdb)" {
+		t.Fatal("Failed to parse driver.")
+	}
+
+	if u.User != "reader" {
+		t.Fatal("Failed to parse username.")
+	}
+
+	if u.Password != "pa;ss}" {
+		t.Fatal("Failed to parse password.")
+	}
+
+	if u.Options["DBQ"] != `C:\data\legacy.accdb` {
+		t.Fatal("Failed to parse DBQ.")
+	}
+
+	// The parsed URL is turned back into the same settings.
+	v, err := ParseURL(u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.String() != u.String() {
+		t.Fatalf("Expecting %q, got %q", u.String(), v.String())
+	}
+
+	if _, err := ParseURL(`DSN={legacy`); err == nil {
+		t.Fatal("Expecting an error on an unterminated value.")
+	}
+
+	if _, err := ParseURL(`DSN=legacy;readonly`); err == nil {
+		t.Fatal("Expecting an error on a keyword without value.")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (ConnectionURL{User: "reader"}).Validate(); err == nil {
+		t.Fatal("Expecting an error without DSN or driver.")
+	}
+	if err := (ConnectionURL{DSN: "legacy"}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	c := ConnectionURL{DSN: "legacy", User: "reader", Password: "secret"}
+	if c.Redacted() != "DSN=legacy;UID=reader;PWD=xxxxx" {
+		t.Fatal(`Test failed, got:`, c.Redacted())
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package odbc
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/sqladapter"
+	"github.com/upper/db/v4/internal/sqladapter/exql"
+)
+
+// driverName is the name github.com/alexbrainman/odbc registers its driver
+// with.
+const driverName = `odbc`
+
+type database struct {
+	template *exql.Template
+}
+
+func (d *database) Template() *exql.Template {
+	return d.template
+}
+
+func (*database) OpenDSN(sess sqladapter.Session, dsn string) (*sql.DB, error) {
+	return sql.Open(driverName, dsn)
+}
+
+// sqlState reports whether err was reported by the driver with the given
+// SQLSTATE, which it writes between braces, like
+// "SQLExecute: {23000} [Microsoft][ODBC Microsoft Access Driver] ...".
+func sqlState(err error, state string) bool {
+	return strings.Contains(err.Error(), "{"+state+"}")
+}
+
+// Collections is not supported, tables can only be listed with the catalog
+// functions of ODBC, which database/sql doesn't expose.
+func (*database) Collections(sess sqladapter.Session) ([]string, error) {
+	return nil, fmt.Errorf("%w: listing tables through ODBC", db.ErrUnsupported)
+}
+
+func (*database) Err(err error) error {
+	if err != nil {
+		switch {
+		case sqlState(err, `23505`):
+			return db.NewConstraintError(db.ErrDuplicateKey, err)
+		case sqlState(err, `23503`):
+			return db.NewConstraintError(db.ErrForeignKeyViolation, err)
+		case sqlState(err, `23502`):
+			return db.NewConstraintError(db.ErrNotNullViolation, err)
+		case sqlState(err, `23513`):
+			return db.NewConstraintError(db.ErrCheckViolation, err)
+		case sqlState(err, `40001`):
+			return db.NewContentionError(db.ErrDeadlock, err)
+		}
+	}
+	return err
+}
+
+func (*database) Capabilities() db.Capability {
+	return db.CapabilityTransactions
+}
+
+// ParseURL parses a connection URL of the adapter.
+func (*database) ParseURL(s string) (db.ConnectionURL, error) {
+	return ParseURL(s)
+}
+
+func (*database) NewCollection() sqladapter.CollectionAdapter {
+	return &collectionAdapter{}
+}
+
+// LookupName returns the data source name, or the database file of sources
+// that are opened with a driver instead.
+func (*database) LookupName(sess sqladapter.Session) (string, error) {
+	connURL, err := ParseURL(sess.ConnectionURL().String())
+	if err != nil {
+		return "", err
+	}
+	if connURL.DSN != "" {
+		return connURL.DSN, nil
+	}
+	for k, v := range connURL.Options {
+		if strings.EqualFold(k, "DBQ") || strings.EqualFold(k, "DATABASE") {
+			return v, nil
+		}
+	}
+	return "", nil
+}
+
+// TableExists selects no rows from the table, which fails with SQLSTATE 42S02
+// if it doesn't exist. Drivers that pass the state of the server through,
+// like psqlODBC, report 42P01 instead.
+func (*database) TableExists(sess sqladapter.Session, name string) error {
+	rows, err := sess.SQL().Select(db.Raw("1")).From(name).Where("1 = 0").Query()
+	if err != nil {
+		if sqlState(err, `42S02`) || sqlState(err, `42P01`) {
+			return db.ErrCollectionDoesNotExist
+		}
+		return err
+	}
+	return rows.Close()
+}
+
+// PrimaryKeys returns no keys, they can only be read with the catalog
+// functions of ODBC.
+func (*database) PrimaryKeys(sess sqladapter.Session, tableName string) ([]string, error) {
+	return []string{}, nil
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2012-today The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package integration
+
+import (
+	"database/sql"
+	"os"
+
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/odbc"
+	"github.com/upper/db/v4/internal/testsuite"
+
+	_ "github.com/alexbrainman/odbc"
+)
+
+// settings reach the PostgreSQL server of docker-compose.yml through the
+// psqlODBC driver, which must be registered in the driver manager of the
+// host running the tests.
+var settings = odbc.ConnectionURL{
+	Driver:   driverName(),
+	User:     os.Getenv("DB_USERNAME"),
+	Password: os.Getenv("DB_PASSWORD"),
+	Options: map[string]string{
+		"Server":   os.Getenv("DB_HOST"),
+		"Port":     os.Getenv("DB_PORT"),
+		"Database": os.Getenv("DB_NAME"),
+	},
+}
+
+func driverName() string {
+	if name := os.Getenv("ODBC_DRIVER"); name != "" {
+		return name
+	}
+	return "PostgreSQL Unicode"
+}
+
+type Helper struct {
+	sess db.Session
+}
+
+func (h *Helper) Session() db.Session {
+	return h.sess
+}
+
+func (h *Helper) Adapter() string {
+	return odbc.Adapter
+}
+
+func (h *Helper) TearDown() error {
+	return h.sess.Close()
+}
+
+func (h *Helper) TearUp() error {
+	var err error
+
+	h.sess, err = odbc.Open(settings)
+	if err != nil {
+		return err
+	}
+
+	batch := []string{
+		`DROP TABLE IF EXISTS artist`,
+		`CREATE TABLE artist (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(60) NOT NULL UNIQUE
+		)`,
+
+		`DROP TABLE IF EXISTS publication`,
+		`CREATE TABLE publication (
+			id SERIAL PRIMARY KEY,
+			title VARCHAR(80),
+			author_id INTEGER
+		)`,
+	}
+
+	driver := h.sess.Driver().(*sql.DB)
+	tx, err := driver.Begin()
+	if err != nil {
+		return err
+	}
+	for _, query := range batch {
+		if _, err := tx.Exec(query); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+var _ testsuite.Helper = &Helper{}
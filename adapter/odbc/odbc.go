@@ -0,0 +1,83 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package odbc is a minimal adapter for data sources that are only reachable
+// through ODBC, like Microsoft Access files or old Db2 servers. It's meant to
+// read legacy data through the Collection and Result API, see the README for
+// what isn't supported.
+//
+// The adapter uses the github.com/alexbrainman/odbc driver, which is not
+// imported by this package because it needs an ODBC driver manager (unixODBC
+// or odbc32.dll) to build. Programs using this adapter must import it:
+//
+//	import _ "github.com/alexbrainman/odbc"
+//
+// ODBC sources don't share an SQL dialect, the package registers two
+// adapters: Adapter speaks standard SQL and AccessAdapter the Access dialect.
+package odbc
+
+import (
+	"database/sql"
+
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/sqladapter"
+	"github.com/upper/db/v4/internal/sqlbuilder"
+)
+
+// Adapter is the public name of the adapter for ODBC sources that speak
+// standard SQL, with double quoted identifiers and OFFSET ... FETCH FIRST
+// pagination.
+const Adapter = `odbc`
+
+// AccessAdapter is the public name of the adapter for Microsoft Access
+// sources, with bracketed identifiers and TOP n pagination.
+const AccessAdapter = `odbc-access`
+
+var (
+	registeredAdapter       = sqladapter.RegisterAdapter(Adapter, &database{template: template})
+	registeredAccessAdapter = sqladapter.RegisterAdapter(AccessAdapter, &database{template: accessTemplate})
+)
+
+// Open establishes a connection to the data source and returns a db.Session
+// instance (which is compatible with db.Session).
+func Open(connURL db.ConnectionURL) (db.Session, error) {
+	return registeredAdapter.OpenDSN(connURL)
+}
+
+// OpenAccess is like Open, for Microsoft Access sources.
+func OpenAccess(connURL db.ConnectionURL) (db.Session, error) {
+	return registeredAccessAdapter.OpenDSN(connURL)
+}
+
+// NewTx creates a sqlbuilder.Tx instance by wrapping a *sql.Tx value.
+func NewTx(sqlTx *sql.Tx) (sqlbuilder.Tx, error) {
+	return registeredAdapter.NewTx(sqlTx)
+}
+
+// New creates a sqlbuilder.Sesion instance by wrapping a *sql.DB value.
+func New(sqlDB *sql.DB) (db.Session, error) {
+	return registeredAdapter.New(sqlDB)
+}
+
+// NewAccess is like New, for Microsoft Access sources.
+func NewAccess(sqlDB *sql.DB) (db.Session, error) {
+	return registeredAccessAdapter.New(sqlDB)
+}
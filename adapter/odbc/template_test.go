@@ -0,0 +1,73 @@
+package odbc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4/internal/sqlbuilder"
+)
+
+func TestTemplateSelect(t *testing.T) {
+	b := sqlbuilder.WithTemplate(template)
+	assert := assert.New(t)
+
+	assert.Equal(
+		`SELECT * FROM "artist"`,
+		b.SelectFrom("artist").String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" ORDER BY "name" DESC`,
+		b.Select().From("artist").OrderBy("-name").String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" FETCH FIRST 10 ROWS ONLY`,
+		b.SelectFrom("artist").Limit(10).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" ORDER BY "id" ASC OFFSET 5 ROWS FETCH FIRST 10 ROWS ONLY`,
+		b.SelectFrom("artist").OrderBy("id").Limit(10).Offset(5).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" WHERE ("name" = $1)`,
+		b.SelectFrom("artist").Where("name", "Haruki").String(),
+	)
+}
+
+func TestTemplateSelectAccess(t *testing.T) {
+	b := sqlbuilder.WithTemplate(accessTemplate)
+	assert := assert.New(t)
+
+	assert.Equal(
+		`SELECT * FROM [artist]`,
+		b.SelectFrom("artist").String(),
+	)
+
+	assert.Equal(
+		`SELECT TOP 10 * FROM [artist] ORDER BY [name] DESC`,
+		b.SelectFrom("artist").OrderBy("-name").Limit(10).String(),
+	)
+
+	assert.Equal(
+		`SELECT DISTINCT TOP 1 [name] FROM [artist]`,
+		b.Select().Distinct("name").From("artist").Limit(1).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM [artist] WHERE ([id] IN ($1, $2))`,
+		b.SelectFrom("artist").Where("id IN", []int{1, 9}).String(),
+	)
+}
+
+func TestTemplateInsert(t *testing.T) {
+	b := sqlbuilder.WithTemplate(accessTemplate)
+	assert := assert.New(t)
+
+	assert.Equal(
+		`INSERT INTO [artist] ([id], [name]) VALUES ($1, $2)`,
+		b.InsertInto("artist").Values(map[string]interface{}{"name": "Chavela", "id": 12}).String(),
+	)
+}
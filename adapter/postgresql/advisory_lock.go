@@ -0,0 +1,147 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	db "github.com/upper/db/v4"
+)
+
+// Lock is an advisory lock acquired with AdvisoryLock or TryAdvisoryLock.
+type Lock struct {
+	key int64
+
+	// conn is the dedicated connection that holds the lock, nil if the lock
+	// belongs to a transaction.
+	conn *sql.Conn
+
+	unlockOnce sync.Once
+	unlockErr  error
+}
+
+// Key returns the key of the lock.
+func (l *Lock) Key() int64 {
+	return l.key
+}
+
+// Unlock releases the lock. Locks acquired within a transaction can't be
+// released before the transaction ends, Unlock does nothing for them.
+func (l *Lock) Unlock() error {
+	l.unlockOnce.Do(func() {
+		if l.conn == nil {
+			return
+		}
+		defer l.conn.Close()
+
+		var released bool
+		err := l.conn.QueryRowContext(context.Background(), `SELECT pg_advisory_unlock($1)`, l.key).Scan(&released)
+		if err == nil && !released {
+			err = fmt.Errorf("advisory lock %d was not held", l.key)
+		}
+		l.unlockErr = err
+	})
+	return l.unlockErr
+}
+
+// AdvisoryLock acquires the application-defined advisory lock identified by
+// key, waiting until it's released by any other session that holds it or
+// until the context of the session is done:
+//
+//	lock, err := postgresql.AdvisoryLock(sess, jobID)
+//	if err != nil {
+//		return err
+//	}
+//	defer lock.Unlock()
+//
+// The lock is held by a dedicated connection of the pool until it's unlocked.
+// When sess is a transaction the lock is held by the transaction instead, and
+// it's released when the transaction is committed or rolled back.
+func AdvisoryLock(sess db.Session, key int64) (*Lock, error) {
+	lock, acquired, err := advisoryLock(sess, key,
+		`SELECT true FROM pg_advisory_lock($1)`,
+		`SELECT true FROM pg_advisory_xact_lock($1)`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, fmt.Errorf("could not acquire advisory lock %d", key)
+	}
+	return lock, nil
+}
+
+// TryAdvisoryLock is like AdvisoryLock but it does not wait for the lock to be
+// released, it returns a nil Lock if it's held by another session.
+func TryAdvisoryLock(sess db.Session, key int64) (*Lock, error) {
+	lock, acquired, err := advisoryLock(sess, key,
+		`SELECT pg_try_advisory_lock($1)`,
+		`SELECT pg_try_advisory_xact_lock($1)`,
+	)
+	if err != nil || !acquired {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// advisoryLock runs query, or xactQuery when sess is a transaction, to acquire
+// the lock. Both return whether the lock was acquired.
+func advisoryLock(sess db.Session, key int64, query string, xactQuery string) (*Lock, bool, error) {
+	ctx := sess.Context()
+
+	switch driver := sess.Driver().(type) {
+	case *sql.Tx:
+		acquired, err := queryAdvisoryLock(ctx, driver, xactQuery, key)
+		if err != nil || !acquired {
+			return nil, false, err
+		}
+		return &Lock{key: key}, true, nil
+	case *sql.DB:
+		conn, err := driver.Conn(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+		acquired, err := queryAdvisoryLock(ctx, conn, query, key)
+		if err != nil || !acquired {
+			conn.Close()
+			return nil, false, err
+		}
+		return &Lock{key: key, conn: conn}, true, nil
+	}
+
+	return nil, false, fmt.Errorf("%w: advisory locks on %T", db.ErrUnsupported, sess.Driver())
+}
+
+type rowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func queryAdvisoryLock(ctx context.Context, q rowQueryer, query string, key int64) (bool, error) {
+	var acquired bool
+	if err := q.QueryRowContext(ctx, query, key).Scan(&acquired); err != nil {
+		return false, err
+	}
+	return acquired, nil
+}
@@ -22,8 +22,15 @@
 package postgresql
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
 	db "github.com/upper/db/v4"
 	"github.com/upper/db/v4/internal/sqladapter"
+	"github.com/upper/db/v4/internal/sqlbuilder"
 )
 
 type collectionAdapter struct {
@@ -66,3 +73,335 @@ func (*collectionAdapter) Insert(col sqladapter.Collection, item interface{}) (i
 	// This was a compound key and no interface matched it, let's return a map.
 	return keyMap, nil
 }
+
+func (*collectionAdapter) InsertBatch(col sqladapter.Collection, columnNames []string, rows [][]interface{}) ([]interface{}, error) {
+	pKey := col.PrimaryKeys()
+
+	q := col.SQL().InsertInto(col.Name()).Columns(columnNames...)
+	for i := range rows {
+		q = q.Values(rows[i]...)
+	}
+
+	ids := make([]interface{}, len(rows))
+
+	if len(pKey) == 0 {
+		// There is no primary key.
+		if _, err := q.Exec(); err != nil {
+			return nil, err
+		}
+		return ids, nil
+	}
+
+	// Asking the database to return the primary keys of all the inserted rows,
+	// those are returned in the same order the rows were given.
+	q = q.Returning(pKey...)
+
+	var keyMaps []map[string]interface{}
+	if err := q.Iterator().All(&keyMaps); err != nil {
+		return nil, err
+	}
+
+	for i := range keyMaps {
+		if i >= len(ids) {
+			break
+		}
+		if len(pKey) == 1 {
+			ids[i] = keyMaps[i][pKey[0]]
+			continue
+		}
+		keyMap := db.Cond{}
+		for j := range pKey {
+			keyMap[pKey[j]] = keyMaps[i][pKey[j]]
+		}
+		ids[i] = keyMap
+	}
+
+	return ids, nil
+}
+
+func (*collectionAdapter) Upsert(col sqladapter.Collection, item interface{}, conflictColumns ...string) (interface{}, error) {
+	columnNames, columnValues, err := sqlbuilder.Map(item, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pKey := col.PrimaryKeys()
+
+	q := col.SQL().InsertInto(col.Name()).
+		Columns(columnNames...).
+		Values(columnValues...).
+		OnConflict(conflictColumns...).
+		DoUpdate(sqladapter.UpsertColumns(columnNames, conflictColumns)...)
+
+	if len(pKey) == 0 {
+		// There is no primary key.
+		if _, err := q.Exec(); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	// Asking the database to return the primary key of the inserted or updated
+	// row.
+	q = q.Returning(pKey...)
+
+	var keyMap db.Cond
+	if err := q.Iterator().One(&keyMap); err != nil {
+		return nil, err
+	}
+
+	if len(keyMap) == 1 {
+		return keyMap[pKey[0]], nil
+	}
+
+	return keyMap, nil
+}
+
+// InsertOrIgnore inserts item with ON CONFLICT DO NOTHING and returns its
+// primary key, or nil if the row was skipped.
+func (*collectionAdapter) InsertOrIgnore(col sqladapter.Collection, item interface{}) (interface{}, error) {
+	pKey := col.PrimaryKeys()
+
+	q := col.SQL().InsertInto(col.Name()).Values(item).Ignore()
+
+	if len(pKey) == 0 {
+		// There is no primary key.
+		if _, err := q.Exec(); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	var keyMap db.Cond
+	if err := q.Returning(pKey...).Iterator().One(&keyMap); err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			// The row conflicted with an existing one.
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if len(keyMap) == 1 {
+		return keyMap[pKey[0]], nil
+	}
+	return keyMap, nil
+}
+
+// InsertReturning inserts item and asks the database to return the whole row,
+// so values set by defaults and triggers are read in the same statement.
+func (*collectionAdapter) InsertReturning(col sqladapter.Collection, item interface{}, dst interface{}) error {
+	return col.SQL().InsertInto(col.Name()).Values(item).Returning("*").Iterator().One(dst)
+}
+
+// UpdateReturning updates the row matching conds and asks the database to
+// return it as stored after the update.
+func (*collectionAdapter) UpdateReturning(col sqladapter.Collection, item interface{}, conds db.Cond, dst interface{}) error {
+	return col.SQL().Update(col.Name()).Set(item).Where(conds).Returning("*").Iterator().One(dst)
+}
+
+// Analyze refreshes the statistics of the table used by the query planner.
+func (*collectionAdapter) Analyze(col sqladapter.Collection) error {
+	_, err := col.SQL().Exec("ANALYZE " + quotedTableName(col.Name()))
+	return err
+}
+
+// Vacuum reclaims the storage of dead rows so it can be reused by the table.
+// VACUUM can't run within a transaction.
+func (*collectionAdapter) Vacuum(col sqladapter.Collection) error {
+	_, err := col.SQL().Exec("VACUUM " + quotedTableName(col.Name()))
+	return err
+}
+
+// Stats reads the statistics of the table from the system catalogs. Rows is
+// the estimate of the query planner, which is refreshed by ANALYZE, VACUUM
+// and autovacuum; DataSize includes TOAST storage.
+func (*collectionAdapter) Stats(col sqladapter.Collection) (*db.CollectionStats, error) {
+	row, err := col.SQL().QueryRow(`
+		SELECT
+			GREATEST(c.reltuples, 0)::bigint,
+			pg_table_size(c.oid),
+			pg_indexes_size(c.oid),
+			GREATEST(s.last_analyze, s.last_autoanalyze)
+		FROM pg_class c
+		LEFT JOIN pg_stat_all_tables s ON s.relid = c.oid
+		WHERE c.oid = ?::regclass`, quotedTableName(col.Name()))
+	if err != nil {
+		return nil, err
+	}
+
+	var stats db.CollectionStats
+	var lastAnalyzed sql.NullTime
+	if err := row.Scan(&stats.Rows, &stats.DataSize, &stats.IndexSize, &lastAnalyzed); err != nil {
+		return nil, err
+	}
+	if lastAnalyzed.Valid {
+		stats.LastAnalyzed = &lastAnalyzed.Time
+	}
+	return &stats, nil
+}
+
+// Triggers reads the row-level triggers of the table from pg_trigger, Body is
+// the body of their functions.
+func (*collectionAdapter) Triggers(col sqladapter.Collection) ([]*db.Trigger, error) {
+	rows, err := col.SQL().Query(`
+		SELECT t.tgname, t.tgtype, p.prosrc
+		FROM pg_trigger t
+		JOIN pg_proc p ON p.oid = t.tgfoid
+		WHERE t.tgrelid = ?::regclass AND NOT t.tgisinternal
+		ORDER BY t.tgname`, quotedTableName(col.Name()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var triggers []*db.Trigger
+	for rows.Next() {
+		var trigger db.Trigger
+		var tgtype int
+		var body string
+		if err := rows.Scan(&trigger.Name, &tgtype, &body); err != nil {
+			return nil, err
+		}
+		// See TRIGGER_TYPE_* in src/include/catalog/pg_trigger.h.
+		switch {
+		case tgtype&64 != 0:
+			trigger.Timing = db.TriggerInsteadOf
+		case tgtype&2 != 0:
+			trigger.Timing = db.TriggerBefore
+		default:
+			trigger.Timing = db.TriggerAfter
+		}
+		if tgtype&4 != 0 {
+			trigger.Events |= db.TriggerInsert
+		}
+		if tgtype&8 != 0 {
+			trigger.Events |= db.TriggerDelete
+		}
+		if tgtype&16 != 0 {
+			trigger.Events |= db.TriggerUpdate
+		}
+		trigger.Body = sqladapter.TriggerBody(body)
+		triggers = append(triggers, &trigger)
+	}
+	return triggers, rows.Err()
+}
+
+// CreateTrigger creates the function of the trigger and the trigger itself.
+func (*collectionAdapter) CreateTrigger(col sqladapter.Collection, trigger *db.Trigger) error {
+	timing := trigger.Timing
+	if timing == "" {
+		timing = db.TriggerBefore
+	}
+	function := triggerFunction(col.Name(), trigger.Name)
+
+	// Question marks would be taken as placeholders.
+	body := strings.Replace(trigger.Body, "?", "??", -1)
+	_, err := col.SQL().Exec(fmt.Sprintf(
+		"CREATE FUNCTION %s() RETURNS trigger AS $upper$ %s $upper$ LANGUAGE plpgsql",
+		function, sqladapter.TriggerBlock(body),
+	))
+	if err != nil {
+		return err
+	}
+
+	_, err = col.SQL().Exec(fmt.Sprintf(
+		"CREATE TRIGGER %q %s %s ON %s FOR EACH ROW EXECUTE PROCEDURE %s()",
+		trigger.Name, timing, trigger.Events, quotedTableName(col.Name()), function,
+	))
+	return err
+}
+
+// DropTrigger drops the trigger and the function that was created for it.
+func (*collectionAdapter) DropTrigger(col sqladapter.Collection, name string) error {
+	_, err := col.SQL().Exec(fmt.Sprintf("DROP TRIGGER %q ON %s", name, quotedTableName(col.Name())))
+	if err != nil {
+		return err
+	}
+	_, err = col.SQL().Exec(fmt.Sprintf("DROP FUNCTION IF EXISTS %s()", triggerFunction(col.Name(), name)))
+	return err
+}
+
+// triggerFunction returns the quoted name of the function of a trigger, it's
+// created in the schema of the table.
+func triggerFunction(table string, trigger string) string {
+	chunks := strings.Split(table, ".")
+	chunks[len(chunks)-1] += "_" + trigger
+	return quotedTableName(strings.Join(chunks, "."))
+}
+
+// ModifyColumn returns an expression that applies the given modifiers to the
+// jsonb document stored in column.
+func (*collectionAdapter) ModifyColumn(column string, mods []*db.Modifier) (*db.RawExpr, error) {
+	expr := `"` + strings.Replace(column, `"`, `""`, -1) + `"`
+	args := []interface{}{}
+
+	for _, mod := range mods {
+		_, path := mod.Field()
+
+		var value string
+		if mod.Op != db.ModifierUnset {
+			v := mod.Value
+			if mod.Op == db.ModifierPush {
+				v = []interface{}{v}
+			}
+			buf, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			value = string(buf)
+		}
+
+		if len(path) == 0 {
+			switch mod.Op {
+			case db.ModifierSet:
+				expr, args = `?::jsonb`, []interface{}{value}
+			case db.ModifierUnset:
+				expr, args = `NULL`, []interface{}{}
+			case db.ModifierPush:
+				expr, args = `COALESCE(`+expr+`, '[]'::jsonb) || ?::jsonb`, append(args, value)
+			default:
+				return nil, fmt.Errorf("%w: modifier %d", db.ErrUnsupported, mod.Op)
+			}
+			continue
+		}
+
+		if mod.Op == db.ModifierUnset {
+			expr, args = `(`+expr+` #- ?::text[])`, append(args, jsonbPath(path))
+			continue
+		}
+
+		// jsonb_set() does not create missing parents, so every parent is set
+		// to its current value or to an empty object first. The current document
+		// is bound to "v" to avoid repeating its expression.
+		doc, docArgs := `COALESCE(v, '{}'::jsonb)`, []interface{}{}
+		for i := 1; i < len(path); i++ {
+			doc = `jsonb_set(` + doc + `, ?::text[], COALESCE(v #> ?::text[], '{}'::jsonb), true)`
+			docArgs = append(docArgs, jsonbPath(path[:i]), jsonbPath(path[:i]))
+		}
+
+		switch mod.Op {
+		case db.ModifierSet:
+			doc = `jsonb_set(` + doc + `, ?::text[], ?::jsonb, true)`
+			docArgs = append(docArgs, jsonbPath(path), value)
+		case db.ModifierPush:
+			doc = `jsonb_set(` + doc + `, ?::text[], COALESCE(v #> ?::text[], '[]'::jsonb) || ?::jsonb, true)`
+			docArgs = append(docArgs, jsonbPath(path), jsonbPath(path), value)
+		default:
+			return nil, fmt.Errorf("%w: modifier %d", db.ErrUnsupported, mod.Op)
+		}
+
+		expr = `(SELECT ` + doc + ` FROM (SELECT ` + expr + ` AS v) AS t)`
+		args = append(docArgs, args...)
+	}
+
+	return db.Raw(expr, args...), nil
+}
+
+// jsonbPath returns the text[] literal that represents the given path.
+func jsonbPath(path []string) string {
+	elems := make([]string, len(path))
+	for i := range path {
+		elems[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(path[i]) + `"`
+	}
+	return "{" + strings.Join(elems, ",") + "}"
+}
@@ -28,6 +28,7 @@ import (
 	"unicode"
 
 	"github.com/lib/pq"
+	db "github.com/upper/db/v4"
 )
 
 // scanner implements a tokenizer for libpq-style option strings.
@@ -93,7 +94,13 @@ type ConnectionURL struct {
 	Host     string
 	Socket   string
 	Database string
-	Options  map[string]string
+
+	// DefaultSchema is the schema where the names of collections that are not
+	// qualified with a schema are looked for. It's set as the search_path of
+	// the connections, unless Options has a search_path.
+	DefaultSchema string
+
+	Options map[string]string
 }
 
 var escaper = strings.NewReplacer(` `, `\ `, `'`, `\'`, `\`, `\\`)
@@ -136,6 +143,10 @@ func (c ConnectionURL) String() (s string) {
 		u = append(u, "dbname="+escaper.Replace(c.Database))
 	}
 
+	if _, ok := c.Options["search_path"]; !ok && c.DefaultSchema != "" {
+		u = append(u, "search_path="+escaper.Replace(c.DefaultSchema))
+	}
+
 	// Is there actually any connection data?
 	if len(u) == 0 {
 		return ""
@@ -151,12 +162,56 @@ func (c ConnectionURL) String() (s string) {
 	}
 
 	for k, v := range c.Options {
+		if db.IsPoolOption(k) {
+			continue
+		}
 		u = append(u, escaper.Replace(k)+"="+escaper.Replace(v))
 	}
 
 	return strings.Join(u, " ")
 }
 
+// PoolOptions returns the connection pool options, which are not passed to
+// the driver.
+func (c ConnectionURL) PoolOptions() map[string]string {
+	return db.PoolOptions(c.Options)
+}
+
+// Validate checks the connection settings before connecting.
+func (c ConnectionURL) Validate() error {
+	if c.Host != "" && c.Socket != "" {
+		return &db.ConnectionURLError{Field: "Socket", Reason: "can't be used along with Host", Err: db.ErrSockerOrHost}
+	}
+	if c.Host != "" {
+		if err := db.ValidateAddress("Host", c.Host); err != nil {
+			return err
+		}
+	}
+	switch c.Options["sslmode"] {
+	case "", "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		return db.NewConnectionURLError("Options[sslmode]", "must be one of disable, allow, prefer, require, verify-ca or verify-full")
+	}
+	return nil
+}
+
+// Redacted returns the DSN with the password replaced by db.RedactedPassword,
+// it's safe to log.
+func (c ConnectionURL) Redacted() string {
+	if c.Password != "" {
+		c.Password = db.RedactedPassword
+	}
+	if _, ok := c.Options["password"]; ok {
+		options := make(map[string]string, len(c.Options))
+		for k, v := range c.Options {
+			options[k] = v
+		}
+		options["password"] = db.RedactedPassword
+		c.Options = options
+	}
+	return c.String()
+}
+
 // ParseURL parses the given DSN into a ConnectionURL struct.
 // A typical PostgreSQL connection URL looks like:
 //
@@ -22,9 +22,11 @@
 package postgresql
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	db "github.com/upper/db/v4"
 )
 
 func TestConnectionURL(t *testing.T) {
@@ -62,6 +64,15 @@ func TestConnectionURL(t *testing.T) {
 		"sslmode": "verify-full",
 	}
 	assert.Equal(t, `user=Anakin password=Some\ Sort\ of\ \'\ Password host=localhost port=1234 dbname=MyDatabase sslmode=verify-full`, c.String())
+
+	// Setting the default schema.
+	c.DefaultSchema = "analytics"
+	assert.Equal(t, `user=Anakin password=Some\ Sort\ of\ \'\ Password host=localhost port=1234 dbname=MyDatabase search_path=analytics sslmode=verify-full`, c.String())
+
+	// A search_path option takes precedence.
+	c.Options["search_path"] = "reports"
+	assert.NotContains(t, c.String(), "search_path=analytics")
+	assert.Contains(t, c.String(), "search_path=reports")
 }
 
 func TestParseConnectionURL(t *testing.T) {
@@ -124,3 +135,35 @@ func TestParseConnectionURL(t *testing.T) {
 		assert.Equal(t, "UTC", u.Options["timezone"])
 	}
 }
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, ConnectionURL{Host: "localhost:5432", Database: "test"}.Validate())
+
+	var urlErr *db.ConnectionURLError
+
+	err := ConnectionURL{Host: "localhost", Socket: "/var/run/postgresql"}.Validate()
+	assert.True(t, errors.As(err, &urlErr))
+	assert.Equal(t, "Socket", urlErr.Field)
+	assert.True(t, errors.Is(err, db.ErrSockerOrHost))
+
+	err = ConnectionURL{Host: "localhost:port"}.Validate()
+	assert.True(t, errors.As(err, &urlErr))
+	assert.Equal(t, "Host", urlErr.Field)
+
+	err = ConnectionURL{Host: "localhost", Options: map[string]string{"sslmode": "maybe"}}.Validate()
+	assert.True(t, errors.As(err, &urlErr))
+	assert.Equal(t, "Options[sslmode]", urlErr.Field)
+
+	_, err = Open(ConnectionURL{Host: "localhost", Options: map[string]string{"max_open_conns": "many"}})
+	assert.True(t, errors.Is(err, db.ErrInvalidConnectionURL))
+}
+
+func TestRedacted(t *testing.T) {
+	c := ConnectionURL{User: "anakin", Password: "skywalker", Database: "jedis"}
+	assert.Equal(t, "user=anakin password=xxxxx dbname=jedis sslmode=disable", c.Redacted())
+	assert.Equal(t, "skywalker", c.Password)
+
+	c = ConnectionURL{User: "anakin", Database: "jedis", Options: map[string]string{"password": "skywalker", "sslmode": "disable"}}
+	assert.Contains(t, c.Redacted(), "password=xxxxx")
+	assert.Equal(t, "skywalker", c.Options["password"])
+}
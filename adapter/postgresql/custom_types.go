@@ -28,6 +28,7 @@ import (
 	"reflect"
 
 	"github.com/lib/pq"
+	db "github.com/upper/db/v4"
 	"github.com/upper/db/v4/internal/sqlbuilder"
 )
 
@@ -195,6 +196,112 @@ func (g *GenericArray) Scan(src interface{}) error {
 	return nil
 }
 
+// numberArray maps slices of integers and floats other than []int64 and
+// []float64 to PostgreSQL's numeric arrays, through Int64Array and
+// Float64Array.
+type numberArray struct {
+	v interface{}
+}
+
+func isFloatKind(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
+
+// Value satisfies the driver.Valuer interface.
+func (a *numberArray) Value() (driver.Value, error) {
+	rv := reflect.Indirect(reflect.ValueOf(a.v))
+	if rv.IsNil() {
+		return nil, nil
+	}
+
+	if isFloatKind(rv.Type().Elem().Kind()) {
+		f := make(Float64Array, rv.Len())
+		for i := range f {
+			f[i] = rv.Index(i).Float()
+		}
+		return f.Value()
+	}
+
+	n := make(Int64Array, rv.Len())
+	for i := range n {
+		switch elem := rv.Index(i); elem.Kind() {
+		case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n[i] = int64(elem.Uint())
+		default:
+			n[i] = elem.Int()
+		}
+	}
+	return n.Value()
+}
+
+// Scan satisfies the sql.Scanner interface.
+func (a *numberArray) Scan(src interface{}) error {
+	rv := reflect.ValueOf(a.v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("expecting a pointer to a slice")
+	}
+	rv = rv.Elem()
+
+	if src == nil {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+
+	var elems []reflect.Value
+	if isFloatKind(rv.Type().Elem().Kind()) {
+		var f Float64Array
+		if err := f.Scan(src); err != nil {
+			return err
+		}
+		for i := range f {
+			elems = append(elems, reflect.ValueOf(f[i]))
+		}
+	} else {
+		var n Int64Array
+		if err := n.Scan(src); err != nil {
+			return err
+		}
+		for i := range n {
+			elems = append(elems, reflect.ValueOf(n[i]))
+		}
+	}
+
+	dst := reflect.MakeSlice(rv.Type(), len(elems), len(elems))
+	for i := range elems {
+		dst.Index(i).Set(elems[i].Convert(rv.Type().Elem()))
+	}
+	rv.Set(dst)
+	return nil
+}
+
+// ArrayContains is a comparison that checks whether the array column
+// contains all the given values (`@>`):
+//
+//	col.Find(db.Cond{"tags": postgresql.ArrayContains([]string{"go", "sql"})})
+func ArrayContains(values interface{}) *db.Comparison {
+	return db.Op("@>", Array(values))
+}
+
+// ArrayContainedBy is a comparison that checks whether all the elements of
+// the array column are among the given values (`<@`).
+func ArrayContainedBy(values interface{}) *db.Comparison {
+	return db.Op("<@", Array(values))
+}
+
+// ArrayOverlaps is a comparison that checks whether the array column has any
+// element in common with the given values (`&&`).
+func ArrayOverlaps(values interface{}) *db.Comparison {
+	return db.Op("&&", Array(values))
+}
+
+// ArrayAny is a comparison that checks whether the given value is an element
+// of the array column (`value = ANY(column)`):
+//
+//	col.Find(db.Cond{"tags": postgresql.ArrayAny("go")})
+func ArrayAny(value interface{}) *db.Comparison {
+	return db.Op("? = ANY(:column)", value)
+}
+
 // JSONBMap represents a map of interfaces with string keys
 // (`map[string]interface{}`) that is compatible with PostgreSQL's JSONB type.
 // JSONBMap satisfies sqlbuilder.ScannerValuer.
@@ -323,6 +430,7 @@ var (
 	_ sqlbuilder.ScannerValuer = &Float64Array{}
 	_ sqlbuilder.ScannerValuer = &BoolArray{}
 	_ sqlbuilder.ScannerValuer = &GenericArray{}
+	_ sqlbuilder.ScannerValuer = &numberArray{}
 	_ sqlbuilder.ScannerValuer = &JSONBMap{}
 	_ sqlbuilder.ScannerValuer = &JSONBArray{}
 )
@@ -85,3 +85,43 @@ func TestScanJSONB(t *testing.T) {
 		assert.Equal(t, 12.34, a[0].V.V)
 	}
 }
+
+func TestNumberArray(t *testing.T) {
+	{
+		v, err := (&numberArray{[]int{1, -2, 3}}).Value()
+		assert.NoError(t, err)
+		assert.Equal(t, "{1,-2,3}", v)
+	}
+	{
+		v, err := (&numberArray{&[]uint16{1, 2}}).Value()
+		assert.NoError(t, err)
+		assert.Equal(t, "{1,2}", v)
+	}
+	{
+		v, err := (&numberArray{[]int32(nil)}).Value()
+		assert.NoError(t, err)
+		assert.Nil(t, v)
+	}
+	{
+		var ints []int32
+		err := (&numberArray{&ints}).Scan([]byte(`{1,-2,3}`))
+		assert.NoError(t, err)
+		assert.Equal(t, []int32{1, -2, 3}, ints)
+
+		err = (&numberArray{&ints}).Scan(nil)
+		assert.NoError(t, err)
+		assert.Nil(t, ints)
+	}
+	{
+		var floats []float32
+		err := (&numberArray{&floats}).Scan([]byte(`{1.5,2}`))
+		assert.NoError(t, err)
+		assert.Equal(t, []float32{1.5, 2}, floats)
+	}
+	{
+		var ints []int
+		err := (&numberArray{&ints}).Scan([]byte(`{}`))
+		assert.NoError(t, err)
+		assert.Equal(t, []int{}, ints)
+	}
+}
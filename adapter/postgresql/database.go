@@ -32,7 +32,7 @@ import (
 	"strings"
 	"time"
 
-	_ "github.com/lib/pq" // PostgreSQL driver.
+	pq "github.com/lib/pq" // PostgreSQL driver.
 	db "github.com/upper/db/v4"
 	"github.com/upper/db/v4/internal/sqladapter"
 	"github.com/upper/db/v4/internal/sqladapter/exql"
@@ -50,11 +50,14 @@ func (*database) OpenDSN(sess sqladapter.Session, dsn string) (*sql.DB, error) {
 	return sql.Open("postgres", dsn)
 }
 
+// Collections returns the tables of all the schemas, except for the system
+// ones. Tables of the current schema (the first one of the search_path) are
+// named as is, the other ones are qualified with their schema.
 func (*database) Collections(sess sqladapter.Session) (collections []string, err error) {
 	q := sess.SQL().
-		Select("table_name").
+		Select(db.Raw("CASE WHEN table_schema = CURRENT_SCHEMA() THEN table_name ELSE table_schema || '.' || table_name END")).
 		From("information_schema.tables").
-		Where("table_schema = ?", "public")
+		Where("table_schema NOT IN ? AND table_schema NOT LIKE ?", []string{"information_schema", "pg_catalog"}, `pg\_%`)
 
 	iter := q.Iterator()
 	defer iter.Close()
@@ -91,6 +94,8 @@ func (*database) ConvertValues(values []interface{}) []interface{} {
 			values[i] = (*BoolArray)(v)
 		case *map[string]interface{}:
 			values[i] = (*JSONBMap)(v)
+		case *[]int, *[]int8, *[]int16, *[]int32, *[]uint, *[]uint16, *[]uint32, *[]uint64, *[]float32:
+			values[i] = &numberArray{v}
 
 		case []int64:
 			values[i] = (*Int64Array)(&v)
@@ -102,6 +107,8 @@ func (*database) ConvertValues(values []interface{}) []interface{} {
 			values[i] = (*BoolArray)(&v)
 		case map[string]interface{}:
 			values[i] = (*JSONBMap)(&v)
+		case []int, []int8, []int16, []int32, []uint, []uint16, []uint32, []uint64, []float32:
+			values[i] = &numberArray{v}
 
 		case sqlbuilder.ValueWrapper:
 			values[i] = v.WrapValue(v)
@@ -132,10 +139,68 @@ func (*database) Err(err error) error {
 		if strings.Contains(s, `too many clients`) || strings.Contains(s, `remaining connection slots are reserved`) || strings.Contains(s, `too many open`) {
 			return db.ErrTooManyClients
 		}
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code {
+			case "23505":
+				return db.NewConstraintError(db.ErrDuplicateKey, err)
+			case "23503":
+				return db.NewConstraintError(db.ErrForeignKeyViolation, err)
+			case "23502":
+				return db.NewConstraintError(db.ErrNotNullViolation, err)
+			case "23514":
+				return db.NewConstraintError(db.ErrCheckViolation, err)
+			case "55P03":
+				return db.NewContentionError(db.ErrLockTimeout, err)
+			case "40P01":
+				return db.NewContentionError(db.ErrDeadlock, err)
+			}
+		}
 	}
 	return err
 }
 
+func (*database) Capabilities() db.Capability {
+	return db.CapabilityTransactions |
+		db.CapabilityReturning |
+		db.CapabilityCompositeKeys |
+		db.CapabilitySchemas |
+		db.CapabilitySavepoints
+}
+
+// ParseURL parses a connection URL of the adapter.
+func (*database) ParseURL(s string) (db.ConnectionURL, error) {
+	return ParseURL(s)
+}
+
+// SnapshotTransaction opens snapshots as SERIALIZABLE, READ ONLY, DEFERRABLE
+// transactions, which wait for a safe snapshot and then run without the risk
+// of serialization failures.
+func (*database) SnapshotTransaction() (*sql.TxOptions, string) {
+	return nil, "SET TRANSACTION ISOLATION LEVEL SERIALIZABLE, READ ONLY, DEFERRABLE"
+}
+
+// BlockedTransactions counts the backends of the current database that are
+// waiting for a lock.
+func (*database) BlockedTransactions(sess sqladapter.Session) (int, error) {
+	row, err := sess.SQL().QueryRow(`
+		SELECT COUNT(*) FROM pg_stat_activity
+		WHERE datname = CURRENT_DATABASE() AND wait_event_type = 'Lock'`)
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	if err := row.Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// RecursiveWith returns the keyword that starts recursive common table
+// expressions.
+func (*database) RecursiveWith() string {
+	return "WITH RECURSIVE"
+}
+
 func (*database) NewCollection() sqladapter.CollectionAdapter {
 	return &collectionAdapter{}
 }
@@ -158,11 +223,18 @@ func (*database) LookupName(sess sqladapter.Session) (string, error) {
 	return "", iter.Err()
 }
 
+// TableExists looks for the table in the given schema, if the name is
+// qualified, or in the schemas of the search_path otherwise.
 func (*database) TableExists(sess sqladapter.Session, name string) error {
 	q := sess.SQL().
 		Select("table_name").
-		From("information_schema.tables").
-		Where("table_catalog = ? AND table_name = ?", sess.Name(), name)
+		From("information_schema.tables")
+
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		q = q.Where("table_catalog = ? AND table_schema = ? AND table_name = ?", sess.Name(), name[:i], name[i+1:])
+	} else {
+		q = q.Where("table_catalog = ? AND table_schema = ANY(CURRENT_SCHEMAS(false)) AND table_name = ?", sess.Name(), name)
+	}
 
 	iter := q.Iterator()
 	defer iter.Close()
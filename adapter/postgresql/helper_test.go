@@ -161,6 +161,13 @@ func (h *Helper) TearUp() error {
 			value integer
 		)`,
 
+		`DROP TABLE IF EXISTS bool_flags`,
+		`CREATE TABLE bool_flags (
+			name varchar(60) primary key,
+			active char(1),
+			verified char(1)
+		)`,
+
 		`DROP TABLE IF EXISTS composite_keys`,
 		`CREATE TABLE composite_keys (
 			code varchar(255) default '',
@@ -303,6 +310,22 @@ func (h *Helper) TearUp() error {
 			id serial primary key,
 			message VARCHAR
 		)`,
+
+		`DROP TABLE IF EXISTS returning_rows`,
+		`CREATE TABLE returning_rows (
+			id serial primary key,
+			name varchar(60),
+			version integer NOT NULL DEFAULT 1,
+			created_at timestamp with time zone NOT NULL DEFAULT now()
+		)`,
+		`CREATE OR REPLACE FUNCTION bump_version() RETURNS trigger AS $$
+			BEGIN
+				NEW.version = OLD.version + 1;
+				RETURN NEW;
+			END;
+		$$ LANGUAGE plpgsql`,
+		`CREATE TRIGGER returning_rows_version BEFORE UPDATE ON returning_rows
+			FOR EACH ROW EXECUTE PROCEDURE bump_version()`,
 	}
 
 	driver := h.sess.Driver().(*sql.DB)
@@ -0,0 +1,186 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package postgresql
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	db "github.com/upper/db/v4"
+)
+
+const (
+	listenerMinReconnectInterval = 100 * time.Millisecond
+	listenerMaxReconnectInterval = time.Minute
+	listenerPingInterval         = time.Minute
+)
+
+// Notification is a message sent with NOTIFY to a channel a Listener is
+// listening on.
+type Notification struct {
+	// Channel is the name of the channel the notification was sent to.
+	Channel string
+
+	// Payload is the text that was sent along with the notification, if any.
+	Payload string
+
+	// PID is the process ID of the server session that sent the notification.
+	PID int
+}
+
+// Listener receives the notifications sent to one or more channels through
+// a dedicated connection, which is re-established if it's lost.
+type Listener struct {
+	// C delivers the notifications. A nil notification is delivered after the
+	// connection is re-established, as notifications sent while it was lost
+	// are missed. C is closed when the Listener is closed.
+	C <-chan *Notification
+
+	listener *pq.Listener
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// Listen opens a dedicated connection with the connection URL of the given
+// session and starts listening on the given channels:
+//
+//	l, err := postgresql.Listen(sess, "cache_invalidation")
+//	...
+//	defer l.Close()
+//
+//	for n := range l.C {
+//		if n == nil {
+//			// Reconnected, some notifications may have been missed.
+//			cache.Flush()
+//			continue
+//		}
+//		cache.Delete(n.Payload)
+//	}
+func Listen(sess db.Session, channels ...string) (*Listener, error) {
+	if len(channels) == 0 {
+		return nil, errors.New("missing channel name")
+	}
+
+	connURL := sess.ConnectionURL()
+	if connURL == nil {
+		return nil, db.ErrMissingConnURL
+	}
+
+	// Errors that happen before the first connection is established are
+	// returned, later ones are handled by reconnecting.
+	var connectedOnce sync.Once
+	connected := make(chan error, 1)
+	callback := func(event pq.ListenerEventType, err error) {
+		switch event {
+		case pq.ListenerEventConnected:
+			connectedOnce.Do(func() { connected <- nil })
+		case pq.ListenerEventConnectionAttemptFailed:
+			connectedOnce.Do(func() { connected <- err })
+		}
+	}
+
+	pqListener := pq.NewListener(connURL.String(), listenerMinReconnectInterval, listenerMaxReconnectInterval, callback)
+	if err := <-connected; err != nil {
+		_ = pqListener.Close()
+		return nil, db.RedactError(err, connURL)
+	}
+
+	for _, channel := range channels {
+		if err := pqListener.Listen(channel); err != nil {
+			_ = pqListener.Close()
+			return nil, err
+		}
+	}
+
+	c := make(chan *Notification)
+	l := &Listener{
+		C:        c,
+		listener: pqListener,
+		done:     make(chan struct{}),
+	}
+	go l.run(c)
+
+	return l, nil
+}
+
+func (l *Listener) run(c chan<- *Notification) {
+	defer close(c)
+
+	ping := time.NewTicker(listenerPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case n, ok := <-l.listener.Notify:
+			if !ok {
+				return
+			}
+			var notification *Notification
+			if n != nil {
+				notification = &Notification{Channel: n.Channel, Payload: n.Extra, PID: n.BePid}
+			}
+			select {
+			case c <- notification:
+			case <-l.done:
+				return
+			}
+		case <-ping.C:
+			// Detects connections that were lost without being closed.
+			go func() {
+				_ = l.listener.Ping()
+			}()
+		case <-l.done:
+			return
+		}
+	}
+}
+
+// Listen starts listening on another channel.
+func (l *Listener) Listen(channel string) error {
+	return l.listener.Listen(channel)
+}
+
+// Unlisten stops listening on the given channel.
+func (l *Listener) Unlisten(channel string) error {
+	return l.listener.Unlisten(channel)
+}
+
+// Close stops listening and closes the connection.
+func (l *Listener) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		close(l.done)
+		err = l.listener.Close()
+	})
+	return err
+}
+
+// Notify sends a notification with the given payload to a channel. Within a
+// transaction the notification is delivered when the transaction is
+// committed.
+func Notify(sess db.Session, channel string, payload string) error {
+	_, err := sess.SQL().Exec("SELECT pg_notify(?, ?)", channel, payload)
+	return err
+}
@@ -25,6 +25,7 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"math/rand"
 	"strconv"
@@ -768,6 +769,53 @@ func (s *AdapterTests) TestOptionTypeJsonbStruct() {
 	s.Equal(int64(123), item1Chk.Settings.Num)
 }
 
+func (s *AdapterTests) TestModifyJSONB() {
+	sess := s.Session()
+
+	optionTypes := sess.Collection("option_types")
+
+	err := optionTypes.Truncate()
+	s.NoError(err)
+
+	record, err := optionTypes.Insert(map[string]interface{}{
+		"name":     "Hi",
+		"settings": JSONBMap{"name": "a", "tmp": true},
+	})
+	s.NoError(err)
+
+	res := optionTypes.Find(record)
+
+	err = res.Modify(
+		db.SetPath("settings.prefs.theme", "dark"),
+		db.Unset("settings.tmp"),
+		db.Push("settings.tags", "x"),
+		db.Push("settings.tags", "y"),
+		db.SetPath("name", "Hello"),
+	)
+	s.NoError(err)
+
+	var item struct {
+		Name     string   `db:"name"`
+		Settings JSONBMap `db:"settings"`
+	}
+	err = res.One(&item)
+	s.NoError(err)
+
+	s.Equal("Hello", item.Name)
+	s.Equal(JSONBMap{
+		"name":  "a",
+		"prefs": map[string]interface{}{"theme": "dark"},
+		"tags":  []interface{}{"x", "y"},
+	}, item.Settings)
+
+	err = res.Modify(db.Unset("settings"))
+	s.NoError(err)
+
+	err = res.One(&item)
+	s.NoError(err)
+	s.Nil(item.Settings)
+}
+
 func (s *AdapterTests) TestSchemaCollection() {
 	sess := s.Session()
 
@@ -780,6 +828,62 @@ func (s *AdapterTests) TestSchemaCollection() {
 	s.Nil(err)
 	s.Equal(1, len(dump))
 	s.Equal(9, dump[0]["id"])
+
+	ok, err := col.Exists()
+	s.NoError(err)
+	s.True(ok)
+
+	// The table is not in the search_path.
+	ok, err = sess.Collection("test").Exists()
+	s.Error(err)
+	s.False(ok)
+
+	names := []string{}
+	collections, err := sess.Collections()
+	s.NoError(err)
+	for _, c := range collections {
+		names = append(names, c.Name())
+	}
+	s.Contains(names, "artist")
+	s.Contains(names, "test_schema.test")
+
+	s.NoError(col.Truncate())
+
+	count, err := col.Count()
+	s.NoError(err)
+	s.Zero(count)
+}
+
+func (s *AdapterTests) TestDefaultSchema() {
+	schemaSettings := settings
+	schemaSettings.DefaultSchema = "test_schema"
+
+	sess, err := Open(schemaSettings)
+	s.NoError(err)
+	defer sess.Close()
+
+	col := sess.Collection("test")
+
+	ok, err := col.Exists()
+	s.NoError(err)
+	s.True(ok)
+
+	_, err = col.Insert(map[string]int{"id": 10})
+	s.NoError(err)
+
+	count, err := sess.Collection("test_schema.test").Find(db.Cond{"id": 10}).Count()
+	s.NoError(err)
+	s.Equal(uint64(1), count)
+
+	// Tables of other schemas are qualified.
+	names := []string{}
+	collections, err := sess.Collections()
+	s.NoError(err)
+	for _, c := range collections {
+		names = append(names, c.Name())
+	}
+	s.Contains(names, "test")
+	s.Contains(names, "public.artist")
 }
 
 func (s *AdapterTests) Test_Issue340_MaxOpenConns() {
@@ -898,6 +1002,48 @@ func (s *AdapterTests) Test_Issue370_InsertUUID() {
 	}
 }
 
+func (s *AdapterTests) TestReturningComputedColumns() {
+	sess := s.Session()
+
+	type returningRow struct {
+		ID        int64     `db:"id,omitempty"`
+		Name      string    `db:"name"`
+		Version   int       `db:"version,omitempty"`
+		CreatedAt time.Time `db:"created_at,omitempty"`
+	}
+
+	col := sess.Collection("returning_rows")
+	err := col.Truncate()
+	s.NoError(err)
+
+	row := returningRow{Name: "Ozzie"}
+	err = col.InsertReturning(&row)
+	s.NoError(err)
+	s.NotZero(row.ID)
+	s.Equal("Ozzie", row.Name)
+	s.Equal(1, row.Version)
+	s.False(row.CreatedAt.IsZero())
+
+	row.Name = "Flea"
+	err = col.UpdateReturning(&row)
+	s.NoError(err)
+	s.Equal("Flea", row.Name)
+	s.Equal(2, row.Version, "the value set by the trigger is returned")
+
+	// Maps get all the columns of the row.
+	item := map[string]interface{}{"name": "Slash"}
+	err = col.InsertReturning(&item)
+	s.NoError(err)
+	s.NotNil(item["id"])
+	s.EqualValues(1, item["version"])
+	s.NotNil(item["created_at"])
+
+	// Nothing is updated when the row does not exist.
+	missing := returningRow{ID: row.ID + 100, Name: "Nobody"}
+	err = col.UpdateReturning(&missing)
+	s.Equal(db.ErrNoMoreRows, err)
+}
+
 func (s *AdapterTests) TestInsertVarcharPrimaryKey() {
 	sess := s.Session()
 
@@ -1193,6 +1339,69 @@ func (s *AdapterTests) TestPreparedStatements() {
 	}
 }
 
+func (s *AdapterTests) TestNativeArrays() {
+	sess := s.Session()
+	driver := sess.Driver().(*sql.DB)
+
+	defer func() {
+		_, _ = driver.Exec(`DROP TABLE IF EXISTS native_arrays`)
+	}()
+
+	_, err := driver.Exec(`
+		CREATE TABLE native_arrays (
+			id serial primary key,
+			tags text[],
+			scores integer[],
+			weights real[]
+		)`)
+	s.NoError(err)
+
+	type nativeArray struct {
+		ID      int64     `db:"id,omitempty"`
+		Tags    []string  `db:"tags"`
+		Scores  []int     `db:"scores"`
+		Weights []float32 `db:"weights"`
+	}
+
+	col := sess.Collection("native_arrays")
+
+	items := []nativeArray{
+		{Tags: []string{"go", "sql"}, Scores: []int{1, 2, 3}, Weights: []float32{0.5}},
+		{Tags: []string{"go"}, Scores: []int{4}, Weights: []float32{}},
+		{Tags: nil, Scores: nil, Weights: nil},
+	}
+	for i := range items {
+		res, err := col.Insert(items[i])
+		s.NoError(err)
+		items[i].ID = res.ID().(int64)
+	}
+
+	var all []nativeArray
+	err = col.Find().OrderBy("id").All(&all)
+	s.NoError(err)
+	s.Equal(items, all)
+
+	err = col.Find(items[1].ID).Update(map[string]interface{}{"scores": []int{5, 6}})
+	s.NoError(err)
+
+	var item nativeArray
+	err = col.Find(items[1].ID).One(&item)
+	s.NoError(err)
+	s.Equal([]int{5, 6}, item.Scores)
+
+	count := func(cond db.Cond) uint64 {
+		n, err := col.Find(cond).Count()
+		s.NoError(err)
+		return n
+	}
+
+	s.Equal(uint64(1), count(db.Cond{"tags": ArrayContains([]string{"go", "sql"})}))
+	s.Equal(uint64(2), count(db.Cond{"tags": ArrayContainedBy([]string{"go", "sql", "orm"})}))
+	s.Equal(uint64(2), count(db.Cond{"scores": ArrayOverlaps([]int{1, 6})}))
+	s.Equal(uint64(2), count(db.Cond{"tags": ArrayAny("go")}))
+	s.Equal(uint64(0), count(db.Cond{"tags": ArrayAny("orm")}))
+}
+
 func (s *AdapterTests) TestNonTrivialSubqueries() {
 	sess := s.Session()
 
@@ -1283,6 +1492,100 @@ func (s *AdapterTests) TestNonTrivialSubqueries() {
 	}
 }
 
+func (s *AdapterTests) TestAdvisoryLock() {
+	sess := s.Session()
+
+	lock, err := AdvisoryLock(sess, 42)
+	s.NoError(err)
+	s.Equal(int64(42), lock.Key())
+
+	// The lock is held by another connection.
+	other, err := TryAdvisoryLock(sess, 42)
+	s.NoError(err)
+	s.Nil(other)
+
+	s.NoError(lock.Unlock())
+	s.NoError(lock.Unlock())
+
+	other, err = TryAdvisoryLock(sess, 42)
+	s.NoError(err)
+	if s.NotNil(other) {
+		s.NoError(other.Unlock())
+	}
+
+	// Locks acquired within a transaction are released when it ends.
+	err = sess.Tx(func(tx db.Session) error {
+		lock, err := AdvisoryLock(tx, 42)
+		if err != nil {
+			return err
+		}
+
+		other, err := TryAdvisoryLock(sess, 42)
+		s.NoError(err)
+		s.Nil(other)
+
+		return lock.Unlock()
+	})
+	s.NoError(err)
+
+	other, err = TryAdvisoryLock(sess, 42)
+	s.NoError(err)
+	if s.NotNil(other) {
+		s.NoError(other.Unlock())
+	}
+}
+
+func (s *AdapterTests) TestListenNotify() {
+	sess := s.Session()
+
+	_, err := Listen(sess)
+	s.Error(err)
+
+	l, err := Listen(sess, "upper_events")
+	s.NoError(err)
+	defer l.Close()
+
+	s.NoError(Notify(sess, "upper_events", "hello"))
+
+	select {
+	case n := <-l.C:
+		s.NotNil(n)
+		s.Equal("upper_events", n.Channel)
+		s.Equal("hello", n.Payload)
+		s.NotZero(n.PID)
+	case <-time.After(5 * time.Second):
+		s.Fail("timed out waiting for notification")
+	}
+
+	// Notifications sent within a transaction are delivered on commit.
+	err = sess.Tx(func(tx db.Session) error {
+		if err := Notify(tx, "upper_events", "committed"); err != nil {
+			return err
+		}
+		select {
+		case <-l.C:
+			return errors.New("notification delivered before commit")
+		case <-time.After(100 * time.Millisecond):
+		}
+		return nil
+	})
+	s.NoError(err)
+
+	select {
+	case n := <-l.C:
+		s.NotNil(n)
+		s.Equal("committed", n.Payload)
+	case <-time.After(5 * time.Second):
+		s.Fail("timed out waiting for notification")
+	}
+
+	s.NoError(l.Unlisten("upper_events"))
+	s.NoError(l.Close())
+
+	_, ok := <-l.C
+	s.False(ok)
+}
+
 func TestAdapter(t *testing.T) {
 	suite.Run(t, &AdapterTests{})
 }
@@ -88,6 +88,11 @@ func TestTemplateSelect(t *testing.T) {
 		b.Select("id").From("artist").Where(`name LIKE ? OR name LIKE ?`, `%Miya%`, `F%`).String(),
 	)
 
+	assert.Equal(
+		`SELECT * FROM "artist" WHERE ("name" ILIKE $1 AND "name" NOT ILIKE $2)`,
+		b.SelectFrom("artist").Where(db.Cond{"name": db.ILike("%f%")}).And(db.Cond{"name": db.NotILike("%z%")}).String(),
+	)
+
 	assert.Equal(
 		`SELECT * FROM "artist" WHERE ("id" > $1)`,
 		b.SelectFrom("artist").Where("id >", 2).String(),
@@ -160,6 +165,21 @@ func TestTemplateSelect(t *testing.T) {
 		`SELECT DATE()`,
 		b.Select(db.Raw("DATE()")).String(),
 	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" WHERE ("tags" @> $1)`,
+		b.SelectFrom("artist").Where(db.Cond{"tags": ArrayContains([]string{"a", "b"})}).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" WHERE ("tags" <@ $1 AND "scores" && $2)`,
+		b.SelectFrom("artist").Where(db.Cond{"tags": ArrayContainedBy([]string{"a"})}, db.Cond{"scores": ArrayOverlaps([]int{1})}).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" WHERE ($1 = ANY("tags"))`,
+		b.SelectFrom("artist").Where(db.Cond{"tags": ArrayAny("a")}).String(),
+	)
 }
 
 func TestTemplateInsert(t *testing.T) {
@@ -180,6 +200,11 @@ func TestTemplateInsert(t *testing.T) {
 		b.InsertInto("artist").Values(map[string]string{"id": "12", "name": "Chavela Vargas"}).String(),
 	)
 
+	assert.Equal(
+		`INSERT INTO "artist" ("id", "name") VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		b.InsertInto("artist").Values(map[string]string{"id": "12", "name": "Chavela Vargas"}).Ignore().String(),
+	)
+
 	assert.Equal(
 		`INSERT INTO "artist" ("id", "name") VALUES ($1, $2) RETURNING "id"`,
 		b.InsertInto("artist").Values(map[string]string{"id": "12", "name": "Chavela Vargas"}).Returning("id").String(),
@@ -237,6 +262,11 @@ func TestTemplateUpdate(t *testing.T) {
 		}{"Artist"}).Set(map[string]string{"last_name": "Foo"}).Where(db.Cond{"id <": 5}).String(),
 	)
 
+	assert.Equal(
+		`UPDATE "artist" SET "name" = $1 WHERE ("id" = $2) RETURNING *`,
+		b.Update("artist").Set("name", "Artist").Where(db.Cond{"id": 5}).Returning("*").String(),
+	)
+
 	assert.Equal(
 		`UPDATE "artist" SET "name" = $1 || ' ' || $2 || id, "id" = id + $3 WHERE (id > $4)`,
 		b.Update("artist").Set(
@@ -41,7 +41,7 @@ func (r *resultProxy) Select(fields ...interface{}) db.Result {
 			var columns []struct {
 				Name string `db:"Name"`
 			}
-			err := r.col.SQL().Select("Name").
+			err := sqladapter.Unmasked(r.col.Session()).SQL().Select("Name").
 				From("__Column").
 				Where("TableName", r.col.Name()).
 				Iterator().All(&columns)
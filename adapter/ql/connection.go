@@ -26,6 +26,8 @@ import (
 	"net/url"
 	"path/filepath"
 	"strings"
+
+	db "github.com/upper/db/v4"
 )
 
 const connectionScheme = `file`
@@ -55,6 +57,9 @@ func (c ConnectionURL) String() (s string) {
 
 	// Converting options into URL values.
 	for k, v := range c.Options {
+		if db.IsPoolOption(k) {
+			continue
+		}
 		vv.Set(k, v)
 	}
 
@@ -68,6 +73,20 @@ func (c ConnectionURL) String() (s string) {
 	return u.String()
 }
 
+// PoolOptions returns the connection pool options, which are not passed to
+// the driver.
+func (c ConnectionURL) PoolOptions() map[string]string {
+	return db.PoolOptions(c.Options)
+}
+
+// Validate checks the connection settings before connecting.
+func (c ConnectionURL) Validate() error {
+	if c.Database == "" {
+		return db.NewConnectionURLError("Database", "is required")
+	}
+	return nil
+}
+
 // ParseURL parses s into a ConnectionURL struct.
 func ParseURL(s string) (conn ConnectionURL, err error) {
 	var u *url.URL
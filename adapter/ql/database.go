@@ -90,12 +90,13 @@ func (*database) StatementExec(sess sqladapter.Session, ctx context.Context, que
 		return compat.ExecContext(sess.Driver().(*sql.Tx), ctx, query, args)
 	}
 
-	sqlTx, err := compat.BeginTx(sess.Driver().(*sql.DB), ctx, nil)
+	sqlTx, err := compat.BeginTx(sess.Driver().(compat.TxStarter), ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	if res, err = compat.ExecContext(sqlTx, ctx, query, args); err != nil {
+		_ = sqlTx.Rollback()
 		return nil, err
 	}
 
@@ -106,6 +107,21 @@ func (*database) StatementExec(sess sqladapter.Session, ctx context.Context, que
 	return res, err
 }
 
+func (*database) Capabilities() db.Capability {
+	return db.CapabilityTransactions
+}
+
+// ParseURL parses a connection URL of the adapter.
+func (*database) ParseURL(s string) (db.ConnectionURL, error) {
+	return ParseURL(s)
+}
+
+// SnapshotTransaction opens snapshots as plain transactions, the QL driver
+// doesn't accept isolation levels.
+func (*database) SnapshotTransaction() (*sql.TxOptions, string) {
+	return nil, ""
+}
+
 func (*database) NewCollection() sqladapter.CollectionAdapter {
 	return &collectionAdapter{}
 }
@@ -39,3 +39,15 @@ func (s *SQLTests) SetupSuite() {
 func TestSQL(t *testing.T) {
 	suite.Run(t, &SQLTests{})
 }
+
+func (s *SQLTests) TestQLWriteAfterFailedStatement() {
+	sess := s.Session()
+
+	// QL allows a single write transaction at a time, a failed statement
+	// that leaves its transaction open blocks every write after it.
+	_, err := sess.SQL().InsertInto("artist").Columns("nonexistent").Values("Vinicius").Exec()
+	s.Error(err)
+
+	_, err = sess.Collection("artist").Insert(map[string]string{"name": "Vinicius"})
+	s.NoError(err)
+}
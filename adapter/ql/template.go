@@ -35,8 +35,10 @@ const (
 	adapterValueQuote          = `"{{.}}"`
 	adapterAndKeyword          = `&&`
 	adapterOrKeyword           = `||`
+	adapterNotKeyword          = `!`
 	adapterDescKeyword         = `DESC`
 	adapterAscKeyword          = `ASC`
+	adapterCurrentTimeKeyword  = `now()`
 	adapterAssignmentOperator  = `=`
 	adapterClauseGroup         = `({{.}})`
 	adapterClauseOperator      = ` {{.}} `
@@ -181,8 +183,10 @@ var template = &exql.Template{
 	ValueQuote:          adapterValueQuote,
 	AndKeyword:          adapterAndKeyword,
 	OrKeyword:           adapterOrKeyword,
+	NotKeyword:          adapterNotKeyword,
 	DescKeyword:         adapterDescKeyword,
 	AscKeyword:          adapterAscKeyword,
+	CurrentTimeKeyword:  adapterCurrentTimeKeyword,
 	AssignmentOperator:  adapterAssignmentOperator,
 	ClauseGroup:         adapterClauseGroup,
 	ClauseOperator:      adapterClauseOperator,
@@ -210,5 +214,8 @@ var template = &exql.Template{
 		adapter.ComparisonOperatorNotLike:   "!(:column LIKE ?)",
 		adapter.ComparisonOperatorRegExp:    "LIKE",
 		adapter.ComparisonOperatorNotRegExp: "!(:column LIKE ?)",
+		// The ? of the (?i) flag is escaped so it's not taken as a placeholder.
+		adapter.ComparisonOperatorILike:    `:column LIKE "(\x3fi)" + ?`,
+		adapter.ComparisonOperatorNotILike: `!(:column LIKE "(\x3fi)" + ?)`,
 	},
 }
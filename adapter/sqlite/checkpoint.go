@@ -0,0 +1,93 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sqlite
+
+import (
+	"fmt"
+
+	db "github.com/upper/db/v4"
+)
+
+// CheckpointMode defines how a WAL checkpoint deals with concurrent readers
+// and writers, see https://www.sqlite.org/pragma.html#pragma_wal_checkpoint.
+type CheckpointMode string
+
+// Checkpoint modes.
+const (
+	// CheckpointPassive checkpoints as many frames as possible without
+	// waiting for readers or writers.
+	CheckpointPassive CheckpointMode = "PASSIVE"
+
+	// CheckpointFull waits for writers and for readers of the log, then
+	// checkpoints all of it.
+	CheckpointFull CheckpointMode = "FULL"
+
+	// CheckpointRestart is like CheckpointFull, and it also waits for readers
+	// so the next writer starts the log from the beginning.
+	CheckpointRestart CheckpointMode = "RESTART"
+
+	// CheckpointTruncate is like CheckpointRestart, and it also truncates the
+	// log file to zero bytes.
+	CheckpointTruncate CheckpointMode = "TRUNCATE"
+)
+
+// CheckpointResult is the outcome of a WAL checkpoint.
+type CheckpointResult struct {
+	// Busy is true if the checkpoint could not complete because of concurrent
+	// readers or writers.
+	Busy bool
+
+	// LogFrames is the number of frames in the log, -1 if the database is not
+	// in WAL mode.
+	LogFrames int
+
+	// CheckpointedFrames is the number of frames of the log that were written
+	// back into the database, -1 if the database is not in WAL mode.
+	CheckpointedFrames int
+}
+
+// Checkpoint copies the content of the write-ahead log into the database,
+// which keeps the log from growing without bounds when readers prevent the
+// automatic checkpoints from completing:
+//
+//	res, err := sqlite.Checkpoint(sess, sqlite.CheckpointTruncate)
+func Checkpoint(sess db.Session, mode CheckpointMode) (*CheckpointResult, error) {
+	switch mode {
+	case CheckpointPassive, CheckpointFull, CheckpointRestart, CheckpointTruncate:
+	default:
+		return nil, fmt.Errorf("invalid checkpoint mode %q", mode)
+	}
+
+	row, err := sess.SQL().QueryRow(fmt.Sprintf("PRAGMA wal_checkpoint(%s)", mode))
+	if err != nil {
+		return nil, err
+	}
+
+	var busy int
+	var res CheckpointResult
+	if err := row.Scan(&busy, &res.LogFrames, &res.CheckpointedFrames); err != nil {
+		return nil, err
+	}
+	res.Busy = busy != 0
+
+	return &res, nil
+}
@@ -23,9 +23,14 @@ package sqlite
 
 import (
 	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
 
 	db "github.com/upper/db/v4"
 	"github.com/upper/db/v4/internal/sqladapter"
+	"github.com/upper/db/v4/internal/sqladapter/compat"
+	"github.com/upper/db/v4/internal/sqladapter/exql"
 	"github.com/upper/db/v4/internal/sqlbuilder"
 )
 
@@ -49,12 +54,125 @@ func (*collectionAdapter) Insert(col sqladapter.Collection, item interface{}) (i
 		return nil, err
 	}
 
-	if len(pKey) <= 1 {
+	if len(pKey) == 0 {
 		return res.LastInsertId()
 	}
 
+	// Keys given by the item are returned as they are.
 	keyMap := db.Cond{}
+	for i := range columnNames {
+		for j := 0; j < len(pKey); j++ {
+			if pKey[j] == columnNames[i] && columnValues[i] != nil {
+				keyMap[pKey[j]] = columnValues[i]
+			}
+		}
+	}
+
+	if len(pKey) > 1 {
+		return keyMap, nil
+	}
+
+	if id, ok := keyMap[pKey[0]]; ok {
+		return id, nil
+	}
+
+	// The key was generated, an INTEGER PRIMARY KEY is an alias of the rowid.
+	return res.LastInsertId()
+}
+
+func (*collectionAdapter) InsertBatch(col sqladapter.Collection, columnNames []string, rows [][]interface{}) ([]interface{}, error) {
+	pKey := col.PrimaryKeys()
+
+	q := col.SQL().InsertInto(col.Name()).Columns(columnNames...)
+	for i := range rows {
+		q = q.Values(rows[i]...)
+	}
+
+	res, err := q.Exec()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]interface{}, len(rows))
+	if len(pKey) == 0 {
+		return ids, nil
+	}
+
+	keyMaps := make([]db.Cond, len(rows))
+	generated := 0
+	for i := range rows {
+		keyMaps[i] = db.Cond{}
+		for j := range columnNames {
+			for k := 0; k < len(pKey); k++ {
+				if pKey[k] == columnNames[j] && rows[i][j] != nil {
+					keyMaps[i][pKey[k]] = rows[i][j]
+				}
+			}
+		}
+		if len(keyMaps[i]) < len(pKey) {
+			generated++
+		}
+	}
+
+	// LastInsertId() returns the rowid of the last row of the statement, which
+	// is the key of an INTEGER PRIMARY KEY table. Generated rowids of the same
+	// statement are consecutive, but rows with their own key may take any
+	// rowid, so keys are only derived when all of them were generated.
+	lastID, _ := res.LastInsertId()
+	if len(pKey) > 1 || generated != len(rows) {
+		lastID = 0
+	}
+
+	for i := range rows {
+		keyMap := keyMaps[i]
+
+		if lastID > 0 {
+			keyMap[pKey[0]] = lastID - int64(len(rows)-1-i)
+		}
+
+		if len(pKey) == 1 {
+			ids[i] = keyMap[pKey[0]]
+			continue
+		}
+		ids[i] = keyMap
+	}
+
+	return ids, nil
+}
+
+// InsertOrIgnore inserts item using INSERT OR IGNORE and returns its primary key,
+// or nil if the row was skipped.
+func (*collectionAdapter) InsertOrIgnore(col sqladapter.Collection, item interface{}) (interface{}, error) {
+	columnNames, columnValues, err := sqlbuilder.Map(item, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pKey := col.PrimaryKeys()
+
+	res, err := col.SQL().InsertInto(col.Name()).
+		Columns(columnNames...).
+		Values(columnValues...).
+		Ignore().
+		Exec()
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		// The row conflicted with an existing one.
+		return nil, nil
+	}
 
+	if len(pKey) <= 1 {
+		return res.LastInsertId()
+	}
+
+	keyMap := db.Cond{}
 	for i := range columnNames {
 		for j := 0; j < len(pKey); j++ {
 			if pKey[j] == columnNames[i] {
@@ -62,6 +180,159 @@ func (*collectionAdapter) Insert(col sqladapter.Collection, item interface{}) (i
 			}
 		}
 	}
-
 	return keyMap, nil
 }
+
+func (*collectionAdapter) Upsert(col sqladapter.Collection, item interface{}, conflictColumns ...string) (interface{}, error) {
+	return sqladapter.UpsertUsingLastInsertID(col, item, conflictColumns, nil)
+}
+
+// Analyze gathers the statistics of the table and its indexes into the
+// sqlite_stat1 table.
+func (*collectionAdapter) Analyze(col sqladapter.Collection) error {
+	table, err := exql.TableWithName(col.Name()).Compile(template)
+	if err != nil {
+		return err
+	}
+	_, err = col.SQL().Exec("ANALYZE " + table)
+	return err
+}
+
+// Vacuum rebuilds the database file to reclaim the storage of deleted rows.
+// SQLite can't vacuum a single table, the whole database is vacuumed. VACUUM
+// can't run within a transaction.
+func (*collectionAdapter) Vacuum(col sqladapter.Collection) error {
+	sess := col.Session()
+	if _, isTx := sess.Driver().(*sql.Tx); !isTx {
+		// Statements are executed within a transaction by default, which
+		// VACUUM does not allow.
+		driver, ok := sess.Driver().(compat.Execer)
+		if !ok {
+			return db.ErrNotConnected
+		}
+		_, err := driver.ExecContext(sess.Context(), "VACUUM")
+		return err
+	}
+	_, err := col.SQL().Exec("VACUUM")
+	return err
+}
+
+// Stats returns the number of rows estimated by the last ANALYZE, or counts
+// them if the table was never analyzed. Sizes are read from the dbstat
+// virtual table and are zero if SQLite was built without it. SQLite does not
+// record when a table was analyzed, LastAnalyzed is always nil.
+func (*collectionAdapter) Stats(col sqladapter.Collection) (*db.CollectionStats, error) {
+	var stats db.CollectionStats
+
+	var stat string
+	row, err := col.SQL().QueryRow(`SELECT stat FROM sqlite_stat1 WHERE tbl = ? ORDER BY idx IS NOT NULL LIMIT 1`, col.Name())
+	if err == nil {
+		err = row.Scan(&stat)
+	}
+	if err == nil {
+		if _, err = fmt.Sscanf(stat, "%d", &stats.Rows); err != nil {
+			return nil, err
+		}
+	} else {
+		// Not analyzed yet.
+		if stats.Rows, err = col.Count(); err != nil {
+			return nil, err
+		}
+	}
+
+	row, err = col.SQL().QueryRow(`
+		SELECT
+			COALESCE(SUM(CASE WHEN name = ? THEN pgsize ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN name <> ? THEN pgsize ELSE 0 END), 0)
+		FROM dbstat
+		WHERE name = ? OR name IN (SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ?)`,
+		col.Name(), col.Name(), col.Name(), col.Name())
+	if err == nil {
+		// dbstat may not be available.
+		_ = row.Scan(&stats.DataSize, &stats.IndexSize)
+	}
+
+	return &stats, nil
+}
+
+var (
+	reTriggerBegin  = regexp.MustCompile(`(?i)\bBEGIN\b`)
+	reTriggerTiming = regexp.MustCompile(`(?i)\b(BEFORE|AFTER|INSTEAD\s+OF)\s+(INSERT|UPDATE|DELETE)\b`)
+	reTriggerEvent  = regexp.MustCompile(`(?i)\b(INSERT|UPDATE|DELETE)\b`)
+)
+
+// Triggers reads the triggers of the table from sqlite_master. SQLite only
+// keeps the statement that created them, which is parsed.
+func (*collectionAdapter) Triggers(col sqladapter.Collection) ([]*db.Trigger, error) {
+	rows, err := col.SQL().Query(`SELECT name, sql FROM sqlite_master WHERE type = 'trigger' AND tbl_name = ? ORDER BY name`, col.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var triggers []*db.Trigger
+	for rows.Next() {
+		var trigger db.Trigger
+		var stmt string
+		if err := rows.Scan(&trigger.Name, &stmt); err != nil {
+			return nil, err
+		}
+
+		header := stmt
+		if loc := reTriggerBegin.FindStringIndex(stmt); loc != nil {
+			header = stmt[:loc[0]]
+			trigger.Body = sqladapter.TriggerBody(stmt[loc[0]:])
+		}
+		trigger.Timing = db.TriggerBefore
+		if m := reTriggerTiming.FindStringSubmatch(header); m != nil {
+			if timing := strings.ToUpper(m[1]); strings.HasPrefix(timing, "INSTEAD") {
+				trigger.Timing = db.TriggerInsteadOf
+			} else {
+				trigger.Timing = db.TriggerTiming(timing)
+			}
+			trigger.Events = db.ParseTriggerEvent(m[2])
+		} else if m := reTriggerEvent.FindStringSubmatch(header); m != nil {
+			trigger.Events = db.ParseTriggerEvent(m[1])
+		}
+
+		triggers = append(triggers, &trigger)
+	}
+	return triggers, rows.Err()
+}
+
+// CreateTrigger creates a trigger on the table. SQLite triggers have a single
+// event, INSTEAD OF triggers can only be created on views.
+func (*collectionAdapter) CreateTrigger(col sqladapter.Collection, trigger *db.Trigger) error {
+	if ev := trigger.Events; ev&(ev-1) != 0 {
+		return fmt.Errorf("%w: triggers with more than one event", db.ErrUnsupported)
+	}
+	timing := trigger.Timing
+	if timing == "" {
+		timing = db.TriggerBefore
+	}
+
+	name, err := exql.ColumnWithName(trigger.Name).Compile(template)
+	if err != nil {
+		return err
+	}
+	table, err := exql.TableWithName(col.Name()).Compile(template)
+	if err != nil {
+		return err
+	}
+
+	_, err = col.SQL().Exec(fmt.Sprintf(
+		"CREATE TRIGGER %s %s %s ON %s FOR EACH ROW %s",
+		name, timing, trigger.Events, table, sqladapter.TriggerBlock(trigger.Body),
+	))
+	return err
+}
+
+// DropTrigger drops a trigger of the table.
+func (*collectionAdapter) DropTrigger(col sqladapter.Collection, name string) error {
+	quoted, err := exql.ColumnWithName(name).Compile(template)
+	if err != nil {
+		return err
+	}
+	_, err = col.SQL().Exec("DROP TRIGGER " + quoted)
+	return err
+}
@@ -27,11 +27,33 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+
+	db "github.com/upper/db/v4"
 )
 
 const connectionScheme = `file`
 
-// ConnectionURL implements a SQLite connection struct.
+// memoryDatabase is the name of SQLite in-memory databases.
+const memoryDatabase = `:memory:`
+
+// ConnectionURL implements a SQLite connection struct. Set Database to
+// ":memory:" to use an in-memory database, the session keeps a single
+// connection open so the database lives as long as the session.
+//
+// Options are passed to the driver, except for connection pool options and
+// the following pragmas, which are applied with a PRAGMA statement on every
+// new connection: busy_timeout, journal_mode, journal_size_limit,
+// wal_autocheckpoint, synchronous, foreign_keys, cache_size, temp_store,
+// mmap_size, locking_mode, auto_vacuum, recursive_triggers, secure_delete and
+// case_sensitive_like.
+//
+//	sqlite.ConnectionURL{
+//	  Database: "app.db",
+//	  Options: map[string]string{
+//	    "journal_mode": "WAL",
+//	    "foreign_keys": "on",
+//	  },
+//	}
 type ConnectionURL struct {
 	Database string
 	Options  map[string]string
@@ -45,7 +67,7 @@ func (c ConnectionURL) String() (s string) {
 	}
 
 	// Did the user provided a full database path?
-	if !strings.HasPrefix(c.Database, "/") {
+	if c.Database != memoryDatabase && !strings.HasPrefix(c.Database, "/") {
 		c.Database, _ = filepath.Abs(c.Database)
 		if runtime.GOOS == "windows" {
 			// Closes https://github.com/upper/db/issues/60
@@ -58,15 +80,21 @@ func (c ConnectionURL) String() (s string) {
 		c.Options = map[string]string{}
 	}
 
-	if _, ok := c.Options["_busy_timeout"]; !ok {
-		c.Options["_busy_timeout"] = "10000"
-	}
+	setDefaultOptions(c.Options)
 
 	// Converting options into URL values.
 	for k, v := range c.Options {
+		if db.IsPoolOption(k) {
+			continue
+		}
 		vv.Set(k, v)
 	}
 
+	if c.Database == memoryDatabase {
+		// In-memory databases have no path: file::memory:?cache=shared
+		return connectionScheme + ":" + memoryDatabase + "?" + vv.Encode()
+	}
+
 	// Building URL.
 	u := url.URL{
 		Scheme:   connectionScheme,
@@ -77,11 +105,31 @@ func (c ConnectionURL) String() (s string) {
 	return u.String()
 }
 
-// ParseURL parses s into a ConnectionURL struct.
+// PoolOptions returns the connection pool options, which are not passed to
+// the driver.
+func (c ConnectionURL) PoolOptions() map[string]string {
+	return db.PoolOptions(c.Options)
+}
+
+// Validate checks the connection settings before connecting.
+func (c ConnectionURL) Validate() error {
+	if c.Database == "" {
+		return db.NewConnectionURLError("Database", "is required")
+	}
+	return nil
+}
+
+// ParseURL parses s into a ConnectionURL struct. Besides file:// URLs, it
+// accepts in-memory databases: ":memory:" or "file::memory:?cache=shared".
 func ParseURL(s string) (conn ConnectionURL, err error) {
 	var u *url.URL
 
-	if !strings.HasPrefix(s, connectionScheme+"://") {
+	if s == memoryDatabase {
+		s = connectionScheme + ":" + memoryDatabase
+	}
+
+	memory := strings.HasPrefix(s, connectionScheme+":"+memoryDatabase)
+	if !memory && !strings.HasPrefix(s, connectionScheme+"://") {
 		return conn, fmt.Errorf(`Expecting file:// connection scheme.`)
 	}
 
@@ -90,6 +138,9 @@ func ParseURL(s string) (conn ConnectionURL, err error) {
 	}
 
 	conn.Database = u.Host + u.Path
+	if memory {
+		conn.Database = memoryDatabase
+	}
 	conn.Options = map[string]string{}
 
 	var vv url.Values
@@ -102,9 +153,20 @@ func ParseURL(s string) (conn ConnectionURL, err error) {
 		conn.Options[k] = vv.Get(k)
 	}
 
-	if _, ok := conn.Options["cache"]; !ok {
+	// A shared cache would make in-memory databases visible to all the
+	// sessions in the process.
+	if _, ok := conn.Options["cache"]; !ok && !memory {
 		conn.Options["cache"] = "shared"
 	}
 
 	return conn, err
 }
+
+// isMemoryDSN returns true if dsn points to an in-memory database.
+func isMemoryDSN(dsn string) bool {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return false
+	}
+	return u.Opaque == memoryDatabase || u.Query().Get("mode") == "memory"
+}
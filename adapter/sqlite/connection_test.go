@@ -22,8 +22,11 @@
 package sqlite
 
 import (
+	"errors"
 	"path/filepath"
 	"testing"
+
+	db "github.com/upper/db/v4"
 )
 
 func TestConnectionURL(t *testing.T) {
@@ -61,6 +64,38 @@ func TestConnectionURL(t *testing.T) {
 		t.Fatal(`Test failed, got:`, c.String())
 	}
 
+	// Connection pool options are not passed to the driver.
+	c.Options["max_open_conns"] = "10"
+
+	if c.String() != `file:///another/database?_busy_timeout=10000&cache=foobar&mode=ro` {
+		t.Fatal(`Test failed, got:`, c.String())
+	}
+
+	if pool := c.PoolOptions(); len(pool) != 1 || pool["max_open_conns"] != "10" {
+		t.Fatal(`Expecting pool options, got:`, pool)
+	}
+
+	// In-memory databases have no path.
+	m := ConnectionURL{Database: ":memory:", Options: map[string]string{"cache": "shared"}}
+
+	if m.String() != `file::memory:?_busy_timeout=10000&cache=shared` {
+		t.Fatal(`Test failed, got:`, m.String())
+	}
+
+	if !isMemoryDSN(m.String()) || !isMemoryDSN("file:///tmp/test.db?mode=memory") || isMemoryDSN("file:///tmp/test.db") {
+		t.Fatal(`Expecting in-memory DSNs to be detected`)
+	}
+
+	// Setting busy_timeout replaces the default one.
+	c.Options = map[string]string{
+		"busy_timeout": "5000",
+		"journal_mode": "WAL",
+	}
+
+	if c.String() != `file:///another/database?busy_timeout=5000&journal_mode=WAL` {
+		t.Fatal(`Test failed, got:`, c.String())
+	}
+
 }
 
 func TestParseConnectionURL(t *testing.T) {
@@ -100,6 +135,24 @@ func TestParseConnectionURL(t *testing.T) {
 		t.Fatal("Expecting option.")
 	}
 
+	s = "file::memory:?cache=shared"
+
+	if u, err = ParseURL(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Database != ":memory:" || u.Options["cache"] != "shared" {
+		t.Fatal("Failed to parse in-memory database, got:", u)
+	}
+
+	if u, err = ParseURL(":memory:"); err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Database != ":memory:" || u.Options["cache"] != "" {
+		t.Fatal("Failed to parse in-memory database, got:", u)
+	}
+
 	s = "http://example.org"
 
 	if _, err = ParseURL(s); err == nil {
@@ -107,3 +160,12 @@ func TestParseConnectionURL(t *testing.T) {
 	}
 
 }
+
+func TestValidate(t *testing.T) {
+	_, err := Open(ConnectionURL{})
+
+	var urlErr *db.ConnectionURLError
+	if !errors.As(err, &urlErr) || urlErr.Field != "Database" {
+		t.Fatal("Expecting an error about the database, got:", err)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sqlite
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	db "github.com/upper/db/v4"
+)
+
+func TestContentionMetrics(t *testing.T) {
+	settings := ConnectionURL{
+		Database: filepath.Join(t.TempDir(), "contention.db"),
+		Options: map[string]string{
+			"busy_timeout": "10",
+		},
+	}
+
+	writer, err := Open(settings)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer writer.Close()
+
+	sess, err := Open(settings)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer sess.Close()
+
+	metrics := db.NewContentionMetrics()
+	sess.Use(metrics.Middleware())
+
+	_, err = writer.SQL().Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)")
+	assert.NoError(t, err)
+
+	err = writer.Tx(func(tx db.Session) error {
+		if _, err := tx.Collection("items").Insert(map[string]string{"name": "a"}); err != nil {
+			return err
+		}
+
+		// The writer holds the lock until the transaction ends.
+		_, err := sess.Collection("items").Insert(map[string]string{"name": "b"})
+		assert.True(t, errors.Is(err, db.ErrDatabaseBusy))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	stats := metrics.Stats()
+	assert.Equal(t, uint64(1), stats.BusyErrors)
+	assert.NotZero(t, stats.LockWaitTime)
+
+	_, err = db.BlockedTransactions(sess)
+	assert.Equal(t, db.ErrUnsupported, err)
+}
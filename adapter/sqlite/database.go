@@ -19,7 +19,9 @@
 // OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
 // WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
 
-// Package sqlite wraps the github.com/lib/sqlite SQLite driver. See
+// Package sqlite wraps the github.com/mattn/go-sqlite3 SQLite driver, or a
+// pure-Go driver registered as "sqlite" (such as modernc.org/sqlite) when
+// built with the sqlite_purego tag. See
 // https://github.com/upper/db/adapter/sqlite for documentation, particularities and
 // usage examples.
 package sqlite
@@ -29,7 +31,6 @@ import (
 	"database/sql"
 	"fmt"
 
-	_ "github.com/mattn/go-sqlite3" // SQLite3 driver.
 	db "github.com/upper/db/v4"
 	"github.com/upper/db/v4/internal/sqladapter"
 	"github.com/upper/db/v4/internal/sqladapter/compat"
@@ -45,7 +46,15 @@ func (*database) Template() *exql.Template {
 }
 
 func (*database) OpenDSN(sess sqladapter.Session, dsn string) (*sql.DB, error) {
-	return sql.Open("sqlite3", dsn)
+	if isMemoryDSN(dsn) {
+		// Every connection to an in-memory database gets its own database,
+		// which is lost when the connection is closed, so the pool is pinned
+		// to a single connection that is never closed.
+		sess.SetMaxOpenConns(1)
+		sess.SetMaxIdleConns(1)
+		sess.SetConnMaxLifetime(0)
+	}
+	return openDSN(dsn)
 }
 
 func (*database) Collections(sess sqladapter.Session) (collections []string, err error) {
@@ -76,12 +85,13 @@ func (*database) StatementExec(sess sqladapter.Session, ctx context.Context, que
 		return compat.ExecContext(sess.Driver().(*sql.Tx), ctx, query, args)
 	}
 
-	sqlTx, err := compat.BeginTx(sess.Driver().(*sql.DB), ctx, nil)
+	sqlTx, err := compat.BeginTx(sess.Driver().(compat.TxStarter), ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	if res, err = compat.ExecContext(sqlTx, ctx, query, args); err != nil {
+		_ = sqlTx.Rollback()
 		return nil, err
 	}
 
@@ -92,6 +102,39 @@ func (*database) StatementExec(sess sqladapter.Session, ctx context.Context, que
 	return res, err
 }
 
+func (*database) Err(err error) error {
+	if kind := constraintKind(err); kind != nil {
+		return db.NewConstraintError(kind, err)
+	}
+	if isBusy(err) {
+		return db.NewContentionError(db.ErrDatabaseBusy, err)
+	}
+	return err
+}
+
+func (*database) Capabilities() db.Capability {
+	return db.CapabilityTransactions |
+		db.CapabilityCompositeKeys |
+		db.CapabilitySavepoints
+}
+
+// ParseURL parses a connection URL of the adapter.
+func (*database) ParseURL(s string) (db.ConnectionURL, error) {
+	return ParseURL(s)
+}
+
+// SnapshotTransaction opens snapshots as plain transactions, SQLite
+// transactions are already serializable.
+func (*database) SnapshotTransaction() (*sql.TxOptions, string) {
+	return nil, ""
+}
+
+// RecursiveWith returns the keyword that starts recursive common table
+// expressions.
+func (*database) RecursiveWith() string {
+	return "WITH RECURSIVE"
+}
+
 func (*database) NewCollection() sqladapter.CollectionAdapter {
 	return &collectionAdapter{}
 }
@@ -0,0 +1,85 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+//go:build !sqlite_purego
+// +build !sqlite_purego
+
+package sqlite
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	sqlite3 "github.com/mattn/go-sqlite3" // SQLite3 driver.
+	db "github.com/upper/db/v4"
+)
+
+// driverName is the name github.com/mattn/go-sqlite3 registers itself with.
+const driverName = "sqlite3"
+
+// customFunctions is true because the driver can register Go functions on
+// each connection.
+const customFunctions = true
+
+func setDefaultOptions(options map[string]string) {
+	_, busyTimeout := options["busy_timeout"]
+	if _, ok := options["_busy_timeout"]; !ok && !busyTimeout {
+		options["_busy_timeout"] = "10000"
+	}
+}
+
+// constraintKind returns the kind of constraint err violates, if any.
+func constraintKind(err error) error {
+	if sqliteErr, ok := err.(sqlite3.Error); ok {
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return db.ErrDuplicateKey
+		case sqlite3.ErrConstraintForeignKey:
+			return db.ErrForeignKeyViolation
+		case sqlite3.ErrConstraintNotNull:
+			return db.ErrNotNullViolation
+		case sqlite3.ErrConstraintCheck:
+			return db.ErrCheckViolation
+		}
+	}
+	return nil
+}
+
+// isBusy returns true if err was caused by a lock held by another connection
+// or by another statement of the same connection.
+func isBusy(err error) bool {
+	if sqliteErr, ok := err.(sqlite3.Error); ok {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	return false
+}
+
+// registerFunction registers fn on the given connection.
+func registerFunction(conn driver.Conn, fn function) error {
+	sqliteConn, ok := conn.(*sqlite3.SQLiteConn)
+	if !ok {
+		return fmt.Errorf("expecting a *sqlite3.SQLiteConn, got %T", conn)
+	}
+	if fn.aggregate {
+		return sqliteConn.RegisterAggregator(fn.name, fn.impl, fn.pure)
+	}
+	return sqliteConn.RegisterFunc(fn.name, fn.impl, fn.pure)
+}
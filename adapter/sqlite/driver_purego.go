@@ -0,0 +1,86 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+//go:build sqlite_purego
+// +build sqlite_purego
+
+package sqlite
+
+import (
+	"database/sql/driver"
+	"strings"
+
+	db "github.com/upper/db/v4"
+)
+
+// driverName is the name pure-Go drivers, such as modernc.org/sqlite,
+// register themselves with. The adapter does not import the driver, so
+// binaries can be built without cgo by importing it along the adapter:
+//
+//	import _ "modernc.org/sqlite"
+const driverName = "sqlite"
+
+// customFunctions is false because pure-Go drivers register functions
+// globally through their own APIs, such as
+// modernc.org/sqlite.RegisterScalarFunction.
+const customFunctions = false
+
+func setDefaultOptions(options map[string]string) {
+	_, busyTimeout := options["busy_timeout"]
+	if _, ok := options["_pragma"]; !ok && !busyTimeout {
+		options["_pragma"] = "busy_timeout(10000)"
+	}
+}
+
+// constraintKind returns the kind of constraint err violates, if any. Error
+// types differ from driver to driver, but the messages come from SQLite.
+func constraintKind(err error) error {
+	if err == nil {
+		return nil
+	}
+	s := err.Error()
+	switch {
+	case strings.Contains(s, "UNIQUE constraint failed"), strings.Contains(s, "PRIMARY KEY constraint failed"):
+		return db.ErrDuplicateKey
+	case strings.Contains(s, "FOREIGN KEY constraint failed"):
+		return db.ErrForeignKeyViolation
+	case strings.Contains(s, "NOT NULL constraint failed"):
+		return db.ErrNotNullViolation
+	case strings.Contains(s, "CHECK constraint failed"):
+		return db.ErrCheckViolation
+	}
+	return nil
+}
+
+// isBusy returns true if err was caused by a lock held by another connection
+// or by another statement of the same connection.
+func isBusy(err error) bool {
+	if err == nil {
+		return false
+	}
+	s := err.Error()
+	return strings.Contains(s, "database is locked") || strings.Contains(s, "database table is locked")
+}
+
+// registerFunction is never called, as no functions can be registered.
+func registerFunction(conn driver.Conn, fn function) error {
+	return nil
+}
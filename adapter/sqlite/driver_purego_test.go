@@ -0,0 +1,44 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+//go:build sqlite_purego
+// +build sqlite_purego
+
+package sqlite
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	db "github.com/upper/db/v4"
+)
+
+func TestConstraintKind(t *testing.T) {
+	assert.Equal(t, db.ErrDuplicateKey, constraintKind(errors.New("constraint failed: UNIQUE constraint failed: users.username (2067)")))
+	assert.Equal(t, db.ErrNotNullViolation, constraintKind(errors.New("constraint failed: NOT NULL constraint failed: users.id (1299)")))
+	assert.Nil(t, constraintKind(errors.New("no such table: users")))
+	assert.Nil(t, constraintKind(nil))
+
+	options := map[string]string{}
+	setDefaultOptions(options)
+	assert.Equal(t, "busy_timeout(10000)", options["_pragma"])
+}
@@ -0,0 +1,344 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sqlite
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	db "github.com/upper/db/v4"
+)
+
+func openMemoryAccounts(t *testing.T) db.Session {
+	sess, err := Open(ConnectionURL{Database: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sess.SQL().Exec(`CREATE TABLE accounts (id integer primary key, name text, balance integer)`); err != nil {
+		t.Fatal(err)
+	}
+	return sess
+}
+
+func TestDualWrite(t *testing.T) {
+	primary := openMemoryAccounts(t)
+	secondary := openMemoryAccounts(t)
+
+	sess := db.DualWrite(primary, secondary)
+	defer sess.Close()
+
+	var divergences []db.Divergence
+	sess.OnDivergence(func(d db.Divergence) {
+		divergences = append(divergences, d)
+	})
+
+	type account struct {
+		ID      int64  `db:"id,omitempty"`
+		Name    string `db:"name"`
+		Balance int64  `db:"balance"`
+	}
+
+	count := func(s db.Session, cond db.Cond) uint64 {
+		n, err := s.Collection("accounts").Find(cond).Count()
+		assert.NoError(t, err)
+		return n
+	}
+
+	accounts := sess.Collection("accounts")
+
+	// The secondary gets a row with the same ID.
+	_, err := primary.SQL().Exec(`INSERT INTO accounts (id, name, balance) VALUES (10, 'skipped', 0)`)
+	assert.NoError(t, err)
+
+	res, err := accounts.Insert(account{Name: "Ann", Balance: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count(secondary, db.Cond{"id": res.ID(), "name": "Ann"}))
+
+	_, err = accounts.InsertMany([]account{{Name: "Bob", Balance: 20}, {Name: "Cid", Balance: 30}}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), count(secondary, db.Cond{}))
+
+	// Writes through result sets are replayed with the same conditions.
+	assert.NoError(t, accounts.Find(db.Cond{"name": "Bob"}).Limit(1).Update(map[string]interface{}{"balance": 25}))
+	assert.Equal(t, uint64(1), count(secondary, db.Cond{"name": "Bob", "balance": 25}))
+
+	assert.NoError(t, accounts.Find(db.Cond{"name": "Cid"}).Delete())
+	assert.Equal(t, uint64(0), count(secondary, db.Cond{"name": "Cid"}))
+
+	// Transactions are mirrored after being committed.
+	err = sess.Tx(func(tx db.Session) error {
+		_, err := tx.Collection("accounts").Insert(account{Name: "Dee"})
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count(secondary, db.Cond{"name": "Dee"}))
+
+	err = sess.Tx(func(tx db.Session) error {
+		_, err := tx.Collection("accounts").Insert(account{Name: "Eve"})
+		assert.NoError(t, err)
+		return errors.New("rolled back")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, uint64(0), count(primary, db.Cond{"name": "Eve"}))
+	assert.Equal(t, uint64(0), count(secondary, db.Cond{"name": "Eve"}))
+
+	assert.Empty(t, divergences)
+
+	// Failures on the secondary are reported, not returned.
+	_, err = secondary.SQL().Exec(`DROP TABLE accounts`)
+	assert.NoError(t, err)
+
+	_, err = accounts.Insert(account{Name: "Fay"})
+	assert.NoError(t, err)
+	if assert.Len(t, divergences, 1) {
+		assert.Equal(t, "accounts", divergences[0].Collection)
+		assert.Equal(t, "Insert", divergences[0].Operation)
+		assert.Error(t, divergences[0].Err)
+	}
+
+	// After the cutover reads come from the secondary.
+	_, err = secondary.SQL().Exec(`CREATE TABLE accounts (id integer primary key, name text, balance integer)`)
+	assert.NoError(t, err)
+
+	sess.SetCutover(true)
+	assert.True(t, sess.Cutover())
+
+	assert.Equal(t, uint64(0), count(sess, db.Cond{}))
+
+	_, err = accounts.Insert(account{Name: "Gus"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count(sess, db.Cond{}))
+	assert.Equal(t, uint64(1), count(primary, db.Cond{"name": "Gus"}))
+}
+
+func TestDualWriteResultCounts(t *testing.T) {
+	primary := openMemoryAccounts(t)
+	secondary := openMemoryAccounts(t)
+
+	sess := db.DualWrite(primary, secondary)
+	defer sess.Close()
+
+	var divergences []db.Divergence
+	sess.OnDivergence(func(d db.Divergence) {
+		divergences = append(divergences, d)
+	})
+
+	accounts := sess.Collection("accounts")
+	for _, name := range []string{"Ann", "Bob", "Cid"} {
+		_, err := accounts.Insert(map[string]interface{}{"name": name, "balance": 10})
+		assert.NoError(t, err)
+	}
+
+	n, err := accounts.Find(db.Cond{"name IN": []string{"Ann", "Bob"}}).UpdateCount(map[string]interface{}{"balance": 20})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), n)
+
+	n, err = secondary.Collection("accounts").Find(db.Cond{"balance": 20}).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), n)
+
+	n, err = accounts.Find(db.Cond{"name": "Cid"}).DeleteCount()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), n)
+
+	n, err = secondary.Collection("accounts").Find().Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), n)
+
+	assert.Empty(t, divergences)
+
+	// Failures on the secondary are reported.
+	_, err = secondary.SQL().Exec(`DROP TABLE accounts`)
+	assert.NoError(t, err)
+
+	_, err = accounts.Find(db.Cond{"name": "Ann"}).UpdateCount(map[string]interface{}{"balance": 30})
+	assert.NoError(t, err)
+	_, err = accounts.Find(db.Cond{"name": "Ann"}).DeleteCount()
+	assert.NoError(t, err)
+	if assert.Len(t, divergences, 2) {
+		assert.Equal(t, "UpdateCount", divergences[0].Operation)
+		assert.Equal(t, "DeleteCount", divergences[1].Operation)
+	}
+}
+
+func TestDualWriteResultReturning(t *testing.T) {
+	primary := openMemoryAccounts(t)
+	secondary := openMemoryAccounts(t)
+
+	sess := db.DualWrite(primary, secondary)
+	defer sess.Close()
+
+	var divergences []db.Divergence
+	sess.OnDivergence(func(d db.Divergence) {
+		divergences = append(divergences, d)
+	})
+
+	type account struct {
+		ID      int64  `db:"id,omitempty"`
+		Name    string `db:"name"`
+		Balance int64  `db:"balance"`
+	}
+
+	accounts := sess.Collection("accounts")
+	for _, name := range []string{"Ann", "Bob", "Cid"} {
+		_, err := accounts.Insert(account{Name: name, Balance: 10})
+		assert.NoError(t, err)
+	}
+
+	var updated []account
+	err := accounts.Find().OrderBy("name").Limit(2).UpdateReturning(map[string]interface{}{"balance": 20}, &updated)
+	assert.NoError(t, err)
+	assert.Len(t, updated, 2)
+
+	n, err := secondary.Collection("accounts").Find(db.Cond{"balance": 20}).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), n)
+
+	// Popping a row removes the same one from the secondary.
+	var popped account
+	assert.NoError(t, accounts.Find().OrderBy("-name").DeleteReturning(&popped))
+	assert.Equal(t, "Cid", popped.Name)
+
+	var names []string
+	for _, store := range []db.Session{primary, secondary} {
+		var rest []account
+		assert.NoError(t, store.Collection("accounts").Find().OrderBy("name").All(&rest))
+		for _, item := range rest {
+			names = append(names, item.Name)
+		}
+	}
+	assert.Equal(t, []string{"Ann", "Bob", "Ann", "Bob"}, names)
+
+	assert.Empty(t, divergences)
+
+	// Failures on the secondary are reported.
+	_, err = secondary.SQL().Exec(`DROP TABLE accounts`)
+	assert.NoError(t, err)
+
+	assert.NoError(t, accounts.Find(db.Cond{"name": "Ann"}).UpdateReturning(map[string]interface{}{"balance": 30}, &popped))
+	assert.NoError(t, accounts.Find(db.Cond{"name": "Ann"}).DeleteReturning(&popped))
+	if assert.Len(t, divergences, 2) {
+		assert.Equal(t, "UpdateReturning", divergences[0].Operation)
+		assert.Equal(t, "DeleteReturning", divergences[1].Operation)
+	}
+}
+
+func TestDualWriteShadowReads(t *testing.T) {
+	primary := openMemoryAccounts(t)
+	secondary := openMemoryAccounts(t)
+
+	sess := db.DualWrite(primary, secondary)
+	defer sess.Close()
+
+	var mu sync.Mutex
+	var mismatches []db.ReadMismatch
+	sess.OnReadMismatch(func(m db.ReadMismatch) {
+		mu.Lock()
+		mismatches = append(mismatches, m)
+		mu.Unlock()
+	})
+
+	type account struct {
+		ID      int64  `db:"id,omitempty"`
+		Name    string `db:"name"`
+		Balance int64  `db:"balance"`
+	}
+
+	accounts := sess.Collection("accounts")
+
+	_, err := accounts.InsertMany([]account{{Name: "Ann", Balance: 10}, {Name: "Bob", Balance: 20}}, 0)
+	assert.NoError(t, err)
+
+	read := func() {
+		var all []account
+		assert.NoError(t, accounts.Find().OrderBy("id").All(&all))
+
+		var one account
+		assert.NoError(t, accounts.Find(db.Cond{"name": "Bob"}).One(&one))
+
+		_, err := accounts.Count()
+		assert.NoError(t, err)
+
+		sess.WaitShadowReads()
+	}
+
+	// Shadow reads are disabled by default.
+	_, err = secondary.SQL().Exec(`UPDATE accounts SET balance = 0`)
+	assert.NoError(t, err)
+
+	read()
+	assert.Empty(t, mismatches)
+
+	_, err = secondary.SQL().Exec(`UPDATE accounts SET balance = id * 10`)
+	assert.NoError(t, err)
+
+	sess.SetShadowReads(100)
+	assert.Equal(t, float64(100), sess.ShadowReads())
+
+	read()
+	assert.Empty(t, mismatches)
+
+	// Values that differ are reported.
+	_, err = secondary.SQL().Exec(`UPDATE accounts SET balance = 0 WHERE name = 'Bob'`)
+	assert.NoError(t, err)
+
+	read()
+	if assert.Len(t, mismatches, 2) {
+		ops := []string{mismatches[0].Operation, mismatches[1].Operation}
+		assert.ElementsMatch(t, []string{"All", "One"}, ops)
+		for _, m := range mismatches {
+			assert.Equal(t, "accounts", m.Collection)
+			assert.Equal(t, m.MainRows, m.MirrorRows)
+			assert.NotEqual(t, m.MainChecksum, m.MirrorChecksum)
+		}
+	}
+
+	// So are missing rows.
+	mismatches = nil
+
+	_, err = secondary.SQL().Exec(`DELETE FROM accounts WHERE name = 'Bob'`)
+	assert.NoError(t, err)
+
+	read()
+	if assert.Len(t, mismatches, 3) {
+		for _, m := range mismatches {
+			if m.Operation == "Count" {
+				assert.Equal(t, uint64(2), m.MainRows)
+				assert.Equal(t, uint64(1), m.MirrorRows)
+			}
+		}
+	}
+
+	// Reads within transactions are not shadowed.
+	mismatches = nil
+
+	err = sess.Tx(func(tx db.Session) error {
+		_, err := tx.Collection("accounts").Count()
+		return err
+	})
+	assert.NoError(t, err)
+
+	sess.WaitShadowReads()
+	assert.Empty(t, mismatches)
+}
@@ -0,0 +1,251 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/dbcache"
+)
+
+type cachedAccount struct {
+	ID      int64  `db:"id,omitempty"`
+	Name    string `db:"name"`
+	Balance int64  `db:"balance"`
+}
+
+func (*cachedAccount) Store(sess db.Session) db.Store {
+	return sess.Collection("accounts")
+}
+
+func TestEntityCache(t *testing.T) {
+	conn := openMemoryAccounts(t)
+	defer conn.Close()
+
+	entities, err := db.NewEntityCache(100)
+	assert.NoError(t, err)
+
+	sess := db.WithEntityCache(conn, entities)
+
+	type invalidation struct {
+		collection string
+		id         interface{}
+	}
+	var invalidations []invalidation
+	sess.OnInvalidate(func(collection string, id interface{}) {
+		invalidations = append(invalidations, invalidation{collection, id})
+	})
+
+	_, err = conn.SQL().Exec(`INSERT INTO accounts (id, name, balance) VALUES (1, 'Ann', 10), (2, 'Bob', 20)`)
+	assert.NoError(t, err)
+
+	// Writes the database behind the back of the session.
+	setBalance := func(id int64, balance int64) {
+		_, err := conn.SQL().Exec(`UPDATE accounts SET balance = ? WHERE id = ?`, balance, id)
+		assert.NoError(t, err)
+	}
+
+	balance := func(id int64) int64 {
+		var account cachedAccount
+		assert.NoError(t, sess.Get(&account, id))
+		return account.Balance
+	}
+
+	// Lookups by primary key are cached.
+	assert.Equal(t, int64(10), balance(1))
+	setBalance(1, 11)
+	assert.Equal(t, int64(10), balance(1))
+
+	var account cachedAccount
+	assert.NoError(t, sess.Collection("accounts").Find(1).One(&account))
+	assert.Equal(t, int64(10), account.Balance)
+
+	// Other lookups are not.
+	assert.NoError(t, sess.Collection("accounts").Find(db.Cond{"id": 1}).One(&account))
+	assert.Equal(t, int64(11), account.Balance)
+
+	var row map[string]interface{}
+	assert.NoError(t, sess.Collection("accounts").Find(1).One(&row))
+	assert.Equal(t, int64(11), row["balance"])
+
+	// Invalidate drops the entity, without publishing it.
+	sess.Invalidate("accounts", 1)
+	assert.Equal(t, int64(11), balance(1))
+	assert.Empty(t, invalidations)
+
+	// Writes through the session invalidate the entity.
+	assert.NoError(t, sess.Collection("accounts").Find(1).Update(db.Cond{"balance": 12}))
+	assert.Equal(t, int64(12), balance(1))
+	assert.Equal(t, []invalidation{{"accounts", 1}}, invalidations)
+
+	account = cachedAccount{}
+	assert.NoError(t, sess.Get(&account, 1))
+	account.Balance = 13
+	assert.NoError(t, sess.Save(&account))
+	assert.Equal(t, int64(13), balance(1))
+	assert.Equal(t, invalidation{"accounts", int64(1)}, invalidations[1])
+
+	// Writes that don't target a single primary key value invalidate the
+	// collection.
+	assert.Equal(t, int64(20), balance(2))
+	assert.NoError(t, sess.Collection("accounts").Find(db.Cond{"name": "Bob"}).Update(db.Cond{"balance": 21}))
+	assert.Equal(t, int64(21), balance(2))
+	assert.Equal(t, invalidation{"accounts", nil}, invalidations[2])
+
+	// Within transactions the cache is bypassed, and the entities are
+	// published once the transaction is committed.
+	invalidations = nil
+	assert.Equal(t, int64(21), balance(2))
+	setBalance(2, 22)
+	err = sess.Tx(func(tx db.Session) error {
+
+		var account cachedAccount
+		if err := tx.Get(&account, int64(2)); err != nil {
+			return err
+		}
+		assert.Equal(t, int64(22), account.Balance)
+
+		if err := tx.Collection("accounts").Find(2).Update(db.Cond{"balance": 23}); err != nil {
+			return err
+		}
+		assert.Empty(t, invalidations)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []invalidation{{"accounts", 2}}, invalidations)
+	assert.Equal(t, int64(23), balance(2))
+
+	// Deleted entities are not served.
+	assert.NoError(t, sess.Delete(&cachedAccount{ID: 2}))
+	assert.Equal(t, db.ErrNoMoreRows, sess.Get(&account, 2))
+
+	// Reset clears the cache.
+	setBalance(1, 14)
+	sess.Reset()
+	assert.Equal(t, int64(14), balance(1))
+}
+
+func TestEntityCacheNegativeTTL(t *testing.T) {
+	conn := openMemoryAccounts(t)
+	defer conn.Close()
+
+	entities, err := db.NewEntityCache(100)
+	assert.NoError(t, err)
+
+	sess := db.WithEntityCache(conn, entities)
+	accounts := sess.Collection("accounts")
+
+	insert := func(id int64) {
+		_, err := conn.SQL().Exec(`INSERT INTO accounts (id, name, balance) VALUES (?, 'Ann', 0)`, id)
+		assert.NoError(t, err)
+	}
+
+	exists := func(id int64) bool {
+		ok, err := accounts.Find(id).Exists()
+		assert.NoError(t, err)
+		return ok
+	}
+
+	// Missing entities are not cached by default.
+	var account cachedAccount
+	assert.Equal(t, db.ErrNoMoreRows, sess.Get(&account, 1))
+	insert(1)
+	assert.NoError(t, sess.Get(&account, 1))
+
+	sess.SetNegativeTTL(time.Hour)
+
+	// Missing entities are remembered by lookups and existence checks.
+	assert.Equal(t, db.ErrNoMoreRows, sess.Get(&account, 2))
+	assert.False(t, exists(3))
+
+	insert(2)
+	insert(3)
+	assert.Equal(t, db.ErrNoMoreRows, sess.Get(&account, 2))
+	assert.False(t, exists(2))
+	assert.False(t, exists(3))
+
+	sess.Invalidate("accounts", 2)
+	assert.NoError(t, sess.Get(&account, 2))
+	assert.True(t, exists(2))
+
+	// Inserts through the session invalidate them.
+	assert.False(t, exists(4))
+	_, err = accounts.Insert(cachedAccount{ID: 4, Name: "Bob"})
+	assert.NoError(t, err)
+	assert.True(t, exists(4))
+
+	assert.Equal(t, db.ErrNoMoreRows, sess.Get(&account, 5))
+	created := cachedAccount{Name: "Cid"}
+	assert.NoError(t, sess.Save(&created))
+	assert.Equal(t, int64(5), created.ID)
+	assert.NoError(t, sess.Get(&account, 5))
+	assert.Equal(t, "Cid", account.Name)
+
+	// And they expire.
+	sess.SetNegativeTTL(time.Millisecond)
+	assert.False(t, exists(6))
+	insert(6)
+	time.Sleep(5 * time.Millisecond)
+	assert.True(t, exists(6))
+}
+
+func TestCacheWrap(t *testing.T) {
+	conn := openMemoryAccounts(t)
+	defer conn.Close()
+
+	backend, err := db.NewEntityCache(100)
+	assert.NoError(t, err)
+
+	accounts := dbcache.Wrap(conn.Collection("accounts"), backend, 20*time.Millisecond)
+
+	_, err = conn.SQL().Exec(`INSERT INTO accounts (id, name, balance) VALUES (1, 'Ann', 10)`)
+	assert.NoError(t, err)
+
+	setBalance := func(balance int64) {
+		_, err := conn.SQL().Exec(`UPDATE accounts SET balance = ? WHERE id = 1`, balance)
+		assert.NoError(t, err)
+	}
+
+	balance := func() int64 {
+		var account cachedAccount
+		assert.NoError(t, accounts.Find(1).One(&account))
+		return account.Balance
+	}
+
+	// Reads are served from the cache.
+	assert.Equal(t, int64(10), balance())
+	setBalance(11)
+	assert.Equal(t, int64(10), balance())
+
+	// Writes through the collection invalidate them.
+	assert.NoError(t, accounts.Find(1).Update(map[string]interface{}{"balance": 12}))
+	assert.Equal(t, int64(12), balance())
+
+	// Other writes are seen once the items expire.
+	setBalance(13)
+	assert.Equal(t, int64(12), balance())
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, int64(13), balance())
+}
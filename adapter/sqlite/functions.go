@@ -0,0 +1,108 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	db "github.com/upper/db/v4"
+)
+
+// function is a Go function registered as a SQL function.
+type function struct {
+	name      string
+	impl      interface{}
+	pure      bool
+	aggregate bool
+}
+
+var (
+	functions   []function
+	functionsMu sync.RWMutex
+)
+
+// RegisterFunc makes a Go function available as a SQL scalar function named
+// name on every connection opened afterwards, so it can be used in queries
+// and in db.Raw and db.Func expressions:
+//
+//	sqlite.RegisterFunc("normalize", func(s string) string {
+//		return strings.ToLower(strings.TrimSpace(s))
+//	}, true)
+//	...
+//	res := users.Find(db.Cond{"email": db.Func("normalize", email)})
+//
+// Arguments and return values follow the rules of the driver, which include
+// an optional error as last return value. pure means the function always
+// returns the same result given the same arguments. Registering a name again
+// replaces the previous function.
+func RegisterFunc(name string, impl interface{}, pure bool) error {
+	return register(function{name: name, impl: impl, pure: pure})
+}
+
+// RegisterAggregator makes a Go type available as a SQL aggregate function
+// named name on every connection opened afterwards. impl is a function that
+// returns a pointer to a new aggregator, which has a Step method that
+// receives the values of each row and a Done method that returns the result:
+//
+//	type stddev struct{ ... }
+//
+//	func (s *stddev) Step(x float64) { ... }
+//	func (s *stddev) Done() float64  { ... }
+//
+//	sqlite.RegisterAggregator("stddev", func() *stddev { return &stddev{} }, true)
+func RegisterAggregator(name string, impl interface{}, pure bool) error {
+	return register(function{name: name, impl: impl, pure: pure, aggregate: true})
+}
+
+func register(fn function) error {
+	if !customFunctions {
+		return fmt.Errorf("%w: custom functions with this SQLite driver", db.ErrUnsupported)
+	}
+	if fn.name == "" {
+		return errors.New("missing function name")
+	}
+	if fn.impl == nil || reflect.TypeOf(fn.impl).Kind() != reflect.Func {
+		return fmt.Errorf("expecting a function for %q, got %T", fn.name, fn.impl)
+	}
+
+	functionsMu.Lock()
+	defer functionsMu.Unlock()
+
+	for i := range functions {
+		if functions[i].name == fn.name {
+			functions[i] = fn
+			return nil
+		}
+	}
+	functions = append(functions, fn)
+	return nil
+}
+
+func registeredFunctions() []function {
+	functionsMu.RLock()
+	defer functionsMu.RUnlock()
+
+	return append([]function(nil), functions...)
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+//go:build !sqlite_purego
+// +build !sqlite_purego
+
+package sqlite
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	db "github.com/upper/db/v4"
+)
+
+type productAggregator struct {
+	product int64
+}
+
+func (p *productAggregator) Step(x int64) {
+	p.product *= x
+}
+
+func (p *productAggregator) Done() int64 {
+	return p.product
+}
+
+func TestRegisterFunc(t *testing.T) {
+	assert.NoError(t, RegisterFunc("test_normalize", func(s string) string {
+		return strings.ToLower(strings.TrimSpace(s))
+	}, true))
+	assert.NoError(t, RegisterAggregator("test_product", func() *productAggregator {
+		return &productAggregator{product: 1}
+	}, true))
+
+	assert.Error(t, RegisterFunc("", strings.ToLower, true))
+	assert.Error(t, RegisterFunc("test_invalid", "not a function", true))
+
+	sess, err := Open(ConnectionURL{Database: ":memory:"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer sess.Close()
+
+	_, err = sess.SQL().Exec(`CREATE TABLE numbers (n integer, name text)`)
+	assert.NoError(t, err)
+
+	numbers := sess.Collection("numbers")
+	for _, n := range []int{2, 3, 7} {
+		_, err := numbers.Insert(map[string]interface{}{"n": n, "name": " Number "})
+		assert.NoError(t, err)
+	}
+
+	var row struct {
+		Name    string `db:"name"`
+		Product int64  `db:"product"`
+	}
+	err = sess.SQL().
+		Select(db.Raw("test_normalize(name) AS name"), db.Raw("test_product(n) AS product")).
+		From("numbers").
+		GroupBy(db.Func("test_normalize", db.Raw("name"))).
+		One(&row)
+	assert.NoError(t, err)
+	assert.Equal(t, "number", row.Name)
+	assert.Equal(t, int64(42), row.Product)
+
+	count, err := numbers.Find(db.Raw("test_normalize(name) = test_normalize(?)", " NUMBER ")).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), count)
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	db "github.com/upper/db/v4"
+)
+
+func TestMemoryDatabase(t *testing.T) {
+	for _, settings := range []db.ConnectionURL{
+		ConnectionURL{Database: ":memory:"},
+		ConnectionURL{Database: ":memory:", Options: map[string]string{"cache": "shared"}},
+	} {
+		sess, err := Open(settings)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		assert.Equal(t, ":memory:", sess.Name())
+		assert.Equal(t, 1, sess.MaxOpenConns())
+
+		_, err = sess.SQL().Exec(`CREATE TABLE notes (id integer primary key, body text)`)
+		assert.NoError(t, err)
+
+		notes := sess.Collection("notes")
+
+		exists, err := notes.Exists()
+		assert.NoError(t, err)
+		assert.True(t, exists)
+
+		collections, err := sess.Collections()
+		assert.NoError(t, err)
+		assert.Len(t, collections, 1)
+
+		err = sess.Tx(func(tx db.Session) error {
+			_, err := tx.Collection("notes").Insert(map[string]interface{}{"body": "hello"})
+			return err
+		})
+		assert.NoError(t, err)
+
+		count, err := notes.Find().Count()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), count)
+
+		assert.NoError(t, sess.Close())
+	}
+
+	// Each session gets its own database.
+	sess, err := Open(ConnectionURL{Database: ":memory:"})
+	if assert.NoError(t, err) {
+		exists, err := sess.Collection("notes").Exists()
+		assert.Error(t, err)
+		assert.False(t, exists)
+		assert.NoError(t, sess.Close())
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+// pragmaOptions are the ConnectionURL options that are applied with a PRAGMA
+// statement on every new connection, in this order. busy_timeout goes first so
+// it's already in effect when switching the journal mode.
+var pragmaOptions = []string{
+	"busy_timeout",
+	"journal_mode",
+	"journal_size_limit",
+	"wal_autocheckpoint",
+	"synchronous",
+	"foreign_keys",
+	"cache_size",
+	"temp_store",
+	"mmap_size",
+	"locking_mode",
+	"auto_vacuum",
+	"recursive_triggers",
+	"secure_delete",
+	"case_sensitive_like",
+}
+
+var pragmaValuePattern = regexp.MustCompile(`^-?[a-zA-Z0-9_]+$`)
+
+// splitPragmas removes the pragma options from the given DSN and returns the
+// PRAGMA statements that apply them.
+func splitPragmas(dsn string) (string, []string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", nil, err
+	}
+
+	vv := u.Query()
+
+	var pragmas []string
+	for _, name := range pragmaOptions {
+		if _, ok := vv[name]; !ok {
+			continue
+		}
+		value := vv.Get(name)
+		if !pragmaValuePattern.MatchString(value) {
+			return "", nil, fmt.Errorf("invalid value %q for %s", value, name)
+		}
+		pragmas = append(pragmas, fmt.Sprintf("PRAGMA %s = %s", name, value))
+		vv.Del(name)
+	}
+	if len(pragmas) == 0 {
+		return dsn, nil, nil
+	}
+
+	u.RawQuery = vv.Encode()
+	return u.String(), pragmas, nil
+}
+
+// connector opens connections with the underlying SQLite driver, runs the
+// PRAGMA statements and registers the custom functions on each one of them.
+type connector struct {
+	dsn     string
+	driver  driver.Driver
+	pragmas []string
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.setup(ctx, conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (c *connector) setup(ctx context.Context, conn driver.Conn) error {
+	for _, pragma := range c.pragmas {
+		if err := execPragma(ctx, conn, pragma); err != nil {
+			return err
+		}
+	}
+	for _, fn := range registeredFunctions() {
+		if err := registerFunction(conn, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+func execPragma(ctx context.Context, conn driver.Conn, pragma string) error {
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, pragma, nil)
+		return err
+	}
+
+	stmt, err := conn.Prepare(pragma)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(nil)
+	return err
+}
+
+// openDSN opens a database handle whose connections are set up with the
+// pragma options of the DSN and the registered functions.
+func openDSN(dsn string) (*sql.DB, error) {
+	dsn, pragmas, err := splitPragmas(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// sql.Open does not connect, it's only used to look up the driver.
+	sqlDB, err := sql.Open(driverName, "")
+	if err != nil {
+		return nil, err
+	}
+	drv := sqlDB.Driver()
+	_ = sqlDB.Close()
+
+	return sql.OpenDB(&connector{dsn: dsn, driver: drv, pragmas: pragmas}), nil
+}
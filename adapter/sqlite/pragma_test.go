@@ -0,0 +1,137 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sqlite
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitPragmas(t *testing.T) {
+	dsn, pragmas, err := splitPragmas("file:///tmp/app.db?cache=shared&journal_mode=WAL&busy_timeout=5000&cache_size=-4000")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "file:///tmp/app.db?cache=shared", dsn)
+	assert.Equal(t, []string{
+		"PRAGMA busy_timeout = 5000",
+		"PRAGMA journal_mode = WAL",
+		"PRAGMA cache_size = -4000",
+	}, pragmas)
+
+	dsn, pragmas, err = splitPragmas("file:///tmp/app.db?_busy_timeout=10000")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "file:///tmp/app.db?_busy_timeout=10000", dsn)
+	assert.Nil(t, pragmas)
+
+	_, _, err = splitPragmas("file:///tmp/app.db?journal_mode=WAL%27%29")
+	assert.Error(t, err)
+}
+
+func TestPragmaOptions(t *testing.T) {
+	sess, err := Open(ConnectionURL{
+		Database: filepath.Join(t.TempDir(), "pragma.db"),
+		Options: map[string]string{
+			"journal_mode":   "WAL",
+			"foreign_keys":   "on",
+			"cache_size":     "-4000",
+			"max_open_conns": "2",
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer sess.Close()
+
+	pragma := func(name string) string {
+		row, err := sess.SQL().QueryRow("PRAGMA " + name)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		var value string
+		assert.NoError(t, row.Scan(&value))
+		return value
+	}
+
+	// Each query may run on a different connection.
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "wal", pragma("journal_mode"))
+		assert.Equal(t, "1", pragma("foreign_keys"))
+		assert.Equal(t, "-4000", pragma("cache_size"))
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "checkpoint.db")
+
+	sess, err := Open(ConnectionURL{
+		Database: dbPath,
+		Options: map[string]string{
+			"journal_mode":       "WAL",
+			"journal_size_limit": "1048576",
+			"wal_autocheckpoint": "0",
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer sess.Close()
+
+	row, err := sess.SQL().QueryRow("PRAGMA journal_size_limit")
+	if assert.NoError(t, err) {
+		var limit int64
+		assert.NoError(t, row.Scan(&limit))
+		assert.Equal(t, int64(1048576), limit)
+	}
+
+	_, err = sess.SQL().Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)")
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		_, err = sess.Collection("items").Insert(map[string]string{"name": "item"})
+		assert.NoError(t, err)
+	}
+
+	// Automatic checkpoints are disabled, the log keeps every frame.
+	stat, err := os.Stat(dbPath + "-wal")
+	if assert.NoError(t, err) {
+		assert.NotZero(t, stat.Size())
+	}
+
+	res, err := Checkpoint(sess, CheckpointTruncate)
+	if assert.NoError(t, err) {
+		assert.False(t, res.Busy)
+		assert.Zero(t, res.LogFrames)
+	}
+
+	stat, err = os.Stat(dbPath + "-wal")
+	if assert.NoError(t, err) {
+		assert.Zero(t, stat.Size())
+	}
+
+	_, err = Checkpoint(sess, CheckpointMode("ALL; DROP TABLE items"))
+	assert.Error(t, err)
+}
@@ -85,6 +85,8 @@ const (
   `
 
 	adapterSelectLayout = `
+    {{.With | compile}}
+
     SELECT
       {{if .Distinct}}
         DISTINCT
@@ -108,6 +110,10 @@ const (
         {{.GroupBy | compile}}
       {{end}}
 
+      {{if defined .Having}}
+        {{.Having | compile}}
+      {{end}}
+
       {{.OrderBy | compile}}
 
       {{if .Limit}}
@@ -141,7 +147,7 @@ const (
   `
 
 	adapterInsertLayout = `
-    INSERT INTO {{.Table | compile}}
+    INSERT {{if .Ignore}}OR IGNORE {{end}}INTO {{.Table | compile}}
       {{if .Columns }}({{.Columns | compile}}){{end}}
     {{if defined .Values}}
       VALUES
@@ -149,11 +155,25 @@ const (
     {{else}}
       DEFAULT VALUES
     {{end}}
+    {{if defined .OnConflict}}
+      {{.OnConflict | compile}}
+    {{end}}
     {{if defined .Returning}}
       RETURNING {{.Returning | compile}}
     {{end}}
   `
 
+	adapterOnConflictLayout = `
+    ON CONFLICT {{if .Columns}}({{.Columns}}){{end}}
+    {{if .Update}}
+      DO UPDATE SET {{.Update}}
+    {{else}}
+      DO NOTHING
+    {{end}}
+  `
+
+	adapterOnConflictUpdateLayout = `{{.Column}} = EXCLUDED.{{.Column}}`
+
 	adapterTruncateLayout = `
     DELETE FROM {{.Table | compile}}
   `
@@ -166,43 +186,69 @@ const (
     DROP TABLE {{.Table | compile}}
   `
 
+	adapterCreateTableAsLayout = `
+    CREATE TABLE {{.Table | compile}} AS {{.Query | compile}}
+  `
+
 	adapterGroupByLayout = `
     {{if .GroupColumns}}
       GROUP BY {{.GroupColumns}}
     {{end}}
+  `
+
+	adapterHavingLayout = `
+    {{if .Conds}}
+      HAVING {{.Conds}}
+    {{end}}
+  `
+
+	adapterValuesTableLayout = `
+    (SELECT {{range $i, $column := .ColumnNames}}{{if $i}}, {{end}}{{index $.FirstRow $i}} AS {{$column}}{{end}}{{range $i, $row := .Rows}}{{if eq $i 1}} UNION ALL VALUES {{$row}}{{else if $i}}, {{$row}}{{end}}{{end}}) AS {{.Name}}
+  `
+
+	adapterWithLayout = `
+    {{if .Tables}}
+      WITH {{if .Recursive}}RECURSIVE{{end}} {{.Tables}}
+    {{end}}
   `
 )
 
 var template = &exql.Template{
-	ColumnSeparator:     adapterColumnSeparator,
-	IdentifierSeparator: adapterIdentifierSeparator,
-	IdentifierQuote:     adapterIdentifierQuote,
-	ValueSeparator:      adapterValueSeparator,
-	ValueQuote:          adapterValueQuote,
-	AndKeyword:          adapterAndKeyword,
-	OrKeyword:           adapterOrKeyword,
-	DescKeyword:         adapterDescKeyword,
-	AscKeyword:          adapterAscKeyword,
-	AssignmentOperator:  adapterAssignmentOperator,
-	ClauseGroup:         adapterClauseGroup,
-	ClauseOperator:      adapterClauseOperator,
-	ColumnValue:         adapterColumnValue,
-	TableAliasLayout:    adapterTableAliasLayout,
-	ColumnAliasLayout:   adapterColumnAliasLayout,
-	SortByColumnLayout:  adapterSortByColumnLayout,
-	WhereLayout:         adapterWhereLayout,
-	JoinLayout:          adapterJoinLayout,
-	OnLayout:            adapterOnLayout,
-	UsingLayout:         adapterUsingLayout,
-	OrderByLayout:       adapterOrderByLayout,
-	InsertLayout:        adapterInsertLayout,
-	SelectLayout:        adapterSelectLayout,
-	UpdateLayout:        adapterUpdateLayout,
-	DeleteLayout:        adapterDeleteLayout,
-	TruncateLayout:      adapterTruncateLayout,
-	DropDatabaseLayout:  adapterDropDatabaseLayout,
-	DropTableLayout:     adapterDropTableLayout,
-	CountLayout:         adapterSelectCountLayout,
-	GroupByLayout:       adapterGroupByLayout,
-	Cache:               cache.NewCache(),
+	ColumnSeparator:        adapterColumnSeparator,
+	IdentifierSeparator:    adapterIdentifierSeparator,
+	IdentifierQuote:        adapterIdentifierQuote,
+	ValueSeparator:         adapterValueSeparator,
+	ValuesTableLayout:      adapterValuesTableLayout,
+	ValueQuote:             adapterValueQuote,
+	AndKeyword:             adapterAndKeyword,
+	OrKeyword:              adapterOrKeyword,
+	DescKeyword:            adapterDescKeyword,
+	AscKeyword:             adapterAscKeyword,
+	AssignmentOperator:     adapterAssignmentOperator,
+	ClauseGroup:            adapterClauseGroup,
+	ClauseOperator:         adapterClauseOperator,
+	ColumnValue:            adapterColumnValue,
+	TableAliasLayout:       adapterTableAliasLayout,
+	ColumnAliasLayout:      adapterColumnAliasLayout,
+	SortByColumnLayout:     adapterSortByColumnLayout,
+	WhereLayout:            adapterWhereLayout,
+	JoinLayout:             adapterJoinLayout,
+	OnConflictLayout:       adapterOnConflictLayout,
+	OnConflictUpdateLayout: adapterOnConflictUpdateLayout,
+	OnLayout:               adapterOnLayout,
+	UsingLayout:            adapterUsingLayout,
+	OrderByLayout:          adapterOrderByLayout,
+	InsertLayout:           adapterInsertLayout,
+	SelectLayout:           adapterSelectLayout,
+	UpdateLayout:           adapterUpdateLayout,
+	DeleteLayout:           adapterDeleteLayout,
+	TruncateLayout:         adapterTruncateLayout,
+	DropDatabaseLayout:     adapterDropDatabaseLayout,
+	DropTableLayout:        adapterDropTableLayout,
+	CreateTableAsLayout:    adapterCreateTableAsLayout,
+	CountLayout:            adapterSelectCountLayout,
+	GroupByLayout:          adapterGroupByLayout,
+	HavingLayout:           adapterHavingLayout,
+	WithLayout:             adapterWithLayout,
+	Cache:                  cache.NewCache(),
 }
@@ -0,0 +1,77 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAdapter struct {
+	capabilities Capability
+}
+
+func (*fakeAdapter) Open(ConnectionURL) (Session, error) {
+	return nil, ErrNotImplemented
+}
+
+func (a *fakeAdapter) Capabilities() Capability {
+	return a.capabilities
+}
+
+func TestCapability(t *testing.T) {
+	c := CapabilityTransactions | CapabilityReturning
+
+	assert.True(t, c.Has(CapabilityTransactions))
+	assert.True(t, c.Has(CapabilityTransactions|CapabilityReturning))
+	assert.False(t, c.Has(CapabilitySchemas))
+	assert.False(t, c.Has(CapabilityReturning|CapabilitySchemas))
+
+	assert.Equal(t, "[transactions returning]", c.String())
+	assert.Equal(t, "[read preferences]", CapabilityReadPreferences.String())
+	assert.Equal(t, "none", CapabilityNone.String())
+}
+
+func TestAdapters(t *testing.T) {
+	RegisterAdapter("fake-b", &fakeAdapter{capabilities: CapabilityTransactions | CapabilityCompositeKeys})
+	RegisterAdapter("fake-a", &fakeAdapter{})
+
+	var names []string
+	for _, info := range Adapters() {
+		names = append(names, info.Name)
+	}
+	assert.Subset(t, names, []string{"fake-a", "fake-b"})
+
+	info, ok := LookupAdapterInfo("fake-b")
+	assert.True(t, ok)
+	assert.True(t, info.Supports(CapabilityTransactions))
+	assert.True(t, info.Supports(CapabilityCompositeKeys))
+	assert.False(t, info.Supports(CapabilityReturning))
+
+	info, ok = LookupAdapterInfo("fake-a")
+	assert.True(t, ok)
+	assert.Equal(t, CapabilityNone, info.Capabilities)
+
+	_, ok = LookupAdapterInfo("fake-missing")
+	assert.False(t, ok)
+}
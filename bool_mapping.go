@@ -0,0 +1,104 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BoolMapping defines the values that represent true and false in columns
+// that store flags as something other than a boolean, like CHAR(1) columns
+// with 'Y' and 'N'.
+//
+// A mapping can be set for a single struct field with the bool tag option,
+// which has the form true/false:
+//
+//	type Account struct {
+//		Active bool `db:"active,bool=Y/N"`
+//	}
+//
+// Or for all the bool values of a session with Session.SetBoolMapping.
+//
+// The bool tag option only applies to values that are written from or read
+// into struct fields. Conditions don't know about struct fields, so a value
+// like db.Cond{"active": true} is sent as is; compare with the stored value
+// instead, db.Cond{"active": "Y"}, or use a session mapping, which applies to
+// conditions as well.
+type BoolMapping struct {
+	True  string
+	False string
+}
+
+// Common mappings of bool values.
+var (
+	BoolYesNo   = BoolMapping{True: "Y", False: "N"}
+	BoolTF      = BoolMapping{True: "t", False: "f"}
+	BoolOneZero = BoolMapping{True: "1", False: "0"}
+)
+
+// ParseBoolMapping parses a mapping in the true/false form used by the bool
+// tag option, like "Y/N".
+func ParseBoolMapping(s string) (BoolMapping, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || parts[0] == parts[1] {
+		return BoolMapping{}, fmt.Errorf("upper: invalid bool mapping %q, expecting true/false values like Y/N", s)
+	}
+	return BoolMapping{True: parts[0], False: parts[1]}, nil
+}
+
+// Value returns the value that represents b.
+func (m BoolMapping) Value(b bool) string {
+	if b {
+		return m.True
+	}
+	return m.False
+}
+
+// Bool returns the bool that src, a value read from the database, represents.
+// Values are compared without case and surrounding spaces, so padded CHAR
+// columns work as well.
+func (m BoolMapping) Bool(src interface{}) (bool, error) {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case bool:
+		return v, nil
+	default:
+		return false, fmt.Errorf("upper: can't map %T to bool with %s/%s", src, m.True, m.False)
+	}
+
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.EqualFold(s, m.True):
+		return true, nil
+	case strings.EqualFold(s, m.False):
+		return false, nil
+	}
+	return false, fmt.Errorf("upper: can't map %q to bool with %s/%s", s, m.True, m.False)
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoolMapping(t *testing.T) {
+	m, err := ParseBoolMapping("Y/N")
+	assert.NoError(t, err)
+	assert.Equal(t, BoolYesNo, m)
+
+	assert.Equal(t, "Y", m.Value(true))
+	assert.Equal(t, "N", m.Value(false))
+
+	for _, src := range []interface{}{"Y", []byte("y"), "Y   ", true} {
+		b, err := m.Bool(src)
+		assert.NoError(t, err)
+		assert.True(t, b)
+	}
+
+	b, err := m.Bool([]byte("N"))
+	assert.NoError(t, err)
+	assert.False(t, b)
+
+	_, err = m.Bool("X")
+	assert.Error(t, err)
+
+	_, err = m.Bool(nil)
+	assert.Error(t, err)
+
+	b, err = BoolOneZero.Bool(int64(1))
+	assert.NoError(t, err)
+	assert.True(t, b)
+
+	for _, s := range []string{"", "Y", "Y/", "/N", "Y/Y", "Y/N/X"} {
+		_, err := ParseBoolMapping(s)
+		assert.Error(t, err, s)
+	}
+}
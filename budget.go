@@ -0,0 +1,98 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/upper/db/v4/internal/queryctx"
+)
+
+// Budget limits the statements a session may send to the database on behalf
+// of a single context, like the one of an HTTP request. Zero values mean no
+// limit.
+type Budget struct {
+	// MaxQueries is the maximum number of statements.
+	MaxQueries int
+
+	// MaxDuration is the maximum time spent waiting for the database, added
+	// up over all statements.
+	MaxDuration time.Duration
+}
+
+// WithBudget returns a copy of ctx that carries the given budget. Sessions
+// bound to the context with WithContext count every statement against the
+// budget, once it's spent they return a *BudgetError instead of executing
+// more statements:
+//
+//	sess := sess.WithContext(db.WithBudget(r.Context(), db.Budget{MaxQueries: 50}))
+//
+// Every session and transaction that uses the context shares the same budget.
+// The budget is only enforced by SQL adapters.
+func WithBudget(ctx context.Context, budget Budget) context.Context {
+	return queryctx.WithBudget(ctx, budget.MaxQueries, budget.MaxDuration)
+}
+
+// BudgetUsage returns the number of statements and the database time spent
+// so far from the budget carried by ctx.
+func BudgetUsage(ctx context.Context) (queries int, duration time.Duration, ok bool) {
+	_, _, queries, duration, ok = queryctx.BudgetUsage(ctx)
+	return
+}
+
+// BudgetError is returned when a statement would exceed the budget of its
+// context. It matches ErrBudgetExceeded with errors.Is, Stack has the call
+// stack of the statement that was refused, which is usually enough to find
+// the loop that caused it.
+type BudgetError struct {
+	Budget Budget
+
+	// Queries and Duration are what was spent before the statement was
+	// refused.
+	Queries  int
+	Duration time.Duration
+
+	Stack []byte
+}
+
+// NewBudgetError returns an error for a statement that was refused by the
+// budget carried by ctx.
+func NewBudgetError(ctx context.Context, stack []byte) *BudgetError {
+	maxQueries, maxDuration, queries, duration, _ := queryctx.BudgetUsage(ctx)
+	return &BudgetError{
+		Budget:   Budget{MaxQueries: maxQueries, MaxDuration: maxDuration},
+		Queries:  queries,
+		Duration: duration,
+		Stack:    stack,
+	}
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%v: %d queries in %v", ErrBudgetExceeded, e.Queries, e.Duration)
+}
+
+// Is reports whether target is ErrBudgetExceeded.
+func (e *BudgetError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
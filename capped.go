@@ -0,0 +1,198 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CappedOptions defines the limits of a capped collection, see Capped.
+type CappedOptions struct {
+	// MaxItems is the maximum number of items the collection keeps, once
+	// reached the oldest items are removed to make room for new ones.
+	MaxItems uint64
+
+	// MaxBytes is the maximum size of the collection in bytes. It is only
+	// used by databases with native capped collections, MongoDB defaults to
+	// 1 GiB.
+	MaxBytes uint64
+
+	// OrderColumn is a column whose values grow with every insert, like an
+	// auto-incremented ID or a creation time, it's used to find out which
+	// items are the oldest ones. It is required unless the database supports
+	// capped collections natively. Items with the same value are told apart by
+	// the primary key of the collection, if the collection doesn't have a
+	// single-column primary key OrderColumn must be unique.
+	OrderColumn string
+}
+
+// CappedCreator is implemented by collections of databases with native capped
+// collections.
+type CappedCreator interface {
+	// CreateCapped creates the collection as a capped collection. If the
+	// collection already exists and is not capped it returns ErrUnsupported.
+	CreateCapped(maxItems uint64, maxBytes uint64) error
+}
+
+// Capped returns a collection that keeps at most opts.MaxItems items, which
+// is useful for logs and event buffers:
+//
+//	events, err := db.Capped(sess.Collection("events"), db.CappedOptions{
+//	  MaxItems:    10000,
+//	  OrderColumn: "id",
+//	})
+//	...
+//	_, err = events.Insert(event)
+//
+// Collections of databases with native capped collections (like MongoDB) are
+// created as such. On other databases every write made through the returned
+// collection deletes the oldest items beyond the limit, writes made through
+// other collection values are not pruned until the next write made through a
+// capped one. A write that succeeds is not reported as failed if pruning
+// fails afterwards, the pruning error is logged and retried on the next write.
+func Capped(col Collection, opts CappedOptions) (Collection, error) {
+	if opts.MaxItems < 1 {
+		return nil, errors.New("upper: capped collections must keep at least one item")
+	}
+
+	if creator, ok := col.(CappedCreator); ok {
+		err := creator.CreateCapped(opts.MaxItems, opts.MaxBytes)
+		if err == nil {
+			return col, nil
+		}
+		if !errors.Is(err, ErrUnsupported) {
+			return nil, err
+		}
+	}
+
+	if opts.OrderColumn == "" {
+		return nil, fmt.Errorf("upper: %q is not a native capped collection, an order column is required", col.Name())
+	}
+	return &cappedCollection{Collection: col, opts: opts}, nil
+}
+
+type cappedCollection struct {
+	Collection
+
+	opts CappedOptions
+}
+
+// pruneBatchSize is the maximum number of items prune deletes per statement.
+const pruneBatchSize = 500
+
+// keyColumn returns the column that identifies items, the primary key if
+// there is a single one, or the order column otherwise.
+func (c *cappedCollection) keyColumn() string {
+	if pker, ok := c.Collection.(interface{ PrimaryKeys() []string }); ok {
+		if pKey := pker.PrimaryKeys(); len(pKey) == 1 {
+			return pKey[0]
+		}
+	}
+	return c.opts.OrderColumn
+}
+
+// prune deletes the oldest items of the collection beyond the limit. Items
+// are deleted by key, so items that share their OrderColumn value with the
+// newest ones are kept.
+func (c *cappedCollection) prune() error {
+	column, key := c.opts.OrderColumn, c.keyColumn()
+
+	order := []interface{}{"-" + column}
+	if key != column {
+		order = append(order, "-"+key)
+	}
+
+	for {
+		var items []map[string]interface{}
+		err := c.Collection.Find().
+			Select(key).
+			OrderBy(order...).
+			Offset(int(c.opts.MaxItems)).
+			Limit(pruneBatchSize).
+			All(&items)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		keys := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			value, ok := item[key]
+			if !ok && len(item) == 1 {
+				// Expressions like id() may be returned under a different name.
+				for _, v := range item {
+					value = v
+				}
+			}
+			keys = append(keys, value)
+		}
+
+		if err := c.Collection.Find(Cond{key: In(keys...)}).Delete(); err != nil {
+			return err
+		}
+		if len(items) < pruneBatchSize {
+			return nil
+		}
+	}
+}
+
+// pruneAfter prunes the collection after a successful write. Pruning errors
+// are logged instead of returned, as the write itself succeeded and retrying
+// it would store the items twice.
+func (c *cappedCollection) pruneAfter(err error) error {
+	if err != nil {
+		return err
+	}
+	if err := c.prune(); err != nil {
+		LC().Errorf("upper: could not prune capped collection %q: %v", c.Name(), err)
+	}
+	return nil
+}
+
+func (c *cappedCollection) Insert(item interface{}) (*InsertResult, error) {
+	res, err := c.Collection.Insert(item)
+	return res, c.pruneAfter(err)
+}
+
+func (c *cappedCollection) InsertMany(items interface{}, batchSize int) ([]*InsertResult, error) {
+	res, err := c.Collection.InsertMany(items, batchSize)
+	return res, c.pruneAfter(err)
+}
+
+func (c *cappedCollection) Upsert(item interface{}, conflictColumns ...string) (*InsertResult, error) {
+	res, err := c.Collection.Upsert(item, conflictColumns...)
+	return res, c.pruneAfter(err)
+}
+
+func (c *cappedCollection) InsertOrIgnore(item interface{}) (*InsertResult, error) {
+	res, err := c.Collection.InsertOrIgnore(item)
+	return res, c.pruneAfter(err)
+}
+
+func (c *cappedCollection) InsertReturning(item interface{}) error {
+	return c.pruneAfter(c.Collection.InsertReturning(item))
+}
+
+var _ = Collection(&cappedCollection{})
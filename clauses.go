@@ -70,6 +70,28 @@ type Selector interface {
 	//   s.Columns(...).From("people p").Where("p.name = ?", ...)
 	From(tables ...interface{}) Selector
 
+	// With defines a common table expression that can be used as a table by
+	// the query. query can be a Selector, the Result of a SQL adapter or a
+	// raw expression. name may include the names of the columns:
+	//
+	//   sess.SQL().SelectFrom("recent").
+	//     With("recent(id, title)", sess.SQL().Select("id", "title").From("posts").Limit(10))
+	//
+	// Common table expressions are supported by PostgreSQL, CockroachDB,
+	// SQLite and MySQL 8+.
+	With(name string, query interface{}) Selector
+
+	// WithRecursive is like With, but the query may refer to the table it
+	// defines, which is used to walk trees and graphs. The recursive query is
+	// usually given as a raw expression joined with UNION ALL:
+	//
+	//   sess.SQL().SelectFrom("tree").WithRecursive("tree(id, parent_id)", db.Raw(
+	//     `SELECT id, parent_id FROM categories WHERE id = ?
+	//      UNION ALL
+	//      SELECT c.id, c.parent_id FROM categories c JOIN tree t ON c.parent_id = t.id`, rootID,
+	//   ))
+	WithRecursive(name string, query interface{}) Selector
+
 	// Distict represents a DISTINCT clause
 	//
 	// DISTINCT is used to ask the database to return only values that are
@@ -125,7 +147,15 @@ type Selector interface {
 	//   s.GroupBy("country_id", "city_id")
 	GroupBy(columns ...interface{}) Selector
 
-	// Having(...interface{}) Selector
+	// Having filters the groups defined by GroupBy, it accepts the same
+	// conditions as Where and appends them to the ones already set, usually
+	// to constrain aggregate functions:
+	//
+	//   s.Select("author_id", db.Raw("COUNT(1) AS total")).
+	//     From("book").
+	//     GroupBy("author_id").
+	//     Having(db.Raw("COUNT(1) > ?", 3))
+	Having(conds ...interface{}) Selector
 
 	// OrderBy represents a ORDER BY statement.
 	//
@@ -169,6 +199,9 @@ type Selector interface {
 	//   s.Join("employee").Using("department_id")
 	Join(table ...interface{}) Selector
 
+	// InnerJoin is like Join() but with INNER JOIN.
+	InnerJoin(...interface{}) Selector
+
 	// FullJoin is like Join() but with FULL JOIN.
 	FullJoin(...interface{}) Selector
 
@@ -211,6 +244,20 @@ type Selector interface {
 	// s.Offset(56)
 	Offset(int) Selector
 
+	// ForUpdate locks the selected rows against concurrent updates until the
+	// end of the current transaction (SELECT ... FOR UPDATE). Pass NoWait or
+	// SkipLocked to change how already locked rows are handled. Adapters that
+	// do not support row locking ignore this clause.
+	//
+	//  s.ForUpdate(db.SkipLocked)
+	ForUpdate(...LockOption) Selector
+
+	// ForShare is like ForUpdate but acquires a shared lock, which allows other
+	// transactions to read but not to modify the selected rows.
+	//
+	//  s.ForShare()
+	ForShare(...LockOption) Selector
+
 	// Amend lets you alter the query's text just before sending it to the
 	// database server.
 	Amend(func(queryIn string) (queryOut string)) Selector
@@ -274,6 +321,33 @@ type Inserter interface {
 	// RETURNING may not be supported by all SQL databases.
 	Returning(columns ...string) Inserter
 
+	// OnConflict represents an ON CONFLICT clause.
+	//
+	// ON CONFLICT defines the columns that identify a row that conflicts with
+	// the one being inserted. If DoUpdate is not used the conflicting row is
+	// left untouched.
+	//
+	//   i.Values(...).OnConflict("email").DoUpdate("name")
+	//
+	// ON CONFLICT may not be supported by all SQL databases. MySQL ignores the
+	// conflict columns and uses ON DUPLICATE KEY UPDATE instead.
+	OnConflict(columns ...string) Inserter
+
+	// DoUpdate defines the columns that are overwritten with the new values
+	// when the row being inserted conflicts with an existing one.
+	DoUpdate(columns ...string) Inserter
+
+	// Ignore makes the database skip the rows that conflict with existing
+	// ones instead of failing:
+	//
+	//   i.Values(...).Ignore()
+	//
+	// It's compiled as INSERT IGNORE on MySQL, INSERT OR IGNORE on SQLite and
+	// ON CONFLICT DO NOTHING on PostgreSQL and CockroachDB, it's not supported
+	// by other databases. Note that MySQL and SQLite ignore other errors, like
+	// NOT NULL violations, as well.
+	Ignore() Inserter
+
 	// Iterator provides methods to iterate over the results returned by the
 	// Inserter. This is only possible when using Returning().
 	Iterator() Iterator
@@ -322,6 +396,24 @@ type Deleter interface {
 	// See Selector.Limit for documentation and usage examples.
 	Limit(int) Deleter
 
+	// Returning represents a RETURNING clause.
+	//
+	// RETURNING specifies which columns of the deleted rows should be
+	// returned. It may not be supported by all SQL databases.
+	Returning(columns ...string) Deleter
+
+	// Iterator provides methods to iterate over the rows returned by the
+	// Deleter. This is only possible when using Returning().
+	Iterator() Iterator
+
+	// IteratorContext provides methods to iterate over the rows returned by
+	// the Deleter. This is only possible when using Returning().
+	IteratorContext(ctx context.Context) Iterator
+
+	// SQLGetter provides methods to return query results from DELETE statements
+	// that use Returning().
+	SQLGetter
+
 	// Amend lets you alter the query's text just before sending it to the
 	// database server.
 	Amend(func(queryIn string) (queryOut string)) Deleter
@@ -359,6 +451,24 @@ type Updater interface {
 	// See Selector.Limit for documentation and usage examples.
 	Limit(int) Updater
 
+	// Returning represents a RETURNING clause.
+	//
+	// RETURNING specifies which columns should be returned after updating the
+	// matching rows. It may not be supported by all SQL databases.
+	Returning(columns ...string) Updater
+
+	// Iterator provides methods to iterate over the rows returned by the
+	// Updater. This is only possible when using Returning().
+	Iterator() Iterator
+
+	// IteratorContext provides methods to iterate over the rows returned by
+	// the Updater. This is only possible when using Returning().
+	IteratorContext(ctx context.Context) Iterator
+
+	// SQLGetter provides methods to return query results from UPDATE statements
+	// that use Returning().
+	SQLGetter
+
 	// SQLPreparer provides methods for creating prepared statements.
 	SQLPreparer
 
@@ -452,6 +562,21 @@ type ResultMapper interface {
 	// The behaviour of One() extends to each one of the results.
 	All(destSlice interface{}) error
 
+	// AllColumns dumps all the results into the given pointer to struct, in
+	// column-major order. Each field of the struct must be a slice, which is
+	// matched with a column using the `db` tag, and gets one element per row.
+	// Columns without a matching field are discarded.
+	//
+	//   var cols struct {
+	//     ID   []int64  `db:"id"`
+	//     Name []string `db:"name"`
+	//   }
+	//   err := q.AllColumns(&cols)
+	//
+	// AllColumns does not allocate a new struct per row, use it when a large
+	// number of rows is going to be processed column by column.
+	AllColumns(destStruct interface{}) error
+
 	// One maps the row that is in the current query cursor into the
 	// given interface, which can be a pointer to either a map or a
 	// struct.
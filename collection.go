@@ -45,6 +45,30 @@ type Collection interface {
 	// newly added element.
 	Insert(interface{}) (*InsertResult, error)
 
+	// InsertMany inserts all the items of the given slice into the collection
+	// using multi-row INSERT statements of up to batchSize rows each. If
+	// batchSize is < 1 all the items are sent in a single statement. InsertMany
+	// returns one InsertResult per item, in the same order the items were
+	// given. If an error occurs, the results of the rows that were already
+	// inserted are returned along with the error. Adapters that can't tell the
+	// ID generated for a row return an InsertResult with a nil ID for it.
+	InsertMany(items interface{}, batchSize int) ([]*InsertResult, error)
+
+	// Upsert inserts a new item into the collection or, if the item conflicts
+	// with an existing row on the given columns, updates that row with the
+	// values of the item. When no conflict columns are given the primary keys
+	// of the collection are used. Upsert returns the ID of the inserted or
+	// updated element the same way Insert does. If the database does not
+	// support upserts this method returns db.ErrUnsupported.
+	Upsert(item interface{}, conflictColumns ...string) (*InsertResult, error)
+
+	// InsertOrIgnore inserts a new item into the collection unless it
+	// conflicts with an existing row, in which case nothing is written and the
+	// returned InsertResult has a nil ID. It makes inserts idempotent without
+	// checking for the row first. If the database does not support it this
+	// method returns db.ErrUnsupported.
+	InsertOrIgnore(item interface{}) (*InsertResult, error)
+
 	// InsertReturning is like Insert() but it takes a pointer to map or struct
 	// and, if the operation succeeds, updates it with data from the newly
 	// inserted row. If the database does not support transactions this method
@@ -58,6 +82,69 @@ type Collection interface {
 	// db.ErrUnsupported
 	UpdateReturning(interface{}) error
 
+	// CompileInsert returns the INSERT statement and the arguments Insert()
+	// would use to add the given item, without sending anything to the
+	// database. Clauses that adapters append when executing the statement
+	// (like RETURNING) are not included. If the database does not use SQL this
+	// method returns db.ErrUnsupported.
+	CompileInsert(interface{}) (string, []interface{}, error)
+
+	// FindDescendants returns the item with the given ID and all the items
+	// below it in the hierarchy defined by parentColumn, which holds the ID of
+	// the parent of each item. Nodes are sorted by depth and linked to their
+	// children, so the first node is the root of the tree:
+	//
+	//   nodes, err := categories.FindDescendants(1, "parent_id")
+	//
+	// SQL databases use a recursive common table expression when they support
+	// it, other databases are walked one level at a time.
+	FindDescendants(rootID interface{}, parentColumn string) ([]*Node, error)
+
+	// FindAncestors returns the item with the given ID and all the items above
+	// it in the hierarchy defined by parentColumn, the last node is the top of
+	// the hierarchy. See FindDescendants.
+	FindAncestors(id interface{}, parentColumn string) ([]*Node, error)
+
+	// ExistingIDs returns which of the given primary key values exist in the
+	// collection, the returned map has one entry per ID. IDs are checked with
+	// a single IN query per chunk of IDs instead of one query per ID:
+	//
+	//   exists, err := col.ExistingIDs([]interface{}{1, 2, 3})
+	//   if !exists[2] {
+	//     ...
+	//   }
+	ExistingIDs(ids []interface{}) (map[interface{}]bool, error)
+
+	// Stats returns the storage statistics of the collection, like its
+	// estimated number of rows and the size of its data and indexes.
+	Stats() (*CollectionStats, error)
+
+	// Analyze refreshes the statistics the database keeps on the collection,
+	// which are used by the query planner and reported by Stats.
+	Analyze() error
+
+	// Vacuum reclaims the storage of the deleted and obsolete rows of the
+	// collection. It's supported by PostgreSQL and SQLite, SQLite vacuums the
+	// whole database.
+	Vacuum() error
+
+	// Optimize rebuilds the collection and its indexes to reclaim unused
+	// storage and defragment its data. It's supported by MySQL.
+	Optimize() error
+
+	// Triggers returns the triggers of the collection, sorted by name. It's
+	// supported by PostgreSQL, MySQL and SQLite.
+	Triggers() ([]*Trigger, error)
+
+	// CreateTrigger creates a row-level trigger on the collection, with the
+	// syntax of the database. On PostgreSQL the body is wrapped in a trigger
+	// function that is named after the collection and the trigger.
+	CreateTrigger(trigger *Trigger) error
+
+	// DropTrigger drops the trigger with the given name from the collection,
+	// and its function on PostgreSQL.
+	DropTrigger(name string) error
+
 	// Exists returns true if the collection exists, false otherwise.
 	Exists() (bool, error)
 
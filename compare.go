@@ -0,0 +1,248 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/upper/db/v4/internal/adapter"
+)
+
+// ComparisonOperator identifies the operator of a structured condition.
+type ComparisonOperator = adapter.ComparisonOperator
+
+// Comparison operators for structured conditions, see Compare.
+const (
+	OpEq         = adapter.ComparisonOperatorEqual
+	OpNotEq      = adapter.ComparisonOperatorNotEqual
+	OpLt         = adapter.ComparisonOperatorLessThan
+	OpGt         = adapter.ComparisonOperatorGreaterThan
+	OpLte        = adapter.ComparisonOperatorLessThanOrEqualTo
+	OpGte        = adapter.ComparisonOperatorGreaterThanOrEqualTo
+	OpBetween    = adapter.ComparisonOperatorBetween
+	OpNotBetween = adapter.ComparisonOperatorNotBetween
+	OpIn         = adapter.ComparisonOperatorIn
+	OpNotIn      = adapter.ComparisonOperatorNotIn
+	OpIs         = adapter.ComparisonOperatorIs
+	OpIsNot      = adapter.ComparisonOperatorIsNot
+	OpLike       = adapter.ComparisonOperatorLike
+	OpNotLike    = adapter.ComparisonOperatorNotLike
+	OpRegExp     = adapter.ComparisonOperatorRegExp
+	OpNotRegExp  = adapter.ComparisonOperatorNotRegExp
+	OpILike      = adapter.ComparisonOperatorILike
+	OpNotILike   = adapter.ComparisonOperatorNotILike
+)
+
+// legacyOperators maps the operators that can be written after the column
+// name in Cond keys to structured operators.
+var legacyOperators = map[string]ComparisonOperator{
+	"=":           OpEq,
+	"==":          OpEq,
+	"!=":          OpNotEq,
+	"<>":          OpNotEq,
+	"<":           OpLt,
+	">":           OpGt,
+	"<=":          OpLte,
+	">=":          OpGte,
+	"BETWEEN":     OpBetween,
+	"NOT BETWEEN": OpNotBetween,
+	"IN":          OpIn,
+	"NOT IN":      OpNotIn,
+	"IS":          OpIs,
+	"IS NOT":      OpIsNot,
+	"LIKE":        OpLike,
+	"NOT LIKE":    OpNotLike,
+	"REGEXP":      OpRegExp,
+	"NOT REGEXP":  OpNotRegExp,
+	"ILIKE":       OpILike,
+	"NOT ILIKE":   OpNotILike,
+	"$eq":         OpEq,
+	"$ne":         OpNotEq,
+	"$lt":         OpLt,
+	"$gt":         OpGt,
+	"$lte":        OpLte,
+	"$gte":        OpGte,
+	"$in":         OpIn,
+	"$nin":        OpNotIn,
+	"$regex":      OpRegExp,
+}
+
+// CompareExpr is a condition made of a column, a comparison operator and a
+// value. Unlike Cond keys like "age >=" the parts of a CompareExpr are kept
+// apart, so they can be inspected and validated before the query is built.
+type CompareExpr struct {
+	column string
+	op     ComparisonOperator
+	value  interface{}
+}
+
+// Compare returns a structured condition that compares column against value
+// using the given operator:
+//
+//	// age >= 18
+//	db.Compare("age", db.OpGte, 18)
+//
+//	// id NOT IN (1, 2, 3)
+//	db.Compare("id", db.OpNotIn, []int{1, 2, 3})
+//
+//	// created_at BETWEEN a AND b
+//	db.Compare("created_at", db.OpBetween, []time.Time{a, b})
+//
+// Values of OpIn, OpNotIn, OpBetween and OpNotBetween are expected to be
+// slices. Structured conditions can be mixed with Cond, And, Or and Not.
+func Compare(column string, op ComparisonOperator, value interface{}) *CompareExpr {
+	switch op {
+	case OpIn, OpNotIn, OpBetween, OpNotBetween:
+		if value != nil {
+			value = toInterfaceArray(value)
+		}
+	}
+	return &CompareExpr{column: strings.TrimSpace(column), op: op, value: value}
+}
+
+// Column returns the name of the column being compared.
+func (c *CompareExpr) Column() string {
+	return c.column
+}
+
+// ComparisonOperator returns the comparison operator.
+func (c *CompareExpr) ComparisonOperator() ComparisonOperator {
+	return c.op
+}
+
+// Value returns the value the column is compared against.
+func (c *CompareExpr) Value() interface{} {
+	return c.value
+}
+
+// Validate returns an error if the column is missing, the operator is not
+// known or the value does not fit the operator.
+func (c *CompareExpr) Validate() error {
+	if c.column == "" {
+		return fmt.Errorf("%w: missing column", ErrInvalidCondition)
+	}
+	if strings.ContainsAny(c.column, " \t\n") {
+		return fmt.Errorf("%w: column %q contains whitespace", ErrInvalidCondition, c.column)
+	}
+	switch c.op {
+	case OpEq, OpNotEq, OpLt, OpGt, OpLte, OpGte:
+	case OpIs, OpIsNot:
+		switch c.value {
+		case nil, true, false:
+		default:
+			return fmt.Errorf("%w: %q expects nil, true or false", ErrInvalidCondition, c.column)
+		}
+	case OpIn, OpNotIn:
+		if _, ok := c.value.([]interface{}); !ok {
+			return fmt.Errorf("%w: %q expects a list of values", ErrInvalidCondition, c.column)
+		}
+	case OpBetween, OpNotBetween:
+		if values, ok := c.value.([]interface{}); !ok || len(values) != 2 {
+			return fmt.Errorf("%w: %q expects lower and upper bounds", ErrInvalidCondition, c.column)
+		}
+	case OpLike, OpNotLike, OpILike, OpNotILike, OpRegExp, OpNotRegExp:
+		if _, ok := c.value.(string); !ok {
+			return fmt.Errorf("%w: %q expects a string pattern", ErrInvalidCondition, c.column)
+		}
+	default:
+		return fmt.Errorf("%w: unknown operator %v for %q", ErrInvalidCondition, c.op, c.column)
+	}
+	return nil
+}
+
+// Comparison returns the operator and value as a Comparison.
+func (c *CompareExpr) Comparison() *Comparison {
+	return &Comparison{adapter.NewComparisonOperator(c.op, c.value)}
+}
+
+// Expressions returns the condition as a Cond.
+func (c *CompareExpr) Expressions() []LogicalExpr {
+	return []LogicalExpr{Cond{c.column: c.Comparison()}}
+}
+
+// Operator returns the AND operator.
+func (c *CompareExpr) Operator() LogicalOperator {
+	return adapter.LogicalOperatorAnd
+}
+
+// Empty returns false.
+func (c *CompareExpr) Empty() bool {
+	return false
+}
+
+// Comparisons returns the entries of the map as structured conditions. Keys
+// that are not strings or that use operators with no structured equivalent
+// return an error.
+func (c Cond) Comparisons() ([]*CompareExpr, error) {
+	z := make([]*CompareExpr, 0, len(c))
+	for _, k := range c.keys() {
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: key of type %T", ErrInvalidCondition, k)
+		}
+		expr, err := parseCondEntry(key, c[k])
+		if err != nil {
+			return nil, err
+		}
+		z = append(z, expr)
+	}
+	return z, nil
+}
+
+func parseCondEntry(key string, value interface{}) (*CompareExpr, error) {
+	chunks := strings.SplitN(strings.TrimSpace(key), " ", 2)
+	column := chunks[0]
+
+	if cmp, ok := value.(*Comparison); ok {
+		if len(chunks) > 1 {
+			return nil, fmt.Errorf("%w: %q has both an operator and a comparison", ErrInvalidCondition, key)
+		}
+		if cmp.Operator() == adapter.ComparisonOperatorCustom {
+			return nil, fmt.Errorf("%w: custom operator %q", ErrInvalidCondition, cmp.CustomOperator())
+		}
+		return &CompareExpr{column: column, op: cmp.Operator(), value: cmp.Value()}, nil
+	}
+
+	if len(chunks) == 1 {
+		if value == nil {
+			return Compare(column, OpIs, nil), nil
+		}
+		if _, isBytes := value.([]byte); !isBytes && reflect.TypeOf(value).Kind() == reflect.Slice {
+			return Compare(column, OpIn, value), nil
+		}
+		return Compare(column, OpEq, value), nil
+	}
+
+	opName := strings.Join(strings.Fields(chunks[1]), " ")
+	op, ok := legacyOperators[opName]
+	if !ok {
+		op, ok = legacyOperators[strings.ToUpper(opName)]
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown operator %q in %q", ErrInvalidCondition, chunks[1], key)
+	}
+	return Compare(column, op, value), nil
+}
+
+var _ = LogicalExpr(&CompareExpr{})
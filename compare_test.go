@@ -0,0 +1,75 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	assert.NoError(t, Compare("age", OpGte, 18).Validate())
+	assert.NoError(t, Compare("id", OpIn, []int{1, 2}).Validate())
+	assert.Equal(t, []interface{}{1, 2}, Compare("id", OpIn, []int{1, 2}).Value())
+
+	for _, expr := range []*CompareExpr{
+		Compare("", OpEq, 1),
+		Compare("first name", OpEq, 1),
+		Compare("age", OpBetween, []int{1}),
+		Compare("deleted_at", OpIs, 1),
+		Compare("name", OpLike, 1),
+		Compare("age", ComparisonOperator(200), 1),
+	} {
+		assert.True(t, errors.Is(expr.Validate(), ErrInvalidCondition), "%v", expr)
+	}
+}
+
+func TestCondComparisons(t *testing.T) {
+	exprs, err := Cond{
+		"age >=":        18,
+		"deleted_at":    nil,
+		"id":            []int{1, 2},
+		"name":          Like("J%"),
+		"status NOT IN": []string{"banned"},
+	}.Comparisons()
+	assert.NoError(t, err)
+
+	got := map[string]ComparisonOperator{}
+	for _, expr := range exprs {
+		got[expr.Column()] = expr.ComparisonOperator()
+	}
+	assert.Equal(t, map[string]ComparisonOperator{
+		"age":        OpGte,
+		"deleted_at": OpIs,
+		"id":         OpIn,
+		"name":       OpLike,
+		"status":     OpNotIn,
+	}, got)
+
+	_, err = Cond{"age =>": 18}.Comparisons()
+	assert.True(t, errors.Is(err, ErrInvalidCondition))
+
+	_, err = Cond{"age": Op("@>", 18)}.Comparisons()
+	assert.True(t, errors.Is(err, ErrInvalidCondition))
+}
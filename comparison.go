@@ -135,6 +135,16 @@ func NotLike(value string) *Comparison {
 	return &Comparison{adapter.NewComparisonOperator(adapter.ComparisonOperatorNotLike, value)}
 }
 
+// ILike is like Like but the match is case-insensitive.
+func ILike(value string) *Comparison {
+	return &Comparison{adapter.NewComparisonOperator(adapter.ComparisonOperatorILike, value)}
+}
+
+// NotILike is like NotLike but the match is case-insensitive.
+func NotILike(value string) *Comparison {
+	return &Comparison{adapter.NewComparisonOperator(adapter.ComparisonOperatorNotILike, value)}
+}
+
 // RegExp is a comparison that checks whether the reference matches the regular
 // expression.
 func RegExp(value string) *Comparison {
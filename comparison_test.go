@@ -112,6 +112,14 @@ func TestComparison(t *testing.T) {
 			adapter.NewComparisonOperator(adapter.ComparisonOperatorNotLike, "%z%"),
 			NotLike("%z%"),
 		},
+		{
+			adapter.NewComparisonOperator(adapter.ComparisonOperatorILike, "%a%"),
+			ILike("%a%"),
+		},
+		{
+			adapter.NewComparisonOperator(adapter.ComparisonOperatorNotILike, "%z%"),
+			NotILike("%z%"),
+		},
 		{
 			adapter.NewComparisonOperator(adapter.ComparisonOperatorRegExp, ".*"),
 			RegExp(".*"),
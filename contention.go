@@ -0,0 +1,194 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ContentionStats holds the number of statements that failed because of
+// contention with other sessions, see ContentionMetrics.
+type ContentionStats struct {
+	// LockTimeouts is the number of statements that gave up waiting for a
+	// lock held by another session.
+	LockTimeouts uint64
+
+	// Deadlocks is the number of statements that were chosen as the victim of
+	// a deadlock.
+	Deadlocks uint64
+
+	// BusyErrors is the number of statements that failed because the database
+	// was locked by another connection, after the busy timeout of the driver
+	// expired (SQLite).
+	BusyErrors uint64
+
+	// TransactionAborts is the number of statements that failed because their
+	// transaction was aborted by a conflict. Transactions run with Tx are
+	// retried after these.
+	TransactionAborts uint64
+
+	// LockWaitTime is the total time spent by the statements above before
+	// they failed, most of it waiting for locks.
+	LockWaitTime time.Duration
+}
+
+// ContentionEvent describes a statement that failed because of contention.
+type ContentionEvent struct {
+	// QueryID is the ID of the statement, as printed in the query log.
+	QueryID uint64
+
+	// Query is the statement that failed.
+	Query string
+
+	// Kind is one of ErrLockTimeout, ErrDeadlock, ErrDatabaseBusy or
+	// ErrTransactionAborted.
+	Kind error
+
+	// Duration is the time the statement ran before it failed.
+	Duration time.Duration
+
+	// Err is the error returned by the driver.
+	Err error
+}
+
+// ContentionMetrics is a middleware that counts the statements that fail
+// because of contention with other sessions, such as lock timeouts and
+// deadlocks, as translated by the adapter:
+//
+//	metrics := db.NewContentionMetrics()
+//	metrics.OnContention(func(ev *db.ContentionEvent) {
+//		log.Printf("query %d: %v after %v", ev.QueryID, ev.Kind, ev.Duration)
+//	})
+//	sess.Use(metrics.Middleware())
+//	...
+//	stats := metrics.Stats()
+//
+// The number of transactions that are currently waiting for locks can be
+// read from the database with BlockedTransactions.
+type ContentionMetrics struct {
+	mu      sync.Mutex
+	stats   ContentionStats
+	onEvent func(*ContentionEvent)
+}
+
+// NewContentionMetrics returns a ContentionMetrics with all counters at zero.
+func NewContentionMetrics() *ContentionMetrics {
+	return &ContentionMetrics{}
+}
+
+// OnContention sets a function that is called after each statement that
+// fails because of contention. It's called from the goroutine that executed
+// the statement, and must be safe for concurrent use.
+func (m *ContentionMetrics) OnContention(fn func(*ContentionEvent)) {
+	m.mu.Lock()
+	m.onEvent = fn
+	m.mu.Unlock()
+}
+
+// Stats returns a snapshot of the counters.
+func (m *ContentionMetrics) Stats() ContentionStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// Reset sets all counters to zero.
+func (m *ContentionMetrics) Reset() {
+	m.mu.Lock()
+	m.stats = ContentionStats{}
+	m.mu.Unlock()
+}
+
+// Middleware returns the middleware that feeds the counters, it can be used
+// by many sessions at once.
+func (m *ContentionMetrics) Middleware() Middleware {
+	return func(next QueryHandler) QueryHandler {
+		return func(ctx context.Context, query string, args []interface{}) error {
+			start := time.Now()
+			err := next(ctx, query, args)
+			if err != nil {
+				m.record(ctx, query, err, time.Since(start))
+			}
+			return err
+		}
+	}
+}
+
+func (m *ContentionMetrics) record(ctx context.Context, query string, err error, duration time.Duration) {
+	translated := StatementError(ctx)
+	if translated == nil {
+		translated = err
+	}
+
+	var kind error
+	for _, k := range []error{ErrLockTimeout, ErrDeadlock, ErrDatabaseBusy, ErrTransactionAborted} {
+		if errors.Is(translated, k) {
+			kind = k
+			break
+		}
+	}
+	if kind == nil {
+		return
+	}
+
+	m.mu.Lock()
+	switch kind {
+	case ErrLockTimeout:
+		m.stats.LockTimeouts++
+	case ErrDeadlock:
+		m.stats.Deadlocks++
+	case ErrDatabaseBusy:
+		m.stats.BusyErrors++
+	case ErrTransactionAborted:
+		m.stats.TransactionAborts++
+	}
+	m.stats.LockWaitTime += duration
+	onEvent := m.onEvent
+	m.mu.Unlock()
+
+	if onEvent != nil {
+		queryID, _ := QueryID(ctx)
+		onEvent(&ContentionEvent{
+			QueryID:  queryID,
+			Query:    query,
+			Kind:     kind,
+			Duration: duration,
+			Err:      err,
+		})
+	}
+}
+
+// BlockedTransactions returns the number of transactions, from any session,
+// that are currently waiting for a lock held by another transaction. It
+// returns ErrUnsupported if the adapter can't tell.
+func BlockedTransactions(sess Session) (int, error) {
+	counter, ok := sess.(interface {
+		BlockedTransactions() (int, error)
+	})
+	if !ok {
+		return 0, ErrUnsupported
+	}
+	return counter.BlockedTransactions()
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4/internal/queryctx"
+)
+
+func TestContentionMetrics(t *testing.T) {
+	metrics := NewContentionMetrics()
+
+	var events []*ContentionEvent
+	metrics.OnContention(func(ev *ContentionEvent) {
+		events = append(events, ev)
+	})
+
+	driverErr := errors.New("deadlock detected")
+
+	handler := Chain(func(ctx context.Context, query string, args []interface{}) error {
+		switch query {
+		case "UPDATE a":
+			// Sessions record the error as translated by the adapter.
+			queryctx.SetError(ctx, NewContentionError(ErrDeadlock, driverErr))
+			return driverErr
+		case "UPDATE b":
+			return NewContentionError(ErrLockTimeout, errors.New("lock timeout"))
+		case "INSERT c":
+			return NewConstraintError(ErrDuplicateKey, errors.New("duplicate"))
+		}
+		return nil
+	}, metrics.Middleware())
+
+	ctx := queryctx.WithQueryID(context.Background(), 7)
+
+	assert.Equal(t, driverErr, handler(ctx, "UPDATE a", nil))
+	assert.Error(t, handler(context.Background(), "UPDATE b", nil))
+	assert.Error(t, handler(context.Background(), "INSERT c", nil))
+	assert.NoError(t, handler(context.Background(), "SELECT 1", nil))
+
+	stats := metrics.Stats()
+	assert.Equal(t, uint64(1), stats.Deadlocks)
+	assert.Equal(t, uint64(1), stats.LockTimeouts)
+	assert.Zero(t, stats.BusyErrors)
+	assert.Zero(t, stats.TransactionAborts)
+
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, uint64(7), events[0].QueryID)
+		assert.Equal(t, "UPDATE a", events[0].Query)
+		assert.Equal(t, ErrDeadlock, events[0].Kind)
+		assert.Equal(t, driverErr, events[0].Err)
+		assert.Equal(t, ErrLockTimeout, events[1].Kind)
+	}
+
+	metrics.Reset()
+	assert.Equal(t, ContentionStats{}, metrics.Stats())
+}
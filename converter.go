@@ -0,0 +1,94 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ConverterFunc transforms src, a value read from a column as returned by the
+// driver (nil, int64, float64, bool, []byte, string or time.Time), into a Go
+// value. Returning a nil value sets the destination to its zero value.
+type ConverterFunc func(src interface{}) (interface{}, error)
+
+type converterKey struct {
+	sqlType string
+	goType  reflect.Type
+}
+
+var (
+	convertersMu sync.RWMutex
+	converters   = map[converterKey]ConverterFunc{}
+)
+
+// RegisterConverter sets fn as the function that converts values read from
+// columns of the given SQL type into struct fields of the same type as goType,
+// taking precedence over the conversions made by the driver and the adapter:
+//
+//	db.RegisterConverter("TEXT", time.Time{}, func(src interface{}) (interface{}, error) {
+//		...
+//	})
+//
+// SQL types are matched case-insensitively and without length or precision,
+// "varchar(60)" matches "VARCHAR". Fields that are pointers to goType use the
+// same converter. Passing a nil fn removes the converter.
+func RegisterConverter(sqlType string, goType interface{}, fn ConverterFunc) {
+	key := converterKey{normalizeSQLType(sqlType), reflect.TypeOf(goType)}
+
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+
+	if fn == nil {
+		delete(converters, key)
+		return
+	}
+	converters[key] = fn
+}
+
+// LookupConverter returns the converter registered for the given SQL and Go
+// types, or nil if there is none.
+func LookupConverter(sqlType string, goType reflect.Type) ConverterFunc {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+
+	if len(converters) == 0 {
+		return nil
+	}
+	return converters[converterKey{normalizeSQLType(sqlType), goType}]
+}
+
+// HasConverters returns true if at least one converter was registered.
+func HasConverters() bool {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+
+	return len(converters) > 0
+}
+
+func normalizeSQLType(sqlType string) string {
+	if i := strings.IndexByte(sqlType, '('); i >= 0 {
+		sqlType = sqlType[:i]
+	}
+	return strings.ToUpper(strings.TrimSpace(sqlType))
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterConverter(t *testing.T) {
+	type flag bool
+
+	fn := func(src interface{}) (interface{}, error) {
+		return flag(string(src.([]byte)) == "Y"), nil
+	}
+
+	flagType := reflect.TypeOf(flag(false))
+
+	assert.Nil(t, LookupConverter("CHAR", flagType))
+
+	RegisterConverter("char", flag(false), fn)
+	assert.True(t, HasConverters())
+
+	assert.NotNil(t, LookupConverter("CHAR", flagType))
+	assert.NotNil(t, LookupConverter("char(1)", flagType))
+	assert.Nil(t, LookupConverter("VARCHAR", flagType))
+	assert.Nil(t, LookupConverter("CHAR", reflect.TypeOf(false)))
+
+	RegisterConverter("CHAR", flag(false), nil)
+	assert.Nil(t, LookupConverter("CHAR", flagType))
+}
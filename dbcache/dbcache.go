@@ -0,0 +1,82 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package dbcache provides a read-through cache for collections.
+//
+// A wrapped collection serves lookups of a single primary key value, like
+// col.Find(id).One(&item), from the cache after the first read, and
+// invalidates cached items when they're written through it:
+//
+//	backend, err := db.NewEntityCache(1000)
+//	...
+//	accounts := dbcache.Wrap(sess.Collection("accounts"), backend, time.Minute)
+//
+// Writes that don't go through the wrapped collection are only seen after the
+// TTL expires. The cache works the same way as an EntityCacheSession, which
+// can be used to cache every collection of a session instead.
+package dbcache
+
+import (
+	"time"
+
+	db "github.com/upper/db/v4"
+)
+
+// Wrap returns a collection that reads through col and keeps the items it
+// reads by primary key in backend for up to ttl. A zero ttl keeps items until
+// they're invalidated or dropped by the backend.
+func Wrap(col db.Collection, backend db.EntityCache, ttl time.Duration) db.Collection {
+	if ttl > 0 {
+		backend = &expiringCache{backend: backend, ttl: ttl}
+	}
+	return db.WithEntityCache(col.Session(), backend).WrapCollection(col)
+}
+
+// expiringCache stores values in a backend along with their expiration time.
+type expiringCache struct {
+	backend db.EntityCache
+	ttl     time.Duration
+}
+
+type expiringValue struct {
+	value   interface{}
+	expires time.Time
+}
+
+func (c *expiringCache) Get(key string) (interface{}, bool) {
+	v, ok := c.backend.Get(key)
+	if !ok {
+		return nil, false
+	}
+	e, ok := v.(expiringValue)
+	if !ok || !time.Now().Before(e.expires) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (c *expiringCache) Set(key string, value interface{}) {
+	c.backend.Set(key, expiringValue{value: value, expires: time.Now().Add(c.ttl)})
+}
+
+func (c *expiringCache) Delete(key string) {
+	c.backend.Delete(key)
+}
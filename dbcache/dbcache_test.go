@@ -0,0 +1,173 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package dbcache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/sqlite"
+)
+
+// stubCache is a db.EntityCache that keeps values in a map and records the
+// keys it's asked to delete.
+type stubCache struct {
+	values  map[string]interface{}
+	deleted []string
+}
+
+func newStubCache() *stubCache {
+	return &stubCache{values: map[string]interface{}{}}
+}
+
+func (c *stubCache) Get(key string) (interface{}, bool) {
+	v, ok := c.values[key]
+	return v, ok
+}
+
+func (c *stubCache) Set(key string, value interface{}) {
+	c.values[key] = value
+}
+
+func (c *stubCache) Delete(key string) {
+	c.deleted = append(c.deleted, key)
+	delete(c.values, key)
+}
+
+type account struct {
+	ID      int64  `db:"id,omitempty"`
+	Name    string `db:"name"`
+	Balance int64  `db:"balance"`
+}
+
+func openAccounts(t *testing.T) db.Session {
+	sess, err := sqlite.Open(sqlite.ConnectionURL{Database: ":memory:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sess.SQL().Exec(`CREATE TABLE accounts (id integer primary key, name text, balance integer)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sess.SQL().Exec(`INSERT INTO accounts (id, name, balance) VALUES (1, 'Ann', 10)`); err != nil {
+		t.Fatal(err)
+	}
+	return sess
+}
+
+func TestExpiringCache(t *testing.T) {
+	backend := newStubCache()
+	c := &expiringCache{backend: backend, ttl: time.Minute}
+
+	c.Set("fresh", 1)
+	v, ok := c.Get("fresh")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	// Values are stored along with their expiration time.
+	if assert.IsType(t, expiringValue{}, backend.values["fresh"]) {
+		assert.WithinDuration(t, time.Now().Add(time.Minute), backend.values["fresh"].(expiringValue).expires, time.Second)
+	}
+
+	backend.values["expired"] = expiringValue{value: 2, expires: time.Now().Add(-time.Second)}
+	_, ok = c.Get("expired")
+	assert.False(t, ok)
+
+	// Values that weren't stored by the cache are ignored.
+	backend.values["foreign"] = 3
+	_, ok = c.Get("foreign")
+	assert.False(t, ok)
+
+	_, ok = c.Get("missing")
+	assert.False(t, ok)
+
+	c.Delete("fresh")
+	assert.Equal(t, []string{"fresh"}, backend.deleted)
+	_, ok = c.Get("fresh")
+	assert.False(t, ok)
+}
+
+func TestWrap(t *testing.T) {
+	sess := openAccounts(t)
+	defer sess.Close()
+
+	backend := newStubCache()
+	accounts := Wrap(sess.Collection("accounts"), backend, time.Minute)
+
+	var item account
+	assert.NoError(t, accounts.Find(1).One(&item))
+	assert.Equal(t, int64(10), item.Balance)
+	if assert.Len(t, backend.values, 1) {
+		for _, v := range backend.values {
+			assert.IsType(t, expiringValue{}, v)
+		}
+	}
+
+	// Writes through the collection invalidate the item.
+	assert.NoError(t, accounts.Find(1).Update(map[string]interface{}{"balance": 12}))
+	assert.Len(t, backend.deleted, 1)
+	assert.Len(t, backend.values, 0)
+
+	assert.NoError(t, accounts.Find(1).One(&item))
+	assert.Equal(t, int64(12), item.Balance)
+
+	// Expired items are read again from the database.
+	_, err := sess.SQL().Exec(`UPDATE accounts SET balance = 13 WHERE id = 1`)
+	assert.NoError(t, err)
+	for k, v := range backend.values {
+		e := v.(expiringValue)
+		e.expires = time.Now().Add(-time.Second)
+		backend.values[k] = e
+	}
+	assert.NoError(t, accounts.Find(1).One(&item))
+	assert.Equal(t, int64(13), item.Balance)
+}
+
+func TestWrapWithoutTTL(t *testing.T) {
+	sess := openAccounts(t)
+	defer sess.Close()
+
+	backend := newStubCache()
+	accounts := Wrap(sess.Collection("accounts"), backend, 0)
+
+	var item account
+	assert.NoError(t, accounts.Find(1).One(&item))
+
+	// Items are stored as they are and never expire.
+	if assert.Len(t, backend.values, 1) {
+		for _, v := range backend.values {
+			_, expiring := v.(expiringValue)
+			assert.False(t, expiring)
+		}
+	}
+
+	_, err := sess.SQL().Exec(`UPDATE accounts SET balance = 11 WHERE id = 1`)
+	assert.NoError(t, err)
+	assert.NoError(t, accounts.Find(1).One(&item))
+	assert.Equal(t, int64(10), item.Balance)
+
+	// Until a write through the collection invalidates them.
+	assert.NoError(t, accounts.Find(1).Delete())
+	assert.Len(t, backend.deleted, 1)
+	assert.Equal(t, db.ErrNoMoreRows, accounts.Find(1).One(&item))
+}
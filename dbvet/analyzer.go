@@ -0,0 +1,213 @@
+// Package dbvet provides a go/analysis analyzer that checks the column names
+// a program uses with upper/db against a snapshot of the database schema.
+//
+// The analyzer looks at:
+//
+//	db.Cond{"column": value}       string keys of db.Cond literals
+//	sess.SQL().Select("column")    string arguments of Select and Columns
+//	Name string `db:"column"`      db struct tags
+//
+// Only constant strings are checked, anything that looks like an expression
+// (function calls, "*", quoted or aliased names that can't be resolved) is
+// skipped.
+//
+// dbvet is built on golang.org/x/tools v0.40.0 and requires Go 1.24 or later,
+// as declared in its go.mod. upper/db itself keeps its own, older, minimum.
+package dbvet
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const dbPackagePath = "github.com/upper/db/v4"
+
+var schemaPath string
+
+// Analyzer reports column names that don't exist in the schema snapshot given
+// with the -schema flag. If no snapshot is given the analyzer does nothing.
+var Analyzer = &analysis.Analyzer{
+	Name:     "dbvet",
+	Doc:      "check upper/db column names against a schema snapshot",
+	Run:      run,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+}
+
+func init() {
+	Analyzer.Flags.StringVar(&schemaPath, "schema", "", "path to a JSON schema snapshot")
+}
+
+var (
+	schemasMu sync.Mutex
+	schemas   = map[string]*Schema{}
+)
+
+func loadSchema(path string) (*Schema, error) {
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+
+	if schema, ok := schemas[path]; ok {
+		return schema, nil
+	}
+	schema, err := LoadSchema(path)
+	if err != nil {
+		return nil, err
+	}
+	schemas[path] = schema
+	return schema, nil
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	if schemaPath == "" {
+		return nil, nil
+	}
+	schema, err := loadSchema(schemaPath)
+	if err != nil {
+		return nil, err
+	}
+
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{
+		(*ast.CompositeLit)(nil),
+		(*ast.CallExpr)(nil),
+		(*ast.StructType)(nil),
+	}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.CompositeLit:
+			checkCond(pass, schema, n)
+		case *ast.CallExpr:
+			checkSelect(pass, schema, n)
+		case *ast.StructType:
+			checkTags(pass, schema, n)
+		}
+	})
+
+	return nil, nil
+}
+
+func checkCond(pass *analysis.Pass, schema *Schema, lit *ast.CompositeLit) {
+	if !isDBType(pass.TypesInfo.TypeOf(lit), "Cond") {
+		return
+	}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := stringValue(pass, kv.Key)
+		if !ok {
+			continue
+		}
+		// The key may be followed by an operator, as in "id >".
+		fields := strings.Fields(key)
+		if len(fields) == 0 {
+			continue
+		}
+		if column, ok := columnName(fields[0]); ok && !schema.HasColumn(column) {
+			pass.Reportf(kv.Key.Pos(), "unknown column %q in db.Cond", column)
+		}
+	}
+}
+
+func checkSelect(pass *analysis.Pass, schema *Schema, call *ast.CallExpr) {
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != dbPackagePath {
+		return
+	}
+	if name := fn.Name(); name != "Select" && name != "Columns" {
+		return
+	}
+	for _, arg := range call.Args {
+		value, ok := stringValue(pass, arg)
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		// "column AS alias"
+		if len(fields) == 3 && strings.EqualFold(fields[1], "AS") {
+			fields = fields[:1]
+		}
+		if len(fields) != 1 {
+			continue
+		}
+		if column, ok := columnName(fields[0]); ok && !schema.HasColumn(column) {
+			pass.Reportf(arg.Pos(), "unknown column %q in %s", column, fn.Name())
+		}
+	}
+}
+
+func checkTags(pass *analysis.Pass, schema *Schema, st *ast.StructType) {
+	for _, field := range st.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+		value, ok := reflect.StructTag(tag).Lookup("db")
+		if !ok {
+			continue
+		}
+		parts := strings.Split(value, ",")
+		name := parts[0]
+		if name == "" || name == "-" || hasOption(parts[1:], "inline") {
+			continue
+		}
+		if column, ok := columnName(name); ok && !schema.HasColumn(column) {
+			pass.Reportf(field.Tag.Pos(), "unknown column %q in db tag", column)
+		}
+	}
+}
+
+func isDBType(t types.Type, name string) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == dbPackagePath && obj.Name() == name
+}
+
+func stringValue(pass *analysis.Pass, expr ast.Expr) (string, bool) {
+	tv, ok := pass.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// columnName returns the column part of a name, or false if the name is not a
+// plain (optionally table-qualified) column identifier.
+func columnName(name string) (string, bool) {
+	if name == "" || strings.ContainsAny(name, "*()'\"`") {
+		return "", false
+	}
+	for _, r := range name {
+		if !(r == '_' || r == '.' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return "", false
+		}
+	}
+	return name, true
+}
+
+func hasOption(options []string, option string) bool {
+	for _, o := range options {
+		if strings.TrimSpace(o) == option {
+			return true
+		}
+	}
+	return false
+}
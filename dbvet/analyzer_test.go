@@ -0,0 +1,16 @@
+package dbvet
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	schemaPath = "testdata/schema.json"
+	defer func() {
+		schemaPath = ""
+	}()
+
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "a")
+}
@@ -0,0 +1,14 @@
+// Command dbvet checks upper/db column names against a schema snapshot. It can
+// be run on its own or through go vet:
+//
+//	go vet -vettool=$(which dbvet) -schema=schema.json ./...
+package main
+
+import (
+	"github.com/upper/db/v4/dbvet"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(dbvet.Analyzer)
+}
@@ -0,0 +1,15 @@
+module github.com/upper/db/v4/dbvet
+
+go 1.24.0
+
+require (
+	github.com/upper/db/v4 v4.0.0
+	golang.org/x/tools v0.40.0
+)
+
+require (
+	golang.org/x/mod v0.31.0 // indirect
+	golang.org/x/sync v0.19.0 // indirect
+)
+
+replace github.com/upper/db/v4 => ../
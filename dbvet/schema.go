@@ -0,0 +1,113 @@
+package dbvet
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+
+	db "github.com/upper/db/v4"
+)
+
+// Schema is a snapshot of the tables of a database and their columns, it's
+// what the analyzer checks column names against. Triggers holds the names of
+// the triggers of each table that has any.
+type Schema struct {
+	Tables   map[string][]string `json:"tables"`
+	Triggers map[string][]string `json:"triggers,omitempty"`
+}
+
+// Snapshot introspects the tables of a SQL session and returns their columns
+// and triggers. Columns are read from the result of an empty SELECT, so no
+// rows are transferred.
+func Snapshot(sess db.Session) (*Schema, error) {
+	if sess.SQL() == nil {
+		return nil, db.ErrUnsupported
+	}
+
+	collections, err := sess.Collections()
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{Tables: map[string][]string{}}
+	for _, col := range collections {
+		rows, err := sess.SQL().
+			SelectFrom(col.Name()).
+			Where(db.Raw("1 = 0")).
+			Query()
+		if err != nil {
+			return nil, err
+		}
+		columns, err := rows.Columns()
+		_ = rows.Close()
+		if err != nil {
+			return nil, err
+		}
+		schema.Tables[col.Name()] = columns
+
+		triggers, err := col.Triggers()
+		if err != nil && !errors.Is(err, db.ErrUnsupported) {
+			return nil, err
+		}
+		for _, trigger := range triggers {
+			if schema.Triggers == nil {
+				schema.Triggers = map[string][]string{}
+			}
+			schema.Triggers[col.Name()] = append(schema.Triggers[col.Name()], trigger.Name)
+		}
+	}
+
+	return schema, nil
+}
+
+// LoadSchema reads a snapshot that was previously written with Save.
+func LoadSchema(path string) (*Schema, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var schema Schema
+	if err := json.Unmarshal(buf, &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// Save writes the snapshot to the given path as JSON.
+func (s *Schema) Save(path string) error {
+	buf, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(buf, '\n'), 0644)
+}
+
+// HasColumn returns true if the given column exists. The column may be
+// qualified with a table name ("table.column"), otherwise any table having a
+// column with that name is a match.
+func (s *Schema) HasColumn(column string) bool {
+	if i := strings.LastIndex(column, "."); i > 0 {
+		table := column[:i]
+		if _, ok := s.Tables[table]; ok {
+			return contains(s.Tables[table], column[i+1:])
+		}
+		// The qualifier may be an alias we don't know about.
+		column = column[i+1:]
+	}
+	for _, columns := range s.Tables {
+		if contains(columns, column) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(columns []string, column string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
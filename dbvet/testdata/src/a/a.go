@@ -0,0 +1,34 @@
+package a
+
+import (
+	db "github.com/upper/db/v4"
+)
+
+type Artist struct {
+	ID     uint64 `db:"id,omitempty"`
+	Name   string `db:"name"`
+	Gender string `db:"gender"` // want `unknown column "gender" in db tag`
+	Skip   string `db:"-"`
+	Other  `db:",inline"`
+}
+
+type Other struct {
+	Title string `db:"title"`
+}
+
+const titleColumn = "titel"
+
+func conds() {
+	_ = db.Cond{"id": 1, "name LIKE": "a%"}
+	_ = db.Cond{"age >": 18} // want `unknown column "age" in db.Cond`
+	_ = db.Cond{"publication.title": ""}
+	_ = db.Cond{"artist.title": ""} // want `unknown column "artist.title" in db.Cond`
+	_ = db.Cond{titleColumn: ""}    // want `unknown column "titel" in db.Cond`
+	_ = db.Cond{db.Cond{}: 1}
+}
+
+func selects(sqlb db.SQL) {
+	sqlb.Select("id", "name AS artist_name", "COUNT(*)", "*")
+	sqlb.Select("nickname")                                    // want `unknown column "nickname" in Select`
+	sqlb.Select().Columns("author_id", "p.title", "p.summary") // want `unknown column "p.summary" in Columns`
+}
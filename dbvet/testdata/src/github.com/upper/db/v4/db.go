@@ -0,0 +1,14 @@
+package db
+
+import "database/sql"
+
+type Cond map[interface{}]interface{}
+
+type Selector interface {
+	Columns(columns ...interface{}) Selector
+	Query() (*sql.Rows, error)
+}
+
+type SQL interface {
+	Select(columns ...interface{}) Selector
+}
@@ -0,0 +1,938 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Divergence describes a write that succeeded on the main session of a
+// DualWriteSession but failed on the mirror.
+type Divergence struct {
+	// Collection is the name of the collection the write was sent to, empty
+	// for writes that are not bound to a collection.
+	Collection string
+
+	// Operation is the name of the method that was called, like "Insert" or
+	// "Update".
+	Operation string
+
+	// Err is the error returned by the mirror.
+	Err error
+}
+
+// dualWriteState is shared by a DualWriteSession and the sessions derived
+// from it.
+type dualWriteState struct {
+	cutover uint32
+
+	mu             sync.RWMutex
+	onDivergence   func(Divergence)
+	shadowReads    float64
+	onReadMismatch func(ReadMismatch)
+
+	shadowWG sync.WaitGroup
+}
+
+func (st *dualWriteState) report(d Divergence) {
+	st.mu.RLock()
+	fn := st.onDivergence
+	st.mu.RUnlock()
+
+	if fn != nil {
+		fn(d)
+		return
+	}
+	LC().Warnf("upper: dual write diverged on %s %s: %v", d.Operation, d.Collection, d.Err)
+}
+
+// mirrorOp is a write that is replayed on the mirror session.
+type mirrorOp struct {
+	collection string
+	operation  string
+	fn         func(mirror Session) error
+}
+
+// DualWriteSession is a Session that sends writes to two sessions and reads
+// from one of them, it keeps a new database in sync with the current one
+// while migrating data between them.
+//
+// Before the cutover reads and writes go to the primary session, and writes
+// that succeed are replayed on the secondary. After the cutover the roles are
+// swapped: the secondary becomes the main session and the primary the mirror,
+// so going back is a matter of toggling the cutover off.
+//
+// A write that fails on the mirror does not fail the call, it's reported as a
+// Divergence instead. Inserted rows are read back from the main session and
+// inserted with the same values, including their IDs, into the mirror; other
+// writes are replayed with the same conditions. Statements sent through SQL()
+// are not mirrored.
+//
+// A sample of reads can be sent to the mirror as well to verify that both
+// sessions hold the same data, see SetShadowReads.
+type DualWriteSession struct {
+	primary   Session
+	secondary Session
+
+	state *dualWriteState
+
+	// Within transactions the writes for the mirror are queued and replayed
+	// after the main transaction is committed.
+	queueMu *sync.Mutex
+	queue   *[]mirrorOp
+}
+
+// DualWrite returns a session that mirrors the writes sent to primary into
+// secondary, see DualWriteSession.
+func DualWrite(primary Session, secondary Session) *DualWriteSession {
+	return &DualWriteSession{
+		primary:   primary,
+		secondary: secondary,
+		state:     &dualWriteState{},
+	}
+}
+
+// Primary returns the primary session.
+func (s *DualWriteSession) Primary() Session {
+	return s.primary
+}
+
+// Secondary returns the secondary session.
+func (s *DualWriteSession) Secondary() Session {
+	return s.secondary
+}
+
+// SetCutover makes the secondary session the main one when enabled is true,
+// and the primary session the main one again when it's false.
+func (s *DualWriteSession) SetCutover(enabled bool) {
+	var v uint32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreUint32(&s.state.cutover, v)
+}
+
+// Cutover returns true if the secondary session is the main one.
+func (s *DualWriteSession) Cutover() bool {
+	return atomic.LoadUint32(&s.state.cutover) == 1
+}
+
+// OnDivergence sets the function that is called when a write fails on the
+// mirror session, by default divergences are logged as warnings.
+func (s *DualWriteSession) OnDivergence(fn func(Divergence)) {
+	s.state.mu.Lock()
+	s.state.onDivergence = fn
+	s.state.mu.Unlock()
+}
+
+// sessions returns the session reads and writes are sent to, and the session
+// writes are mirrored to.
+func (s *DualWriteSession) sessions() (main Session, mirror Session) {
+	if s.queue == nil && s.Cutover() {
+		return s.secondary, s.primary
+	}
+	return s.primary, s.secondary
+}
+
+func (s *DualWriteSession) main() Session {
+	main, _ := s.sessions()
+	return main
+}
+
+// derive returns a DualWriteSession that shares the state of s.
+func (s *DualWriteSession) derive(primary Session, secondary Session) *DualWriteSession {
+	return &DualWriteSession{
+		primary:   primary,
+		secondary: secondary,
+		state:     s.state,
+		queueMu:   s.queueMu,
+		queue:     s.queue,
+	}
+}
+
+// mirror replays a write that succeeded on the main session on the given
+// mirror session, or queues it if s is a transaction.
+func (s *DualWriteSession) mirror(mirror Session, op mirrorOp) {
+	if s.queue != nil {
+		s.queueMu.Lock()
+		*s.queue = append(*s.queue, op)
+		s.queueMu.Unlock()
+		return
+	}
+
+	if err := op.fn(mirror); err != nil {
+		s.state.report(Divergence{Collection: op.collection, Operation: op.operation, Err: err})
+	}
+}
+
+func (s *DualWriteSession) ConnectionURL() ConnectionURL {
+	return s.main().ConnectionURL()
+}
+
+func (s *DualWriteSession) Name() string {
+	return s.main().Name()
+}
+
+// Ping pings both sessions.
+func (s *DualWriteSession) Ping() error {
+	main, mirror := s.sessions()
+	if err := main.Ping(); err != nil {
+		return err
+	}
+	return mirror.Ping()
+}
+
+func (s *DualWriteSession) Collection(name string) Collection {
+	return &dualWriteCollection{sess: s, name: name}
+}
+
+func (s *DualWriteSession) Collections() ([]Collection, error) {
+	main := s.main()
+	collections, err := main.Collections()
+	if err != nil {
+		return nil, err
+	}
+	for i := range collections {
+		collections[i] = s.Collection(collections[i].Name())
+	}
+	return collections, nil
+}
+
+// Save saves the record on the main session, and upserts it into the mirror.
+func (s *DualWriteSession) Save(record Record) error {
+	main, mirror := s.sessions()
+	if err := main.Save(record); err != nil {
+		return err
+	}
+
+	name := record.Store(main).Name()
+	s.mirror(mirror, mirrorOp{collection: name, operation: "Save", fn: func(mirror Session) error {
+		_, err := mirror.Collection(name).Upsert(record)
+		return err
+	}})
+	return nil
+}
+
+func (s *DualWriteSession) Get(record Record, cond interface{}) error {
+	return s.main().Get(record, cond)
+}
+
+func (s *DualWriteSession) Delete(record Record) error {
+	main, mirror := s.sessions()
+	if err := main.Delete(record); err != nil {
+		return err
+	}
+
+	name := record.Store(main).Name()
+	s.mirror(mirror, mirrorOp{collection: name, operation: "Delete", fn: func(mirror Session) error {
+		return mirror.Delete(record)
+	}})
+	return nil
+}
+
+func (s *DualWriteSession) Reset() {
+	s.primary.Reset()
+	s.secondary.Reset()
+}
+
+// Close closes both sessions.
+func (s *DualWriteSession) Close() error {
+	err := s.primary.Close()
+	if err2 := s.secondary.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+func (s *DualWriteSession) Driver() interface{} {
+	return s.main().Driver()
+}
+
+// SQL returns the SQL builder of the main session, statements sent through it
+// are not mirrored.
+func (s *DualWriteSession) SQL() SQL {
+	return s.main().SQL()
+}
+
+// Query runs the given query on the main session.
+func (s *DualWriteSession) Query(dst interface{}, query interface{}, args ...interface{}) error {
+	return s.main().Query(dst, query, args...)
+}
+
+// Exec runs the given statement on the main session, like statements sent
+// through SQL() it is not mirrored.
+func (s *DualWriteSession) Exec(query interface{}, args ...interface{}) (sql.Result, error) {
+	return s.main().Exec(query, args...)
+}
+
+func (s *DualWriteSession) Tx(fn func(sess Session) error) error {
+	return s.TxContext(s.Context(), fn, nil)
+}
+
+// TxContext runs fn within a transaction of the main session. The writes for
+// the mirror are replayed in a transaction of the mirror session after the
+// main transaction is committed, and are discarded if it's rolled back.
+func (s *DualWriteSession) TxContext(ctx context.Context, fn func(sess Session) error, opts *sql.TxOptions) error {
+	main, mirror := s.sessions()
+
+	var queue []mirrorOp
+	err := main.TxContext(ctx, func(tx Session) error {
+		// The transaction may be retried, writes from previous attempts are
+		// discarded.
+		queue = nil
+
+		txSess := &DualWriteSession{
+			primary:   tx,
+			secondary: mirror,
+			state:     s.state,
+			queueMu:   &sync.Mutex{},
+			queue:     &queue,
+		}
+		return fn(txSess)
+	}, opts)
+	if err != nil || len(queue) == 0 {
+		return err
+	}
+
+	if s.queue != nil {
+		// Nested transaction, the writes are replayed along with the ones of
+		// the outermost transaction.
+		for _, op := range queue {
+			s.mirror(mirror, op)
+		}
+		return nil
+	}
+
+	var failed *mirrorOp
+	mirrorErr := mirror.TxContext(ctx, func(tx Session) error {
+		for i := range queue {
+			if err := queue[i].fn(tx); err != nil {
+				failed = &queue[i]
+				return err
+			}
+		}
+		return nil
+	}, opts)
+	if mirrorErr != nil {
+		d := Divergence{Operation: "Tx", Err: mirrorErr}
+		if failed != nil {
+			d.Collection, d.Operation = failed.collection, failed.operation
+		}
+		s.state.report(d)
+	}
+
+	return nil
+}
+
+// SnapshotRead runs fn within a snapshot of the main session, the snapshot is
+// read-only so there are no writes to mirror.
+func (s *DualWriteSession) SnapshotRead(fn func(sess Session) error) error {
+	return s.main().SnapshotRead(fn)
+}
+
+func (s *DualWriteSession) Conn(fn func(sess Session) error) error {
+	return s.ConnContext(s.Context(), fn)
+}
+
+// ConnContext pins a connection of the main session, the writes for the
+// mirror are replayed through the pool of the mirror session.
+func (s *DualWriteSession) ConnContext(ctx context.Context, fn func(sess Session) error) error {
+	if s.queue == nil && s.Cutover() {
+		return s.secondary.ConnContext(ctx, func(conn Session) error {
+			return fn(s.derive(s.primary, conn))
+		})
+	}
+	return s.primary.ConnContext(ctx, func(conn Session) error {
+		return fn(s.derive(conn, s.secondary))
+	})
+}
+
+func (s *DualWriteSession) Context() context.Context {
+	return s.main().Context()
+}
+
+func (s *DualWriteSession) WithContext(ctx context.Context) Session {
+	return s.derive(s.primary.WithContext(ctx), s.secondary.WithContext(ctx))
+}
+
+// Use adds middleware to both sessions.
+func (s *DualWriteSession) Use(middleware ...Middleware) {
+	s.primary.Use(middleware...)
+	s.secondary.Use(middleware...)
+}
+
+func (s *DualWriteSession) SetBoolMapping(mapping *BoolMapping) {
+	s.primary.SetBoolMapping(mapping)
+	s.secondary.SetBoolMapping(mapping)
+}
+
+func (s *DualWriteSession) BoolMapping() *BoolMapping {
+	return s.main().BoolMapping()
+}
+
+func (s *DualWriteSession) SetPreparedStatementCache(value bool) {
+	s.primary.SetPreparedStatementCache(value)
+	s.secondary.SetPreparedStatementCache(value)
+}
+
+func (s *DualWriteSession) PreparedStatementCacheEnabled() bool {
+	return s.main().PreparedStatementCacheEnabled()
+}
+
+func (s *DualWriteSession) SetQueryIDComments(value bool) {
+	s.primary.SetQueryIDComments(value)
+	s.secondary.SetQueryIDComments(value)
+}
+
+func (s *DualWriteSession) QueryIDCommentsEnabled() bool {
+	return s.main().QueryIDCommentsEnabled()
+}
+
+func (s *DualWriteSession) SetConnMaxLifetime(t time.Duration) {
+	s.primary.SetConnMaxLifetime(t)
+	s.secondary.SetConnMaxLifetime(t)
+}
+
+func (s *DualWriteSession) ConnMaxLifetime() time.Duration {
+	return s.main().ConnMaxLifetime()
+}
+
+func (s *DualWriteSession) SetMaxIdleConns(n int) {
+	s.primary.SetMaxIdleConns(n)
+	s.secondary.SetMaxIdleConns(n)
+}
+
+func (s *DualWriteSession) MaxIdleConns() int {
+	return s.main().MaxIdleConns()
+}
+
+func (s *DualWriteSession) SetMaxOpenConns(n int) {
+	s.primary.SetMaxOpenConns(n)
+	s.secondary.SetMaxOpenConns(n)
+}
+
+func (s *DualWriteSession) MaxOpenConns() int {
+	return s.main().MaxOpenConns()
+}
+
+func (s *DualWriteSession) SetMaxTransactionRetries(n int) {
+	s.primary.SetMaxTransactionRetries(n)
+	s.secondary.SetMaxTransactionRetries(n)
+}
+
+func (s *DualWriteSession) MaxTransactionRetries() int {
+	return s.main().MaxTransactionRetries()
+}
+
+func (s *DualWriteSession) SetQueryLogger(logger QueryLogger) {
+	s.primary.SetQueryLogger(logger)
+	s.secondary.SetQueryLogger(logger)
+}
+
+func (s *DualWriteSession) QueryLogger() QueryLogger {
+	return s.main().QueryLogger()
+}
+
+func (s *DualWriteSession) SetSlowQueryThreshold(d time.Duration) {
+	s.primary.SetSlowQueryThreshold(d)
+	s.secondary.SetSlowQueryThreshold(d)
+}
+
+func (s *DualWriteSession) SlowQueryThreshold() time.Duration {
+	return s.main().SlowQueryThreshold()
+}
+
+// dualWriteCollection reads from the collection of the main session and
+// mirrors its writes. The main session is looked up on every call, so
+// collections follow the cutover.
+type dualWriteCollection struct {
+	sess *DualWriteSession
+	name string
+}
+
+func (c *dualWriteCollection) collections() (main Collection, mirror Session) {
+	mainSess, mirror := c.sess.sessions()
+	return mainSess.Collection(c.name), mirror
+}
+
+func (c *dualWriteCollection) main() Collection {
+	main, _ := c.collections()
+	return main
+}
+
+func (c *dualWriteCollection) mirror(mirror Session, operation string, fn func(mirror Collection) error) {
+	name := c.name
+	c.sess.mirror(mirror, mirrorOp{collection: name, operation: operation, fn: func(mirror Session) error {
+		return fn(mirror.Collection(name))
+	}})
+}
+
+func (c *dualWriteCollection) Name() string {
+	return c.name
+}
+
+func (c *dualWriteCollection) Session() Session {
+	return c.sess
+}
+
+func (c *dualWriteCollection) Find(conds ...interface{}) Result {
+	main, mirror := c.collections()
+	return &dualWriteResult{
+		Result: main.Find(conds...),
+		col:    c,
+		mirror: mirror,
+		conds:  conds,
+	}
+}
+
+func (c *dualWriteCollection) CompileInsert(item interface{}) (string, []interface{}, error) {
+	return c.main().CompileInsert(item)
+}
+
+func (c *dualWriteCollection) FindDescendants(rootID interface{}, parentColumn string) ([]*Node, error) {
+	return c.main().FindDescendants(rootID, parentColumn)
+}
+
+func (c *dualWriteCollection) FindAncestors(id interface{}, parentColumn string) ([]*Node, error) {
+	return c.main().FindAncestors(id, parentColumn)
+}
+
+func (c *dualWriteCollection) ExistingIDs(ids []interface{}) (map[interface{}]bool, error) {
+	return c.main().ExistingIDs(ids)
+}
+
+func (c *dualWriteCollection) Stats() (*CollectionStats, error) {
+	return c.main().Stats()
+}
+
+// Analyze, Vacuum and Optimize maintain the collection of the main session
+// only, the mirror is expected to be maintained on its own.
+func (c *dualWriteCollection) Analyze() error {
+	return c.main().Analyze()
+}
+
+func (c *dualWriteCollection) Vacuum() error {
+	return c.main().Vacuum()
+}
+
+func (c *dualWriteCollection) Optimize() error {
+	return c.main().Optimize()
+}
+
+func (c *dualWriteCollection) Triggers() ([]*Trigger, error) {
+	return c.main().Triggers()
+}
+
+// CreateTrigger and DropTrigger change the collection of the main session
+// only, like Analyze.
+func (c *dualWriteCollection) CreateTrigger(trigger *Trigger) error {
+	return c.main().CreateTrigger(trigger)
+}
+
+func (c *dualWriteCollection) DropTrigger(name string) error {
+	return c.main().DropTrigger(name)
+}
+
+func (c *dualWriteCollection) Exists() (bool, error) {
+	return c.main().Exists()
+}
+
+// storedItem reads the row that was written with the given ID from the main
+// collection, with the same type as item, so it can be written as is into
+// the mirror.
+func storedItem(main Collection, item interface{}, id ID) interface{} {
+	if id == nil {
+		return item
+	}
+
+	var dst interface{}
+	itemT := reflect.TypeOf(item)
+	for itemT != nil && itemT.Kind() == reflect.Ptr {
+		itemT = itemT.Elem()
+	}
+	if itemT != nil && itemT.Kind() == reflect.Struct {
+		dst = reflect.New(itemT).Interface()
+	} else {
+		dst = &map[string]interface{}{}
+	}
+
+	var cond interface{} = id
+	if keys, ok := id.(map[string]interface{}); ok {
+		c := Cond{}
+		for k, v := range keys {
+			c[k] = v
+		}
+		cond = c
+	}
+
+	if err := main.Find(cond).One(dst); err != nil {
+		return item
+	}
+	return reflect.ValueOf(dst).Elem().Interface()
+}
+
+func (c *dualWriteCollection) Insert(item interface{}) (*InsertResult, error) {
+	main, mirror := c.collections()
+
+	res, err := main.Insert(item)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := storedItem(main, item, res.ID())
+	c.mirror(mirror, "Insert", func(mirror Collection) error {
+		_, err := mirror.Insert(stored)
+		return err
+	})
+	return res, nil
+}
+
+func (c *dualWriteCollection) InsertMany(items interface{}, batchSize int) ([]*InsertResult, error) {
+	main, mirror := c.collections()
+
+	results, err := main.InsertMany(items, batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	itemsV := reflect.ValueOf(items)
+	stored := make([]interface{}, 0, len(results))
+	for i, res := range results {
+		stored = append(stored, storedItem(main, itemsV.Index(i).Interface(), res.ID()))
+	}
+
+	c.mirror(mirror, "InsertMany", func(mirror Collection) error {
+		_, err := mirror.InsertMany(stored, batchSize)
+		return err
+	})
+	return results, nil
+}
+
+func (c *dualWriteCollection) Upsert(item interface{}, conflictColumns ...string) (*InsertResult, error) {
+	main, mirror := c.collections()
+
+	res, err := main.Upsert(item, conflictColumns...)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := storedItem(main, item, res.ID())
+	c.mirror(mirror, "Upsert", func(mirror Collection) error {
+		_, err := mirror.Upsert(stored, conflictColumns...)
+		return err
+	})
+	return res, nil
+}
+
+func (c *dualWriteCollection) InsertOrIgnore(item interface{}) (*InsertResult, error) {
+	main, mirror := c.collections()
+
+	res, err := main.InsertOrIgnore(item)
+	if err != nil {
+		return nil, err
+	}
+	if res.ID() == nil {
+		// Nothing was written, the mirror is expected to have the row already.
+		return res, nil
+	}
+
+	stored := storedItem(main, item, res.ID())
+	c.mirror(mirror, "InsertOrIgnore", func(mirror Collection) error {
+		_, err := mirror.InsertOrIgnore(stored)
+		return err
+	})
+	return res, nil
+}
+
+func (c *dualWriteCollection) InsertReturning(item interface{}) error {
+	main, mirror := c.collections()
+
+	if err := main.InsertReturning(item); err != nil {
+		return err
+	}
+
+	// item holds the values that were stored, including its ID.
+	stored := copyItem(item)
+	c.mirror(mirror, "InsertReturning", func(mirror Collection) error {
+		_, err := mirror.Insert(stored)
+		return err
+	})
+	return nil
+}
+
+func (c *dualWriteCollection) UpdateReturning(item interface{}) error {
+	main, mirror := c.collections()
+
+	if err := main.UpdateReturning(item); err != nil {
+		return err
+	}
+
+	stored := copyItem(item)
+	c.mirror(mirror, "UpdateReturning", func(mirror Collection) error {
+		return mirror.UpdateReturning(copyItem(stored))
+	})
+	return nil
+}
+
+func (c *dualWriteCollection) Truncate() error {
+	main, mirror := c.collections()
+
+	if err := main.Truncate(); err != nil {
+		return err
+	}
+
+	c.mirror(mirror, "Truncate", func(mirror Collection) error {
+		return mirror.Truncate()
+	})
+	return nil
+}
+
+// copyItem returns a pointer to a copy of the value item points to, so the
+// mirror can't modify the item of the caller.
+func copyItem(item interface{}) interface{} {
+	itemV := reflect.ValueOf(item)
+	if itemV.Kind() != reflect.Ptr || itemV.IsNil() {
+		return item
+	}
+	dst := reflect.New(itemV.Elem().Type())
+	dst.Elem().Set(itemV.Elem())
+	return dst.Interface()
+}
+
+// newItem returns a pointer to a new value of the type dst points to, so the
+// mirror writes as many rows as the main session did, like one for a struct
+// or all of them for a slice.
+func newItem(dst interface{}) interface{} {
+	dstV := reflect.ValueOf(dst)
+	if dstV.Kind() != reflect.Ptr || dstV.IsNil() {
+		return dst
+	}
+	return reflect.New(dstV.Elem().Type()).Interface()
+}
+
+// dualWriteResult reads from the result set of the main session, and records
+// the calls that define it so writes can be replayed on the mirror.
+type dualWriteResult struct {
+	Result
+
+	col    *dualWriteCollection
+	mirror Session
+	conds  []interface{}
+	steps  []func(Result) Result
+}
+
+// then returns a result set that reads from res and replays step on the
+// mirror.
+func (r *dualWriteResult) then(res Result, step func(Result) Result) Result {
+	steps := make([]func(Result) Result, len(r.steps), len(r.steps)+1)
+	copy(steps, r.steps)
+	return &dualWriteResult{
+		Result: res,
+		col:    r.col,
+		mirror: r.mirror,
+		conds:  r.conds,
+		steps:  append(steps, step),
+	}
+}
+
+func (r *dualWriteResult) replay(operation string, fn func(mirror Result) error) {
+	r.col.mirror(r.mirror, operation, func(mirror Collection) error {
+		res := mirror.Find(r.conds...)
+		for _, step := range r.steps {
+			res = step(res)
+		}
+		return fn(res)
+	})
+}
+
+func (r *dualWriteResult) Update(values interface{}) error {
+	if err := r.Result.Update(values); err != nil {
+		return err
+	}
+	r.replay("Update", func(mirror Result) error {
+		return mirror.Update(values)
+	})
+	return nil
+}
+
+func (r *dualWriteResult) Delete() error {
+	if err := r.Result.Delete(); err != nil {
+		return err
+	}
+	r.replay("Delete", func(mirror Result) error {
+		return mirror.Delete()
+	})
+	return nil
+}
+
+func (r *dualWriteResult) UpdateCount(values interface{}) (uint64, error) {
+	n, err := r.Result.UpdateCount(values)
+	if err != nil {
+		return 0, err
+	}
+	r.replay("UpdateCount", func(mirror Result) error {
+		_, err := mirror.UpdateCount(values)
+		return err
+	})
+	return n, nil
+}
+
+func (r *dualWriteResult) DeleteCount() (uint64, error) {
+	n, err := r.Result.DeleteCount()
+	if err != nil {
+		return 0, err
+	}
+	r.replay("DeleteCount", func(mirror Result) error {
+		_, err := mirror.DeleteCount()
+		return err
+	})
+	return n, nil
+}
+
+func (r *dualWriteResult) UpdateReturning(values interface{}, dst interface{}) error {
+	if err := r.Result.UpdateReturning(values, dst); err != nil {
+		return err
+	}
+	r.replay("UpdateReturning", func(mirror Result) error {
+		return mirror.UpdateReturning(values, newItem(dst))
+	})
+	return nil
+}
+
+func (r *dualWriteResult) DeleteReturning(dst interface{}) error {
+	if err := r.Result.DeleteReturning(dst); err != nil {
+		return err
+	}
+	r.replay("DeleteReturning", func(mirror Result) error {
+		return mirror.DeleteReturning(newItem(dst))
+	})
+	return nil
+}
+
+func (r *dualWriteResult) Modify(mods ...*Modifier) error {
+	if err := r.Result.Modify(mods...); err != nil {
+		return err
+	}
+	r.replay("Modify", func(mirror Result) error {
+		return mirror.Modify(mods...)
+	})
+	return nil
+}
+
+func (r *dualWriteResult) Limit(n int) Result {
+	return r.then(r.Result.Limit(n), func(res Result) Result { return res.Limit(n) })
+}
+
+func (r *dualWriteResult) Offset(n int) Result {
+	return r.then(r.Result.Offset(n), func(res Result) Result { return res.Offset(n) })
+}
+
+func (r *dualWriteResult) ForUpdate(opts ...LockOption) Result {
+	return r.then(r.Result.ForUpdate(opts...), func(res Result) Result { return res.ForUpdate(opts...) })
+}
+
+func (r *dualWriteResult) ForShare(opts ...LockOption) Result {
+	return r.then(r.Result.ForShare(opts...), func(res Result) Result { return res.ForShare(opts...) })
+}
+
+func (r *dualWriteResult) ReadPreference(pref ReadPreference) Result {
+	return r.then(r.Result.ReadPreference(pref), func(res Result) Result { return res.ReadPreference(pref) })
+}
+
+func (r *dualWriteResult) OrderBy(fields ...interface{}) Result {
+	return r.then(r.Result.OrderBy(fields...), func(res Result) Result { return res.OrderBy(fields...) })
+}
+
+func (r *dualWriteResult) Select(fields ...interface{}) Result {
+	return r.then(r.Result.Select(fields...), func(res Result) Result { return res.Select(fields...) })
+}
+
+func (r *dualWriteResult) And(conds ...interface{}) Result {
+	return r.then(r.Result.And(conds...), func(res Result) Result { return res.And(conds...) })
+}
+
+func (r *dualWriteResult) GroupBy(fields ...interface{}) Result {
+	return r.then(r.Result.GroupBy(fields...), func(res Result) Result { return res.GroupBy(fields...) })
+}
+
+func (r *dualWriteResult) Having(conds ...interface{}) Result {
+	return r.then(r.Result.Having(conds...), func(res Result) Result { return res.Having(conds...) })
+}
+
+func (r *dualWriteResult) Join(table ...interface{}) Result {
+	return r.then(r.Result.Join(table...), func(res Result) Result { return res.Join(table...) })
+}
+
+func (r *dualWriteResult) InnerJoin(table ...interface{}) Result {
+	return r.then(r.Result.InnerJoin(table...), func(res Result) Result { return res.InnerJoin(table...) })
+}
+
+func (r *dualWriteResult) LeftJoin(table ...interface{}) Result {
+	return r.then(r.Result.LeftJoin(table...), func(res Result) Result { return res.LeftJoin(table...) })
+}
+
+func (r *dualWriteResult) RightJoin(table ...interface{}) Result {
+	return r.then(r.Result.RightJoin(table...), func(res Result) Result { return res.RightJoin(table...) })
+}
+
+func (r *dualWriteResult) FullJoin(table ...interface{}) Result {
+	return r.then(r.Result.FullJoin(table...), func(res Result) Result { return res.FullJoin(table...) })
+}
+
+func (r *dualWriteResult) On(conds ...interface{}) Result {
+	return r.then(r.Result.On(conds...), func(res Result) Result { return res.On(conds...) })
+}
+
+func (r *dualWriteResult) Using(columns ...interface{}) Result {
+	return r.then(r.Result.Using(columns...), func(res Result) Result { return res.Using(columns...) })
+}
+
+func (r *dualWriteResult) Paginate(pageSize uint) Result {
+	return r.then(r.Result.Paginate(pageSize), func(res Result) Result { return res.Paginate(pageSize) })
+}
+
+func (r *dualWriteResult) Page(pageNumber uint) Result {
+	return r.then(r.Result.Page(pageNumber), func(res Result) Result { return res.Page(pageNumber) })
+}
+
+func (r *dualWriteResult) Cursor(cursorColumn string) Result {
+	return r.then(r.Result.Cursor(cursorColumn), func(res Result) Result { return res.Cursor(cursorColumn) })
+}
+
+func (r *dualWriteResult) NextPage(cursorValue interface{}) Result {
+	return r.then(r.Result.NextPage(cursorValue), func(res Result) Result { return res.NextPage(cursorValue) })
+}
+
+func (r *dualWriteResult) PrevPage(cursorValue interface{}) Result {
+	return r.then(r.Result.PrevPage(cursorValue), func(res Result) Result { return res.PrevPage(cursorValue) })
+}
+
+var (
+	_ = Session(&DualWriteSession{})
+	_ = Collection(&dualWriteCollection{})
+	_ = Result(&dualWriteResult{})
+)
@@ -0,0 +1,755 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/upper/db/v4/internal/cache"
+)
+
+// EntityCache is the storage of an EntityCacheSession, it can be implemented
+// to keep entities in a different backend than the default in-memory cache.
+// Values are copies of the entities as they were read from the database and
+// must not be modified.
+type EntityCache interface {
+	// Get returns the value stored under key, if any.
+	Get(key string) (interface{}, bool)
+
+	// Set stores value under key.
+	Set(key string, value interface{})
+
+	// Delete removes the value stored under key, if any.
+	Delete(key string)
+}
+
+// memoryEntityCache is the default EntityCache, it keeps up to a given
+// number of entities in memory.
+type memoryEntityCache struct {
+	c *cache.Cache
+}
+
+// NewEntityCache returns an in-memory EntityCache that holds up to capacity
+// entities, the least recently stored ones are dropped first.
+func NewEntityCache(capacity int) (EntityCache, error) {
+	c, err := cache.NewCacheWithCapacity(capacity)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryEntityCache{c: c}, nil
+}
+
+func (m *memoryEntityCache) Get(key string) (interface{}, bool) {
+	return m.c.ReadRaw(cache.String(key))
+}
+
+func (m *memoryEntityCache) Set(key string, value interface{}) {
+	m.c.Write(cache.String(key), value)
+}
+
+func (m *memoryEntityCache) Delete(key string) {
+	m.c.Delete(cache.String(key))
+}
+
+// entityCacheState is shared by an EntityCacheSession and the sessions
+// derived from it.
+type entityCacheState struct {
+	backend EntityCache
+
+	mu           sync.RWMutex
+	epoch        uint64
+	generations  map[string]uint64
+	onInvalidate func(collection string, id interface{})
+	negativeTTL  time.Duration
+
+	// invalidations is increased on every invalidation, entities that were
+	// read while an invalidation happened are not stored.
+	invalidations uint64
+}
+
+func (st *entityCacheState) key(collection string, id interface{}) string {
+	st.mu.RLock()
+	epoch, gen := st.epoch, st.generations[collection]
+	st.mu.RUnlock()
+	return fmt.Sprintf("%d/%s/%d/%v", epoch, collection, gen, id)
+}
+
+func (st *entityCacheState) invalidate(collection string, id interface{}) {
+	atomic.AddUint64(&st.invalidations, 1)
+	if id == nil {
+		// Entries of previous generations are never read again.
+		st.mu.Lock()
+		st.generations[collection]++
+		st.mu.Unlock()
+		return
+	}
+	st.backend.Delete(st.key(collection, id))
+}
+
+// missingEntity is stored in place of an entity that does not exist.
+type missingEntity struct {
+	expires time.Time
+}
+
+// lookup returns the value stored under key. missing is true if the entity is
+// known not to exist.
+func (st *entityCacheState) lookup(key string) (v interface{}, missing bool, ok bool) {
+	v, ok = st.backend.Get(key)
+	if !ok {
+		return nil, false, false
+	}
+	if m, isMissing := v.(missingEntity); isMissing {
+		if time.Now().Before(m.expires) {
+			return nil, true, true
+		}
+		return nil, false, false
+	}
+	return v, false, true
+}
+
+// store stores v under key, unless an invalidation happened since the
+// invalidations counter was read.
+func (st *entityCacheState) store(key string, v interface{}, invalidations uint64) {
+	if atomic.LoadUint64(&st.invalidations) == invalidations {
+		st.backend.Set(key, v)
+	}
+}
+
+// storeMissing records that the entity stored under key does not exist, if
+// negative caching is enabled.
+func (st *entityCacheState) storeMissing(key string, invalidations uint64) {
+	st.mu.RLock()
+	ttl := st.negativeTTL
+	st.mu.RUnlock()
+
+	if ttl > 0 {
+		st.store(key, missingEntity{expires: time.Now().Add(ttl)}, invalidations)
+	}
+}
+
+// pendingInvalidation is an invalidation that is applied after a transaction
+// is committed.
+type pendingInvalidation struct {
+	collection string
+	id         interface{}
+}
+
+// EntityCacheSession is a Session that caches the entities it reads by their
+// primary key. Lookups of a single primary key value into a struct, like
+// sess.Get(&record, id) or col.Find(id).One(&item), are served from the
+// cache after the first read. Lookups and existence checks that find no
+// entity can be cached as well, see SetNegativeTTL.
+//
+// Entities are invalidated when they're written through the session: by ID
+// when the write targets a single primary key value, and for the whole
+// collection otherwise. Statements sent through SQL() and writes made by
+// other processes are not seen by the session, Invalidate must be called for
+// them; OnInvalidate can be used to publish the invalidations of a session on
+// a bus other sessions listen to:
+//
+//	sess := db.WithEntityCache(conn, entities)
+//	sess.OnInvalidate(func(collection string, id interface{}) {
+//		bus.Publish(collection, id)
+//	})
+//	bus.Subscribe(func(collection string, id interface{}) {
+//		sess.Invalidate(collection, id)
+//	})
+//
+// Within transactions the cache is bypassed, and invalidations are applied
+// after the transaction is committed.
+type EntityCacheSession struct {
+	Session
+
+	state *entityCacheState
+
+	pendingMu *sync.Mutex
+	pending   *[]pendingInvalidation
+}
+
+// WithEntityCache returns a session that caches the entities read from sess
+// in backend, see EntityCacheSession.
+func WithEntityCache(sess Session, backend EntityCache) *EntityCacheSession {
+	return &EntityCacheSession{
+		Session: sess,
+		state: &entityCacheState{
+			backend:     backend,
+			generations: map[string]uint64{},
+		},
+	}
+}
+
+// OnInvalidate sets a function that is called when entities are invalidated
+// by a write sent through the session. id is nil when the whole collection
+// is invalidated.
+func (s *EntityCacheSession) OnInvalidate(fn func(collection string, id interface{})) {
+	s.state.mu.Lock()
+	s.state.onInvalidate = fn
+	s.state.mu.Unlock()
+}
+
+// SetNegativeTTL makes the session remember for the given duration that a
+// primary key lookup found no entity, so repeated lookups of missing keys
+// don't reach the database. Entities inserted through the session are
+// invalidated, so they're found right away; inserts made elsewhere are only
+// seen once the duration expires, unless Invalidate is called. Lookups that
+// find nothing are not cached by default.
+func (s *EntityCacheSession) SetNegativeTTL(ttl time.Duration) {
+	s.state.mu.Lock()
+	s.state.negativeTTL = ttl
+	s.state.mu.Unlock()
+}
+
+// Invalidate removes the entity with the given primary key value from the
+// cache, or all the entities of the collection if id is nil. The function
+// set with OnInvalidate is not called.
+func (s *EntityCacheSession) Invalidate(collection string, id interface{}) {
+	s.state.invalidate(collection, id)
+}
+
+// invalidated invalidates the entity with the given ID after a write. If s is
+// a transaction the invalidation is also queued, to be applied and published
+// once the transaction is committed.
+func (s *EntityCacheSession) invalidated(collection string, id interface{}) {
+	s.state.invalidate(collection, id)
+	if s.pending != nil {
+		s.pendingMu.Lock()
+		*s.pending = append(*s.pending, pendingInvalidation{collection: collection, id: id})
+		s.pendingMu.Unlock()
+		return
+	}
+
+	s.state.mu.RLock()
+	fn := s.state.onInvalidate
+	s.state.mu.RUnlock()
+	if fn != nil {
+		fn(collection, id)
+	}
+}
+
+// cacheKey returns the primary key value cond refers to, if it's a single
+// value that can be used as a cache key.
+func cacheKey(conds []interface{}) (interface{}, bool) {
+	if len(conds) != 1 || conds[0] == nil {
+		return nil, false
+	}
+	switch reflect.TypeOf(conds[0]).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.String:
+		return conds[0], true
+	}
+	return nil, false
+}
+
+// recordKey returns the primary key value of item if col has a single
+// primary key.
+func recordKey(col Collection, item interface{}) (interface{}, bool) {
+	pker, ok := col.(interface{ PrimaryKeys() []string })
+	if !ok {
+		return nil, false
+	}
+	pks := pker.PrimaryKeys()
+	if len(pks) != 1 {
+		return nil, false
+	}
+
+	itemV := reflect.Indirect(reflect.ValueOf(item))
+	switch itemV.Kind() {
+	case reflect.Struct:
+		for i := 0; i < itemV.NumField(); i++ {
+			tag := strings.Split(itemV.Type().Field(i).Tag.Get("db"), ",")[0]
+			if tag == pks[0] {
+				return cacheKey([]interface{}{itemV.Field(i).Interface()})
+			}
+		}
+	case reflect.Map:
+		if itemV.Type().Key().Kind() == reflect.String {
+			if v := itemV.MapIndex(reflect.ValueOf(pks[0])); v.IsValid() {
+				return cacheKey([]interface{}{v.Interface()})
+			}
+		}
+	}
+	return nil, false
+}
+
+func (s *EntityCacheSession) Collection(name string) Collection {
+	return &entityCacheCollection{Collection: s.Session.Collection(name), sess: s}
+}
+
+// WrapCollection returns a collection that reads and writes through col, with
+// its primary key lookups served from the cache of the session. col is
+// usually a collection of the session the cache was created for, possibly
+// wrapped.
+func (s *EntityCacheSession) WrapCollection(col Collection) Collection {
+	return &entityCacheCollection{Collection: col, sess: s}
+}
+
+func (s *EntityCacheSession) Collections() ([]Collection, error) {
+	collections, err := s.Session.Collections()
+	if err != nil {
+		return nil, err
+	}
+	for i := range collections {
+		collections[i] = s.Collection(collections[i].Name())
+	}
+	return collections, nil
+}
+
+func (s *EntityCacheSession) Get(record Record, id interface{}) error {
+	store := record.Store(s)
+	if getter, ok := store.(StoreGetter); ok {
+		return getter.Get(record, id)
+	}
+	return store.Find(id).One(record)
+}
+
+// Save saves the record and invalidates its cached entity.
+func (s *EntityCacheSession) Save(record Record) error {
+	if err := s.Session.Save(record); err != nil {
+		return err
+	}
+	s.invalidatedRecord(record)
+	return nil
+}
+
+// Delete deletes the record and invalidates its cached entity.
+func (s *EntityCacheSession) Delete(record Record) error {
+	if err := s.Session.Delete(record); err != nil {
+		return err
+	}
+	s.invalidatedRecord(record)
+	return nil
+}
+
+func (s *EntityCacheSession) invalidatedRecord(record Record) {
+	// Stores may wrap the collection, the primary keys are looked up on the
+	// collection itself.
+	name := record.Store(s.Session).Name()
+	id, ok := recordKey(s.Session.Collection(name), record)
+	if !ok {
+		id = nil
+	}
+	s.invalidated(name, id)
+}
+
+// Reset clears the cache along with the state of the session.
+func (s *EntityCacheSession) Reset() {
+	atomic.AddUint64(&s.state.invalidations, 1)
+	s.state.mu.Lock()
+	s.state.epoch++
+	s.state.mu.Unlock()
+
+	s.Session.Reset()
+}
+
+func (s *EntityCacheSession) Tx(fn func(sess Session) error) error {
+	return s.TxContext(s.Context(), fn, nil)
+}
+
+// TxContext runs fn within a transaction. Reads within the transaction are
+// not cached, and the entities written within it are invalidated again once
+// it's committed, as they may have been cached by other sessions in the
+// meantime. The function set with OnInvalidate is called after the commit.
+func (s *EntityCacheSession) TxContext(ctx context.Context, fn func(sess Session) error, opts *sql.TxOptions) error {
+	var pending []pendingInvalidation
+	err := s.Session.TxContext(ctx, func(tx Session) error {
+		pending = nil
+
+		return fn(&EntityCacheSession{
+			Session:   tx,
+			state:     s.state,
+			pendingMu: &sync.Mutex{},
+			pending:   &pending,
+		})
+	}, opts)
+	if err != nil {
+		return err
+	}
+
+	// In nested transactions the invalidations are queued again, to be
+	// applied when the outermost transaction is committed.
+	for _, p := range pending {
+		s.invalidated(p.collection, p.id)
+	}
+	return nil
+}
+
+func (s *EntityCacheSession) Conn(fn func(sess Session) error) error {
+	return s.ConnContext(s.Context(), fn)
+}
+
+func (s *EntityCacheSession) ConnContext(ctx context.Context, fn func(sess Session) error) error {
+	return s.Session.ConnContext(ctx, func(conn Session) error {
+		return fn(&EntityCacheSession{
+			Session:   conn,
+			state:     s.state,
+			pendingMu: s.pendingMu,
+			pending:   s.pending,
+		})
+	})
+}
+
+func (s *EntityCacheSession) WithContext(ctx context.Context) Session {
+	return &EntityCacheSession{
+		Session:   s.Session.WithContext(ctx),
+		state:     s.state,
+		pendingMu: s.pendingMu,
+		pending:   s.pending,
+	}
+}
+
+// entityCacheCollection serves primary key lookups from the cache and
+// invalidates the entities it writes.
+type entityCacheCollection struct {
+	Collection
+
+	sess *EntityCacheSession
+}
+
+func (c *entityCacheCollection) Session() Session {
+	return c.sess
+}
+
+func (c *entityCacheCollection) Find(conds ...interface{}) Result {
+	// The key is taken before conds is handed to the collection, which may
+	// rewrite them.
+	id, cacheable := cacheKey(conds)
+	return &entityCacheResult{
+		Result:    c.Collection.Find(conds...),
+		col:       c,
+		id:        id,
+		cacheable: cacheable,
+	}
+}
+
+// insertedID invalidates the entity with the given ID, which may be cached as
+// missing.
+func (c *entityCacheCollection) insertedID(id ID) {
+	if key, ok := cacheKey([]interface{}{id}); ok {
+		c.sess.invalidated(c.Name(), key)
+	}
+}
+
+func (c *entityCacheCollection) Insert(item interface{}) (*InsertResult, error) {
+	res, err := c.Collection.Insert(item)
+	if err != nil {
+		return nil, err
+	}
+	c.insertedID(res.ID())
+	return res, nil
+}
+
+func (c *entityCacheCollection) InsertOrIgnore(item interface{}) (*InsertResult, error) {
+	res, err := c.Collection.InsertOrIgnore(item)
+	if err != nil {
+		return nil, err
+	}
+	c.insertedID(res.ID())
+	return res, nil
+}
+
+func (c *entityCacheCollection) InsertMany(items interface{}, batchSize int) ([]*InsertResult, error) {
+	results, err := c.Collection.InsertMany(items, batchSize)
+	for _, res := range results {
+		c.insertedID(res.ID())
+	}
+	return results, err
+}
+
+func (c *entityCacheCollection) InsertReturning(item interface{}) error {
+	if err := c.Collection.InsertReturning(item); err != nil {
+		return err
+	}
+	if id, ok := recordKey(c.Collection, item); ok {
+		c.sess.invalidated(c.Name(), id)
+	}
+	return nil
+}
+
+func (c *entityCacheCollection) Upsert(item interface{}, conflictColumns ...string) (*InsertResult, error) {
+	res, err := c.Collection.Upsert(item, conflictColumns...)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := cacheKey([]interface{}{res.ID()})
+	if !ok {
+		id = nil
+	}
+	c.sess.invalidated(c.Name(), id)
+	return res, nil
+}
+
+func (c *entityCacheCollection) UpdateReturning(item interface{}) error {
+	if err := c.Collection.UpdateReturning(item); err != nil {
+		return err
+	}
+	id, ok := recordKey(c.Collection, item)
+	if !ok {
+		id = nil
+	}
+	c.sess.invalidated(c.Name(), id)
+	return nil
+}
+
+func (c *entityCacheCollection) Truncate() error {
+	if err := c.Collection.Truncate(); err != nil {
+		return err
+	}
+	c.sess.invalidated(c.Name(), nil)
+	return nil
+}
+
+// entityCacheResult is a result set that is served from the cache when it
+// refers to a single primary key value.
+type entityCacheResult struct {
+	Result
+
+	col       *entityCacheCollection
+	id        interface{}
+	cacheable bool
+}
+
+// then returns a result set that reads from res, which can't be served from
+// the cache.
+func (r *entityCacheResult) then(res Result) Result {
+	return &entityCacheResult{Result: res, col: r.col}
+}
+
+func (r *entityCacheResult) One(dst interface{}) error {
+	dstV := reflect.ValueOf(dst)
+	if !r.cacheable || r.col.sess.pending != nil || dstV.Kind() != reflect.Ptr || dstV.IsNil() || dstV.Elem().Kind() != reflect.Struct {
+		return r.Result.One(dst)
+	}
+
+	st := r.col.sess.state
+	key := st.key(r.col.Name(), r.id)
+	if v, missing, ok := st.lookup(key); ok {
+		if missing {
+			return ErrNoMoreRows
+		}
+		if cached := reflect.ValueOf(v); cached.Type() == dstV.Elem().Type() {
+			dstV.Elem().Set(cached)
+			return nil
+		}
+	}
+
+	invalidations := atomic.LoadUint64(&st.invalidations)
+	if err := r.Result.One(dst); err != nil {
+		if errors.Is(err, ErrNoMoreRows) {
+			st.storeMissing(key, invalidations)
+		}
+		return err
+	}
+	st.store(key, dstV.Elem().Interface(), invalidations)
+	return nil
+}
+
+func (r *entityCacheResult) Exists() (bool, error) {
+	if !r.cacheable || r.col.sess.pending != nil {
+		return r.Result.Exists()
+	}
+
+	st := r.col.sess.state
+	key := st.key(r.col.Name(), r.id)
+	if _, missing, ok := st.lookup(key); ok {
+		return !missing, nil
+	}
+
+	invalidations := atomic.LoadUint64(&st.invalidations)
+	exists, err := r.Result.Exists()
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		st.storeMissing(key, invalidations)
+	}
+	return exists, nil
+}
+
+// invalidated invalidates the entities written through the result set.
+func (r *entityCacheResult) invalidated() {
+	if r.cacheable {
+		r.col.sess.invalidated(r.col.Name(), r.id)
+		return
+	}
+	r.col.sess.invalidated(r.col.Name(), nil)
+}
+
+func (r *entityCacheResult) Update(values interface{}) error {
+	if err := r.Result.Update(values); err != nil {
+		return err
+	}
+	r.invalidated()
+	return nil
+}
+
+func (r *entityCacheResult) Delete() error {
+	if err := r.Result.Delete(); err != nil {
+		return err
+	}
+	r.invalidated()
+	return nil
+}
+
+func (r *entityCacheResult) UpdateCount(values interface{}) (uint64, error) {
+	n, err := r.Result.UpdateCount(values)
+	if err != nil {
+		return 0, err
+	}
+	r.invalidated()
+	return n, nil
+}
+
+func (r *entityCacheResult) DeleteCount() (uint64, error) {
+	n, err := r.Result.DeleteCount()
+	if err != nil {
+		return 0, err
+	}
+	r.invalidated()
+	return n, nil
+}
+
+func (r *entityCacheResult) DeleteReturning(dst interface{}) error {
+	if err := r.Result.DeleteReturning(dst); err != nil {
+		return err
+	}
+	r.invalidated()
+	return nil
+}
+
+func (r *entityCacheResult) UpdateReturning(values interface{}, dst interface{}) error {
+	if err := r.Result.UpdateReturning(values, dst); err != nil {
+		return err
+	}
+	r.invalidated()
+	return nil
+}
+
+func (r *entityCacheResult) Modify(mods ...*Modifier) error {
+	if err := r.Result.Modify(mods...); err != nil {
+		return err
+	}
+	r.invalidated()
+	return nil
+}
+
+func (r *entityCacheResult) Limit(n int) Result {
+	return r.then(r.Result.Limit(n))
+}
+
+func (r *entityCacheResult) Offset(n int) Result {
+	return r.then(r.Result.Offset(n))
+}
+
+func (r *entityCacheResult) ForUpdate(opts ...LockOption) Result {
+	return r.then(r.Result.ForUpdate(opts...))
+}
+
+func (r *entityCacheResult) ForShare(opts ...LockOption) Result {
+	return r.then(r.Result.ForShare(opts...))
+}
+
+func (r *entityCacheResult) ReadPreference(pref ReadPreference) Result {
+	return r.then(r.Result.ReadPreference(pref))
+}
+
+func (r *entityCacheResult) OrderBy(fields ...interface{}) Result {
+	return r.then(r.Result.OrderBy(fields...))
+}
+
+func (r *entityCacheResult) Select(fields ...interface{}) Result {
+	return r.then(r.Result.Select(fields...))
+}
+
+func (r *entityCacheResult) And(conds ...interface{}) Result {
+	return r.then(r.Result.And(conds...))
+}
+
+func (r *entityCacheResult) GroupBy(fields ...interface{}) Result {
+	return r.then(r.Result.GroupBy(fields...))
+}
+
+func (r *entityCacheResult) Having(conds ...interface{}) Result {
+	return r.then(r.Result.Having(conds...))
+}
+
+func (r *entityCacheResult) Join(table ...interface{}) Result {
+	return r.then(r.Result.Join(table...))
+}
+
+func (r *entityCacheResult) InnerJoin(table ...interface{}) Result {
+	return r.then(r.Result.InnerJoin(table...))
+}
+
+func (r *entityCacheResult) LeftJoin(table ...interface{}) Result {
+	return r.then(r.Result.LeftJoin(table...))
+}
+
+func (r *entityCacheResult) RightJoin(table ...interface{}) Result {
+	return r.then(r.Result.RightJoin(table...))
+}
+
+func (r *entityCacheResult) FullJoin(table ...interface{}) Result {
+	return r.then(r.Result.FullJoin(table...))
+}
+
+func (r *entityCacheResult) On(conds ...interface{}) Result {
+	return r.then(r.Result.On(conds...))
+}
+
+func (r *entityCacheResult) Using(columns ...interface{}) Result {
+	return r.then(r.Result.Using(columns...))
+}
+
+func (r *entityCacheResult) Paginate(pageSize uint) Result {
+	return r.then(r.Result.Paginate(pageSize))
+}
+
+func (r *entityCacheResult) Page(pageNumber uint) Result {
+	return r.then(r.Result.Page(pageNumber))
+}
+
+func (r *entityCacheResult) Cursor(cursorColumn string) Result {
+	return r.then(r.Result.Cursor(cursorColumn))
+}
+
+func (r *entityCacheResult) NextPage(cursorValue interface{}) Result {
+	return r.then(r.Result.NextPage(cursorValue))
+}
+
+func (r *entityCacheResult) PrevPage(cursorValue interface{}) Result {
+	return r.then(r.Result.PrevPage(cursorValue))
+}
+
+var (
+	_ = Session(&EntityCacheSession{})
+	_ = Collection(&entityCacheCollection{})
+	_ = Result(&entityCacheResult{})
+)
@@ -23,6 +23,7 @@ package db
 
 import (
 	"errors"
+	"fmt"
 )
 
 // Error messages
@@ -60,4 +61,158 @@ var (
 	ErrTransactionAborted       = errors.New(`upper: transaction was aborted`)
 	ErrNotWithinTransaction     = errors.New(`upper: not within transaction`)
 	ErrNotSupportedByAdapter    = errors.New(`upper: not supported by adapter`)
+	ErrInvalidDocument          = errors.New(`upper: document failed validation`)
+	ErrInvalidConnectionURL     = errors.New(`upper: invalid connection settings`)
+	ErrInvalidCondition         = errors.New(`upper: invalid condition`)
+	ErrQuerySkipped             = errors.New(`upper: statement was not executed by middleware`)
+	ErrBudgetExceeded           = errors.New(`upper: query budget exceeded`)
+	ErrMissingProfile           = errors.New(`upper: missing connection profile`)
 )
+
+// Constraint violations, adapters translate driver errors into these so they
+// can be checked with errors.Is.
+var (
+	ErrDuplicateKey        = errors.New(`upper: duplicate key value violates a unique constraint`)
+	ErrForeignKeyViolation = errors.New(`upper: foreign key constraint violation`)
+	ErrNotNullViolation    = errors.New(`upper: not null constraint violation`)
+	ErrCheckViolation      = errors.New(`upper: check constraint violation`)
+)
+
+// ConstraintError wraps a driver error that was caused by a constraint
+// violation. It matches its Kind with errors.Is and the original driver error
+// with errors.As:
+//
+//	if errors.Is(err, db.ErrDuplicateKey) {
+//	  ...
+//	}
+type ConstraintError struct {
+	// Kind is one of ErrDuplicateKey, ErrForeignKeyViolation,
+	// ErrNotNullViolation or ErrCheckViolation.
+	Kind error
+
+	// Err is the original error returned by the driver.
+	Err error
+}
+
+// NewConstraintError wraps the given driver error.
+func NewConstraintError(kind error, err error) *ConstraintError {
+	return &ConstraintError{Kind: kind, Err: err}
+}
+
+func (e *ConstraintError) Error() string {
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+// Is reports whether target is the kind of the violation.
+func (e *ConstraintError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap returns the original driver error.
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// Contention errors, adapters translate driver errors caused by concurrent
+// sessions competing for the same rows or tables into these so they can be
+// checked with errors.Is.
+var (
+	ErrLockTimeout  = errors.New(`upper: timed out waiting for a lock`)
+	ErrDeadlock     = errors.New(`upper: deadlock detected`)
+	ErrDatabaseBusy = errors.New(`upper: database is busy`)
+)
+
+// ContentionError wraps a driver error that was caused by contention with
+// other sessions. It matches its Kind with errors.Is and the original driver
+// error with errors.As:
+//
+//	if errors.Is(err, db.ErrDeadlock) {
+//	  ...
+//	}
+type ContentionError struct {
+	// Kind is one of ErrLockTimeout, ErrDeadlock or ErrDatabaseBusy.
+	Kind error
+
+	// Err is the original error returned by the driver.
+	Err error
+}
+
+// NewContentionError wraps the given driver error.
+func NewContentionError(kind error, err error) *ContentionError {
+	return &ContentionError{Kind: kind, Err: err}
+}
+
+func (e *ContentionError) Error() string {
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+// Is reports whether target is the kind of the contention.
+func (e *ContentionError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap returns the original driver error.
+func (e *ContentionError) Unwrap() error {
+	return e.Err
+}
+
+// QueryError wraps an error returned while executing a statement with the ID
+// of the statement. The same ID is printed in the query log, and passed to
+// middleware, so a failed statement can be traced back to its log entry.
+type QueryError struct {
+	QueryID uint64
+	Err     error
+}
+
+// NewQueryError wraps an error caused by the statement with the given ID.
+func NewQueryError(queryID uint64, err error) *QueryError {
+	return &QueryError{QueryID: queryID, Err: err}
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("upper: query %05d: %v", e.QueryID, e.Err)
+}
+
+// Unwrap returns the original error.
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// ConnectionURLError describes a missing or invalid field of a connection URL,
+// adapters return it from Open before trying to connect. It matches
+// ErrInvalidConnectionURL with errors.Is:
+//
+//	var urlErr *db.ConnectionURLError
+//	if errors.As(err, &urlErr) {
+//		log.Printf("check the %s setting", urlErr.Field)
+//	}
+type ConnectionURLError struct {
+	// Field is the name of the field, such as "Host" or
+	// "Options[max_open_conns]".
+	Field string
+
+	// Reason explains what is wrong with the field.
+	Reason string
+
+	// Err is the error that made the field invalid, if any.
+	Err error
+}
+
+// NewConnectionURLError returns an error about the given field.
+func NewConnectionURLError(field string, reason string) *ConnectionURLError {
+	return &ConnectionURLError{Field: field, Reason: reason}
+}
+
+func (e *ConnectionURLError) Error() string {
+	return fmt.Sprintf("%v: %s %s", ErrInvalidConnectionURL, e.Field, e.Reason)
+}
+
+// Is returns true if target is ErrInvalidConnectionURL.
+func (e *ConnectionURLError) Is(target error) bool {
+	return target == ErrInvalidConnectionURL
+}
+
+// Unwrap returns the error that made the field invalid.
+func (e *ConnectionURLError) Unwrap() error {
+	return e.Err
+}
@@ -33,3 +33,29 @@ func TestErrorWrap(t *testing.T) {
 	adapterFakeErr := fmt.Errorf("could not find item in %q: %w", "users", ErrCollectionDoesNotExist)
 	assert.True(t, errors.Is(adapterFakeErr, ErrCollectionDoesNotExist))
 }
+
+func TestConstraintError(t *testing.T) {
+	driverErr := errors.New("UNIQUE constraint failed: artist.id")
+
+	err := fmt.Errorf("insert failed: %w", NewConstraintError(ErrDuplicateKey, driverErr))
+	assert.True(t, errors.Is(err, ErrDuplicateKey))
+	assert.True(t, errors.Is(err, driverErr))
+	assert.False(t, errors.Is(err, ErrForeignKeyViolation))
+
+	var constraintErr *ConstraintError
+	assert.True(t, errors.As(err, &constraintErr))
+	assert.Equal(t, ErrDuplicateKey, constraintErr.Kind)
+}
+
+func TestContentionError(t *testing.T) {
+	driverErr := errors.New("database is locked")
+
+	err := fmt.Errorf("update failed: %w", NewContentionError(ErrDatabaseBusy, driverErr))
+	assert.True(t, errors.Is(err, ErrDatabaseBusy))
+	assert.True(t, errors.Is(err, driverErr))
+	assert.False(t, errors.Is(err, ErrDeadlock))
+
+	var contentionErr *ContentionError
+	assert.True(t, errors.As(err, &contentionErr))
+	assert.Equal(t, ErrDatabaseBusy, contentionErr.Kind)
+}
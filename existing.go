@@ -0,0 +1,69 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+// existingIDsChunkSize is the maximum number of IDs sent in a single query by
+// ExistingIDs, it keeps queries below the bind parameter limits of most
+// databases.
+const existingIDsChunkSize = 500
+
+// ExistingIDs looks up which of the given IDs exist in the collection with one
+// IN query per chunk of IDs, it works with any collection. The returned map
+// has one entry per ID, set to true if an item with that ID exists in
+// idColumn.
+func ExistingIDs(col Collection, idColumn string, ids []interface{}) (map[interface{}]bool, error) {
+	exists := make(map[interface{}]bool, len(ids))
+
+	// IDs read from the database may have a different type than the given ones
+	// (e.g.: int64 instead of int), so they are compared by value.
+	byKey := make(map[string][]interface{}, len(ids))
+	for _, id := range ids {
+		exists[id] = false
+		key := valueKey(id)
+		byKey[key] = append(byKey[key], id)
+	}
+
+	for i := 0; i < len(ids); i += existingIDsChunkSize {
+		end := i + existingIDsChunkSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		var items []map[string]interface{}
+		err := col.Find(Cond{idColumn: In(ids[i:end]...)}).Select(idColumn).All(&items)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			// Only idColumn was selected, but some databases name it
+			// differently in the result (e.g.: "id()" in QL).
+			for _, v := range item {
+				for _, id := range byKey[valueKey(v)] {
+					exists[id] = true
+				}
+			}
+		}
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"errors"
+)
+
+// Node is an item of a hierarchy, see Collection.FindDescendants.
+type Node struct {
+	// Item holds the columns of the item.
+	Item map[string]interface{}
+
+	// Depth is the distance to the item the hierarchy was looked up from.
+	Depth int
+
+	// Children are the nodes whose parent is this node.
+	Children []*Node
+}
+
+// Descendants looks up the descendants of the item with the given ID one
+// level at a time, it works with any collection. idColumn is the column that
+// identifies items and parentColumn the one that points to their parent.
+func Descendants(col Collection, idColumn string, rootID interface{}, parentColumn string) ([]*Node, error) {
+	var root map[string]interface{}
+	if err := col.Find(Cond{idColumn: rootID}).One(&root); err != nil {
+		return nil, err
+	}
+
+	nodes := []*Node{{Item: root}}
+	seen := map[string]bool{valueKey(root[idColumn]): true}
+
+	level := []interface{}{root[idColumn]}
+	for depth := 1; len(level) > 0; depth++ {
+		var items []map[string]interface{}
+		if err := col.Find(Cond{parentColumn: In(level...)}).All(&items); err != nil {
+			return nil, err
+		}
+
+		level = level[:0]
+		for _, item := range items {
+			key := valueKey(item[idColumn])
+			if seen[key] {
+				// Cycles are not followed.
+				continue
+			}
+			seen[key] = true
+
+			nodes = append(nodes, &Node{Item: item, Depth: depth})
+			level = append(level, item[idColumn])
+		}
+	}
+
+	LinkNodes(nodes, idColumn, parentColumn)
+	return nodes, nil
+}
+
+// Ancestors looks up the ancestors of the item with the given ID one level at
+// a time, see Descendants.
+func Ancestors(col Collection, idColumn string, id interface{}, parentColumn string) ([]*Node, error) {
+	var item map[string]interface{}
+	if err := col.Find(Cond{idColumn: id}).One(&item); err != nil {
+		return nil, err
+	}
+
+	nodes := []*Node{{Item: item}}
+	seen := map[string]bool{valueKey(item[idColumn]): true}
+
+	for depth := 1; ; depth++ {
+		parentID := item[parentColumn]
+		if parentID == nil || seen[valueKey(parentID)] {
+			break
+		}
+		seen[valueKey(parentID)] = true
+
+		item = nil
+		if err := col.Find(Cond{idColumn: parentID}).One(&item); err != nil {
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			return nil, err
+		}
+
+		nodes = append(nodes, &Node{Item: item, Depth: depth})
+	}
+
+	LinkNodes(nodes, idColumn, parentColumn)
+	return nodes, nil
+}
+
+// LinkNodes sets the children of each node, a node is a child of the node
+// whose ID is the value of its parentColumn.
+func LinkNodes(nodes []*Node, idColumn string, parentColumn string) {
+	byID := make(map[string]*Node, len(nodes))
+	for _, node := range nodes {
+		node.Children = nil
+		byID[valueKey(node.Item[idColumn])] = node
+	}
+	for _, node := range nodes {
+		if node.Item[parentColumn] == nil {
+			continue
+		}
+		if parent, ok := byID[valueKey(node.Item[parentColumn])]; ok && parent != node {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+}
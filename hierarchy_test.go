@@ -0,0 +1,45 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkNodes(t *testing.T) {
+	nodes := []*Node{
+		{Item: map[string]interface{}{"id": int64(1), "parent_id": nil}},
+		{Item: map[string]interface{}{"id": int64(2), "parent_id": int64(1)}, Depth: 1},
+		{Item: map[string]interface{}{"id": int64(3), "parent_id": []byte("1")}, Depth: 1},
+		{Item: map[string]interface{}{"id": int64(4), "parent_id": int64(2)}, Depth: 2},
+		{Item: map[string]interface{}{"id": int64(5), "parent_id": int64(5)}, Depth: 1},
+	}
+
+	LinkNodes(nodes, "id", "parent_id")
+
+	assert.Equal(t, []*Node{nodes[1], nodes[2]}, nodes[0].Children)
+	assert.Equal(t, []*Node{nodes[3]}, nodes[1].Children)
+	assert.Nil(t, nodes[2].Children)
+	assert.Nil(t, nodes[4].Children)
+}
@@ -31,6 +31,9 @@ const (
 
 	ComparisonOperatorRegExp
 	ComparisonOperatorNotRegExp
+
+	ComparisonOperatorILike
+	ComparisonOperatorNotILike
 )
 
 type Comparison struct {
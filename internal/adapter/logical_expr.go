@@ -46,6 +46,7 @@ const (
 	LogicalOperatorNone LogicalOperator = iota
 	LogicalOperatorAnd
 	LogicalOperatorOr
+	LogicalOperatorNot
 )
 
 const DefaultLogicalOperator = LogicalOperatorAnd
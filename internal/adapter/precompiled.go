@@ -0,0 +1,85 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package adapter
+
+import (
+	"sync"
+)
+
+// PrecompiledExpr wraps a logical expression so adapters can compile it only
+// once and reuse the result on every query the expression is part of.
+//
+// The wrapped expression must not be modified after being wrapped.
+type PrecompiledExpr struct {
+	expr LogicalExpr
+
+	mu       sync.RWMutex
+	compiled map[interface{}]interface{}
+}
+
+// NewPrecompiledExpr wraps the given expression.
+func NewPrecompiledExpr(expr LogicalExpr) *PrecompiledExpr {
+	return &PrecompiledExpr{
+		expr:     expr,
+		compiled: make(map[interface{}]interface{}),
+	}
+}
+
+// Expr returns the wrapped expression.
+func (p *PrecompiledExpr) Expr() LogicalExpr {
+	return p.expr
+}
+
+// Expressions returns the expressions of the wrapped expression.
+func (p *PrecompiledExpr) Expressions() []LogicalExpr {
+	return p.expr.Expressions()
+}
+
+// Operator returns the operator of the wrapped expression.
+func (p *PrecompiledExpr) Operator() LogicalOperator {
+	return p.expr.Operator()
+}
+
+// Empty returns true if the wrapped expression is empty.
+func (p *PrecompiledExpr) Empty() bool {
+	return p.expr.Empty()
+}
+
+// Compiled returns the value that was previously stored under the given key
+// (usually an adapter template) with SetCompiled.
+func (p *PrecompiledExpr) Compiled(key interface{}) (interface{}, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	v, ok := p.compiled[key]
+	return v, ok
+}
+
+// SetCompiled stores the compiled form of the expression under the given key.
+func (p *PrecompiledExpr) SetCompiled(key interface{}, value interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.compiled[key] = value
+}
+
+var _ = LogicalExpr(&PrecompiledExpr{})
@@ -0,0 +1,100 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package adapter
+
+import (
+	"database/sql/driver"
+	"reflect"
+)
+
+// ValuesTableExpr represents a table made of a list of rows that are passed
+// along with the query.
+type ValuesTableExpr struct {
+	rows    interface{}
+	name    string
+	columns []string
+}
+
+// NewValuesTableExpr creates a ValuesTableExpr from the given rows.
+func NewValuesTableExpr(rows interface{}) *ValuesTableExpr {
+	return &ValuesTableExpr{rows: rows}
+}
+
+// As returns a copy of the table with the given name and column names.
+func (v *ValuesTableExpr) As(name string, columns ...string) *ValuesTableExpr {
+	return &ValuesTableExpr{rows: v.rows, name: name, columns: columns}
+}
+
+// Name returns the name of the table.
+func (v *ValuesTableExpr) Name() string {
+	return v.name
+}
+
+// Columns returns the column names of the table.
+func (v *ValuesTableExpr) Columns() []string {
+	return v.columns
+}
+
+// Rows returns the rows of the table, each one of them as a list of values.
+func (v *ValuesTableExpr) Rows() [][]interface{} {
+	if rows, ok := v.rows.([][]interface{}); ok {
+		return rows
+	}
+
+	rv := reflect.ValueOf(v.rows)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil
+	}
+
+	rows := make([][]interface{}, rv.Len())
+	for i := range rows {
+		rows[i] = valuesTableRow(rv.Index(i))
+	}
+	return rows
+}
+
+// valuesTableRow returns the values of a row, rows that are not slices have a
+// single value.
+func valuesTableRow(rv reflect.Value) []interface{} {
+	if rv.Kind() == reflect.Interface && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	item := rv.Interface()
+	if _, ok := item.(driver.Valuer); ok {
+		return []interface{}{item}
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return []interface{}{item}
+		}
+		row := make([]interface{}, rv.Len())
+		for i := range row {
+			row[i] = rv.Index(i).Interface()
+		}
+		return row
+	}
+
+	return []interface{}{item}
+}
@@ -99,8 +99,14 @@ func (c *Cache) Write(h Hashable, value interface{}) {
 	defer c.mu.Unlock()
 
 	if el, ok := c.cache[key]; ok {
+		prev := el.Value.(*item).value
 		el.Value.(*item).value = value
 		c.li.MoveToFront(el)
+		// Concurrent writers may race to store the same key, the value that
+		// loses must be purged as if it had been evicted.
+		if p, ok := prev.(HasOnPurge); ok && p != value {
+			p.OnPurge()
+		}
 		return
 	}
 
@@ -115,6 +121,24 @@ func (c *Cache) Write(h Hashable, value interface{}) {
 	}
 }
 
+// Delete removes a value from memory, if it exists.
+func (c *Cache) Delete(h Hashable) {
+	key := h.Hash()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.cache[key]
+	if !ok {
+		return
+	}
+	c.li.Remove(el)
+	delete(c.cache, key)
+	if p, ok := el.Value.(*item).value.(HasOnPurge); ok {
+		p.OnPurge()
+	}
+}
+
 // Clear generates a new memory space, leaving the old memory unreferenced, so
 // it can be claimed by the garbage collector.
 func (c *Cache) Clear() {
@@ -71,6 +71,41 @@ func TestCacheReadExistentValue(t *testing.T) {
 	}
 }
 
+func TestCacheDeleteValue(t *testing.T) {
+	c := NewCache()
+	c.Write(String("foo"), "bar")
+	c.Delete(String("foo"))
+	c.Delete(String("baz"))
+
+	if _, ok := c.Read(String("foo")); ok {
+		t.Fatal("Expecting false.")
+	}
+}
+
+type purgeableT struct {
+	purged bool
+}
+
+func (p *purgeableT) OnPurge() {
+	p.purged = true
+}
+
+func TestCacheOverwritePurgesValue(t *testing.T) {
+	c := NewCache()
+
+	a, b := &purgeableT{}, &purgeableT{}
+	c.Write(String("foo"), a)
+	c.Write(String("foo"), a)
+	if a.purged {
+		t.Fatal("Expecting the same value not to be purged.")
+	}
+
+	c.Write(String("foo"), b)
+	if !a.purged || b.purged {
+		t.Fatal("Expecting the replaced value to be purged.")
+	}
+}
+
 func BenchmarkNewCache(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		NewCache()
@@ -0,0 +1,238 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package queryctx carries what sessions tell middleware about the statement
+// being executed through its context.
+package queryctx
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type infoKey struct{}
+
+type info struct {
+	queryID uint64
+
+	mu           sync.Mutex
+	rowsAffected *int64
+	err          error
+}
+
+// WithQueryID returns a copy of ctx that carries the given query ID.
+func WithQueryID(ctx context.Context, queryID uint64) context.Context {
+	return context.WithValue(ctx, infoKey{}, &info{queryID: queryID})
+}
+
+// QueryID returns the query ID carried by ctx.
+func QueryID(ctx context.Context) (uint64, bool) {
+	in, ok := ctx.Value(infoKey{}).(*info)
+	if !ok {
+		return 0, false
+	}
+	return in.queryID, true
+}
+
+// SetRowsAffected records the number of rows affected by the statement.
+func SetRowsAffected(ctx context.Context, rowsAffected int64) {
+	if in, ok := ctx.Value(infoKey{}).(*info); ok {
+		in.mu.Lock()
+		in.rowsAffected = &rowsAffected
+		in.mu.Unlock()
+	}
+}
+
+// RowsAffected returns the number of rows recorded with SetRowsAffected.
+func RowsAffected(ctx context.Context) (int64, bool) {
+	in, ok := ctx.Value(infoKey{}).(*info)
+	if !ok {
+		return 0, false
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.rowsAffected == nil {
+		return 0, false
+	}
+	return *in.rowsAffected, true
+}
+
+// SetError records the error of the statement as translated by the adapter.
+func SetError(ctx context.Context, err error) {
+	if in, ok := ctx.Value(infoKey{}).(*info); ok {
+		in.mu.Lock()
+		in.err = err
+		in.mu.Unlock()
+	}
+}
+
+// Error returns the error recorded with SetError.
+func Error(ctx context.Context) error {
+	in, ok := ctx.Value(infoKey{}).(*info)
+	if !ok {
+		return nil
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.err
+}
+
+type budgetKey struct{}
+
+type budget struct {
+	maxQueries  int
+	maxDuration time.Duration
+
+	mu       sync.Mutex
+	queries  int
+	duration time.Duration
+}
+
+// WithBudget returns a copy of ctx that carries a budget of statements and
+// database time. Zero values mean no limit.
+func WithBudget(ctx context.Context, maxQueries int, maxDuration time.Duration) context.Context {
+	return context.WithValue(ctx, budgetKey{}, &budget{maxQueries: maxQueries, maxDuration: maxDuration})
+}
+
+// SpendQuery counts a statement against the budget carried by ctx. It returns
+// false, without counting the statement, if the budget is already spent.
+func SpendQuery(ctx context.Context) bool {
+	b, ok := ctx.Value(budgetKey{}).(*budget)
+	if !ok {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.maxQueries > 0 && b.queries >= b.maxQueries {
+		return false
+	}
+	if b.maxDuration > 0 && b.duration >= b.maxDuration {
+		return false
+	}
+	b.queries++
+	return true
+}
+
+// SpendDuration adds the time a statement took to the budget carried by ctx.
+func SpendDuration(ctx context.Context, d time.Duration) {
+	if b, ok := ctx.Value(budgetKey{}).(*budget); ok {
+		b.mu.Lock()
+		b.duration += d
+		b.mu.Unlock()
+	}
+}
+
+// BudgetUsage returns the limits of the budget carried by ctx and what was
+// spent so far.
+func BudgetUsage(ctx context.Context) (maxQueries int, maxDuration time.Duration, queries int, duration time.Duration, ok bool) {
+	b, ok := ctx.Value(budgetKey{}).(*budget)
+	if !ok {
+		return 0, 0, 0, 0, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.maxQueries, b.maxDuration, b.queries, b.duration, true
+}
+
+type repeatsKey struct{}
+
+type repeats struct {
+	threshold int
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// WithRepeatThreshold returns a copy of ctx that counts how many times each
+// statement runs.
+func WithRepeatThreshold(ctx context.Context, threshold int) context.Context {
+	return context.WithValue(ctx, repeatsKey{}, &repeats{threshold: threshold, counts: map[string]int{}})
+}
+
+// CountRepeat counts a run of the statement with the given fingerprint. It
+// returns true only the first time the statement runs more times than the
+// threshold carried by ctx.
+func CountRepeat(ctx context.Context, fingerprint string) (int, bool) {
+	r, ok := ctx.Value(repeatsKey{}).(*repeats)
+	if !ok {
+		return 0, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counts[fingerprint]++
+	n := r.counts[fingerprint]
+	return n, n == r.threshold+1
+}
+
+type usageKey struct{}
+
+type usage struct {
+	mu           sync.Mutex
+	rowsRead     int64
+	bytesRead    int64
+	rowsWritten  int64
+	bytesWritten int64
+}
+
+// WithUsage returns a copy of ctx that accumulates the rows and bytes read
+// and written by the statements that use it.
+func WithUsage(ctx context.Context) context.Context {
+	return context.WithValue(ctx, usageKey{}, &usage{})
+}
+
+// TracksUsage returns true if ctx accumulates usage, so callers can skip
+// measuring values otherwise.
+func TracksUsage(ctx context.Context) bool {
+	_, ok := ctx.Value(usageKey{}).(*usage)
+	return ok
+}
+
+// AddRead adds rows and bytes read to the usage carried by ctx.
+func AddRead(ctx context.Context, rows int64, bytes int64) {
+	if u, ok := ctx.Value(usageKey{}).(*usage); ok {
+		u.mu.Lock()
+		u.rowsRead += rows
+		u.bytesRead += bytes
+		u.mu.Unlock()
+	}
+}
+
+// AddWritten adds rows and bytes written to the usage carried by ctx.
+func AddWritten(ctx context.Context, rows int64, bytes int64) {
+	if u, ok := ctx.Value(usageKey{}).(*usage); ok {
+		u.mu.Lock()
+		u.rowsWritten += rows
+		u.bytesWritten += bytes
+		u.mu.Unlock()
+	}
+}
+
+// Usage returns the usage accumulated so far by ctx.
+func Usage(ctx context.Context) (rowsRead, bytesRead, rowsWritten, bytesWritten int64, ok bool) {
+	u, ok := ctx.Value(usageKey{}).(*usage)
+	if !ok {
+		return 0, 0, 0, 0, false
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.rowsRead, u.bytesRead, u.rowsWritten, u.bytesWritten, true
+}
@@ -65,7 +65,7 @@ type Mapper struct {
 	tagName    string
 	tagMapFunc func(string) string
 	mapFunc    func(string) string
-	mutex      sync.Mutex
+	mutex      sync.RWMutex
 }
 
 // NewMapper returns a new mapper which optionally obeys the field tag given
@@ -103,13 +103,21 @@ func NewMapperFunc(tagName string, f func(string) string) *Mapper {
 // TypeMap returns a mapping of field strings to int slices representing
 // the traversal down the struct to reach the field.
 func (m *Mapper) TypeMap(t reflect.Type) *StructMap {
-	m.mutex.Lock()
+	m.mutex.RLock()
 	mapping, ok := m.cache[t]
+	m.mutex.RUnlock()
+	if ok {
+		return mapping
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	mapping, ok = m.cache[t]
 	if !ok {
 		mapping = getMapping(t, m.tagName, m.mapFunc, m.tagMapFunc)
 		m.cache[t] = mapping
 	}
-	m.mutex.Unlock()
 	return mapping
 }
 
@@ -1,8 +1,10 @@
 package sqladapter
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"sync/atomic"
 
 	db "github.com/upper/db/v4"
 	"github.com/upper/db/v4/internal/sqladapter/exql"
@@ -40,6 +42,18 @@ type Collection interface {
 	// collection's IDs.
 	Truncate() error
 
+	// InsertMany inserts all the items of the given slice into the collection
+	// using multi-row INSERT statements.
+	InsertMany(items interface{}, batchSize int) ([]*db.InsertResult, error)
+
+	// Upsert inserts a new item into the collection or updates the row it
+	// conflicts with.
+	Upsert(item interface{}, conflictColumns ...string) (*db.InsertResult, error)
+
+	// InsertOrIgnore inserts a new item unless it conflicts with an existing
+	// row.
+	InsertOrIgnore(item interface{}) (*db.InsertResult, error)
+
 	// InsertReturning inserts a new item into the collection and refreshes the
 	// item with actual data from the database. This is useful to get automatic
 	// values, such as timestamps, or IDs.
@@ -50,6 +64,43 @@ type Collection interface {
 	// values, such as timestamps, or IDs.
 	UpdateReturning(item interface{}) error
 
+	// CompileInsert returns the INSERT statement and the arguments that Insert
+	// would use for the given item.
+	CompileInsert(item interface{}) (string, []interface{}, error)
+
+	// FindDescendants returns the item with the given ID and all the items
+	// below it in the hierarchy defined by parentColumn.
+	FindDescendants(rootID interface{}, parentColumn string) ([]*db.Node, error)
+
+	// FindAncestors returns the item with the given ID and all the items above
+	// it in the hierarchy defined by parentColumn.
+	FindAncestors(id interface{}, parentColumn string) ([]*db.Node, error)
+
+	// ExistingIDs returns which of the given primary key values exist in the
+	// table.
+	ExistingIDs(ids []interface{}) (map[interface{}]bool, error)
+
+	// Stats returns the storage statistics of the table.
+	Stats() (*db.CollectionStats, error)
+
+	// Analyze refreshes the statistics of the table.
+	Analyze() error
+
+	// Vacuum reclaims the storage of the deleted rows of the table.
+	Vacuum() error
+
+	// Optimize rebuilds the table and its indexes.
+	Optimize() error
+
+	// Triggers returns the triggers of the table.
+	Triggers() ([]*db.Trigger, error)
+
+	// CreateTrigger creates a trigger on the table.
+	CreateTrigger(trigger *db.Trigger) error
+
+	// DropTrigger drops a trigger of the table.
+	DropTrigger(name string) error
+
 	// PrimaryKeys returns the names of all primary keys in the table.
 	PrimaryKeys() []string
 
@@ -57,14 +108,87 @@ type Collection interface {
 	SQL() db.SQL
 }
 
+type batchInserter interface {
+	// InsertBatch adds all the given rows, with a single INSERT statement
+	// when possible, and returns one unique identifier per row (or nil if the
+	// identifier couldn't be determined). On error, the identifiers of the
+	// rows that were already added are returned.
+	InsertBatch(col Collection, columnNames []string, rows [][]interface{}) ([]interface{}, error)
+}
+
+type upserter interface {
+	// Upsert prepares and executes an INSERT statement that updates the
+	// conflicting row, if any, and returns a unique identifier of the inserted
+	// or updated element.
+	Upsert(col Collection, item interface{}, conflictColumns ...string) (interface{}, error)
+}
+
+type ignoringInserter interface {
+	// InsertOrIgnore prepares and executes an INSERT statement that skips
+	// conflicting rows, and returns a unique identifier of the inserted
+	// element or nil if it was skipped.
+	InsertOrIgnore(col Collection, item interface{}) (interface{}, error)
+}
+
+type returner interface {
+	// InsertReturning inserts item and scans the stored row, including the
+	// values computed by the database, into dst.
+	InsertReturning(col Collection, item interface{}, dst interface{}) error
+
+	// UpdateReturning updates the row matching conds with the values of item
+	// and scans the updated row into dst.
+	UpdateReturning(col Collection, item interface{}, conds db.Cond, dst interface{}) error
+}
+
+type analyzer interface {
+	// Analyze refreshes the statistics of the table.
+	Analyze(Collection) error
+}
+
+type vacuumer interface {
+	// Vacuum reclaims the storage of the deleted rows of the table.
+	Vacuum(Collection) error
+}
+
+type optimizer interface {
+	// Optimize rebuilds the table and its indexes.
+	Optimize(Collection) error
+}
+
+type statsReader interface {
+	// Stats returns the storage statistics the database keeps for the table.
+	Stats(Collection) (*db.CollectionStats, error)
+}
+
+type triggerManager interface {
+	// Triggers returns the triggers of the table, sorted by name.
+	Triggers(Collection) ([]*db.Trigger, error)
+
+	// CreateTrigger creates a trigger on the table.
+	CreateTrigger(Collection, *db.Trigger) error
+
+	// DropTrigger drops a trigger of the table.
+	DropTrigger(col Collection, name string) error
+}
+
 type finder interface {
 	Find(Collection, *Result, ...interface{}) db.Result
 }
 
+type documentModifier interface {
+	// ModifyColumn returns an expression that evaluates to the value of the
+	// given column after applying the modifiers, which all belong to it.
+	ModifyColumn(column string, mods []*db.Modifier) (*db.RawExpr, error)
+}
+
 type condsFilter interface {
 	FilterConds(...interface{}) []interface{}
 }
 
+type compilable interface {
+	Compile() (string, error)
+}
+
 // collection is the implementation of Collection.
 type collection struct {
 	name string
@@ -72,7 +196,12 @@ type collection struct {
 
 	adapter CollectionAdapter
 
-	err error
+	// err is shared by every goroutine that uses the cached collection.
+	err atomic.Value
+
+	// ttlColumn holds the expiration time of the rows, expired rows are
+	// skipped by every operation when it's set.
+	ttlColumn string
 }
 
 // NewCollection initializes a Collection by wrapping a CollectionAdapter.
@@ -107,21 +236,321 @@ func (c *collection) Count() (uint64, error) {
 func (c *collection) Insert(item interface{}) (*db.InsertResult, error) {
 	id, err := c.adapter.Insert(c, item)
 	if err != nil {
+		return nil, c.sess.Err(err)
+	}
+
+	return db.NewInsertResult(id), nil
+}
+
+func (c *collection) CompileInsert(item interface{}) (string, []interface{}, error) {
+	if err := c.lastErr(); err != nil {
+		return "", nil, err
+	}
+	q := c.SQL().InsertInto(c.Name()).Values(item)
+	return q.String(), q.Arguments(), nil
+}
+
+func (c *collection) InsertMany(items interface{}, batchSize int) ([]*db.InsertResult, error) {
+	itemsV := reflect.ValueOf(items)
+	if itemsV.Kind() != reflect.Slice && itemsV.Kind() != reflect.Array {
+		return nil, fmt.Errorf("Expecting a slice but got %T", items)
+	}
+
+	n := itemsV.Len()
+	results := make([]*db.InsertResult, 0, n)
+
+	b, ok := c.adapter.(batchInserter)
+	if !ok {
+		// The adapter can't insert many rows at once.
+		for i := 0; i < n; i++ {
+			res, err := c.Insert(itemsV.Index(i).Interface())
+			if err != nil {
+				return results, err
+			}
+			results = append(results, res)
+		}
+		return results, nil
+	}
+
+	if batchSize < 1 {
+		batchSize = n
+	}
+
+	var columnNames []string
+	rows := make([][]interface{}, 0, batchSize)
+
+	flush := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+		ids, err := b.InsertBatch(c, columnNames, rows)
+		for i := range ids {
+			results = append(results, db.NewInsertResult(ids[i]))
+		}
+		if err != nil {
+			return c.sess.Err(err)
+		}
+		rows = rows[:0]
+		return nil
+	}
+
+	for i := 0; i < n; i++ {
+		itemColumns, itemValues, err := sqlbuilder.Map(itemsV.Index(i).Interface(), nil)
+		if err != nil {
+			return results, err
+		}
+		// Rows of the same statement must have the same columns.
+		if len(rows) >= batchSize || !sameColumns(columnNames, itemColumns) {
+			if err := flush(); err != nil {
+				return results, err
+			}
+		}
+		columnNames = itemColumns
+		rows = append(rows, itemValues)
+	}
+
+	if err := flush(); err != nil {
+		return results, err
+	}
+
+	return results, nil
+}
+
+func sameColumns(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *collection) Stats() (*db.CollectionStats, error) {
+	if err := c.lastErr(); err != nil {
+		return nil, err
+	}
+	r, ok := c.adapter.(statsReader)
+	if !ok {
+		return nil, db.ErrUnsupported
+	}
+	stats, err := r.Stats(c)
+	if err != nil {
+		return nil, c.sess.Err(err)
+	}
+	return stats, nil
+}
+
+func (c *collection) Analyze() error {
+	a, ok := c.adapter.(analyzer)
+	if !ok {
+		return db.ErrUnsupported
+	}
+	return c.sess.Err(a.Analyze(c))
+}
+
+func (c *collection) Vacuum() error {
+	v, ok := c.adapter.(vacuumer)
+	if !ok {
+		return db.ErrUnsupported
+	}
+	return c.sess.Err(v.Vacuum(c))
+}
+
+func (c *collection) Optimize() error {
+	o, ok := c.adapter.(optimizer)
+	if !ok {
+		return db.ErrUnsupported
+	}
+	return c.sess.Err(o.Optimize(c))
+}
+
+func (c *collection) Triggers() ([]*db.Trigger, error) {
+	if err := c.lastErr(); err != nil {
 		return nil, err
 	}
+	m, ok := c.adapter.(triggerManager)
+	if !ok {
+		return nil, db.ErrUnsupported
+	}
+	triggers, err := m.Triggers(c)
+	if err != nil {
+		return nil, c.sess.Err(err)
+	}
+	return triggers, nil
+}
+
+func (c *collection) CreateTrigger(trigger *db.Trigger) error {
+	m, ok := c.adapter.(triggerManager)
+	if !ok {
+		return db.ErrUnsupported
+	}
+	if trigger == nil || trigger.Name == "" || trigger.Events == 0 {
+		return fmt.Errorf("upper: trigger needs a name and at least one event")
+	}
+	return c.sess.Err(m.CreateTrigger(c, trigger))
+}
+
+func (c *collection) DropTrigger(name string) error {
+	m, ok := c.adapter.(triggerManager)
+	if !ok {
+		return db.ErrUnsupported
+	}
+	return c.sess.Err(m.DropTrigger(c, name))
+}
+
+func (c *collection) Upsert(item interface{}, conflictColumns ...string) (*db.InsertResult, error) {
+	u, ok := c.adapter.(upserter)
+	if !ok {
+		return nil, db.ErrUnsupported
+	}
+
+	if len(conflictColumns) == 0 {
+		conflictColumns = c.PrimaryKeys()
+		if len(conflictColumns) == 0 {
+			return nil, db.ErrMissingPrimaryKeys
+		}
+	}
+
+	id, err := u.Upsert(c, item, conflictColumns...)
+	if err != nil {
+		return nil, c.sess.Err(err)
+	}
+
+	return db.NewInsertResult(id), nil
+}
+
+func (c *collection) InsertOrIgnore(item interface{}) (*db.InsertResult, error) {
+	i, ok := c.adapter.(ignoringInserter)
+	if !ok {
+		return nil, db.ErrUnsupported
+	}
+
+	id, err := i.InsertOrIgnore(c, item)
+	if err != nil {
+		return nil, c.sess.Err(err)
+	}
 
 	return db.NewInsertResult(id), nil
 }
 
+// WithTTL returns a copy of the collection whose operations skip expired rows
+// when item has a field with the ttl tag option, or the collection itself
+// otherwise.
+func (c *collection) WithTTL(item interface{}) db.Collection {
+	column, ok := sqlbuilder.TTLColumn(item)
+	if !ok {
+		return c
+	}
+	col := &collection{
+		sess:      c.sess,
+		name:      c.name,
+		adapter:   c.adapter,
+		ttlColumn: column,
+	}
+	if err := c.lastErr(); err != nil {
+		col.setErr(err)
+	}
+	return col
+}
+
+// currentTime returns the expression that evaluates to the current time of
+// the database server.
+func (c *collection) currentTime() *db.RawExpr {
+	if sess, ok := c.sess.(*session); ok {
+		if keyword := sess.adapter.Template().CurrentTimeKeyword; keyword != "" {
+			return db.Raw(keyword)
+		}
+	}
+	return db.Raw(db.CurrentTimestamp)
+}
+
+// PurgeExpired deletes the rows whose expiration time, stored in column, is
+// not later than the current time of the database server.
+func (c *collection) PurgeExpired(column string) error {
+	if err := c.lastErr(); err != nil {
+		return err
+	}
+	_, err := c.SQL().DeleteFrom(c.Name()).
+		Where(db.Cond{column: db.Lte(c.currentTime())}).
+		Exec()
+	return err
+}
+
 func (c *collection) PrimaryKeys() []string {
 	pk, err := c.sess.PrimaryKeys(c.Name())
 	if err == nil {
 		return pk
 	}
-	c.err = err
+	c.setErr(err)
 	return nil
 }
 
+func (c *collection) setErr(err error) {
+	if err == nil {
+		return
+	}
+	c.err.Store(err)
+}
+
+// lastErr returns the error that made the collection unusable, if any.
+func (c *collection) lastErr() error {
+	if errV := c.err.Load(); errV != nil {
+		return errV.(error)
+	}
+	return nil
+}
+
+// materialize creates a table with the given name from the rows returned by
+// query, the table is dropped first if it already exists. Both statements run
+// in the same transaction, so the old table is kept if the new one can't be
+// created on databases with transactional DDL.
+func (c *collection) materialize(name string, query db.Paginator) (db.Collection, error) {
+	sess, ok := c.sess.(*session)
+	if !ok || sess.adapter.Template().CreateTableAsLayout == "" {
+		return nil, fmt.Errorf("%w: materializing results", db.ErrUnsupported)
+	}
+
+	compiled, err := query.(compilable).Compile()
+	if err != nil {
+		return nil, err
+	}
+
+	replace := func(tx db.Session) error {
+		if err := tx.(Session).TableExists(name); err == nil {
+			_, err := tx.SQL().Exec(&exql.Statement{
+				Type:  exql.DropTable,
+				Table: exql.TableWithName(name),
+			})
+			if err != nil {
+				return err
+			}
+		} else if !errors.Is(err, db.ErrCollectionDoesNotExist) {
+			return err
+		}
+
+		_, err := tx.SQL().Exec(&exql.Statement{
+			Type:  exql.CreateTableAs,
+			Table: exql.TableWithName(name),
+			Query: exql.RawValue(compiled),
+		}, query.Arguments()...)
+		return err
+	}
+
+	if sess.IsTransaction() {
+		err = replace(sess)
+	} else {
+		err = sess.Tx(replace)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return sess.Collection(name), nil
+}
+
 func (c *collection) filterConds(conds ...interface{}) []interface{} {
 	pk := c.PrimaryKeys()
 	if len(conds) == 1 && len(pk) == 1 {
@@ -136,9 +565,9 @@ func (c *collection) filterConds(conds ...interface{}) []interface{} {
 }
 
 func (c *collection) Find(conds ...interface{}) db.Result {
-	if c.err != nil {
+	if err := c.lastErr(); err != nil {
 		res := &Result{}
-		res.setErr(c.err)
+		res.setErr(err)
 		return res
 	}
 
@@ -146,7 +575,16 @@ func (c *collection) Find(conds ...interface{}) db.Result {
 		c.sess.SQL(),
 		c.Name(),
 		c.filterConds(conds...),
-	)
+	).withPrimaryKeys(c.PrimaryKeys).withMaterializer(c.materialize)
+	res = res.withTTL(c.ttlColumn, c.currentTime())
+	if sess, ok := c.sess.(*session); ok {
+		res = res.withTemplate(sess.adapter.Template())
+	}
+	_, returning := c.adapter.(returner)
+	res = res.withSession(c.sess, returning)
+	if m, ok := c.adapter.(documentModifier); ok {
+		res = res.withDocumentModifier(m)
+	}
 	if f, ok := c.adapter.(finder); ok {
 		return f.Find(c, res, conds...)
 	}
@@ -160,11 +598,31 @@ func (c *collection) Exists() (bool, error) {
 	return true, nil
 }
 
+func (c *collection) ExistingIDs(ids []interface{}) (map[interface{}]bool, error) {
+	pk := c.PrimaryKeys()
+	if err := c.lastErr(); err != nil {
+		return nil, err
+	}
+	if len(pk) != 1 {
+		return nil, errors.New("ExistingIDs requires a table with a single primary key")
+	}
+	return db.ExistingIDs(c, pk[0], ids)
+}
+
 func (c *collection) InsertReturning(item interface{}) error {
 	if item == nil || reflect.TypeOf(item).Kind() != reflect.Ptr {
 		return fmt.Errorf("Expecting a pointer but got %T", item)
 	}
 
+	if r, ok := c.adapter.(returner); ok {
+		// The database can return the inserted row in the same statement.
+		newItem := reflect.New(reflect.ValueOf(item).Elem().Type()).Interface()
+		if err := r.InsertReturning(c, item, newItem); err != nil {
+			return c.sess.Err(err)
+		}
+		return setReturnedFields(item, newItem)
+	}
+
 	// Grab primary keys
 	pks := c.PrimaryKeys()
 	if len(pks) == 0 {
@@ -275,6 +733,25 @@ func (c *collection) UpdateReturning(item interface{}) error {
 		return fmt.Errorf(db.ErrMissingPrimaryKeys.Error(), c.Name())
 	}
 
+	// Allocate a clone of item.
+	defaultItem := reflect.New(reflect.ValueOf(item).Elem().Type()).Interface()
+	var defaultItemFieldMap map[string]reflect.Value
+
+	itemValue := reflect.ValueOf(item)
+
+	conds := db.Cond{}
+	for _, pk := range pks {
+		conds[pk] = db.Eq(sqlbuilder.Mapper.FieldByName(itemValue, pk).Interface())
+	}
+
+	if r, ok := c.adapter.(returner); ok {
+		// The database can return the updated row in the same statement.
+		if err := r.UpdateReturning(c, item, conds, defaultItem); err != nil {
+			return c.sess.Err(err)
+		}
+		return setReturnedFields(item, defaultItem)
+	}
+
 	var tx Session
 	isTransaction := c.sess.IsTransaction()
 
@@ -290,17 +767,6 @@ func (c *collection) UpdateReturning(item interface{}) error {
 		defer tx.Close()
 	}
 
-	// Allocate a clone of item.
-	defaultItem := reflect.New(reflect.ValueOf(item).Elem().Type()).Interface()
-	var defaultItemFieldMap map[string]reflect.Value
-
-	itemValue := reflect.ValueOf(item)
-
-	conds := db.Cond{}
-	for _, pk := range pks {
-		conds[pk] = db.Eq(sqlbuilder.Mapper.FieldByName(itemValue, pk).Interface())
-	}
-
 	col := tx.(Session).Collection(c.Name())
 
 	err := col.Find(conds).Update(item)
@@ -351,6 +817,32 @@ cancel:
 	return err
 }
 
+// setReturnedFields overwrites the fields of item with the ones of newItem,
+// which must be of the same type.
+func setReturnedFields(item interface{}, newItem interface{}) error {
+	itemValue := reflect.ValueOf(item)
+	newItemValue := reflect.ValueOf(newItem)
+
+	switch newItemValue.Elem().Kind() {
+	case reflect.Struct:
+		fieldMap := sqlbuilder.Mapper.ValidFieldMap(newItemValue)
+		for fieldName := range fieldMap {
+			sqlbuilder.Mapper.FieldByName(itemValue, fieldName).Set(fieldMap[fieldName])
+		}
+	case reflect.Map:
+		itemV, newItemV := itemValue.Elem(), newItemValue.Elem()
+		if itemV.IsNil() {
+			itemV.Set(reflect.MakeMap(itemV.Type()))
+		}
+		for _, keyV := range newItemV.MapKeys() {
+			itemV.SetMapIndex(keyV, newItemV.MapIndex(keyV))
+		}
+	default:
+		return fmt.Errorf("expecting a pointer to map or struct, got %T", item)
+	}
+	return nil
+}
+
 func (c *collection) Truncate() error {
 	stmt := exql.Statement{
 		Type:  exql.Truncate,
@@ -0,0 +1,62 @@
+package exql
+
+// Call represents a statement that calls a stored procedure.
+type Call struct {
+	Name      Fragment
+	Arguments []*CallArgument
+	hash      hash
+}
+
+// CallArgument represents an argument of a Call, Output marks the arguments
+// that are bound to OUT parameters.
+type CallArgument struct {
+	Value  Fragment
+	Output bool
+}
+
+var _ = Fragment(&Call{})
+
+type callT struct {
+	Name      string
+	Arguments []callArgumentT
+}
+
+type callArgumentT struct {
+	Value  string
+	Output bool
+}
+
+// Hash returns a unique identifier for the struct.
+func (c *Call) Hash() string {
+	return c.hash.Hash(c)
+}
+
+// Compile transforms the Call into its equivalent SQL representation.
+func (c *Call) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(c); ok {
+		return z, nil
+	}
+
+	name, err := c.Name.Compile(layout)
+	if err != nil {
+		return "", err
+	}
+
+	args := make([]callArgumentT, 0, len(c.Arguments))
+	for _, arg := range c.Arguments {
+		value, err := arg.Value.Compile(layout)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, callArgumentT{Value: value, Output: arg.Output})
+	}
+
+	compiled = trimString(layout.MustCompile(layout.CallLayout, callT{
+		Name:      name,
+		Arguments: args,
+	}))
+
+	layout.Write(c, compiled)
+
+	return
+}
@@ -0,0 +1,31 @@
+package exql
+
+import (
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	call := &Call{
+		Name: ColumnWithName("archive_orders"),
+	}
+
+	s := mustTrim(call.Compile(defaultTemplate))
+	e := `CALL "archive_orders"()`
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+
+	call = &Call{
+		Name: ColumnWithName("sales.archive_orders"),
+		Arguments: []*CallArgument{
+			{Value: &Raw{Value: "?"}},
+			{Value: &Raw{Value: "?"}, Output: true},
+		},
+	}
+
+	s = mustTrim(call.Compile(defaultTemplate))
+	e = `CALL "sales"."archive_orders"(?, ?)`
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+}
@@ -12,6 +12,7 @@ const (
 	defaultValueQuote          = `'{{.}}'`
 	defaultAndKeyword          = `AND`
 	defaultOrKeyword           = `OR`
+	defaultNotKeyword          = `NOT`
 	defaultDescKeyword         = `DESC`
 	defaultAscKeyword          = `ASC`
 	defaultAssignmentOperator  = `=`
@@ -63,6 +64,8 @@ const (
   `
 
 	defaultSelectLayout = `
+    {{.With | compile}}
+
     SELECT
       {{if .Distinct}}
         DISTINCT
@@ -84,6 +87,8 @@ const (
 
       {{.GroupBy | compile}}
 
+      {{.Having | compile}}
+
       {{.OrderBy | compile}}
 
       {{if .Limit}}
@@ -93,6 +98,10 @@ const (
       {{if .Offset}}
         OFFSET {{.Offset}}
       {{end}}
+
+      {{if defined .Lock}}
+        {{.Lock | compile}}
+      {{end}}
   `
 	defaultDeleteLayout = `
     DELETE
@@ -132,11 +141,32 @@ const (
       {{if .Columns }}({{.Columns | compile}}){{end}}
     VALUES
       {{.Values | compile}}
+    {{if defined .OnConflict}}
+      {{.OnConflict | compile}}
+    {{end}}
     {{if .Returning}}
       RETURNING {{.Returning | compile}}
     {{end}}
   `
 
+	defaultOnConflictLayout = `
+    ON CONFLICT {{if .Columns}}({{.Columns}}){{end}}
+    {{if .Update}}
+      DO UPDATE SET {{.Update}}
+    {{else}}
+      DO NOTHING
+    {{end}}
+  `
+
+	defaultOnConflictUpdateLayout = `{{.Column}} = EXCLUDED.{{.Column}}`
+
+	defaultLockLayout = `
+    FOR {{.Mode}}
+    {{if .Wait}}
+      {{.Wait}}
+    {{end}}
+  `
+
 	defaultTruncateLayout = `
     TRUNCATE TABLE {{.Table | compile}}
   `
@@ -149,44 +179,77 @@ const (
     DROP TABLE {{.Table | compile}}
   `
 
+	defaultCreateTableAsLayout = `
+    CREATE TABLE {{.Table | compile}} AS {{.Query | compile}}
+  `
+
 	defaultGroupByLayout = `
     {{if .GroupColumns}}
       GROUP BY {{.GroupColumns}}
     {{end}}
+  `
+
+	defaultHavingLayout = `
+    {{if .Conds}}
+      HAVING {{.Conds}}
+    {{end}}
+  `
+
+	defaultWithLayout = `
+    {{if .Tables}}
+      WITH {{if .Recursive}}RECURSIVE{{end}} {{.Tables}}
+    {{end}}
+  `
+
+	defaultValuesTableLayout = `
+    (VALUES {{range $i, $row := .Rows}}{{if $i}}, {{end}}{{$row}}{{end}}) AS {{.Name}} ({{.Columns}})
+  `
+
+	defaultCallLayout = `
+    CALL {{.Name}}({{range $i, $arg := .Arguments}}{{if $i}}, {{end}}{{$arg.Value}}{{end}})
   `
 )
 
 var defaultTemplate = &Template{
-	AndKeyword:          defaultAndKeyword,
-	AscKeyword:          defaultAscKeyword,
-	AssignmentOperator:  defaultAssignmentOperator,
-	ClauseGroup:         defaultClauseGroup,
-	ClauseOperator:      defaultClauseOperator,
-	ColumnAliasLayout:   defaultColumnAliasLayout,
-	ColumnSeparator:     defaultColumnSeparator,
-	ColumnValue:         defaultColumnValue,
-	CountLayout:         defaultCountLayout,
-	DeleteLayout:        defaultDeleteLayout,
-	DescKeyword:         defaultDescKeyword,
-	DropDatabaseLayout:  defaultDropDatabaseLayout,
-	DropTableLayout:     defaultDropTableLayout,
-	GroupByLayout:       defaultGroupByLayout,
-	IdentifierQuote:     defaultIdentifierQuote,
-	IdentifierSeparator: defaultIdentifierSeparator,
-	InsertLayout:        defaultInsertLayout,
-	JoinLayout:          defaultJoinLayout,
-	OnLayout:            defaultOnLayout,
-	OrKeyword:           defaultOrKeyword,
-	OrderByLayout:       defaultOrderByLayout,
-	SelectLayout:        defaultSelectLayout,
-	SortByColumnLayout:  defaultSortByColumnLayout,
-	TableAliasLayout:    defaultTableAliasLayout,
-	TruncateLayout:      defaultTruncateLayout,
-	UpdateLayout:        defaultUpdateLayout,
-	UsingLayout:         defaultUsingLayout,
-	ValueQuote:          defaultValueQuote,
-	ValueSeparator:      defaultValueSeparator,
-	WhereLayout:         defaultWhereLayout,
+	AndKeyword:             defaultAndKeyword,
+	AscKeyword:             defaultAscKeyword,
+	AssignmentOperator:     defaultAssignmentOperator,
+	CallLayout:             defaultCallLayout,
+	ClauseGroup:            defaultClauseGroup,
+	ClauseOperator:         defaultClauseOperator,
+	ColumnAliasLayout:      defaultColumnAliasLayout,
+	ColumnSeparator:        defaultColumnSeparator,
+	ColumnValue:            defaultColumnValue,
+	CountLayout:            defaultCountLayout,
+	CreateTableAsLayout:    defaultCreateTableAsLayout,
+	DeleteLayout:           defaultDeleteLayout,
+	DescKeyword:            defaultDescKeyword,
+	DropDatabaseLayout:     defaultDropDatabaseLayout,
+	DropTableLayout:        defaultDropTableLayout,
+	GroupByLayout:          defaultGroupByLayout,
+	HavingLayout:           defaultHavingLayout,
+	IdentifierQuote:        defaultIdentifierQuote,
+	IdentifierSeparator:    defaultIdentifierSeparator,
+	InsertLayout:           defaultInsertLayout,
+	JoinLayout:             defaultJoinLayout,
+	LockLayout:             defaultLockLayout,
+	NotKeyword:             defaultNotKeyword,
+	OnConflictLayout:       defaultOnConflictLayout,
+	OnConflictUpdateLayout: defaultOnConflictUpdateLayout,
+	OnLayout:               defaultOnLayout,
+	OrKeyword:              defaultOrKeyword,
+	OrderByLayout:          defaultOrderByLayout,
+	SelectLayout:           defaultSelectLayout,
+	SortByColumnLayout:     defaultSortByColumnLayout,
+	TableAliasLayout:       defaultTableAliasLayout,
+	TruncateLayout:         defaultTruncateLayout,
+	UpdateLayout:           defaultUpdateLayout,
+	UsingLayout:            defaultUsingLayout,
+	ValueQuote:             defaultValueQuote,
+	ValueSeparator:         defaultValueSeparator,
+	ValuesTableLayout:      defaultValuesTableLayout,
+	WhereLayout:            defaultWhereLayout,
+	WithLayout:             defaultWithLayout,
 
 	Cache: cache.NewCache(),
 }
@@ -0,0 +1,46 @@
+package exql
+
+// Having represents an SQL HAVING clause, its conditions are joined with AND
+// like the ones of a WHERE clause.
+type Having Where
+
+var _ = Fragment(&Having{})
+
+// HavingConditions creates and returns a new Having.
+func HavingConditions(conditions ...Fragment) *Having {
+	return &Having{Conditions: conditions}
+}
+
+// Hash returns a unique identifier for the struct.
+func (h *Having) Hash() string {
+	w := Where(*h)
+	return `Having(` + w.Hash() + `)`
+}
+
+// Append adds the conditions to the ones that already exist.
+func (h *Having) Append(a *Where) *Having {
+	if a != nil {
+		h.Conditions = append(h.Conditions, a.Conditions...)
+	}
+	return h
+}
+
+// Compile transforms the Having into an equivalent SQL representation.
+func (h *Having) Compile(layout *Template) (compiled string, err error) {
+	if c, ok := layout.Read(h); ok {
+		return c, nil
+	}
+
+	grouped, err := groupCondition(layout, h.Conditions, layout.AndKeyword)
+	if err != nil {
+		return "", err
+	}
+
+	if grouped != "" {
+		compiled = layout.MustCompile(layout.HavingLayout, conds{grouped})
+	}
+
+	layout.Write(h, compiled)
+
+	return
+}
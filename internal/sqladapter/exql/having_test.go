@@ -0,0 +1,23 @@
+package exql
+
+import (
+	"testing"
+)
+
+func TestHaving(t *testing.T) {
+	having := HavingConditions(
+		&ColumnValue{Column: &Raw{Value: "COUNT(1)"}, Operator: ">", Value: NewValue(&Raw{Value: "3"})},
+	)
+	having.Append(WhereConditions(&Raw{Value: "SUM(value) < 100"}))
+
+	s := mustTrim(having.Compile(defaultTemplate))
+	e := `HAVING (COUNT(1) > 3 AND SUM(value) < 100)`
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+
+	s = mustTrim(HavingConditions().Compile(defaultTemplate))
+	if s != "" {
+		t.Fatalf("Got: %s, Expecting an empty string", s)
+	}
+}
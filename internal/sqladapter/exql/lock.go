@@ -0,0 +1,44 @@
+package exql
+
+import (
+	"strings"
+)
+
+// Lock modes.
+const (
+	LockForUpdate = "UPDATE"
+	LockForShare  = "SHARE"
+)
+
+// Lock wait policies.
+const (
+	LockNoWait     = "NOWAIT"
+	LockSkipLocked = "SKIP LOCKED"
+)
+
+// Lock represents the locking clause of a SELECT statement, like FOR UPDATE.
+type Lock struct {
+	Mode string
+	Wait string
+	hash hash
+}
+
+var _ = Fragment(&Lock{})
+
+// Hash returns a unique identifier for the struct.
+func (l *Lock) Hash() string {
+	return l.hash.Hash(l)
+}
+
+// Compile transforms the Lock into an equivalent SQL representation.
+func (l *Lock) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(l); ok {
+		return z, nil
+	}
+
+	compiled = strings.TrimSpace(layout.MustCompile(layout.LockLayout, l))
+
+	layout.Write(l, compiled)
+
+	return
+}
@@ -0,0 +1,63 @@
+package exql
+
+import (
+	"strings"
+)
+
+// OnConflict represents the clause that tells an INSERT statement what to do
+// when the new row conflicts with an existing one.
+type OnConflict struct {
+	Columns *Columns
+	Update  *Columns
+	hash    hash
+}
+
+var _ = Fragment(&OnConflict{})
+
+type onConflictT struct {
+	Columns string
+	Update  string
+}
+
+type onConflictUpdateT struct {
+	Column string
+}
+
+// Hash returns a unique identifier for the struct.
+func (o *OnConflict) Hash() string {
+	return o.hash.Hash(o)
+}
+
+// Compile transforms the OnConflict into an equivalent SQL representation.
+func (o *OnConflict) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(o); ok {
+		return z, nil
+	}
+
+	var data onConflictT
+
+	if !o.Columns.IsEmpty() {
+		data.Columns, err = o.Columns.Compile(layout)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if !o.Update.IsEmpty() {
+		update := make([]string, len(o.Update.Columns))
+		for i := range o.Update.Columns {
+			column, err := o.Update.Columns[i].Compile(layout)
+			if err != nil {
+				return "", err
+			}
+			update[i] = strings.TrimSpace(layout.MustCompile(layout.OnConflictUpdateLayout, onConflictUpdateT{column}))
+		}
+		data.Update = strings.Join(update, layout.IdentifierSeparator)
+	}
+
+	compiled = strings.TrimSpace(layout.MustCompile(layout.OnConflictLayout, data))
+
+	layout.Write(o, compiled)
+
+	return
+}
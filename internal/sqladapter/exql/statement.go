@@ -8,7 +8,7 @@ import (
 
 var errUnknownTemplateType = errors.New("Unknown template type")
 
-//  represents different kinds of SQL statements.
+// represents different kinds of SQL statements.
 type Statement struct {
 	Type
 	Table        Fragment
@@ -19,9 +19,15 @@ type Statement struct {
 	ColumnValues Fragment
 	OrderBy      Fragment
 	GroupBy      Fragment
+	Having       Fragment
 	Joins        Fragment
 	Where        Fragment
+	OnConflict   Fragment
+	Ignore       bool
+	Lock         Fragment
 	Returning    Fragment
+	Query        Fragment
+	With         Fragment
 
 	Limit
 	Offset
@@ -63,6 +69,8 @@ func (s *Statement) template(layout *Template) (string, error) {
 		return layout.DropTableLayout, nil
 	case DropDatabase:
 		return layout.DropDatabaseLayout, nil
+	case CreateTableAs:
+		return layout.CreateTableAsLayout, nil
 	case Count:
 		return layout.CountLayout, nil
 	case Select:
@@ -68,6 +68,26 @@ func TestDropDatabase(t *testing.T) {
 	}
 }
 
+func TestCreateTableAs(t *testing.T) {
+	var s, e string
+
+	stmt := Statement{
+		Type:  CreateTableAs,
+		Table: TableWithName("table_copy"),
+		Query: &Statement{
+			Type:  Select,
+			Table: TableWithName("table_name"),
+		},
+	}
+
+	s = mustTrim(stmt.Compile(defaultTemplate))
+	e = `CREATE TABLE "table_copy" AS SELECT * FROM "table_name"`
+
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+}
+
 func TestCount(t *testing.T) {
 	var s, e string
 
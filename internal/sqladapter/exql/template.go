@@ -1,12 +1,12 @@
 package exql
 
 import (
-	"bytes"
 	"reflect"
 	"sync"
 	"text/template"
 
 	"github.com/upper/db/v4/internal/adapter"
+	"github.com/upper/db/v4/internal/bufferpool"
 	"github.com/upper/db/v4/internal/cache"
 )
 
@@ -20,6 +20,7 @@ const (
 	Truncate
 	DropTable
 	DropDatabase
+	CreateTableAs
 	Count
 	Insert
 	Select
@@ -38,36 +39,46 @@ type (
 
 // Template is an SQL template.
 type Template struct {
-	AndKeyword          string
-	AscKeyword          string
-	AssignmentOperator  string
-	ClauseGroup         string
-	ClauseOperator      string
-	ColumnAliasLayout   string
-	ColumnSeparator     string
-	ColumnValue         string
-	CountLayout         string
-	DeleteLayout        string
-	DescKeyword         string
-	DropDatabaseLayout  string
-	DropTableLayout     string
-	GroupByLayout       string
-	IdentifierQuote     string
-	IdentifierSeparator string
-	InsertLayout        string
-	JoinLayout          string
-	OnLayout            string
-	OrKeyword           string
-	OrderByLayout       string
-	SelectLayout        string
-	SortByColumnLayout  string
-	TableAliasLayout    string
-	TruncateLayout      string
-	UpdateLayout        string
-	UsingLayout         string
-	ValueQuote          string
-	ValueSeparator      string
-	WhereLayout         string
+	AndKeyword             string
+	AscKeyword             string
+	AssignmentOperator     string
+	CallLayout             string
+	ClauseGroup            string
+	ClauseOperator         string
+	ColumnAliasLayout      string
+	ColumnSeparator        string
+	ColumnValue            string
+	CountLayout            string
+	CreateTableAsLayout    string
+	CurrentTimeKeyword     string
+	DeleteLayout           string
+	DescKeyword            string
+	DropDatabaseLayout     string
+	DropTableLayout        string
+	GroupByLayout          string
+	HavingLayout           string
+	IdentifierQuote        string
+	IdentifierSeparator    string
+	InsertLayout           string
+	JoinLayout             string
+	LockLayout             string
+	NotKeyword             string
+	OnConflictLayout       string
+	OnConflictUpdateLayout string
+	OnLayout               string
+	OrKeyword              string
+	OrderByLayout          string
+	SelectLayout           string
+	SortByColumnLayout     string
+	TableAliasLayout       string
+	TruncateLayout         string
+	UpdateLayout           string
+	UsingLayout            string
+	ValueQuote             string
+	ValueSeparator         string
+	ValuesTableLayout      string
+	WhereLayout            string
+	WithLayout             string
 
 	ComparisonOperator map[adapter.ComparisonOperator]string
 
@@ -78,10 +89,11 @@ type Template struct {
 }
 
 func (layout *Template) MustCompile(templateText string, data interface{}) string {
-	var b bytes.Buffer
+	b := bufferpool.Get()
+	defer bufferpool.Put(b)
 
 	v, ok := layout.getTemplate(templateText)
-	if !ok || true {
+	if !ok {
 		v = template.
 			Must(template.New("").
 				Funcs(map[string]interface{}{
@@ -109,7 +121,7 @@ func (layout *Template) MustCompile(templateText string, data interface{}) strin
 		layout.setTemplate(templateText, v)
 	}
 
-	if err := v.Execute(&b, data); err != nil {
+	if err := v.Execute(b, data); err != nil {
 		panic("There was an error compiling the following template:\n" + templateText + "\nError was: " + err.Error())
 	}
 
@@ -120,10 +132,6 @@ func (t *Template) getTemplate(k string) (*template.Template, bool) {
 	t.templateMutex.RLock()
 	defer t.templateMutex.RUnlock()
 
-	if t.templateMap == nil {
-		t.templateMap = make(map[string]*template.Template)
-	}
-
 	v, ok := t.templateMap[k]
 	return v, ok
 }
@@ -132,5 +140,8 @@ func (t *Template) setTemplate(k string, v *template.Template) {
 	t.templateMutex.Lock()
 	defer t.templateMutex.Unlock()
 
+	if t.templateMap == nil {
+		t.templateMap = make(map[string]*template.Template)
+	}
 	t.templateMap[k] = v
 }
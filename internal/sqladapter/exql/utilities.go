@@ -149,3 +149,35 @@ func separateByAS(in string) (out []string) {
 
 	return
 }
+
+// hasTopLevelKeyword returns true if the given SQL expression has keyword as a
+// word outside of parentheses and quoted strings, keywords are matched case
+// insensitively.
+func hasTopLevelKeyword(expr string, keyword string) bool {
+	depth := 0
+	var quote byte
+
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && i+len(keyword) <= len(expr) && strings.EqualFold(expr[i:i+len(keyword)], keyword):
+			before := i == 0 || isBlankSymbol(expr[i-1]) || expr[i-1] == ')'
+			after := i+len(keyword) == len(expr) || isBlankSymbol(expr[i+len(keyword)]) || expr[i+len(keyword)] == '('
+			if before && after {
+				return true
+			}
+		}
+	}
+
+	return false
+}
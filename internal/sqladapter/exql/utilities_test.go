@@ -282,3 +282,26 @@ func BenchmarkUtilRegExpSeparateByAS(b *testing.B) {
 		_ = sep.Split(stringWithASKeyword, -1)
 	}
 }
+
+func TestUtilHasTopLevelKeyword(t *testing.T) {
+	var tests = []struct {
+		in  string
+		out bool
+	}{
+		{`a = 1 OR b = 2`, true},
+		{`a = 1 or b = 2`, true},
+		{`(a = 1 OR b = 2)`, false},
+		{`(a = 1 OR b = 2) AND c = 3`, false},
+		{`(a = 1) OR(b = 2)`, true},
+		{`name = 'x OR y'`, false},
+		{`"OR" = 1`, false},
+		{`color = 1 AND orientation = 2`, false},
+		{`a = 1 || b = 2`, false},
+	}
+
+	for _, test := range tests {
+		if hasTopLevelKeyword(test.in, "OR") != test.out {
+			t.Fatalf("Expecting %v for %q", test.out, test.in)
+		}
+	}
+}
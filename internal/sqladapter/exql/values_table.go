@@ -0,0 +1,80 @@
+package exql
+
+import (
+	"strings"
+)
+
+// ValuesTable represents a table made of a list of rows.
+type ValuesTable struct {
+	Name    Fragment
+	Columns []Fragment
+	Rows    []*Values
+	hash    hash
+}
+
+var _ = Fragment(&ValuesTable{})
+
+type valuesTableT struct {
+	Name        string
+	Columns     string
+	ColumnNames []string
+	Rows        []string
+	FirstRow    []string
+}
+
+// Hash returns a unique identifier for the struct.
+func (vt *ValuesTable) Hash() string {
+	return vt.hash.Hash(vt)
+}
+
+// Compile transforms the ValuesTable into its equivalent SQL representation.
+func (vt *ValuesTable) Compile(layout *Template) (compiled string, err error) {
+	if z, ok := layout.Read(vt); ok {
+		return z, nil
+	}
+
+	data := valuesTableT{
+		ColumnNames: make([]string, 0, len(vt.Columns)),
+		Rows:        make([]string, 0, len(vt.Rows)),
+	}
+
+	data.Name, err = vt.Name.Compile(layout)
+	if err != nil {
+		return "", err
+	}
+
+	for i := range vt.Columns {
+		column, err := vt.Columns[i].Compile(layout)
+		if err != nil {
+			return "", err
+		}
+		data.ColumnNames = append(data.ColumnNames, column)
+	}
+
+	data.Columns = strings.Join(data.ColumnNames, layout.IdentifierSeparator)
+
+	for i := range vt.Rows {
+		row, err := vt.Rows[i].Compile(layout)
+		if err != nil {
+			return "", err
+		}
+		data.Rows = append(data.Rows, row)
+	}
+
+	if len(vt.Rows) > 0 {
+		data.FirstRow = make([]string, 0, len(vt.Rows[0].Values))
+		for _, value := range vt.Rows[0].Values {
+			cell, err := value.Compile(layout)
+			if err != nil {
+				return "", err
+			}
+			data.FirstRow = append(data.FirstRow, cell)
+		}
+	}
+
+	compiled = trimString(layout.MustCompile(layout.ValuesTableLayout, data))
+
+	layout.Write(vt, compiled)
+
+	return
+}
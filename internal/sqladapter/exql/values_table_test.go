@@ -0,0 +1,22 @@
+package exql
+
+import (
+	"testing"
+)
+
+func TestValuesTable(t *testing.T) {
+	vt := &ValuesTable{
+		Name:    ColumnWithName("k"),
+		Columns: []Fragment{ColumnWithName("id"), ColumnWithName("name")},
+		Rows: []*Values{
+			NewValueGroup(&Raw{Value: "?"}, &Raw{Value: "?"}),
+			NewValueGroup(&Raw{Value: "?"}, &Raw{Value: "?"}),
+		},
+	}
+
+	s := mustTrim(vt.Compile(defaultTemplate))
+	e := `(VALUES (?, ?), (?, ?)) AS "k" ("id", "name")`
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+}
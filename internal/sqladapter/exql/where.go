@@ -10,6 +10,9 @@ type Or Where
 // And represents an SQL AND operator.
 type And Where
 
+// Not represents an SQL NOT operator applied to conditions joined with AND.
+type Not Where
+
 // Where represents an SQL WHERE clause.
 type Where struct {
 	Conditions []Fragment
@@ -37,6 +40,11 @@ func JoinWithAnd(conditions ...Fragment) *And {
 	return &And{Conditions: conditions}
 }
 
+// JoinWithNot creates and returns a new Not.
+func JoinWithNot(conditions ...Fragment) *Not {
+	return &Not{Conditions: conditions}
+}
+
 // Hash returns a unique identifier for the struct.
 func (w *Where) Hash() string {
 	return w.hash.Hash(w)
@@ -68,7 +76,7 @@ func (o *Or) Compile(layout *Template) (compiled string, err error) {
 		return z, nil
 	}
 
-	compiled, err = groupCondition(layout, o.Conditions, layout.MustCompile(layout.ClauseOperator, layout.OrKeyword))
+	compiled, err = groupCondition(layout, o.Conditions, layout.OrKeyword)
 	if err != nil {
 		return "", err
 	}
@@ -84,7 +92,7 @@ func (a *And) Compile(layout *Template) (compiled string, err error) {
 		return c, nil
 	}
 
-	compiled, err = groupCondition(layout, a.Conditions, layout.MustCompile(layout.ClauseOperator, layout.AndKeyword))
+	compiled, err = groupCondition(layout, a.Conditions, layout.AndKeyword)
 	if err != nil {
 		return "", err
 	}
@@ -94,13 +102,43 @@ func (a *And) Compile(layout *Template) (compiled string, err error) {
 	return
 }
 
+// Hash returns a unique identifier.
+func (n *Not) Hash() string {
+	w := Where(*n)
+	return `Not(` + w.Hash() + `)`
+}
+
+// Compile transforms the Not into an equivalent SQL representation.
+func (n *Not) Compile(layout *Template) (compiled string, err error) {
+	if c, ok := layout.Read(n); ok {
+		return c, nil
+	}
+
+	grouped, err := groupCondition(layout, n.Conditions, layout.AndKeyword)
+	if err != nil {
+		return "", err
+	}
+
+	if grouped != "" {
+		keyword := layout.NotKeyword
+		if keyword == "" {
+			keyword = defaultNotKeyword
+		}
+		compiled = keyword + ` ` + grouped
+	}
+
+	layout.Write(n, compiled)
+
+	return
+}
+
 // Compile transforms the Where into an equivalent SQL representation.
 func (w *Where) Compile(layout *Template) (compiled string, err error) {
 	if c, ok := layout.Read(w); ok {
 		return c, nil
 	}
 
-	grouped, err := groupCondition(layout, w.Conditions, layout.MustCompile(layout.ClauseOperator, layout.AndKeyword))
+	grouped, err := groupCondition(layout, w.Conditions, layout.AndKeyword)
 	if err != nil {
 		return "", err
 	}
@@ -114,7 +152,10 @@ func (w *Where) Compile(layout *Template) (compiled string, err error) {
 	return
 }
 
-func groupCondition(layout *Template, terms []Fragment, joinKeyword string) (string, error) {
+// groupCondition joins terms with the given logical keyword. Raw terms that
+// have an OR of their own are grouped when joined with AND, so the precedence
+// of the OR is kept.
+func groupCondition(layout *Template, terms []Fragment, keyword string) (string, error) {
 	l := len(terms)
 
 	chunks := make([]string, 0, l)
@@ -125,12 +166,17 @@ func groupCondition(layout *Template, terms []Fragment, joinKeyword string) (str
 			if err != nil {
 				return "", err
 			}
+			if _, ok := terms[i].(*Raw); ok && l > 1 && keyword == layout.AndKeyword {
+				if hasTopLevelKeyword(chunk, layout.OrKeyword) {
+					chunk = layout.MustCompile(layout.ClauseGroup, chunk)
+				}
+			}
 			chunks = append(chunks, chunk)
 		}
 	}
 
 	if len(chunks) > 0 {
-		return layout.MustCompile(layout.ClauseGroup, strings.Join(chunks, joinKeyword)), nil
+		return layout.MustCompile(layout.ClauseGroup, strings.Join(chunks, layout.MustCompile(layout.ClauseOperator, keyword))), nil
 	}
 
 	return "", nil
@@ -87,6 +87,53 @@ func TestWhereAndRawOrAnd(t *testing.T) {
 	}
 }
 
+func TestWhereNot(t *testing.T) {
+	where := WhereConditions(
+		&ColumnValue{Column: &Column{Name: "name"}, Operator: "=", Value: NewValue("John")},
+		JoinWithNot(
+			&ColumnValue{Column: &Column{Name: "age"}, Operator: ">", Value: NewValue(&Raw{Value: "18"})},
+			JoinWithOr(
+				&ColumnValue{Column: &Column{Name: "last_name"}, Operator: "=", Value: NewValue("Smith")},
+				&ColumnValue{Column: &Column{Name: "last_name"}, Operator: "=", Value: NewValue("Reyes")},
+			),
+		),
+		JoinWithNot(
+			&Raw{Value: "city_id = 728"},
+		),
+	)
+
+	s := mustTrim(where.Compile(defaultTemplate))
+
+	e := `WHERE ("name" = 'John' AND NOT ("age" > 18 AND ("last_name" = 'Smith' OR "last_name" = 'Reyes')) AND NOT (city_id = 728))`
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+}
+
+func TestWhereRawWithOr(t *testing.T) {
+	where := WhereConditions(
+		&ColumnValue{Column: &Column{Name: "name"}, Operator: "=", Value: NewValue("John")},
+		&Raw{Value: "city_id = 1 OR city_id = 2"},
+		&Raw{Value: "(a = 1 OR b = 2) AND c = 3"},
+		&Raw{Value: "note = 'x OR y'"},
+	)
+
+	s := mustTrim(where.Compile(defaultTemplate))
+
+	e := `WHERE ("name" = 'John' AND (city_id = 1 OR city_id = 2) AND (a = 1 OR b = 2) AND c = 3 AND note = 'x OR y')`
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+
+	// A single raw condition keeps its own precedence.
+	s = mustTrim(WhereConditions(&Raw{Value: "city_id = 1 OR city_id = 2"}).Compile(defaultTemplate))
+
+	e = `WHERE (city_id = 1 OR city_id = 2)`
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+}
+
 func BenchmarkWhere(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_ = WhereConditions(
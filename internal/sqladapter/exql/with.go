@@ -0,0 +1,75 @@
+package exql
+
+import (
+	"strings"
+)
+
+// With represents a WITH clause, which defines common table expressions that
+// can be used as tables by the statement.
+type With struct {
+	Recursive bool
+	Tables    []*CommonTable
+	hash      hash
+}
+
+// CommonTable represents a named query of a WITH clause, Columns is optional.
+type CommonTable struct {
+	Name    Fragment
+	Columns Fragment
+	Query   Fragment
+}
+
+var _ = Fragment(&With{})
+
+type withT struct {
+	Recursive bool
+	Tables    string
+}
+
+// Hash returns a unique identifier for the struct.
+func (w *With) Hash() string {
+	return w.hash.Hash(w)
+}
+
+// IsEmpty returns true if the clause has no tables.
+func (w *With) IsEmpty() bool {
+	return w == nil || len(w.Tables) == 0
+}
+
+// Compile transforms the With into its equivalent SQL representation.
+func (w *With) Compile(layout *Template) (compiled string, err error) {
+	if c, ok := layout.Read(w); ok {
+		return c, nil
+	}
+
+	tables := make([]string, 0, len(w.Tables))
+	for _, t := range w.Tables {
+		name, err := t.Name.Compile(layout)
+		if err != nil {
+			return "", err
+		}
+		if t.Columns != nil {
+			columns, err := t.Columns.Compile(layout)
+			if err != nil {
+				return "", err
+			}
+			name = name + " (" + columns + ")"
+		}
+		query, err := t.Query.Compile(layout)
+		if err != nil {
+			return "", err
+		}
+		tables = append(tables, name+" AS ("+query+")")
+	}
+
+	if len(tables) > 0 {
+		compiled = layout.MustCompile(layout.WithLayout, withT{
+			Recursive: w.Recursive,
+			Tables:    strings.Join(tables, ", "),
+		})
+	}
+
+	layout.Write(w, compiled)
+
+	return
+}
@@ -0,0 +1,44 @@
+package exql
+
+import (
+	"testing"
+)
+
+func TestWith(t *testing.T) {
+	with := &With{
+		Tables: []*CommonTable{
+			{
+				Name:  ColumnWithName("recent"),
+				Query: &Raw{Value: `SELECT * FROM "posts" WHERE "id" > 10`},
+			},
+		},
+	}
+
+	s := mustTrim(with.Compile(defaultTemplate))
+	e := `WITH "recent" AS (SELECT * FROM "posts" WHERE "id" > 10)`
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+
+	with = &With{
+		Recursive: true,
+		Tables: []*CommonTable{
+			{
+				Name:    ColumnWithName("tree"),
+				Columns: JoinColumns(ColumnWithName("id"), ColumnWithName("parent_id")),
+				Query:   &Raw{Value: `SELECT 1, NULL`},
+			},
+		},
+	}
+
+	s = mustTrim(with.Compile(defaultTemplate))
+	e = `WITH RECURSIVE "tree" ("id", "parent_id") AS (SELECT 1, NULL)`
+	if s != e {
+		t.Fatalf("Got: %s, Expecting: %s", s, e)
+	}
+
+	s = mustTrim((&With{}).Compile(defaultTemplate))
+	if s != "" {
+		t.Fatalf("Got: %s, Expecting an empty string", s)
+	}
+}
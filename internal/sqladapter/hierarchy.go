@@ -0,0 +1,129 @@
+package sqladapter
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/sqladapter/exql"
+)
+
+// recursiveQuerier is implemented by adapters that support recursive common
+// table expressions, it returns the keyword that starts them.
+type recursiveQuerier interface {
+	RecursiveWith() string
+}
+
+const hierarchyDepthColumn = "__depth"
+
+// maxHierarchyDepth stops recursive queries on cycles, it matches the default
+// recursion limit of SQL Server.
+const maxHierarchyDepth = 100
+
+func (c *collection) FindDescendants(rootID interface{}, parentColumn string) ([]*db.Node, error) {
+	return c.findHierarchy(rootID, parentColumn, false)
+}
+
+func (c *collection) FindAncestors(id interface{}, parentColumn string) ([]*db.Node, error) {
+	return c.findHierarchy(id, parentColumn, true)
+}
+
+// findHierarchy looks up the descendants (or ancestors) of the item with the
+// given ID with a recursive query, or one level at a time if the adapter does
+// not support recursive queries. Recursive queries stop after
+// maxHierarchyDepth levels.
+func (c *collection) findHierarchy(id interface{}, parentColumn string, ancestors bool) ([]*db.Node, error) {
+	if err := c.lastErr(); err != nil {
+		return nil, err
+	}
+
+	pk := c.PrimaryKeys()
+	if len(pk) != 1 {
+		return nil, errors.New("hierarchies require a table with a single primary key")
+	}
+	idColumn := pk[0]
+
+	sess, ok := c.sess.(*session)
+	if !ok {
+		return nil, db.ErrUnsupported
+	}
+	rq, ok := sess.adapter.(recursiveQuerier)
+	if !ok {
+		if ancestors {
+			return db.Ancestors(c, idColumn, id, parentColumn)
+		}
+		return db.Descendants(c, idColumn, id, parentColumn)
+	}
+
+	t := sess.adapter.Template()
+	identifier := func(name string) string {
+		s, _ := exql.ColumnWithName(name).Compile(t)
+		return s
+	}
+	table, err := exql.TableWithName(c.Name()).Compile(t)
+	if err != nil {
+		return nil, err
+	}
+
+	// Descendants point to the rows that are already in the tree, ancestors
+	// are pointed to by them.
+	join := fmt.Sprintf("%s = __tree.%s", identifier("t."+parentColumn), identifier(idColumn))
+	if ancestors {
+		join = fmt.Sprintf("%s = __tree.%s", identifier("t."+idColumn), identifier(parentColumn))
+	}
+
+	query := fmt.Sprintf(
+		`%s __tree AS (
+			SELECT t.*, 0 AS %s FROM %s AS t WHERE %s = ?
+			UNION ALL
+			SELECT t.*, __tree.%s + 1 FROM %s AS t INNER JOIN __tree ON %s
+			WHERE __tree.%s < %d
+		)
+		SELECT * FROM __tree ORDER BY %s`,
+		rq.RecursiveWith(),
+		hierarchyDepthColumn, table, identifier("t."+idColumn),
+		hierarchyDepthColumn, table, join,
+		hierarchyDepthColumn, maxHierarchyDepth,
+		hierarchyDepthColumn,
+	)
+
+	var items []map[string]interface{}
+	if err := c.SQL().Iterator(query, id).All(&items); err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, db.ErrNoMoreRows
+	}
+
+	// Rows are sorted by depth, on cycles only the first time a row is found
+	// is kept.
+	seen := map[string]bool{}
+	nodes := make([]*db.Node, 0, len(items))
+	for _, item := range items {
+		key := fmt.Sprintf("%v", item[idColumn])
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		depth, err := strconv.Atoi(depthValue(item[hierarchyDepthColumn]))
+		if err != nil {
+			return nil, err
+		}
+		delete(item, hierarchyDepthColumn)
+		nodes = append(nodes, &db.Node{Item: item, Depth: depth})
+	}
+
+	db.LinkNodes(nodes, idColumn, parentColumn)
+	return nodes, nil
+}
+
+// depthValue returns the depth column as text, drivers may return it as a
+// number or as []byte.
+func depthValue(v interface{}) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprintf("%v", v)
+}
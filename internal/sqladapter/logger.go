@@ -23,32 +23,16 @@ package sqladapter
 
 import (
 	"context"
-	"fmt"
-	"regexp"
-	"strings"
 	"time"
-)
-
-const (
-	fmtLogSessID       = `Session ID:     %05d`
-	fmtLogTxID         = `Transaction ID: %05d`
-	fmtLogQuery        = `Query:          %s`
-	fmtLogArgs         = `Arguments:      %#v`
-	fmtLogRowsAffected = `Rows affected:  %d`
-	fmtLogLastInsertID = `Last insert ID: %d`
-	fmtLogError        = `Error:          %v`
-	fmtLogTimeTaken    = `Time taken:     %0.5fs`
-	fmtLogContext      = `Context:        %v`
-)
 
-var (
-	reInvisibleChars = regexp.MustCompile(`[\s\r\n\t]+`)
+	db "github.com/upper/db/v4"
 )
 
 // QueryStatus represents the status of a query after being executed.
 type QueryStatus struct {
-	SessID uint64
-	TxID   uint64
+	SessID  uint64
+	TxID    uint64
+	QueryID uint64
 
 	RowsAffected *int64
 	LastInsertID *int64
@@ -66,42 +50,21 @@ type QueryStatus struct {
 
 // String returns a formatted log message.
 func (q *QueryStatus) String() string {
-	lines := make([]string, 0, 8)
-
-	if q.SessID > 0 {
-		lines = append(lines, fmt.Sprintf(fmtLogSessID, q.SessID))
-	}
-
-	if q.TxID > 0 {
-		lines = append(lines, fmt.Sprintf(fmtLogTxID, q.TxID))
-	}
-
-	if query := q.Query; query != "" {
-		query = reInvisibleChars.ReplaceAllString(query, ` `)
-		query = strings.TrimSpace(query)
-		lines = append(lines, fmt.Sprintf(fmtLogQuery, query))
-	}
-
-	if len(q.Args) > 0 {
-		lines = append(lines, fmt.Sprintf(fmtLogArgs, q.Args))
-	}
-
-	if q.RowsAffected != nil {
-		lines = append(lines, fmt.Sprintf(fmtLogRowsAffected, *q.RowsAffected))
-	}
-	if q.LastInsertID != nil {
-		lines = append(lines, fmt.Sprintf(fmtLogLastInsertID, *q.LastInsertID))
-	}
-
-	if q.Err != nil {
-		lines = append(lines, fmt.Sprintf(fmtLogError, q.Err))
-	}
-
-	lines = append(lines, fmt.Sprintf(fmtLogTimeTaken, float64(q.End.UnixNano()-q.Start.UnixNano())/float64(1e9)))
+	return q.Event().String()
+}
 
-	if q.Context != nil {
-		lines = append(lines, fmt.Sprintf(fmtLogContext, q.Context))
+// Event returns the status as a db.QueryEvent, to be sent to query loggers.
+func (q *QueryStatus) Event() *db.QueryEvent {
+	return &db.QueryEvent{
+		SessionID:    q.SessID,
+		TxID:         q.TxID,
+		QueryID:      q.QueryID,
+		Query:        q.Query,
+		Args:         q.Args,
+		Duration:     q.End.Sub(q.Start),
+		RowsAffected: q.RowsAffected,
+		LastInsertID: q.LastInsertID,
+		Err:          q.Err,
+		Context:      q.Context,
 	}
-
-	return "\t" + strings.Replace(strings.Join(lines, "\n"), "\n", "\n\t", -1) + "\n\n"
 }
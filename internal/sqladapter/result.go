@@ -22,12 +22,22 @@
 package sqladapter
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
 
 	db "github.com/upper/db/v4"
 	"github.com/upper/db/v4/internal/immutable"
+	"github.com/upper/db/v4/internal/reflectx"
+	"github.com/upper/db/v4/internal/sqladapter/exql"
+	"github.com/upper/db/v4/internal/sqlbuilder"
 )
 
 type Result struct {
@@ -52,19 +62,65 @@ type result struct {
 	pageNumber uint
 
 	cursorColumn        string
+	primaryKeys         func() []string
+	documentModifier    documentModifier
+	materialize         func(string, db.Paginator) (db.Collection, error)
 	nextPageCursorValue interface{}
 	prevPageCursorValue interface{}
 
 	fields  []interface{}
 	orderBy []interface{}
 	groupBy []interface{}
+	having  [][]interface{}
 	conds   [][]interface{}
+	joins   []*resultJoin
+
+	ttlColumn   string
+	currentTime interface{}
+
+	template *exql.Template
+
+	sess      db.Session
+	returning bool
+
+	lock func(db.Selector) db.Selector
+}
+
+// resultJoin represents a JOIN clause on a result set.
+type resultJoin struct {
+	joinType string
+	tables   []interface{}
+	on       []interface{}
+	using    []interface{}
 }
 
 func filter(conds []interface{}) []interface{} {
 	return conds
 }
 
+// where returns the conditions of the result set, including the one that
+// skips expired rows.
+func (res *result) where() [][]interface{} {
+	if res.ttlColumn == "" {
+		return res.conds
+	}
+	now := res.currentTime
+	if now == nil {
+		now = db.Raw(db.CurrentTimestamp)
+	}
+	conds := append([][]interface{}{}, res.conds...)
+	return append(conds, []interface{}{db.Unexpired(res.ttlColumn, now)})
+}
+
+// snapshot copies the arguments of a chained call, so the caller can reuse or
+// modify its slice without altering the result set that was derived from it.
+func snapshot(args []interface{}) []interface{} {
+	if args == nil {
+		return nil
+	}
+	return append(make([]interface{}, 0, len(args)), args...)
+}
+
 // NewResult creates and Results a new Result set on the given table, this set
 // is limited by the given exql.Where conditions.
 func NewResult(builder db.SQL, table string, conds []interface{}) *Result {
@@ -74,8 +130,12 @@ func NewResult(builder db.SQL, table string, conds []interface{}) *Result {
 	return r.from(table).where(conds)
 }
 
+// frame returns a new Result that applies fn on top of r, r is never modified
+// so it can be reused and branched from different goroutines.
 func (r *Result) frame(fn func(*result) error) *Result {
-	return &Result{err: r.err, prev: r, fn: fn}
+	next := &Result{prev: r, fn: fn}
+	next.setErr(r.Err())
+	return next
 }
 
 func (r *Result) SQL() db.SQL {
@@ -93,12 +153,83 @@ func (r *Result) from(table string) *Result {
 }
 
 func (r *Result) where(conds []interface{}) *Result {
+	conds = snapshot(conds)
 	return r.frame(func(res *result) error {
 		res.conds = [][]interface{}{conds}
 		return nil
 	})
 }
 
+// withPrimaryKeys sets the function that returns the primary keys of the
+// table, which are used as default cursor for NextPage and PrevPage.
+func (r *Result) withPrimaryKeys(fn func() []string) *Result {
+	return r.frame(func(res *result) error {
+		res.primaryKeys = fn
+		return nil
+	})
+}
+
+// withMaterializer sets the function that stores the rows of a query into a
+// new table.
+func (r *Result) withMaterializer(fn func(string, db.Paginator) (db.Collection, error)) *Result {
+	return r.frame(func(res *result) error {
+		res.materialize = fn
+		return nil
+	})
+}
+
+// withTTL makes the result set skip the rows whose expiration time, stored in
+// column, is not later than now. An empty column doesn't skip any row.
+func (r *Result) withTTL(column string, now interface{}) *Result {
+	return r.frame(func(res *result) error {
+		res.ttlColumn = column
+		res.currentTime = now
+		return nil
+	})
+}
+
+// unexpired returns a result set that skips expired rows when dst has a field
+// with the ttl tag option.
+func (r *Result) unexpired(dst interface{}) *Result {
+	column, ok := sqlbuilder.TTLColumn(dst)
+	if !ok {
+		return r
+	}
+	return r.frame(func(res *result) error {
+		res.ttlColumn = column
+		return nil
+	})
+}
+
+// withTemplate sets the template that is used to quote the column names given
+// to aggregate functions.
+func (r *Result) withTemplate(t *exql.Template) *Result {
+	return r.frame(func(res *result) error {
+		res.template = t
+		return nil
+	})
+}
+
+// withDocumentModifier sets the adapter that compiles modifiers on nested
+// fields.
+func (r *Result) withDocumentModifier(m documentModifier) *Result {
+	return r.frame(func(res *result) error {
+		res.documentModifier = m
+		return nil
+	})
+}
+
+// withSession sets the session that opens the transactions of
+// DeleteReturning and UpdateReturning, returning tells whether the database
+// can return the affected rows in the same statement.
+func (r *Result) withSession(sess db.Session, returning bool) *Result {
+	return r.frame(func(res *result) error {
+		res.sess = sess
+		res.returning = returning
+		return nil
+	})
+}
+
 func (r *Result) setErr(err error) {
 	if err == nil {
 		return
@@ -122,6 +253,7 @@ func (r *Result) Where(conds ...interface{}) db.Result {
 
 // And adds more conditions on top of the existing ones.
 func (r *Result) And(conds ...interface{}) db.Result {
+	conds = snapshot(conds)
 	return r.frame(func(res *result) error {
 		res.conds = append(res.conds, conds)
 		return nil
@@ -175,6 +307,102 @@ func (r *Result) PrevPage(cursorValue interface{}) db.Result {
 	})
 }
 
+func (r *Result) pushJoin(joinType string, tables []interface{}) *Result {
+	tables = snapshot(tables)
+	return r.frame(func(res *result) error {
+		res.joins = append(res.joins, &resultJoin{
+			joinType: joinType,
+			tables:   tables,
+		})
+		return nil
+	})
+}
+
+// Join adds a JOIN clause to the result set.
+func (r *Result) Join(tables ...interface{}) db.Result {
+	return r.pushJoin("", tables)
+}
+
+// InnerJoin adds an INNER JOIN clause to the result set.
+func (r *Result) InnerJoin(tables ...interface{}) db.Result {
+	return r.pushJoin("INNER", tables)
+}
+
+// LeftJoin adds a LEFT JOIN clause to the result set.
+func (r *Result) LeftJoin(tables ...interface{}) db.Result {
+	return r.pushJoin("LEFT", tables)
+}
+
+// RightJoin adds a RIGHT JOIN clause to the result set.
+func (r *Result) RightJoin(tables ...interface{}) db.Result {
+	return r.pushJoin("RIGHT", tables)
+}
+
+// FullJoin adds a FULL JOIN clause to the result set.
+func (r *Result) FullJoin(tables ...interface{}) db.Result {
+	return r.pushJoin("FULL", tables)
+}
+
+// On sets the conditions of the last join.
+func (r *Result) On(conds ...interface{}) db.Result {
+	conds = snapshot(conds)
+	return r.frame(func(res *result) error {
+		join, err := res.lastJoin("On")
+		if err != nil {
+			return err
+		}
+		join.on = conds
+		return nil
+	})
+}
+
+// Using sets the columns the last join uses to match rows.
+func (r *Result) Using(columns ...interface{}) db.Result {
+	columns = snapshot(columns)
+	return r.frame(func(res *result) error {
+		join, err := res.lastJoin("Using")
+		if err != nil {
+			return err
+		}
+		join.using = columns
+		return nil
+	})
+}
+
+func (res *result) lastJoin(clause string) (*resultJoin, error) {
+	if len(res.joins) == 0 {
+		return nil, fmt.Errorf("cannot use %s() without a preceding Join() expression", clause)
+	}
+	join := res.joins[len(res.joins)-1]
+	if join.on != nil || join.using != nil {
+		return nil, errors.New("cannot use Using() and On() with the same Join() expression")
+	}
+	return join, nil
+}
+
+func (res *result) applyJoins(sel db.Selector) db.Selector {
+	for _, join := range res.joins {
+		switch join.joinType {
+		case "INNER":
+			sel = sel.InnerJoin(join.tables...)
+		case "LEFT":
+			sel = sel.LeftJoin(join.tables...)
+		case "RIGHT":
+			sel = sel.RightJoin(join.tables...)
+		case "FULL":
+			sel = sel.FullJoin(join.tables...)
+		default:
+			sel = sel.Join(join.tables...)
+		}
+		if join.on != nil {
+			sel = sel.On(join.on...)
+		} else if join.using != nil {
+			sel = sel.Using(join.using...)
+		}
+	}
+	return sel
+}
+
 // Offset determines how many documents will be skipped before starting to grab
 // Results.
 func (r *Result) Offset(n int) db.Result {
@@ -184,19 +412,58 @@ func (r *Result) Offset(n int) db.Result {
 	})
 }
 
+// ForUpdate locks the matched rows until the end of the current transaction.
+func (r *Result) ForUpdate(opts ...db.LockOption) db.Result {
+	return r.frame(func(res *result) error {
+		res.lock = func(sel db.Selector) db.Selector {
+			return sel.ForUpdate(opts...)
+		}
+		return nil
+	})
+}
+
+// ForShare acquires a shared lock on the matched rows until the end of the
+// current transaction.
+func (r *Result) ForShare(opts ...db.LockOption) db.Result {
+	return r.frame(func(res *result) error {
+		res.lock = func(sel db.Selector) db.Selector {
+			return sel.ForShare(opts...)
+		}
+		return nil
+	})
+}
+
+// ReadPreference is ignored by SQL adapters, reads are always served by the
+// database the session is connected to.
+func (r *Result) ReadPreference(db.ReadPreference) db.Result {
+	return r
+}
+
 // GroupBy is used to group Results that have the same value in the same column
 // or columns.
 func (r *Result) GroupBy(fields ...interface{}) db.Result {
+	fields = snapshot(fields)
 	return r.frame(func(res *result) error {
 		res.groupBy = fields
 		return nil
 	})
 }
 
+// Having filters the groups of the Result, conditions are appended to the
+// ones already set.
+func (r *Result) Having(conds ...interface{}) db.Result {
+	conds = snapshot(conds)
+	return r.frame(func(res *result) error {
+		res.having = append(res.having, conds)
+		return nil
+	})
+}
+
 // OrderBy determines sorting of Results according to the provided names. Fields
 // may be prefixed by - (minus) which means descending order, ascending order
 // would be used otherwise.
 func (r *Result) OrderBy(fields ...interface{}) db.Result {
+	fields = snapshot(fields)
 	return r.frame(func(res *result) error {
 		res.orderBy = fields
 		return nil
@@ -205,6 +472,7 @@ func (r *Result) OrderBy(fields ...interface{}) db.Result {
 
 // Select determines which fields to return.
 func (r *Result) Select(fields ...interface{}) db.Result {
+	fields = snapshot(fields)
 	return r.frame(func(res *result) error {
 		res.fields = fields
 		return nil
@@ -220,9 +488,42 @@ func (r *Result) String() string {
 	return query.String()
 }
 
+// Compile returns the SELECT statement and its arguments.
+func (r *Result) Compile() (string, []interface{}, error) {
+	query, err := r.buildPaginator()
+	if err != nil {
+		return "", nil, err
+	}
+	return query.String(), query.Arguments(), nil
+}
+
+// Subquery returns the SELECT statement of the result set, which is used when
+// the result set is given as a value to a condition or as a table.
+func (r *Result) Subquery() (db.Paginator, error) {
+	return r.buildPaginator()
+}
+
+// CompileUpdate returns the UPDATE statement and its arguments.
+func (r *Result) CompileUpdate(values interface{}) (string, []interface{}, error) {
+	query, err := r.buildUpdate(values)
+	if err != nil {
+		return "", nil, err
+	}
+	return query.String(), query.Arguments(), nil
+}
+
+// CompileDelete returns the DELETE statement and its arguments.
+func (r *Result) CompileDelete() (string, []interface{}, error) {
+	query, err := r.buildDelete()
+	if err != nil {
+		return "", nil, err
+	}
+	return query.String(), query.Arguments(), nil
+}
+
 // All dumps all Results into a pointer to an slice of structs or maps.
 func (r *Result) All(dst interface{}) error {
-	query, err := r.buildPaginator()
+	query, err := r.unexpired(dst).buildPaginator()
 	if err != nil {
 		r.setErr(err)
 		return err
@@ -232,9 +533,57 @@ func (r *Result) All(dst interface{}) error {
 	return err
 }
 
+// AllColumns dumps all Results into a struct of slices, one per column.
+func (r *Result) AllColumns(dst interface{}) error {
+	query, err := r.unexpired(dst).buildPaginator()
+	if err != nil {
+		r.setErr(err)
+		return err
+	}
+	err = query.Iterator().AllColumns(dst)
+	r.setErr(err)
+	return err
+}
+
+// Scanner returns a reader for the value of the given column of the first
+// Result from the set.
+func (r *Result) Scanner(column string) (io.ReadCloser, error) {
+	query, err := r.frame(func(res *result) error {
+		res.fields = []interface{}{column}
+		return nil
+	}).buildPaginator()
+	if err != nil {
+		r.setErr(err)
+		return nil, err
+	}
+
+	iter := query.Iterator()
+
+	// The driver owns the memory sql.RawBytes points to, it stays valid until
+	// the iterator is closed.
+	var value sql.RawBytes
+	if err := iter.NextScan(&value); err != nil {
+		_ = iter.Close()
+		r.setErr(err)
+		return nil, err
+	}
+
+	return &columnReader{Reader: bytes.NewReader(value), iter: iter}, nil
+}
+
+// columnReader reads a column value straight from the driver's buffer.
+type columnReader struct {
+	*bytes.Reader
+	iter db.Iterator
+}
+
+func (cr *columnReader) Close() error {
+	return cr.iter.Close()
+}
+
 // One fetches only one Result from the set.
 func (r *Result) One(dst interface{}) error {
-	query, err := r.buildPaginator()
+	query, err := r.unexpired(dst).buildPaginator()
 	if err != nil {
 		r.setErr(err)
 		return err
@@ -244,13 +593,55 @@ func (r *Result) One(dst interface{}) error {
 	return err
 }
 
+// First stores the first item of the result set into dst, the set is ordered
+// by primary key if it has no order.
+func (r *Result) First(dst interface{}) error {
+	res, err := r.fastForward()
+	if err != nil {
+		r.setErr(err)
+		return err
+	}
+	if len(res.orderBy) > 0 {
+		return r.Limit(1).One(dst)
+	}
+	return r.orderByKeys(res, "").Limit(1).One(dst)
+}
+
+// Last stores the item with the greatest value of orderColumn into dst, or the
+// one with the greatest primary key if orderColumn is empty.
+func (r *Result) Last(dst interface{}, orderColumn string) error {
+	if orderColumn != "" {
+		return r.OrderBy("-" + orderColumn).Limit(1).One(dst)
+	}
+	res, err := r.fastForward()
+	if err != nil {
+		r.setErr(err)
+		return err
+	}
+	return r.orderByKeys(res, "-").Limit(1).One(dst)
+}
+
+// orderByKeys orders the result set by primary key, each key is prefixed with
+// prefix.
+func (r *Result) orderByKeys(res *result, prefix string) db.Result {
+	if res.primaryKeys == nil {
+		return r
+	}
+	pks := res.primaryKeys()
+	fields := make([]interface{}, len(pks))
+	for i := range pks {
+		fields[i] = prefix + pks[i]
+	}
+	return r.OrderBy(fields...)
+}
+
 // Next fetches the next Result from the set.
 func (r *Result) Next(dst interface{}) bool {
 	r.iterMu.Lock()
 	defer r.iterMu.Unlock()
 
 	if r.iter == nil {
-		query, err := r.buildPaginator()
+		query, err := r.unexpired(dst).buildPaginator()
 		if err != nil {
 			r.setErr(err)
 			return false
@@ -258,6 +649,150 @@ func (r *Result) Next(dst interface{}) bool {
 		r.iter = query.Iterator()
 	}
 
+	return r.next(dst)
+}
+
+// NextContext fetches the next Result from the set, unless the given context
+// is done, in which case the set is closed and the context's error is kept.
+func (r *Result) NextContext(ctx context.Context, dst interface{}) bool {
+	r.iterMu.Lock()
+	defer r.iterMu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		if r.iter != nil {
+			_ = r.iter.Close()
+		}
+		r.setErr(err)
+		return false
+	}
+
+	if r.iter == nil {
+		query, err := r.unexpired(dst).buildPaginator()
+		if err != nil {
+			r.setErr(err)
+			return false
+		}
+		r.iter = query.IteratorContext(ctx)
+	}
+
+	return r.next(dst)
+}
+
+// Iterate returns a function that streams the Results of the set into dst.
+// Unlike Next, the cursor belongs to each call of the returned function, not
+// to the Result, and errors are yielded instead of being stored on it.
+func (r *Result) Iterate(dst interface{}) func(yield func(int, error) bool) {
+	return func(yield func(int, error) bool) {
+		query, err := r.unexpired(dst).buildPaginator()
+		if err != nil {
+			yield(0, err)
+			return
+		}
+
+		iter := query.Iterator()
+		defer iter.Close()
+
+		i := 0
+		for ; iter.Next(dst); i++ {
+			if !yield(i, nil) {
+				return
+			}
+		}
+
+		if err := iter.Err(); err != nil {
+			yield(i, err)
+		}
+	}
+}
+
+// Batches returns a function that loads the result set into dst in batches of
+// at most size items, using cursor-based pagination. Errors are yielded
+// instead of being stored on the Result.
+func (r *Result) Batches(size uint, dst interface{}) func(yield func(int, error) bool) {
+	return func(yield func(int, error) bool) {
+		column, err := r.batchCursor(size, dst)
+		if err != nil {
+			yield(0, err)
+			return
+		}
+
+		slicev := reflect.ValueOf(dst).Elem()
+		page := r.Paginate(size).Cursor(column)
+		for i := 0; ; i++ {
+			if err := page.All(dst); err != nil {
+				yield(i, err)
+				return
+			}
+			n := slicev.Len()
+			if n == 0 {
+				return
+			}
+			if !yield(i, nil) || n < int(size) {
+				return
+			}
+
+			value, err := cursorValue(slicev.Index(n-1), strings.TrimPrefix(column, "-"))
+			if err != nil {
+				yield(i+1, err)
+				return
+			}
+			page = r.Paginate(size).Cursor(column).NextPage(value)
+		}
+	}
+}
+
+// batchCursor returns the cursor column of the result set, or its primary key
+// if no cursor was set.
+func (r *Result) batchCursor(size uint, dst interface{}) (string, error) {
+	if size == 0 {
+		return "", fmt.Errorf("upper: batch size must be greater than zero")
+	}
+	dstv := reflect.ValueOf(dst)
+	if dstv.Kind() != reflect.Ptr || dstv.IsNil() || dstv.Elem().Kind() != reflect.Slice {
+		return "", sqlbuilder.ErrExpectingSlicePointer
+	}
+
+	res, err := r.fastForward()
+	if err != nil {
+		return "", err
+	}
+	if res.cursorColumn != "" {
+		return res.cursorColumn, nil
+	}
+	if res.primaryKeys != nil {
+		if pks := res.primaryKeys(); len(pks) == 1 {
+			return pks[0], nil
+		}
+	}
+	return "", fmt.Errorf("%w: batches need a cursor or a single column primary key", db.ErrUnsupported)
+}
+
+// keyAlias returns the name key is read with. Keys read through a function,
+// like id() on QL, are selected with the name of the function as alias.
+func keyAlias(key string) string {
+	return strings.TrimSuffix(key, "()")
+}
+
+// cursorValue returns the value of the given column in item, which is a map
+// or a struct.
+func cursorValue(item reflect.Value, column string) (interface{}, error) {
+	column = keyAlias(column)
+
+	item = reflect.Indirect(item)
+	switch item.Kind() {
+	case reflect.Map:
+		if v := item.MapIndex(reflect.ValueOf(column)); v.IsValid() {
+			return v.Interface(), nil
+		}
+	case reflect.Struct:
+		if fi, ok := sqlbuilder.Mapper.TypeMap(item.Type()).Names[column]; ok {
+			return reflectx.FieldByIndexes(item, fi.Index).Interface(), nil
+		}
+	}
+	return nil, fmt.Errorf("upper: batch items have no value for cursor column %q", column)
+}
+
+func (r *Result) next(dst interface{}) bool {
 	if r.iter.Next(dst) {
 		return true
 	}
@@ -272,19 +807,66 @@ func (r *Result) Next(dst interface{}) bool {
 
 // Delete deletes all matching items from the collection.
 func (r *Result) Delete() error {
+	_, err := r.execDelete()
+	return err
+}
+
+// DeleteCount deletes all matching items from the collection and returns the
+// number of deleted items.
+func (r *Result) DeleteCount() (uint64, error) {
+	res, err := r.execDelete()
+	if err != nil {
+		return 0, err
+	}
+	return rowsAffected(res)
+}
+
+// execDelete runs the delete statement. Delete doesn't need the number of
+// affected rows and must not fail on drivers that can't report it, like QL
+// after a DELETE without conditions.
+func (r *Result) execDelete() (sql.Result, error) {
 	query, err := r.buildDelete()
 	if err != nil {
 		r.setErr(err)
-		return err
+		return nil, err
 	}
 
-	_, err = query.Exec()
+	res, err := query.Exec()
+	if err != nil {
+		r.setErr(err)
+		return nil, err
+	}
+	return res, nil
+}
+
+// Into stores the Results into a new table with the given name, the table is
+// replaced if it already exists.
+func (r *Result) Into(name string) (db.Collection, error) {
+	query, err := r.buildPaginator()
+	if err != nil {
+		r.setErr(err)
+		return nil, err
+	}
+
+	res, err := r.fastForward()
+	if err != nil {
+		r.setErr(err)
+		return nil, err
+	}
+	if res.materialize == nil {
+		return nil, fmt.Errorf("%w: materializing results", db.ErrUnsupported)
+	}
+
+	col, err := res.materialize(name, query)
 	r.setErr(err)
-	return err
+	return col, err
 }
 
 // Close closes the Result set.
 func (r *Result) Close() error {
+	r.iterMu.Lock()
+	defer r.iterMu.Unlock()
+
 	if r.iter != nil {
 		err := r.iter.Close()
 		r.setErr(err)
@@ -296,7 +878,130 @@ func (r *Result) Close() error {
 // Update updates matching items from the collection with values of the given
 // map or struct.
 func (r *Result) Update(values interface{}) error {
+	_, err := r.execUpdate(values)
+	return err
+}
+
+// UpdateCount updates matching items from the collection with values of the
+// given map or struct and returns the number of affected items.
+func (r *Result) UpdateCount(values interface{}) (uint64, error) {
+	res, err := r.execUpdate(values)
+	if err != nil {
+		return 0, err
+	}
+	return rowsAffected(res)
+}
+
+// execUpdate runs the update statement, see execDelete.
+func (r *Result) execUpdate(values interface{}) (sql.Result, error) {
 	query, err := r.buildUpdate(values)
+	if err != nil {
+		r.setErr(err)
+		return nil, err
+	}
+
+	res, err := query.Exec()
+	if err != nil {
+		r.setErr(err)
+		return nil, err
+	}
+	return res, nil
+}
+
+func rowsAffected(res sql.Result) (uint64, error) {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(n), nil
+}
+
+// DeleteReturning deletes the matching items and stores them into dst.
+func (r *Result) DeleteReturning(dst interface{}) error {
+	res, pks, err := r.buildReturning()
+	if err != nil {
+		r.setErr(err)
+		return err
+	}
+
+	if res.returning && len(pks) == 1 {
+		// The database can return the deleted rows in the same statement.
+		iter := r.SQL().DeleteFrom(res.table).
+			Where(db.Cond{pks[0] + " IN": res.keys(r.SQL(), pks, dst)}).
+			Returning("*").
+			Iterator()
+		err = scanReturned(iter, dst)
+		r.setErr(err)
+		return err
+	}
+
+	err = res.sess.Tx(func(tx db.Session) error {
+		cond, err := lockKeys(res.keys(tx.SQL(), pks, dst), pks)
+		if err != nil {
+			return err
+		}
+		if cond == nil {
+			return noneReturned(dst)
+		}
+
+		iter := tx.SQL().SelectFrom(res.table).Where(cond).Iterator()
+		if err := scanReturned(iter, dst); err != nil {
+			return err
+		}
+		_, err = tx.SQL().DeleteFrom(res.table).Where(cond).Exec()
+		return err
+	})
+	r.setErr(err)
+	return err
+}
+
+// UpdateReturning updates the matching items with the given values and stores
+// the updated items into dst.
+func (r *Result) UpdateReturning(values interface{}, dst interface{}) error {
+	res, pks, err := r.buildReturning()
+	if err != nil {
+		r.setErr(err)
+		return err
+	}
+
+	if res.returning && len(pks) == 1 {
+		// The database can return the updated rows in the same statement.
+		iter := r.SQL().Update(res.table).
+			Set(values).
+			Where(db.Cond{pks[0] + " IN": res.keys(r.SQL(), pks, dst)}).
+			Returning("*").
+			Iterator()
+		err = scanReturned(iter, dst)
+		r.setErr(err)
+		return err
+	}
+
+	err = res.sess.Tx(func(tx db.Session) error {
+		cond, err := lockKeys(res.keys(tx.SQL(), pks, dst), pks)
+		if err != nil {
+			return err
+		}
+		if cond == nil {
+			return noneReturned(dst)
+		}
+
+		if _, err := tx.SQL().Update(res.table).Set(values).Where(cond).Exec(); err != nil {
+			return err
+		}
+		iter := tx.SQL().SelectFrom(res.table).Where(cond).Iterator()
+		return scanReturned(iter, dst)
+	})
+	r.setErr(err)
+	return err
+}
+
+// Modify applies the given modifiers to all items within the result set.
+func (r *Result) Modify(mods ...*db.Modifier) error {
+	if len(mods) == 0 {
+		return r.Err()
+	}
+
+	query, err := r.buildModify(mods)
 	if err != nil {
 		r.setErr(err)
 		return err
@@ -407,13 +1112,32 @@ func (r *Result) buildPaginator() (db.Paginator, error) {
 		GroupBy(res.groupBy...).
 		OrderBy(res.orderBy...)
 
-	for i := range res.conds {
-		sel = sel.And(filter(res.conds[i])...)
+	sel = res.applyJoins(sel)
+
+	if res.lock != nil {
+		sel = res.lock(sel)
+	}
+
+	for _, conds := range res.where() {
+		sel = sel.And(filter(conds)...)
+	}
+
+	for i := range res.having {
+		sel = sel.Having(res.having[i]...)
+	}
+
+	cursorColumn := res.cursorColumn
+	if cursorColumn == "" && res.primaryKeys != nil {
+		if res.nextPageCursorValue != nil || res.prevPageCursorValue != nil {
+			if pks := res.primaryKeys(); len(pks) == 1 {
+				cursorColumn = pks[0]
+			}
+		}
 	}
 
 	pag := sel.Paginate(res.pageSize).
 		Page(res.pageNumber).
-		Cursor(res.cursorColumn)
+		Cursor(cursorColumn)
 
 	if res.nextPageCursorValue != nil {
 		pag = pag.NextPage(res.nextPageCursorValue)
@@ -436,16 +1160,135 @@ func (r *Result) buildDelete() (db.Deleter, error) {
 		return nil, err
 	}
 
+	if len(res.joins) > 0 {
+		return nil, fmt.Errorf("%w: can't delete from a result set with joins", db.ErrUnsupported)
+	}
+
 	del := r.SQL().DeleteFrom(res.table).
 		Limit(res.limit)
 
-	for i := range res.conds {
-		del = del.And(filter(res.conds[i])...)
+	for _, conds := range res.where() {
+		del = del.And(filter(conds)...)
 	}
 
 	return del, nil
 }
 
+func (r *Result) buildReturning() (*result, []string, error) {
+	if err := r.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	res, err := r.fastForward()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(res.joins) > 0 {
+		return nil, nil, fmt.Errorf("%w: can't modify a result set with joins", db.ErrUnsupported)
+	}
+	if res.sess == nil || res.primaryKeys == nil {
+		return nil, nil, fmt.Errorf("%w: returning modified items", db.ErrUnsupported)
+	}
+
+	pks := res.primaryKeys()
+	if len(pks) == 0 {
+		return nil, nil, db.ErrMissingPrimaryKeys
+	}
+
+	return res, pks, nil
+}
+
+// keys returns a query that selects and locks the primary keys of the items
+// that DeleteReturning and UpdateReturning modify. At most one item is
+// modified when dst is not a slice.
+func (res *result) keys(sqlb db.SQL, pks []string, dst interface{}) db.Selector {
+	columns := make([]interface{}, len(pks))
+	for i := range pks {
+		columns[i] = pks[i]
+		if alias := keyAlias(pks[i]); alias != pks[i] {
+			columns[i] = pks[i] + " AS " + alias
+		}
+	}
+
+	limit := res.limit
+	if limit == 0 && !isSlicePtr(dst) {
+		limit = 1
+	}
+
+	sel := sqlb.Select(columns...).
+		From(res.table).
+		OrderBy(res.orderBy...).
+		Limit(limit).
+		Offset(res.offset)
+
+	if res.lock != nil {
+		sel = res.lock(sel)
+	} else {
+		sel = sel.ForUpdate()
+	}
+
+	for _, conds := range res.where() {
+		sel = sel.And(filter(conds)...)
+	}
+
+	return sel
+}
+
+// lockKeys runs the given query and returns a condition that matches the rows
+// with the selected keys, or nil if no rows were selected.
+func lockKeys(sel db.Selector, pks []string) (db.LogicalExpr, error) {
+	var rows []map[string]interface{}
+	if err := sel.All(&rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	if len(pks) == 1 {
+		values := make([]interface{}, len(rows))
+		for i := range rows {
+			values[i] = rows[i][keyAlias(pks[0])]
+		}
+		return db.Cond{pks[0]: db.In(values...)}, nil
+	}
+
+	conds := make([]db.LogicalExpr, len(rows))
+	for i := range rows {
+		cond := db.Cond{}
+		for _, pk := range pks {
+			cond[pk] = rows[i][keyAlias(pk)]
+		}
+		conds[i] = cond
+	}
+	return db.Or(conds...), nil
+}
+
+// scanReturned stores the rows of iter into dst, which is either a pointer to
+// a slice or a pointer to a single item.
+func scanReturned(iter db.Iterator, dst interface{}) error {
+	if isSlicePtr(dst) {
+		return iter.All(dst)
+	}
+	return iter.One(dst)
+}
+
+// noneReturned is like scanReturned for an empty set of rows.
+func noneReturned(dst interface{}) error {
+	if !isSlicePtr(dst) {
+		return db.ErrNoMoreRows
+	}
+	dstv := reflect.ValueOf(dst).Elem()
+	dstv.Set(reflect.MakeSlice(dstv.Type(), 0, 0))
+	return nil
+}
+
+func isSlicePtr(dst interface{}) bool {
+	dstv := reflect.ValueOf(dst)
+	return dstv.Kind() == reflect.Ptr && !dstv.IsNil() && dstv.Elem().Kind() == reflect.Slice
+}
+
 func (r *Result) buildUpdate(values interface{}) (db.Updater, error) {
 	if err := r.Err(); err != nil {
 		return nil, err
@@ -456,17 +1299,93 @@ func (r *Result) buildUpdate(values interface{}) (db.Updater, error) {
 		return nil, err
 	}
 
+	if len(res.joins) > 0 {
+		return nil, fmt.Errorf("%w: can't update a result set with joins", db.ErrUnsupported)
+	}
+
 	upd := r.SQL().Update(res.table).
 		Set(values).
 		Limit(res.limit)
 
-	for i := range res.conds {
-		upd = upd.And(filter(res.conds[i])...)
+	for _, conds := range res.where() {
+		upd = upd.And(filter(conds)...)
 	}
 
 	return upd, nil
 }
 
+func (r *Result) buildModify(mods []*db.Modifier) (db.Updater, error) {
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	res, err := r.fastForward()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.joins) > 0 {
+		return nil, fmt.Errorf("%w: can't update a result set with joins", db.ErrUnsupported)
+	}
+
+	// Modifiers grouped by column, in order of appearance.
+	columns := []string{}
+	columnMods := map[string][]*db.Modifier{}
+	for _, mod := range mods {
+		column, _ := mod.Field()
+		if _, ok := columnMods[column]; !ok {
+			columns = append(columns, column)
+		}
+		columnMods[column] = append(columnMods[column], mod)
+	}
+
+	values := make(map[string]interface{}, len(columns))
+	for _, column := range columns {
+		if value, ok := plainColumnValue(columnMods[column]); ok {
+			values[column] = value
+			continue
+		}
+		if res.documentModifier == nil {
+			return nil, fmt.Errorf("%w: nested fields and push modifiers", db.ErrUnsupported)
+		}
+		expr, err := res.documentModifier.ModifyColumn(column, columnMods[column])
+		if err != nil {
+			return nil, err
+		}
+		values[column] = expr
+	}
+
+	upd := r.SQL().Update(res.table).
+		Set(values).
+		Limit(res.limit)
+
+	for _, conds := range res.where() {
+		upd = upd.And(filter(conds)...)
+	}
+
+	return upd, nil
+}
+
+// plainColumnValue returns the value a column ends up with when all of its
+// modifiers set or unset the whole column.
+func plainColumnValue(mods []*db.Modifier) (interface{}, bool) {
+	var value interface{}
+	for _, mod := range mods {
+		if _, path := mod.Field(); len(path) > 0 {
+			return nil, false
+		}
+		switch mod.Op {
+		case db.ModifierSet:
+			value = mod.Value
+		case db.ModifierUnset:
+			value = nil
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
 func (r *Result) buildCount() (db.Selector, error) {
 	if err := r.Err(); err != nil {
 		return nil, err
@@ -477,15 +1396,122 @@ func (r *Result) buildCount() (db.Selector, error) {
 		return nil, err
 	}
 
-	sel := r.SQL().Select(db.Raw("count(1) AS _t")).
+	return res.buildAggregate(r.SQL(), db.Raw("count(1) AS _t")), nil
+}
+
+// buildAggregate returns a query that selects expr over the rows that match
+// the conditions of the result set.
+func (res *result) buildAggregate(sqlb db.SQL, expr interface{}) db.Selector {
+	sel := sqlb.Select(expr).
 		From(res.table).
 		GroupBy(res.groupBy...)
 
-	for i := range res.conds {
-		sel = sel.And(filter(res.conds[i])...)
+	sel = res.applyJoins(sel)
+
+	for _, conds := range res.where() {
+		sel = sel.And(filter(conds)...)
+	}
+
+	for i := range res.having {
+		sel = sel.Having(res.having[i]...)
+	}
+
+	return sel
+}
+
+// aggregate scans the value of fn applied to column into dst, fn is a format
+// string like "sum(%s)".
+func (r *Result) aggregate(fn string, column string, dst interface{}) error {
+	if err := r.Err(); err != nil {
+		return err
+	}
+
+	res, err := r.fastForward()
+	if err != nil {
+		return err
+	}
+	if res.template == nil {
+		return db.ErrUnsupported
+	}
+
+	col, err := exql.ColumnWithName(column).Compile(res.template)
+	if err != nil {
+		return err
 	}
 
-	return sel, nil
+	// The value is read through an iterator, so the masking policy of the
+	// session applies to it.
+	iter := res.buildAggregate(r.SQL(), db.Raw(fmt.Sprintf(fn, col)+" AS _t")).Iterator()
+	if err := iter.ScanOne(dst); err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return err
+		}
+		r.setErr(err)
+		return err
+	}
+	return nil
+}
+
+// Sum returns the sum of the values of the given column.
+func (r *Result) Sum(column string) (float64, error) {
+	return r.aggregateFloat("sum(%s)", column)
+}
+
+// Avg returns the average of the values of the given column.
+func (r *Result) Avg(column string) (float64, error) {
+	return r.aggregateFloat("avg(%s)", column)
+}
+
+func (r *Result) aggregateFloat(fn string, column string) (float64, error) {
+	var value sql.NullFloat64
+	if err := r.aggregate(fn, column, &value); err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return value.Float64, nil
+}
+
+// Min stores the smallest value of the given column into dst.
+func (r *Result) Min(column string, dst interface{}) error {
+	return r.aggregateValue("min(%s)", column, dst)
+}
+
+// Max stores the largest value of the given column into dst.
+func (r *Result) Max(column string, dst interface{}) error {
+	return r.aggregateValue("max(%s)", column, dst)
+}
+
+func (r *Result) aggregateValue(fn string, column string, dst interface{}) error {
+	dstv := reflect.ValueOf(dst)
+	if dstv.Kind() != reflect.Ptr || dstv.IsNil() {
+		return db.ErrUnsupportedDestination
+	}
+
+	// Scanning into a pointer to a pointer leaves it nil on NULL, which is
+	// what MIN and MAX return when there are no rows.
+	value := reflect.New(dstv.Type())
+	if err := r.aggregate(fn, column, value.Interface()); err != nil {
+		return err
+	}
+	if value.Elem().IsNil() {
+		return db.ErrNoMoreRows
+	}
+	dstv.Elem().Set(value.Elem().Elem())
+	return nil
+}
+
+// CountDistinct returns the number of distinct values of the given column.
+func (r *Result) CountDistinct(column string) (uint64, error) {
+	var count uint64
+	if err := r.aggregate("count(DISTINCT %s)", column, &count); err != nil {
+		if errors.Is(err, db.ErrNoMoreRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return count, nil
 }
 
 func (r *Result) Prev() immutable.Immutable {
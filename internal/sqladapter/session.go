@@ -7,25 +7,28 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"runtime/debug"
 	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/bufferpool"
 	"github.com/upper/db/v4/internal/cache"
+	"github.com/upper/db/v4/internal/queryctx"
 	"github.com/upper/db/v4/internal/sqladapter/compat"
 	"github.com/upper/db/v4/internal/sqladapter/exql"
 	"github.com/upper/db/v4/internal/sqlbuilder"
 )
 
 var (
-	lastSessID uint64
-	lastTxID   uint64
+	lastSessID  uint64
+	lastTxID    uint64
+	lastQueryID uint64
 )
 
 var (
-	slowQueryThreshold          = time.Millisecond * 200
 	retryTransactionWaitTime    = time.Millisecond * 10
 	retryTransactionMaxWaitTime = time.Second * 1
 )
@@ -58,6 +61,26 @@ type errorConverter interface {
 	Err(errIn error) (errOut error)
 }
 
+// savepointStatements allows the adapter to define its own statements to
+// create, release and roll back to a savepoint. An empty release statement
+// means savepoints don't need to be released.
+type savepointStatements interface {
+	SavepointStatements(name string) (create string, release string, rollback string)
+}
+
+// snapshotTransaction allows the adapter to define how read-only snapshot
+// transactions are opened: the options passed to BeginTx and a statement that
+// runs first within the transaction, if not empty.
+type snapshotTransaction interface {
+	SnapshotTransaction() (opts *sql.TxOptions, stmt string)
+}
+
+// blockedTransactionsCounter counts the transactions of the database that are
+// waiting for a lock held by another transaction.
+type blockedTransactionsCounter interface {
+	BlockedTransactions(sess Session) (int, error)
+}
+
 // AdapterSession defines methods to be implemented by SQL adapters.
 type AdapterSession interface {
 	Template() *exql.Template
@@ -84,9 +107,22 @@ type AdapterSession interface {
 type Session interface {
 	SQL() db.SQL
 
+	// Query stores the rows returned by a raw query into dst.
+	Query(dst interface{}, query interface{}, args ...interface{}) error
+
+	// Exec executes a raw statement that doesn't return rows.
+	Exec(query interface{}, args ...interface{}) (sql.Result, error)
+
 	// PrimaryKeys returns all primary keys on the table.
 	PrimaryKeys(tableName string) ([]string, error)
 
+	// CachedValue returns the value fn computes for key, computed once per
+	// session. Adapters use it for settings of the server.
+	CachedValue(key string, fn func() (interface{}, error)) (interface{}, error)
+
+	// Err converts a driver error into a db error, if the adapter knows how.
+	Err(errIn error) error
+
 	// Collections returns a list of references to all collections in the
 	// database.
 	Collections() ([]db.Collection, error)
@@ -156,8 +192,23 @@ type Session interface {
 
 	TxContext(ctx context.Context, fn func(sess db.Session) error, opts *sql.TxOptions) error
 
+	SnapshotRead(fn func(sess db.Session) error) error
+
+	Conn(fn func(sess db.Session) error) error
+
+	ConnContext(ctx context.Context, fn func(sess db.Session) error) error
+
 	WithContext(context.Context) db.Session
 
+	// Use appends middleware to the session's statement chain.
+	Use(...db.Middleware)
+
+	// SetBoolMapping sets how the session stores bool values.
+	SetBoolMapping(*db.BoolMapping)
+
+	// BoolMapping returns how the session stores bool values.
+	BoolMapping() *db.BoolMapping
+
 	IsTransaction() bool
 
 	Commit() error
@@ -175,6 +226,7 @@ func NewTx(adapter AdapterSession, tx *sql.Tx) (Session, error) {
 		sqlTx:             tx,
 		adapter:           adapter,
 		cachedPKs:         cache.NewCache(),
+		cachedValues:      cache.NewCache(),
 		cachedCollections: cache.NewCache(),
 		cachedStatements:  cache.NewCache(),
 	}
@@ -190,6 +242,7 @@ func NewSession(connURL db.ConnectionURL, adapter AdapterSession) Session {
 		connURL:           connURL,
 		adapter:           adapter,
 		cachedPKs:         cache.NewCache(),
+		cachedValues:      cache.NewCache(),
 		cachedCollections: cache.NewCache(),
 		cachedStatements:  cache.NewCache(),
 	}
@@ -209,20 +262,28 @@ type session struct {
 	lookupNameOnce sync.Once
 	name           string
 
-	mu        sync.Mutex // guards ctx, txOptions
-	ctx       context.Context
-	txOptions *sql.TxOptions
+	mu            sync.Mutex // guards ctx, txOptions, middleware, boolMapping, maskingPolicy
+	ctx           context.Context
+	txOptions     *sql.TxOptions
+	middleware    []db.Middleware
+	boolMapping   *db.BoolMapping
+	maskingPolicy *db.MaskingPolicy
 
-	sqlDBMu sync.Mutex // guards sess, baseTx
+	sqlDBMu sync.RWMutex // guards sqlDB, sqlConn and sqlTx
 
-	sqlDB *sql.DB
-	sqlTx *sql.Tx
+	sqlDB   *sql.DB
+	sqlConn *sql.Conn
+	sqlTx   *sql.Tx
 
 	sessID uint64
 	txID   uint64
 
+	// savepoint is the name of the savepoint the session is nested in, if any.
+	savepoint string
+
 	cacheMu           sync.Mutex // guards cachedStatements and cachedCollections
 	cachedPKs         *cache.Cache
+	cachedValues      *cache.Cache
 	cachedStatements  *cache.Cache
 	cachedCollections *cache.Cache
 
@@ -247,10 +308,116 @@ func (sess *session) TxContext(ctx context.Context, fn func(sess db.Session) err
 	return TxContext(ctx, sess, fn, opts)
 }
 
+func (sess *session) SnapshotRead(fn func(sess db.Session) error) error {
+	if sess.IsTransaction() {
+		return db.ErrAlreadyWithinTransaction
+	}
+
+	opts, stmt := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, ""
+	if s, ok := sess.adapter.(snapshotTransaction); ok {
+		opts, stmt = s.SnapshotTransaction()
+	}
+
+	return TxContext(sess.Context(), sess, func(tx db.Session) error {
+		if stmt != "" {
+			if _, err := tx.SQL().Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return fn(tx)
+	}, opts)
+}
+
+func (sess *session) Conn(fn func(sess db.Session) error) error {
+	return sess.ConnContext(sess.Context(), fn)
+}
+
+func (sess *session) ConnContext(ctx context.Context, fn func(sess db.Session) error) error {
+	if sess.pinnedConn() != nil || sess.IsTransaction() {
+		// Already bound to a single connection.
+		return fn(sess)
+	}
+
+	sqlDB := sess.DB()
+	if sqlDB == nil {
+		return db.ErrNotConnected
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return sess.Err(err)
+	}
+	defer conn.Close()
+
+	clone, err := sess.NewClone(sess.adapter, false)
+	if err != nil {
+		return err
+	}
+	pinned := clone.(*session)
+
+	pinned.sqlDBMu.Lock()
+	pinned.sqlConn = conn
+	pinned.sqlDBMu.Unlock()
+	pinned.SetContext(ctx)
+
+	// Statements prepared on the connection must be closed before it's
+	// returned to the pool.
+	defer pinned.cachedStatements.Clear()
+
+	return fn(pinned)
+}
+
+// pinnedConn returns the connection the session is bound to by ConnContext,
+// if any.
+func (sess *session) pinnedConn() *sql.Conn {
+	sess.sqlDBMu.RLock()
+	defer sess.sqlDBMu.RUnlock()
+
+	return sess.sqlConn
+}
+
+// sqlConnection is implemented by *sql.DB and *sql.Conn.
+type sqlConnection interface {
+	compat.Execer
+	compat.Queryer
+	compat.RowQueryer
+	compat.Preparer
+	compat.TxStarter
+}
+
+// conn returns the connection statements that are not part of a transaction
+// are sent to, which is either the pool or a pinned connection.
+func (sess *session) conn() sqlConnection {
+	sess.sqlDBMu.RLock()
+	defer sess.sqlDBMu.RUnlock()
+
+	if sess.sqlConn != nil {
+		return sess.sqlConn
+	}
+	if sess.sqlDB != nil {
+		return sess.sqlDB
+	}
+	return nil
+}
+
 func (sess *session) SQL() db.SQL {
 	return sess.builder
 }
 
+// Query stores the rows returned by the given query into dst, see
+// db.Session.Query.
+func (sess *session) Query(dst interface{}, query interface{}, args ...interface{}) error {
+	iter := sess.SQL().Iterator(query, args...)
+	if dstv := reflect.ValueOf(dst); dstv.Kind() == reflect.Ptr && dstv.Elem().Kind() == reflect.Slice {
+		return iter.All(dst)
+	}
+	return iter.One(dst)
+}
+
+func (sess *session) Exec(query interface{}, args ...interface{}) (sql.Result, error) {
+	return sess.SQL().Exec(query, args...)
+}
+
 func (sess *session) Err(errIn error) (errOur error) {
 	if convertError, ok := sess.adapter.(errorConverter); ok {
 		return convertError.Err(errIn)
@@ -258,6 +425,20 @@ func (sess *session) Err(errIn error) (errOur error) {
 	return errIn
 }
 
+// BlockedTransactions returns the number of transactions waiting for locks,
+// see db.BlockedTransactions.
+func (sess *session) BlockedTransactions() (int, error) {
+	counter, ok := sess.adapter.(blockedTransactionsCounter)
+	if !ok {
+		return 0, db.ErrUnsupported
+	}
+	n, err := counter.BlockedTransactions(sess)
+	if err != nil {
+		return 0, sess.Err(err)
+	}
+	return n, nil
+}
+
 func (sess *session) PrimaryKeys(tableName string) ([]string, error) {
 	h := cache.String(tableName)
 	cachedPK, ok := sess.cachedPKs.ReadRaw(h)
@@ -265,7 +446,7 @@ func (sess *session) PrimaryKeys(tableName string) ([]string, error) {
 		return cachedPK.([]string), nil
 	}
 
-	pk, err := sess.adapter.PrimaryKeys(sess, tableName)
+	pk, err := sess.adapter.PrimaryKeys(sess.unmasked(), tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -274,21 +455,173 @@ func (sess *session) PrimaryKeys(tableName string) ([]string, error) {
 	return pk, nil
 }
 
+// CachedValue returns the value fn computes for key. It is computed once per
+// session and shared with its clones, fn is called again if it fails.
+func (sess *session) CachedValue(key string, fn func() (interface{}, error)) (interface{}, error) {
+	h := cache.String(key)
+	if value, ok := sess.cachedValues.ReadRaw(h); ok {
+		return value, nil
+	}
+
+	value, err := fn()
+	if err != nil {
+		return nil, err
+	}
+
+	sess.cachedValues.Write(h, value)
+	return value, nil
+}
+
 func (sess *session) TableExists(name string) error {
-	return sess.adapter.TableExists(sess, name)
+	return sess.adapter.TableExists(sess.unmasked(), name)
+}
+
+func (sess *session) Use(middleware ...db.Middleware) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	chain := make([]db.Middleware, 0, len(sess.middleware)+len(middleware))
+	chain = append(chain, sess.middleware...)
+	sess.middleware = append(chain, middleware...)
+}
+
+func (sess *session) SetBoolMapping(mapping *db.BoolMapping) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	sess.boolMapping = mapping
+}
+
+func (sess *session) BoolMapping() *db.BoolMapping {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	return sess.boolMapping
+}
+
+// SetMaskingPolicy sets the policy that masks columns when rows are scanned,
+// see db.SetMaskingPolicy.
+func (sess *session) SetMaskingPolicy(policy *db.MaskingPolicy) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	sess.maskingPolicy = policy
+}
+
+// MaskingPolicy returns the policy set with SetMaskingPolicy, if any.
+func (sess *session) MaskingPolicy() *db.MaskingPolicy {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	return sess.maskingPolicy
+}
+
+// unmaskedSession runs statements on its session without masking the rows
+// they return.
+type unmaskedSession struct {
+	*session
+	builder db.SQL
+}
+
+func (sess *unmaskedSession) SQL() db.SQL {
+	return sess.builder
+}
+
+func (sess *unmaskedSession) MaskingPolicy() *db.MaskingPolicy {
+	return nil
+}
+
+// Unmasked returns a session that reads rows through sess without applying
+// its masking policy. Adapters use it to read the catalog of the database,
+// which has columns like "name" that policies are likely to mask.
+func Unmasked(sess db.Session) db.Session {
+	if s, ok := sess.(*session); ok {
+		return s.unmasked()
+	}
+	return sess
+}
+
+func (sess *session) unmasked() Session {
+	if sess.MaskingPolicy() == nil {
+		return sess
+	}
+	unmasked := &unmaskedSession{session: sess}
+	unmasked.builder = sqlbuilder.WithSession(unmasked, sess.adapter.Template())
+	return unmasked
+}
+
+func (sess *session) middlewareChain() []db.Middleware {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	return sess.middleware
+}
+
+// sendsPlainText returns true if statements have to be compiled and sent as
+// plain text instead of using the prepared statement cache, that's the case
+// when they pass through middleware or are prefixed with their query ID.
+func (sess *session) sendsPlainText() bool {
+	return len(sess.middlewareChain()) > 0 || sess.QueryIDCommentsEnabled()
+}
+
+// runMiddleware compiles stmt and passes the resulting query through the
+// session's middleware, fn is called at the end of the chain with the query
+// to execute. If the chain returns without fn having run the statement
+// successfully, db.ErrQuerySkipped is returned so callers never see a nil
+// result along with a nil error.
+func (sess *session) runMiddleware(ctx context.Context, queryID uint64, stmt *exql.Statement, args []interface{}, fn db.QueryHandler) (string, []interface{}, error) {
+	query, args, err := sess.compileStatement(stmt, args)
+	if err != nil {
+		return query, args, err
+	}
+
+	if sess.QueryIDCommentsEnabled() {
+		query = fmt.Sprintf("/* upper_query_id=%05d */ %s", queryID, query)
+	}
+	ctx = queryctx.WithQueryID(ctx, queryID)
+
+	var executed bool
+	var execErr error
+
+	handler := db.Chain(func(ctx context.Context, q string, a []interface{}) error {
+		query, args = q, a
+		err := fn(ctx, q, a)
+		if err != nil {
+			queryctx.SetError(ctx, sess.Err(err))
+			execErr = err
+			return err
+		}
+		executed = true
+		return nil
+	}, sess.middlewareChain()...)
+
+	if err := handler(ctx, query, args); err != nil {
+		return query, args, err
+	}
+	if !executed {
+		if execErr != nil {
+			// A middleware discarded the error of the statement.
+			return query, args, execErr
+		}
+		return query, args, db.ErrQuerySkipped
+	}
+	return query, args, nil
 }
 
 func (sess *session) NewTransaction(ctx context.Context, opts *sql.TxOptions) (Session, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	if sess.IsTransaction() {
+		return sess.newSavepoint(ctx)
+	}
 	clone, err := sess.NewClone(sess.adapter, false)
 	if err != nil {
 		return nil, err
 	}
 
 	connFn := func() error {
-		sqlTx, err := compat.BeginTx(clone.DB(), clone.Context(), opts)
+		sqlTx, err := compat.BeginTx(clone.(*session).conn(), clone.Context(), opts)
 		if err == nil {
 			return clone.BindTx(ctx, sqlTx)
 		}
@@ -303,7 +636,7 @@ func (sess *session) NewTransaction(ctx context.Context, opts *sql.TxOptions) (S
 }
 
 func (sess *session) Collections() ([]db.Collection, error) {
-	names, err := sess.adapter.Collections(sess)
+	names, err := sess.adapter.Collections(sess.unmasked())
 	if err != nil {
 		return nil, err
 	}
@@ -324,6 +657,16 @@ func (sess *session) Open() error {
 	var sqlDB *sql.DB
 	var err error
 
+	if err := db.ValidateConnectionURL(sess.connURL); err != nil {
+		return err
+	}
+
+	if u, ok := sess.connURL.(db.PoolConnectionURL); ok {
+		if err := db.ApplyPoolOptions(sess, u.PoolOptions()); err != nil {
+			return err
+		}
+	}
+
 	connFn := func() error {
 		sqlDB, err = sess.adapter.OpenDSN(sess, sess.connURL.String())
 		if err != nil {
@@ -336,11 +679,12 @@ func (sess *session) Open() error {
 		return nil
 	}
 
+	// Drivers may include the DSN in their errors.
 	if err := sess.WaitForConnection(connFn); err != nil {
-		return err
+		return db.RedactError(err, sess.connURL)
 	}
 
-	return sess.BindDB(sqlDB)
+	return db.RedactError(sess.BindDB(sqlDB), sess.connURL)
 }
 
 func (sess *session) Get(record db.Record, id interface{}) error {
@@ -425,6 +769,9 @@ func (sess *session) Delete(record db.Record) error {
 }
 
 func (sess *session) DB() *sql.DB {
+	sess.sqlDBMu.RLock()
+	defer sess.sqlDBMu.RUnlock()
+
 	return sess.sqlDB
 }
 
@@ -458,11 +805,47 @@ func (sess *session) TxOptions() *sql.TxOptions {
 	return sess.txOptions
 }
 
+// newSavepoint creates a session that is nested in the current transaction
+// by means of a savepoint.
+func (sess *session) newSavepoint(ctx context.Context) (Session, error) {
+	adapter, ok := sess.adapter.(db.AdapterCapabilities)
+	if !ok || !adapter.Capabilities().Has(db.CapabilitySavepoints) {
+		return nil, fmt.Errorf("%w: nested transactions require savepoints", db.ErrUnsupported)
+	}
+
+	clone, err := sess.NewClone(sess.adapter, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := clone.BindTx(ctx, sess.Transaction()); err != nil {
+		return nil, err
+	}
+
+	nested := clone.(*session)
+	nested.savepoint = fmt.Sprintf("upper_savepoint_%d", nested.txID)
+
+	create, _, _ := nested.savepointStatements()
+	if _, err := nested.SQL().ExecContext(ctx, create); err != nil {
+		return nil, err
+	}
+
+	return nested, nil
+}
+
+func (sess *session) savepointStatements() (create string, release string, rollback string) {
+	if s, ok := sess.adapter.(savepointStatements); ok {
+		return s.SavepointStatements(sess.savepoint)
+	}
+	return "SAVEPOINT " + sess.savepoint,
+		"RELEASE SAVEPOINT " + sess.savepoint,
+		"ROLLBACK TO SAVEPOINT " + sess.savepoint
+}
+
 func (sess *session) BindTx(ctx context.Context, tx *sql.Tx) error {
 	sess.sqlDBMu.Lock()
-	defer sess.sqlDBMu.Unlock()
-
 	sess.sqlTx = tx
+	sess.sqlDBMu.Unlock()
+
 	sess.SetContext(ctx)
 
 	sess.txID = newBaseTxID()
@@ -471,31 +854,47 @@ func (sess *session) BindTx(ctx context.Context, tx *sql.Tx) error {
 }
 
 func (sess *session) Commit() error {
-	if sess.sqlTx != nil {
-		return sess.sqlTx.Commit()
+	if sess.savepoint != "" {
+		_, release, _ := sess.savepointStatements()
+		if release == "" {
+			return nil
+		}
+		_, err := sess.SQL().Exec(release)
+		return err
+	}
+	if tx := sess.Transaction(); tx != nil {
+		return tx.Commit()
 	}
 	return db.ErrNotWithinTransaction
 }
 
 func (sess *session) Rollback() error {
-	if sess.sqlTx != nil {
-		return sess.sqlTx.Rollback()
+	if sess.savepoint != "" {
+		_, _, rollback := sess.savepointStatements()
+		_, err := sess.SQL().Exec(rollback)
+		return err
+	}
+	if tx := sess.Transaction(); tx != nil {
+		return tx.Rollback()
 	}
 	return db.ErrNotWithinTransaction
 }
 
 func (sess *session) IsTransaction() bool {
-	return sess.sqlTx != nil
+	return sess.Transaction() != nil
 }
 
 func (sess *session) Transaction() *sql.Tx {
+	sess.sqlDBMu.RLock()
+	defer sess.sqlDBMu.RUnlock()
+
 	return sess.sqlTx
 }
 
 func (sess *session) Name() string {
 	sess.lookupNameOnce.Do(func() {
 		if sess.name == "" {
-			sess.name, _ = sess.adapter.LookupName(sess)
+			sess.name, _ = sess.adapter.LookupName(sess.unmasked())
 		}
 	})
 
@@ -512,7 +911,7 @@ func (sess *session) BindDB(sqlDB *sql.DB) error {
 	}
 
 	sess.sessID = newSessionID()
-	name, err := sess.adapter.LookupName(sess)
+	name, err := sess.adapter.LookupName(sess.unmasked())
 	if err != nil {
 		return err
 	}
@@ -522,8 +921,8 @@ func (sess *session) BindDB(sqlDB *sql.DB) error {
 }
 
 func (sess *session) Ping() error {
-	if sess.sqlDB != nil {
-		return sess.sqlDB.Ping()
+	if sqlDB := sess.DB(); sqlDB != nil {
+		return sqlDB.Ping()
 	}
 	return db.ErrNotConnected
 }
@@ -555,6 +954,7 @@ func (sess *session) Reset() {
 	defer sess.cacheMu.Unlock()
 
 	sess.cachedPKs.Clear()
+	sess.cachedValues.Clear()
 	sess.cachedCollections.Clear()
 	sess.cachedStatements.Clear()
 
@@ -567,8 +967,10 @@ func (sess *session) NewClone(adapter AdapterSession, checkConn bool) (Session,
 	newSess := NewSession(sess.connURL, adapter).(*session)
 
 	newSess.name = sess.name
-	newSess.sqlDB = sess.sqlDB
+	newSess.sqlDB = sess.DB()
+	newSess.sqlConn = sess.pinnedConn()
 	newSess.cachedPKs = sess.cachedPKs
+	newSess.cachedValues = sess.cachedValues
 
 	if checkConn {
 		if err := newSess.Ping(); err != nil {
@@ -581,6 +983,9 @@ func (sess *session) NewClone(adapter AdapterSession, checkConn bool) (Session,
 
 	// New transaction should inherit parent settings
 	copySettings(sess, newSess)
+	newSess.Use(sess.middlewareChain()...)
+	newSess.SetBoolMapping(sess.BoolMapping())
+	newSess.SetMaskingPolicy(sess.MaskingPolicy())
 
 	return newSess, nil
 }
@@ -589,25 +994,27 @@ func (sess *session) Close() error {
 	defer func() {
 		sess.sqlDBMu.Lock()
 		sess.sqlDB = nil
+		sess.sqlConn = nil
 		sess.sqlTx = nil
 		sess.sqlDBMu.Unlock()
 	}()
 
-	if sess.sqlDB == nil {
+	sqlDB := sess.DB()
+	if sqlDB == nil {
 		return nil
 	}
 
 	sess.cachedCollections.Clear()
 	sess.cachedStatements.Clear() // Closes prepared statements as well.
 
-	if !sess.IsTransaction() {
+	if !sess.IsTransaction() && sess.pinnedConn() == nil {
 		if cleaner, ok := sess.adapter.(hasCleanUp); ok {
 			if err := cleaner.CleanUp(); err != nil {
 				return err
 			}
 		}
 		// Not within a transaction.
-		return sess.sqlDB.Close()
+		return sqlDB.Close()
 	}
 
 	return nil
@@ -630,30 +1037,27 @@ func (sess *session) Collection(name string) db.Collection {
 	return col
 }
 
-func queryLog(status *QueryStatus) {
-	diff := status.End.Sub(status.Start)
-
-	slowQuery := false
-	if diff >= slowQueryThreshold {
-		status.Err = db.ErrWarnSlowQuery
-		slowQuery = true
-	}
-
-	if status.Err != nil || slowQuery {
-		db.LC().Warn(status)
-		return
-	}
-
-	db.LC().Debug(status)
+func (sess *session) queryLog(status *QueryStatus) {
+	db.LogQuery(sess, status.Event())
 }
 
 func (sess *session) StatementPrepare(ctx context.Context, stmt *exql.Statement) (sqlStmt *sql.Stmt, err error) {
 	var query string
 
+	queryID := newQueryID()
+
+	defer func() {
+		// Wrapped after being logged, the log has the ID already.
+		if err != nil {
+			err = db.NewQueryError(queryID, err)
+		}
+	}()
+
 	defer func(start time.Time) {
-		queryLog(&QueryStatus{
+		sess.queryLog(&QueryStatus{
 			TxID:    sess.txID,
 			SessID:  sess.sessID,
+			QueryID: queryID,
 			Query:   query,
 			Err:     err,
 			Start:   start,
@@ -662,6 +1066,10 @@ func (sess *session) StatementPrepare(ctx context.Context, stmt *exql.Statement)
 		})
 	}(time.Now())
 
+	defer func() {
+		err = sess.Err(err)
+	}()
+
 	query, _, err = sess.compileStatement(stmt, nil)
 	if err != nil {
 		return nil, err
@@ -673,7 +1081,7 @@ func (sess *session) StatementPrepare(ctx context.Context, stmt *exql.Statement)
 		return
 	}
 
-	sqlStmt, err = compat.PrepareContext(sess.sqlDB, ctx, query)
+	sqlStmt, err = compat.PrepareContext(sess.conn(), ctx, query)
 	return
 }
 
@@ -684,13 +1092,63 @@ func (sess *session) ConvertValues(values []interface{}) []interface{} {
 	return values
 }
 
+// spendBudget counts a statement against the budget carried by ctx, see
+// db.WithBudget.
+func spendBudget(ctx context.Context) error {
+	if !queryctx.SpendQuery(ctx) {
+		return db.NewBudgetError(ctx, debug.Stack())
+	}
+	return nil
+}
+
+// warnRepeated logs a warning the first time the same statement runs more
+// times than allowed by the context, see db.DetectNPlusOne.
+func warnRepeated(ctx context.Context, stmt *exql.Statement, query string) {
+	if n, ok := queryctx.CountRepeat(ctx, stmt.Hash()); ok {
+		db.LC().Warnf("upper: possible N+1 query, ran %d times with the same context: %s\n%s", n, query, debug.Stack())
+	}
+}
+
+// accountWritten adds the rows written by a statement and the size of its
+// arguments to the usage tracked by the context, see db.TrackUsage.
+func accountWritten(ctx context.Context, rows int64, args []interface{}) {
+	if !queryctx.TracksUsage(ctx) {
+		return
+	}
+	var size int64
+	for _, arg := range args {
+		size += sqlbuilder.ValueSize(arg)
+	}
+	queryctx.AddWritten(ctx, rows, size)
+}
+
+// writes returns true for the statements that write rows. When they return
+// rows, as with RETURNING, the iterator accounts each returned row as written.
+func writes(stmt *exql.Statement) bool {
+	switch stmt.Type {
+	case exql.Insert, exql.Update, exql.Delete:
+		return true
+	}
+	return false
+}
+
 func (sess *session) StatementExec(ctx context.Context, stmt *exql.Statement, args ...interface{}) (res sql.Result, err error) {
 	var query string
 
+	queryID := newQueryID()
+
+	defer func() {
+		// Wrapped after being logged, the log has the ID already.
+		if err != nil {
+			err = db.NewQueryError(queryID, err)
+		}
+	}()
+
 	defer func(start time.Time) {
 		status := QueryStatus{
 			TxID:    sess.txID,
 			SessID:  sess.sessID,
+			QueryID: queryID,
 			Query:   query,
 			Args:    args,
 			Err:     err,
@@ -709,9 +1167,46 @@ func (sess *session) StatementExec(ctx context.Context, stmt *exql.Statement, ar
 			}
 		}
 
-		queryLog(&status)
+		sess.queryLog(&status)
+	}(time.Now())
+
+	defer func() {
+		err = sess.Err(err)
+	}()
+
+	if err = spendBudget(ctx); err != nil {
+		return
+	}
+	defer func(start time.Time) {
+		queryctx.SpendDuration(ctx, time.Since(start))
+		warnRepeated(ctx, stmt, query)
+		if err == nil && res != nil {
+			if rowsAffected, err := res.RowsAffected(); err == nil {
+				accountWritten(ctx, rowsAffected, args)
+			}
+		}
 	}(time.Now())
 
+	if sess.sendsPlainText() {
+		query, args, err = sess.runMiddleware(ctx, queryID, stmt, args, func(ctx context.Context, query string, args []interface{}) (err error) {
+			if execer, ok := sess.adapter.(statementExecer); ok {
+				res, err = execer.StatementExec(sess, ctx, query, args...)
+			} else if tx := sess.Transaction(); tx != nil {
+				res, err = compat.ExecContext(tx, ctx, query, args)
+			} else {
+				res, err = compat.ExecContext(sess.conn(), ctx, query, args)
+			}
+			if err != nil {
+				return err
+			}
+			if rowsAffected, err := res.RowsAffected(); err == nil {
+				queryctx.SetRowsAffected(ctx, rowsAffected)
+			}
+			return nil
+		})
+		return
+	}
+
 	if execer, ok := sess.adapter.(statementExecer); ok {
 		query, args, err = sess.compileStatement(stmt, args)
 		if err != nil {
@@ -743,7 +1238,7 @@ func (sess *session) StatementExec(ctx context.Context, stmt *exql.Statement, ar
 		return
 	}
 
-	res, err = compat.ExecContext(sess.sqlDB, ctx, query, args)
+	res, err = compat.ExecContext(sess.conn(), ctx, query, args)
 	return
 }
 
@@ -751,10 +1246,20 @@ func (sess *session) StatementExec(ctx context.Context, stmt *exql.Statement, ar
 func (sess *session) StatementQuery(ctx context.Context, stmt *exql.Statement, args ...interface{}) (rows *sql.Rows, err error) {
 	var query string
 
+	queryID := newQueryID()
+
+	defer func() {
+		// Wrapped after being logged, the log has the ID already.
+		if err != nil {
+			err = db.NewQueryError(queryID, err)
+		}
+	}()
+
 	defer func(start time.Time) {
 		status := QueryStatus{
 			TxID:    sess.txID,
 			SessID:  sess.sessID,
+			QueryID: queryID,
 			Query:   query,
 			Args:    args,
 			Err:     err,
@@ -762,11 +1267,38 @@ func (sess *session) StatementQuery(ctx context.Context, stmt *exql.Statement, a
 			End:     time.Now(),
 			Context: ctx,
 		}
-		queryLog(&status)
+		sess.queryLog(&status)
+	}(time.Now())
+
+	defer func() {
+		err = sess.Err(err)
+	}()
+
+	if err = spendBudget(ctx); err != nil {
+		return
+	}
+	defer func(start time.Time) {
+		queryctx.SpendDuration(ctx, time.Since(start))
+		warnRepeated(ctx, stmt, query)
+		if err == nil && writes(stmt) {
+			accountWritten(ctx, 0, args)
+		}
 	}(time.Now())
 
 	tx := sess.Transaction()
 
+	if sess.sendsPlainText() {
+		query, args, err = sess.runMiddleware(ctx, queryID, stmt, args, func(ctx context.Context, query string, args []interface{}) (err error) {
+			if tx != nil {
+				rows, err = compat.QueryContext(tx, ctx, query, args)
+				return
+			}
+			rows, err = compat.QueryContext(sess.conn(), ctx, query, args)
+			return
+		})
+		return
+	}
+
 	if sess.Settings.PreparedStatementCacheEnabled() && tx == nil {
 		var p *Stmt
 		if p, query, args, err = sess.prepareStatement(ctx, stmt, args); err != nil {
@@ -787,7 +1319,7 @@ func (sess *session) StatementQuery(ctx context.Context, stmt *exql.Statement, a
 		return
 	}
 
-	rows, err = compat.QueryContext(sess.sqlDB, ctx, query, args)
+	rows, err = compat.QueryContext(sess.conn(), ctx, query, args)
 	return
 
 }
@@ -797,10 +1329,20 @@ func (sess *session) StatementQuery(ctx context.Context, stmt *exql.Statement, a
 func (sess *session) StatementQueryRow(ctx context.Context, stmt *exql.Statement, args ...interface{}) (row *sql.Row, err error) {
 	var query string
 
+	queryID := newQueryID()
+
+	defer func() {
+		// Wrapped after being logged, the log has the ID already.
+		if err != nil {
+			err = db.NewQueryError(queryID, err)
+		}
+	}()
+
 	defer func(start time.Time) {
 		status := QueryStatus{
 			TxID:    sess.txID,
 			SessID:  sess.sessID,
+			QueryID: queryID,
 			Query:   query,
 			Args:    args,
 			Err:     err,
@@ -808,11 +1350,38 @@ func (sess *session) StatementQueryRow(ctx context.Context, stmt *exql.Statement
 			End:     time.Now(),
 			Context: ctx,
 		}
-		queryLog(&status)
+		sess.queryLog(&status)
+	}(time.Now())
+
+	defer func() {
+		err = sess.Err(err)
+	}()
+
+	if err = spendBudget(ctx); err != nil {
+		return
+	}
+	defer func(start time.Time) {
+		queryctx.SpendDuration(ctx, time.Since(start))
+		warnRepeated(ctx, stmt, query)
+		if err == nil && writes(stmt) {
+			accountWritten(ctx, 1, args)
+		}
 	}(time.Now())
 
 	tx := sess.Transaction()
 
+	if sess.sendsPlainText() {
+		query, args, err = sess.runMiddleware(ctx, queryID, stmt, args, func(ctx context.Context, query string, args []interface{}) (err error) {
+			if tx != nil {
+				row = compat.QueryRowContext(tx, ctx, query, args)
+				return
+			}
+			row = compat.QueryRowContext(sess.conn(), ctx, query, args)
+			return
+		})
+		return
+	}
+
 	if sess.Settings.PreparedStatementCacheEnabled() && tx == nil {
 		var p *Stmt
 		if p, query, args, err = sess.prepareStatement(ctx, stmt, args); err != nil {
@@ -833,20 +1402,29 @@ func (sess *session) StatementQueryRow(ctx context.Context, stmt *exql.Statement
 		return
 	}
 
-	row = compat.QueryRowContext(sess.sqlDB, ctx, query, args)
+	row = compat.QueryRowContext(sess.conn(), ctx, query, args)
 	return
 }
 
-// Driver returns the underlying *sql.DB or *sql.Tx instance.
+// Driver returns the underlying *sql.DB, *sql.Conn or *sql.Tx instance.
 func (sess *session) Driver() interface{} {
+	sess.sqlDBMu.RLock()
+	defer sess.sqlDBMu.RUnlock()
+
 	if sess.sqlTx != nil {
 		return sess.sqlTx
 	}
+	if sess.sqlConn != nil {
+		return sess.sqlConn
+	}
 	return sess.sqlDB
 }
 
 // compileStatement compiles the given statement into a string.
 func (sess *session) compileStatement(stmt *exql.Statement, args []interface{}) (string, []interface{}, error) {
+	if mapping := sess.BoolMapping(); mapping != nil {
+		args = mapBoolArgs(*mapping, args)
+	}
 	if converter, ok := sess.adapter.(valueConverter); ok {
 		args = converter.ConvertValues(args)
 	}
@@ -862,13 +1440,31 @@ func (sess *session) compileStatement(stmt *exql.Statement, args []interface{})
 	return query, args, nil
 }
 
+// mapBoolArgs returns a copy of args with bool values replaced by their
+// mapped representation.
+func mapBoolArgs(mapping db.BoolMapping, args []interface{}) []interface{} {
+	var mapped []interface{}
+	for i := range args {
+		b, ok := args[i].(bool)
+		if !ok {
+			continue
+		}
+		if mapped == nil {
+			mapped = make([]interface{}, len(args))
+			copy(mapped, args)
+		}
+		mapped[i] = mapping.Value(b)
+	}
+	if mapped == nil {
+		return args
+	}
+	return mapped
+}
+
 // prepareStatement compiles a query and tries to use previously generated
 // statement.
 func (sess *session) prepareStatement(ctx context.Context, stmt *exql.Statement, args []interface{}) (*Stmt, string, []interface{}, error) {
-	sess.sqlDBMu.Lock()
-	defer sess.sqlDBMu.Unlock()
-
-	sqlDB, tx := sess.sqlDB, sess.Transaction()
+	sqlDB, tx := sess.conn(), sess.Transaction()
 	if sqlDB == nil && tx == nil {
 		return nil, "", nil, db.ErrNotConnected
 	}
@@ -894,7 +1490,7 @@ func (sess *session) prepareStatement(ctx context.Context, stmt *exql.Statement,
 		if tx != nil {
 			return compat.PrepareContext(tx, ctx, *query)
 		}
-		return compat.PrepareContext(sess.sqlDB, ctx, *query)
+		return compat.PrepareContext(sqlDB, ctx, *query)
 	}(&query)
 	if err != nil {
 		return nil, "", nil, err
@@ -952,35 +1548,42 @@ func (sess *session) WaitForConnection(connectFn func() error) error {
 // ReplaceWithDollarSign turns a SQL statament with '?' placeholders into
 // dollar placeholders, like $1, $2, ..., $n
 func ReplaceWithDollarSign(in string) string {
-	buf := []byte(in)
-	out := make([]byte, 0, len(buf))
+	b := bufferpool.Get()
+	defer bufferpool.Put(b)
+
+	b.Grow(len(in))
 
-	i, j, k, t := 0, 1, 0, len(buf)
+	var num [20]byte
+	i, j, k, t := 0, 1, 0, len(in)
 
 	for i < t {
-		if buf[i] == '?' {
-			out = append(out, buf[k:i]...)
+		if in[i] == '?' {
+			b.WriteString(in[k:i])
 			k = i + 1
 
-			if k < t && buf[k] == '?' {
+			if k < t && in[k] == '?' {
 				i = k
 			} else {
-				out = append(out, []byte("$"+strconv.Itoa(j))...)
+				b.WriteByte('$')
+				b.Write(strconv.AppendInt(num[:0], int64(j), 10))
 				j++
 			}
 		}
 		i++
 	}
-	out = append(out, buf[k:i]...)
+	b.WriteString(in[k:i])
 
-	return string(out)
+	return b.String()
 }
 
 func copySettings(from Session, into Session) {
 	into.SetPreparedStatementCache(from.PreparedStatementCacheEnabled())
+	into.SetQueryIDComments(from.QueryIDCommentsEnabled())
 	into.SetConnMaxLifetime(from.ConnMaxLifetime())
 	into.SetMaxIdleConns(from.MaxIdleConns())
 	into.SetMaxOpenConns(from.MaxOpenConns())
+	into.SetQueryLogger(from.QueryLogger())
+	into.SetSlowQueryThreshold(from.SlowQueryThreshold())
 }
 
 func newSessionID() uint64 {
@@ -999,6 +1602,14 @@ func newBaseTxID() uint64 {
 	return atomic.AddUint64(&lastTxID, 1)
 }
 
+func newQueryID() uint64 {
+	if atomic.LoadUint64(&lastQueryID) == math.MaxUint64 {
+		atomic.StoreUint64(&lastQueryID, 0)
+		return 0
+	}
+	return atomic.AddUint64(&lastQueryID, 1)
+}
+
 var _ db.Session = &session{}
 
 // TxContext creates a transaction context and runs fn within it.
@@ -1010,6 +1621,14 @@ func TxContext(ctx context.Context, sess db.Session, fn func(tx db.Session) erro
 		}
 		defer tx.Close()
 
+		defer func() {
+			// Roll back if fn panics, then let the panic go on.
+			if p := recover(); p != nil {
+				_ = tx.Rollback()
+				panic(p)
+			}
+		}()
+
 		if err := fn(tx); err != nil {
 			if rollbackErr := tx.Rollback(); rollbackErr != nil {
 				return fmt.Errorf("%v: %w", rollbackErr, err)
@@ -25,6 +25,8 @@ package sqladapter
 import (
 	"database/sql"
 	"database/sql/driver"
+	"fmt"
+	"strings"
 
 	"github.com/upper/db/v4"
 	"github.com/upper/db/v4/internal/sqlbuilder"
@@ -47,6 +49,134 @@ func IsKeyValue(v interface{}) bool {
 	return false
 }
 
+// UpsertColumns returns the columns an upsert should overwrite when the new
+// row conflicts with an existing one: every column that is not a conflict
+// column. If all the columns are conflict columns those are returned instead,
+// so the conflicting row is still touched by the statement.
+func UpsertColumns(columnNames []string, conflictColumns []string) []string {
+	isConflict := make(map[string]bool, len(conflictColumns))
+	for i := range conflictColumns {
+		isConflict[conflictColumns[i]] = true
+	}
+
+	columns := make([]string, 0, len(columnNames))
+	for i := range columnNames {
+		if !isConflict[columnNames[i]] {
+			columns = append(columns, columnNames[i])
+		}
+	}
+	if len(columns) == 0 {
+		return conflictColumns
+	}
+	return columns
+}
+
+// UpsertUsingLastInsertID implements Upsert for adapters whose drivers don't
+// support RETURNING and report generated keys with LastInsertId(). The
+// OnConflict clause is compiled by the adapter's template.
+//
+// rowInserted tells whether the statement inserted a new row, as opposed to
+// updating an existing one. It's nil when the conflict clause only catches
+// conflicts on conflictColumns, as other conflicts fail the statement and
+// any item that lacks one of those columns must have been inserted.
+func UpsertUsingLastInsertID(col Collection, item interface{}, conflictColumns []string, rowInserted func(sql.Result) bool) (interface{}, error) {
+	columnNames, columnValues, err := sqlbuilder.Map(item, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	q := col.SQL().InsertInto(col.Name()).
+		Columns(columnNames...).
+		Values(columnValues...).
+		OnConflict(conflictColumns...).
+		DoUpdate(UpsertColumns(columnNames, conflictColumns)...)
+
+	res, err := q.Exec()
+	if err != nil {
+		return nil, err
+	}
+
+	pKey := col.PrimaryKeys()
+	if len(pKey) == 0 {
+		return nil, nil
+	}
+
+	keyMap := db.Cond{}
+	conflictMap := db.Cond{}
+	for i := range columnNames {
+		for j := 0; j < len(pKey); j++ {
+			if pKey[j] == columnNames[i] {
+				keyMap[pKey[j]] = columnValues[i]
+			}
+		}
+		for j := 0; j < len(conflictColumns); j++ {
+			if conflictColumns[j] == columnNames[i] {
+				conflictMap[conflictColumns[j]] = columnValues[i]
+			}
+		}
+	}
+
+	if len(keyMap) == len(pKey) {
+		// The item carries its own primary key.
+		if len(keyMap) == 1 {
+			return keyMap[pKey[0]], nil
+		}
+		return keyMap, nil
+	}
+
+	inserted := len(conflictMap) < len(conflictColumns)
+	if rowInserted != nil {
+		inserted = rowInserted(res)
+	}
+	if inserted {
+		return res.LastInsertId()
+	}
+	if len(conflictMap) < len(conflictColumns) {
+		// The row conflicted on a key other than conflictColumns, there's no
+		// way to tell which row was updated.
+		return nil, nil
+	}
+
+	// LastInsertId() is not reliable when the row was updated, let's search
+	// for it.
+	fields := make([]interface{}, len(pKey))
+	for i := range pKey {
+		fields[i] = pKey[i]
+	}
+
+	keyMap = db.Cond{}
+	if err := col.Find(conflictMap).Select(fields...).One(&keyMap); err != nil {
+		return nil, err
+	}
+
+	if len(keyMap) == 1 {
+		return keyMap[pKey[0]], nil
+	}
+
+	return keyMap, nil
+}
+
+// TriggerBlock wraps the statements of a trigger body in a BEGIN ... END
+// block, the last statement is terminated if it isn't.
+func TriggerBlock(body string) string {
+	body = strings.TrimSpace(body)
+	if body != "" && !strings.HasSuffix(body, ";") {
+		body += ";"
+	}
+	return "BEGIN " + body + " END"
+}
+
+// TriggerBody returns the statements of a BEGIN ... END block, as created by
+// TriggerBlock. Other bodies are returned as they are.
+func TriggerBody(block string) string {
+	body := strings.TrimSpace(block)
+	upper := strings.ToUpper(body)
+	if strings.HasPrefix(upper, "BEGIN") && strings.HasSuffix(upper, "END") {
+		body = strings.TrimSpace(body[len("BEGIN") : len(body)-len("END")])
+	}
+	return body
+}
+
 type sqlAdapterWrapper struct {
 	adapter AdapterSession
 }
@@ -75,7 +205,26 @@ func (w *sqlAdapterWrapper) New(sqlDB *sql.DB) (db.Session, error) {
 	return sess, nil
 }
 
-// RegisterAdapter registers a new SQL adapter.
+func (w *sqlAdapterWrapper) Capabilities() db.Capability {
+	if ac, ok := w.adapter.(db.AdapterCapabilities); ok {
+		return ac.Capabilities()
+	}
+	return db.CapabilityNone
+}
+
+func (w *sqlAdapterWrapper) ParseURL(s string) (db.ConnectionURL, error) {
+	if p, ok := w.adapter.(db.AdapterURLParser); ok {
+		return p.ParseURL(s)
+	}
+	return nil, fmt.Errorf("%w: parsing connection URLs", db.ErrUnsupported)
+}
+
+func (w *sqlAdapterWrapper) SQL() db.SQL {
+	return sqlbuilder.WithTemplate(w.adapter.Template())
+}
+
+// RegisterAdapter registers a new SQL adapter. Adapters may implement
+// db.AdapterCapabilities to declare which optional features they support.
 func RegisterAdapter(name string, adapter AdapterSession) sqlbuilder.Adapter {
 	z := &sqlAdapterWrapper{adapter}
 	db.RegisterAdapter(name, sqlbuilder.NewCompatAdapter(z))
@@ -45,3 +45,12 @@ func TestReplaceWithDollarSign(t *testing.T) {
 		assert.Equal(t, test.out, ReplaceWithDollarSign(test.in))
 	}
 }
+
+func TestTriggerBlock(t *testing.T) {
+	assert.Equal(t, "BEGIN NEW.n = 1; END", TriggerBlock("NEW.n = 1"))
+	assert.Equal(t, "BEGIN NEW.n = 1; RETURN NEW; END", TriggerBlock(" NEW.n = 1; RETURN NEW; "))
+
+	assert.Equal(t, "NEW.n = 1;", TriggerBody(TriggerBlock("NEW.n = 1")))
+	assert.Equal(t, "NEW.n = 1;", TriggerBody("begin\n  NEW.n = 1;\nend"))
+	assert.Equal(t, "SET NEW.n = 1", TriggerBody("SET NEW.n = 1"))
+}
@@ -19,8 +19,9 @@ type Stmt struct {
 	query string
 	mu    sync.Mutex
 
-	count int64
-	dead  bool
+	count  int64
+	dead   bool
+	closed bool
 }
 
 // NewStatement creates an returns an opened statement
@@ -57,8 +58,9 @@ func (c *Stmt) Close() error {
 }
 
 func (c *Stmt) checkClose() error {
-	if c.dead && c.count == 0 {
+	if c.dead && c.count == 0 && !c.closed {
 		// Statement is dead and we can close it for real.
+		c.closed = true
 		err := c.Stmt.Close()
 		if err != nil {
 			return err
@@ -25,6 +25,7 @@ package sqlbuilder
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -36,6 +37,7 @@ import (
 
 	db "github.com/upper/db/v4"
 	"github.com/upper/db/v4/internal/adapter"
+	"github.com/upper/db/v4/internal/bufferpool"
 	"github.com/upper/db/v4/internal/reflectx"
 	"github.com/upper/db/v4/internal/sqladapter/compat"
 	"github.com/upper/db/v4/internal/sqladapter/exql"
@@ -57,14 +59,55 @@ type compilable interface {
 	Arguments() []interface{}
 }
 
+// subquery is implemented by result sets of SQL adapters, the SELECT
+// statement of a result set can be used wherever a selector can.
+type subquery interface {
+	Subquery() (db.Paginator, error)
+}
+
+// asSubquery returns the statement of v if v is a result set, or v itself
+// otherwise.
+func asSubquery(v interface{}) (interface{}, error) {
+	if sq, ok := v.(subquery); ok {
+		return sq.Subquery()
+	}
+	return v, nil
+}
+
+// isSubquery returns true if v is a statement that must be wrapped in
+// parentheses to be used as a value.
+func isSubquery(v interface{}) bool {
+	switch v.(type) {
+	case db.Selector, db.Paginator, subquery:
+		return true
+	}
+	return false
+}
+
 type hasIsZero interface {
 	IsZero() bool
 }
 
 type iterator struct {
 	sess   exprDB
-	cursor *sql.Rows // This is the main query cursor. It starts as a nil value.
+	ctx    context.Context // The context of the query, the session's if nil.
+	cursor *sql.Rows       // This is the main query cursor. It starts as a nil value.
 	err    error
+
+	// Rows come from a statement that writes them, like INSERT ... RETURNING.
+	written bool
+
+	// Column names, column types and scan destinations are read once and
+	// reused for every row of the cursor.
+	cols        []string
+	types       []string
+	typesLoaded bool
+	values      []interface{}
+
+	// The SQL text of the query and how the masking policy of the session
+	// applies to each column, only set if the session has a policy.
+	query    string
+	colMasks []columnMask
 }
 
 type fieldValue struct {
@@ -117,7 +160,7 @@ func WithTemplate(t *exql.Template) db.SQL {
 }
 
 func (b *sqlBuilder) NewIteratorContext(ctx context.Context, rows *sql.Rows) db.Iterator {
-	return &iterator{b.sess, rows, nil}
+	return &iterator{sess: b.sess, ctx: ctx, cursor: rows}
 }
 
 func (b *sqlBuilder) NewIterator(rows *sql.Rows) db.Iterator {
@@ -130,7 +173,7 @@ func (b *sqlBuilder) Iterator(query interface{}, args ...interface{}) db.Iterato
 
 func (b *sqlBuilder) IteratorContext(ctx context.Context, query interface{}, args ...interface{}) db.Iterator {
 	rows, err := b.QueryContext(ctx, query, args...)
-	return &iterator{b.sess, rows, err}
+	return &iterator{sess: b.sess, ctx: ctx, cursor: rows, err: err, query: maskedQuery(b.sess, b.t.Template, query)}
 }
 
 func (b *sqlBuilder) Prepare(query interface{}) (*sql.Stmt, error) {
@@ -236,7 +279,72 @@ func (b *sqlBuilder) Update(table string) db.Updater {
 	return qu.setTable(table)
 }
 
+// mapBool returns the value that represents fld, a bool or *bool field, with
+// the mapping given by its bool tag option.
+func mapBool(fi *structField, fld reflect.Value) (interface{}, error) {
+	if fi.boolErr != nil {
+		return nil, fi.boolErr
+	}
+	fld = reflect.Indirect(fld)
+	if fld.Kind() != reflect.Bool {
+		return nil, fmt.Errorf("upper: the bool tag option can't be used on %v fields", fld.Type())
+	}
+	return fi.boolMapping.Value(fld.Bool()), nil
+}
+
+// marshalJSONField returns the JSON encoding of a field with the json tag
+// option. Nil pointers, maps and slices are written as NULL.
+func marshalJSONField(fld reflect.Value) (interface{}, error) {
+	switch fld.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if fld.IsNil() {
+			return nil, nil
+		}
+	}
+	buf, err := json.Marshal(fld.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return string(buf), nil
+}
+
+// TTLColumn returns the name of the column that holds the expiration time of
+// item, a struct or a pointer, slice or array of structs, given by a field
+// with the ttl tag option.
+func TTLColumn(item interface{}) (string, bool) {
+	t := reflect.TypeOf(item)
+	for t != nil {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+			t = t.Elem()
+			continue
+		case reflect.Struct:
+			if column := fieldsOf(t).ttlColumn; column != "" {
+				return column, true
+			}
+		}
+		break
+	}
+	return "", false
+}
+
+// isZeroValue reports whether fld holds the zero value of its type, an empty
+// slice or a value whose IsZero method returns true.
+func isZeroValue(fld reflect.Value) bool {
+	if t, ok := fld.Interface().(hasIsZero); ok {
+		return t.IsZero()
+	}
+	if fld.Kind() == reflect.Array || fld.Kind() == reflect.Slice {
+		return fld.Len() == 0
+	}
+	return reflect.DeepEqual(reflect.Zero(fld.Type()).Interface(), fld.Interface())
+}
+
 // Map receives a pointer to map or struct and maps it to columns and values.
+//
+// Struct fields with the zeronil tag option (`db:"name,zeronil"`) are mapped
+// to NULL when they hold a zero value, or a pointer to one. Use the nullzero
+// option to read NULLs back as zero values.
 func Map(item interface{}, options *MapOptions) ([]string, []interface{}, error) {
 	var fv fieldValue
 	if options == nil {
@@ -260,21 +368,21 @@ func Map(item interface{}, options *MapOptions) ([]string, []interface{}, error)
 
 	switch itemT.Kind() {
 	case reflect.Struct:
-		fieldMap := Mapper.TypeMap(itemT).Names
-		nfields := len(fieldMap)
+		fields := fieldsOf(itemT).fields
+		nfields := len(fields)
 
 		fv.values = make([]interface{}, 0, nfields)
 		fv.fields = make([]string, 0, nfields)
 
-		for _, fi := range fieldMap {
+		for _, fi := range fields {
 
 			// Check for deprecated JSONB tag
-			if _, hasJSONBTag := fi.Options["jsonb"]; hasJSONBTag {
+			if fi.jsonb {
 				return nil, nil, errDeprecatedJSONBTag
 			}
 
 			// Field options
-			_, tagOmitEmpty := fi.Options["omitempty"]
+			tagOmitEmpty, tagZeroNil := fi.omitEmpty, fi.zeroNil
 
 			fld := reflectx.FieldByIndexesReadOnly(itemV, fi.Index)
 			if fld.Kind() == reflect.Ptr && fld.IsNil() {
@@ -292,23 +400,34 @@ func Map(item interface{}, options *MapOptions) ([]string, []interface{}, error)
 
 			value := fld.Interface()
 
-			isZero := false
-			if t, ok := fld.Interface().(hasIsZero); ok {
-				if t.IsZero() {
-					isZero = true
-				}
-			} else if fld.Kind() == reflect.Array || fld.Kind() == reflect.Slice {
-				if fld.Len() == 0 {
-					isZero = true
-				}
-			} else if reflect.DeepEqual(fi.Zero.Interface(), value) {
-				isZero = true
-			}
+			isZero := isZeroValue(fld)
 
 			if isZero && tagOmitEmpty && !options.IncludeZeroed {
 				continue
 			}
 
+			if tagZeroNil && (isZero || fld.Kind() == reflect.Ptr && isZeroValue(fld.Elem())) {
+				fv.fields = append(fv.fields, fi.Name)
+				fv.values = append(fv.values, nil)
+				continue
+			}
+
+			if fi.json {
+				encoded, err := marshalJSONField(fld)
+				if err != nil {
+					return nil, nil, err
+				}
+				value = encoded
+			}
+
+			if fi.boolMapping != nil || fi.boolErr != nil {
+				mapped, err := mapBool(fi, fld)
+				if err != nil {
+					return nil, nil, err
+				}
+				value = mapped
+			}
+
 			fv.fields = append(fv.fields, fi.Name)
 			v, err := marshal(value)
 			if err != nil {
@@ -337,12 +456,13 @@ func Map(item interface{}, options *MapOptions) ([]string, []interface{}, error)
 
 			fv.values[i] = v
 		}
+
+		// Map keys come in random order, struct fields are sorted already.
+		sort.Sort(&fv)
 	default:
 		return nil, nil, ErrExpectingPointerToEitherMapOrStruct
 	}
 
-	sort.Sort(&fv)
-
 	return fv.fields, fv.values, nil
 }
 
@@ -352,14 +472,18 @@ func columnFragments(columns []interface{}) ([]exql.Fragment, []interface{}, err
 	args := []interface{}{}
 
 	for i := 0; i < l; i++ {
-		switch v := columns[i].(type) {
+		column, err := asSubquery(columns[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		switch v := column.(type) {
 		case compilable:
 			c, err := v.Compile()
 			if err != nil {
 				return nil, nil, err
 			}
 			q, a := Preprocess(c, v.Arguments())
-			if _, ok := v.(db.Selector); ok {
+			if isSubquery(v) {
 				q = "(" + q + ")"
 			}
 			f[i] = exql.RawValue(q)
@@ -394,17 +518,24 @@ func columnFragments(columns []interface{}) ([]exql.Fragment, []interface{}, err
 }
 
 func prepareQueryForDisplay(in string) (out string) {
+	b := bufferpool.Get()
+	defer bufferpool.Put(b)
+
+	b.Grow(len(in))
+
+	var num [20]byte
 	j := 1
-	for i := range in {
+	for i := 0; i < len(in); i++ {
 		if in[i] == '?' {
-			out = out + "$" + strconv.Itoa(j)
+			b.WriteByte('$')
+			b.Write(strconv.AppendInt(num[:0], int64(j), 10))
 			j++
 		} else {
-			out = out + string(in[i])
+			b.WriteByte(in[i])
 		}
 	}
 
-	out = reInvisibleChars.ReplaceAllString(out, ` `)
+	out = reInvisibleChars.ReplaceAllString(b.String(), ` `)
 	return strings.TrimSpace(out)
 }
 
@@ -427,7 +558,56 @@ func (iter *iterator) Scan(dst ...interface{}) error {
 	if err := iter.Err(); err != nil {
 		return err
 	}
-	return iter.cursor.Scan(dst...)
+	if err := iter.cursor.Scan(dst...); err != nil {
+		return err
+	}
+	if maskingPolicy(iter.sess) == nil {
+		return nil
+	}
+	columns, err := iter.columns()
+	if err != nil {
+		return err
+	}
+	for i, mask := range iter.masks(columns) {
+		if i >= len(dst) || !mask.masked {
+			continue
+		}
+		if v := reflect.ValueOf(dst[i]); v.Kind() == reflect.Ptr && !v.IsNil() {
+			mask.applyTo(v.Elem())
+		}
+	}
+	return nil
+}
+
+func (iter *iterator) columns() ([]string, error) {
+	if iter.cols == nil {
+		cols, err := iter.cursor.Columns()
+		if err != nil {
+			return nil, err
+		}
+		iter.cols = cols
+	}
+	return iter.cols, nil
+}
+
+func (iter *iterator) columnTypes() ([]string, error) {
+	if !iter.typesLoaded {
+		types, err := columnTypes(iter.cursor)
+		if err != nil {
+			return nil, err
+		}
+		iter.types, iter.typesLoaded = types, true
+	}
+	return iter.types, nil
+}
+
+// scanValues returns a slice of n scan destinations that is shared by all
+// the rows of the cursor.
+func (iter *iterator) scanValues(n int) []interface{} {
+	if cap(iter.values) < n {
+		iter.values = make([]interface{}, n)
+	}
+	return iter.values[:n]
 }
 
 func (iter *iterator) setErr(err error) error {
@@ -457,6 +637,19 @@ func (iter *iterator) All(dst interface{}) error {
 	return nil
 }
 
+func (iter *iterator) AllColumns(dst interface{}) error {
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	if err := fetchColumns(iter, dst); err != nil {
+		return iter.setErr(err)
+	}
+
+	return nil
+}
+
 func (iter *iterator) Err() (err error) {
 	return iter.err
 }
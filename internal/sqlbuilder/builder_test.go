@@ -1,12 +1,17 @@
 package sqlbuilder
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/reflectx"
+	"github.com/upper/db/v4/internal/sqladapter/exql"
 )
 
 func TestSelect(t *testing.T) {
@@ -257,6 +262,78 @@ func TestSelect(t *testing.T) {
 		)
 	}
 
+	{
+		cond := db.Precompile(
+			db.Cond{"age >=": 18},
+			db.Or(db.Cond{"status": "active"}, db.Cond{"status": "trial"}),
+		)
+
+		for i := 0; i < 2; i++ {
+			q := b.Select().From("artist").Where(cond).And(db.Cond{"id >": i})
+			assert.Equal(
+				`SELECT * FROM "artist" WHERE (("age" >= $1 AND ("status" = $2 OR "status" = $3)) AND "id" > $4)`,
+				q.String(),
+			)
+			assert.Equal(
+				[]interface{}{18, "active", "trial", i},
+				q.Arguments(),
+			)
+		}
+	}
+
+	{
+		q := b.Select().From("artist").Where(
+			db.Or(
+				db.Cond{"id": 1},
+				db.And(
+					db.Cond{"name": "John", "age >": 18},
+					db.Raw("group_id = ? OR group_id IS NULL", 2),
+					db.Not(
+						db.Or(db.Cond{"status": "banned"}, db.Cond{"status": "deleted"}),
+					),
+				),
+			),
+		)
+		assert.Equal(
+			`SELECT * FROM "artist" WHERE (("id" = $1 OR ("age" > $2 AND "name" = $3 AND (group_id = $4 OR group_id IS NULL) AND NOT (("status" = $5 OR "status" = $6)))))`,
+			q.String(),
+		)
+		assert.Equal(
+			[]interface{}{1, 18, "John", 2, "banned", "deleted"},
+			q.Arguments(),
+		)
+	}
+
+	assert.Equal(
+		`SELECT * FROM "artist" WHERE (NOT ("age" > $1 AND "name" = $2))`,
+		b.Select().From("artist").Where(db.Not(db.Cond{"name": "John", "age >": 18})).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" WHERE ("id" > $1 AND NOT (name LIKE $2))`,
+		b.Select().From("artist").Where(db.Cond{"id >": 1}).And(db.Not(db.Raw("name LIKE ?", "J%"))).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist"`,
+		b.Select().From("artist").Where(db.Not()).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" WHERE ("age" >= $1 AND "id" NOT IN ($2, $3))`,
+		b.Select().From("artist").Where(
+			db.Compare("age", db.OpGte, 18),
+			db.Compare("id", db.OpNotIn, []int{1, 2}),
+		).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" WHERE (("id" = $1 OR "created_at" BETWEEN $2 AND $3))`,
+		b.Select().From("artist").Where(
+			db.Or(db.Cond{"id": 1}, db.Compare("created_at", db.OpBetween, []int{1, 2})),
+		).String(),
+	)
+
 	assert.Equal(
 		`SELECT * FROM "artist" WHERE ((("id" = $1 OR "id" = $2 OR "id" IS NULL) OR ("name" = $3 OR "name" = $4)))`,
 		b.Select().From("artist").Where(
@@ -361,6 +438,21 @@ func TestSelect(t *testing.T) {
 		b.Select().From("artist").Limit(1).Offset(5).String(),
 	)
 
+	assert.Equal(
+		`SELECT * FROM "artist" WHERE ("id" = $1) FOR UPDATE`,
+		b.Select().From("artist").Where("id", 1).ForUpdate().String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" LIMIT 1 FOR UPDATE NOWAIT`,
+		b.Select().From("artist").Limit(1).ForUpdate(db.NoWait).String(),
+	)
+
+	assert.Equal(
+		`SELECT * FROM "artist" FOR SHARE SKIP LOCKED`,
+		b.Select().From("artist").ForShare(db.SkipLocked).String(),
+	)
+
 	assert.Equal(
 		`SELECT "id" FROM "artist"`,
 		b.Select("id").From("artist").String(),
@@ -386,6 +478,11 @@ func TestSelect(t *testing.T) {
 		b.Select("id").From("artist").Where(`name LIKE ? OR name LIKE ?`, `%Miya%`, `F%`).String(),
 	)
 
+	assert.Equal(
+		`SELECT * FROM "artist" WHERE (LOWER("name") LIKE LOWER($1) AND LOWER("name") NOT LIKE LOWER($2))`,
+		b.SelectFrom("artist").Where(db.Cond{"name": db.ILike("%f%")}).And(db.Cond{"name": db.NotILike("%z%")}).String(),
+	)
+
 	assert.Equal(
 		`SELECT * FROM "artist" WHERE ("id" > $1)`,
 		b.SelectFrom("artist").Where("id >", 2).String(),
@@ -459,6 +556,11 @@ func TestSelect(t *testing.T) {
 			String(),
 	)
 
+	assert.Equal(
+		`SELECT * FROM "artist" AS "a" INNER JOIN "publication" AS "p" ON (p.author_id = a.id)`,
+		b.SelectFrom("artist a").InnerJoin("publication p").On("p.author_id = a.id").String(),
+	)
+
 	assert.Equal(
 		`SELECT * FROM "artist" CROSS JOIN "publication"`,
 		b.SelectFrom("artist").CrossJoin("publication").String(),
@@ -869,6 +971,77 @@ func TestSelect(t *testing.T) {
 		)
 
 	}
+
+	{
+		sel := b.Select("author_id", db.Raw("COUNT(1) AS total")).
+			From("book").
+			Where(db.Cond{"year >": 2000}).
+			GroupBy("author_id").
+			Having(db.Raw("COUNT(1) > ?", 3)).
+			Having(db.Cond{"author_id <>": 7}).
+			OrderBy("-total")
+		assert.Equal(
+			`SELECT "author_id", COUNT(1) AS total FROM "book" WHERE ("year" > $1) GROUP BY "author_id" HAVING (COUNT(1) > $2 AND "author_id" <> $3) ORDER BY "total" DESC`,
+			sel.String(),
+		)
+		assert.Equal([]interface{}{2000, 3, 7}, sel.Arguments())
+	}
+
+	{
+		authors := b.Select("id").From("author").Where(db.Cond{"country": "JP"})
+
+		sel := b.SelectFrom("book").Where(db.Cond{"author_id IN": authors, "year >": 2000})
+		assert.Equal(
+			`SELECT * FROM "book" WHERE ("author_id" IN (SELECT "id" FROM "author" WHERE ("country" = $1)) AND "year" > $2)`,
+			sel.String(),
+		)
+		assert.Equal([]interface{}{"JP", 2000}, sel.Arguments())
+
+		sel = b.SelectFrom("book").Where(db.Cond{"year >": 2000, "author_id": db.NotIn(authors)})
+		assert.Equal(
+			`SELECT * FROM "book" WHERE ("author_id" NOT IN (SELECT "id" FROM "author" WHERE ("country" = $1)) AND "year" > $2)`,
+			sel.String(),
+		)
+		assert.Equal([]interface{}{"JP", 2000}, sel.Arguments())
+
+		sel = b.SelectFrom("author").Where(
+			db.Cond{"country": "MX"},
+			db.Exists(b.Select(db.Raw("1")).From("book").Where("book.author_id = author.id AND book.year > ?", 2000)),
+		)
+		assert.Equal(
+			`SELECT * FROM "author" WHERE ("country" = $1 AND EXISTS (SELECT 1 FROM "book" WHERE (book.author_id = author.id AND book.year > $2)))`,
+			sel.String(),
+		)
+		assert.Equal([]interface{}{"MX", 2000}, sel.Arguments())
+
+		sel = b.SelectFrom("author").Where(db.NotExists(b.SelectFrom("book").Where("book.author_id = author.id")))
+		assert.Equal(
+			`SELECT * FROM "author" WHERE (NOT EXISTS (SELECT * FROM "book" WHERE (book.author_id = author.id)))`,
+			sel.String(),
+		)
+	}
+
+	{
+		recent := b.Select("id", "title").From("book").Where(db.Cond{"year >": 2000})
+
+		sel := b.SelectFrom("recent").
+			With("recent", recent).
+			Where(db.Cond{"title LIKE": "A%"})
+		assert.Equal(
+			`WITH "recent" AS (SELECT "id", "title" FROM "book" WHERE ("year" > $1)) SELECT * FROM "recent" WHERE ("title" LIKE $2)`,
+			sel.String(),
+		)
+		assert.Equal([]interface{}{2000, "A%"}, sel.Arguments())
+
+		sel = b.SelectFrom("tree").WithRecursive("tree(id, parent_id)", db.Raw(
+			`SELECT id, parent_id FROM category WHERE id = ? UNION ALL SELECT c.id, c.parent_id FROM category c JOIN tree t ON c.parent_id = t.id`, 1,
+		))
+		assert.Equal(
+			`WITH RECURSIVE "tree" ("id", "parent_id") AS (SELECT id, parent_id FROM category WHERE id = $1 UNION ALL SELECT c.id, c.parent_id FROM category c JOIN tree t ON c.parent_id = t.id) SELECT * FROM "tree"`,
+			sel.String(),
+		)
+		assert.Equal([]interface{}{1}, sel.Arguments())
+	}
 }
 
 func TestInsert(t *testing.T) {
@@ -906,6 +1079,33 @@ func TestInsert(t *testing.T) {
 		b.InsertInto("artist").Values(map[string]interface{}{"name": "Chavela Vargas", "id": 12}).String(),
 	)
 
+	assert.Equal(
+		`INSERT INTO "artist" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name" RETURNING "id"`,
+		b.InsertInto("artist").
+			Values(map[string]interface{}{"name": "Chavela Vargas", "id": 12}).
+			OnConflict("id").
+			DoUpdate("name").
+			Returning("id").
+			String(),
+	)
+
+	assert.Equal(
+		`INSERT INTO "artist" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO NOTHING`,
+		b.InsertInto("artist").
+			Values(map[string]interface{}{"name": "Chavela Vargas", "id": 12}).
+			OnConflict("id").
+			String(),
+	)
+
+	assert.Equal(
+		`INSERT INTO "artist" ("id", "name") VALUES ($1, $2) ON CONFLICT DO NOTHING RETURNING "id"`,
+		b.InsertInto("artist").
+			Values(map[string]interface{}{"name": "Chavela Vargas", "id": 12}).
+			Ignore().
+			Returning("id").
+			String(),
+	)
+
 	assert.Equal(
 		`INSERT INTO "artist" ("id", "name") VALUES ($1, $2)`,
 		b.InsertInto("artist").Values(struct {
@@ -1259,6 +1459,14 @@ func TestDelete(t *testing.T) {
 		`DELETE FROM "artist" WHERE (id > 5)`,
 		bt.DeleteFrom("artist").Where("id > 5").String(),
 	)
+
+	assert.Equal(
+		`DELETE FROM "artist" WHERE ("id" IN (SELECT "id" FROM "artist" WHERE ("name" = $1) LIMIT 1 FOR UPDATE SKIP LOCKED)) RETURNING *`,
+		bt.DeleteFrom("artist").
+			Where(db.Cond{"id IN": bt.Select("id").From("artist").Where(db.Cond{"name": "Chavela Vargas"}).Limit(1).ForUpdate(db.SkipLocked)}).
+			Returning("*").
+			String(),
+	)
 }
 
 func TestPaginate(t *testing.T) {
@@ -1342,6 +1550,210 @@ func TestPaginate(t *testing.T) {
 	}
 }
 
+func TestCall(t *testing.T) {
+	b := &sqlBuilder{t: newTemplateWithUtils(&testTemplate)}
+	assert := assert.New(t)
+
+	{
+		query, args, err := b.compileCall("archive_orders", nil)
+		assert.NoError(err)
+		assert.Equal(`CALL "archive_orders"()`, query)
+		assert.Empty(args)
+	}
+
+	{
+		query, args, err := b.compileCall("sales.archive_orders", []interface{}{2020, db.Func("NOW")})
+		assert.NoError(err)
+		assert.Equal(`CALL "sales"."archive_orders"(?, NOW())`, query)
+		assert.Equal([]interface{}{2020}, args)
+	}
+
+	{
+		b := &sqlBuilder{t: newTemplateWithUtils(&exql.Template{})}
+		_, _, err := b.compileCall("archive_orders", nil)
+		assert.True(errors.Is(err, db.ErrUnsupported))
+	}
+}
+
+func TestValuesTable(t *testing.T) {
+	b := &sqlBuilder{t: newTemplateWithUtils(&testTemplate)}
+	assert := assert.New(t)
+
+	{
+		sel := b.Select("a.*").From("artist a").
+			Join(db.ValuesTable([]int{1, 2, 3}).As("k", "id integer")).On("k.id = a.id")
+		assert.Equal(
+			`SELECT "a".* FROM "artist" AS "a" JOIN (VALUES (CAST($1 AS integer)), ($2), ($3)) AS "k" ("id") ON (k.id = a.id)`,
+			sel.String(),
+		)
+		assert.Equal([]interface{}{1, 2, 3}, sel.Arguments())
+	}
+
+	{
+		sel := b.SelectFrom(db.ValuesTable([][]interface{}{{1, "a"}, {2, "b"}}).As("t", "id", "name"))
+		assert.Equal(
+			`SELECT * FROM (VALUES ($1, $2), ($3, $4)) AS "t" ("id", "name")`,
+			sel.String(),
+		)
+		assert.Equal([]interface{}{1, "a", 2, "b"}, sel.Arguments())
+	}
+
+	{
+		sel := b.SelectFrom("artist").Where(db.Cond{"id IN": db.ValuesTable([]int64{4, 5})})
+		assert.Equal(
+			`SELECT * FROM "artist" WHERE ("id" IN (SELECT * FROM (VALUES ($1), ($2)) AS "v" ("column1")))`,
+			sel.String(),
+		)
+		assert.Equal([]interface{}{int64(4), int64(5)}, sel.Arguments())
+	}
+
+	{
+		_, _, err := b.t.valuesTable(db.ValuesTable([]int{}))
+		assert.Error(err)
+
+		_, _, err = b.t.valuesTable(db.ValuesTable([][]int{{1, 2}, {3}}))
+		assert.Error(err)
+
+		b := &sqlBuilder{t: newTemplateWithUtils(&exql.Template{})}
+		_, _, err = b.t.valuesTable(db.ValuesTable([]int{1}))
+		assert.True(errors.Is(err, db.ErrUnsupported))
+	}
+}
+
+func TestTTLColumn(t *testing.T) {
+	assert := assert.New(t)
+
+	type session struct {
+		Token     string    `db:"token"`
+		ExpiresAt time.Time `db:"expires_at,ttl"`
+	}
+
+	column, ok := TTLColumn(session{})
+	assert.True(ok)
+	assert.Equal("expires_at", column)
+
+	column, ok = TTLColumn(&[]*session{})
+	assert.True(ok)
+	assert.Equal("expires_at", column)
+
+	_, ok = TTLColumn(struct {
+		Name string `db:"name"`
+	}{})
+	assert.False(ok)
+
+	_, ok = TTLColumn(map[string]interface{}{"expires_at": nil})
+	assert.False(ok)
+
+	_, ok = TTLColumn(nil)
+	assert.False(ok)
+}
+
+func TestBoolTagOption(t *testing.T) {
+	assert := assert.New(t)
+
+	type account struct {
+		Active bool  `db:"active,bool=Y/N"`
+		Admin  *bool `db:"admin,bool=1/0"`
+	}
+
+	yes := true
+	columns, values, err := Map(account{Active: false, Admin: &yes}, nil)
+	assert.NoError(err)
+	assert.Equal([]string{"active", "admin"}, columns)
+	assert.Equal([]interface{}{"N", "1"}, values)
+
+	type invalid struct {
+		Active bool `db:"active,bool=Y"`
+	}
+
+	// The option is parsed once, the error is returned on every call.
+	for i := 0; i < 2; i++ {
+		_, _, err = Map(invalid{}, nil)
+		assert.Error(err)
+	}
+}
+
+func TestJSONTagOption(t *testing.T) {
+	assert := assert.New(t)
+
+	type payload struct {
+		Kind  string   `json:"kind"`
+		Attrs []string `json:"attrs"`
+	}
+
+	type event struct {
+		Name    string            `db:"name"`
+		Payload payload           `db:"payload,json"`
+		Extra   map[string]string `db:"extra,json"`
+		Ref     *payload          `db:"ref,json"`
+		Flag    bool              `db:"flag,json"`
+	}
+
+	columns, values, err := Map(event{Name: "a", Payload: payload{Kind: "b", Attrs: []string{"c"}}, Flag: true}, nil)
+	assert.NoError(err)
+
+	byColumn := map[string]interface{}{}
+	for i := range columns {
+		byColumn[columns[i]] = values[i]
+	}
+	assert.Equal(map[string]interface{}{
+		"name":    "a",
+		"payload": `{"kind":"b","attrs":["c"]}`,
+		"extra":   nil,
+		"ref":     nil,
+		"flag":    true,
+	}, byColumn)
+
+	var dst event
+	for column, src := range map[string]interface{}{
+		"payload": []byte(`{"kind":"d","attrs":["e","f"]}`),
+		"extra":   `{"g":"h"}`,
+		"ref":     nil,
+	} {
+		fi := Mapper.TypeMap(reflect.TypeOf(dst)).Names[column]
+		f := reflectx.FieldByIndexes(reflect.ValueOf(&dst).Elem(), fi.Index)
+		assert.NoError(jsonScanner{f}.Scan(src))
+	}
+	assert.Equal(event{
+		Payload: payload{Kind: "d", Attrs: []string{"e", "f"}},
+		Extra:   map[string]string{"g": "h"},
+	}, dst)
+
+	assert.Error(jsonScanner{reflect.ValueOf(&dst.Payload).Elem()}.Scan(42))
+}
+
+func TestFieldsOf(t *testing.T) {
+	assert := assert.New(t)
+
+	type base struct {
+		ID        int       `db:"id,omitempty"`
+		ExpiresAt time.Time `db:"expires_at,ttl"`
+	}
+
+	type item struct {
+		base  `db:",inline"`
+		Name  string            `db:"name"`
+		Attrs map[string]string `db:"attrs,json"`
+	}
+
+	typ := reflect.TypeOf(item{})
+
+	fields := fieldsOf(typ)
+	assert.True(fields == fieldsOf(typ))
+
+	names := []string{}
+	for _, f := range fields.fields {
+		names = append(names, f.Name)
+	}
+	assert.Equal([]string{"attrs", "expires_at", "id", "name"}, names)
+
+	assert.Equal("expires_at", fields.ttlColumn)
+	assert.True(fields.byName["id"].omitEmpty)
+	assert.False(fields.byName["name"].omitEmpty)
+	assert.True(fields.byName["attrs"].json)
+	assert.False(fields.byName["name"].json)
+}
+
 func BenchmarkDelete1(b *testing.B) {
 	bt := WithTemplate(&testTemplate)
 	for n := 0; n < b.N; n++ {
@@ -0,0 +1,51 @@
+package sqlbuilder
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/sqladapter/exql"
+)
+
+func (b *sqlBuilder) Call(procedure string, args ...interface{}) db.Iterator {
+	return b.CallContext(b.sess.Context(), procedure, args...)
+}
+
+func (b *sqlBuilder) CallContext(ctx context.Context, procedure string, args ...interface{}) db.Iterator {
+	query, queryArgs, err := b.compileCall(procedure, args)
+	if err != nil {
+		return &iterator{sess: b.sess, ctx: ctx, err: err}
+	}
+	return b.IteratorContext(ctx, query, queryArgs...)
+}
+
+// compileCall returns the statement that calls the given stored procedure
+// and its arguments.
+func (b *sqlBuilder) compileCall(procedure string, args []interface{}) (string, []interface{}, error) {
+	if b.t.CallLayout == "" {
+		return "", nil, fmt.Errorf("%w: stored procedures", db.ErrUnsupported)
+	}
+
+	call := &exql.Call{
+		Name:      exql.ColumnWithName(procedure),
+		Arguments: make([]*exql.CallArgument, 0, len(args)),
+	}
+	callArgs := make([]interface{}, 0, len(args))
+	for i := range args {
+		value, valueArgs := b.t.PlaceholderValue(args[i])
+		_, output := args[i].(sql.Out)
+		call.Arguments = append(call.Arguments, &exql.CallArgument{
+			Value:  value,
+			Output: output,
+		})
+		callArgs = append(callArgs, valueArgs...)
+	}
+
+	query, err := call.Compile(b.t.Template)
+	if err != nil {
+		return "", nil, err
+	}
+	return query, callArgs, nil
+}
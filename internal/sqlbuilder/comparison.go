@@ -33,6 +33,9 @@ var comparisonOperators = map[adapter.ComparisonOperator]string{
 
 	adapter.ComparisonOperatorRegExp:    "REGEXP",
 	adapter.ComparisonOperatorNotRegExp: "NOT REGEXP",
+
+	adapter.ComparisonOperatorILike:    "LOWER(:column) LIKE LOWER(?)",
+	adapter.ComparisonOperatorNotILike: "LOWER(:column) NOT LIKE LOWER(?)",
 }
 
 type operatorWrapper struct {
@@ -89,6 +92,19 @@ func (ow *operatorWrapper) preprocess() (string, []interface{}) {
 			placeholder, args = "(NULL)", []interface{}{}
 			break
 		}
+		if vt, ok := values[0].(*adapter.ValuesTableExpr); ok && len(values) == 1 {
+			compiled, vtArgs, err := ow.tu.valuesTable(vt)
+			if err != nil {
+				panic(err.Error())
+			}
+			placeholder, args = "(SELECT * FROM "+compiled+")", vtArgs
+			break
+		}
+		if len(values) == 1 && isSubquery(values[0]) {
+			// The subquery is wrapped in parentheses already.
+			placeholder, args = "?", values
+			break
+		}
 		placeholder, args = "(?"+strings.Repeat(", ?", len(values)-1)+")", values
 	case adapter.ComparisonOperatorIs, adapter.ComparisonOperatorIsNot:
 		switch c.Value() {
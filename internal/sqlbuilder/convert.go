@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/upper/db/v4/internal/adapter"
+	"github.com/upper/db/v4/internal/bufferpool"
 	"github.com/upper/db/v4/internal/sqladapter/exql"
 )
 
@@ -14,7 +15,10 @@ var (
 )
 
 func expandQuery(in string, args []interface{}, fn func(interface{}) (string, []interface{})) (string, []interface{}) {
-	argn := 0
+	b := bufferpool.Get()
+	defer bufferpool.Put(b)
+
+	argn, last := 0, 0
 	argx := make([]interface{}, 0, len(args))
 	for i := 0; i < len(in); i++ {
 		if in[i] != '?' {
@@ -24,9 +28,15 @@ func expandQuery(in string, args []interface{}, fn func(interface{}) (string, []
 			k, values := fn(args[argn])
 			k, values = expandQuery(k, values, fn)
 
+			if _, isSlice := toInterfaceArguments(args[argn]); isSlice && isEnclosed(in, i) {
+				// The list is already in parentheses: IN (?).
+				k = k[1 : len(k)-1]
+			}
+
 			if k != "" {
-				in = in[:i] + k + in[i+1:]
-				i += len(k) - 1
+				b.WriteString(in[last:i])
+				b.WriteString(k)
+				last = i + 1
 			}
 			if len(values) > 0 {
 				argx = append(argx, values...)
@@ -37,7 +47,25 @@ func expandQuery(in string, args []interface{}, fn func(interface{}) (string, []
 	if len(argx) < len(args) {
 		argx = append(argx, args[argn:]...)
 	}
-	return in, argx
+	if last == 0 {
+		// Nothing was replaced.
+		return in, argx
+	}
+	b.WriteString(in[last:])
+	return b.String(), argx
+}
+
+// isEnclosed reports whether the placeholder at position i of the query is
+// the only thing between a pair of parentheses.
+func isEnclosed(in string, i int) bool {
+	l, r := i-1, i+1
+	for l >= 0 && in[l] == ' ' {
+		l--
+	}
+	for r < len(in) && in[r] == ' ' {
+		r++
+	}
+	return l >= 0 && r < len(in) && in[l] == '(' && in[r] == ')'
 }
 
 // toInterfaceArguments converts the given value into an array of interfaces.
@@ -110,6 +138,11 @@ func toColumnsValuesAndArguments(columnNames []string, columnValues []interface{
 }
 
 func preprocessFn(arg interface{}) (string, []interface{}) {
+	arg, err := asSubquery(arg)
+	if err != nil {
+		panic(err.Error())
+	}
+
 	values, isSlice := toInterfaceArguments(arg)
 
 	if isSlice {
@@ -138,7 +171,9 @@ func preprocessFn(arg interface{}) (string, []interface{}) {
 }
 
 // Preprocess expands arguments that needs to be expanded and compiles a query
-// into a single string.
+// into a single string. Named parameters (:name) are bound from a single map or
+// struct argument before expanding.
 func Preprocess(in string, args []interface{}) (string, []interface{}) {
+	in, args = bindNamed(in, args)
 	return expandQuery(in, args, preprocessFn)
 }
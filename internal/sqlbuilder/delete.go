@@ -16,6 +16,8 @@ type deleterQuery struct {
 	where     *exql.Where
 	whereArgs []interface{}
 
+	returning []exql.Fragment
+
 	amendFn func(string) string
 }
 
@@ -45,6 +47,10 @@ func (dq *deleterQuery) statement() *exql.Statement {
 		stmt.Limit = exql.Limit(dq.limit)
 	}
 
+	if len(dq.returning) > 0 {
+		stmt.Returning = exql.ReturningColumns(dq.returning...)
+	}
+
 	stmt.SetAmendment(dq.amendFn)
 
 	return stmt
@@ -109,6 +115,13 @@ func (del *deleter) Limit(limit int) db.Deleter {
 	})
 }
 
+func (del *deleter) Returning(columns ...string) db.Deleter {
+	return del.frame(func(dq *deleterQuery) error {
+		columnsToFragments(&dq.returning, columns)
+		return nil
+	})
+}
+
 func (del *deleter) Amend(fn func(string) string) db.Deleter {
 	return del.frame(func(dq *deleterQuery) error {
 		dq.amendFn = fn
@@ -152,6 +165,40 @@ func (del *deleter) ExecContext(ctx context.Context) (sql.Result, error) {
 	return del.SQL().sess.StatementExec(ctx, dq.statement(), dq.arguments()...)
 }
 
+func (del *deleter) Query() (*sql.Rows, error) {
+	return del.QueryContext(del.SQL().sess.Context())
+}
+
+func (del *deleter) QueryContext(ctx context.Context) (*sql.Rows, error) {
+	dq, err := del.build()
+	if err != nil {
+		return nil, err
+	}
+	return del.SQL().sess.StatementQuery(ctx, dq.statement(), dq.arguments()...)
+}
+
+func (del *deleter) QueryRow() (*sql.Row, error) {
+	return del.QueryRowContext(del.SQL().sess.Context())
+}
+
+func (del *deleter) QueryRowContext(ctx context.Context) (*sql.Row, error) {
+	dq, err := del.build()
+	if err != nil {
+		return nil, err
+	}
+	return del.SQL().sess.StatementQueryRow(ctx, dq.statement(), dq.arguments()...)
+}
+
+func (del *deleter) Iterator() db.Iterator {
+	return del.IteratorContext(del.SQL().sess.Context())
+}
+
+func (del *deleter) IteratorContext(ctx context.Context) db.Iterator {
+	rows, err := del.QueryContext(ctx)
+	sess := del.SQL().sess
+	return &iterator{sess: sess, ctx: ctx, cursor: rows, err: err, written: true, query: maskedQuery(sess, nil, del)}
+}
+
 func (del *deleter) statement() (*exql.Statement, error) {
 	iq, err := del.build()
 	if err != nil {
@@ -10,5 +10,6 @@ var (
 	ErrExpectingSlicePointer               = errors.New(`argument must be a slice address`)
 	ErrExpectingSliceMapStruct             = errors.New(`argument must be a slice address of maps or structs`)
 	ErrExpectingMapOrStruct                = errors.New(`argument must be either a map or a struct`)
+	ErrExpectingStructOfSlices             = errors.New(`argument must be a struct address whose fields are slices`)
 	ErrExpectingPointerToEitherMapOrStruct = errors.New(`expecting a pointer to either a map or a struct`)
 )
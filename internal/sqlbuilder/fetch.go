@@ -22,9 +22,12 @@
 package sqlbuilder
 
 import (
+	"context"
+	"database/sql/driver"
 	"reflect"
 
 	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/queryctx"
 	"github.com/upper/db/v4/internal/reflectx"
 )
 
@@ -50,7 +53,7 @@ func fetchRow(iter *iterator, dst interface{}) error {
 
 	itemV := dstv.Elem()
 
-	if columns, err = rows.Columns(); err != nil {
+	if columns, err = iter.columns(); err != nil {
 		return err
 	}
 
@@ -66,6 +69,11 @@ func fetchRow(iter *iterator, dst interface{}) error {
 	}
 
 	itemT := itemV.Type()
+	if itemT.Kind() == reflect.Struct {
+		// Scan straight into dst, rows are not copied from a temporary item.
+		return scanStruct(iter, dstv, columns)
+	}
+
 	item, err := fetchResult(iter, itemT, columns)
 	if err != nil {
 		return err
@@ -103,7 +111,7 @@ func fetchRows(iter *iterator, dst interface{}) error {
 	}
 
 	var columns []string
-	if columns, err = rows.Columns(); err != nil {
+	if columns, err = iter.columns(); err != nil {
 		return err
 	}
 
@@ -129,10 +137,154 @@ func fetchRows(iter *iterator, dst interface{}) error {
 	return rows.Err()
 }
 
+// fetchColumns receives a *sql.Rows value and appends each column of every
+// row to the matching slice field of the struct given by the pointer `dst`.
+func fetchColumns(iter *iterator, dst interface{}) error {
+	rows := iter.cursor
+	defer rows.Close()
+
+	dstv := reflect.ValueOf(dst)
+
+	if dstv.Kind() != reflect.Ptr || dstv.IsNil() {
+		return ErrExpectingPointer
+	}
+
+	itemv := dstv.Elem()
+	if itemv.Kind() != reflect.Struct {
+		return ErrExpectingStructOfSlices
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	types, err := columnTypes(rows)
+	if err != nil {
+		return err
+	}
+
+	fieldMap := fieldsOf(itemv.Type()).byName
+
+	// Slices that receive each one of the columns, or nil if the column is
+	// discarded.
+	slices := make([]reflect.Value, len(columns))
+	boolMappings := make([]*db.BoolMapping, len(columns))
+	nullZeroColumns := make([]bool, len(columns))
+	jsonColumns := make([]bool, len(columns))
+	for i, k := range columns {
+		fi, ok := fieldMap[k]
+		if !ok {
+			continue
+		}
+		f := reflectx.FieldByIndexes(itemv, fi.Index)
+		if f.Kind() != reflect.Slice {
+			return ErrExpectingStructOfSlices
+		}
+		if boolMappings[i], err = fieldBoolMapping(iter.sess, f.Type().Elem(), fi.Options); err != nil {
+			return err
+		}
+		nullZeroColumns[i] = fi.nullZero
+		jsonColumns[i] = isJSONField(f.Type().Elem(), fi.Options)
+		f.SetLen(0)
+		slices[i] = f
+	}
+
+	masks := iter.masks(columns)
+
+	discard := new(interface{})
+	values := make([]interface{}, len(columns))
+
+	for rows.Next() {
+		var nullZeros []*nullZero
+		for i := range slices {
+			if !slices[i].IsValid() {
+				values[i] = discard
+				continue
+			}
+			elem := growSlice(slices[i])
+			values[i] = elem.Addr().Interface()
+			if jsonColumns[i] {
+				values[i] = jsonScanner{elem}
+				continue
+			}
+			if types != nil {
+				if s := convertedField(types[i], elem); s != nil {
+					values[i] = s
+					continue
+				}
+			}
+			if boolMappings[i] != nil {
+				values[i] = boolScanner{*boolMappings[i], elem}
+				continue
+			}
+			if u, ok := values[i].(db.Unmarshaler); ok {
+				values[i] = scanner{u}
+				continue
+			}
+			if nullZeroColumns[i] {
+				nz := newNullZero(elem)
+				nullZeros = append(nullZeros, nz)
+				values[i] = nz.target()
+			}
+		}
+
+		scanValues := values
+		if converter, ok := iter.sess.(hasConvertValues); ok {
+			scanValues = converter.ConvertValues(values)
+		}
+
+		if err := rows.Scan(scanValues...); err != nil {
+			return err
+		}
+
+		for _, nz := range nullZeros {
+			nz.apply()
+		}
+
+		iter.read(func() (n int64) {
+			for i := range slices {
+				if slices[i].IsValid() {
+					n += valueSize(slices[i].Index(slices[i].Len() - 1))
+				} else {
+					n += ValueSize(discard)
+				}
+			}
+			return n
+		})
+
+		for i := range masks {
+			if masks[i].masked && slices[i].IsValid() {
+				masks[i].applyTo(slices[i].Index(slices[i].Len() - 1))
+			}
+		}
+	}
+
+	return rows.Err()
+}
+
+// growSlice adds a zero element to the given slice and returns it, growing
+// the slice's capacity geometrically so most rows don't allocate.
+func growSlice(slicev reflect.Value) reflect.Value {
+	n := slicev.Len()
+	if n == slicev.Cap() {
+		newCap := 2 * n
+		if newCap < 16 {
+			newCap = 16
+		}
+		grown := reflect.MakeSlice(slicev.Type(), n, newCap)
+		reflect.Copy(grown, slicev)
+		slicev.Set(grown)
+	}
+	slicev.SetLen(n + 1)
+
+	elem := slicev.Index(n)
+	elem.Set(reflect.Zero(elem.Type()))
+	return elem
+}
+
 func fetchResult(iter *iterator, itemT reflect.Type, columns []string) (reflect.Value, error) {
 	var item reflect.Value
-	var err error
-	rows := iter.cursor
 
 	objT := itemT
 
@@ -151,67 +303,138 @@ func fetchResult(iter *iterator, itemT reflect.Type, columns []string) (reflect.
 		return item, ErrExpectingMapOrStruct
 	}
 
-	switch objT.Kind() {
-	case reflect.Struct:
-
-		values := make([]interface{}, len(columns))
-		typeMap := Mapper.TypeMap(itemT)
-		fieldMap := typeMap.Names
+	if objT.Kind() == reflect.Map {
+		return item, scanMap(iter, item, columns)
+	}
+	return item, scanStruct(iter, item, columns)
+}
 
-		for i, k := range columns {
-			fi, ok := fieldMap[k]
-			if !ok {
-				values[i] = new(interface{})
-				continue
-			}
+// scanStruct scans the current row into the struct item points to.
+func scanStruct(iter *iterator, item reflect.Value, columns []string) error {
+	types, err := iter.columnTypes()
+	if err != nil {
+		return err
+	}
 
-			// Check for deprecated jsonb tag.
-			if _, hasJSONBTag := fi.Options["jsonb"]; hasJSONBTag {
-				return item, errDeprecatedJSONBTag
-			}
+	values := iter.scanValues(len(columns))
+	fieldMap := fieldsOf(item.Type().Elem()).byName
 
-			f := reflectx.FieldByIndexes(item, fi.Index)
-			values[i] = f.Addr().Interface()
+	var nullZeros []*nullZero
 
-			if u, ok := values[i].(db.Unmarshaler); ok {
-				values[i] = scanner{u}
-			}
+	for i, k := range columns {
+		fi, ok := fieldMap[k]
+		if !ok {
+			values[i] = new(interface{})
+			continue
 		}
 
-		if converter, ok := iter.sess.(hasConvertValues); ok {
-			values = converter.ConvertValues(values)
+		// Check for deprecated jsonb tag.
+		if fi.jsonb {
+			return errDeprecatedJSONBTag
 		}
 
-		if err = rows.Scan(values...); err != nil {
-			return item, err
+		f := reflectx.FieldByIndexes(item, fi.Index)
+		values[i] = f.Addr().Interface()
+
+		if fi.json {
+			values[i] = jsonScanner{f}
+			continue
 		}
 
-	case reflect.Map:
+		if types != nil {
+			if s := convertedField(types[i], f); s != nil {
+				values[i] = s
+				continue
+			}
+		}
 
-		columns, err := rows.Columns()
+		mapping, err := fieldBoolMapping(iter.sess, f.Type(), fi.Options)
 		if err != nil {
-			return item, err
+			return err
+		}
+		if mapping != nil {
+			values[i] = boolScanner{*mapping, f}
+			continue
 		}
 
-		values := make([]interface{}, len(columns))
-		for i := range values {
-			if itemT.Elem().Kind() == reflect.Interface {
-				values[i] = new(interface{})
+		if u, ok := values[i].(db.Unmarshaler); ok {
+			values[i] = scanner{u}
+			continue
+		}
+
+		if fi.nullZero {
+			nz := newNullZero(f)
+			nullZeros = append(nullZeros, nz)
+			values[i] = nz.target()
+		}
+	}
+
+	if converter, ok := iter.sess.(hasConvertValues); ok {
+		values = converter.ConvertValues(values)
+	}
+
+	if err = iter.cursor.Scan(values...); err != nil {
+		return err
+	}
+
+	for _, nz := range nullZeros {
+		nz.apply()
+	}
+
+	iter.read(func() (n int64) {
+		for i, k := range columns {
+			if fi, ok := fieldMap[k]; ok {
+				n += valueSize(reflectx.FieldByIndexes(item, fi.Index))
 			} else {
-				values[i] = reflect.New(itemT.Elem()).Interface()
+				n += ValueSize(values[i])
 			}
 		}
+		return n
+	})
+
+	for i, mask := range iter.masks(columns) {
+		if fi, ok := fieldMap[columns[i]]; ok && mask.masked {
+			mask.applyTo(reflectx.FieldByIndexes(item, fi.Index))
+		}
+	}
+
+	return nil
+}
+
+// scanMap scans the current row into the map item.
+func scanMap(iter *iterator, item reflect.Value, columns []string) error {
+	elemT := item.Type().Elem()
 
-		if err = rows.Scan(values...); err != nil {
-			return item, err
+	values := iter.scanValues(len(columns))
+	for i := range values {
+		if elemT.Kind() == reflect.Interface {
+			values[i] = new(interface{})
+		} else {
+			values[i] = reflect.New(elemT).Interface()
 		}
+	}
+
+	if err := iter.cursor.Scan(values...); err != nil {
+		return err
+	}
 
-		for i, column := range columns {
-			item.SetMapIndex(reflect.ValueOf(column), reflect.Indirect(reflect.ValueOf(values[i])))
+	iter.read(func() (n int64) {
+		for i := range values {
+			n += ValueSize(values[i])
+		}
+		return n
+	})
+
+	masks := iter.masks(columns)
+	for i, column := range columns {
+		value := reflect.Indirect(reflect.ValueOf(values[i]))
+		if masks[i].masked {
+			value = masks[i].mapValue(elemT)
 		}
+		item.SetMapIndex(reflect.ValueOf(column), value)
 	}
 
-	return item, nil
+	return nil
 }
 
 func reset(data interface{}) {
@@ -230,3 +453,74 @@ func reset(data interface{}) {
 
 	v.Set(z)
 }
+
+// context returns the context of the query, or the one of the session if the
+// query has none.
+func (iter *iterator) context() context.Context {
+	if iter.ctx != nil {
+		return iter.ctx
+	}
+	return iter.sess.Context()
+}
+
+// read accounts a scanned row to the usage tracked by the context of the
+// query. size is only called if the context tracks usage. Rows returned by
+// statements that write them count as written, their size was accounted with
+// the arguments of the statement.
+func (iter *iterator) read(size func() int64) {
+	ctx := iter.context()
+	if !queryctx.TracksUsage(ctx) {
+		return
+	}
+	if iter.written {
+		queryctx.AddWritten(ctx, 1, 0)
+		return
+	}
+	queryctx.AddRead(ctx, 1, size())
+}
+
+// ValueSize approximates the size in bytes of a value that was scanned from,
+// or is sent to, the database.
+func ValueSize(v interface{}) int64 {
+	return valueSize(reflect.ValueOf(v))
+}
+
+func valueSize(v reflect.Value) int64 {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return 0
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		return valueSize(v.Elem())
+	case reflect.String:
+		return int64(v.Len())
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return int64(v.Len())
+		}
+		var n int64
+		for i := 0; i < v.Len(); i++ {
+			n += valueSize(v.Index(i))
+		}
+		return n
+	case reflect.Map:
+		var n int64
+		iter := v.MapRange()
+		for iter.Next() {
+			n += valueSize(iter.Value())
+		}
+		return n
+	case reflect.Struct:
+		if !v.CanInterface() {
+			break
+		}
+		if valuer, ok := v.Interface().(driver.Valuer); ok {
+			if value, err := valuer.Value(); err == nil {
+				return ValueSize(value)
+			}
+		}
+	}
+	return int64(v.Type().Size())
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sqlbuilder
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/reflectx"
+)
+
+// structField describes how a struct field is mapped to a column, the tag
+// options are parsed once per struct type.
+type structField struct {
+	*reflectx.FieldInfo
+
+	omitEmpty bool
+	zeroNil   bool
+	nullZero  bool
+	json      bool
+	jsonb     bool
+
+	// boolMapping and boolErr hold the parsed bool tag option, if any.
+	boolMapping *db.BoolMapping
+	boolErr     error
+}
+
+// structFields holds the fields of a struct type, including the ones of
+// embedded structs, sorted by column name.
+type structFields struct {
+	fields []*structField
+	byName map[string]*structField
+
+	ttlColumn string
+}
+
+var structFieldsCache sync.Map // map[reflect.Type]*structFields
+
+// fieldsOf returns the fields of the struct type t, which are computed once
+// and cached.
+func fieldsOf(t reflect.Type) *structFields {
+	if sf, ok := structFieldsCache.Load(t); ok {
+		return sf.(*structFields)
+	}
+
+	names := Mapper.TypeMap(t).Names
+
+	sf := &structFields{
+		fields: make([]*structField, 0, len(names)),
+		byName: make(map[string]*structField, len(names)),
+	}
+	for name, fi := range names {
+		f := &structField{FieldInfo: fi}
+		_, f.omitEmpty = fi.Options["omitempty"]
+		_, f.zeroNil = fi.Options["zeronil"]
+		_, f.nullZero = fi.Options["nullzero"]
+		_, f.jsonb = fi.Options["jsonb"]
+		if opt, ok := fi.Options["bool"]; ok {
+			f.boolMapping, f.boolErr = parseBoolOption(opt)
+		}
+		f.json = isJSONField(fi.Field.Type, fi.Options)
+
+		sf.fields = append(sf.fields, f)
+		sf.byName[name] = f
+	}
+	sort.Slice(sf.fields, func(i, j int) bool {
+		if sf.fields[i].Name != sf.fields[j].Name {
+			return sf.fields[i].Name < sf.fields[j].Name
+		}
+		return sf.fields[i].Path < sf.fields[j].Path
+	})
+
+	for _, f := range sf.fields {
+		if _, ok := f.Options[db.TTLOption]; ok {
+			sf.ttlColumn = f.Name
+			break
+		}
+	}
+
+	actual, _ := structFieldsCache.LoadOrStore(t, sf)
+	return actual.(*structFields)
+}
@@ -14,6 +14,8 @@ type inserterQuery struct {
 	table          string
 	enqueuedValues [][]interface{}
 	returning      []exql.Fragment
+	onConflict     *exql.OnConflict
+	ignore         bool
 	columns        []exql.Fragment
 	values         []*exql.Values
 	arguments      []interface{}
@@ -83,6 +85,12 @@ func (iq *inserterQuery) statement() *exql.Statement {
 		stmt.Columns = exql.JoinColumns(iq.columns...)
 	}
 
+	if iq.onConflict != nil {
+		stmt.OnConflict = iq.onConflict
+	}
+
+	stmt.Ignore = iq.ignore
+
 	if len(iq.returning) > 0 {
 		stmt.Returning = exql.ReturningColumns(iq.returning...)
 	}
@@ -150,6 +158,35 @@ func (ins *inserter) Returning(columns ...string) db.Inserter {
 	})
 }
 
+func (ins *inserter) OnConflict(columns ...string) db.Inserter {
+	return ins.frame(func(iq *inserterQuery) error {
+		if iq.onConflict == nil {
+			iq.onConflict = &exql.OnConflict{}
+		}
+		iq.onConflict.Columns = exql.JoinColumns()
+		columnsToFragments(&iq.onConflict.Columns.Columns, columns)
+		return nil
+	})
+}
+
+func (ins *inserter) DoUpdate(columns ...string) db.Inserter {
+	return ins.frame(func(iq *inserterQuery) error {
+		if iq.onConflict == nil {
+			iq.onConflict = &exql.OnConflict{}
+		}
+		iq.onConflict.Update = exql.JoinColumns()
+		columnsToFragments(&iq.onConflict.Update.Columns, columns)
+		return nil
+	})
+}
+
+func (ins *inserter) Ignore() db.Inserter {
+	return ins.frame(func(iq *inserterQuery) error {
+		iq.ignore = true
+		return nil
+	})
+}
+
 func (ins *inserter) Exec() (sql.Result, error) {
 	return ins.ExecContext(ins.SQL().sess.Context())
 }
@@ -204,7 +241,8 @@ func (ins *inserter) Iterator() db.Iterator {
 
 func (ins *inserter) IteratorContext(ctx context.Context) db.Iterator {
 	rows, err := ins.QueryContext(ctx)
-	return &iterator{ins.SQL().sess, rows, err}
+	sess := ins.SQL().sess
+	return &iterator{sess: sess, ctx: ctx, cursor: rows, err: err, written: true, query: maskedQuery(sess, nil, ins)}
 }
 
 func (ins *inserter) Into(table string) db.Inserter {
@@ -0,0 +1,416 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sqlbuilder
+
+import (
+	"reflect"
+	"strings"
+
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/adapter"
+	"github.com/upper/db/v4/internal/sqladapter/exql"
+)
+
+// columnMask is how the masking policy of the session applies to a column.
+type columnMask struct {
+	masked      bool
+	replacement interface{}
+}
+
+// maskingPolicy returns the masking policy of sess, if any.
+func maskingPolicy(sess interface{}) *db.MaskingPolicy {
+	if s, ok := sess.(hasMaskingPolicy); ok {
+		return s.MaskingPolicy()
+	}
+	return nil
+}
+
+// maskedQuery returns the SQL text of query, a raw query or a statement
+// builder, when sess has a masking policy. The columns of the result set are
+// traced back through it to the columns they're read from.
+func maskedQuery(sess interface{}, t *exql.Template, query interface{}) string {
+	if maskingPolicy(sess) == nil {
+		return ""
+	}
+	switch q := query.(type) {
+	case string:
+		return q
+	case *adapter.RawExpr:
+		return q.Raw()
+	case *exql.Statement:
+		if s, err := q.Compile(t); err == nil {
+			return s
+		}
+	case interface{ Compile() (string, error) }:
+		if s, err := q.Compile(); err == nil {
+			return s
+		}
+	}
+	return ""
+}
+
+// masks returns how the masking policy of the session applies to each one of
+// the given columns, for the roles carried by the context of the query. A
+// column is masked when it's named after a masked column, or when the
+// expression it's read from refers to one, like in "salary AS s" or
+// "MAX(salary)".
+func (iter *iterator) masks(columns []string) []columnMask {
+	if iter.colMasks != nil {
+		return iter.colMasks
+	}
+	masks := make([]columnMask, len(columns))
+	iter.colMasks = masks
+
+	policy := maskingPolicy(iter.sess)
+	if policy == nil {
+		return masks
+	}
+
+	ctx := iter.context()
+	lists := selectLists(iter.query)
+
+	// Names given to expressions that read masked columns are masked as well,
+	// so aliases can't be used to get around the policy from a subquery.
+	derived := map[string]columnMask{}
+	lookup := func(name string) columnMask {
+		if mask, ok := derived[name]; ok {
+			return mask
+		}
+		var mask columnMask
+		mask.replacement, mask.masked = policy.Masked(ctx, name)
+		return mask
+	}
+	sources := func(expr selectExpr) columnMask {
+		for _, ident := range expr.idents {
+			if mask := lookup(ident); mask.masked {
+				return mask
+			}
+		}
+		return columnMask{}
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, list := range lists {
+			for _, expr := range list.exprs {
+				if expr.name == "" || lookup(expr.name).masked {
+					continue
+				}
+				if mask := sources(expr); mask.masked {
+					derived[expr.name] = mask
+					changed = true
+				}
+			}
+		}
+	}
+
+	for i, column := range columns {
+		if masks[i] = lookup(strings.ToLower(column)); masks[i].masked {
+			continue
+		}
+		// The columns of the result set are the expressions of the outermost
+		// lists, in the same order, unless a * stands for an unknown number of
+		// them.
+		for _, list := range lists {
+			if list.depth > 0 || list.star || len(list.exprs) != len(columns) {
+				continue
+			}
+			if masks[i] = sources(list.exprs[i]); masks[i].masked {
+				break
+			}
+		}
+	}
+	return masks
+}
+
+// applyTo replaces the value of f with the replacement of the mask, or with
+// its zero value if the replacement can't be stored in f.
+func (m columnMask) applyTo(f reflect.Value) {
+	f.Set(reflect.Zero(f.Type()))
+	if m.replacement == nil {
+		return
+	}
+
+	v := reflect.ValueOf(m.replacement)
+	switch {
+	case v.Type().ConvertibleTo(f.Type()):
+		f.Set(v.Convert(f.Type()))
+	case f.Kind() == reflect.Ptr && v.Type().ConvertibleTo(f.Type().Elem()):
+		p := reflect.New(f.Type().Elem())
+		p.Elem().Set(v.Convert(f.Type().Elem()))
+		f.Set(p)
+	}
+}
+
+// mapValue returns the value that replaces a masked column in a map whose
+// values are of type elemT, the zero Value removes the key.
+func (m columnMask) mapValue(elemT reflect.Type) reflect.Value {
+	if m.replacement == nil {
+		return reflect.Value{}
+	}
+	v := reflect.ValueOf(m.replacement)
+	if !v.Type().ConvertibleTo(elemT) {
+		return reflect.Value{}
+	}
+	return v.Convert(elemT)
+}
+
+// selectList is the list of expressions of a SELECT or RETURNING clause.
+type selectList struct {
+	depth int // Parentheses around the clause, 0 for the outermost query.
+	star  bool
+	exprs []selectExpr
+}
+
+// selectExpr is an expression of a selectList.
+type selectExpr struct {
+	name   string   // The alias of the expression, or the column it reads.
+	idents []string // The identifiers the expression refers to.
+	tokens []sqlToken
+}
+
+type sqlToken struct {
+	text  string
+	kind  byte // 'w' word, 'q' quoted identifier, 'l' literal, or punctuation.
+	depth int
+}
+
+// Keywords that end the list of expressions of a SELECT clause.
+var selectListEnd = map[string]bool{
+	"FROM": true, "WHERE": true, "GROUP": true, "HAVING": true, "ORDER": true,
+	"LIMIT": true, "OFFSET": true, "FETCH": true, "FOR": true, "INTO": true,
+	"UNION": true, "INTERSECT": true, "EXCEPT": true, "WINDOW": true,
+	"RETURNING": true,
+}
+
+// Keywords that can't be identifiers or aliases in a list of expressions.
+var selectKeywords = map[string]bool{
+	"SELECT": true, "DISTINCT": true, "ALL": true, "TOP": true, "AS": true,
+	"CASE": true, "WHEN": true, "THEN": true, "ELSE": true, "END": true,
+	"AND": true, "OR": true, "NOT": true, "NULL": true, "IS": true, "IN": true,
+	"LIKE": true, "ILIKE": true, "BETWEEN": true, "TRUE": true, "FALSE": true,
+	"COLLATE": true, "OVER": true, "PARTITION": true, "BY": true, "ASC": true,
+	"DESC": true, "FILTER": true, "EXISTS": true, "CAST": true, "ON": true,
+	"INTERVAL": true, "ESCAPE": true, "FROM": true, "WHERE": true,
+}
+
+// selectLists returns the SELECT and RETURNING lists of query, including the
+// ones of subqueries.
+func selectLists(query string) []selectList {
+	if query == "" {
+		return nil
+	}
+
+	var lists []selectList
+	var open []int // Indexes of the lists that are still being read.
+
+	closeLists := func(depth int) {
+		for len(open) > 0 && lists[open[len(open)-1]].depth >= depth {
+			open = open[:len(open)-1]
+		}
+	}
+
+	depth := 0
+	for _, tok := range tokenizeSQL(query) {
+		switch tok.text {
+		case "(":
+			depth++
+		case ")":
+			closeLists(depth)
+			depth--
+		}
+		tok.depth = depth
+
+		word := ""
+		if tok.kind == 'w' {
+			word = strings.ToUpper(tok.text)
+		}
+		if len(open) > 0 {
+			list := &lists[open[len(open)-1]]
+			if list.depth == depth && selectListEnd[word] {
+				closeLists(depth)
+			}
+		}
+
+		for _, i := range open {
+			list := &lists[i]
+			if tok.depth == list.depth && tok.text == "," {
+				list.exprs = append(list.exprs, selectExpr{})
+				continue
+			}
+			expr := &list.exprs[len(list.exprs)-1]
+			if len(expr.tokens) == 0 && tok.depth == list.depth && (word == "DISTINCT" || word == "ALL") {
+				continue
+			}
+			expr.tokens = append(expr.tokens, tok)
+		}
+
+		if word == "SELECT" || word == "RETURNING" {
+			lists = append(lists, selectList{depth: depth, exprs: []selectExpr{{}}})
+			open = append(open, len(lists)-1)
+		}
+	}
+
+	for i := range lists {
+		for j := range lists[i].exprs {
+			expr := &lists[i].exprs[j]
+			expr.analyze(lists[i].depth)
+			if expr.isStar(lists[i].depth) {
+				lists[i].star = true
+			}
+		}
+	}
+	return lists
+}
+
+// analyze finds the name of the expression and the identifiers it refers to.
+func (e *selectExpr) analyze(depth int) {
+	var top []int // Tokens that are not within parentheses.
+	for i, tok := range e.tokens {
+		if tok.depth == depth && tok.text != ")" {
+			top = append(top, i)
+		}
+	}
+
+	alias := -1
+	if n := len(top); n >= 2 && isIdent(e.tokens[top[n-1]]) {
+		prev := e.tokens[top[n-2]]
+		switch {
+		case prev.kind == 'w' && strings.ToUpper(prev.text) == "AS":
+			alias = top[n-1]
+		case isIdent(prev), prev.kind == 'l':
+			alias = top[n-1]
+		}
+	}
+	if alias < 0 && len(top) > 0 && len(top) == len(e.tokens) {
+		// A column, maybe qualified with its table.
+		last := e.tokens[top[len(top)-1]]
+		plain := isIdent(last)
+		for i, idx := range top {
+			tok := e.tokens[idx]
+			if (i%2 == 0 && !isIdent(tok)) || (i%2 == 1 && tok.text != ".") {
+				plain = false
+			}
+		}
+		if plain {
+			e.name = identName(last)
+		}
+	}
+	if alias >= 0 {
+		e.name = identName(e.tokens[alias])
+	}
+
+	for i, tok := range e.tokens {
+		if i != alias && isIdent(tok) {
+			e.idents = append(e.idents, identName(tok))
+		}
+	}
+}
+
+// isStar returns whether the expression is * or table.*.
+func (e *selectExpr) isStar(depth int) bool {
+	n := len(e.tokens)
+	return n > 0 && e.tokens[n-1].text == "*" && e.tokens[n-1].depth == depth &&
+		(n == 1 || (n == 3 && e.tokens[1].text == "."))
+}
+
+func isIdent(tok sqlToken) bool {
+	return tok.kind == 'q' || (tok.kind == 'w' && !selectKeywords[strings.ToUpper(tok.text)])
+}
+
+// identName returns the name of an identifier token, unquoted and in lower
+// case.
+func identName(tok sqlToken) string {
+	name := tok.text
+	if tok.kind == 'q' {
+		name = name[1 : len(name)-1]
+	}
+	return strings.ToLower(name)
+}
+
+// tokenizeSQL splits query into words, quoted identifiers, literals and
+// punctuation. Comments are skipped.
+func tokenizeSQL(query string) []sqlToken {
+	var tokens []sqlToken
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return tokens
+			}
+			i += end + 1
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return tokens
+			}
+			i += end + 4
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			j := i + 1
+			for j < len(query) {
+				if query[j] == closing {
+					if closing != ']' && j+1 < len(query) && query[j+1] == closing {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			if j >= len(query) {
+				j = len(query) - 1
+			}
+			kind := byte('q')
+			if c == '\'' {
+				kind = 'l'
+			}
+			tokens = append(tokens, sqlToken{text: query[i : j+1], kind: kind})
+			i = j + 1
+		case isWordByte(c):
+			j := i
+			for j < len(query) && (isWordByte(query[j]) || query[j] == '$') {
+				j++
+			}
+			kind := byte('w')
+			if c >= '0' && c <= '9' {
+				kind = 'l'
+			}
+			tokens = append(tokens, sqlToken{text: query[i:j], kind: kind})
+			i = j
+		default:
+			tokens = append(tokens, sqlToken{text: query[i : i+1], kind: c})
+			i++
+		}
+	}
+	return tokens
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c >= 0x80
+}
@@ -0,0 +1,64 @@
+package sqlbuilder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectLists(t *testing.T) {
+	type expr struct {
+		name   string
+		idents []string
+	}
+
+	exprsOf := func(list selectList) []expr {
+		exprs := make([]expr, len(list.exprs))
+		for i := range list.exprs {
+			exprs[i] = expr{list.exprs[i].name, list.exprs[i].idents}
+		}
+		return exprs
+	}
+
+	{
+		lists := selectLists(`SELECT "id", "t"."salary", salary AS s, MAX(bonus) b, 'salary' AS lit FROM staff t WHERE salary > ?`)
+		assert.Equal(t, 1, len(lists))
+		assert.False(t, lists[0].star)
+		assert.Equal(t, []expr{
+			{"id", []string{"id"}},
+			{"salary", []string{"t", "salary"}},
+			{"s", []string{"salary"}},
+			{"b", []string{"max", "bonus"}},
+			{"lit", nil},
+		}, exprsOf(lists[0]))
+	}
+
+	{
+		lists := selectLists(`SELECT * FROM (SELECT DISTINCT UPPER(name) AS n, CASE WHEN a THEN b END FROM t) AS x`)
+		assert.Equal(t, 2, len(lists))
+		assert.True(t, lists[0].star)
+		assert.Equal(t, 1, lists[1].depth)
+		assert.Equal(t, []expr{
+			{"n", []string{"upper", "name"}},
+			{"", []string{"a", "b"}},
+		}, exprsOf(lists[1]))
+	}
+
+	{
+		lists := selectLists(`SELECT id FROM a UNION SELECT (SELECT salary FROM b) FROM c -- SELECT x`)
+		assert.Equal(t, 3, len(lists))
+		assert.Equal(t, 0, lists[1].depth)
+		assert.Equal(t, []expr{
+			{"", []string{"salary", "b"}},
+		}, exprsOf(lists[1]))
+	}
+
+	{
+		lists := selectLists(`UPDATE staff SET salary = ? WHERE id = ? RETURNING id, salary AS s`)
+		assert.Equal(t, 1, len(lists))
+		assert.Equal(t, []expr{
+			{"id", []string{"id"}},
+			{"s", []string{"salary"}},
+		}, exprsOf(lists[0]))
+	}
+}
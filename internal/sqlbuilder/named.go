@@ -0,0 +1,144 @@
+package sqlbuilder
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"time"
+
+	"github.com/upper/db/v4/internal/adapter"
+	"github.com/upper/db/v4/internal/bufferpool"
+	"github.com/upper/db/v4/internal/reflectx"
+)
+
+// namedArgs returns a function that looks up named parameters in the given
+// argument list, named parameters can only be bound from a single map with
+// string keys or from a single struct.
+func namedArgs(args []interface{}) func(name string) (interface{}, bool) {
+	if len(args) != 1 || args[0] == nil {
+		return nil
+	}
+
+	switch args[0].(type) {
+	case driver.Valuer, time.Time, *time.Time, *adapter.RawExpr, compilable:
+		return nil
+	}
+
+	v := reflect.ValueOf(args[0])
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		return func(name string) (interface{}, bool) {
+			value := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !value.IsValid() {
+				return nil, false
+			}
+			return value.Interface(), true
+		}
+	case reflect.Struct:
+		fields := Mapper.TypeMap(v.Type()).Names
+		return func(name string) (interface{}, bool) {
+			fi, ok := fields[name]
+			if !ok {
+				return nil, false
+			}
+			return reflectx.FieldByIndexesReadOnly(v, fi.Index).Interface(), true
+		}
+	}
+
+	return nil
+}
+
+// isNameStart reports whether c can start the name of a named parameter.
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isNameChar reports whether c can be part of the name of a named parameter.
+func isNameChar(c byte) bool {
+	return isNameStart(c) || c == '.' || (c >= '0' && c <= '9')
+}
+
+// bindNamed replaces :name parameters in the given query with ? placeholders
+// and returns the values they were bound to. Queries that already use ?
+// placeholders or have arguments other than a single map or struct are
+// returned untouched. Quoted strings and identifiers, :: casts and :=
+// assignments are left as they are, and so are names that can't be found in
+// the map or struct.
+func bindNamed(in string, args []interface{}) (string, []interface{}) {
+	lookup := namedArgs(args)
+	if lookup == nil {
+		return in, args
+	}
+
+	b := bufferpool.Get()
+	defer bufferpool.Put(b)
+
+	var argx []interface{}
+	var quote byte
+	last := 0
+	for i := 0; i < len(in); i++ {
+		c := in[i]
+
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+			continue
+		case '?':
+			// Positional placeholders take precedence over named ones.
+			return in, args
+		case ':':
+		default:
+			continue
+		}
+
+		if i+1 < len(in) && (in[i+1] == ':' || in[i+1] == '=') {
+			// Skip casts and assignments.
+			i++
+			continue
+		}
+		if i+1 >= len(in) || !isNameStart(in[i+1]) {
+			continue
+		}
+
+		j := i + 1
+		for j < len(in) && isNameChar(in[j]) {
+			j++
+		}
+
+		value, ok := lookup(in[i+1 : j])
+		if !ok {
+			i = j - 1
+			continue
+		}
+
+		b.WriteString(in[last:i])
+		b.WriteString(`?`)
+		argx = append(argx, value)
+		last = j
+		i = j - 1
+	}
+
+	if last == 0 {
+		// No named parameters were found.
+		return in, args
+	}
+
+	b.WriteString(in[last:])
+	return b.String(), argx
+}
@@ -87,7 +87,7 @@ func (pag *paginator) Cursor(column string) db.Paginator {
 
 func (pag *paginator) NextPage(cursorValue interface{}) db.Paginator {
 	return pag.frame(func(pq *paginatorQuery) error {
-		if pq.cursorValue != nil && pq.cursorColumn == "" {
+		if pq.cursorColumn == "" {
 			return errMissingCursorColumn
 		}
 		pq.cursorValue = cursorValue
@@ -107,7 +107,7 @@ func (pag *paginator) NextPage(cursorValue interface{}) db.Paginator {
 
 func (pag *paginator) PrevPage(cursorValue interface{}) db.Paginator {
 	return pag.frame(func(pq *paginatorQuery) error {
-		if pq.cursorValue != nil && pq.cursorColumn == "" {
+		if pq.cursorColumn == "" {
 			return errMissingCursorColumn
 		}
 		pq.cursorValue = cursorValue
@@ -159,6 +159,14 @@ func (pag *paginator) All(dest interface{}) error {
 	return nil
 }
 
+func (pag *paginator) AllColumns(dest interface{}) error {
+	pq, err := pag.buildWithCursor()
+	if err != nil {
+		return err
+	}
+	return pq.sel.AllColumns(dest)
+}
+
 func (pag *paginator) One(dest interface{}) error {
 	pq, err := pag.buildWithCursor()
 	if err != nil {
@@ -171,7 +179,7 @@ func (pag *paginator) Iterator() db.Iterator {
 	pq, err := pag.buildWithCursor()
 	if err != nil {
 		sess := pq.sel.(*selector).SQL().sess
-		return &iterator{sess, nil, err}
+		return &iterator{sess: sess, err: err}
 	}
 	return pq.sel.Iterator()
 }
@@ -180,7 +188,7 @@ func (pag *paginator) IteratorContext(ctx context.Context) db.Iterator {
 	pq, err := pag.buildWithCursor()
 	if err != nil {
 		sess := pq.sel.(*selector).SQL().sess
-		return &iterator{sess, nil, err}
+		return &iterator{sess: sess, err: err}
 	}
 	return pq.sel.IteratorContext(ctx)
 }
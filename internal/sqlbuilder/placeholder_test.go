@@ -52,6 +52,30 @@ func TestPlaceholderArray(t *testing.T) {
 	}
 }
 
+func TestPlaceholderEnclosedArray(t *testing.T) {
+	{
+		ret, args := Preprocess("id IN (?)", []interface{}{[]int{1, 2, 3}})
+		assert.Equal(t, "id IN (?, ?, ?)", ret)
+		assert.Equal(t, []interface{}{1, 2, 3}, args)
+	}
+
+	{
+		ret, args := Preprocess("id IN ( ? ) AND name IN (?)", []interface{}{[]int{1, 2}, []string{}})
+		assert.Equal(t, "id IN ( ?, ? ) AND name IN (NULL)", ret)
+		assert.Equal(t, []interface{}{1, 2}, args)
+	}
+
+	{
+		ret, _ := Preprocess("(?, ?)", []interface{}{[]int{1, 2}, 3})
+		assert.Equal(t, "((?, ?), ?)", ret)
+	}
+
+	{
+		ret, _ := Preprocess("id = (?)", []interface{}{db.Raw("SELECT MAX(id) FROM artist")})
+		assert.Equal(t, "id = (SELECT MAX(id) FROM artist)", ret)
+	}
+}
+
 func TestPlaceholderArguments(t *testing.T) {
 	{
 		_, args := Preprocess("?, ?, ?", []interface{}{1, 2, []interface{}{3, 4, 5}})
@@ -81,3 +105,44 @@ func TestPlaceholderReplace(t *testing.T) {
 		assert.Equal(t, []interface{}{1, 3}, args)
 	}
 }
+
+func TestPlaceholderNamed(t *testing.T) {
+	{
+		ret, args := Preprocess("id = :id AND name = :name", []interface{}{map[string]interface{}{"id": 1, "name": "Hayao"}})
+		assert.Equal(t, "id = ? AND name = ?", ret)
+		assert.Equal(t, []interface{}{1, "Hayao"}, args)
+	}
+
+	{
+		ret, args := Preprocess("id IN :ids OR parent_id IN :ids", []interface{}{map[string]interface{}{"ids": []int{1, 2}}})
+		assert.Equal(t, "id IN (?, ?) OR parent_id IN (?, ?)", ret)
+		assert.Equal(t, []interface{}{1, 2, 1, 2}, args)
+	}
+
+	{
+		type artist struct {
+			ID   int    `db:"id"`
+			Name string `db:"name"`
+		}
+		ret, args := Preprocess("name = :name AND id > :id", []interface{}{&artist{ID: 3, Name: "Miyazaki"}})
+		assert.Equal(t, "name = ? AND id > ?", ret)
+		assert.Equal(t, []interface{}{"Miyazaki", 3}, args)
+	}
+
+	{
+		ret, args := Preprocess("created::date = :day AND note = ':day' AND x := :missing", []interface{}{map[string]string{"day": "2020-01-01"}})
+		assert.Equal(t, "created::date = ? AND note = ':day' AND x := :missing", ret)
+		assert.Equal(t, []interface{}{"2020-01-01"}, args)
+	}
+
+	{
+		ret, args := Preprocess("id = ? AND name = :name", []interface{}{map[string]interface{}{"name": "Hayao"}})
+		assert.Equal(t, "id = ? AND name = :name", ret)
+		assert.Equal(t, []interface{}{map[string]interface{}{"name": "Hayao"}}, args)
+	}
+
+	{
+		ret, _ := Preprocess("id = :id", []interface{}{db.Raw("1")})
+		assert.Equal(t, "id = :id", ret)
+	}
+}
@@ -23,6 +23,11 @@ package sqlbuilder
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
 
 	db "github.com/upper/db/v4"
 )
@@ -36,3 +41,242 @@ func (u scanner) Scan(v interface{}) error {
 }
 
 var _ sql.Scanner = scanner{}
+
+// converterScanner scans a column value into dst by means of a converter that
+// was registered with db.RegisterConverter.
+type converterScanner struct {
+	fn  db.ConverterFunc
+	dst reflect.Value
+}
+
+func (c converterScanner) Scan(src interface{}) error {
+	v, err := c.fn(src)
+	if err != nil {
+		return err
+	}
+
+	if v == nil {
+		c.dst.Set(reflect.Zero(c.dst.Type()))
+		return nil
+	}
+
+	dst := c.dst
+	if dst.Kind() == reflect.Ptr && reflect.TypeOf(v) != dst.Type() {
+		dst.Set(reflect.New(dst.Type().Elem()))
+		dst = dst.Elem()
+	}
+
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.Type().AssignableTo(dst.Type()):
+		dst.Set(rv)
+	case rv.Type().ConvertibleTo(dst.Type()):
+		dst.Set(rv.Convert(dst.Type()))
+	default:
+		return fmt.Errorf("converter returned %T, expecting %v", v, dst.Type())
+	}
+	return nil
+}
+
+// columnTypes returns the database type of each column, or nil if there are
+// no converters that would use them.
+func columnTypes(rows *sql.Rows) ([]string, error) {
+	if !db.HasConverters() {
+		return nil, nil
+	}
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(types))
+	for i := range types {
+		names[i] = types[i].DatabaseTypeName()
+	}
+	return names, nil
+}
+
+// convertedField returns a scanner that uses the converter registered for
+// the given SQL type and the type of field, or nil if there is none.
+func convertedField(sqlType string, field reflect.Value) sql.Scanner {
+	if fn := db.LookupConverter(sqlType, field.Type()); fn != nil {
+		return converterScanner{fn, field}
+	}
+	if field.Kind() == reflect.Ptr {
+		if fn := db.LookupConverter(sqlType, field.Type().Elem()); fn != nil {
+			return converterScanner{fn, field}
+		}
+	}
+	return nil
+}
+
+// boolScanner scans a column value into dst, a bool or *bool, by means of a
+// bool mapping.
+type boolScanner struct {
+	mapping db.BoolMapping
+	dst     reflect.Value
+}
+
+func (b boolScanner) Scan(src interface{}) error {
+	if src == nil && b.dst.Kind() == reflect.Ptr {
+		b.dst.Set(reflect.Zero(b.dst.Type()))
+		return nil
+	}
+
+	v, err := b.mapping.Bool(src)
+	if err != nil {
+		return err
+	}
+
+	dst := b.dst
+	if dst.Kind() == reflect.Ptr {
+		dst.Set(reflect.New(dst.Type().Elem()))
+		dst = dst.Elem()
+	}
+	dst.SetBool(v)
+	return nil
+}
+
+// fieldBoolMapping returns the bool mapping of a struct field of type t,
+// given either by its bool tag option or by the session, or nil if the field
+// is not a bool or doesn't need to be mapped.
+func fieldBoolMapping(sess interface{}, t reflect.Type, options map[string]string) (*db.BoolMapping, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Bool {
+		return nil, nil
+	}
+
+	if opt, ok := options["bool"]; ok {
+		return parseBoolOption(opt)
+	}
+
+	if s, ok := sess.(hasBoolMapping); ok {
+		return s.BoolMapping(), nil
+	}
+	return nil, nil
+}
+
+// isJSONField returns true if a field of type t with the given tag options is
+// stored as a JSON document. The json option only applies to structs, maps,
+// slices and arrays that can't be stored as they are; on other fields it's
+// ignored, as it was before it encoded values.
+func isJSONField(t reflect.Type, options map[string]string) bool {
+	if _, ok := options["json"]; !ok {
+		return false
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Implements(ValuerType) || reflect.PtrTo(t).Implements(ScannerType) || t == timeType {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Array, reflect.Interface:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// jsonScanner scans a JSON document from a column into dst, a field with the
+// json tag option. NULL is read as the zero value of the field.
+type jsonScanner struct {
+	dst reflect.Value
+}
+
+func (j jsonScanner) Scan(src interface{}) error {
+	j.dst.Set(reflect.Zero(j.dst.Type()))
+
+	var buf []byte
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		buf = v
+	case string:
+		buf = []byte(v)
+	default:
+		return fmt.Errorf("upper: can't decode JSON from %T", src)
+	}
+	return json.Unmarshal(buf, j.dst.Addr().Interface())
+}
+
+// nullZero scans a column into dst, a field with the nullzero tag option, so
+// that NULL is read as the zero value of the field: a zero value for plain
+// fields and a pointer to a zero value for pointer fields.
+type nullZero struct {
+	dst reflect.Value
+	ptr reflect.Value
+}
+
+func newNullZero(dst reflect.Value) *nullZero {
+	if dst.Kind() == reflect.Ptr {
+		return &nullZero{dst: dst, ptr: dst.Addr()}
+	}
+	return &nullZero{dst: dst, ptr: reflect.New(reflect.PtrTo(dst.Type()))}
+}
+
+// target returns the value that must be passed to rows.Scan.
+func (n *nullZero) target() interface{} {
+	return n.ptr.Interface()
+}
+
+// apply copies the scanned value into dst, must be called after rows.Scan.
+func (n *nullZero) apply() {
+	if n.dst.Kind() == reflect.Ptr {
+		if n.dst.IsNil() {
+			n.dst.Set(reflect.New(n.dst.Type().Elem()))
+		}
+		return
+	}
+	if v := n.ptr.Elem(); v.IsNil() {
+		n.dst.Set(reflect.Zero(n.dst.Type()))
+	} else {
+		n.dst.Set(v.Elem())
+	}
+}
+
+// parsedBoolOption is the result of parsing a bool tag option.
+type parsedBoolOption struct {
+	mapping *db.BoolMapping
+	err     error
+}
+
+var boolOptionCache sync.Map // map[string]*parsedBoolOption
+
+// parseBoolOption parses the value of a bool tag option, like "Y/N". Each
+// value is parsed once and cached.
+func parseBoolOption(opt string) (*db.BoolMapping, error) {
+	v, ok := boolOptionCache.Load(opt)
+	if !ok {
+		p := &parsedBoolOption{}
+		if mapping, err := db.ParseBoolMapping(opt); err != nil {
+			p.err = err
+		} else {
+			p.mapping = &mapping
+		}
+		v, _ = boolOptionCache.LoadOrStore(opt, p)
+	}
+	p := v.(*parsedBoolOption)
+	return p.mapping, p.err
+}
+
+type hasBoolMapping interface {
+	BoolMapping() *db.BoolMapping
+}
+
+type hasMaskingPolicy interface {
+	MaskingPolicy() *db.MaskingPolicy
+}
+
+var (
+	_ sql.Scanner = converterScanner{}
+	_ sql.Scanner = boolScanner{}
+	_ sql.Scanner = jsonScanner{}
+)
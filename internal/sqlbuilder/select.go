@@ -14,6 +14,9 @@ import (
 )
 
 type selectorQuery struct {
+	with     *exql.With
+	withArgs []interface{}
+
 	table     *exql.Columns
 	tableArgs []interface{}
 
@@ -25,6 +28,9 @@ type selectorQuery struct {
 	groupBy     *exql.GroupBy
 	groupByArgs []interface{}
 
+	having     *exql.Having
+	havingArgs []interface{}
+
 	orderBy     *exql.OrderBy
 	orderByArgs []interface{}
 
@@ -37,6 +43,8 @@ type selectorQuery struct {
 	joins     []*exql.Join
 	joinsArgs []interface{}
 
+	lock *exql.Lock
+
 	amendFn func(string) string
 }
 
@@ -54,11 +62,13 @@ func (sq *selectorQuery) and(b *sqlBuilder, terms ...interface{}) error {
 
 func (sq *selectorQuery) arguments() []interface{} {
 	return joinArguments(
+		sq.withArgs,
 		sq.columnsArgs,
 		sq.tableArgs,
 		sq.joinsArgs,
 		sq.whereArgs,
 		sq.groupByArgs,
+		sq.havingArgs,
 		sq.orderByArgs,
 	)
 }
@@ -74,18 +84,32 @@ func (sq *selectorQuery) statement() *exql.Statement {
 		Where:    sq.where,
 		OrderBy:  sq.orderBy,
 		GroupBy:  sq.groupBy,
+		Having:   sq.having,
+	}
+
+	if sq.with != nil {
+		stmt.With = sq.with
 	}
 
 	if len(sq.joins) > 0 {
 		stmt.Joins = exql.JoinConditions(sq.joins...)
 	}
 
+	if sq.lock != nil {
+		stmt.Lock = sq.lock
+	}
+
 	stmt.SetAmendment(sq.amendFn)
 
 	return stmt
 }
 
-func (sq *selectorQuery) pushJoin(t string, tables []interface{}) error {
+func (sq *selectorQuery) pushJoin(tu *templateWithUtils, t string, tables []interface{}) error {
+	tables, err := tu.expandValuesTables(tables)
+	if err != nil {
+		return err
+	}
+
 	fragments, args, err := columnFragments(tables)
 	if err != nil {
 		return err
@@ -143,6 +167,10 @@ func (sel *selector) clone() db.Selector {
 func (sel *selector) From(tables ...interface{}) db.Selector {
 	return sel.frame(
 		func(sq *selectorQuery) error {
+			tables, err := sel.SQL().t.expandValuesTables(tables)
+			if err != nil {
+				return err
+			}
 			fragments, args, err := columnFragments(tables)
 			if err != nil {
 				return err
@@ -154,6 +182,71 @@ func (sel *selector) From(tables ...interface{}) db.Selector {
 	)
 }
 
+func (sel *selector) With(name string, query interface{}) db.Selector {
+	return sel.frame(func(sq *selectorQuery) error {
+		return sel.pushWith(sq, name, query, false)
+	})
+}
+
+func (sel *selector) WithRecursive(name string, query interface{}) db.Selector {
+	return sel.frame(func(sq *selectorQuery) error {
+		return sel.pushWith(sq, name, query, true)
+	})
+}
+
+// pushWith adds a common table expression to the WITH clause of the query,
+// the clause becomes recursive if any of its tables is.
+func (sel *selector) pushWith(sq *selectorQuery, name string, query interface{}, recursive bool) error {
+	if sel.template().WithLayout == "" {
+		return fmt.Errorf("%w: common table expressions", db.ErrUnsupported)
+	}
+
+	q, err := asSubquery(query)
+	if err != nil {
+		return err
+	}
+
+	var compiled string
+	var args []interface{}
+	switch v := q.(type) {
+	case compilable:
+		s, err := v.Compile()
+		if err != nil {
+			return err
+		}
+		compiled, args = Preprocess(s, v.Arguments())
+	case *adapter.RawExpr:
+		compiled, args = Preprocess(v.Raw(), v.Arguments())
+	default:
+		return fmt.Errorf("unexpected argument type %T for With()", query)
+	}
+
+	table := &exql.CommonTable{
+		Query: exql.RawValue(compiled),
+	}
+
+	// The name may be followed by a list of columns: name(a, b).
+	if i := strings.Index(name, "("); i > 0 && strings.HasSuffix(name, ")") {
+		names := strings.Split(name[i+1:len(name)-1], ",")
+		columns := make([]exql.Fragment, len(names))
+		for j := range names {
+			columns[j] = exql.ColumnWithName(strings.TrimSpace(names[j]))
+		}
+		table.Columns = exql.JoinColumns(columns...)
+		name = strings.TrimSpace(name[:i])
+	}
+	table.Name = exql.ColumnWithName(name)
+
+	if sq.with == nil {
+		sq.with = &exql.With{}
+	}
+	sq.with.Recursive = sq.with.Recursive || recursive
+	sq.with.Tables = append(sq.with.Tables, table)
+	sq.withArgs = append(sq.withArgs, args...)
+
+	return nil
+}
+
 func (sel *selector) setColumns(columns ...interface{}) db.Selector {
 	return sel.frame(func(sq *selectorQuery) error {
 		sq.columns = nil
@@ -239,6 +332,24 @@ func (sel *selector) GroupBy(columns ...interface{}) db.Selector {
 	})
 }
 
+func (sel *selector) Having(terms ...interface{}) db.Selector {
+	return sel.frame(func(sq *selectorQuery) error {
+		if sel.template().HavingLayout == "" {
+			return fmt.Errorf("%w: HAVING clauses", db.ErrUnsupported)
+		}
+
+		having, havingArgs := sel.SQL().t.toWhereWithArguments(terms)
+
+		if sq.having == nil {
+			sq.having, sq.havingArgs = &exql.Having{}, []interface{}{}
+		}
+		sq.having.Append(&having)
+		sq.havingArgs = append(sq.havingArgs, havingArgs...)
+
+		return nil
+	})
+}
+
 func (sel *selector) OrderBy(columns ...interface{}) db.Selector {
 	return sel.frame(func(sq *selectorQuery) error {
 
@@ -329,33 +440,39 @@ func (sel *selector) Using(columns ...interface{}) db.Selector {
 	})
 }
 
+func (sel *selector) InnerJoin(tables ...interface{}) db.Selector {
+	return sel.frame(func(sq *selectorQuery) error {
+		return sq.pushJoin(sel.SQL().t, "INNER", tables)
+	})
+}
+
 func (sel *selector) FullJoin(tables ...interface{}) db.Selector {
 	return sel.frame(func(sq *selectorQuery) error {
-		return sq.pushJoin("FULL", tables)
+		return sq.pushJoin(sel.SQL().t, "FULL", tables)
 	})
 }
 
 func (sel *selector) CrossJoin(tables ...interface{}) db.Selector {
 	return sel.frame(func(sq *selectorQuery) error {
-		return sq.pushJoin("CROSS", tables)
+		return sq.pushJoin(sel.SQL().t, "CROSS", tables)
 	})
 }
 
 func (sel *selector) RightJoin(tables ...interface{}) db.Selector {
 	return sel.frame(func(sq *selectorQuery) error {
-		return sq.pushJoin("RIGHT", tables)
+		return sq.pushJoin(sel.SQL().t, "RIGHT", tables)
 	})
 }
 
 func (sel *selector) LeftJoin(tables ...interface{}) db.Selector {
 	return sel.frame(func(sq *selectorQuery) error {
-		return sq.pushJoin("LEFT", tables)
+		return sq.pushJoin(sel.SQL().t, "LEFT", tables)
 	})
 }
 
 func (sel *selector) Join(tables ...interface{}) db.Selector {
 	return sel.frame(func(sq *selectorQuery) error {
-		return sq.pushJoin("", tables)
+		return sq.pushJoin(sel.SQL().t, "", tables)
 	})
 }
 
@@ -403,6 +520,33 @@ func (sel *selector) Offset(n int) db.Selector {
 	})
 }
 
+func (sel *selector) ForUpdate(opts ...db.LockOption) db.Selector {
+	return sel.frame(func(sq *selectorQuery) error {
+		sq.lock = newLock(exql.LockForUpdate, opts)
+		return nil
+	})
+}
+
+func (sel *selector) ForShare(opts ...db.LockOption) db.Selector {
+	return sel.frame(func(sq *selectorQuery) error {
+		sq.lock = newLock(exql.LockForShare, opts)
+		return nil
+	})
+}
+
+func newLock(mode string, opts []db.LockOption) *exql.Lock {
+	lock := &exql.Lock{Mode: mode}
+	for _, opt := range opts {
+		switch opt {
+		case db.NoWait:
+			lock.Wait = exql.LockNoWait
+		case db.SkipLocked:
+			lock.Wait = exql.LockSkipLocked
+		}
+	}
+	return lock
+}
+
 func (sel *selector) template() *exql.Template {
 	return sel.SQL().t.Template
 }
@@ -474,11 +618,11 @@ func (sel *selector) IteratorContext(ctx context.Context) db.Iterator {
 	sess := sel.SQL().sess
 	sq, err := sel.build()
 	if err != nil {
-		return &iterator{sess, nil, err}
+		return &iterator{sess: sess, err: err}
 	}
 
 	rows, err := sess.StatementQuery(ctx, sq.statement(), sq.arguments()...)
-	return &iterator{sess, rows, err}
+	return &iterator{sess: sess, ctx: ctx, cursor: rows, err: err, query: maskedQuery(sess, sel.template(), sq.statement())}
 }
 
 func (sel *selector) Paginate(pageSize uint) db.Paginator {
@@ -489,6 +633,10 @@ func (sel *selector) All(destSlice interface{}) error {
 	return sel.Iterator().All(destSlice)
 }
 
+func (sel *selector) AllColumns(destStruct interface{}) error {
+	return sel.Iterator().AllColumns(destStruct)
+}
+
 func (sel *selector) One(dest interface{}) error {
 	return sel.Iterator().One(dest)
 }
@@ -7,13 +7,6 @@ import (
 	"github.com/upper/db/v4"
 )
 
-// Engine represents a SQL database engine.
-type Engine interface {
-	db.Session
-
-	db.SQL
-}
-
 func lookupAdapter(adapterName string) (Adapter, error) {
 	adapter := db.LookupAdapter(adapterName)
 	if sqlAdapter, ok := adapter.(Adapter); ok {
@@ -10,6 +10,12 @@ import (
 	"github.com/upper/db/v4/internal/sqladapter/exql"
 )
 
+// precompiledWhere holds the compiled form of an adapter.PrecompiledExpr.
+type precompiledWhere struct {
+	where exql.Where
+	args  []interface{}
+}
+
 type templateWithUtils struct {
 	*exql.Template
 }
@@ -93,6 +99,18 @@ func (tu *templateWithUtils) toWhereWithArguments(term interface{}) (where exql.
 			where.Conditions = append(where.Conditions, w.Conditions...)
 		}
 		return
+	case *adapter.PrecompiledExpr:
+		c, ok := t.Compiled(tu.Template)
+		if !ok {
+			w, v := tu.toWhereWithArguments(t.Expr())
+			c = &precompiledWhere{where: w, args: v}
+			t.SetCompiled(tu.Template, c)
+		}
+		pw := c.(*precompiledWhere)
+		where.Conditions = append(where.Conditions, pw.where.Conditions...)
+		args = append(args, pw.args...)
+		return where, args
+
 	case adapter.LogicalExpr:
 		var cond exql.Where
 
@@ -109,7 +127,7 @@ func (tu *templateWithUtils) toWhereWithArguments(term interface{}) (where exql.
 			return
 		}
 
-		if len(cond.Conditions) <= 1 {
+		if len(cond.Conditions) <= 1 && t.Operator() != adapter.LogicalOperatorNot {
 			where.Conditions = append(where.Conditions, cond.Conditions...)
 			return where, args
 		}
@@ -122,6 +140,9 @@ func (tu *templateWithUtils) toWhereWithArguments(term interface{}) (where exql.
 		case adapter.LogicalOperatorOr:
 			q := exql.Or(cond)
 			frag = &q
+		case adapter.LogicalOperatorNot:
+			q := exql.Not(cond)
+			frag = &q
 		default:
 			panic(fmt.Sprintf("Unknown type %T", t))
 		}
@@ -196,6 +217,16 @@ func (tu *templateWithUtils) toColumnValues(term interface{}) (cv exql.ColumnVal
 			q, a := Preprocess(value.Raw(), value.Arguments())
 			columnValue.Value = exql.RawValue(q)
 			args = append(args, a...)
+		case *adapter.ValuesTableExpr:
+			q, a, err := tu.valuesTable(value)
+			if err != nil {
+				panic(err.Error())
+			}
+			if columnValue.Operator == "" {
+				columnValue.Operator = tu.comparisonOperatorMapper(adapter.ComparisonOperatorIn)
+			}
+			columnValue.Value = exql.RawValue("(SELECT * FROM " + q + ")")
+			args = append(args, a...)
 		case driver.Valuer:
 			columnValue.Value = exql.RawValue("?")
 			args = append(args, value)
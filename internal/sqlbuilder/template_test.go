@@ -64,6 +64,8 @@ const (
   `
 
 	defaultSelectLayout = `
+    {{.With | compile}}
+
     SELECT
       {{if .Distinct}}
         DISTINCT
@@ -87,6 +89,10 @@ const (
         {{.GroupBy | compile}}
       {{end}}
 
+      {{if defined .Having}}
+        {{.Having | compile}}
+      {{end}}
+
       {{.OrderBy | compile}}
 
       {{if .Limit}}
@@ -96,11 +102,18 @@ const (
       {{if .Offset}}
         OFFSET {{.Offset}}
       {{end}}
+
+      {{if defined .Lock}}
+        {{.Lock | compile}}
+      {{end}}
   `
 	defaultDeleteLayout = `
     DELETE
       FROM {{.Table | compile}}
       {{.Where | compile}}
+    {{if defined .Returning}}
+      RETURNING {{.Returning | compile}}
+    {{end}}
   `
 	defaultUpdateLayout = `
     UPDATE
@@ -133,11 +146,34 @@ const (
     {{else}}
       (default)
     {{end}}
+    {{if defined .OnConflict}}
+      {{.OnConflict | compile}}
+    {{else if .Ignore}}
+      ON CONFLICT DO NOTHING
+    {{end}}
     {{if defined .Returning}}
       RETURNING {{.Returning | compile}}
     {{end}}
   `
 
+	defaultOnConflictLayout = `
+    ON CONFLICT {{if .Columns}}({{.Columns}}){{end}}
+    {{if .Update}}
+      DO UPDATE SET {{.Update}}
+    {{else}}
+      DO NOTHING
+    {{end}}
+  `
+
+	defaultOnConflictUpdateLayout = `{{.Column}} = EXCLUDED.{{.Column}}`
+
+	defaultLockLayout = `
+    FOR {{.Mode}}
+    {{if .Wait}}
+      {{.Wait}}
+    {{end}}
+  `
+
 	defaultTruncateLayout = `
     TRUNCATE TABLE {{.Table | compile}}
   `
@@ -154,39 +190,66 @@ const (
     {{if .GroupColumns}}
       GROUP BY {{.GroupColumns}}
     {{end}}
+  `
+
+	defaultHavingLayout = `
+    {{if .Conds}}
+      HAVING {{.Conds}}
+    {{end}}
+  `
+
+	defaultWithLayout = `
+    {{if .Tables}}
+      WITH {{if .Recursive}}RECURSIVE{{end}} {{.Tables}}
+    {{end}}
+  `
+
+	defaultValuesTableLayout = `
+    (VALUES {{range $i, $row := .Rows}}{{if $i}}, {{end}}{{$row}}{{end}}) AS {{.Name}} ({{.Columns}})
+  `
+
+	defaultCallLayout = `
+    CALL {{.Name}}({{range $i, $arg := .Arguments}}{{if $i}}, {{end}}{{$arg.Value}}{{end}})
   `
 )
 
 var testTemplate = exql.Template{
-	ColumnSeparator:     defaultColumnSeparator,
-	IdentifierSeparator: defaultIdentifierSeparator,
-	IdentifierQuote:     defaultIdentifierQuote,
-	ValueSeparator:      defaultValueSeparator,
-	ValueQuote:          defaultValueQuote,
-	AndKeyword:          defaultAndKeyword,
-	OrKeyword:           defaultOrKeyword,
-	DescKeyword:         defaultDescKeyword,
-	AscKeyword:          defaultAscKeyword,
-	AssignmentOperator:  defaultAssignmentOperator,
-	ClauseGroup:         defaultClauseGroup,
-	ClauseOperator:      defaultClauseOperator,
-	ColumnValue:         defaultColumnValue,
-	TableAliasLayout:    defaultTableAliasLayout,
-	ColumnAliasLayout:   defaultColumnAliasLayout,
-	SortByColumnLayout:  defaultSortByColumnLayout,
-	WhereLayout:         defaultWhereLayout,
-	OnLayout:            defaultOnLayout,
-	UsingLayout:         defaultUsingLayout,
-	JoinLayout:          defaultJoinLayout,
-	OrderByLayout:       defaultOrderByLayout,
-	InsertLayout:        defaultInsertLayout,
-	SelectLayout:        defaultSelectLayout,
-	UpdateLayout:        defaultUpdateLayout,
-	DeleteLayout:        defaultDeleteLayout,
-	TruncateLayout:      defaultTruncateLayout,
-	DropDatabaseLayout:  defaultDropDatabaseLayout,
-	DropTableLayout:     defaultDropTableLayout,
-	CountLayout:         defaultCountLayout,
-	GroupByLayout:       defaultGroupByLayout,
-	Cache:               cache.NewCache(),
+	ColumnSeparator:        defaultColumnSeparator,
+	IdentifierSeparator:    defaultIdentifierSeparator,
+	IdentifierQuote:        defaultIdentifierQuote,
+	ValueSeparator:         defaultValueSeparator,
+	ValuesTableLayout:      defaultValuesTableLayout,
+	ValueQuote:             defaultValueQuote,
+	AndKeyword:             defaultAndKeyword,
+	OrKeyword:              defaultOrKeyword,
+	DescKeyword:            defaultDescKeyword,
+	AscKeyword:             defaultAscKeyword,
+	AssignmentOperator:     defaultAssignmentOperator,
+	ClauseGroup:            defaultClauseGroup,
+	ClauseOperator:         defaultClauseOperator,
+	ColumnValue:            defaultColumnValue,
+	TableAliasLayout:       defaultTableAliasLayout,
+	ColumnAliasLayout:      defaultColumnAliasLayout,
+	SortByColumnLayout:     defaultSortByColumnLayout,
+	WhereLayout:            defaultWhereLayout,
+	OnLayout:               defaultOnLayout,
+	OnConflictLayout:       defaultOnConflictLayout,
+	OnConflictUpdateLayout: defaultOnConflictUpdateLayout,
+	UsingLayout:            defaultUsingLayout,
+	JoinLayout:             defaultJoinLayout,
+	LockLayout:             defaultLockLayout,
+	OrderByLayout:          defaultOrderByLayout,
+	InsertLayout:           defaultInsertLayout,
+	SelectLayout:           defaultSelectLayout,
+	UpdateLayout:           defaultUpdateLayout,
+	DeleteLayout:           defaultDeleteLayout,
+	TruncateLayout:         defaultTruncateLayout,
+	DropDatabaseLayout:     defaultDropDatabaseLayout,
+	DropTableLayout:        defaultDropTableLayout,
+	CountLayout:            defaultCountLayout,
+	GroupByLayout:          defaultGroupByLayout,
+	HavingLayout:           defaultHavingLayout,
+	WithLayout:             defaultWithLayout,
+	CallLayout:             defaultCallLayout,
+	Cache:                  cache.NewCache(),
 }
@@ -20,6 +20,8 @@ type updaterQuery struct {
 	where     *exql.Where
 	whereArgs []interface{}
 
+	returning []exql.Fragment
+
 	amendFn func(string) string
 }
 
@@ -50,6 +52,10 @@ func (uq *updaterQuery) statement() *exql.Statement {
 		stmt.Limit = exql.Limit(uq.limit)
 	}
 
+	if len(uq.returning) > 0 {
+		stmt.Returning = exql.ReturningColumns(uq.returning...)
+	}
+
 	stmt.SetAmendment(uq.amendFn)
 
 	return stmt
@@ -192,6 +198,47 @@ func (upd *updater) ExecContext(ctx context.Context) (sql.Result, error) {
 	return upd.SQL().sess.StatementExec(ctx, uq.statement(), uq.arguments()...)
 }
 
+func (upd *updater) Returning(columns ...string) db.Updater {
+	return upd.frame(func(uq *updaterQuery) error {
+		columnsToFragments(&uq.returning, columns)
+		return nil
+	})
+}
+
+func (upd *updater) Query() (*sql.Rows, error) {
+	return upd.QueryContext(upd.SQL().sess.Context())
+}
+
+func (upd *updater) QueryContext(ctx context.Context) (*sql.Rows, error) {
+	uq, err := upd.build()
+	if err != nil {
+		return nil, err
+	}
+	return upd.SQL().sess.StatementQuery(ctx, uq.statement(), uq.arguments()...)
+}
+
+func (upd *updater) QueryRow() (*sql.Row, error) {
+	return upd.QueryRowContext(upd.SQL().sess.Context())
+}
+
+func (upd *updater) QueryRowContext(ctx context.Context) (*sql.Row, error) {
+	uq, err := upd.build()
+	if err != nil {
+		return nil, err
+	}
+	return upd.SQL().sess.StatementQueryRow(ctx, uq.statement(), uq.arguments()...)
+}
+
+func (upd *updater) Iterator() db.Iterator {
+	return upd.IteratorContext(upd.SQL().sess.Context())
+}
+
+func (upd *updater) IteratorContext(ctx context.Context) db.Iterator {
+	rows, err := upd.QueryContext(ctx)
+	sess := upd.SQL().sess
+	return &iterator{sess: sess, ctx: ctx, cursor: rows, err: err, written: true, query: maskedQuery(sess, nil, upd)}
+}
+
 func (upd *updater) Limit(limit int) db.Updater {
 	return upd.frame(func(uq *updaterQuery) error {
 		uq.limit = limit
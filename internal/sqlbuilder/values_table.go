@@ -0,0 +1,111 @@
+package sqlbuilder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	db "github.com/upper/db/v4"
+	"github.com/upper/db/v4/internal/adapter"
+	"github.com/upper/db/v4/internal/sqladapter/exql"
+)
+
+// defaultValuesTableName is the name of values tables that were not given
+// one with As.
+const defaultValuesTableName = "v"
+
+// valuesTable compiles the given values table and returns its arguments.
+func (tu *templateWithUtils) valuesTable(vt *adapter.ValuesTableExpr) (string, []interface{}, error) {
+	if tu.ValuesTableLayout == "" {
+		return "", nil, fmt.Errorf("%w: values tables", db.ErrUnsupported)
+	}
+
+	rows := vt.Rows()
+	if len(rows) == 0 {
+		return "", nil, errors.New("upper: values table has no rows")
+	}
+
+	width := len(rows[0])
+	columns := vt.Columns()
+	if len(columns) == 0 {
+		columns = make([]string, width)
+		for i := range columns {
+			columns[i] = fmt.Sprintf("column%d", i+1)
+		}
+	}
+	if len(columns) != width {
+		return "", nil, fmt.Errorf("upper: values table has %d columns but rows have %d values", len(columns), width)
+	}
+
+	name := vt.Name()
+	if name == "" {
+		name = defaultValuesTableName
+	}
+
+	table := &exql.ValuesTable{
+		Name:    exql.ColumnWithName(name),
+		Columns: make([]exql.Fragment, width),
+		Rows:    make([]*exql.Values, 0, len(rows)),
+	}
+
+	// A column may be followed by the type its values are cast to.
+	types := make([]string, width)
+	for i := range columns {
+		column := strings.TrimSpace(columns[i])
+		if j := strings.IndexAny(column, " \t"); j > 0 {
+			column, types[i] = column[:j], strings.TrimSpace(column[j:])
+		}
+		table.Columns[i] = exql.ColumnWithName(column)
+	}
+
+	args := make([]interface{}, 0, len(rows)*width)
+	for i := range rows {
+		if len(rows[i]) != width {
+			return "", nil, fmt.Errorf("upper: row %d of values table has %d values, expecting %d", i, len(rows[i]), width)
+		}
+		values := make([]exql.Fragment, width)
+		for j := range rows[i] {
+			value, valueArgs := tu.PlaceholderValue(rows[i][j])
+			if i == 0 && types[j] != "" {
+				compiled, err := value.Compile(tu.Template)
+				if err != nil {
+					return "", nil, err
+				}
+				value = exql.RawValue("CAST(" + compiled + " AS " + types[j] + ")")
+			}
+			values[j] = value
+			args = append(args, valueArgs...)
+		}
+		table.Rows = append(table.Rows, exql.NewValueGroup(values...))
+	}
+
+	compiled, err := table.Compile(tu.Template)
+	if err != nil {
+		return "", nil, err
+	}
+	return compiled, args, nil
+}
+
+// expandValuesTables replaces the values tables in the given list of tables
+// with their compiled form.
+func (tu *templateWithUtils) expandValuesTables(tables []interface{}) ([]interface{}, error) {
+	var expanded []interface{}
+	for i := range tables {
+		vt, ok := tables[i].(*adapter.ValuesTableExpr)
+		if !ok {
+			continue
+		}
+		if expanded == nil {
+			expanded = append([]interface{}(nil), tables...)
+		}
+		compiled, args, err := tu.valuesTable(vt)
+		if err != nil {
+			return nil, err
+		}
+		expanded[i] = adapter.NewRawExpr(compiled, args)
+	}
+	if expanded == nil {
+		return tables, nil
+	}
+	return expanded, nil
+}
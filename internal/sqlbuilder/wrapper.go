@@ -23,6 +23,7 @@ package sqlbuilder
 
 import (
 	"database/sql"
+	"fmt"
 
 	db "github.com/upper/db/v4"
 )
@@ -80,6 +81,38 @@ func (d *dbAdapter) Open(conn db.ConnectionURL) (db.Session, error) {
 	return sess.(db.Session), nil
 }
 
+// Capabilities returns the capabilities declared by the wrapped adapter, if
+// any.
+func (d *dbAdapter) Capabilities() db.Capability {
+	if ac, ok := d.Adapter.(db.AdapterCapabilities); ok {
+		return ac.Capabilities()
+	}
+	return db.CapabilityNone
+}
+
+// SQL returns a builder that is not connected to any database, if the wrapped
+// adapter can provide one.
+func (d *dbAdapter) SQL() db.SQL {
+	if c, ok := d.Adapter.(db.AdapterCompiler); ok {
+		return c.SQL()
+	}
+	return nil
+}
+
+// ParseURL parses a connection URL with the wrapped adapter, if it can.
+func (d *dbAdapter) ParseURL(s string) (db.ConnectionURL, error) {
+	if p, ok := d.Adapter.(db.AdapterURLParser); ok {
+		return p.ParseURL(s)
+	}
+	return nil, fmt.Errorf("%w: parsing connection URLs", db.ErrUnsupported)
+}
+
 func NewCompatAdapter(adapter Adapter) db.Adapter {
 	return &dbAdapter{adapter}
 }
+
+var (
+	_ = db.AdapterCapabilities(&dbAdapter{})
+	_ = db.AdapterCompiler(&dbAdapter{})
+	_ = db.AdapterURLParser(&dbAdapter{})
+)
@@ -26,7 +26,7 @@ import (
 
 	"github.com/stretchr/testify/suite"
 	db "github.com/upper/db/v4"
-	"gopkg.in/mgo.v2/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type birthday struct {
@@ -47,23 +47,23 @@ type oddEven struct {
 	// Test for JSON option.
 	Input int `json:"input" db:"input"`
 	// Test for JSON option.
-	// The "bson" tag is required by mgo.
+	// The "bson" tag is required by the MongoDB driver.
 	IsEven bool `json:"is_even" db:"is_even,json" bson:"is_even"`
 	OmitMe bool `json:"omit_me" db:"-" bson:"-"`
 }
 
 // Struct that relies on explicit mapping.
 type mapE struct {
-	ID       uint          `db:"id,omitempty" bson:"-"`
-	MongoID  bson.ObjectId `db:"-" bson:"_id,omitempty"`
-	CaseTest string        `db:"case_test" bson:"case_test"`
+	ID       uint               `db:"id,omitempty" bson:"-"`
+	MongoID  primitive.ObjectID `db:"-" bson:"_id,omitempty"`
+	CaseTest string             `db:"case_test" bson:"case_test"`
 }
 
 // Struct that will fallback to default mapping.
 type mapN struct {
-	ID        uint          `db:"id,omitempty"`
-	MongoID   bson.ObjectId `db:"-" bson:"_id,omitempty"`
-	Case_TEST string        `db:"case_test"`
+	ID        uint               `db:"id,omitempty"`
+	MongoID   primitive.ObjectID `db:"-" bson:"_id,omitempty"`
+	Case_TEST string             `db:"case_test"`
 }
 
 // Struct for testing marshalling.
@@ -156,7 +156,7 @@ func (s *GenericTestSuite) TestDatesAndUnicode() {
 	var res db.Result
 	switch s.Adapter() {
 	case "mongo":
-		res = col.Find(db.Cond{"_id": record.ID().(bson.ObjectId)})
+		res = col.Find(db.Cond{"_id": record.ID().(primitive.ObjectID)})
 	case "ql":
 		res = col.Find(db.Cond{"id()": record.ID()})
 	default:
@@ -441,7 +441,7 @@ func (s *GenericTestSuite) TestOddEven() {
 	res = col.Find()
 
 	var item2 struct {
-		Value uint `db:"input" bson:"input"` // The "bson" tag is required by mgo.
+		Value uint `db:"input" bson:"input"` // The "bson" tag is required by the MongoDB driver.
 	}
 	for res.Next(&item2) {
 		s.NotZero(item2.Value % 2)
@@ -521,7 +521,7 @@ func (s *GenericTestSuite) TestExplicitAndDefaultMapping() {
 	s.NoError(err)
 
 	if s.Adapter() == "mongo" {
-		s.True(testE.MongoID.Valid())
+		s.False(testE.MongoID.IsZero())
 	} else {
 		s.NotZero(testE.ID)
 	}
@@ -548,7 +548,7 @@ func (s *GenericTestSuite) TestExplicitAndDefaultMapping() {
 	s.NoError(err)
 
 	if s.Adapter() == `mongo` {
-		s.True(testN.MongoID.Valid())
+		s.False(testN.MongoID.IsZero())
 	} else {
 		s.NotZero(testN.ID)
 	}
@@ -1,10 +1,12 @@
 package testsuite
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"strconv"
 	"strings"
@@ -188,6 +190,18 @@ func (s *SQLTestSuite) TestTruncateAllCollections() {
 	}
 }
 
+func (s *SQLTestSuite) TestCollectionExists() {
+	sess := s.Session()
+
+	ok, err := sess.Collection("artist").Exists()
+	s.NoError(err)
+	s.True(ok)
+
+	ok, err = sess.Collection("artist_does_not_exist").Exists()
+	s.Error(err)
+	s.False(ok)
+}
+
 func (s *SQLTestSuite) TestQueryLogger() {
 	logLevel := db.LC().Level()
 
@@ -208,6 +222,122 @@ func (s *SQLTestSuite) TestQueryLogger() {
 	s.NotEqual(nil, err)
 }
 
+func (s *SQLTestSuite) TestDetectNPlusOne() {
+	logLevel := db.LC().Level()
+
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+
+	db.LC().SetLogger(logger)
+	db.LC().SetLevel(db.LogLevelWarn)
+
+	defer func() {
+		db.LC().SetLogger(nil)
+		db.LC().SetLevel(logLevel)
+	}()
+
+	sess := s.Session().WithContext(db.DetectNPlusOne(context.Background(), 3))
+
+	artist := sess.Collection("artist")
+	for i := 1; i <= 3; i++ {
+		_, err := artist.Find(db.Cond{"name": fmt.Sprintf("Artist %d", i)}).Count()
+		s.NoError(err)
+	}
+	s.NotContains(buf.String(), "N+1")
+
+	for i := 4; i <= 6; i++ {
+		_, err := artist.Find(db.Cond{"name": fmt.Sprintf("Artist %d", i)}).Count()
+		s.NoError(err)
+	}
+	warnings := 1
+	if s.Adapter() == "ql" {
+		// QL looks up the columns of the table on every Find.
+		warnings = 2
+	}
+	s.Equal(warnings, strings.Count(buf.String(), "possible N+1 query, ran 4 times"))
+	s.Contains(buf.String(), "TestDetectNPlusOne")
+}
+
+func (s *SQLTestSuite) TestMaskingPolicy() {
+	sess := s.Session().WithContext(context.Background())
+
+	policy := db.NewMaskingPolicy().Mask("name", "***", "admin")
+	s.NoError(db.SetMaskingPolicy(sess, policy))
+
+	var artist artistType
+	err := sess.Collection("artist").Find().OrderBy("id").One(&artist)
+	s.NoError(err)
+	s.NotZero(artist.ID)
+	s.Equal("***", artist.Name)
+
+	var names struct {
+		Name []string `db:"name"`
+	}
+	err = sess.Collection("artist").Find().AllColumns(&names)
+	s.NoError(err)
+	s.NotEmpty(names.Name)
+	for _, name := range names.Name {
+		s.Equal("***", name)
+	}
+
+	// Aliases, aggregates and subqueries can't be used to read the column.
+	var alias struct {
+		Name string `db:"n"`
+	}
+	err = sess.Collection("artist").Find().Select("name AS n").One(&alias)
+	s.NoError(err)
+	s.Equal("***", alias.Name)
+
+	var max string
+	err = sess.Collection("artist").Find().Max("name", &max)
+	s.NoError(err)
+	s.Equal("***", max)
+
+	var name string
+	err = sess.SQL().Iterator(`SELECT n FROM (SELECT name AS n FROM artist) AS t`).ScanOne(&name)
+	s.NoError(err)
+	s.Equal("***", name)
+
+	// So are the values read with Scan and Scanner.
+	err = sess.SQL().Select("name").From("artist").Iterator().ScanOne(&name)
+	s.NoError(err)
+	s.Equal("***", name)
+
+	r, err := sess.Collection("artist").Find().Scanner("name")
+	s.NoError(err)
+	var value bytes.Buffer
+	_, err = io.Copy(&value, r)
+	s.NoError(err)
+	s.NoError(r.Close())
+	s.Equal("***", value.String())
+
+	// Omitted columns are removed from maps.
+	policy.Omit("name", "admin")
+	var item map[string]interface{}
+	err = sess.Collection("artist").Find().OrderBy("id").One(&item)
+	s.NoError(err)
+	s.Contains(item, "id")
+	s.NotContains(item, "name")
+
+	// Aggregates of omitted columns have no value.
+	err = sess.Collection("artist").Find().Max("name", &max)
+	s.True(errors.Is(err, db.ErrNoMoreRows))
+
+	// Roles carried by the context unmask them, derived sessions inherit the
+	// policy.
+	admin := sess.WithContext(db.WithRoles(context.Background(), "admin"))
+	err = admin.Collection("artist").Find().OrderBy("id").One(&artist)
+	s.NoError(err)
+	s.NotEqual("***", artist.Name)
+	s.NotEmpty(artist.Name)
+
+	// Other sessions are not affected.
+	err = s.Session().Collection("artist").Find().OrderBy("id").One(&artist)
+	s.NoError(err)
+	s.NotEqual("***", artist.Name)
+}
+
 func (s *SQLTestSuite) TestExpectCursorError() {
 	sess := s.Session()
 
@@ -247,7 +377,7 @@ func (s *SQLTestSuite) TestInsertDefault() {
 	s.NotNil(id)
 }
 
-func (s *SQLTestSuite) TestInsertReturning() {
+func (s *SQLTestSuite) TestInsertMany() {
 	sess := s.Session()
 
 	artist := sess.Collection("artist")
@@ -255,125 +385,101 @@ func (s *SQLTestSuite) TestInsertReturning() {
 	err := artist.Truncate()
 	s.NoError(err)
 
-	itemMap := map[string]string{
-		"name": "Ozzie",
+	type artistType struct {
+		ID   int64  `db:"id,omitempty"`
+		Name string `db:"name"`
 	}
-	s.Zero(itemMap["id"], "Must be zero before inserting")
-	err = artist.InsertReturning(&itemMap)
-	s.NoError(err)
-	s.NotZero(itemMap["id"], "Must not be zero after inserting")
 
-	itemStruct := struct {
-		ID   int    `db:"id,omitempty"`
-		Name string `db:"name"`
-	}{
-		0,
-		"Flea",
+	items := make([]artistType, 25)
+	for i := range items {
+		items[i].Name = fmt.Sprintf("artist-%d", i)
 	}
-	s.Zero(itemStruct.ID, "Must be zero before inserting")
-	err = artist.InsertReturning(&itemStruct)
-	s.NoError(err)
-	s.NotZero(itemStruct.ID, "Must not be zero after inserting")
 
-	count, err := artist.Find().Count()
+	res, err := artist.InsertMany(items, 10)
 	s.NoError(err)
-	s.Equal(uint64(2), count, "Expecting 2 elements")
+	s.Equal(len(items), len(res))
 
-	itemStruct2 := struct {
-		ID   int    `db:"id,omitempty"`
-		Name string `db:"name"`
-	}{
-		0,
-		"Slash",
-	}
-	s.Zero(itemStruct2.ID, "Must be zero before inserting")
-	err = artist.InsertReturning(itemStruct2)
-	s.Error(err, "Should not happen, using a pointer should be enforced")
-	s.Zero(itemStruct2.ID, "Must still be zero because there was no insertion")
+	for i := range res {
+		s.NotNil(res[i].ID())
 
-	itemMap2 := map[string]string{
-		"name": "Janus",
+		var item artistType
+		err := artist.Find(res[i].ID()).One(&item)
+		s.NoError(err)
+		s.Equal(items[i].Name, item.Name)
 	}
-	s.Zero(itemMap2["id"], "Must be zero before inserting")
-	err = artist.InsertReturning(itemMap2)
-	s.Error(err, "Should not happen, using a pointer should be enforced")
-	s.Zero(itemMap2["id"], "Must still be zero because there was no insertion")
 
-	// Counting elements, must be exactly 2 elements.
-	count, err = artist.Find().Count()
+	count, err := artist.Find().Count()
 	s.NoError(err)
-	s.Equal(uint64(2), count, "Expecting 2 elements")
+	s.Equal(uint64(len(items)), count)
+
+	if s.Adapter() != "ql" {
+		// Items don't need to have the same columns.
+		res, err = artist.InsertMany([]interface{}{
+			map[string]interface{}{"name": "Ozzie"},
+			artistType{ID: 1000, Name: "Flea"},
+			artistType{Name: "Slash"},
+		}, 0)
+		s.NoError(err)
+		s.Equal(3, len(res))
+		s.Equal(int64(1000), res[1].ID())
+	}
+
+	_, err = artist.InsertMany(artistType{Name: "Chrono"}, 0)
+	s.Error(err, "Expecting a slice")
 }
 
-func (s *SQLTestSuite) TestInsertReturningWithinTransaction() {
+func (s *SQLTestSuite) TestUpsert() {
+	switch s.Adapter() {
+	case "mssql", "ql":
+		s.T().Skip("Currently not supported.")
+	}
+
 	sess := s.Session()
 
-	err := sess.Collection("artist").Truncate()
-	s.NoError(err)
+	artist := sess.Collection("artist")
 
-	err = sess.Tx(func(tx db.Session) error {
-		artist := tx.Collection("artist")
+	err := artist.Truncate()
+	s.NoError(err)
 
-		itemMap := map[string]string{
-			"name": "Ozzie",
-		}
-		s.Zero(itemMap["id"], "Must be zero before inserting")
-		err = artist.InsertReturning(&itemMap)
-		s.NoError(err)
-		s.NotZero(itemMap["id"], "Must not be zero after inserting")
+	type artistType struct {
+		ID   int64  `db:"id,omitempty"`
+		Name string `db:"name"`
+	}
 
-		itemStruct := struct {
-			ID   int    `db:"id,omitempty"`
-			Name string `db:"name"`
-		}{
-			0,
-			"Flea",
-		}
-		s.Zero(itemStruct.ID, "Must be zero before inserting")
-		err = artist.InsertReturning(&itemStruct)
-		s.NoError(err)
-		s.NotZero(itemStruct.ID, "Must not be zero after inserting")
+	res, err := artist.Upsert(artistType{Name: "Ozzie"})
+	s.NoError(err)
+	s.NotNil(res.ID())
 
-		count, err := artist.Find().Count()
-		s.NoError(err)
-		s.Equal(uint64(2), count, "Expecting 2 elements")
+	var item artistType
+	err = artist.Find(res.ID()).One(&item)
+	s.NoError(err)
+	s.Equal("Ozzie", item.Name)
 
-		itemStruct2 := struct {
-			ID   int    `db:"id,omitempty"`
-			Name string `db:"name"`
-		}{
-			0,
-			"Slash",
-		}
-		s.Zero(itemStruct2.ID, "Must be zero before inserting")
-		err = artist.InsertReturning(itemStruct2)
-		s.Error(err, "Should not happen, using a pointer should be enforced")
-		s.Zero(itemStruct2.ID, "Must still be zero because there was no insertion")
+	item.Name = "Ozzy"
+	res, err = artist.Upsert(item, "id")
+	s.NoError(err)
+	s.NotNil(res.ID())
 
-		itemMap2 := map[string]string{
-			"name": "Janus",
-		}
-		s.Zero(itemMap2["id"], "Must be zero before inserting")
-		err = artist.InsertReturning(itemMap2)
-		s.Error(err, "Should not happen, using a pointer should be enforced")
-		s.Zero(itemMap2["id"], "Must still be zero because there was no insertion")
+	var updated artistType
+	err = artist.Find(res.ID()).One(&updated)
+	s.NoError(err)
+	s.Equal(item.ID, updated.ID)
+	s.Equal("Ozzy", updated.Name)
 
-		// Counting elements, must be exactly 2 elements.
-		count, err = artist.Find().Count()
-		s.NoError(err)
-		s.Equal(uint64(2), count, "Expecting 2 elements")
+	count, err := artist.Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(1), count, "Expecting 1 element")
 
-		return fmt.Errorf("rolling back for no reason")
-	})
-	s.Error(err)
+	res, err = artist.Upsert(artistType{Name: "Flea"})
+	s.NoError(err)
+	s.NotNil(res.ID())
 
-	// Expecting no elements.
-	count, err := sess.Collection("artist").Find().Count()
+	count, err = artist.Find().Count()
 	s.NoError(err)
-	s.Equal(uint64(0), count, "Expecting 0 elements, everything was rolled back!")
+	s.Equal(uint64(2), count, "Expecting 2 elements")
 }
 
-func (s *SQLTestSuite) TestInsertIntoArtistsTable() {
+func (s *SQLTestSuite) TestResultReuse() {
 	sess := s.Session()
 
 	artist := sess.Collection("artist")
@@ -381,593 +487,2809 @@ func (s *SQLTestSuite) TestInsertIntoArtistsTable() {
 	err := artist.Truncate()
 	s.NoError(err)
 
-	itemMap := map[string]string{
-		"name": "Ozzie",
-	}
-
-	record, err := artist.Insert(itemMap)
-	s.NoError(err)
-	s.NotNil(record)
-
-	if pk, ok := record.ID().(int64); !ok || pk == 0 {
-		s.T().Errorf("Expecting an ID.")
-	}
-
-	// Attempt to append a struct.
-	itemStruct := struct {
-		Name string `db:"name"`
-	}{
-		"Flea",
+	for i := 0; i < 10; i++ {
+		_, err := artist.Insert(map[string]interface{}{"name": fmt.Sprintf("artist-%d", i)})
+		s.NoError(err)
 	}
 
-	record, err = artist.Insert(itemStruct)
-	s.NoError(err)
-	s.NotNil(record)
-
-	if pk, ok := record.ID().(int64); !ok || pk == 0 {
-		s.T().Errorf("Expecting an ID.")
-	}
+	base := artist.Find()
 
-	// Attempt to append a tagged struct.
-	itemStruct2 := struct {
-		ArtistName string `db:"name"`
-	}{
-		"Slash",
-	}
+	// Branches do not modify the base result.
+	limited := base.Limit(3)
+	filtered := base.And(db.Cond{"name": "artist-1"})
 
-	record, err = artist.Insert(&itemStruct2)
+	count, err := base.Count()
 	s.NoError(err)
-	s.NotNil(record)
+	s.Equal(uint64(10), count)
 
-	if pk, ok := record.ID().(int64); !ok || pk == 0 {
-		s.T().Errorf("Expecting an ID.")
-	}
+	var items []map[string]interface{}
+	s.NoError(limited.All(&items))
+	s.Len(items, 3)
 
-	itemStruct3 := artistType{
-		Name: "Janus",
-	}
-	record, err = artist.Insert(&itemStruct3)
+	count, err = filtered.Count()
 	s.NoError(err)
-	if s.Adapter() != "ql" {
-		s.NotZero(record) // QL always inserts an ID.
-	}
+	s.Equal(uint64(1), count)
 
-	// Counting elements, must be exactly 4 elements.
-	count, err := artist.Find().Count()
-	s.NoError(err)
-	s.Equal(uint64(4), count)
+	// Arguments are copied, the caller can reuse its slice.
+	conds := []interface{}{db.Cond{"name": "artist-2"}}
+	byName := base.And(conds...)
+	conds[0] = db.Cond{"name": "nobody"}
 
-	count, err = artist.Find(db.Cond{"name": db.Eq("Ozzie")}).Count()
+	count, err = byName.Count()
 	s.NoError(err)
 	s.Equal(uint64(1), count)
 
-	count, err = artist.Find("name", "Ozzie").And("name", "Flea").Count()
-	s.NoError(err)
-	s.Equal(uint64(0), count)
-
-	count, err = artist.Find(db.Or(db.Cond{"name": "Ozzie"}, db.Cond{"name": "Flea"})).Count()
-	s.NoError(err)
-	s.Equal(uint64(2), count)
+	// The base result can be branched from many goroutines at once.
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
 
-	count, err = artist.Find(db.And(db.Cond{"name": "Ozzie"}, db.Cond{"name": "Flea"})).Count()
-	s.NoError(err)
-	s.Equal(uint64(0), count)
+			var item struct {
+				Name string `db:"name"`
+			}
+			err := base.And(db.Cond{"name": fmt.Sprintf("artist-%d", i)}).OrderBy("name").One(&item)
+			if err == nil && item.Name != fmt.Sprintf("artist-%d", i) {
+				err = fmt.Errorf("expecting artist-%d, got %q", i, item.Name)
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
 
-	count, err = artist.Find(db.Cond{"name": "Ozzie"}).And(db.Cond{"name": "Flea"}).Count()
-	s.NoError(err)
-	s.Equal(uint64(0), count)
+	for err := range errs {
+		s.NoError(err)
+	}
 }
 
-func (s *SQLTestSuite) TestQueryNonExistentCollection() {
+func (s *SQLTestSuite) TestConcurrentSession() {
 	sess := s.Session()
 
-	count, err := sess.Collection("doesnotexist").Find().Count()
-	s.Error(err)
+	err := sess.Collection("artist").Truncate()
+	s.NoError(err)
+
+	// The session, its collections and results are shared by all goroutines
+	// without any external locking.
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			errs <- func() error {
+				artist := sess.Collection("artist")
+				if pk := artist.(interface{ PrimaryKeys() []string }).PrimaryKeys(); len(pk) != 1 {
+					return fmt.Errorf("expecting one primary key, got %v", pk)
+				}
+
+				name := fmt.Sprintf("concurrent-%d", i)
+				if _, err := artist.Insert(map[string]interface{}{"name": name}); err != nil {
+					return err
+				}
+
+				var item struct {
+					Name string `db:"name"`
+				}
+				if err := artist.Find(db.Cond{"name": name}).One(&item); err != nil {
+					return err
+				}
+
+				var rows []map[string]interface{}
+				if err := sess.SQL().Select("name").From("artist").Where("name", name).Iterator().All(&rows); err != nil {
+					return err
+				}
+				if len(rows) != 1 {
+					return fmt.Errorf("expecting one %q, got %d", name, len(rows))
+				}
+
+				if i%5 == 0 {
+					// Dropping caches while other goroutines use them.
+					sess.Reset()
+				}
+				return nil
+			}()
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		s.NoError(err)
+	}
+
+	count, err := sess.Collection("artist").Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(20), count)
+}
+
+func (s *SQLTestSuite) TestConn() {
+	switch s.Adapter() {
+	case "postgresql", "mysql", "sqlite":
+	default:
+		s.T().Skip("Temporary tables are not supported by this test.")
+	}
+
+	sess := s.Session()
+
+	err := sess.Conn(func(conn db.Session) error {
+		if _, ok := conn.Driver().(*sql.Conn); !ok {
+			return fmt.Errorf("expecting a *sql.Conn, got %T", conn.Driver())
+		}
+
+		// Temporary tables are only visible to the connection that created
+		// them.
+		if _, err := conn.SQL().Exec("CREATE TEMPORARY TABLE conn_tmp (id INTEGER)"); err != nil {
+			return err
+		}
+
+		if _, err := conn.SQL().InsertInto("conn_tmp").Values(1).Exec(); err != nil {
+			return err
+		}
+
+		err := conn.Tx(func(tx db.Session) error {
+			_, err := tx.SQL().InsertInto("conn_tmp").Values(2).Exec()
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < 5; i++ {
+			count, err := conn.Collection("conn_tmp").Find().Count()
+			if err != nil {
+				return err
+			}
+			if count != 2 {
+				return fmt.Errorf("expecting 2 rows, got %d", count)
+			}
+		}
+
+		_, err = conn.SQL().Exec("DROP TABLE conn_tmp")
+		return err
+	})
+	s.NoError(err)
+
+	// The session is still usable after the connection is released.
+	s.NoError(sess.Ping())
+	_, err = sess.Collection("artist").Find().Count()
+	s.NoError(err)
+}
+
+func (s *SQLTestSuite) TestInsertIDs() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+
+	err := artist.Truncate()
+	s.NoError(err)
+
+	// Generated keys are returned for maps and anonymous structs.
+	res, err := artist.Insert(map[string]interface{}{"name": "Ozzie"})
+	s.NoError(err)
+	s.NotNil(res.ID())
+
+	var item struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+	s.NoError(artist.Find(res.ID()).One(&item))
+	s.Equal("Ozzie", item.Name)
+
+	res, err = artist.Insert(struct {
+		Name string `db:"name"`
+	}{"Flea"})
+	s.NoError(err)
+	s.NotNil(res.ID())
+
+	s.NoError(artist.Find(res.ID()).One(&item))
+	s.Equal("Flea", item.Name)
+
+	switch s.Adapter() {
+	case "mssql", "ql":
+		// Explicit values for identity columns are not allowed.
+		return
+	}
+
+	// Keys given by the item are returned as they are.
+	res, err = artist.Insert(map[string]interface{}{"id": 1000, "name": "Slash"})
+	s.NoError(err)
+	s.EqualValues(1000, res.ID())
+
+	flags := sess.Collection("bool_flags")
+	s.NoError(flags.Truncate())
+
+	res, err = flags.Insert(map[string]interface{}{"name": "beta"})
+	s.NoError(err)
+	s.Equal("beta", res.ID())
+
+	results, err := artist.InsertMany([]map[string]interface{}{
+		{"name": "Angus"},
+		{"name": "Malcolm"},
+		{"name": "Bon"},
+	}, 0)
+	s.NoError(err)
+	s.Len(results, 3)
+
+	for i, name := range []string{"Angus", "Malcolm", "Bon"} {
+		s.NoError(artist.Find(results[i].ID()).One(&item))
+		s.Equal(name, item.Name)
+	}
+}
+
+func (s *SQLTestSuite) TestInsertOrIgnore() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+
+	err := artist.Truncate()
+	s.NoError(err)
+
+	type artistType struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+
+	res, err := artist.InsertOrIgnore(artistType{ID: 7, Name: "Ozzie"})
+	if errors.Is(err, db.ErrUnsupported) {
+		s.T().Skip("InsertOrIgnore is not supported by this adapter")
+	}
+	s.NoError(err)
+	s.NotNil(res.ID())
+
+	res, err = artist.InsertOrIgnore(artistType{ID: 7, Name: "Ozzy"})
+	s.NoError(err)
+	s.Nil(res.ID())
+
+	var item artistType
+	err = artist.Find(db.Cond{"id": 7}).One(&item)
+	s.NoError(err)
+	s.Equal("Ozzie", item.Name)
+
+	count, err := artist.Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(1), count, "Expecting 1 element")
+}
+
+func (s *SQLTestSuite) TestInsertReturning() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+
+	err := artist.Truncate()
+	s.NoError(err)
+
+	itemMap := map[string]string{
+		"name": "Ozzie",
+	}
+	s.Zero(itemMap["id"], "Must be zero before inserting")
+	err = artist.InsertReturning(&itemMap)
+	s.NoError(err)
+	s.NotZero(itemMap["id"], "Must not be zero after inserting")
+
+	itemStruct := struct {
+		ID   int    `db:"id,omitempty"`
+		Name string `db:"name"`
+	}{
+		0,
+		"Flea",
+	}
+	s.Zero(itemStruct.ID, "Must be zero before inserting")
+	err = artist.InsertReturning(&itemStruct)
+	s.NoError(err)
+	s.NotZero(itemStruct.ID, "Must not be zero after inserting")
+
+	count, err := artist.Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(2), count, "Expecting 2 elements")
+
+	itemStruct2 := struct {
+		ID   int    `db:"id,omitempty"`
+		Name string `db:"name"`
+	}{
+		0,
+		"Slash",
+	}
+	s.Zero(itemStruct2.ID, "Must be zero before inserting")
+	err = artist.InsertReturning(itemStruct2)
+	s.Error(err, "Should not happen, using a pointer should be enforced")
+	s.Zero(itemStruct2.ID, "Must still be zero because there was no insertion")
+
+	itemMap2 := map[string]string{
+		"name": "Janus",
+	}
+	s.Zero(itemMap2["id"], "Must be zero before inserting")
+	err = artist.InsertReturning(itemMap2)
+	s.Error(err, "Should not happen, using a pointer should be enforced")
+	s.Zero(itemMap2["id"], "Must still be zero because there was no insertion")
+
+	// Counting elements, must be exactly 2 elements.
+	count, err = artist.Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(2), count, "Expecting 2 elements")
+}
+
+func (s *SQLTestSuite) TestInsertReturningWithinTransaction() {
+	sess := s.Session()
+
+	err := sess.Collection("artist").Truncate()
+	s.NoError(err)
+
+	err = sess.Tx(func(tx db.Session) error {
+		artist := tx.Collection("artist")
+
+		itemMap := map[string]string{
+			"name": "Ozzie",
+		}
+		s.Zero(itemMap["id"], "Must be zero before inserting")
+		err = artist.InsertReturning(&itemMap)
+		s.NoError(err)
+		s.NotZero(itemMap["id"], "Must not be zero after inserting")
+
+		itemStruct := struct {
+			ID   int    `db:"id,omitempty"`
+			Name string `db:"name"`
+		}{
+			0,
+			"Flea",
+		}
+		s.Zero(itemStruct.ID, "Must be zero before inserting")
+		err = artist.InsertReturning(&itemStruct)
+		s.NoError(err)
+		s.NotZero(itemStruct.ID, "Must not be zero after inserting")
+
+		count, err := artist.Find().Count()
+		s.NoError(err)
+		s.Equal(uint64(2), count, "Expecting 2 elements")
+
+		itemStruct2 := struct {
+			ID   int    `db:"id,omitempty"`
+			Name string `db:"name"`
+		}{
+			0,
+			"Slash",
+		}
+		s.Zero(itemStruct2.ID, "Must be zero before inserting")
+		err = artist.InsertReturning(itemStruct2)
+		s.Error(err, "Should not happen, using a pointer should be enforced")
+		s.Zero(itemStruct2.ID, "Must still be zero because there was no insertion")
+
+		itemMap2 := map[string]string{
+			"name": "Janus",
+		}
+		s.Zero(itemMap2["id"], "Must be zero before inserting")
+		err = artist.InsertReturning(itemMap2)
+		s.Error(err, "Should not happen, using a pointer should be enforced")
+		s.Zero(itemMap2["id"], "Must still be zero because there was no insertion")
+
+		// Counting elements, must be exactly 2 elements.
+		count, err = artist.Find().Count()
+		s.NoError(err)
+		s.Equal(uint64(2), count, "Expecting 2 elements")
+
+		return fmt.Errorf("rolling back for no reason")
+	})
+	s.Error(err)
+
+	// Expecting no elements.
+	count, err := sess.Collection("artist").Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(0), count, "Expecting 0 elements, everything was rolled back!")
+}
+
+func (s *SQLTestSuite) TestInsertIntoArtistsTable() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+
+	err := artist.Truncate()
+	s.NoError(err)
+
+	itemMap := map[string]string{
+		"name": "Ozzie",
+	}
+
+	record, err := artist.Insert(itemMap)
+	s.NoError(err)
+	s.NotNil(record)
+
+	if pk, ok := record.ID().(int64); !ok || pk == 0 {
+		s.T().Errorf("Expecting an ID.")
+	}
+
+	// Attempt to append a struct.
+	itemStruct := struct {
+		Name string `db:"name"`
+	}{
+		"Flea",
+	}
+
+	record, err = artist.Insert(itemStruct)
+	s.NoError(err)
+	s.NotNil(record)
+
+	if pk, ok := record.ID().(int64); !ok || pk == 0 {
+		s.T().Errorf("Expecting an ID.")
+	}
+
+	// Attempt to append a tagged struct.
+	itemStruct2 := struct {
+		ArtistName string `db:"name"`
+	}{
+		"Slash",
+	}
+
+	record, err = artist.Insert(&itemStruct2)
+	s.NoError(err)
+	s.NotNil(record)
+
+	if pk, ok := record.ID().(int64); !ok || pk == 0 {
+		s.T().Errorf("Expecting an ID.")
+	}
+
+	itemStruct3 := artistType{
+		Name: "Janus",
+	}
+	record, err = artist.Insert(&itemStruct3)
+	s.NoError(err)
+	if s.Adapter() != "ql" {
+		s.NotZero(record) // QL always inserts an ID.
+	}
+
+	// Counting elements, must be exactly 4 elements.
+	count, err := artist.Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(4), count)
+
+	count, err = artist.Find(db.Cond{"name": db.Eq("Ozzie")}).Count()
+	s.NoError(err)
+	s.Equal(uint64(1), count)
+
+	count, err = artist.Find("name", "Ozzie").And("name", "Flea").Count()
+	s.NoError(err)
+	s.Equal(uint64(0), count)
+
+	count, err = artist.Find(db.Or(db.Cond{"name": "Ozzie"}, db.Cond{"name": "Flea"})).Count()
+	s.NoError(err)
+	s.Equal(uint64(2), count)
+
+	count, err = artist.Find(db.And(db.Cond{"name": "Ozzie"}, db.Cond{"name": "Flea"})).Count()
+	s.NoError(err)
+	s.Equal(uint64(0), count)
+
+	count, err = artist.Find(db.Cond{"name": "Ozzie"}).And(db.Cond{"name": "Flea"}).Count()
+	s.NoError(err)
+	s.Equal(uint64(0), count)
+}
+
+func (s *SQLTestSuite) TestQueryNonExistentCollection() {
+	sess := s.Session()
+
+	count, err := sess.Collection("doesnotexist").Find().Count()
+	s.Error(err)
 	s.Zero(count)
 }
 
-func (s *SQLTestSuite) TestGetOneResult() {
+func (s *SQLTestSuite) TestGetOneResult() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+
+	for i := 0; i < 5; i++ {
+		_, err := artist.Insert(map[string]string{
+			"name": fmt.Sprintf("Artist %d", i),
+		})
+		s.NoError(err)
+	}
+
+	// Fetching one struct.
+	var someArtist artistType
+	err := artist.Find().Limit(1).One(&someArtist)
+	s.NoError(err)
+
+	s.NotZero(someArtist.Name)
+	if s.Adapter() != "ql" {
+		s.NotZero(someArtist.ID)
+	}
+
+	// Fetching a pointer to a pointer.
+	var someArtistObj *artistType
+	err = artist.Find().Limit(1).One(&someArtistObj)
+	s.NoError(err)
+	s.NotZero(someArtist.Name)
+	if s.Adapter() != "ql" {
+		s.NotZero(someArtist.ID)
+	}
+}
+
+func (s *SQLTestSuite) TestGetWithOffset() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+
+	// Fetching one struct.
+	var artists []artistType
+	err := artist.Find().Offset(1).All(&artists)
+	s.NoError(err)
+
+	s.Equal(3, len(artists))
+}
+
+func (s *SQLTestSuite) TestGetResultsOneByOne() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+
+	rowMap := map[string]interface{}{}
+
+	res := artist.Find()
+
+	err := res.Err()
+	s.NoError(err)
+
+	for res.Next(&rowMap) {
+		s.NotZero(rowMap["id"])
+		s.NotZero(rowMap["name"])
+	}
+	err = res.Err()
+	s.NoError(err)
+
+	err = res.Close()
+	s.NoError(err)
+
+	// Dumping into a tagged struct.
+	rowStruct2 := struct {
+		Value1 int64  `db:"id"`
+		Value2 string `db:"name"`
+	}{}
+
+	res = artist.Find()
+
+	for res.Next(&rowStruct2) {
+		s.NotZero(rowStruct2.Value1)
+		s.NotZero(rowStruct2.Value2)
+	}
+	err = res.Err()
+	s.NoError(err)
+
+	err = res.Close()
+	s.NoError(err)
+
+	// Dumping into a slice of maps.
+	allRowsMap := []map[string]interface{}{}
+
+	res = artist.Find()
+
+	err = res.All(&allRowsMap)
+	s.NoError(err)
+	s.Equal(4, len(allRowsMap))
+
+	for _, singleRowMap := range allRowsMap {
+		if fmt.Sprintf("%d", singleRowMap["id"]) == "0" {
+			s.T().Errorf("Expecting a not null ID.")
+		}
+	}
+
+	// Dumping into a slice of structs.
+	allRowsStruct := []struct {
+		ID   int64  `db:"id,omitempty"`
+		Name string `db:"name"`
+	}{}
+
+	res = artist.Find()
+
+	if err = res.All(&allRowsStruct); err != nil {
+		s.T().Errorf("%v", err)
+	}
+
+	s.Equal(4, len(allRowsStruct))
+
+	for _, singleRowStruct := range allRowsStruct {
+		s.NotZero(singleRowStruct.ID)
+	}
+
+	// Dumping into a slice of tagged structs.
+	allRowsStruct2 := []struct {
+		Value1 int64  `db:"id"`
+		Value2 string `db:"name"`
+	}{}
+
+	res = artist.Find()
+
+	err = res.All(&allRowsStruct2)
+	s.NoError(err)
+
+	s.Equal(4, len(allRowsStruct2))
+
+	for _, singleRowStruct := range allRowsStruct2 {
+		s.NotZero(singleRowStruct.Value1)
+	}
+}
+
+func (s *SQLTestSuite) TestGetAllResults() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+
+	total, err := artist.Find().Count()
+	s.NoError(err)
+	s.NotZero(total)
+
+	// Fetching all artists into struct
+	artists := []artistType{}
+
+	res := artist.Find()
+
+	err = res.All(&artists)
+	s.NoError(err)
+	s.Equal(len(artists), int(total))
+
+	s.NotZero(artists[0].Name)
+	s.NotZero(artists[0].ID)
+
+	// Fetching all artists into struct pointers
+	artistObjs := []*artistType{}
+	res = artist.Find()
+
+	err = res.All(&artistObjs)
+	s.NoError(err)
+	s.Equal(len(artistObjs), int(total))
+
+	s.NotZero(artistObjs[0].Name)
+	s.NotZero(artistObjs[0].ID)
+}
+
+func (s *SQLTestSuite) TestInlineStructs() {
+	type reviewTypeDetails struct {
+		Name     string    `db:"name"`
+		Comments string    `db:"comments"`
+		Created  time.Time `db:"created"`
+	}
+
+	type reviewType struct {
+		ID            int64             `db:"id,omitempty"`
+		PublicationID int64             `db:"publication_id"`
+		Details       reviewTypeDetails `db:",inline"`
+	}
+
+	sess := s.Session()
+
+	review := sess.Collection("review")
+
+	err := review.Truncate()
+	s.NoError(err)
+
+	rec := reviewType{
+		PublicationID: 123,
+		Details: reviewTypeDetails{
+			Name:     "..name..",
+			Comments: "..comments..",
+		},
+	}
+
+	var createdAt time.Time
+
+	switch s.Adapter() {
+	case "postgresql", "cockroachdb":
+		createdAt = time.Date(2016, time.January, 1, 2, 3, 4, 0, time.FixedZone("", 0))
+	case "mysql":
+		// MySQL uses a global time zone
+		createdAt = time.Date(2016, time.January, 1, 2, 3, 4, 0, TimeLocation)
+	default:
+		createdAt = time.Date(2016, time.January, 1, 2, 3, 4, 0, time.UTC)
+	}
+	rec.Details.Created = createdAt
+
+	record, err := review.Insert(rec)
+	s.NoError(err)
+	s.NotZero(record.ID().(int64))
+
+	rec.ID = record.ID().(int64)
+
+	var recChk reviewType
+	res := review.Find()
+
+	err = res.One(&recChk)
+	s.NoError(err)
+
+	s.Equal(rec, recChk)
+}
+
+func (s *SQLTestSuite) TestUpdate() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+
+	_, err := artist.Insert(map[string]string{
+		"name": "Ozzie",
+	})
+	s.NoError(err)
+
+	// Defining destination struct
+	value := struct {
+		ID   int64  `db:"id,omitempty"`
+		Name string `db:"name"`
+	}{}
+
+	// Getting the first artist.
+	cond := db.Cond{"id !=": db.NotEq(0)}
+	if s.Adapter() == "ql" {
+		cond = db.Cond{"id() !=": 0}
+	}
+	res := artist.Find(cond).Limit(1)
+
+	err = res.One(&value)
+	s.NoError(err)
+
+	res = artist.Find(value.ID)
+
+	// Updating set with a map
+	rowMap := map[string]interface{}{
+		"name": strings.ToUpper(value.Name),
+	}
+
+	err = res.Update(rowMap)
+	s.NoError(err)
+
+	// Pulling it again.
+	err = res.One(&value)
+	s.NoError(err)
+
+	// Verifying.
+	s.Equal(value.Name, rowMap["name"])
+
+	if s.Adapter() != "ql" {
+
+		// Updating using raw
+		if err = res.Update(map[string]interface{}{"name": db.Raw("LOWER(name)")}); err != nil {
+			s.T().Errorf("%v", err)
+		}
+
+		// Pulling it again.
+		err = res.One(&value)
+		s.NoError(err)
+
+		// Verifying.
+		s.Equal(value.Name, strings.ToLower(rowMap["name"].(string)))
+
+		// Updating using raw
+		if err = res.Update(struct {
+			Name *db.RawExpr `db:"name"`
+		}{db.Raw(`UPPER(name)`)}); err != nil {
+			s.T().Errorf("%v", err)
+		}
+
+		// Pulling it again.
+		err = res.One(&value)
+		s.NoError(err)
+
+		// Verifying.
+		s.Equal(value.Name, strings.ToUpper(rowMap["name"].(string)))
+
+		// Updating using raw
+		if err = res.Update(struct {
+			Name *db.FuncExpr `db:"name"`
+		}{db.Func("LOWER", db.Raw("name"))}); err != nil {
+			s.T().Errorf("%v", err)
+		}
+
+		// Pulling it again.
+		err = res.One(&value)
+		s.NoError(err)
+
+		// Verifying.
+		s.Equal(value.Name, strings.ToLower(rowMap["name"].(string)))
+	}
+
+	// Updating set with a struct
+	rowStruct := struct {
+		Name string `db:"name"`
+	}{strings.ToLower(value.Name)}
+
+	err = res.Update(rowStruct)
+	s.NoError(err)
+
+	// Pulling it again.
+	err = res.One(&value)
+	s.NoError(err)
+
+	// Verifying
+	s.Equal(value.Name, rowStruct.Name)
+
+	// Updating set with a tagged struct
+	rowStruct2 := struct {
+		Value1 string `db:"name"`
+	}{"john"}
+
+	err = res.Update(rowStruct2)
+	s.NoError(err)
+
+	// Pulling it again.
+	err = res.One(&value)
+	s.NoError(err)
+
+	// Verifying
+	s.Equal(value.Name, rowStruct2.Value1)
+
+	// Updating set with a tagged object
+	rowStruct3 := &struct {
+		Value1 string `db:"name"`
+	}{"anderson"}
+
+	err = res.Update(rowStruct3)
+	s.NoError(err)
+
+	// Pulling it again.
+	err = res.One(&value)
+	s.NoError(err)
+
+	// Verifying
+	s.Equal(value.Name, rowStruct3.Value1)
+}
+
+func (s *SQLTestSuite) TestFunction() {
+	sess := s.Session()
+
+	rowStruct := struct {
+		ID   int64
+		Name string
+	}{}
+
+	artist := sess.Collection("artist")
+
+	cond := db.Cond{"id NOT IN": []int{0, -1}}
+	if s.Adapter() == "ql" {
+		cond = db.Cond{"id() NOT IN": []int{0, -1}}
+	}
+	res := artist.Find(cond)
+
+	err := res.One(&rowStruct)
+	s.NoError(err)
+
+	total, err := res.Count()
+	s.NoError(err)
+	s.Equal(uint64(4), total)
+
+	// Testing conditions
+	cond = db.Cond{"id NOT IN": []interface{}{0, -1}}
+	if s.Adapter() == "ql" {
+		cond = db.Cond{"id() NOT IN": []interface{}{0, -1}}
+	}
+	res = artist.Find(cond)
+
+	err = res.One(&rowStruct)
+	s.NoError(err)
+
+	total, err = res.Count()
+	s.NoError(err)
+	s.Equal(uint64(4), total)
+
+	res = artist.Find().Select("name")
+
+	var rowMap map[string]interface{}
+	err = res.One(&rowMap)
+	s.NoError(err)
+
+	total, err = res.Count()
+	s.NoError(err)
+	s.Equal(uint64(4), total)
+
+	res = artist.Find().Select("name")
+
+	err = res.One(&rowMap)
+	s.NoError(err)
+
+	total, err = res.Count()
+	s.NoError(err)
+	s.Equal(uint64(4), total)
+}
+
+func (s *SQLTestSuite) TestNullableFields() {
+	sess := s.Session()
+
+	type testType struct {
+		ID              int64           `db:"id,omitempty"`
+		NullStringTest  sql.NullString  `db:"_string"`
+		NullInt64Test   sql.NullInt64   `db:"_int64"`
+		NullFloat64Test sql.NullFloat64 `db:"_float64"`
+		NullBoolTest    sql.NullBool    `db:"_bool"`
+	}
+
+	col := sess.Collection(`data_types`)
+
+	err := col.Truncate()
+	s.NoError(err)
+
+	// Testing insertion of invalid nulls.
+	test := testType{
+		NullStringTest:  sql.NullString{String: "", Valid: false},
+		NullInt64Test:   sql.NullInt64{Int64: 0, Valid: false},
+		NullFloat64Test: sql.NullFloat64{Float64: 0.0, Valid: false},
+		NullBoolTest:    sql.NullBool{Bool: false, Valid: false},
+	}
+
+	id, err := col.Insert(testType{})
+	s.NoError(err)
+
+	// Testing fetching of invalid nulls.
+	err = col.Find(id).One(&test)
+	s.NoError(err)
+
+	s.False(test.NullInt64Test.Valid)
+	s.False(test.NullFloat64Test.Valid)
+	s.False(test.NullBoolTest.Valid)
+
+	// Testing insertion of valid nulls.
+	test = testType{
+		NullStringTest:  sql.NullString{String: "", Valid: true},
+		NullInt64Test:   sql.NullInt64{Int64: 0, Valid: true},
+		NullFloat64Test: sql.NullFloat64{Float64: 0.0, Valid: true},
+		NullBoolTest:    sql.NullBool{Bool: false, Valid: true},
+	}
+
+	id, err = col.Insert(test)
+	s.NoError(err)
+
+	// Testing fetching of valid nulls.
+	err = col.Find(id).One(&test)
+	s.NoError(err)
+
+	s.True(test.NullInt64Test.Valid)
+	s.True(test.NullBoolTest.Valid)
+	s.True(test.NullStringTest.Valid)
+}
+
+func (s *SQLTestSuite) TestZeroNullFields() {
+	if s.Adapter() == "ql" {
+		s.T().Skip("Currently not supported.")
+	}
+
+	sess := s.Session()
+
+	type testType struct {
+		ID      int64    `db:"id,omitempty"`
+		String  string   `db:"_string,zeronil,nullzero"`
+		Int64   int64    `db:"_int64,zeronil,nullzero"`
+		Float64 *float64 `db:"_float64,nullzero"`
+	}
+
+	type rawType struct {
+		ID      int64    `db:"id,omitempty"`
+		String  *string  `db:"_string"`
+		Int64   *int64   `db:"_int64"`
+		Float64 *float64 `db:"_float64"`
+	}
+
+	col := sess.Collection(`data_types`)
+
+	err := col.Truncate()
+	s.NoError(err)
+
+	// Zero values are written as NULL.
+	record, err := col.Insert(testType{})
+	s.NoError(err)
+
+	var raw rawType
+	err = col.Find(record.ID()).One(&raw)
+	s.NoError(err)
+	s.Nil(raw.String)
+	s.Nil(raw.Int64)
+	s.Nil(raw.Float64)
+
+	// NULLs are read as zero values.
+	var test testType
+	err = col.Find(record.ID()).One(&test)
+	s.NoError(err)
+	s.Equal("", test.String)
+	s.Equal(int64(0), test.Int64)
+	if s.NotNil(test.Float64) {
+		s.Equal(0.0, *test.Float64)
+	}
+
+	var tests []testType
+	err = col.Find().All(&tests)
+	s.NoError(err)
+	s.Len(tests, 1)
+
+	var columns struct {
+		String []string `db:"_string,nullzero"`
+	}
+	err = col.Find().Select("_string").AllColumns(&columns)
+	s.NoError(err)
+	s.Equal([]string{""}, columns.String)
+
+	// Non-zero values are written as usual and can be reset to NULL.
+	test.String, test.Int64 = "foo", 42
+	err = col.Find(record.ID()).Update(test)
+	s.NoError(err)
+
+	err = col.Find(record.ID()).One(&raw)
+	s.NoError(err)
+	if s.NotNil(raw.String) {
+		s.Equal("foo", *raw.String)
+	}
+	if s.NotNil(raw.Int64) {
+		s.Equal(int64(42), *raw.Int64)
+	}
+
+	test.String, test.Int64 = "", 0
+	err = col.Find(record.ID()).Update(test)
+	s.NoError(err)
+
+	err = col.Find(record.ID()).One(&raw)
+	s.NoError(err)
+	s.Nil(raw.String)
+	s.Nil(raw.Int64)
+}
+
+func (s *SQLTestSuite) TestJSONFields() {
+	if s.Adapter() == "ql" {
+		s.T().Skip("Currently not supported.")
+	}
+
+	sess := s.Session()
+
+	type payload struct {
+		Kind  string   `json:"kind"`
+		Attrs []string `json:"attrs"`
+	}
+
+	type testType struct {
+		ID      int64    `db:"id,omitempty"`
+		Payload *payload `db:"_string,json"`
+	}
+
+	type rawType struct {
+		ID     int64   `db:"id,omitempty"`
+		String *string `db:"_string"`
+	}
+
+	col := sess.Collection(`data_types`)
+
+	err := col.Truncate()
+	s.NoError(err)
+
+	record, err := col.Insert(testType{Payload: &payload{Kind: "created", Attrs: []string{"a", "b"}}})
+	s.NoError(err)
+
+	// The field is stored as a JSON document.
+	var raw rawType
+	err = col.Find(record.ID()).One(&raw)
+	s.NoError(err)
+	if s.NotNil(raw.String) {
+		s.JSONEq(`{"kind": "created", "attrs": ["a", "b"]}`, *raw.String)
+	}
+
+	var test testType
+	err = col.Find(record.ID()).One(&test)
+	s.NoError(err)
+	s.Equal(&payload{Kind: "created", Attrs: []string{"a", "b"}}, test.Payload)
+
+	test.Payload.Kind = "updated"
+	err = col.Find(record.ID()).Update(test)
+	s.NoError(err)
+
+	var tests []testType
+	err = col.Find().All(&tests)
+	s.NoError(err)
+	if s.Len(tests, 1) {
+		s.Equal("updated", tests[0].Payload.Kind)
+	}
+
+	// Nil values are stored as NULL.
+	test.Payload = nil
+	err = col.Find(record.ID()).Update(test)
+	s.NoError(err)
+
+	err = col.Find(record.ID()).One(&raw)
+	s.NoError(err)
+	s.Nil(raw.String)
+
+	test.Payload = &payload{}
+	err = col.Find(record.ID()).One(&test)
+	s.NoError(err)
+	s.Nil(test.Payload)
+}
+
+func (s *SQLTestSuite) TestCollectionStats() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+
+	err := artist.Truncate()
+	s.NoError(err)
+
+	for _, name := range []string{"Ozzie", "Flea", "Slash"} {
+		_, err := artist.Insert(map[string]string{"name": name})
+		s.NoError(err)
+	}
+
+	stats, err := artist.Stats()
+	if errors.Is(err, db.ErrUnsupported) {
+		s.T().Skip("Currently not supported.")
+	}
+	s.NoError(err)
+	s.NotNil(stats)
+
+	// Row counts are estimates on most databases.
+	s.True(stats.Rows <= 3)
+
+	_, err = sess.Collection("does_not_exist").Stats()
+	s.Error(err)
+}
+
+func (s *SQLTestSuite) TestCollectionMaintenance() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+
+	_, err := artist.Insert(map[string]string{"name": "Ozzie"})
+	s.NoError(err)
+
+	for _, fn := range []func() error{artist.Analyze, artist.Vacuum, artist.Optimize} {
+		err := fn()
+		if !errors.Is(err, db.ErrUnsupported) {
+			s.NoError(err)
+		}
+	}
+
+	err = db.Maintain(sess, db.MaintenanceAnalyze|db.MaintenanceVacuum|db.MaintenanceOptimize, "artist")
+	s.NoError(err)
+
+	err = db.Maintain(sess, db.MaintenanceAnalyze)
+	s.NoError(err)
+
+	err = db.Maintain(sess, db.MaintenanceAnalyze, "does_not_exist")
+	if s.Adapter() != "ql" {
+		s.Error(err)
+	}
+}
+
+func (s *SQLTestSuite) TestGroup() {
+	sess := s.Session()
+
+	type statsType struct {
+		Numeric int `db:"numeric"`
+		Value   int `db:"value"`
+	}
+
+	stats := sess.Collection("stats_test")
+
+	err := stats.Truncate()
+	s.NoError(err)
+
+	// Adding rows in batches.
+	items := make([]statsType, 100)
+	for i := range items {
+		items[i] = statsType{rand.Intn(5), rand.Intn(100)}
+	}
+	_, err = stats.InsertMany(items, 50)
+	s.NoError(err)
+
+	// Testing GROUP BY
+	res := stats.Find().Select(
+		"numeric",
+		db.Raw("count(1) AS counter"),
+		db.Raw("sum(value) AS total"),
+	).GroupBy("numeric")
+
+	var results []map[string]interface{}
+
+	err = res.All(&results)
+	s.NoError(err)
+
+	s.Equal(5, len(results))
+
+	// Testing HAVING
+	if s.Adapter() == "ql" {
+		err = res.Having(db.Raw("count(1) > ?", 0)).All(&results)
+		s.True(errors.Is(err, db.ErrUnsupported))
+		return
+	}
+
+	err = res.Having(db.Raw("count(1) > ?", 100)).All(&results)
+	s.NoError(err)
+	s.Equal(0, len(results))
+
+	err = res.Having(db.Raw("count(1) > ?", 0)).Having(db.Cond{"numeric >": 2}).All(&results)
+	s.NoError(err)
+	s.Equal(2, len(results))
+}
+
+func (s *SQLTestSuite) TestAggregates() {
+	sess := s.Session()
+
+	type statsType struct {
+		Numeric int `db:"numeric"`
+		Value   int `db:"value"`
+	}
+
+	stats := sess.Collection("stats_test")
+	s.NoError(stats.Truncate())
+
+	for i := 1; i <= 10; i++ {
+		_, err := stats.Insert(statsType{i % 3, i})
+		s.NoError(err)
+	}
+
+	sum, err := stats.Find().Sum("value")
+	s.NoError(err)
+	s.Equal(55.0, sum)
+
+	avg, err := stats.Find(db.Cond{"numeric": 0}).Avg("value")
+	s.NoError(err)
+	s.Equal(6.0, avg)
+
+	var lowest, highest int
+	s.NoError(stats.Find().Min("value", &lowest))
+	s.Equal(1, lowest)
+	s.NoError(stats.Find(db.Cond{"numeric": 1}).Max("value", &highest))
+	s.Equal(10, highest)
+
+	if s.Adapter() != "ql" {
+		// QL doesn't support DISTINCT within function calls.
+		distinct, err := stats.Find().CountDistinct("numeric")
+		s.NoError(err)
+		s.Equal(uint64(3), distinct)
+	}
+
+	empty := stats.Find(db.Cond{"value >": 100})
+
+	sum, err = empty.Sum("value")
+	s.NoError(err)
+	s.Zero(sum)
+
+	err = empty.Max("value", &highest)
+	s.True(errors.Is(err, db.ErrNoMoreRows))
+	s.Equal(10, highest)
+}
+
+func (s *SQLTestSuite) TestFirstLast() {
+	sess := s.Session()
+
+	type artistType struct {
+		ID   int64  `db:"id,omitempty"`
+		Name string `db:"name"`
+	}
+
+	artist := sess.Collection("artist")
+	s.NoError(artist.Truncate())
+
+	for _, name := range []string{"b", "c", "a"} {
+		_, err := artist.Insert(artistType{Name: name})
+		s.NoError(err)
+	}
+
+	var item artistType
+	s.NoError(artist.Find().First(&item))
+	s.Equal("b", item.Name)
+
+	s.NoError(artist.Find().OrderBy("name").First(&item))
+	s.Equal("a", item.Name)
+
+	s.NoError(artist.Find().Last(&item, ""))
+	s.Equal("a", item.Name)
+
+	s.NoError(artist.Find().OrderBy("id").Last(&item, "name"))
+	s.Equal("c", item.Name)
+
+	err := artist.Find(db.Cond{"name": "z"}).First(&item)
+	s.True(errors.Is(err, db.ErrNoMoreRows))
+
+	err = artist.Find(db.Cond{"name": "z"}).Last(&item, "name")
+	s.True(errors.Is(err, db.ErrNoMoreRows))
+}
+
+func (s *SQLTestSuite) TestUpdateDeleteCount() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+	s.NoError(artist.Truncate())
+
+	for _, name := range []string{"a", "b", "c"} {
+		_, err := artist.Insert(map[string]string{"name": name})
+		s.NoError(err)
+	}
+
+	n, err := artist.Find(db.Cond{"name IN": []string{"a", "b"}}).UpdateCount(map[string]string{"name": "x"})
+	s.NoError(err)
+	s.Equal(uint64(2), n)
+
+	n, err = artist.Find(db.Cond{"name": "z"}).UpdateCount(map[string]string{"name": "y"})
+	s.NoError(err)
+	s.Zero(n)
+
+	n, err = artist.Find(db.Cond{"name": "x"}).DeleteCount()
+	s.NoError(err)
+	s.Equal(uint64(2), n)
+
+	n, err = artist.Find(db.Cond{"name": "x"}).DeleteCount()
+	s.NoError(err)
+	s.Zero(n)
+}
+
+func (s *SQLTestSuite) TestModifyReturning() {
+	sess := s.Session()
+
+	type artistType struct {
+		ID   int64  `db:"id,omitempty"`
+		Name string `db:"name"`
+	}
+
+	artist := sess.Collection("artist")
+	s.NoError(artist.Truncate())
+
+	for _, name := range []string{"a", "b", "c", "d"} {
+		_, err := artist.Insert(artistType{Name: name})
+		s.NoError(err)
+	}
+
+	first := artist.Find().OrderBy("name").Limit(2)
+	last := artist.Find(db.Cond{"name <>": "x"}).OrderBy("-name")
+	if s.Adapter() == "ql" {
+		// QL can't order by columns that are not selected, and the primary
+		// keys of the items to modify are selected alone.
+		first = artist.Find(db.Cond{"name IN": []string{"a", "b"}})
+		last = artist.Find(db.Cond{"name": "d"})
+	}
+
+	var updated []artistType
+	err := first.UpdateReturning(map[string]interface{}{"name": "x"}, &updated)
+	s.NoError(err)
+	s.Len(updated, 2)
+	for _, item := range updated {
+		s.Equal("x", item.Name)
+	}
+
+	count, err := artist.Find(db.Cond{"name": "x"}).Count()
+	s.NoError(err)
+	s.Equal(uint64(2), count)
+
+	var popped artistType
+	err = last.DeleteReturning(&popped)
+	s.NoError(err)
+	s.Equal("d", popped.Name)
+
+	count, err = artist.Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(3), count)
+
+	var deleted []artistType
+	s.NoError(artist.Find().DeleteReturning(&deleted))
+	s.Len(deleted, 3)
+
+	err = artist.Find().DeleteReturning(&popped)
+	s.True(errors.Is(err, db.ErrNoMoreRows))
+
+	deleted = nil
+	s.NoError(artist.Find().DeleteReturning(&deleted))
+	s.NotNil(deleted)
+	s.Empty(deleted)
+}
+
+func (s *SQLTestSuite) TestAllColumns() {
+	if s.Adapter() == "ql" {
+		s.T().Skip("Currently not supported.")
+	}
+
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+
+	var cols struct {
+		ID   []int64  `db:"id"`
+		Name []string `db:"name"`
+	}
+
+	err := artist.Find().OrderBy("name").AllColumns(&cols)
+	s.NoError(err)
+	s.Equal([]string{"Chrono", "Flea", "Ozzie", "Slash"}, cols.Name)
+	s.Equal(4, len(cols.ID))
+	for i := range cols.ID {
+		s.NotZero(cols.ID[i])
+	}
+
+	// Previous values are discarded and columns without a field are ignored.
+	var names struct {
+		Name []string `db:"name"`
+	}
+	names.Name = []string{"Janus"}
+
+	err = sess.SQL().SelectFrom("artist").OrderBy("-name").Limit(2).AllColumns(&names)
+	s.NoError(err)
+	s.Equal([]string{"Slash", "Ozzie"}, names.Name)
+
+	var notSlices struct {
+		Name string `db:"name"`
+	}
+	err = artist.Find().AllColumns(&notSlices)
+	s.Error(err)
+
+	err = artist.Find().AllColumns(cols)
+	s.Error(err)
+}
+
+func (s *SQLTestSuite) TestResultScanner() {
+	sess := s.Session()
+
+	review := sess.Collection("review")
+
+	err := review.Truncate()
+	s.NoError(err)
+
+	comments := strings.Repeat("Lorem ipsum dolor sit amet. ", 1500)
+
+	_, err = review.Insert(map[string]interface{}{
+		"publication_id": 1,
+		"name":           "Long review",
+		"comments":       comments,
+		"created":        time.Now(),
+	})
+	s.NoError(err)
+
+	r, err := review.Find(db.Cond{"name": "Long review"}).Scanner("comments")
+	s.NoError(err)
+
+	var buf strings.Builder
+	n, err := io.Copy(&buf, r)
+	s.NoError(err)
+	s.Equal(int64(len(comments)), n)
+	s.Equal(comments, buf.String())
+
+	err = r.Close()
+	s.NoError(err)
+
+	_, err = review.Find(db.Cond{"name": "Missing review"}).Scanner("comments")
+	s.True(errors.Is(err, db.ErrNoMoreRows))
+}
+
+func (s *SQLTestSuite) TestResultNextContext() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	res := artist.Find().OrderBy("name")
+
+	var item artistType
+	rows := 0
+	for res.NextContext(ctx, &item) {
+		rows++
+		if rows == 2 {
+			// Stop the loop as if a shutdown signal was received.
+			cancel()
+		}
+	}
+	s.Equal(2, rows)
+	s.True(errors.Is(res.Err(), context.Canceled))
+
+	err := res.Close()
+	s.NoError(err)
+
+	// A live context behaves just like Next.
+	res = artist.Find()
+	rows = 0
+	for res.NextContext(context.Background(), &item) {
+		rows++
+	}
+	s.NoError(res.Err())
+	s.Equal(4, rows)
+
+	err = res.Close()
+	s.NoError(err)
+}
+
+func (s *SQLTestSuite) TestResultIterate() {
+	sess := s.Session()
+
+	res := sess.Collection("artist").Find().OrderBy("name")
+
+	var names []string
+	var item artistType
+	res.Iterate(&item)(func(i int, err error) bool {
+		s.NoError(err)
+		s.Equal(len(names), i)
+		names = append(names, item.Name)
+		return true
+	})
+	s.Equal(4, len(names))
+
+	var all []artistType
+	err := res.All(&all)
+	s.NoError(err)
+	for i := range all {
+		s.Equal(all[i].Name, names[i])
+	}
+
+	// Stopping early closes the cursor, the function can be called again.
+	iterate := res.Iterate(&item)
+	for j := 0; j < 2; j++ {
+		rows := 0
+		iterate(func(i int, err error) bool {
+			s.NoError(err)
+			rows++
+			return rows < 2
+		})
+		s.Equal(2, rows)
+		s.Equal(names[1], item.Name)
+	}
+
+	// Maps are supported as well.
+	rows := 0
+	res.Iterate(&map[string]interface{}{})(func(i int, err error) bool {
+		s.NoError(err)
+		rows++
+		return true
+	})
+	s.Equal(4, rows)
+
+	// Errors are yielded as the last step.
+	var errs []error
+	sess.Collection("nonexistent_table").Find().Iterate(&item)(func(i int, err error) bool {
+		errs = append(errs, err)
+		return true
+	})
+	s.Equal(1, len(errs))
+	s.Error(errs[0])
+}
+
+func (s *SQLTestSuite) TestResultBatches() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+	s.NoError(artist.Truncate())
+
+	for i := 0; i < 7; i++ {
+		_, err := artist.Insert(artistType{Name: fmt.Sprintf("artist-%d", i)})
+		s.NoError(err)
+	}
+
+	var names []string
+	var sizes []int
+	var batch []artistType
+	artist.Find().Batches(3, &batch)(func(i int, err error) bool {
+		s.NoError(err)
+		s.Equal(len(sizes), i)
+		sizes = append(sizes, len(batch))
+		for _, item := range batch {
+			names = append(names, item.Name)
+		}
+		return true
+	})
+	s.Equal([]int{3, 3, 1}, sizes)
+	s.Equal(7, len(names))
+	s.Equal("artist-0", names[0])
+	s.Equal("artist-6", names[6])
+
+	// Conditions and custom cursors are kept.
+	names = nil
+	res := artist.Find(db.Cond{"name >": "artist-2"}).Cursor("-name")
+	res.Batches(2, &batch)(func(i int, err error) bool {
+		s.NoError(err)
+		for _, item := range batch {
+			names = append(names, item.Name)
+		}
+		return true
+	})
+	s.Equal([]string{"artist-6", "artist-5", "artist-4", "artist-3"}, names)
+
+	// Errors are yielded as the last step.
+	var errs []error
+	artist.Find().Batches(0, &batch)(func(i int, err error) bool {
+		errs = append(errs, err)
+		return true
+	})
+	s.Equal(1, len(errs))
+	s.Error(errs[0])
+}
+
+func (s *SQLTestSuite) TestReadPreference() {
+	res := s.Session().Collection("artist").Find().OrderBy("name")
+
+	// SQL adapters ignore read preferences.
+	for _, pref := range []db.ReadPreference{db.ReadPrimary, db.ReadSecondary, db.ReadEventual} {
+		withPref := res.ReadPreference(pref)
+		s.Equal(res.String(), withPref.String())
+
+		var items []artistType
+		err := withPref.All(&items)
+		s.NoError(err)
+		s.Equal(4, len(items))
+
+		total, err := withPref.Count()
+		s.NoError(err)
+		s.Equal(uint64(4), total)
+	}
+}
+
+func (s *SQLTestSuite) TestNestedConditions() {
+	artist := s.Session().Collection("artist")
+
+	names := func(cond db.LogicalExpr) []string {
+		var items []artistType
+		err := artist.Find(cond).OrderBy("name").All(&items)
+		s.NoError(err)
+
+		names := []string{}
+		for i := range items {
+			names = append(names, items[i].Name)
+		}
+		return names
+	}
+
+	s.Equal([]string{"Chrono", "Slash"}, names(
+		db.Not(db.Or(db.Cond{"name": "Ozzie"}, db.Cond{"name": "Flea"})),
+	))
+
+	s.Equal([]string{"Chrono", "Flea", "Ozzie"}, names(
+		db.Not(db.Cond{"name": "Slash"}),
+	))
+
+	raw := db.Raw("name = ? OR name = ?", "Flea", "Slash")
+	if s.Adapter() == "ql" {
+		raw = db.Raw("name == ? || name == ?", "Flea", "Slash")
+	}
+
+	// The OR of the raw condition must not leak out of the AND group.
+	s.Equal([]string{"Ozzie", "Slash"}, names(
+		db.Or(
+			db.Cond{"name": "Ozzie"},
+			db.And(raw, db.Not(db.Cond{"name": "Flea"})),
+		),
+	))
+
+	s.Equal([]string{"Chrono"}, names(
+		db.And(
+			db.Not(raw),
+			db.Or(db.Cond{"name": "Chrono"}, db.And(db.Cond{"name": "Ozzie"}, db.Not(db.Cond{"name": "Ozzie"}))),
+		),
+	))
+}
+
+func (s *SQLTestSuite) TestComparisonHelpers() {
+	artist := s.Session().Collection("artist")
+
+	names := func(cond db.Cond) []string {
+		var items []artistType
+		err := artist.Find(cond).OrderBy("name").All(&items)
+		s.NoError(err)
+
+		names := []string{}
+		for i := range items {
+			names = append(names, items[i].Name)
+		}
+		return names
+	}
+
+	s.Equal([]string{"Flea", "Ozzie"}, names(db.Cond{"name": db.In("Ozzie", "Flea", "Nobody")}))
+	s.Equal([]string{"Chrono", "Slash"}, names(db.Cond{"name": db.NotIn("Ozzie", "Flea")}))
+	s.Equal([]string{"Ozzie", "Slash"}, names(db.Cond{"name": db.Gt("Flea")}))
+	s.Equal([]string{"Chrono", "Flea"}, names(db.Cond{"name": db.Lte("Flea")}))
+	s.Equal([]string{"Flea", "Ozzie"}, names(db.Cond{"name": db.Between("Flea", "Ozzie")}))
+	s.Equal([]string{}, names(db.Cond{"name": db.IsNull()}))
+
+	// The patterns of ql are regular expressions.
+	prefix := "o%"
+	if s.Adapter() == "ql" {
+		prefix = "^o"
+	}
+
+	s.Equal([]string{"Ozzie"}, names(db.Cond{"name": db.ILike(prefix)}))
+	s.Equal([]string{"Chrono", "Flea", "Slash"}, names(db.Cond{"name": db.NotILike(prefix)}))
+}
+
+func (s *SQLTestSuite) TestMiddleware() {
+	var queries []string
+
+	errBlocked := errors.New("blocked")
+
+	sess := s.Session().WithContext(context.Background())
+	sess.Use(
+		func(next db.QueryHandler) db.QueryHandler {
+			// Rejects deletions and tags every other statement.
+			return func(ctx context.Context, query string, args []interface{}) error {
+				if strings.Contains(query, "DELETE") {
+					return errBlocked
+				}
+				return next(ctx, "/* tagged */ "+query, args)
+			}
+		},
+		func(next db.QueryHandler) db.QueryHandler {
+			return func(ctx context.Context, query string, args []interface{}) error {
+				err := next(ctx, query, args)
+				if err == nil {
+					queries = append(queries, query)
+				}
+				return err
+			}
+		},
+	)
+
+	artist := sess.Collection("artist")
+
+	total, err := artist.Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(4), total)
+
+	s.NotEmpty(queries)
+	for _, query := range queries {
+		s.True(strings.HasPrefix(query, "/* tagged */ "))
+	}
+
+	err = artist.Find().Delete()
+	s.True(errors.Is(err, errBlocked))
+
+	// The middleware is inherited by transactions.
+	err = sess.Tx(func(tx db.Session) error {
+		return tx.Collection("artist").Find().Delete()
+	})
+	s.True(errors.Is(err, errBlocked))
+
+	// The session the copy was made from is not affected.
+	n := len(queries)
+
+	total, err = s.Session().Collection("artist").Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(4), total)
+	s.Equal(n, len(queries))
+}
+
+func (s *SQLTestSuite) TestMiddlewareSkip() {
+	sess := s.Session().WithContext(context.Background())
+	sess.Use(func(next db.QueryHandler) db.QueryHandler {
+		// Records nothing and runs nothing, like a dry run.
+		return func(ctx context.Context, query string, args []interface{}) error {
+			return nil
+		}
+	})
+
+	artist := sess.Collection("artist")
+
+	_, err := artist.Insert(map[string]string{"name": "Skipped"})
+	s.True(errors.Is(err, db.ErrQuerySkipped))
+
+	_, err = artist.Find().Count()
+	s.True(errors.Is(err, db.ErrQuerySkipped))
+
+	var item map[string]interface{}
+	err = artist.Find().One(&item)
+	s.True(errors.Is(err, db.ErrQuerySkipped))
+
+	_, err = sess.SQL().QueryRow("SELECT 1")
+	s.True(errors.Is(err, db.ErrQuerySkipped))
+
+	total, err := s.Session().Collection("artist").Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(4), total)
+}
+
+func (s *SQLTestSuite) TestQueryID() {
+	var lastQuery string
+	var lastQueryID uint64
+	var lastRowsAffected int64
+
+	sess := s.Session().WithContext(context.Background())
+	sess.Use(func(next db.QueryHandler) db.QueryHandler {
+		return func(ctx context.Context, query string, args []interface{}) error {
+			queryID, ok := db.QueryID(ctx)
+			s.True(ok)
+			lastQuery, lastQueryID = query, queryID
+
+			err := next(ctx, query, args)
+			lastRowsAffected, _ = db.RowsAffected(ctx)
+			return err
+		}
+	})
+
+	sess.SetQueryIDComments(true)
+	defer sess.SetQueryIDComments(false)
+
+	total, err := sess.Collection("artist").Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(4), total)
+
+	s.NotZero(lastQueryID)
+	s.True(strings.HasPrefix(lastQuery, fmt.Sprintf("/* upper_query_id=%05d */ ", lastQueryID)))
+
+	// Middleware can read the number of rows a statement affected.
+	err = sess.Collection("artist").Find("name", "Ozzie").Update(map[string]interface{}{"name": "Ozzy"})
+	s.NoError(err)
+	s.Equal(int64(1), lastRowsAffected)
+
+	// Errors carry the ID of the statement that failed.
+	_, err = sess.SQL().Exec("DELETE FROM artist_that_does_not_exist")
+	s.Error(err)
+
+	var queryErr *db.QueryError
+	s.True(errors.As(err, &queryErr))
+	s.Equal(lastQueryID, queryErr.QueryID)
+	s.True(strings.Contains(err.Error(), fmt.Sprintf("query %05d", lastQueryID)))
+}
+
+func (s *SQLTestSuite) TestConverter() {
+	if s.Adapter() == "ql" {
+		s.T().Skip("Currently not supported.")
+	}
+
+	type shoutedName string
+
+	shout := func(src interface{}) (interface{}, error) {
+		switch v := src.(type) {
+		case nil:
+			return nil, nil
+		case []byte:
+			return strings.ToUpper(string(v)), nil
+		case string:
+			return strings.ToUpper(v), nil
+		}
+		return nil, fmt.Errorf("unexpected %T", src)
+	}
+
+	for _, sqlType := range []string{"VARCHAR", "NVARCHAR", "TEXT", "STRING"} {
+		db.RegisterConverter(sqlType, shoutedName(""), shout)
+		defer db.RegisterConverter(sqlType, shoutedName(""), nil)
+	}
+
+	sess := s.Session()
+
+	var item struct {
+		ID   int64        `db:"id"`
+		Name shoutedName  `db:"name"`
+		Alt  *shoutedName `db:"alt"`
+	}
+	err := sess.SQL().
+		Select("id", "name", db.Raw("name AS alt")).
+		From("artist").
+		Where("name", "Ozzie").
+		One(&item)
+	s.NoError(err)
+	s.Equal(shoutedName("OZZIE"), item.Name)
+	s.NotNil(item.Alt)
+	s.Equal(shoutedName("OZZIE"), *item.Alt)
+
+	// Fields of other types are not affected.
+	var artist artistType
+	err = sess.Collection("artist").Find("name", "Ozzie").One(&artist)
+	s.NoError(err)
+	s.Equal("Ozzie", artist.Name)
+
+	var columns struct {
+		Names []shoutedName `db:"name"`
+	}
+	err = sess.Collection("artist").Find().OrderBy("name").AllColumns(&columns)
+	s.NoError(err)
+	s.Equal([]shoutedName{"CHRONO", "FLEA", "OZZIE", "SLASH"}, columns.Names)
+}
+
+func (s *SQLTestSuite) TestBoolMapping() {
+	if s.Adapter() == "ql" {
+		s.T().Skip("Currently not supported.")
+	}
+
 	sess := s.Session()
 
-	artist := sess.Collection("artist")
+	flags := sess.Collection("bool_flags")
+	err := flags.Truncate()
+	s.NoError(err)
 
-	for i := 0; i < 5; i++ {
-		_, err := artist.Insert(map[string]string{
-			"name": fmt.Sprintf("Artist %d", i),
-		})
-		s.NoError(err)
+	type flagsType struct {
+		Name     string `db:"name"`
+		Active   bool   `db:"active,bool=Y/N"`
+		Verified *bool  `db:"verified,bool=1/0"`
 	}
 
-	// Fetching one struct.
-	var someArtist artistType
-	err := artist.Find().Limit(1).One(&someArtist)
+	verified := true
+	_, err = flags.Insert(flagsType{Name: "a", Active: true, Verified: &verified})
+	s.NoError(err)
+	_, err = flags.Insert(flagsType{Name: "b"})
 	s.NoError(err)
 
-	s.NotZero(someArtist.Name)
-	if s.Adapter() != "ql" {
-		s.NotZero(someArtist.ID)
+	// Flags are stored with their mapped values.
+	var raw []struct {
+		Name     string  `db:"name"`
+		Active   string  `db:"active"`
+		Verified *string `db:"verified"`
+	}
+	err = flags.Find().OrderBy("name").All(&raw)
+	s.NoError(err)
+	s.Len(raw, 2)
+	s.Equal("Y", raw[0].Active)
+	s.Equal("1", *raw[0].Verified)
+	s.Equal("N", raw[1].Active)
+	s.Nil(raw[1].Verified)
+
+	var items []flagsType
+	err = flags.Find().OrderBy("name").All(&items)
+	s.NoError(err)
+	s.Len(items, 2)
+	s.True(items[0].Active)
+	s.True(*items[0].Verified)
+	s.False(items[1].Active)
+	s.Nil(items[1].Verified)
+
+	var columns struct {
+		Active []bool `db:"active,bool=Y/N"`
 	}
+	err = flags.Find().OrderBy("name").AllColumns(&columns)
+	s.NoError(err)
+	s.Equal([]bool{true, false}, columns.Active)
 
-	// Fetching a pointer to a pointer.
-	var someArtistObj *artistType
-	err = artist.Find().Limit(1).One(&someArtistObj)
+	// A session mapping applies to arguments and fields without a bool tag.
+	mapped := sess.WithContext(context.Background())
+	mapped.SetBoolMapping(&db.BoolYesNo)
+
+	var untagged []struct {
+		Name   string `db:"name"`
+		Active bool   `db:"active"`
+	}
+	err = mapped.Collection("bool_flags").Find(db.Cond{"active": true}).All(&untagged)
 	s.NoError(err)
-	s.NotZero(someArtist.Name)
-	if s.Adapter() != "ql" {
-		s.NotZero(someArtist.ID)
+	s.Len(untagged, 1)
+	s.Equal("a", untagged[0].Name)
+	s.True(untagged[0].Active)
+
+	s.Nil(sess.BoolMapping())
+}
+
+func (s *SQLTestSuite) TestRowLocking() {
+	if s.Adapter() == "ql" {
+		s.T().Skip("Currently not supported.")
 	}
+
+	sess := s.Session()
+
+	err := sess.Tx(func(tx db.Session) error {
+		var artist artistType
+
+		res := tx.Collection("artist").Find(db.Cond{"name": "Ozzie"}).ForUpdate()
+		if err := res.One(&artist); err != nil {
+			return err
+		}
+		s.Equal("Ozzie", artist.Name)
+
+		artist.Name = "Ozzy"
+		if err := res.Update(artist); err != nil {
+			return err
+		}
+
+		var artists []artistType
+		err := tx.SQL().
+			SelectFrom("artist").
+			Where("name", "Ozzy").
+			ForShare().
+			All(&artists)
+		if err != nil {
+			return err
+		}
+		s.Len(artists, 1)
+
+		return nil
+	})
+	s.NoError(err)
+
+	count, err := sess.Collection("artist").Find(db.Cond{"name": "Ozzy"}).Count()
+	s.NoError(err)
+	s.Equal(uint64(1), count)
 }
 
-func (s *SQLTestSuite) TestGetWithOffset() {
+func (s *SQLTestSuite) TestModify() {
 	sess := s.Session()
 
 	artist := sess.Collection("artist")
+	res := artist.Find(db.Cond{"name": "Ozzie"})
 
-	// Fetching one struct.
-	var artists []artistType
-	err := artist.Find().Offset(1).All(&artists)
+	err := res.Modify(db.SetPath("name", "Ozzy"))
 	s.NoError(err)
 
-	s.Equal(3, len(artists))
+	count, err := artist.Find(db.Cond{"name": "Ozzy"}).Count()
+	s.NoError(err)
+	s.Equal(uint64(1), count)
+
+	switch s.Adapter() {
+	case "postgresql", "cockroachdb":
+		// Nested fields are tested by the adapter.
+	default:
+		err = artist.Find().Modify(db.SetPath("name.first", "Ozzy"))
+		s.True(errors.Is(err, db.ErrUnsupported))
+
+		err = artist.Find().Modify(db.Push("name", "Ozzy"))
+		s.True(errors.Is(err, db.ErrUnsupported))
+	}
 }
 
-func (s *SQLTestSuite) TestGetResultsOneByOne() {
+func (s *SQLTestSuite) TestCompile() {
 	sess := s.Session()
 
 	artist := sess.Collection("artist")
+	res := artist.Find(db.Cond{"name": "Ozzie"})
 
-	rowMap := map[string]interface{}{}
+	query, args, err := res.Compile()
+	s.NoError(err)
+	s.Contains(query, "SELECT")
+	s.Equal([]interface{}{"Ozzie"}, args)
 
-	res := artist.Find()
+	query, args, err = res.CompileUpdate(map[string]interface{}{"name": "Ozzy"})
+	s.NoError(err)
+	s.Contains(query, "UPDATE")
+	s.Equal([]interface{}{"Ozzy", "Ozzie"}, args)
 
-	err := res.Err()
+	query, args, err = res.CompileDelete()
 	s.NoError(err)
+	s.Contains(query, "DELETE")
+	s.Equal([]interface{}{"Ozzie"}, args)
 
-	for res.Next(&rowMap) {
-		s.NotZero(rowMap["id"])
-		s.NotZero(rowMap["name"])
-	}
-	err = res.Err()
+	query, args, err = artist.CompileInsert(artistType{Name: "Ozzy"})
 	s.NoError(err)
+	s.Contains(query, "INSERT")
+	s.Equal([]interface{}{"Ozzy"}, args)
 
-	err = res.Close()
+	// Nothing was sent to the database.
+	count, err := artist.Find(db.Cond{"name": "Ozzie"}).Count()
 	s.NoError(err)
+	s.Equal(uint64(1), count)
 
-	// Dumping into a tagged struct.
-	rowStruct2 := struct {
-		Value1 int64  `db:"id"`
-		Value2 string `db:"name"`
-	}{}
+	count, err = artist.Find(db.Cond{"name": "Ozzy"}).Count()
+	s.NoError(err)
+	s.Equal(uint64(0), count)
+}
 
-	res = artist.Find()
+func (s *SQLTestSuite) TestTTL() {
+	sess := s.Session()
 
-	for res.Next(&rowStruct2) {
-		s.NotZero(rowStruct2.Value1)
-		s.NotZero(rowStruct2.Value2)
+	type expiringBirthday struct {
+		ID   int64      `db:"id,omitempty"`
+		Name string     `db:"name"`
+		Born *time.Time `db:"born,ttl"`
 	}
-	err = res.Err()
+
+	birthdays := sess.Collection("birthdays")
+	s.NoError(birthdays.Truncate())
+
+	past, future := time.Now().Add(-48*time.Hour), time.Now().Add(48*time.Hour)
+
+	for _, item := range []expiringBirthday{
+		{Name: "expired", Born: &past},
+		{Name: "current", Born: &future},
+		{Name: "forever"},
+	} {
+		_, err := birthdays.Insert(item)
+		s.NoError(err)
+	}
+
+	var items []expiringBirthday
+	err := birthdays.Find().OrderBy("name").All(&items)
 	s.NoError(err)
+	s.Len(items, 2)
+	s.Equal("current", items[0].Name)
+	s.Equal("forever", items[1].Name)
 
-	err = res.Close()
+	var item expiringBirthday
+	err = birthdays.Find(db.Cond{"name": "expired"}).One(&item)
+	s.True(errors.Is(err, db.ErrNoMoreRows))
+
+	// Results that are not read into a struct with a ttl field are not filtered.
+	count, err := birthdays.Find().Count()
 	s.NoError(err)
+	s.Equal(uint64(3), count)
 
-	// Dumping into a slice of maps.
-	allRowsMap := []map[string]interface{}{}
+	// Every operation on a collection with a TTL skips expired rows.
+	expiring := db.WithTTL(birthdays, expiringBirthday{})
 
-	res = artist.Find()
+	count, err = expiring.Count()
+	s.NoError(err)
+	s.Equal(uint64(2), count)
 
-	err = res.All(&allRowsMap)
+	exists, err := expiring.Find(db.Cond{"name": "expired"}).Exists()
 	s.NoError(err)
-	s.Equal(4, len(allRowsMap))
+	s.False(exists)
 
-	for _, singleRowMap := range allRowsMap {
-		if fmt.Sprintf("%d", singleRowMap["id"]) == "0" {
-			s.T().Errorf("Expecting a not null ID.")
-		}
+	total, err := expiring.Find().Paginate(1).TotalEntries()
+	s.NoError(err)
+	s.Equal(uint64(2), total)
+
+	var rows []map[string]interface{}
+	err = expiring.Find().All(&rows)
+	s.NoError(err)
+	s.Len(rows, 2)
+
+	err = expiring.Find().Update(map[string]interface{}{"name": "updated"})
+	s.NoError(err)
+
+	err = expiring.Find(db.Cond{"name": "expired"}).Delete()
+	s.NoError(err)
+
+	count, err = birthdays.Find(db.Cond{"name": "expired"}).Count()
+	s.NoError(err)
+	s.Equal(uint64(1), count)
+
+	s.NoError(db.PurgeExpired(expiring, "born"))
+
+	count, err = birthdays.Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(2), count)
+
+	count, err = birthdays.Find(db.Cond{"name": "updated"}).Count()
+	s.NoError(err)
+	s.Equal(uint64(2), count)
+}
+
+func (s *SQLTestSuite) TestCapped() {
+	sess := s.Session()
+
+	birthdays := sess.Collection("birthdays")
+	s.NoError(birthdays.Truncate())
+
+	orderColumn := "id"
+	if s.Adapter() == "ql" {
+		orderColumn = "id()"
 	}
 
-	// Dumping into a slice of structs.
-	allRowsStruct := []struct {
+	_, err := db.Capped(birthdays, db.CappedOptions{OrderColumn: orderColumn})
+	s.Error(err)
+
+	_, err = db.Capped(birthdays, db.CappedOptions{MaxItems: 3})
+	s.Error(err)
+
+	capped, err := db.Capped(birthdays, db.CappedOptions{MaxItems: 3, OrderColumn: orderColumn})
+	s.NoError(err)
+	s.Equal("birthdays", capped.Name())
+
+	type birthday struct {
 		ID   int64  `db:"id,omitempty"`
 		Name string `db:"name"`
-	}{}
+	}
 
-	res = artist.Find()
+	names := func() []string {
+		var items []birthday
+		err := birthdays.Find().OrderBy(orderColumn).All(&items)
+		s.NoError(err)
+		names := []string{}
+		for _, item := range items {
+			names = append(names, item.Name)
+		}
+		return names
+	}
 
-	if err = res.All(&allRowsStruct); err != nil {
-		s.T().Errorf("%v", err)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		_, err := capped.Insert(birthday{Name: name})
+		s.NoError(err)
 	}
+	s.Equal([]string{"c", "d", "e"}, names())
 
-	s.Equal(4, len(allRowsStruct))
+	_, err = capped.InsertMany([]birthday{{Name: "f"}, {Name: "g"}}, 0)
+	s.NoError(err)
+	s.Equal([]string{"e", "f", "g"}, names())
 
-	for _, singleRowStruct := range allRowsStruct {
-		s.NotZero(singleRowStruct.ID)
+	// Writes through the plain collection are pruned on the next capped write.
+	_, err = birthdays.Insert(birthday{Name: "h"})
+	s.NoError(err)
+	s.Equal([]string{"e", "f", "g", "h"}, names())
+
+	_, err = capped.Insert(birthday{Name: "i"})
+	s.NoError(err)
+	s.Equal([]string{"g", "h", "i"}, names())
+
+	if s.Adapter() == "ql" {
+		return
 	}
 
-	// Dumping into a slice of tagged structs.
-	allRowsStruct2 := []struct {
-		Value1 int64  `db:"id"`
-		Value2 string `db:"name"`
-	}{}
+	// Items that share their order value are told apart by the primary key.
+	s.NoError(birthdays.Truncate())
 
-	res = artist.Find()
+	byBornUT, err := db.Capped(birthdays, db.CappedOptions{MaxItems: 3, OrderColumn: "born_ut"})
+	s.NoError(err)
 
-	err = res.All(&allRowsStruct2)
+	type birthdayUT struct {
+		Name   string `db:"name"`
+		BornUT int64  `db:"born_ut"`
+	}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		_, err := byBornUT.Insert(birthdayUT{Name: name, BornUT: 1})
+		s.NoError(err)
+	}
+	s.Equal([]string{"c", "d", "e"}, names())
+}
+
+func (s *SQLTestSuite) TestCommonTableExpressions() {
+	switch s.Adapter() {
+	case "postgresql", "cockroachdb", "sqlite", "mysql":
+	default:
+		s.T().Skip("Currently not supported.")
+	}
+
+	sess := s.Session()
+
+	var artists []artistType
+	err := sess.SQL().SelectFrom("named").
+		With("named", sess.SQL().SelectFrom("artist").Where(db.Cond{"name <>": "Ozzie"})).
+		OrderBy("name").
+		All(&artists)
 	s.NoError(err)
+	s.Len(artists, 3)
+	s.Equal("Chrono", artists[0].Name)
 
-	s.Equal(4, len(allRowsStruct2))
+	var numbers []struct {
+		N int `db:"n"`
+	}
+	err = sess.SQL().SelectFrom("counter").
+		WithRecursive("counter(n)", db.Raw(`SELECT 1 UNION ALL SELECT n + 1 FROM counter WHERE n < ?`, 5)).
+		OrderBy("n").
+		All(&numbers)
+	s.NoError(err)
+	s.Len(numbers, 5)
+	s.Equal(5, numbers[4].N)
+}
 
-	for _, singleRowStruct := range allRowsStruct2 {
-		s.NotZero(singleRowStruct.Value1)
+func (s *SQLTestSuite) TestRawQuery() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+	s.NoError(artist.Truncate())
+
+	for _, name := range []string{"Ozzie", "Flea", "Slash"} {
+		_, err := artist.Insert(artistType{Name: name})
+		s.NoError(err)
+	}
+
+	var artists []artistType
+	err := sess.Query(&artists, `SELECT * FROM artist WHERE name != ? ORDER BY name`, "Ozzie")
+	s.NoError(err)
+	s.Len(artists, 2)
+	s.Equal("Flea", artists[0].Name)
+
+	err = sess.Query(&artists, `SELECT * FROM artist WHERE name IN (?) ORDER BY name`, []string{"Flea", "Ozzie"})
+	s.NoError(err)
+	s.Len(artists, 2)
+	s.Equal("Ozzie", artists[1].Name)
+
+	count, err := artist.Find(db.Raw("name IN (?)", []string{"Slash"})).Count()
+	s.NoError(err)
+	s.Equal(uint64(1), count)
+
+	var one artistType
+	err = sess.Query(&one, `SELECT * FROM artist WHERE name = ?`, "Slash")
+	s.NoError(err)
+	s.Equal("Slash", one.Name)
+
+	err = sess.Query(&one, `SELECT * FROM artist WHERE name = ?`, "Nobody")
+	s.True(errors.Is(err, db.ErrNoMoreRows))
+
+	err = sess.Tx(func(tx db.Session) error {
+		res, err := tx.Exec(`UPDATE artist SET name = ? WHERE name = ?`, "Anthony", "Flea")
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		s.NoError(err)
+		s.Equal(int64(1), affected)
+
+		return tx.Query(&one, `SELECT * FROM artist WHERE name = ?`, "Anthony")
+	})
+	s.NoError(err)
+	s.Equal("Anthony", one.Name)
+}
+
+func (s *SQLTestSuite) TestRawQueryNamedParameters() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+	s.NoError(artist.Truncate())
+
+	for _, name := range []string{"Ozzie", "Flea", "Slash"} {
+		_, err := artist.Insert(artistType{Name: name})
+		s.NoError(err)
+	}
+
+	var artists []artistType
+	err := sess.Query(&artists, `SELECT * FROM artist WHERE name IN :names OR name = :name ORDER BY name`, map[string]interface{}{
+		"names": []string{"Flea", "Slash"},
+		"name":  "Ozzie",
+	})
+	s.NoError(err)
+	s.Len(artists, 3)
+
+	var one artistType
+	err = sess.Query(&one, `SELECT * FROM artist WHERE name = :name`, artistType{Name: "Slash"})
+	s.NoError(err)
+	s.Equal("Slash", one.Name)
+
+	res, err := sess.Exec(`UPDATE artist SET name = :to WHERE name = :from`, map[string]string{"from": "Flea", "to": "Anthony"})
+	s.NoError(err)
+	affected, err := res.RowsAffected()
+	s.NoError(err)
+	s.Equal(int64(1), affected)
+
+	count, err := artist.Find(db.Raw("name = :name", map[string]interface{}{"name": "Anthony"})).Count()
+	s.NoError(err)
+	s.Equal(uint64(1), count)
+}
+
+func (s *SQLTestSuite) TestTriggers() {
+	trigger := &db.Trigger{
+		Name:   "artist_upper_name",
+		Events: db.TriggerInsert,
+	}
+	switch s.Adapter() {
+	case "postgresql":
+		trigger.Body = "NEW.name = upper(NEW.name); RETURN NEW;"
+	case "mysql":
+		trigger.Body = "SET NEW.name = UPPER(NEW.name);"
+	case "sqlite":
+		trigger.Timing = db.TriggerAfter
+		trigger.Body = "UPDATE artist SET name = upper(name) WHERE id = NEW.id;"
+	default:
+		s.T().Skip("Currently not supported.")
 	}
-}
 
-func (s *SQLTestSuite) TestGetAllResults() {
 	sess := s.Session()
-
 	artist := sess.Collection("artist")
+	s.NoError(artist.Truncate())
 
-	total, err := artist.Find().Count()
-	s.NoError(err)
-	s.NotZero(total)
-
-	// Fetching all artists into struct
-	artists := []artistType{}
+	s.NoError(artist.CreateTrigger(trigger))
+	defer func() {
+		_ = artist.DropTrigger(trigger.Name)
+	}()
 
-	res := artist.Find()
+	triggers, err := artist.Triggers()
+	s.NoError(err)
+	s.Len(triggers, 1)
+	s.Equal(trigger.Name, triggers[0].Name)
+	s.Equal(db.TriggerInsert, triggers[0].Events)
+	s.Contains(strings.ToLower(triggers[0].Body), "upper(")
 
-	err = res.All(&artists)
+	_, err = artist.Insert(artistType{Name: "Ozzie"})
 	s.NoError(err)
-	s.Equal(len(artists), int(total))
 
-	s.NotZero(artists[0].Name)
-	s.NotZero(artists[0].ID)
+	var item artistType
+	s.NoError(artist.Find().One(&item))
+	s.Equal("OZZIE", item.Name)
 
-	// Fetching all artists into struct pointers
-	artistObjs := []*artistType{}
-	res = artist.Find()
+	s.NoError(artist.DropTrigger(trigger.Name))
 
-	err = res.All(&artistObjs)
+	triggers, err = artist.Triggers()
 	s.NoError(err)
-	s.Equal(len(artistObjs), int(total))
+	s.Empty(triggers)
 
-	s.NotZero(artistObjs[0].Name)
-	s.NotZero(artistObjs[0].ID)
+	err = artist.CreateTrigger(&db.Trigger{Name: "artist_no_events"})
+	s.Error(err)
 }
 
-func (s *SQLTestSuite) TestInlineStructs() {
-	type reviewTypeDetails struct {
-		Name     string    `db:"name"`
-		Comments string    `db:"comments"`
-		Created  time.Time `db:"created"`
+func (s *SQLTestSuite) TestQueryBudget() {
+	ctx := db.WithBudget(context.Background(), db.Budget{MaxQueries: 2})
+	sess := s.Session().WithContext(ctx)
+
+	// A single statement on every adapter, QL's Find looks up the columns of
+	// the table first.
+	count := func(sess db.Session) error {
+		var total int
+		return sess.SQL().Select(db.Raw("count(1)")).From("artist").Iterator().ScanOne(&total)
 	}
 
-	type reviewType struct {
-		ID            int64             `db:"id,omitempty"`
-		PublicationID int64             `db:"publication_id"`
-		Details       reviewTypeDetails `db:",inline"`
+	for i := 0; i < 2; i++ {
+		s.NoError(count(sess))
 	}
 
-	sess := s.Session()
+	err := count(sess)
+	s.True(errors.Is(err, db.ErrBudgetExceeded))
 
-	review := sess.Collection("review")
+	var budgetErr *db.BudgetError
+	s.True(errors.As(err, &budgetErr))
+	s.Equal(2, budgetErr.Queries)
+	s.Contains(string(budgetErr.Stack), "TestQueryBudget")
 
-	err := review.Truncate()
-	s.NoError(err)
+	queries, _, ok := db.BudgetUsage(ctx)
+	s.True(ok)
+	s.Equal(2, queries)
 
-	rec := reviewType{
-		PublicationID: 123,
-		Details: reviewTypeDetails{
-			Name:     "..name..",
-			Comments: "..comments..",
-		},
-	}
+	// Sessions without the budget are not affected.
+	s.NoError(count(s.Session()))
+}
 
-	var createdAt time.Time
+func (s *SQLTestSuite) TestTrackUsage() {
+	s.NoError(s.Session().Collection("artist").Truncate())
 
-	switch s.Adapter() {
-	case "postgresql", "cockroachdb":
-		createdAt = time.Date(2016, time.January, 1, 2, 3, 4, 0, time.FixedZone("", 0))
-	case "mysql":
-		// MySQL uses a global time zone
-		createdAt = time.Date(2016, time.January, 1, 2, 3, 4, 0, TimeLocation)
-	default:
-		createdAt = time.Date(2016, time.January, 1, 2, 3, 4, 0, time.UTC)
+	var reported db.Usage
+	ctx, done := db.TrackUsage(context.Background(), func(u db.Usage) {
+		reported = u
+	})
+	sess := s.Session().WithContext(ctx)
+
+	artist := sess.Collection("artist")
+	for _, name := range []string{"Ozzie", "Flea"} {
+		_, err := artist.Insert(map[string]string{"name": name})
+		s.NoError(err)
 	}
-	rec.Details.Created = createdAt
 
-	record, err := review.Insert(rec)
+	// Use the builder, QL's Find reads the columns of the table too.
+	var artists []map[string]interface{}
+	s.NoError(sess.SQL().SelectFrom("artist").All(&artists))
+	s.Len(artists, 2)
+
+	_, err := sess.SQL().Update("artist").Set("name", "Slash").Where("name", "Flea").Exec()
 	s.NoError(err)
-	s.NotZero(record.ID().(int64))
 
-	rec.ID = record.ID().(int64)
+	usage, ok := db.UsageOf(ctx)
+	s.True(ok)
+	s.Equal(int64(2), usage.RowsRead)
+	s.True(usage.BytesRead >= int64(len("Ozzie")+len("Flea")))
+	s.Equal(int64(3), usage.RowsWritten)
+	s.True(usage.BytesWritten >= int64(len("Ozzie")+len("Flea")+len("Slash")))
 
-	var recChk reviewType
-	res := review.Find()
+	done()
+	s.Equal(usage, reported)
 
-	err = res.One(&recChk)
+	// Sessions without the context don't add to the usage.
+	_, err = s.Session().Collection("artist").Find().Count()
 	s.NoError(err)
 
-	s.Equal(rec, recChk)
+	usage, _ = db.UsageOf(ctx)
+	s.Equal(reported, usage)
 }
 
-func (s *SQLTestSuite) TestUpdate() {
+func (s *SQLTestSuite) TestInto() {
 	sess := s.Session()
-
 	artist := sess.Collection("artist")
 
-	_, err := artist.Insert(map[string]string{
-		"name": "Ozzie",
-	})
-	s.NoError(err)
-
-	// Defining destination struct
-	value := struct {
-		ID   int64  `db:"id,omitempty"`
-		Name string `db:"name"`
-	}{}
-
-	// Getting the first artist.
-	cond := db.Cond{"id !=": db.NotEq(0)}
 	if s.Adapter() == "ql" {
-		cond = db.Cond{"id() !=": 0}
+		_, err := artist.Find().Into("artist_report")
+		s.True(errors.Is(err, db.ErrUnsupported))
+		return
 	}
-	res := artist.Find(cond).Limit(1)
 
-	err = res.One(&value)
+	res := artist.Find(db.Cond{"name <>": "Ozzie"}).OrderBy("name")
+
+	report, err := res.Into("artist_report")
 	s.NoError(err)
+	s.Equal("artist_report", report.Name())
 
-	res = artist.Find(value.ID)
+	defer func() {
+		_, err := sess.SQL().Exec("DROP TABLE artist_report")
+		s.NoError(err)
+	}()
 
-	// Updating set with a map
-	rowMap := map[string]interface{}{
-		"name": strings.ToUpper(value.Name),
+	var names []struct {
+		Name string `db:"name"`
 	}
-
-	err = res.Update(rowMap)
+	err = report.Find().OrderBy("name").All(&names)
 	s.NoError(err)
+	s.Len(names, 3)
+	s.Equal("Chrono", names[0].Name)
 
-	// Pulling it again.
-	err = res.One(&value)
+	// The table is replaced on the next call.
+	report, err = artist.Find(db.Cond{"name": "Ozzie"}).Into("artist_report")
 	s.NoError(err)
 
-	// Verifying.
-	s.Equal(value.Name, rowMap["name"])
-
-	if s.Adapter() != "ql" {
+	count, err := report.Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(1), count)
 
-		// Updating using raw
-		if err = res.Update(map[string]interface{}{"name": db.Raw("LOWER(name)")}); err != nil {
-			s.T().Errorf("%v", err)
-		}
+	// A failed replacement keeps the previous table where DDL is transactional.
+	if s.Adapter() == "postgresql" || s.Adapter() == "sqlite" {
+		_, err = artist.Find(db.Cond{"no_such_table.id": 1}).Into("artist_report")
+		s.Error(err)
 
-		// Pulling it again.
-		err = res.One(&value)
+		count, err = report.Find().Count()
 		s.NoError(err)
+		s.Equal(uint64(1), count)
+	}
+}
 
-		// Verifying.
-		s.Equal(value.Name, strings.ToLower(rowMap["name"].(string)))
-
-		// Updating using raw
-		if err = res.Update(struct {
-			Name *db.RawExpr `db:"name"`
-		}{db.Raw(`UPPER(name)`)}); err != nil {
-			s.T().Errorf("%v", err)
-		}
-
-		// Pulling it again.
-		err = res.One(&value)
-		s.NoError(err)
+func (s *SQLTestSuite) TestPivot() {
+	if s.Adapter() == "ql" {
+		s.T().Skip("Currently not supported.")
+	}
 
-		// Verifying.
-		s.Equal(value.Name, strings.ToUpper(rowMap["name"].(string)))
+	sess := s.Session()
 
-		// Updating using raw
-		if err = res.Update(struct {
-			Name *db.FuncExpr `db:"name"`
-		}{db.Func("LOWER", db.Raw("name"))}); err != nil {
-			s.T().Errorf("%v", err)
-		}
+	type itemWithCompoundKey struct {
+		Code    string `db:"code"`
+		UserID  string `db:"user_id"`
+		SomeVal string `db:"some_val"`
+	}
 
-		// Pulling it again.
-		err = res.One(&value)
+	compositeKeys := sess.Collection("composite_keys")
+	s.NoError(compositeKeys.Truncate())
+
+	for _, item := range []itemWithCompoundKey{
+		{"a", "1", "x"},
+		{"b", "1", "y"},
+		{"a", "2", "z"},
+		{"c", "2", "ignored"},
+	} {
+		_, err := compositeKeys.Insert(item)
 		s.NoError(err)
+	}
 
-		// Verifying.
-		s.Equal(value.Name, strings.ToLower(rowMap["name"].(string)))
+	p := db.Pivot{
+		Key:        "user_id",
+		Category:   "code",
+		Value:      "some_val",
+		Categories: []interface{}{"a", "b"},
+		Aggregate:  "MAX",
 	}
 
-	// Updating set with a struct
-	rowStruct := struct {
-		Name string `db:"name"`
-	}{strings.ToLower(value.Name)}
+	expected := []map[string]string{
+		{"user_id": "1", "a": "x", "b": "y"},
+		{"user_id": "2", "a": "z", "b": ""},
+	}
 
-	err = res.Update(rowStruct)
-	s.NoError(err)
+	toStrings := func(rows []map[string]interface{}) []map[string]string {
+		out := make([]map[string]string, len(rows))
+		for i := range rows {
+			out[i] = map[string]string{}
+			for k, v := range rows[i] {
+				switch v := v.(type) {
+				case nil:
+					out[i][k] = ""
+				case []byte:
+					out[i][k] = string(v)
+				default:
+					out[i][k] = fmt.Sprintf("%v", v)
+				}
+			}
+		}
+		return out
+	}
 
-	// Pulling it again.
-	err = res.One(&value)
+	// Client side.
+	rows, err := p.Fold(compositeKeys.Find().OrderBy("user_id", "code"))
 	s.NoError(err)
+	s.Equal(expected, toStrings(rows))
 
-	// Verifying
-	s.Equal(value.Name, rowStruct.Name)
-
-	// Updating set with a tagged struct
-	rowStruct2 := struct {
-		Value1 string `db:"name"`
-	}{"john"}
-
-	err = res.Update(rowStruct2)
+	// In SQL.
+	rows = nil
+	err = p.Apply(compositeKeys.Find()).OrderBy("user_id").All(&rows)
 	s.NoError(err)
+	s.Equal(expected, toStrings(rows))
+}
 
-	// Pulling it again.
-	err = res.One(&value)
-	s.NoError(err)
+func (s *SQLTestSuite) TestExistingIDs() {
+	sess := s.Session()
 
-	// Verifying
-	s.Equal(value.Name, rowStruct2.Value1)
+	artist := sess.Collection("artist")
+	s.NoError(artist.Truncate())
 
-	// Updating set with a tagged object
-	rowStruct3 := &struct {
-		Value1 string `db:"name"`
-	}{"anderson"}
+	var existing []interface{}
+	for _, name := range []string{"Ozzie", "Flea", "Slash"} {
+		res, err := artist.Insert(map[string]interface{}{"name": name})
+		s.NoError(err)
+		existing = append(existing, res.ID())
+	}
 
-	err = res.Update(rowStruct3)
-	s.NoError(err)
+	// Enough missing IDs to need more than one query.
+	ids := []interface{}{existing[0], existing[2]}
+	for i := 0; i < 1200; i++ {
+		ids = append(ids, int64(100000+i))
+	}
 
-	// Pulling it again.
-	err = res.One(&value)
+	exists, err := artist.ExistingIDs(ids)
 	s.NoError(err)
+	s.Len(exists, len(ids))
+	s.True(exists[existing[0]])
+	s.True(exists[existing[2]])
+	s.False(exists[int64(100000)])
+	s.False(exists[int64(101199)])
 
-	// Verifying
-	s.Equal(value.Name, rowStruct3.Value1)
+	_, ok := exists[existing[1]]
+	s.False(ok)
+
+	exists, err = artist.ExistingIDs(nil)
+	s.NoError(err)
+	s.Len(exists, 0)
 }
 
-func (s *SQLTestSuite) TestFunction() {
+func (s *SQLTestSuite) TestHierarchy() {
+	if s.Adapter() == "ql" {
+		s.T().Skip("Currently not supported.")
+	}
+
 	sess := s.Session()
 
-	rowStruct := struct {
-		ID   int64
-		Name string
-	}{}
+	// The input column holds the ID of the parent item, zero means no parent.
+	type treeItem struct {
+		ID     uint64 `db:"id,omitempty"`
+		Input  uint64 `db:"input"`
+		Output uint64 `db:"output"`
+	}
 
-	artist := sess.Collection("artist")
+	fibonacci := sess.Collection("fibonacci")
+	s.NoError(fibonacci.Truncate())
 
-	cond := db.Cond{"id NOT IN": []int{0, -1}}
-	if s.Adapter() == "ql" {
-		cond = db.Cond{"id() NOT IN": []int{0, -1}}
+	insert := func(parentID interface{}, output uint64) interface{} {
+		var parent uint64
+		if parentID != nil {
+			parent, _ = strconv.ParseUint(fmt.Sprintf("%v", parentID), 10, 64)
+		}
+		res, err := fibonacci.Insert(treeItem{Input: parent, Output: output})
+		s.NoError(err)
+		return res.ID()
 	}
-	res := artist.Find(cond)
-
-	err := res.One(&rowStruct)
-	s.NoError(err)
 
-	total, err := res.Count()
-	s.NoError(err)
-	s.Equal(uint64(4), total)
+	root := insert(nil, 0)
+	a := insert(root, 1)
+	insert(root, 2)
+	c := insert(a, 3)
 
-	// Testing conditions
-	cond = db.Cond{"id NOT IN": []interface{}{0, -1}}
-	if s.Adapter() == "ql" {
-		cond = db.Cond{"id() NOT IN": []interface{}{0, -1}}
+	outputOf := func(node *db.Node) string {
+		if b, ok := node.Item["output"].([]byte); ok {
+			return string(b)
+		}
+		return fmt.Sprintf("%v", node.Item["output"])
 	}
-	res = artist.Find(cond)
 
-	err = res.One(&rowStruct)
+	nodes, err := fibonacci.FindDescendants(root, "input")
 	s.NoError(err)
-
-	total, err = res.Count()
+	s.Len(nodes, 4)
+	s.Equal("0", outputOf(nodes[0]))
+	s.Equal(0, nodes[0].Depth)
+	s.Len(nodes[0].Children, 2)
+	s.Equal(2, nodes[3].Depth)
+	s.Equal("3", outputOf(nodes[3]))
+	s.Equal([]*db.Node{nodes[3]}, nodes[0].Children[0].Children)
+
+	nodes, err = fibonacci.FindAncestors(c, "input")
 	s.NoError(err)
-	s.Equal(uint64(4), total)
-
-	res = artist.Find().Select("name")
-
-	var rowMap map[string]interface{}
-	err = res.One(&rowMap)
+	s.Len(nodes, 3)
+	s.Equal("3", outputOf(nodes[0]))
+	s.Equal("1", outputOf(nodes[1]))
+	s.Equal("0", outputOf(nodes[2]))
+	s.Equal(2, nodes[2].Depth)
+	s.Equal([]*db.Node{nodes[1]}, nodes[2].Children)
+
+	_, err = fibonacci.FindDescendants(999999, "input")
+	s.True(errors.Is(err, db.ErrNoMoreRows))
+
+	// Walking one level at a time finds the same nodes.
+	nodes, err = db.Descendants(fibonacci, "id", root, "input")
 	s.NoError(err)
+	s.Len(nodes, 4)
+	s.Equal(2, nodes[3].Depth)
+	s.Len(nodes[0].Children, 2)
 
-	total, err = res.Count()
+	nodes, err = db.Ancestors(fibonacci, "id", c, "input")
 	s.NoError(err)
-	s.Equal(uint64(4), total)
-
-	res = artist.Find().Select("name")
+	s.Len(nodes, 3)
+	s.Equal("0", outputOf(nodes[2]))
 
-	err = res.One(&rowMap)
+	// A cycle doesn't make the lookup run forever or repeat nodes.
+	err = fibonacci.Find(root).Update(map[string]interface{}{"input": c})
 	s.NoError(err)
 
-	total, err = res.Count()
+	nodes, err = fibonacci.FindDescendants(root, "input")
 	s.NoError(err)
-	s.Equal(uint64(4), total)
-}
-
-func (s *SQLTestSuite) TestNullableFields() {
-	sess := s.Session()
-
-	type testType struct {
-		ID              int64           `db:"id,omitempty"`
-		NullStringTest  sql.NullString  `db:"_string"`
-		NullInt64Test   sql.NullInt64   `db:"_int64"`
-		NullFloat64Test sql.NullFloat64 `db:"_float64"`
-		NullBoolTest    sql.NullBool    `db:"_bool"`
-	}
-
-	col := sess.Collection(`data_types`)
+	s.Len(nodes, 4)
 
-	err := col.Truncate()
+	nodes, err = fibonacci.FindAncestors(c, "input")
 	s.NoError(err)
+	s.Len(nodes, 3)
+}
 
-	// Testing insertion of invalid nulls.
-	test := testType{
-		NullStringTest:  sql.NullString{String: "", Valid: false},
-		NullInt64Test:   sql.NullInt64{Int64: 0, Valid: false},
-		NullFloat64Test: sql.NullFloat64{Float64: 0.0, Valid: false},
-		NullBoolTest:    sql.NullBool{Bool: false, Valid: false},
+func (s *SQLTestSuite) TestResultJoins() {
+	if s.Adapter() == "ql" {
+		s.T().Skip("Currently not supported.")
 	}
 
-	id, err := col.Insert(testType{})
-	s.NoError(err)
+	sess := s.Session()
 
-	// Testing fetching of invalid nulls.
-	err = col.Find(id).One(&test)
+	artist := sess.Collection("artist")
+	publication := sess.Collection("publication")
+
+	var flea artistType
+	err := artist.Find(db.Cond{"name": "Flea"}).One(&flea)
 	s.NoError(err)
 
-	s.False(test.NullInt64Test.Valid)
-	s.False(test.NullFloat64Test.Valid)
-	s.False(test.NullBoolTest.Valid)
+	for _, title := range []string{"Acid for the Children", "Ain't Love Grand"} {
+		_, err := publication.Insert(map[string]interface{}{
+			"title":     title,
+			"author_id": flea.ID,
+		})
+		s.NoError(err)
+	}
 
-	// Testing insertion of valid nulls.
-	test = testType{
-		NullStringTest:  sql.NullString{String: "", Valid: true},
-		NullInt64Test:   sql.NullInt64{Int64: 0, Valid: true},
-		NullFloat64Test: sql.NullFloat64{Float64: 0.0, Valid: true},
-		NullBoolTest:    sql.NullBool{Bool: false, Valid: true},
+	type artistPublication struct {
+		Name  string `db:"name"`
+		Title string `db:"title"`
 	}
 
-	id, err = col.Insert(test)
-	s.NoError(err)
+	{
+		var rows []artistPublication
+		err := artist.Find().
+			Select("artist.name", "publication.title").
+			InnerJoin("publication").On("publication.author_id = artist.id").
+			OrderBy("publication.title").
+			All(&rows)
+		s.NoError(err)
+		s.Equal([]artistPublication{
+			{"Flea", "Acid for the Children"},
+			{"Flea", "Ain't Love Grand"},
+		}, rows)
+	}
 
-	// Testing fetching of valid nulls.
-	err = col.Find(id).One(&test)
-	s.NoError(err)
+	{
+		res := artist.Find().
+			LeftJoin("publication AS p").On("p.author_id = artist.id")
 
-	s.True(test.NullInt64Test.Valid)
-	s.True(test.NullBoolTest.Valid)
-	s.True(test.NullStringTest.Valid)
+		total, err := res.Count()
+		s.NoError(err)
+		s.Equal(uint64(5), total)
+
+		total, err = res.And("p.id IS NULL").Count()
+		s.NoError(err)
+		s.Equal(uint64(3), total)
+	}
+
+	{
+		res := artist.Find().On("p.author_id = artist.id")
+		err := res.All(&[]artistType{})
+		s.Error(err)
+	}
+
+	{
+		res := artist.Find().Join("publication").On("publication.author_id = artist.id")
+		s.True(errors.Is(res.Delete(), db.ErrUnsupported))
+		s.True(errors.Is(res.Update(db.Cond{"name": "Peppers"}), db.ErrUnsupported))
+	}
 }
 
-func (s *SQLTestSuite) TestGroup() {
+func (s *SQLTestSuite) TestResultSubqueries() {
+	if s.Adapter() == "ql" {
+		s.T().Skip("Currently not supported.")
+	}
+
 	sess := s.Session()
 
-	type statsType struct {
-		Numeric int `db:"numeric"`
-		Value   int `db:"value"`
-	}
+	artist := sess.Collection("artist")
+	publication := sess.Collection("publication")
+	s.NoError(publication.Truncate())
 
-	stats := sess.Collection("stats_test")
+	for _, name := range []string{"Flea", "Slash"} {
+		var author artistType
+		err := artist.Find(db.Cond{"name": name}).One(&author)
+		s.NoError(err)
 
-	err := stats.Truncate()
-	s.NoError(err)
+		_, err = publication.Insert(map[string]interface{}{
+			"title":     "By " + name,
+			"author_id": author.ID,
+		})
+		s.NoError(err)
+	}
 
-	// Adding row append.
-	for i := 0; i < 100; i++ {
-		numeric, value := rand.Intn(5), rand.Intn(100)
-		_, err := stats.Insert(statsType{numeric, value})
+	names := func(res db.Result) []string {
+		var items []artistType
+		err := res.OrderBy("name").All(&items)
 		s.NoError(err)
+
+		names := []string{}
+		for i := range items {
+			names = append(names, items[i].Name)
+		}
+		return names
 	}
 
-	// Testing GROUP BY
-	res := stats.Find().Select(
-		"numeric",
-		db.Raw("count(1) AS counter"),
-		db.Raw("sum(value) AS total"),
-	).GroupBy("numeric")
+	authors := publication.Find(db.Cond{"title LIKE": "By %"}).Select("author_id")
 
-	var results []map[string]interface{}
+	s.Equal([]string{"Flea", "Slash"}, names(artist.Find(db.Cond{"id IN": authors})))
+	s.Equal([]string{"Chrono", "Ozzie"}, names(artist.Find(db.Cond{"id": db.NotIn(authors)})))
+	s.Equal([]string{"Slash"}, names(artist.Find(db.Cond{"id IN": authors, "name <>": "Flea"})))
 
-	err = res.All(&results)
-	s.NoError(err)
+	written := publication.Find(db.Raw("publication.author_id = artist.id"))
+	s.Equal([]string{"Flea", "Slash"}, names(artist.Find(db.Exists(written))))
+	s.Equal([]string{"Chrono", "Ozzie"}, names(artist.Find(db.NotExists(written))))
 
-	s.Equal(5, len(results))
+	var row struct {
+		Total int `db:"total"`
+	}
+	err := sess.SQL().
+		Select(db.Raw("COUNT(1) AS total")).
+		From(artist.Find(db.Cond{"name <>": "Ozzie"})).As("a").
+		One(&row)
+	s.NoError(err)
+	s.Equal(3, row.Total)
 }
 
 func (s *SQLTestSuite) TestInsertAndDelete() {
@@ -988,6 +3310,51 @@ func (s *SQLTestSuite) TestInsertAndDelete() {
 	s.Equal(uint64(0), total)
 }
 
+func (s *SQLTestSuite) TestWriteAfterFailedStatement() {
+	if s.Adapter() == "ql" {
+		s.T().Skip("Currently not supported.")
+	}
+
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+
+	var item artistType
+	err := artist.Find().One(&item)
+	s.NoError(err)
+
+	// The statement fails on the primary key, it must not leave its
+	// transaction open and the table locked.
+	_, err = sess.SQL().InsertInto("artist").Values(item).Exec()
+	s.Error(err)
+
+	_, err = artist.Insert(artistType{Name: "Vinicius"})
+	s.NoError(err)
+}
+
+func (s *SQLTestSuite) TestConstraintErrors() {
+	if s.Adapter() == "ql" {
+		s.T().Skip("Currently not supported.")
+	}
+
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+
+	var item artistType
+	err := artist.Find().One(&item)
+	s.NoError(err)
+
+	_, err = artist.Insert(item)
+	s.Error(err)
+	s.True(errors.Is(err, db.ErrDuplicateKey))
+	s.False(errors.Is(err, db.ErrNotNullViolation))
+
+	var constraintErr *db.ConstraintError
+	s.True(errors.As(err, &constraintErr))
+	s.Equal(db.ErrDuplicateKey, constraintErr.Kind)
+}
+
 func (s *SQLTestSuite) TestCompositeKeys() {
 	if s.Adapter() == "ql" {
 		s.T().Skip("Currently not supported.")
@@ -1034,6 +3401,102 @@ func (s *SQLTestSuite) TestCompositeKeys() {
 	}
 }
 
+// Checks that a panic in the Tx callback rolls back the transaction and is
+// passed on to the caller.
+func (s *SQLTestSuite) TestTransactionPanicRollback() {
+	if s.Adapter() == "ql" {
+		s.T().Skip("Currently not supported.")
+	}
+
+	sess := s.Session()
+
+	count, err := sess.Collection("artist").Find().Count()
+	s.NoError(err)
+
+	s.PanicsWithValue("oops", func() {
+		_ = sess.Tx(func(tx db.Session) error {
+			_, err := tx.Collection("artist").Insert(artistType{Name: "Panic"})
+			s.NoError(err)
+
+			panic("oops")
+		})
+	})
+
+	// The row inserted before the panic must have been rolled back.
+	after, err := sess.Collection("artist").Find().Count()
+	s.NoError(err)
+	s.Equal(count, after)
+
+	// The session is still usable.
+	err = sess.Tx(func(tx db.Session) error {
+		_, err := tx.Collection("artist").Insert(artistType{Name: "Calm"})
+		return err
+	})
+	s.NoError(err)
+}
+
+func (s *SQLTestSuite) TestNestedTransactions() {
+	if s.Adapter() == "ql" {
+		s.T().Skip("Currently not supported.")
+	}
+
+	sess := s.Session()
+
+	err := sess.Collection("artist").Truncate()
+	s.NoError(err)
+
+	err = sess.Tx(func(tx db.Session) error {
+		_, err := tx.Collection("artist").Insert(artistType{Name: "Outer"})
+		s.NoError(err)
+
+		// The inner transaction is rolled back to its savepoint.
+		err = tx.Tx(func(inner db.Session) error {
+			_, err := inner.Collection("artist").Insert(artistType{Name: "Discarded"})
+			s.NoError(err)
+			return fmt.Errorf("rollback for no reason")
+		})
+		s.Error(err)
+
+		// The inner transaction is released into the outer one.
+		err = tx.Tx(func(inner db.Session) error {
+			_, err := inner.Collection("artist").Insert(artistType{Name: "Inner"})
+			return err
+		})
+		s.NoError(err)
+
+		count, err := tx.Collection("artist").Find().Count()
+		s.NoError(err)
+		s.Equal(uint64(2), count)
+
+		return nil
+	})
+	s.NoError(err)
+
+	var names []string
+	var artists []artistType
+	err = sess.Collection("artist").Find().OrderBy("id").All(&artists)
+	s.NoError(err)
+	for i := range artists {
+		names = append(names, artists[i].Name)
+	}
+	s.Equal([]string{"Outer", "Inner"}, names)
+
+	// Nothing survives if the outer transaction is rolled back.
+	err = sess.Tx(func(tx db.Session) error {
+		err := tx.Tx(func(inner db.Session) error {
+			_, err := inner.Collection("artist").Insert(artistType{Name: "Lost"})
+			return err
+		})
+		s.NoError(err)
+		return fmt.Errorf("rollback for no reason")
+	})
+	s.Error(err)
+
+	count, err := sess.Collection("artist").Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(2), count)
+}
+
 // Attempts to test database transactions.
 func (s *SQLTestSuite) TestTransactionsAndRollback() {
 	if s.Adapter() == "ql" {
@@ -1587,6 +4050,36 @@ func (s *SQLTestSuite) TestPaginator() {
 		}
 	}
 
+	{
+		// Keyset pagination uses the primary key when no cursor was set.
+		pageSize := 20
+		res := sess.Collection("artist").Find().OrderBy("id").Paginate(uint(pageSize))
+
+		var firstPage []artistType
+		err := res.All(&firstPage)
+		s.NoError(err)
+		s.Len(firstPage, pageSize)
+
+		next := res.NextPage(firstPage[len(firstPage)-1].ID)
+		s.NotContains(next.String(), "OFFSET")
+
+		var items []artistType
+		err = next.All(&items)
+		s.NoError(err)
+		s.Len(items, pageSize)
+		for j := range items {
+			s.Equal(fmt.Sprintf("artist-%d", pageSize+j), items[j].Name)
+		}
+
+		err = res.PrevPage(items[0].ID).All(&items)
+		s.NoError(err)
+		s.Equal(firstPage, items)
+
+		// A cursor column is required outside of collections.
+		err = q.Paginate(uint(pageSize)).NextPage(1).All(&items)
+		s.Error(err)
+	}
+
 	{
 		// Testing page size 0.
 		paginator := q.Paginate(0)
@@ -1801,3 +4294,129 @@ func (s *SQLTestSuite) Test_Issue565() {
 	s.Error(err)
 	s.Zero(result.Name)
 }
+
+func (s *SQLTestSuite) TestValuesTable() {
+	column := "id"
+	switch s.Adapter() {
+	case "postgresql", "cockroachdb":
+		column = "id integer"
+	case "ql", "clickhouse":
+		s.T().Skip("Currently not supported.")
+	}
+
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+	s.NoError(artist.Truncate())
+
+	ids := []int64{}
+	for _, name := range []string{"Ozzie", "Flea", "Slash", "Chrissie"} {
+		record := artistType{Name: name}
+		s.NoError(artist.InsertReturning(&record))
+		ids = append(ids, record.ID)
+	}
+
+	var artists []artistType
+	err := sess.SQL().
+		Select("a.*").From("artist a").
+		Join(db.ValuesTable(ids[1:3]).As("k", column)).On("k.id = a.id").
+		OrderBy("a.name").
+		All(&artists)
+	s.NoError(err)
+	s.Len(artists, 2)
+	s.Equal("Flea", artists[0].Name)
+	s.Equal("Slash", artists[1].Name)
+
+	count, err := artist.Find(db.Cond{"id IN": db.ValuesTable(ids[:1]).As("k", column)}).Count()
+	s.NoError(err)
+	s.Equal(uint64(1), count)
+}
+
+func (s *SQLTestSuite) TestSessionQueryLogger() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+	s.NoError(artist.Truncate())
+
+	var events []*db.QueryEvent
+	sess.SetQueryLogger(db.QueryLoggerFunc(func(e *db.QueryEvent) {
+		events = append(events, e)
+	}))
+	defer sess.SetQueryLogger(nil)
+
+	_, err := artist.Insert(artistType{Name: "Ozzie"})
+	s.NoError(err)
+
+	events = nil
+	_, err = sess.Exec(`UPDATE artist SET name = ? WHERE name = ?`, "Flea", "Ozzie")
+	s.NoError(err)
+
+	if s.Len(events, 1) {
+		s.NoError(events[0].Err)
+		s.Contains(events[0].Statement(), "'Flea'")
+		if s.NotNil(events[0].RowsAffected) {
+			s.Equal(int64(1), *events[0].RowsAffected)
+		}
+	}
+}
+
+func (s *SQLTestSuite) TestSnapshotRead() {
+	errSnapshotWrite := errors.New("write rejected")
+
+	switch s.Adapter() {
+	case "postgresql", "mysql", "cockroachdb":
+	default:
+		s.T().Skip("Currently not supported.")
+	}
+
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+	s.NoError(artist.Truncate())
+
+	_, err := artist.Insert(map[string]string{"name": "Ozzy"})
+	s.NoError(err)
+
+	err = sess.SnapshotRead(func(tx db.Session) error {
+		count, err := tx.Collection("artist").Find().Count()
+		if err != nil {
+			return err
+		}
+		if count != 1 {
+			return fmt.Errorf("expecting 1 row, got %d", count)
+		}
+
+		// Rows written by other sessions are not visible within the
+		// snapshot.
+		if _, err := artist.Insert(map[string]string{"name": "Flea"}); err != nil {
+			return err
+		}
+
+		count, err = tx.Collection("artist").Find().Count()
+		if err != nil {
+			return err
+		}
+		if count != 1 {
+			return fmt.Errorf("expecting 1 row, got %d", count)
+		}
+
+		// The snapshot is read-only, the failed write aborts it so fn must
+		// return an error.
+		if _, err := tx.Collection("artist").Insert(map[string]string{"name": "Slash"}); err == nil {
+			return errors.New("expecting an error writing within a snapshot")
+		}
+		return errSnapshotWrite
+	})
+	s.True(errors.Is(err, errSnapshotWrite))
+
+	count, err := artist.Find().Count()
+	s.NoError(err)
+	s.Equal(uint64(2), count)
+
+	err = sess.Tx(func(tx db.Session) error {
+		return tx.SnapshotRead(func(db.Session) error {
+			return nil
+		})
+	})
+	s.True(errors.Is(err, db.ErrAlreadyWithinTransaction))
+}
@@ -0,0 +1,82 @@
+//go:build go1.23
+// +build go1.23
+
+package testsuite
+
+import (
+	"fmt"
+)
+
+func (s *SQLTestSuite) TestResultRangeIterate() {
+	sess := s.Session()
+
+	res := sess.Collection("artist").Find().OrderBy("name")
+
+	var names []string
+	var item artistType
+	for i, err := range res.Iterate(&item) {
+		s.NoError(err)
+		s.Equal(len(names), i)
+		names = append(names, item.Name)
+	}
+	s.Equal(4, len(names))
+
+	// Breaking out of the loop closes the cursor.
+	for i, err := range res.Iterate(&item) {
+		s.NoError(err)
+		if i == 1 {
+			break
+		}
+	}
+	s.Equal(names[1], item.Name)
+
+	// Errors are yielded as the last step, the result set is left as it was.
+	res = sess.Collection("nonexistent_table").Find()
+	var errs []error
+	for _, err := range res.Iterate(&item) {
+		errs = append(errs, err)
+	}
+	s.Equal(1, len(errs))
+	s.Error(errs[0])
+	s.NoError(res.Err())
+}
+
+func (s *SQLTestSuite) TestResultRangeBatches() {
+	sess := s.Session()
+
+	artist := sess.Collection("artist")
+	s.NoError(artist.Truncate())
+
+	for i := 0; i < 5; i++ {
+		_, err := artist.Insert(artistType{Name: fmt.Sprintf("artist-%d", i)})
+		s.NoError(err)
+	}
+
+	var sizes []int
+	var batch []artistType
+	for i, err := range artist.Find().Batches(2, &batch) {
+		s.NoError(err)
+		s.Equal(len(sizes), i)
+		sizes = append(sizes, len(batch))
+	}
+	s.Equal([]int{2, 2, 1}, sizes)
+
+	// Breaking out of the loop stops reading batches.
+	sizes = nil
+	for _, err := range artist.Find().Batches(2, &batch) {
+		s.NoError(err)
+		sizes = append(sizes, len(batch))
+		break
+	}
+	s.Equal([]int{2}, sizes)
+
+	// Errors are yielded, the result set is left as it was.
+	res := artist.Find()
+	var errs []error
+	for _, err := range res.Batches(0, &batch) {
+		errs = append(errs, err)
+	}
+	s.Equal(1, len(errs))
+	s.Error(errs[0])
+	s.NoError(res.Err())
+}
@@ -0,0 +1,58 @@
+//go:build go1.18
+// +build go1.18
+
+package testsuite
+
+import (
+	db "github.com/upper/db/v4"
+)
+
+func (s *SQLTestSuite) TestTypedCollection() {
+	sess := s.Session()
+
+	artists := db.NewCollection[artistType](sess, "artist")
+	s.Equal("artist", artists.Name())
+
+	_, err := artists.Append(artistType{Name: "Typed"})
+	s.NoError(err)
+
+	count, err := artists.Count()
+	s.NoError(err)
+	s.Equal(uint64(5), count)
+
+	list, err := artists.Find().OrderBy("name").All()
+	s.NoError(err)
+	s.Equal(5, len(list))
+	s.Equal("Typed", list[len(list)-1].Name)
+
+	item, err := artists.Find(db.Cond{"name": "Typed"}).One()
+	s.NoError(err)
+	s.Equal("Typed", item.Name)
+
+	_, err = artists.Find(db.Cond{"name": "Missing"}).One()
+	s.Equal(db.ErrNoMoreRows, err)
+
+	// Pointers to structs work as well.
+	ptrs, err := db.NewCollection[*artistType](sess, "artist").Find().Limit(2).All()
+	s.NoError(err)
+	s.Equal(2, len(ptrs))
+	s.NotNil(ptrs[0])
+
+	var names []string
+	artists.Find().OrderBy("name").Iterate()(func(item artistType, err error) bool {
+		s.NoError(err)
+		names = append(names, item.Name)
+		return true
+	})
+	s.Equal(5, len(names))
+	for i := range list {
+		s.Equal(list[i].Name, names[i])
+	}
+
+	err = artists.Find(db.Cond{"name": "Typed"}).Delete()
+	s.NoError(err)
+
+	exists, err := artists.Find(db.Cond{"name": "Typed"}).Exists()
+	s.NoError(err)
+	s.False(exists)
+}
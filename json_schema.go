@@ -0,0 +1,376 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// JSONSchema returns a ValidatorFunc that checks documents against the given
+// JSON Schema.
+//
+// Only a subset of the specification is supported: type, enum, properties,
+// required, additionalProperties, items, minItems, maxItems, minLength,
+// maxLength, pattern, minimum and maximum. Other keywords are ignored.
+//
+// On updates, required properties are not enforced at the top level of the
+// document and dotted field names (like "address.city") are checked against
+// the schema of the nested property.
+func JSONSchema(schema []byte) (ValidatorFunc, error) {
+	var s jsonSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil, fmt.Errorf("upper: invalid JSON schema: %w", err)
+	}
+	if err := s.compile(); err != nil {
+		return nil, fmt.Errorf("upper: invalid JSON schema: %w", err)
+	}
+	return func(doc map[string]interface{}, partial bool) error {
+		if partial {
+			return s.validatePartial(doc)
+		}
+		return s.validate("", doc)
+	}, nil
+}
+
+type jsonSchema struct {
+	Type                 schemaTypes            `json:"type"`
+	Enum                 []interface{}          `json:"enum"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *additionalProperties  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	MinItems             *int                   `json:"minItems"`
+	MaxItems             *int                   `json:"maxItems"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	Pattern              string                 `json:"pattern"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+
+	pattern *regexp.Regexp
+}
+
+// schemaTypes holds the value of the type keyword, which can be either a
+// string or an array of strings.
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = schemaTypes(many)
+	return nil
+}
+
+// additionalProperties holds the value of the additionalProperties keyword,
+// which can be either a boolean or a schema.
+type additionalProperties struct {
+	allowed bool
+	schema  *jsonSchema
+}
+
+func (a *additionalProperties) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.allowed); err == nil {
+		return nil
+	}
+	a.allowed = true
+	return json.Unmarshal(data, &a.schema)
+}
+
+func (s *jsonSchema) compile() (err error) {
+	if s.Pattern != "" {
+		if s.pattern, err = regexp.Compile(s.Pattern); err != nil {
+			return err
+		}
+	}
+	for _, sub := range s.Properties {
+		if err := sub.compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.compile(); err != nil {
+			return err
+		}
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.schema != nil {
+		if err := s.AdditionalProperties.schema.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *jsonSchema) validatePartial(doc map[string]interface{}) error {
+	for k, v := range doc {
+		sub, err := s.lookup(k)
+		if err != nil {
+			return err
+		}
+		if sub == nil {
+			continue
+		}
+		if err := sub.validate(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookup returns the schema of the field with the given dotted name, or nil if
+// the field is not described by the schema.
+func (s *jsonSchema) lookup(name string) (*jsonSchema, error) {
+	cur := s
+	for _, part := range strings.Split(name, ".") {
+		if _, err := strconv.Atoi(part); err == nil && cur.Items != nil {
+			cur = cur.Items
+			continue
+		}
+		sub, ok := cur.Properties[part]
+		if ok {
+			cur = sub
+			continue
+		}
+		if ap := cur.AdditionalProperties; ap != nil {
+			if !ap.allowed {
+				return nil, fmt.Errorf("%s: unknown field", name)
+			}
+			if ap.schema != nil {
+				cur = ap.schema
+				continue
+			}
+		}
+		return nil, nil
+	}
+	return cur, nil
+}
+
+func (s *jsonSchema) validate(path string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			rv = reflect.Value{}
+			break
+		}
+		rv = rv.Elem()
+	}
+
+	if len(s.Type) > 0 {
+		matched := false
+		for _, t := range s.Type {
+			if matchesType(t, rv) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: expecting %s", pathName(path), strings.Join(s.Type, " or "))
+		}
+	}
+
+	if len(s.Enum) > 0 {
+		matched := false
+		for _, e := range s.Enum {
+			if equalValues(e, rv) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: value is not one of the allowed values", pathName(path))
+		}
+	}
+
+	if !rv.IsValid() {
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return s.validateString(path, rv.String())
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		return s.validateArray(path, rv)
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		return s.validateObject(path, rv)
+	}
+
+	if f, ok := toFloat(rv); ok {
+		if s.Minimum != nil && f < *s.Minimum {
+			return fmt.Errorf("%s: must be greater than or equal to %v", pathName(path), *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return fmt.Errorf("%s: must be less than or equal to %v", pathName(path), *s.Maximum)
+		}
+	}
+
+	return nil
+}
+
+func (s *jsonSchema) validateString(path string, str string) error {
+	n := utf8.RuneCountInString(str)
+	if s.MinLength != nil && n < *s.MinLength {
+		return fmt.Errorf("%s: must be at least %d characters long", pathName(path), *s.MinLength)
+	}
+	if s.MaxLength != nil && n > *s.MaxLength {
+		return fmt.Errorf("%s: must be at most %d characters long", pathName(path), *s.MaxLength)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		return fmt.Errorf("%s: does not match %q", pathName(path), s.Pattern)
+	}
+	return nil
+}
+
+func (s *jsonSchema) validateArray(path string, rv reflect.Value) error {
+	n := rv.Len()
+	if s.MinItems != nil && n < *s.MinItems {
+		return fmt.Errorf("%s: must have at least %d items", pathName(path), *s.MinItems)
+	}
+	if s.MaxItems != nil && n > *s.MaxItems {
+		return fmt.Errorf("%s: must have at most %d items", pathName(path), *s.MaxItems)
+	}
+	if s.Items != nil {
+		for i := 0; i < n; i++ {
+			if err := s.Items.validate(joinPath(path, strconv.Itoa(i)), rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *jsonSchema) validateObject(path string, rv reflect.Value) error {
+	for _, name := range s.Required {
+		if !rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key())).IsValid() {
+			return fmt.Errorf("%s: missing required field", joinPath(path, name))
+		}
+	}
+
+	iter := rv.MapRange()
+	for iter.Next() {
+		name := iter.Key().String()
+		value := iter.Value().Interface()
+
+		sub, ok := s.Properties[name]
+		if !ok && s.AdditionalProperties != nil {
+			if !s.AdditionalProperties.allowed {
+				return fmt.Errorf("%s: unknown field", joinPath(path, name))
+			}
+			sub = s.AdditionalProperties.schema
+		}
+		if sub == nil {
+			continue
+		}
+		if err := sub.validate(joinPath(path, name), value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func matchesType(t string, rv reflect.Value) bool {
+	if !rv.IsValid() {
+		return t == "null"
+	}
+	switch t {
+	case "boolean":
+		return rv.Kind() == reflect.Bool
+	case "string":
+		return rv.Kind() == reflect.String
+	case "number":
+		_, ok := toFloat(rv)
+		return ok
+	case "integer":
+		f, ok := toFloat(rv)
+		return ok && f == math.Trunc(f)
+	case "array":
+		return (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && rv.Type().Elem().Kind() != reflect.Uint8
+	case "object":
+		return rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String
+	}
+	return false
+}
+
+func toFloat(rv reflect.Value) (float64, bool) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// equalValues compares a value decoded from the schema with a document value.
+func equalValues(expected interface{}, rv reflect.Value) bool {
+	if !rv.IsValid() {
+		return expected == nil
+	}
+	if f, ok := expected.(float64); ok {
+		g, ok := toFloat(rv)
+		return ok && f == g
+	}
+	if rv.Kind() == reflect.String {
+		s, ok := expected.(string)
+		return ok && s == rv.String()
+	}
+	if rv.Kind() == reflect.Bool {
+		b, ok := expected.(bool)
+		return ok && b == rv.Bool()
+	}
+	return reflect.DeepEqual(expected, rv.Interface())
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func pathName(path string) string {
+	if path == "" {
+		return "document"
+	}
+	return path
+}
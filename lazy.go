@@ -0,0 +1,421 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// ConnectionURLFunc is a ConnectionURL that is built when it's needed, it
+// allows OpenLazy to be called before the connection settings are known.
+type ConnectionURLFunc func() ConnectionURL
+
+func (f ConnectionURLFunc) String() string {
+	return f().String()
+}
+
+// lazySession is a Session that connects to the database the first time it's
+// needed.
+type lazySession struct {
+	settings ConnectionURL
+	open     func() (Session, error)
+	ctx      context.Context
+
+	mu   sync.Mutex
+	sess Session
+
+	// Calls that configure the session, they're replayed on every new
+	// underlying session.
+	setup       []func(Session)
+	local       Settings
+	boolMapping *BoolMapping
+}
+
+// OpenLazy returns a session that does not connect to the database until it's
+// first used, so it can be created at package initialization. If settings is
+// a ConnectionURLFunc it's called right before connecting:
+//
+//	var sess = db.OpenLazy("postgresql", db.ConnectionURLFunc(func() db.ConnectionURL {
+//		return postgresql.ConnectionURL{Password: os.Getenv("DB_PASSWORD"), ...}
+//	}))
+//
+// The session is safe for concurrent use. Failed connection attempts are not
+// remembered, the next call tries again. Methods that can return an error
+// return the connection error. The collections, result sets, statements and
+// sessions returned by Collection, SQL and WithContext connect when they're
+// used and return the connection error the same way. Driver returns nil if
+// the connection can't be established.
+func OpenLazy(adapterName string, settings ConnectionURL) Session {
+	return &lazySession{
+		settings: settings,
+		open: func() (Session, error) {
+			if f, ok := settings.(ConnectionURLFunc); ok {
+				return Open(adapterName, f())
+			}
+			return Open(adapterName, settings)
+		},
+		local: NewSettings(),
+		ctx:   context.Background(),
+	}
+}
+
+// session returns the underlying session, connecting to the database if
+// necessary.
+func (s *lazySession) session() (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sess != nil {
+		return s.sess, nil
+	}
+
+	sess, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	for _, fn := range s.setup {
+		fn(sess)
+	}
+	s.sess = sess
+
+	return sess, nil
+}
+
+// connected returns the underlying session, or nil if it was not established
+// yet.
+func (s *lazySession) connected() Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sess
+}
+
+// apply calls fn on the underlying session, if it was established, and
+// records it to be called on the sessions established later.
+func (s *lazySession) apply(fn func(Session)) {
+	s.mu.Lock()
+	s.setup = append(s.setup, fn)
+	sess := s.sess
+	s.mu.Unlock()
+
+	if sess != nil {
+		fn(sess)
+	}
+}
+
+func (s *lazySession) ConnectionURL() ConnectionURL {
+	if sess := s.connected(); sess != nil {
+		return sess.ConnectionURL()
+	}
+	return s.settings
+}
+
+func (s *lazySession) Name() string {
+	sess, err := s.session()
+	if err != nil {
+		return ""
+	}
+	return sess.Name()
+}
+
+func (s *lazySession) Ping() error {
+	sess, err := s.session()
+	if err != nil {
+		return err
+	}
+	return sess.Ping()
+}
+
+func (s *lazySession) Collection(name string) Collection {
+	return &lazyCollection{sess: s, name: name}
+}
+
+func (s *lazySession) Collections() ([]Collection, error) {
+	sess, err := s.session()
+	if err != nil {
+		return nil, err
+	}
+	return sess.Collections()
+}
+
+func (s *lazySession) Save(record Record) error {
+	sess, err := s.session()
+	if err != nil {
+		return err
+	}
+	return sess.Save(record)
+}
+
+func (s *lazySession) Get(record Record, cond interface{}) error {
+	sess, err := s.session()
+	if err != nil {
+		return err
+	}
+	return sess.Get(record, cond)
+}
+
+func (s *lazySession) Delete(record Record) error {
+	sess, err := s.session()
+	if err != nil {
+		return err
+	}
+	return sess.Delete(record)
+}
+
+func (s *lazySession) Reset() {
+	if sess := s.connected(); sess != nil {
+		sess.Reset()
+	}
+}
+
+// Close closes the underlying session, if it was established. The next call
+// that needs a connection establishes a new one.
+func (s *lazySession) Close() error {
+	s.mu.Lock()
+	sess := s.sess
+	s.sess = nil
+	s.mu.Unlock()
+
+	if sess == nil {
+		return nil
+	}
+	return sess.Close()
+}
+
+func (s *lazySession) Driver() interface{} {
+	sess, err := s.session()
+	if err != nil {
+		return nil
+	}
+	return sess.Driver()
+}
+
+func (s *lazySession) SQL() SQL {
+	return &lazySQL{sess: s}
+}
+
+func (s *lazySession) Query(dst interface{}, query interface{}, args ...interface{}) error {
+	sess, err := s.session()
+	if err != nil {
+		return err
+	}
+	return sess.Query(dst, query, args...)
+}
+
+func (s *lazySession) Exec(query interface{}, args ...interface{}) (sql.Result, error) {
+	sess, err := s.session()
+	if err != nil {
+		return nil, err
+	}
+	return sess.Exec(query, args...)
+}
+
+func (s *lazySession) Tx(fn func(sess Session) error) error {
+	sess, err := s.session()
+	if err != nil {
+		return err
+	}
+	return sess.Tx(fn)
+}
+
+func (s *lazySession) TxContext(ctx context.Context, fn func(sess Session) error, opts *sql.TxOptions) error {
+	sess, err := s.session()
+	if err != nil {
+		return err
+	}
+	return sess.TxContext(ctx, fn, opts)
+}
+
+func (s *lazySession) SnapshotRead(fn func(sess Session) error) error {
+	sess, err := s.session()
+	if err != nil {
+		return err
+	}
+	return sess.SnapshotRead(fn)
+}
+
+func (s *lazySession) Conn(fn func(sess Session) error) error {
+	sess, err := s.session()
+	if err != nil {
+		return err
+	}
+	return sess.Conn(fn)
+}
+
+func (s *lazySession) ConnContext(ctx context.Context, fn func(sess Session) error) error {
+	sess, err := s.session()
+	if err != nil {
+		return err
+	}
+	return sess.ConnContext(ctx, fn)
+}
+
+func (s *lazySession) Context() context.Context {
+	if sess := s.connected(); sess != nil {
+		return sess.Context()
+	}
+	return s.ctx
+}
+
+// WithContext returns a lazy session that is a copy of s bound to ctx once s
+// is established.
+func (s *lazySession) WithContext(ctx context.Context) Session {
+	return &lazySession{
+		settings: s.settings,
+		open: func() (Session, error) {
+			sess, err := s.session()
+			if err != nil {
+				return nil, err
+			}
+			return sess.WithContext(ctx), nil
+		},
+		local: NewSettings(),
+		ctx:   ctx,
+	}
+}
+
+func (s *lazySession) Use(middleware ...Middleware) {
+	s.apply(func(sess Session) {
+		sess.Use(middleware...)
+	})
+}
+
+func (s *lazySession) SetBoolMapping(mapping *BoolMapping) {
+	s.mu.Lock()
+	s.boolMapping = mapping
+	s.mu.Unlock()
+
+	s.apply(func(sess Session) {
+		sess.SetBoolMapping(mapping)
+	})
+}
+
+func (s *lazySession) BoolMapping() *BoolMapping {
+	if sess := s.connected(); sess != nil {
+		return sess.BoolMapping()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.boolMapping
+}
+
+// currentSettings returns the settings of the underlying session, or the ones
+// recorded so far if it was not established yet.
+func (s *lazySession) currentSettings() Settings {
+	if sess := s.connected(); sess != nil {
+		return sess
+	}
+	return s.local
+}
+
+func (s *lazySession) SetPreparedStatementCache(value bool) {
+	s.local.SetPreparedStatementCache(value)
+	s.apply(func(sess Session) {
+		sess.SetPreparedStatementCache(value)
+	})
+}
+
+func (s *lazySession) PreparedStatementCacheEnabled() bool {
+	return s.currentSettings().PreparedStatementCacheEnabled()
+}
+
+func (s *lazySession) SetQueryIDComments(value bool) {
+	s.local.SetQueryIDComments(value)
+	s.apply(func(sess Session) {
+		sess.SetQueryIDComments(value)
+	})
+}
+
+func (s *lazySession) QueryIDCommentsEnabled() bool {
+	return s.currentSettings().QueryIDCommentsEnabled()
+}
+
+func (s *lazySession) SetConnMaxLifetime(t time.Duration) {
+	s.local.SetConnMaxLifetime(t)
+	s.apply(func(sess Session) {
+		sess.SetConnMaxLifetime(t)
+	})
+}
+
+func (s *lazySession) ConnMaxLifetime() time.Duration {
+	return s.currentSettings().ConnMaxLifetime()
+}
+
+func (s *lazySession) SetMaxIdleConns(n int) {
+	s.local.SetMaxIdleConns(n)
+	s.apply(func(sess Session) {
+		sess.SetMaxIdleConns(n)
+	})
+}
+
+func (s *lazySession) MaxIdleConns() int {
+	return s.currentSettings().MaxIdleConns()
+}
+
+func (s *lazySession) SetMaxOpenConns(n int) {
+	s.local.SetMaxOpenConns(n)
+	s.apply(func(sess Session) {
+		sess.SetMaxOpenConns(n)
+	})
+}
+
+func (s *lazySession) MaxOpenConns() int {
+	return s.currentSettings().MaxOpenConns()
+}
+
+func (s *lazySession) SetMaxTransactionRetries(n int) {
+	s.local.SetMaxTransactionRetries(n)
+	s.apply(func(sess Session) {
+		sess.SetMaxTransactionRetries(n)
+	})
+}
+
+func (s *lazySession) MaxTransactionRetries() int {
+	return s.currentSettings().MaxTransactionRetries()
+}
+
+func (s *lazySession) SetQueryLogger(logger QueryLogger) {
+	s.local.SetQueryLogger(logger)
+	s.apply(func(sess Session) {
+		sess.SetQueryLogger(logger)
+	})
+}
+
+func (s *lazySession) QueryLogger() QueryLogger {
+	return s.currentSettings().QueryLogger()
+}
+
+func (s *lazySession) SetSlowQueryThreshold(d time.Duration) {
+	s.local.SetSlowQueryThreshold(d)
+	s.apply(func(sess Session) {
+		sess.SetSlowQueryThreshold(d)
+	})
+}
+
+func (s *lazySession) SlowQueryThreshold() time.Duration {
+	return s.currentSettings().SlowQueryThreshold()
+}
+
+var _ = Session(&lazySession{})
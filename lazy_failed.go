@@ -0,0 +1,651 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"io"
+)
+
+// lazyCollection is a collection of a lazy session. Each call establishes the
+// session first and returns the connection error if that fails.
+type lazyCollection struct {
+	sess *lazySession
+	name string
+
+	// ttlItem is the item given to WithTTL, if any.
+	ttlItem interface{}
+}
+
+func (c *lazyCollection) collection() (Collection, error) {
+	sess, err := c.sess.session()
+	if err != nil {
+		return nil, err
+	}
+	col := sess.Collection(c.name)
+	if c.ttlItem != nil {
+		return WithTTL(col, c.ttlItem), nil
+	}
+	return col, nil
+}
+
+func (c *lazyCollection) WithTTL(item interface{}) Collection {
+	return &lazyCollection{sess: c.sess, name: c.name, ttlItem: item}
+}
+
+func (c *lazyCollection) PurgeExpired(column string) error {
+	col, err := c.collection()
+	if err != nil {
+		return err
+	}
+	return PurgeExpired(col, column)
+}
+
+func (c *lazyCollection) Name() string {
+	return c.name
+}
+
+func (c *lazyCollection) Session() Session {
+	return c.sess
+}
+
+func (c *lazyCollection) Find(conds ...interface{}) Result {
+	col, err := c.collection()
+	if err != nil {
+		return &failedResult{err: err}
+	}
+	return col.Find(conds...)
+}
+
+func (c *lazyCollection) Count() (uint64, error) {
+	col, err := c.collection()
+	if err != nil {
+		return 0, err
+	}
+	return col.Count()
+}
+
+func (c *lazyCollection) Insert(item interface{}) (*InsertResult, error) {
+	col, err := c.collection()
+	if err != nil {
+		return nil, err
+	}
+	return col.Insert(item)
+}
+
+func (c *lazyCollection) InsertMany(items interface{}, batchSize int) ([]*InsertResult, error) {
+	col, err := c.collection()
+	if err != nil {
+		return nil, err
+	}
+	return col.InsertMany(items, batchSize)
+}
+
+func (c *lazyCollection) Upsert(item interface{}, conflictColumns ...string) (*InsertResult, error) {
+	col, err := c.collection()
+	if err != nil {
+		return nil, err
+	}
+	return col.Upsert(item, conflictColumns...)
+}
+
+func (c *lazyCollection) InsertOrIgnore(item interface{}) (*InsertResult, error) {
+	col, err := c.collection()
+	if err != nil {
+		return nil, err
+	}
+	return col.InsertOrIgnore(item)
+}
+
+func (c *lazyCollection) InsertReturning(item interface{}) error {
+	col, err := c.collection()
+	if err != nil {
+		return err
+	}
+	return col.InsertReturning(item)
+}
+
+func (c *lazyCollection) UpdateReturning(item interface{}) error {
+	col, err := c.collection()
+	if err != nil {
+		return err
+	}
+	return col.UpdateReturning(item)
+}
+
+func (c *lazyCollection) CompileInsert(item interface{}) (string, []interface{}, error) {
+	col, err := c.collection()
+	if err != nil {
+		return "", nil, err
+	}
+	return col.CompileInsert(item)
+}
+
+func (c *lazyCollection) FindDescendants(rootID interface{}, parentColumn string) ([]*Node, error) {
+	col, err := c.collection()
+	if err != nil {
+		return nil, err
+	}
+	return col.FindDescendants(rootID, parentColumn)
+}
+
+func (c *lazyCollection) FindAncestors(id interface{}, parentColumn string) ([]*Node, error) {
+	col, err := c.collection()
+	if err != nil {
+		return nil, err
+	}
+	return col.FindAncestors(id, parentColumn)
+}
+
+func (c *lazyCollection) ExistingIDs(ids []interface{}) (map[interface{}]bool, error) {
+	col, err := c.collection()
+	if err != nil {
+		return nil, err
+	}
+	return col.ExistingIDs(ids)
+}
+
+func (c *lazyCollection) Stats() (*CollectionStats, error) {
+	col, err := c.collection()
+	if err != nil {
+		return nil, err
+	}
+	return col.Stats()
+}
+
+func (c *lazyCollection) Analyze() error {
+	col, err := c.collection()
+	if err != nil {
+		return err
+	}
+	return col.Analyze()
+}
+
+func (c *lazyCollection) Vacuum() error {
+	col, err := c.collection()
+	if err != nil {
+		return err
+	}
+	return col.Vacuum()
+}
+
+func (c *lazyCollection) Optimize() error {
+	col, err := c.collection()
+	if err != nil {
+		return err
+	}
+	return col.Optimize()
+}
+
+func (c *lazyCollection) Triggers() ([]*Trigger, error) {
+	col, err := c.collection()
+	if err != nil {
+		return nil, err
+	}
+	return col.Triggers()
+}
+
+func (c *lazyCollection) CreateTrigger(trigger *Trigger) error {
+	col, err := c.collection()
+	if err != nil {
+		return err
+	}
+	return col.CreateTrigger(trigger)
+}
+
+func (c *lazyCollection) DropTrigger(name string) error {
+	col, err := c.collection()
+	if err != nil {
+		return err
+	}
+	return col.DropTrigger(name)
+}
+
+func (c *lazyCollection) Exists() (bool, error) {
+	col, err := c.collection()
+	if err != nil {
+		return false, err
+	}
+	return col.Exists()
+}
+
+func (c *lazyCollection) Truncate() error {
+	col, err := c.collection()
+	if err != nil {
+		return err
+	}
+	return col.Truncate()
+}
+
+// lazySQL is the SQL builder of a lazy session. Each call establishes the
+// session first, if that fails the statements it builds return the
+// connection error when they're executed.
+type lazySQL struct {
+	sess *lazySession
+}
+
+func (s *lazySQL) sql() (SQL, error) {
+	sess, err := s.sess.session()
+	if err != nil {
+		return nil, err
+	}
+	return sess.SQL(), nil
+}
+
+func (s *lazySQL) Select(columns ...interface{}) Selector {
+	b, err := s.sql()
+	if err != nil {
+		return &failedSelector{failedQuery{err}}
+	}
+	return b.Select(columns...)
+}
+
+func (s *lazySQL) SelectFrom(table ...interface{}) Selector {
+	b, err := s.sql()
+	if err != nil {
+		return &failedSelector{failedQuery{err}}
+	}
+	return b.SelectFrom(table...)
+}
+
+func (s *lazySQL) InsertInto(table string) Inserter {
+	b, err := s.sql()
+	if err != nil {
+		return &failedInserter{failedQuery{err}}
+	}
+	return b.InsertInto(table)
+}
+
+func (s *lazySQL) DeleteFrom(table string) Deleter {
+	b, err := s.sql()
+	if err != nil {
+		return &failedDeleter{failedQuery{err}}
+	}
+	return b.DeleteFrom(table)
+}
+
+func (s *lazySQL) Update(table string) Updater {
+	b, err := s.sql()
+	if err != nil {
+		return &failedUpdater{failedQuery{err}}
+	}
+	return b.Update(table)
+}
+
+func (s *lazySQL) Exec(query interface{}, args ...interface{}) (sql.Result, error) {
+	return s.ExecContext(s.sess.Context(), query, args...)
+}
+
+func (s *lazySQL) ExecContext(ctx context.Context, query interface{}, args ...interface{}) (sql.Result, error) {
+	b, err := s.sql()
+	if err != nil {
+		return nil, err
+	}
+	return b.ExecContext(ctx, query, args...)
+}
+
+func (s *lazySQL) Prepare(query interface{}) (*sql.Stmt, error) {
+	return s.PrepareContext(s.sess.Context(), query)
+}
+
+func (s *lazySQL) PrepareContext(ctx context.Context, query interface{}) (*sql.Stmt, error) {
+	b, err := s.sql()
+	if err != nil {
+		return nil, err
+	}
+	return b.PrepareContext(ctx, query)
+}
+
+func (s *lazySQL) Query(query interface{}, args ...interface{}) (*sql.Rows, error) {
+	return s.QueryContext(s.sess.Context(), query, args...)
+}
+
+func (s *lazySQL) QueryContext(ctx context.Context, query interface{}, args ...interface{}) (*sql.Rows, error) {
+	b, err := s.sql()
+	if err != nil {
+		return nil, err
+	}
+	return b.QueryContext(ctx, query, args...)
+}
+
+func (s *lazySQL) QueryRow(query interface{}, args ...interface{}) (*sql.Row, error) {
+	return s.QueryRowContext(s.sess.Context(), query, args...)
+}
+
+func (s *lazySQL) QueryRowContext(ctx context.Context, query interface{}, args ...interface{}) (*sql.Row, error) {
+	b, err := s.sql()
+	if err != nil {
+		return nil, err
+	}
+	return b.QueryRowContext(ctx, query, args...)
+}
+
+func (s *lazySQL) Iterator(query interface{}, args ...interface{}) Iterator {
+	return s.IteratorContext(s.sess.Context(), query, args...)
+}
+
+func (s *lazySQL) IteratorContext(ctx context.Context, query interface{}, args ...interface{}) Iterator {
+	b, err := s.sql()
+	if err != nil {
+		return &failedIterator{failedQuery{err}}
+	}
+	return b.IteratorContext(ctx, query, args...)
+}
+
+func (s *lazySQL) Call(procedure string, args ...interface{}) Iterator {
+	return s.CallContext(s.sess.Context(), procedure, args...)
+}
+
+func (s *lazySQL) CallContext(ctx context.Context, procedure string, args ...interface{}) Iterator {
+	b, err := s.sql()
+	if err != nil {
+		return &failedIterator{failedQuery{err}}
+	}
+	return b.CallContext(ctx, procedure, args...)
+}
+
+func (s *lazySQL) NewIterator(rows *sql.Rows) Iterator {
+	return s.NewIteratorContext(s.sess.Context(), rows)
+}
+
+func (s *lazySQL) NewIteratorContext(ctx context.Context, rows *sql.Rows) Iterator {
+	b, err := s.sql()
+	if err != nil {
+		return &failedIterator{failedQuery{err}}
+	}
+	return b.NewIteratorContext(ctx, rows)
+}
+
+// failedQuery holds the methods shared by the statements and iterators of a
+// lazy session that could not connect, all of them return the connection
+// error.
+type failedQuery struct {
+	err error
+}
+
+func (q *failedQuery) String() string {
+	return ""
+}
+
+func (q *failedQuery) Arguments() []interface{} {
+	return nil
+}
+
+func (q *failedQuery) Err() error {
+	return q.err
+}
+
+func (q *failedQuery) Exec() (sql.Result, error) {
+	return nil, q.err
+}
+
+func (q *failedQuery) ExecContext(context.Context) (sql.Result, error) {
+	return nil, q.err
+}
+
+func (q *failedQuery) Prepare() (*sql.Stmt, error) {
+	return nil, q.err
+}
+
+func (q *failedQuery) PrepareContext(context.Context) (*sql.Stmt, error) {
+	return nil, q.err
+}
+
+func (q *failedQuery) Query() (*sql.Rows, error) {
+	return nil, q.err
+}
+
+func (q *failedQuery) QueryContext(context.Context) (*sql.Rows, error) {
+	return nil, q.err
+}
+
+func (q *failedQuery) QueryRow() (*sql.Row, error) {
+	return nil, q.err
+}
+
+func (q *failedQuery) QueryRowContext(context.Context) (*sql.Row, error) {
+	return nil, q.err
+}
+
+func (q *failedQuery) All(interface{}) error {
+	return q.err
+}
+
+func (q *failedQuery) AllColumns(interface{}) error {
+	return q.err
+}
+
+func (q *failedQuery) One(interface{}) error {
+	return q.err
+}
+
+func (q *failedQuery) Iterator() Iterator {
+	return &failedIterator{*q}
+}
+
+func (q *failedQuery) IteratorContext(context.Context) Iterator {
+	return &failedIterator{*q}
+}
+
+type failedIterator struct {
+	failedQuery
+}
+
+func (it *failedIterator) Scan(...interface{}) error {
+	return it.err
+}
+
+func (it *failedIterator) NextScan(...interface{}) error {
+	return it.err
+}
+
+func (it *failedIterator) ScanOne(...interface{}) error {
+	return it.err
+}
+
+func (it *failedIterator) Next(...interface{}) bool {
+	return false
+}
+
+func (it *failedIterator) Close() error {
+	return nil
+}
+
+type failedSelector struct {
+	failedQuery
+}
+
+func (s *failedSelector) Columns(...interface{}) Selector                       { return s }
+func (s *failedSelector) From(...interface{}) Selector                          { return s }
+func (s *failedSelector) With(string, interface{}) Selector                     { return s }
+func (s *failedSelector) WithRecursive(string, interface{}) Selector            { return s }
+func (s *failedSelector) Distinct(...interface{}) Selector                      { return s }
+func (s *failedSelector) As(string) Selector                                    { return s }
+func (s *failedSelector) Where(...interface{}) Selector                         { return s }
+func (s *failedSelector) And(...interface{}) Selector                           { return s }
+func (s *failedSelector) GroupBy(...interface{}) Selector                       { return s }
+func (s *failedSelector) Having(...interface{}) Selector                        { return s }
+func (s *failedSelector) OrderBy(...interface{}) Selector                       { return s }
+func (s *failedSelector) Join(...interface{}) Selector                          { return s }
+func (s *failedSelector) InnerJoin(...interface{}) Selector                     { return s }
+func (s *failedSelector) FullJoin(...interface{}) Selector                      { return s }
+func (s *failedSelector) CrossJoin(...interface{}) Selector                     { return s }
+func (s *failedSelector) RightJoin(...interface{}) Selector                     { return s }
+func (s *failedSelector) LeftJoin(...interface{}) Selector                      { return s }
+func (s *failedSelector) Using(...interface{}) Selector                         { return s }
+func (s *failedSelector) On(...interface{}) Selector                            { return s }
+func (s *failedSelector) Limit(int) Selector                                    { return s }
+func (s *failedSelector) Offset(int) Selector                                   { return s }
+func (s *failedSelector) ForUpdate(...LockOption) Selector                      { return s }
+func (s *failedSelector) ForShare(...LockOption) Selector                       { return s }
+func (s *failedSelector) Amend(func(queryIn string) (queryOut string)) Selector { return s }
+
+func (s *failedSelector) Paginate(uint) Paginator {
+	return &failedPaginator{s.failedQuery}
+}
+
+type failedPaginator struct {
+	failedQuery
+}
+
+func (p *failedPaginator) Page(uint) Paginator            { return p }
+func (p *failedPaginator) Cursor(string) Paginator        { return p }
+func (p *failedPaginator) NextPage(interface{}) Paginator { return p }
+func (p *failedPaginator) PrevPage(interface{}) Paginator { return p }
+func (p *failedPaginator) TotalPages() (uint, error)      { return 0, p.err }
+func (p *failedPaginator) TotalEntries() (uint64, error)  { return 0, p.err }
+
+type failedInserter struct {
+	failedQuery
+}
+
+func (i *failedInserter) Columns(...string) Inserter                            { return i }
+func (i *failedInserter) Values(...interface{}) Inserter                        { return i }
+func (i *failedInserter) Returning(...string) Inserter                          { return i }
+func (i *failedInserter) OnConflict(...string) Inserter                         { return i }
+func (i *failedInserter) DoUpdate(...string) Inserter                           { return i }
+func (i *failedInserter) Ignore() Inserter                                      { return i }
+func (i *failedInserter) Amend(func(queryIn string) (queryOut string)) Inserter { return i }
+
+func (i *failedInserter) Batch(int) BatchInserter {
+	return &failedBatchInserter{i.failedQuery}
+}
+
+type failedBatchInserter struct {
+	failedQuery
+}
+
+func (b *failedBatchInserter) Values(...interface{}) BatchInserter { return b }
+func (b *failedBatchInserter) NextResult(interface{}) bool         { return false }
+func (b *failedBatchInserter) Done()                               {}
+func (b *failedBatchInserter) Wait() error                         { return b.err }
+
+type failedDeleter struct {
+	failedQuery
+}
+
+func (d *failedDeleter) Where(...interface{}) Deleter                         { return d }
+func (d *failedDeleter) And(...interface{}) Deleter                           { return d }
+func (d *failedDeleter) Limit(int) Deleter                                    { return d }
+func (d *failedDeleter) Returning(...string) Deleter                          { return d }
+func (d *failedDeleter) Amend(func(queryIn string) (queryOut string)) Deleter { return d }
+
+type failedUpdater struct {
+	failedQuery
+}
+
+func (u *failedUpdater) Set(...interface{}) Updater                           { return u }
+func (u *failedUpdater) Where(...interface{}) Updater                         { return u }
+func (u *failedUpdater) And(...interface{}) Updater                           { return u }
+func (u *failedUpdater) Limit(int) Updater                                    { return u }
+func (u *failedUpdater) Returning(...string) Updater                          { return u }
+func (u *failedUpdater) Amend(func(queryIn string) (queryOut string)) Updater { return u }
+
+// failedResult is the result set of a lazy session that could not connect,
+// every method that can return an error returns the connection error.
+type failedResult struct {
+	err error
+}
+
+func (r *failedResult) String() string                          { return "" }
+func (r *failedResult) Compile() (string, []interface{}, error) { return "", nil, r.err }
+func (r *failedResult) CompileUpdate(interface{}) (string, []interface{}, error) {
+	return "", nil, r.err
+}
+func (r *failedResult) UpdateReturning(interface{}, interface{}) error {
+	return r.err
+}
+func (r *failedResult) Batches(uint, interface{}) func(yield func(int, error) bool) {
+	return func(yield func(int, error) bool) {
+		yield(0, r.err)
+	}
+}
+func (r *failedResult) CompileDelete() (string, []interface{}, error) { return "", nil, r.err }
+func (r *failedResult) Limit(int) Result                              { return r }
+func (r *failedResult) Offset(int) Result                             { return r }
+func (r *failedResult) ForUpdate(...LockOption) Result                { return r }
+func (r *failedResult) ForShare(...LockOption) Result                 { return r }
+func (r *failedResult) ReadPreference(ReadPreference) Result          { return r }
+func (r *failedResult) OrderBy(...interface{}) Result                 { return r }
+func (r *failedResult) Select(...interface{}) Result                  { return r }
+func (r *failedResult) And(...interface{}) Result                     { return r }
+func (r *failedResult) GroupBy(...interface{}) Result                 { return r }
+func (r *failedResult) Having(...interface{}) Result                  { return r }
+func (r *failedResult) Join(...interface{}) Result                    { return r }
+func (r *failedResult) InnerJoin(...interface{}) Result               { return r }
+func (r *failedResult) LeftJoin(...interface{}) Result                { return r }
+func (r *failedResult) RightJoin(...interface{}) Result               { return r }
+func (r *failedResult) FullJoin(...interface{}) Result                { return r }
+func (r *failedResult) On(...interface{}) Result                      { return r }
+func (r *failedResult) Using(...interface{}) Result                   { return r }
+func (r *failedResult) Paginate(uint) Result                          { return r }
+func (r *failedResult) Page(uint) Result                              { return r }
+func (r *failedResult) Cursor(string) Result                          { return r }
+func (r *failedResult) NextPage(interface{}) Result                   { return r }
+func (r *failedResult) PrevPage(interface{}) Result                   { return r }
+func (r *failedResult) Delete() error                                 { return r.err }
+func (r *failedResult) Update(interface{}) error                      { return r.err }
+func (r *failedResult) DeleteCount() (uint64, error)                  { return 0, r.err }
+func (r *failedResult) UpdateCount(interface{}) (uint64, error)       { return 0, r.err }
+func (r *failedResult) DeleteReturning(interface{}) error             { return r.err }
+func (r *failedResult) Modify(...*Modifier) error                     { return r.err }
+func (r *failedResult) Into(string) (Collection, error)               { return nil, r.err }
+func (r *failedResult) Count() (uint64, error)                        { return 0, r.err }
+func (r *failedResult) Exists() (bool, error)                         { return false, r.err }
+func (r *failedResult) First(interface{}) error                       { return r.err }
+func (r *failedResult) Last(interface{}, string) error                { return r.err }
+func (r *failedResult) Sum(string) (float64, error)                   { return 0, r.err }
+func (r *failedResult) Avg(string) (float64, error)                   { return 0, r.err }
+func (r *failedResult) Min(string, interface{}) error                 { return r.err }
+func (r *failedResult) Max(string, interface{}) error                 { return r.err }
+func (r *failedResult) CountDistinct(string) (uint64, error)          { return 0, r.err }
+func (r *failedResult) Next(interface{}) bool                         { return false }
+func (r *failedResult) NextContext(context.Context, interface{}) bool { return false }
+func (r *failedResult) Err() error                                    { return r.err }
+func (r *failedResult) One(interface{}) error                         { return r.err }
+func (r *failedResult) All(interface{}) error                         { return r.err }
+func (r *failedResult) Scanner(string) (io.ReadCloser, error)         { return nil, r.err }
+func (r *failedResult) AllColumns(interface{}) error                  { return r.err }
+func (r *failedResult) TotalPages() (uint, error)                     { return 0, r.err }
+func (r *failedResult) TotalEntries() (uint64, error)                 { return 0, r.err }
+func (r *failedResult) Close() error                                  { return nil }
+
+func (r *failedResult) Iterate(interface{}) func(yield func(int, error) bool) {
+	return func(yield func(int, error) bool) {
+		yield(0, r.err)
+	}
+}
+
+var (
+	_ = Collection(&lazyCollection{})
+	_ = ttlCollection(&lazyCollection{})
+	_ = SQL(&lazySQL{})
+	_ = Result(&failedResult{})
+	_ = Selector(&failedSelector{})
+	_ = Inserter(&failedInserter{})
+	_ = Deleter(&failedDeleter{})
+	_ = Updater(&failedUpdater{})
+	_ = Paginator(&failedPaginator{})
+	_ = Iterator(&failedIterator{})
+	_ = BatchInserter(&failedBatchInserter{})
+)
@@ -0,0 +1,108 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// MaintenanceTask is a set of maintenance operations that can be run on a
+// collection, see Maintain.
+type MaintenanceTask uint
+
+// Maintenance tasks, they can be combined with the | operator.
+const (
+	// MaintenanceAnalyze runs Collection.Analyze.
+	MaintenanceAnalyze MaintenanceTask = 1 << iota
+
+	// MaintenanceVacuum runs Collection.Vacuum.
+	MaintenanceVacuum
+
+	// MaintenanceOptimize runs Collection.Optimize.
+	MaintenanceOptimize
+)
+
+// Maintain runs the given maintenance tasks on the named collections of the
+// session, or on all of them if no name is given. Tasks that are not
+// supported by the database are skipped. Maintain tries every task on every
+// collection and returns the first error it found.
+//
+//	err := db.Maintain(sess, db.MaintenanceAnalyze|db.MaintenanceOptimize)
+func Maintain(sess Session, tasks MaintenanceTask, collections ...string) error {
+	var cols []Collection
+	if len(collections) == 0 {
+		var err error
+		if cols, err = sess.Collections(); err != nil {
+			return err
+		}
+	} else {
+		for _, name := range collections {
+			cols = append(cols, sess.Collection(name))
+		}
+	}
+
+	var firstErr error
+	for _, col := range cols {
+		for _, run := range maintenanceTasks(col, tasks) {
+			if err := run(); err != nil && !errors.Is(err, ErrUnsupported) && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// MaintainEvery runs Maintain every interval until ctx is done. Errors are
+// sent to the logger.
+//
+//	go db.MaintainEvery(ctx, sess, 24*time.Hour, db.MaintenanceAnalyze|db.MaintenanceVacuum)
+func MaintainEvery(ctx context.Context, sess Session, interval time.Duration, tasks MaintenanceTask, collections ...string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := Maintain(sess, tasks, collections...); err != nil {
+				LC().Errorf("upper: failed to run maintenance tasks: %v", err)
+			}
+		}
+	}
+}
+
+func maintenanceTasks(col Collection, tasks MaintenanceTask) []func() error {
+	var fns []func() error
+	if tasks&MaintenanceAnalyze != 0 {
+		fns = append(fns, col.Analyze)
+	}
+	if tasks&MaintenanceVacuum != 0 {
+		fns = append(fns, col.Vacuum)
+	}
+	if tasks&MaintenanceOptimize != 0 {
+		fns = append(fns, col.Optimize)
+	}
+	return fns
+}
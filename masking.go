@@ -0,0 +1,136 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type rolesKey struct{}
+
+// WithRoles returns a copy of ctx that carries the roles of the caller, which
+// are checked against the masking policy of sessions bound to the context,
+// see MaskingPolicy.
+func WithRoles(ctx context.Context, roles ...string) context.Context {
+	return context.WithValue(ctx, rolesKey{}, roles)
+}
+
+// Roles returns the roles carried by ctx.
+func Roles(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesKey{}).([]string)
+	return roles
+}
+
+type columnMask struct {
+	roles       []string
+	replacement interface{}
+}
+
+// MaskingPolicy hides the values of sensitive columns from callers that don't
+// have one of the roles required to read them. The policy is applied when
+// rows are scanned, so every query of the session is covered without changes
+// to the handlers that run them:
+//
+//	policy := db.NewMaskingPolicy().
+//		Omit("salary", "hr").
+//		Mask("email", "***", "support", "admin")
+//	err := db.SetMaskingPolicy(sess, policy)
+//	...
+//	sess := sess.WithContext(db.WithRoles(ctx, user.Roles...))
+//
+// A column of the result set is masked when it's named after a masked column
+// or when the expression it's read from refers to one, so aliases like
+// "salary AS s", aggregates like MAX(salary) and subqueries are masked too.
+// Names are matched without regard to case.
+//
+// The policy applies to every value read through the Result and Iterator
+// APIs, including Scan, NextScan, Scanner and aggregates like Sum and Max,
+// which read omitted columns as NULL. The *sql.Rows and *sql.Row values
+// returned by SQL().Query and SQL().QueryRow come straight from database/sql
+// and are not masked.
+type MaskingPolicy struct {
+	mu      sync.RWMutex
+	columns map[string]columnMask
+}
+
+// NewMaskingPolicy returns an empty policy.
+func NewMaskingPolicy() *MaskingPolicy {
+	return &MaskingPolicy{columns: map[string]columnMask{}}
+}
+
+// Omit hides column from callers without any of the given roles. Struct
+// fields are left with their zero value and map keys are removed.
+func (p *MaskingPolicy) Omit(column string, roles ...string) *MaskingPolicy {
+	return p.Mask(column, nil, roles...)
+}
+
+// Mask replaces the value of column with replacement for callers without any
+// of the given roles. The replacement must be convertible to the type of the
+// struct fields it's stored into, fields it can't be stored into are left
+// with their zero value. A nil replacement is the same as Omit.
+func (p *MaskingPolicy) Mask(column string, replacement interface{}, roles ...string) *MaskingPolicy {
+	p.mu.Lock()
+	p.columns[strings.ToLower(column)] = columnMask{roles: roles, replacement: replacement}
+	p.mu.Unlock()
+	return p
+}
+
+// Masked returns whether column must be masked for the caller whose roles are
+// carried by ctx, and the value that replaces it, nil meaning the column is
+// omitted.
+func (p *MaskingPolicy) Masked(ctx context.Context, column string) (replacement interface{}, masked bool) {
+	p.mu.RLock()
+	mask, ok := p.columns[strings.ToLower(column)]
+	p.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	for _, role := range Roles(ctx) {
+		for _, allowed := range mask.roles {
+			if role == allowed {
+				return nil, false
+			}
+		}
+	}
+	return mask.replacement, true
+}
+
+// MaskingSession is implemented by sessions that can apply a MaskingPolicy.
+type MaskingSession interface {
+	// SetMaskingPolicy sets the policy of the session, a nil policy removes
+	// it. Sessions derived from the session inherit it.
+	SetMaskingPolicy(policy *MaskingPolicy)
+}
+
+// SetMaskingPolicy applies the given policy to the rows read through sess. It
+// returns ErrUnsupported if the adapter can't mask columns.
+func SetMaskingPolicy(sess Session, policy *MaskingPolicy) error {
+	m, ok := sess.(MaskingSession)
+	if !ok {
+		return fmt.Errorf("%w: column masking", ErrUnsupported)
+	}
+	m.SetMaskingPolicy(policy)
+	return nil
+}
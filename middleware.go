@@ -0,0 +1,83 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+
+	"github.com/upper/db/v4/internal/queryctx"
+)
+
+// QueryHandler sends a query with its arguments to the database and returns
+// the error the database reported, if any.
+type QueryHandler func(ctx context.Context, query string, args []interface{}) error
+
+// Middleware wraps the execution of every statement a session sends to the
+// database. A middleware receives the next handler in the chain and returns a
+// handler that may inspect or rewrite the query and its arguments before
+// calling next, and inspect the error or the duration after next returns:
+//
+//	sess.Use(func(next db.QueryHandler) db.QueryHandler {
+//		return func(ctx context.Context, query string, args []interface{}) error {
+//			start := time.Now()
+//			err := next(ctx, query, args)
+//			log.Printf("%s (%v): %v", query, time.Since(start), err)
+//			return err
+//		}
+//	})
+//
+// A middleware that does not call next prevents the statement from being
+// executed. If it returns a nil error in that case the session returns
+// ErrQuerySkipped instead, as there is no result to hand to the caller.
+type Middleware func(next QueryHandler) QueryHandler
+
+// QueryID returns the ID of the statement being executed, the same ID that is
+// printed in the query log and attached to errors as a QueryError. It is only
+// available to middleware.
+func QueryID(ctx context.Context) (uint64, bool) {
+	return queryctx.QueryID(ctx)
+}
+
+// RowsAffected returns the number of rows affected by the statement being
+// executed. It is only available to middleware, after next returns, and only
+// for statements that don't return rows.
+func RowsAffected(ctx context.Context) (int64, bool) {
+	return queryctx.RowsAffected(ctx)
+}
+
+// StatementError returns the error of the statement being executed, as
+// translated by the adapter, so it can be checked for errors like
+// ErrDuplicateKey or ErrDeadlock with errors.Is. Middleware receive the error
+// returned by the driver from next, StatementError is only available after
+// next returns.
+func StatementError(ctx context.Context) error {
+	return queryctx.Error(ctx)
+}
+
+// Chain returns a handler that runs the given middleware around handler, the
+// first middleware being the outermost.
+func Chain(handler QueryHandler, middleware ...Middleware) QueryHandler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4/internal/queryctx"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+
+	trace := func(name string) Middleware {
+		return func(next QueryHandler) QueryHandler {
+			return func(ctx context.Context, query string, args []interface{}) error {
+				calls = append(calls, name)
+				return next(ctx, query+" /* "+name+" */", args)
+			}
+		}
+	}
+
+	handler := Chain(func(ctx context.Context, query string, args []interface{}) error {
+		calls = append(calls, query)
+		return nil
+	}, trace("a"), trace("b"))
+
+	err := handler(context.Background(), "SELECT 1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "SELECT 1 /* a */ /* b */"}, calls)
+}
+
+func TestRowsAffected(t *testing.T) {
+	ctx := context.Background()
+
+	queryctx.SetRowsAffected(ctx, 3)
+	_, ok := RowsAffected(ctx)
+	assert.False(t, ok)
+
+	ctx = queryctx.WithQueryID(ctx, 42)
+
+	queryID, ok := QueryID(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(42), queryID)
+
+	_, ok = RowsAffected(ctx)
+	assert.False(t, ok)
+
+	queryctx.SetRowsAffected(ctx, 3)
+	rowsAffected, ok := RowsAffected(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), rowsAffected)
+}
+
+func TestStatementError(t *testing.T) {
+	ctx := context.Background()
+
+	queryctx.SetError(ctx, ErrDeadlock)
+	assert.Nil(t, StatementError(ctx))
+
+	ctx = queryctx.WithQueryID(ctx, 42)
+	assert.Nil(t, StatementError(ctx))
+
+	queryctx.SetError(ctx, ErrDeadlock)
+	assert.Equal(t, ErrDeadlock, StatementError(ctx))
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"strings"
+)
+
+// ModifierOp is the operation a Modifier performs.
+type ModifierOp uint8
+
+// Modifier operations.
+const (
+	// ModifierSet sets the value of a field.
+	ModifierSet ModifierOp = iota + 1
+
+	// ModifierUnset removes a field.
+	ModifierUnset
+
+	// ModifierPush appends a value to an array field.
+	ModifierPush
+)
+
+// Modifier represents a change on a field of the documents of a result set,
+// see Result.Modify. The path of the field may point to a nested document by
+// separating the names of the fields with dots, like "prefs.theme".
+type Modifier struct {
+	Op    ModifierOp
+	Path  string
+	Value interface{}
+}
+
+// SetPath returns a modifier that sets the field at the given path to value.
+//
+//	res.Modify(db.SetPath("prefs.theme", "dark"))
+func SetPath(path string, value interface{}) *Modifier {
+	return &Modifier{Op: ModifierSet, Path: path, Value: value}
+}
+
+// Unset returns a modifier that removes the field at the given path.
+//
+//	res.Modify(db.Unset("prefs.tmp"))
+func Unset(path string) *Modifier {
+	return &Modifier{Op: ModifierUnset, Path: path}
+}
+
+// Push returns a modifier that appends value to the array at the given path.
+//
+//	res.Modify(db.Push("tags", "x"))
+func Push(path string, value interface{}) *Modifier {
+	return &Modifier{Op: ModifierPush, Path: path, Value: value}
+}
+
+// Field returns the name of the top-level field the modifier changes and the
+// path to the nested field within it, if any.
+func (m *Modifier) Field() (string, []string) {
+	parts := strings.Split(m.Path, ".")
+	return parts[0], parts[1:]
+}
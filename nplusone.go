@@ -0,0 +1,45 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+
+	"github.com/upper/db/v4/internal/queryctx"
+)
+
+// DetectNPlusOne returns a copy of ctx in which sessions keep track of the
+// statements they run. When the same statement, regardless of its arguments,
+// runs more than threshold times with the same context a warning is logged
+// with the statement and the call stack that ran it, which is the usual sign
+// of a query inside a loop that should be a single query or a join:
+//
+//	if devMode {
+//		ctx = db.DetectNPlusOne(ctx, 10)
+//	}
+//	sess := sess.WithContext(ctx)
+//
+// Each statement is reported once per context. Only SQL adapters keep track
+// of statements.
+func DetectNPlusOne(ctx context.Context, threshold int) context.Context {
+	return queryctx.WithRepeatThreshold(ctx, threshold)
+}
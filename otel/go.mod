@@ -0,0 +1,11 @@
+module github.com/upper/db/v4/otel
+
+go 1.15
+
+require (
+	github.com/upper/db/v4 v4.0.0
+	go.opentelemetry.io/otel v1.6.1
+	go.opentelemetry.io/otel/trace v1.6.1
+)
+
+replace github.com/upper/db/v4 => ../
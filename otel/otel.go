@@ -0,0 +1,137 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package otel instruments upper/db sessions with OpenTelemetry. It provides
+// a db.Middleware that starts a client span for every statement a session
+// sends to the database, that includes the statements behind Insert, Find,
+// Update, Delete and raw queries:
+//
+//	sess.Use(otel.Middleware(postgresql.Adapter))
+//
+// Spans are named after the SQL operation (SELECT, INSERT, ...) and carry the
+// db.system, db.statement and db.operation attributes, the number of affected
+// rows for statements that don't return rows, and the ID of the statement as
+// it appears in the upper/db query log.
+package otel
+
+import (
+	"context"
+	"strings"
+
+	db "github.com/upper/db/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans created by this package.
+const instrumentationName = "github.com/upper/db/v4/otel"
+
+// Attribute keys that are not part of the OpenTelemetry semantic conventions.
+const (
+	RowsAffectedKey = attribute.Key("db.rows_affected")
+	QueryIDKey      = attribute.Key("upper.query_id")
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+}
+
+// Option configures the middleware.
+type Option func(*config)
+
+// WithTracerProvider sets the tracer provider used to create spans, the
+// global one is used by default.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// Middleware returns a db.Middleware that traces every statement. system is
+// the value of the db.system attribute, usually the name of the adapter.
+func Middleware(system string, opts ...Option) db.Middleware {
+	c := config{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.tracerProvider == nil {
+		c.tracerProvider = otel.GetTracerProvider()
+	}
+
+	tracer := c.tracerProvider.Tracer(instrumentationName)
+
+	return func(next db.QueryHandler) db.QueryHandler {
+		return func(ctx context.Context, query string, args []interface{}) error {
+			op := operation(query)
+
+			attrs := []attribute.KeyValue{
+				attribute.String("db.system", system),
+				attribute.String("db.statement", query),
+				attribute.String("db.operation", op),
+			}
+			if queryID, ok := db.QueryID(ctx); ok {
+				attrs = append(attrs, QueryIDKey.Int64(int64(queryID)))
+			}
+
+			ctx, span := tracer.Start(ctx, op,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+
+			err := next(ctx, query, args)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return err
+			}
+
+			if rowsAffected, ok := db.RowsAffected(ctx); ok {
+				span.SetAttributes(RowsAffectedKey.Int64(rowsAffected))
+			}
+			return nil
+		}
+	}
+}
+
+// operation returns the first keyword of query, skipping leading comments.
+func operation(query string) string {
+	for {
+		query = strings.TrimSpace(query)
+		if !strings.HasPrefix(query, "/*") {
+			break
+		}
+		end := strings.Index(query, "*/")
+		if end < 0 {
+			return ""
+		}
+		query = query[end+2:]
+	}
+
+	if i := strings.IndexFunc(query, func(r rune) bool {
+		return r == ' ' || r == '\n' || r == '\t' || r == '\r' || r == '('
+	}); i >= 0 {
+		query = query[:i]
+	}
+	return strings.ToUpper(query)
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pivot turns rows of key, category and value into wide rows, with one column
+// per category. Categories must be declared beforehand:
+//
+//	p := db.Pivot{
+//	  Key:        "product",
+//	  Category:   "quarter",
+//	  Value:      "amount",
+//	  Categories: []interface{}{"q1", "q2", "q3", "q4"},
+//	}
+//
+// Apply pivots in SQL, Fold pivots rows that were already grouped on the
+// client.
+type Pivot struct {
+	// Key is the column that identifies each wide row.
+	Key string
+
+	// Category is the column whose values become columns.
+	Category string
+
+	// Value is the column whose values fill the wide rows.
+	Value string
+
+	// Categories are the values of Category that become columns, other values
+	// are ignored.
+	Categories []interface{}
+
+	// Aggregate is the SQL function that combines the values of the same key
+	// and category, it defaults to SUM.
+	Aggregate string
+
+	// Alias returns the name of the column of the given category. By default
+	// the category is lowercased, anything that is not a letter, a digit or an
+	// underscore is replaced by an underscore and names that would start with a
+	// digit are prefixed by an underscore.
+	Alias func(category interface{}) string
+}
+
+// Columns returns the key column followed by one aggregated column per
+// category, to be used with Select and grouped by the key:
+//
+//	SUM(CASE WHEN quarter = 'q1' THEN amount END) AS q1
+func (p *Pivot) Columns() []interface{} {
+	aggregate := p.Aggregate
+	if aggregate == "" {
+		aggregate = "SUM"
+	}
+
+	columns := make([]interface{}, 0, len(p.Categories)+1)
+	columns = append(columns, p.Key)
+	for _, category := range p.Categories {
+		columns = append(columns, Raw(
+			fmt.Sprintf("%s(CASE WHEN %s = ? THEN %s END) AS %s", aggregate, p.Category, p.Value, p.alias(category)),
+			category,
+		))
+	}
+	return columns
+}
+
+// Apply returns a result set with the wide rows of res, computed by the
+// database.
+//
+//	var rows []map[string]interface{}
+//	err := p.Apply(sales.Find()).All(&rows)
+func (p *Pivot) Apply(res Result) Result {
+	return res.Select(p.Columns()...).GroupBy(p.Key)
+}
+
+// Fold reads all the rows of res, which must have the key, category and value
+// columns and be grouped by key and category, and returns the wide rows in the
+// order their keys were first seen. Categories with no value are nil.
+//
+//	grouped := sales.Find().
+//	  Select("product", "quarter", db.Raw("SUM(amount) AS amount")).
+//	  GroupBy("product", "quarter")
+//	rows, err := p.Fold(grouped)
+func (p *Pivot) Fold(res Result) ([]map[string]interface{}, error) {
+	aliases := make(map[string]string, len(p.Categories))
+	for _, category := range p.Categories {
+		aliases[valueKey(category)] = p.alias(category)
+	}
+
+	rows := []map[string]interface{}{}
+	byKey := map[string]map[string]interface{}{}
+
+	for {
+		var item map[string]interface{}
+		if !res.Next(&item) {
+			break
+		}
+
+		alias, ok := aliases[valueKey(item[p.Category])]
+		if !ok {
+			continue
+		}
+
+		key := valueKey(item[p.Key])
+		row, ok := byKey[key]
+		if !ok {
+			row = map[string]interface{}{p.Key: item[p.Key]}
+			for _, alias := range aliases {
+				row[alias] = nil
+			}
+			byKey[key] = row
+			rows = append(rows, row)
+		}
+		row[alias] = item[p.Value]
+	}
+
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	if err := res.Close(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func (p *Pivot) alias(category interface{}) string {
+	if p.Alias != nil {
+		return p.Alias(category)
+	}
+	alias := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '_'
+	}, valueKey(category))
+	if alias == "" || (alias[0] >= '0' && alias[0] <= '9') {
+		return "_" + alias
+	}
+	return alias
+}
+
+// valueKey returns a comparable representation of a value read from the
+// database, drivers may return text as []byte.
+func valueKey(v interface{}) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprintf("%v", v)
+}
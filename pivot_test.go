@@ -0,0 +1,56 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPivotColumns(t *testing.T) {
+	p := Pivot{
+		Key:        "product",
+		Category:   "quarter",
+		Value:      "amount",
+		Categories: []interface{}{"Q1", "2020-Q2"},
+	}
+
+	columns := p.Columns()
+	assert.Len(t, columns, 3)
+	assert.Equal(t, "product", columns[0])
+
+	raw := columns[1].(*RawExpr)
+	assert.Equal(t, "SUM(CASE WHEN quarter = ? THEN amount END) AS q1", raw.Raw())
+	assert.Equal(t, []interface{}{"Q1"}, raw.Arguments())
+
+	raw = columns[2].(*RawExpr)
+	assert.Equal(t, "SUM(CASE WHEN quarter = ? THEN amount END) AS _2020_q2", raw.Raw())
+
+	p.Aggregate = "MAX"
+	p.Alias = func(category interface{}) string {
+		return "c_" + category.(string)
+	}
+
+	raw = p.Columns()[1].(*RawExpr)
+	assert.Equal(t, "MAX(CASE WHEN quarter = ? THEN amount END) AS c_Q1", raw.Raw())
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"github.com/upper/db/v4/internal/adapter"
+)
+
+// PrecompiledExpr represents a logical expression that is compiled only once
+// per adapter and then reused by every query it is part of.
+type PrecompiledExpr = adapter.PrecompiledExpr
+
+// Precompile joins the given conditions under logical conjunction (like
+// And()) and returns an expression that SQL adapters compile only once. Use
+// it for complex conditions that are used on many queries, the expression and
+// the values it holds must not be modified after calling Precompile.
+//
+// Example:
+//
+//	activeAdults := db.Precompile(
+//		db.Cond{"age >=": 18},
+//		db.Or(
+//			db.Cond{"status": "active"},
+//			db.Cond{"status": "trial"},
+//		),
+//	)
+//
+//	res := col.Find(activeAdults).And(db.Cond{"country": "MX"})
+func Precompile(conds ...LogicalExpr) *PrecompiledExpr {
+	if len(conds) == 1 {
+		return adapter.NewPrecompiledExpr(conds[0])
+	}
+	return adapter.NewPrecompiledExpr(And(conds...))
+}
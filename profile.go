@@ -0,0 +1,341 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultProfileEnvPrefix is the prefix of the environment variables profiles
+// are read from when no other ProfileSource was set, see ProfileEnv.
+const DefaultProfileEnvPrefix = "UPPER_DB"
+
+// Profile holds the settings needed to open a session: the name of the
+// adapter, its connection URL and connection pool settings.
+type Profile struct {
+	// Adapter is the name of the adapter, like "postgresql".
+	Adapter string `json:"adapter" yaml:"adapter" toml:"adapter"`
+
+	// URL is the connection URL, it's parsed by the adapter when it
+	// implements AdapterURLParser and passed as it is otherwise.
+	URL string `json:"url" yaml:"url" toml:"url"`
+
+	// MaxOpenConns, MaxIdleConns and ConnMaxLifetime are applied to the
+	// session after connecting when they're not zero. ConnMaxLifetime uses the
+	// time.ParseDuration format.
+	MaxOpenConns    int    `json:"max_open_conns,omitempty" yaml:"max_open_conns,omitempty" toml:"max_open_conns,omitempty"`
+	MaxIdleConns    int    `json:"max_idle_conns,omitempty" yaml:"max_idle_conns,omitempty" toml:"max_idle_conns,omitempty"`
+	ConnMaxLifetime string `json:"conn_max_lifetime,omitempty" yaml:"conn_max_lifetime,omitempty" toml:"conn_max_lifetime,omitempty"`
+}
+
+// PoolOptions returns the connection pool settings of the profile as
+// connection pool options, see ApplyPoolOptions.
+func (p *Profile) PoolOptions() map[string]string {
+	options := map[string]string{}
+	if p.MaxOpenConns != 0 {
+		options[OptionMaxOpenConns] = strconv.Itoa(p.MaxOpenConns)
+	}
+	if p.MaxIdleConns != 0 {
+		options[OptionMaxIdleConns] = strconv.Itoa(p.MaxIdleConns)
+	}
+	if p.ConnMaxLifetime != "" {
+		options[OptionConnMaxLifetime] = p.ConnMaxLifetime
+	}
+	return options
+}
+
+// merge sets the fields of p that are not zero in other.
+func (p *Profile) merge(other *Profile) {
+	if other.Adapter != "" {
+		p.Adapter = other.Adapter
+	}
+	if other.URL != "" {
+		p.URL = other.URL
+	}
+	if other.MaxOpenConns != 0 {
+		p.MaxOpenConns = other.MaxOpenConns
+	}
+	if other.MaxIdleConns != 0 {
+		p.MaxIdleConns = other.MaxIdleConns
+	}
+	if other.ConnMaxLifetime != "" {
+		p.ConnMaxLifetime = other.ConnMaxLifetime
+	}
+}
+
+// ProfileSource loads named connection profiles.
+type ProfileSource interface {
+	LoadProfiles() (map[string]*Profile, error)
+}
+
+// ProfileSourceFunc is a function that satisfies ProfileSource.
+type ProfileSourceFunc func() (map[string]*Profile, error)
+
+// LoadProfiles calls f.
+func (f ProfileSourceFunc) LoadProfiles() (map[string]*Profile, error) {
+	return f()
+}
+
+// ProfileFile returns a source that reads profiles from the file at path every
+// time they're loaded. The file holds a map of profile names to profiles and
+// is decoded with unmarshal, which can be the Unmarshal function of a YAML or
+// TOML package, encoding/json is used if unmarshal is nil:
+//
+//	# profiles.yml
+//	analytics:
+//	  adapter: postgresql
+//	  url: postgres://analytics@db.internal/events
+//	  max_open_conns: 20
+//
+//	db.SetProfileSource(db.ProfileFile("profiles.yml", yaml.Unmarshal))
+func ProfileFile(path string, unmarshal func([]byte, interface{}) error) ProfileSource {
+	if unmarshal == nil {
+		unmarshal = json.Unmarshal
+	}
+	return ProfileSourceFunc(func() (map[string]*Profile, error) {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		profiles := map[string]*Profile{}
+		if err := unmarshal(data, &profiles); err != nil {
+			return nil, fmt.Errorf("upper: could not read profiles from %s: %w", path, err)
+		}
+		return profiles, nil
+	})
+}
+
+// profileEnvFields maps the suffixes of profile environment variables to the
+// fields they set.
+var profileEnvFields = []struct {
+	suffix string
+	set    func(p *Profile, value string) error
+}{
+	{"_ADAPTER", func(p *Profile, value string) error {
+		p.Adapter = value
+		return nil
+	}},
+	{"_URL", func(p *Profile, value string) error {
+		p.URL = value
+		return nil
+	}},
+	{"_MAX_OPEN_CONNS", func(p *Profile, value string) (err error) {
+		p.MaxOpenConns, err = strconv.Atoi(value)
+		return err
+	}},
+	{"_MAX_IDLE_CONNS", func(p *Profile, value string) (err error) {
+		p.MaxIdleConns, err = strconv.Atoi(value)
+		return err
+	}},
+	{"_CONN_MAX_LIFETIME", func(p *Profile, value string) error {
+		p.ConnMaxLifetime = value
+		return nil
+	}},
+}
+
+// ProfileEnv returns a source that reads profiles from environment variables
+// named after the prefix, the profile and the field, in upper case:
+//
+//	UPPER_DB_ANALYTICS_ADAPTER=postgresql
+//	UPPER_DB_ANALYTICS_URL=postgres://analytics@db.internal/events
+//	UPPER_DB_ANALYTICS_MAX_OPEN_CONNS=20
+//	UPPER_DB_ANALYTICS_MAX_IDLE_CONNS=5
+//	UPPER_DB_ANALYTICS_CONN_MAX_LIFETIME=5m
+//
+// Profile names are read in lower case, "analytics" in the example.
+func ProfileEnv(prefix string) ProfileSource {
+	prefix = strings.ToUpper(strings.TrimSuffix(prefix, "_")) + "_"
+	return ProfileSourceFunc(func() (map[string]*Profile, error) {
+		profiles := map[string]*Profile{}
+		for _, kv := range os.Environ() {
+			i := strings.Index(kv, "=")
+			if i < 0 || !strings.HasPrefix(kv[:i], prefix) {
+				continue
+			}
+			key, value := kv[len(prefix):i], kv[i+1:]
+			for _, field := range profileEnvFields {
+				if !strings.HasSuffix(key, field.suffix) || len(key) == len(field.suffix) {
+					continue
+				}
+				name := strings.ToLower(strings.TrimSuffix(key, field.suffix))
+				if profiles[name] == nil {
+					profiles[name] = &Profile{}
+				}
+				if err := field.set(profiles[name], value); err != nil {
+					return nil, fmt.Errorf("upper: invalid value for %s: %w", kv[:i], err)
+				}
+				break
+			}
+		}
+		return profiles, nil
+	})
+}
+
+// ProfileSources returns a source that merges the profiles of the given
+// sources. Fields set by later sources override the ones set by earlier
+// sources, so a file can be combined with environment variables that replace
+// some of its settings:
+//
+//	db.ProfileSources(db.ProfileFile("profiles.json", nil), db.ProfileEnv("APP_DB"))
+func ProfileSources(sources ...ProfileSource) ProfileSource {
+	return ProfileSourceFunc(func() (map[string]*Profile, error) {
+		profiles := map[string]*Profile{}
+		for _, source := range sources {
+			loaded, err := source.LoadProfiles()
+			if err != nil {
+				return nil, err
+			}
+			for name, profile := range loaded {
+				if profile == nil {
+					continue
+				}
+				if profiles[name] == nil {
+					profiles[name] = &Profile{}
+				}
+				profiles[name].merge(profile)
+			}
+		}
+		return profiles, nil
+	})
+}
+
+var profiles struct {
+	mu       sync.RWMutex
+	source   ProfileSource
+	profiles map[string]*Profile
+}
+
+// SetProfileSource sets the source of the profiles used by OpenProfile and
+// loads them. The profiles that were loaded before are kept if src fails.
+func SetProfileSource(src ProfileSource) error {
+	loaded, err := src.LoadProfiles()
+	if err != nil {
+		return err
+	}
+
+	profiles.mu.Lock()
+	defer profiles.mu.Unlock()
+
+	profiles.source, profiles.profiles = src, loaded
+	return nil
+}
+
+// ReloadProfiles loads the profiles again from their source, for instance
+// after the configuration file was changed. Sessions that were already opened
+// keep their settings. The profiles that were loaded before are kept if the
+// source fails.
+func ReloadProfiles() error {
+	profiles.mu.RLock()
+	src := profiles.source
+	profiles.mu.RUnlock()
+
+	if src == nil {
+		src = ProfileEnv(DefaultProfileEnvPrefix)
+	}
+	return SetProfileSource(src)
+}
+
+// LookupProfile returns a copy of the profile with the given name. Profiles
+// are loaded from the environment, see ProfileEnv and DefaultProfileEnvPrefix,
+// unless SetProfileSource was called.
+func LookupProfile(name string) (*Profile, error) {
+	profiles.mu.RLock()
+	loaded := profiles.profiles != nil
+	profiles.mu.RUnlock()
+
+	if !loaded {
+		if err := ReloadProfiles(); err != nil {
+			return nil, err
+		}
+	}
+
+	profiles.mu.RLock()
+	defer profiles.mu.RUnlock()
+
+	profile, ok := profiles.profiles[name]
+	if !ok || profile == nil {
+		return nil, fmt.Errorf("%w %q", ErrMissingProfile, name)
+	}
+	p := *profile
+	return &p, nil
+}
+
+// profileURL is the connection URL of profiles whose adapter can't parse
+// connection URLs.
+type profileURL string
+
+func (u profileURL) String() string {
+	return string(u)
+}
+
+// OpenProfile opens a session with the settings of the profile with the given
+// name, see LookupProfile.
+//
+// Example:
+//
+//	sess, err := db.OpenProfile("analytics")
+func OpenProfile(name string) (Session, error) {
+	profile, err := LookupProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	if profile.Adapter == "" {
+		return nil, fmt.Errorf("upper: profile %q has no adapter", name)
+	}
+	if profile.URL == "" {
+		return nil, fmt.Errorf("upper: profile %q has no URL", name)
+	}
+
+	if err := ApplyPoolOptions(NewSettings(), profile.PoolOptions()); err != nil {
+		return nil, err
+	}
+
+	adapter := LookupAdapter(profile.Adapter)
+
+	var connURL ConnectionURL = profileURL(profile.URL)
+	if p, ok := adapter.(AdapterURLParser); ok {
+		u, err := p.ParseURL(profile.URL)
+		switch {
+		case err == nil:
+			connURL = u
+		case !errors.Is(err, ErrUnsupported):
+			return nil, fmt.Errorf("upper: invalid URL in profile %q: %w", name, err)
+		}
+	}
+
+	sess, err := adapter.Open(connURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := ApplyPoolOptions(sess, profile.PoolOptions()); err != nil {
+		_ = sess.Close()
+		return nil, err
+	}
+	return sess, nil
+}
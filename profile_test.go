@@ -0,0 +1,140 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type profileAdapter struct {
+	opened ConnectionURL
+}
+
+func (a *profileAdapter) Open(u ConnectionURL) (Session, error) {
+	a.opened = u
+	return nil, ErrNotImplemented
+}
+
+func (*profileAdapter) ParseURL(s string) (ConnectionURL, error) {
+	if s == "invalid" {
+		return nil, errors.New("invalid URL")
+	}
+	return profileURL("parsed:" + s), nil
+}
+
+func TestProfileEnv(t *testing.T) {
+	os.Setenv("PROFILE_TEST_ANALYTICS_ADAPTER", "postgresql")
+	os.Setenv("PROFILE_TEST_ANALYTICS_URL", "postgres://localhost/events")
+	os.Setenv("PROFILE_TEST_ANALYTICS_MAX_OPEN_CONNS", "20")
+	os.Setenv("PROFILE_TEST_USER_STORE_CONN_MAX_LIFETIME", "5m")
+	defer func() {
+		for _, k := range []string{"ANALYTICS_ADAPTER", "ANALYTICS_URL", "ANALYTICS_MAX_OPEN_CONNS", "USER_STORE_CONN_MAX_LIFETIME"} {
+			os.Unsetenv("PROFILE_TEST_" + k)
+		}
+	}()
+
+	profiles, err := ProfileEnv("profile_test").LoadProfiles()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]*Profile{
+		"analytics":  {Adapter: "postgresql", URL: "postgres://localhost/events", MaxOpenConns: 20},
+		"user_store": {ConnMaxLifetime: "5m"},
+	}, profiles)
+
+	os.Setenv("PROFILE_TEST_ANALYTICS_MAX_OPEN_CONNS", "many")
+	_, err = ProfileEnv("PROFILE_TEST_").LoadProfiles()
+	assert.Error(t, err)
+}
+
+func TestProfileFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "upper-profiles")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "profiles.json")
+	err = ioutil.WriteFile(path, []byte(`{"analytics": {"adapter": "mysql", "url": "mysql://localhost/events", "max_idle_conns": 2}}`), 0600)
+	assert.NoError(t, err)
+
+	profiles, err := ProfileFile(path, nil).LoadProfiles()
+	assert.NoError(t, err)
+	assert.Equal(t, &Profile{Adapter: "mysql", URL: "mysql://localhost/events", MaxIdleConns: 2}, profiles["analytics"])
+	assert.Equal(t, map[string]string{OptionMaxIdleConns: "2"}, profiles["analytics"].PoolOptions())
+
+	merged, err := ProfileSources(
+		ProfileFile(path, nil),
+		ProfileSourceFunc(func() (map[string]*Profile, error) {
+			return map[string]*Profile{"analytics": {URL: "mysql://replica/events"}}, nil
+		}),
+	).LoadProfiles()
+	assert.NoError(t, err)
+	assert.Equal(t, &Profile{Adapter: "mysql", URL: "mysql://replica/events", MaxIdleConns: 2}, merged["analytics"])
+
+	_, err = ProfileFile(filepath.Join(dir, "missing.json"), nil).LoadProfiles()
+	assert.Error(t, err)
+}
+
+func TestOpenProfile(t *testing.T) {
+	adapter := &profileAdapter{}
+	RegisterAdapter("fake-profile", adapter)
+
+	source := map[string]*Profile{
+		"main":    {Adapter: "fake-profile", URL: "fake://main"},
+		"broken":  {Adapter: "fake-profile", URL: "invalid"},
+		"no-url":  {Adapter: "fake-profile"},
+		"timeout": {Adapter: "fake-profile", URL: "fake://main", ConnMaxLifetime: "soon"},
+	}
+	err := SetProfileSource(ProfileSourceFunc(func() (map[string]*Profile, error) {
+		return source, nil
+	}))
+	assert.NoError(t, err)
+	defer func() {
+		_ = SetProfileSource(ProfileEnv(DefaultProfileEnvPrefix))
+	}()
+
+	_, err = OpenProfile("main")
+	assert.True(t, errors.Is(err, ErrNotImplemented))
+	assert.Equal(t, "parsed:fake://main", adapter.opened.String())
+
+	_, err = OpenProfile("broken")
+	assert.Error(t, err)
+
+	_, err = OpenProfile("no-url")
+	assert.Error(t, err)
+
+	_, err = OpenProfile("timeout")
+	assert.Error(t, err)
+
+	_, err = OpenProfile("missing")
+	assert.True(t, errors.Is(err, ErrMissingProfile))
+
+	source["missing"] = &Profile{Adapter: "fake-profile", URL: "fake://other"}
+	assert.NoError(t, ReloadProfiles())
+
+	_, err = OpenProfile("missing")
+	assert.True(t, errors.Is(err, ErrNotImplemented))
+	assert.Equal(t, "parsed:fake://other", adapter.opened.String())
+}
@@ -0,0 +1,10 @@
+module github.com/upper/db/v4/prometheus
+
+go 1.15
+
+require (
+	github.com/prometheus/client_golang v1.12.2
+	github.com/upper/db/v4 v4.0.0
+)
+
+replace github.com/upper/db/v4 => ../
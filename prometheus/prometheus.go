@@ -0,0 +1,245 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package prometheus publishes metrics about upper/db sessions as Prometheus
+// collectors. A Collector installs a db.Middleware on the session to time
+// every statement and count its errors, and reads the statistics of the
+// connection pool when it's scraped:
+//
+//	collector := prometheus.NewCollector(postgresql.Adapter, sess)
+//	promclient.MustRegister(collector)
+//
+// Every metric has the adapter and database labels, so the collectors of
+// several sessions can be registered side by side. Statements are labelled
+// with their SQL operation (SELECT, INSERT, ...) and errors with their kind,
+// see ErrorKind.
+package prometheus
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	db "github.com/upper/db/v4"
+)
+
+// Namespace is the prefix of the metric names.
+const Namespace = "upper_db"
+
+type config struct {
+	buckets     []float64
+	constLabels prometheus.Labels
+}
+
+// Option configures a Collector.
+type Option func(*config)
+
+// WithBuckets sets the buckets of the query duration histogram, in seconds,
+// prometheus.DefBuckets is used by default.
+func WithBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+// WithConstLabels adds labels with fixed values to every metric.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+// Collector is a prometheus.Collector that publishes the metrics of a
+// session.
+type Collector struct {
+	sess db.Session
+
+	queryDuration *prometheus.HistogramVec
+	queryErrors   *prometheus.CounterVec
+
+	openConns    *prometheus.Desc
+	inUseConns   *prometheus.Desc
+	idleConns    *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+
+	labels []string
+}
+
+var _ = prometheus.Collector(&Collector{})
+
+// NewCollector creates a Collector for the given session and installs its
+// middleware on it. adapter is the value of the adapter label, usually the
+// name of the adapter.
+//
+// The middleware is installed with sess.Use, so the statements of the session
+// are sent as plain text and no longer use the prepared statement cache. Only
+// the statements of sess and of the transactions and copies created from it
+// after NewCollector returns are measured, create the collector before
+// deriving any.
+func NewCollector(adapter string, sess db.Session, opts ...Option) *Collector {
+	c := config{buckets: prometheus.DefBuckets}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	constLabels := prometheus.Labels{
+		"adapter":  adapter,
+		"database": sess.Name(),
+	}
+	for k, v := range c.constLabels {
+		constLabels[k] = v
+	}
+
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(Namespace, "pool", name), help, nil, constLabels)
+	}
+
+	collector := &Collector{
+		sess: sess,
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   Namespace,
+			Name:        "query_duration_seconds",
+			Help:        "Time taken by statements, by SQL operation.",
+			Buckets:     c.buckets,
+			ConstLabels: constLabels,
+		}, []string{"operation"}),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   Namespace,
+			Name:        "query_errors_total",
+			Help:        "Statements that failed, by SQL operation and kind of error.",
+			ConstLabels: constLabels,
+		}, []string{"operation", "kind"}),
+		openConns:    desc("open_connections", "Established connections, both in use and idle."),
+		inUseConns:   desc("in_use_connections", "Connections currently in use."),
+		idleConns:    desc("idle_connections", "Idle connections."),
+		waitCount:    desc("wait_total", "Times a statement had to wait for a connection."),
+		waitDuration: desc("wait_seconds_total", "Time spent waiting for a connection."),
+	}
+
+	sess.Use(collector.middleware)
+
+	return collector
+}
+
+// middleware times every statement and counts its errors.
+func (c *Collector) middleware(next db.QueryHandler) db.QueryHandler {
+	return func(ctx context.Context, query string, args []interface{}) error {
+		op := operation(query)
+
+		start := time.Now()
+		err := next(ctx, query, args)
+		c.queryDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+
+		if err != nil {
+			if stmtErr := db.StatementError(ctx); stmtErr != nil {
+				err = stmtErr
+			}
+			c.queryErrors.WithLabelValues(op, ErrorKind(err)).Inc()
+		}
+		return err
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.queryDuration.Describe(ch)
+	c.queryErrors.Describe(ch)
+	ch <- c.openConns
+	ch <- c.inUseConns
+	ch <- c.idleConns
+	ch <- c.waitCount
+	ch <- c.waitDuration
+}
+
+// Collect implements prometheus.Collector. Connection pool metrics are only
+// collected for sessions whose driver is a *sql.DB.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.queryDuration.Collect(ch)
+	c.queryErrors.Collect(ch)
+
+	sqlDB, ok := c.sess.Driver().(*sql.DB)
+	if !ok || sqlDB == nil {
+		return
+	}
+
+	stats := sqlDB.Stats()
+	ch <- prometheus.MustNewConstMetric(c.openConns, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUseConns, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+}
+
+// errorKinds maps errors to the values of the kind label, in the order they
+// are checked.
+var errorKinds = []struct {
+	err  error
+	kind string
+}{
+	{db.ErrDuplicateKey, "duplicate_key"},
+	{db.ErrForeignKeyViolation, "foreign_key_violation"},
+	{db.ErrNotNullViolation, "not_null_violation"},
+	{db.ErrCheckViolation, "check_violation"},
+	{db.ErrDeadlock, "deadlock"},
+	{db.ErrLockTimeout, "lock_timeout"},
+	{db.ErrDatabaseBusy, "database_busy"},
+	{db.ErrTransactionAborted, "transaction_aborted"},
+	{db.ErrQuerySkipped, "skipped"},
+	{context.DeadlineExceeded, "timeout"},
+	{context.Canceled, "canceled"},
+}
+
+// ErrorKind returns the value of the kind label for the given error, errors
+// that are not known are reported as "other".
+func ErrorKind(err error) string {
+	for _, ek := range errorKinds {
+		if errors.Is(err, ek.err) {
+			return ek.kind
+		}
+	}
+	return "other"
+}
+
+// operation returns the first keyword of query, skipping leading comments.
+func operation(query string) string {
+	for {
+		query = strings.TrimSpace(query)
+		if !strings.HasPrefix(query, "/*") {
+			break
+		}
+		end := strings.Index(query, "*/")
+		if end < 0 {
+			return ""
+		}
+		query = query[end+2:]
+	}
+
+	if i := strings.IndexFunc(query, func(r rune) bool {
+		return r == ' ' || r == '\n' || r == '\t' || r == '\r' || r == '('
+	}); i >= 0 {
+		query = query[:i]
+	}
+	return strings.ToUpper(query)
+}
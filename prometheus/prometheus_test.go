@@ -0,0 +1,69 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	db "github.com/upper/db/v4"
+)
+
+func TestOperation(t *testing.T) {
+	tests := []struct {
+		query string
+		op    string
+	}{
+		{`SELECT * FROM "artist"`, "SELECT"},
+		{"insert\n\tINTO artist (name) VALUES ($1)", "INSERT"},
+		{`/* upper_query_id=00042 */ UPDATE "artist" SET "name" = $1`, "UPDATE"},
+		{`/* unterminated`, ""},
+		{``, ""},
+	}
+
+	for _, test := range tests {
+		if op := operation(test.query); op != test.op {
+			t.Errorf("operation(%q): expecting %q, got %q", test.query, test.op, op)
+		}
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind string
+	}{
+		{db.ErrDuplicateKey, "duplicate_key"},
+		{fmt.Errorf("insert: %w", db.ErrForeignKeyViolation), "foreign_key_violation"},
+		{db.NewContentionError(db.ErrDeadlock, errors.New("driver error")), "deadlock"},
+		{context.DeadlineExceeded, "timeout"},
+		{errors.New("syntax error"), "other"},
+	}
+
+	for _, test := range tests {
+		if kind := ErrorKind(test.err); kind != test.kind {
+			t.Errorf("ErrorKind(%v): expecting %q, got %q", test.err, test.kind, kind)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fmtLogSessID       = `Session ID:     %05d`
+	fmtLogTxID         = `Transaction ID: %05d`
+	fmtLogQueryID      = `Query ID:       %05d`
+	fmtLogQuery        = `Query:          %s`
+	fmtLogArgs         = `Arguments:      %#v`
+	fmtLogRowsAffected = `Rows affected:  %d`
+	fmtLogLastInsertID = `Last insert ID: %d`
+	fmtLogError        = `Error:          %v`
+	fmtLogTimeTaken    = `Time taken:     %0.5fs`
+	fmtLogContext      = `Context:        %v`
+)
+
+var (
+	reInvisibleChars = regexp.MustCompile(`[\s\r\n\t]+`)
+)
+
+// QueryEvent describes a statement that was sent to the database.
+type QueryEvent struct {
+	SessionID uint64
+	TxID      uint64
+	QueryID   uint64
+
+	// Query is the statement as it was sent to the database, with
+	// placeholders, and Args are the values of its placeholders.
+	Query string
+	Args  []interface{}
+
+	// Duration is the time the statement took to run.
+	Duration time.Duration
+
+	// RowsAffected is nil unless the statement reported how many rows it
+	// changed.
+	RowsAffected *int64
+
+	// LastInsertID is nil unless the statement reported the ID it generated.
+	LastInsertID *int64
+
+	// Err is the error returned by the statement, if any.
+	Err error
+
+	// Slow is true if the statement took longer than the slow query
+	// threshold of the session, see Settings.SetSlowQueryThreshold.
+	Slow bool
+
+	Context context.Context
+}
+
+// Statement returns the query with its arguments in place of the ? and $n
+// placeholders. It's meant to be read by humans, never send it to a
+// database.
+func (e *QueryEvent) Statement() string {
+	var b strings.Builder
+
+	argn := 0
+	var quote byte
+	for i := 0; i < len(e.Query); i++ {
+		c := e.Query[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			b.WriteByte(c)
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '?':
+			if argn < len(e.Args) {
+				b.WriteString(formatQueryArg(e.Args[argn]))
+				argn++
+				continue
+			}
+		case '$':
+			j := i + 1
+			for j < len(e.Query) && e.Query[j] >= '0' && e.Query[j] <= '9' {
+				j++
+			}
+			if n, err := strconv.Atoi(e.Query[i+1 : j]); err == nil && n > 0 && n <= len(e.Args) {
+				b.WriteString(formatQueryArg(e.Args[n-1]))
+				i = j - 1
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
+
+// String returns the event formatted as a log message.
+func (e *QueryEvent) String() string {
+	lines := make([]string, 0, 8)
+
+	if e.SessionID > 0 {
+		lines = append(lines, fmt.Sprintf(fmtLogSessID, e.SessionID))
+	}
+
+	if e.TxID > 0 {
+		lines = append(lines, fmt.Sprintf(fmtLogTxID, e.TxID))
+	}
+
+	if e.QueryID > 0 {
+		lines = append(lines, fmt.Sprintf(fmtLogQueryID, e.QueryID))
+	}
+
+	if query := e.Query; query != "" {
+		query = reInvisibleChars.ReplaceAllString(query, ` `)
+		query = strings.TrimSpace(query)
+		lines = append(lines, fmt.Sprintf(fmtLogQuery, query))
+	}
+
+	if len(e.Args) > 0 {
+		lines = append(lines, fmt.Sprintf(fmtLogArgs, e.Args))
+	}
+
+	if e.RowsAffected != nil {
+		lines = append(lines, fmt.Sprintf(fmtLogRowsAffected, *e.RowsAffected))
+	}
+	if e.LastInsertID != nil {
+		lines = append(lines, fmt.Sprintf(fmtLogLastInsertID, *e.LastInsertID))
+	}
+
+	if e.Err != nil {
+		lines = append(lines, fmt.Sprintf(fmtLogError, e.Err))
+	} else if e.Slow {
+		lines = append(lines, fmt.Sprintf(fmtLogError, ErrWarnSlowQuery))
+	}
+
+	lines = append(lines, fmt.Sprintf(fmtLogTimeTaken, e.Duration.Seconds()))
+
+	if e.Context != nil {
+		lines = append(lines, fmt.Sprintf(fmtLogContext, e.Context))
+	}
+
+	return "\t" + strings.Replace(strings.Join(lines, "\n"), "\n", "\n\t", -1) + "\n\n"
+}
+
+// formatQueryArg returns the SQL literal of a query argument.
+func formatQueryArg(arg interface{}) string {
+	if valuer, ok := arg.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return fmt.Sprintf("%v", arg)
+		}
+		arg = value
+	}
+
+	switch v := arg.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case string:
+		return "'" + strings.Replace(v, "'", "''", -1) + "'"
+	case []byte:
+		return "'" + strings.Replace(string(v), "'", "''", -1) + "'"
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05.999999999Z07:00") + "'"
+	}
+	return fmt.Sprintf("%v", arg)
+}
+
+// QueryLogger receives the statements that sessions send to the database.
+type QueryLogger interface {
+	LogQuery(e *QueryEvent)
+}
+
+// QueryLoggerFunc is a function that satisfies QueryLogger.
+type QueryLoggerFunc func(e *QueryEvent)
+
+// LogQuery calls f.
+func (f QueryLoggerFunc) LogQuery(e *QueryEvent) {
+	f(e)
+}
+
+// NoopQueryLogger discards every event.
+var NoopQueryLogger QueryLogger = QueryLoggerFunc(func(*QueryEvent) {})
+
+// DefaultQueryLogger is the default query logger, it writes events to the
+// logging collector, LC(). Failed and slow statements are logged as warnings
+// and the rest as debug messages, which are only written when the log level
+// is set to DEBUG, like with UPPER_DB_LOG=DEBUG.
+var DefaultQueryLogger QueryLogger = QueryLoggerFunc(func(e *QueryEvent) {
+	if e.Err != nil || e.Slow {
+		LC().Warn(e)
+		return
+	}
+	LC().Debug(e)
+})
+
+var (
+	queryLogger   QueryLogger = DefaultQueryLogger
+	queryLoggerMu sync.RWMutex
+)
+
+// SetQueryLogger sets the query logger of the sessions that don't have one of
+// their own, see Settings.SetQueryLogger. A nil logger restores
+// DefaultQueryLogger.
+//
+// Example:
+//
+//	db.SetQueryLogger(db.QueryLoggerFunc(func(e *db.QueryEvent) {
+//	  if e.Err != nil || e.Slow {
+//	    log.Printf("%s (%v): %v", e.Statement(), e.Duration, e.Err)
+//	  }
+//	}))
+func SetQueryLogger(logger QueryLogger) {
+	if logger == nil {
+		logger = DefaultQueryLogger
+	}
+
+	queryLoggerMu.Lock()
+	defer queryLoggerMu.Unlock()
+
+	queryLogger = logger
+}
+
+// LogQuery sends e to the query logger of settings, or to the one set with
+// SetQueryLogger if settings has none, after marking the event as slow
+// according to the slow query threshold of settings. The default settings are
+// used if settings is nil. Adapters call it after running every statement.
+func LogQuery(settings Settings, e *QueryEvent) {
+	if settings == nil {
+		settings = DefaultSettings
+	}
+
+	if threshold := settings.SlowQueryThreshold(); threshold > 0 && e.Duration >= threshold {
+		e.Slow = true
+	}
+
+	logger := settings.QueryLogger()
+	if logger == nil {
+		queryLoggerMu.RLock()
+		logger = queryLogger
+		queryLoggerMu.RUnlock()
+	}
+
+	logger.LogQuery(e)
+}
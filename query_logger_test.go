@@ -0,0 +1,116 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryEventStatement(t *testing.T) {
+	e := &QueryEvent{
+		Query: `SELECT * FROM "artist" WHERE name = ? AND note <> '?' AND active = ? AND deleted_at IS ?`,
+		Args:  []interface{}{"O'Brien", true, nil},
+	}
+	assert.Equal(t, `SELECT * FROM "artist" WHERE name = 'O''Brien' AND note <> '?' AND active = TRUE AND deleted_at IS NULL`, e.Statement())
+
+	e = &QueryEvent{
+		Query: `UPDATE "artist" SET name = $2 WHERE id = $1 AND created_at < $3`,
+		Args:  []interface{}{7, []byte("Ozzie"), time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}
+	assert.Equal(t, `UPDATE "artist" SET name = 'Ozzie' WHERE id = 7 AND created_at < '2020-01-02 03:04:05Z'`, e.Statement())
+
+	e = &QueryEvent{Query: `SELECT ?, $4`, Args: nil}
+	assert.Equal(t, `SELECT ?, $4`, e.Statement())
+}
+
+func TestLogQuery(t *testing.T) {
+	var global, local []*QueryEvent
+
+	SetQueryLogger(QueryLoggerFunc(func(e *QueryEvent) {
+		global = append(global, e)
+	}))
+	defer SetQueryLogger(nil)
+
+	settings := NewSettings()
+	settings.SetSlowQueryThreshold(time.Second)
+
+	LogQuery(settings, &QueryEvent{Query: "SELECT 1", Duration: time.Millisecond})
+	LogQuery(settings, &QueryEvent{Query: "SELECT 2", Duration: 2 * time.Second, Err: errors.New("timeout")})
+	if assert.Len(t, global, 2) {
+		assert.False(t, global[0].Slow)
+		assert.True(t, global[1].Slow)
+	}
+
+	settings.SetQueryLogger(QueryLoggerFunc(func(e *QueryEvent) {
+		local = append(local, e)
+	}))
+	settings.SetSlowQueryThreshold(0)
+
+	LogQuery(settings, &QueryEvent{Query: "SELECT 3", Duration: time.Hour})
+	assert.Len(t, global, 2)
+	if assert.Len(t, local, 1) {
+		assert.False(t, local[0].Slow)
+	}
+
+	LogQuery(nil, &QueryEvent{Query: "SELECT 4"})
+	assert.Len(t, global, 3)
+}
+
+func TestDefaultQueryLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	level := LC().Level()
+	LC().SetLogger(log.New(&buf, "", 0))
+	defer func() {
+		LC().SetLogger(nil)
+		LC().SetLevel(level)
+	}()
+
+	settings := NewSettings()
+	settings.SetSlowQueryThreshold(time.Second)
+
+	// Only failed and slow statements are written at the default level.
+	LC().SetLevel(LogLevelWarn)
+	LogQuery(settings, &QueryEvent{Query: "SELECT 1", Duration: time.Millisecond})
+	assert.Empty(t, buf.String())
+
+	LogQuery(settings, &QueryEvent{Query: "SELECT 2", Duration: 2 * time.Second})
+	assert.Contains(t, buf.String(), "Query:          SELECT 2")
+	assert.Contains(t, buf.String(), ErrWarnSlowQuery.Error())
+
+	buf.Reset()
+	LogQuery(settings, &QueryEvent{Query: "SELECT 3", Err: errors.New("timeout")})
+	assert.Contains(t, buf.String(), "Error:          timeout")
+
+	// Every statement is written at the debug level.
+	buf.Reset()
+	LC().SetLevel(LogLevelDebug)
+	LogQuery(settings, &QueryEvent{Query: "SELECT 4", Args: []interface{}{1}})
+	assert.Contains(t, buf.String(), "Query:          SELECT 4")
+	assert.Contains(t, buf.String(), "Arguments:      []interface {}{1}")
+}
@@ -35,6 +35,19 @@ type RawExpr = adapter.RawExpr
 //
 //	// SOUNDEX('Hello')
 //	Raw("SOUNDEX('Hello')")
+//
+// Slices are expanded into lists of placeholders, either with or without
+// parentheses around them:
+//
+//	// id IN (?, ?, ?)
+//	Raw("id IN (?)", []int{1, 2, 3})
+//
+// Arguments can also be bound by name using :name parameters when the only
+// argument is a map with string keys or a struct, struct fields are matched
+// by their db tag:
+//
+//	// author_id = ? AND year > ?
+//	Raw("author_id = :author AND year > :year", map[string]interface{}{"author": 7, "year": 1990})
 func Raw(value string, args ...interface{}) *RawExpr {
 	return adapter.NewRawExpr(value, args)
 }
@@ -0,0 +1,77 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+// ReadPreference tells adapters for replicated NoSQL databases which members
+// of the cluster may serve a query and, as a consequence, how fresh the data
+// they read must be. SQL adapters ignore read preferences.
+type ReadPreference int
+
+// Read preferences.
+const (
+	// ReadPrimary reads from the primary member only, this gives strongly
+	// consistent reads and is the default.
+	ReadPrimary ReadPreference = iota + 1
+
+	// ReadPrimaryPreferred reads from the primary member if it is available,
+	// from a secondary member otherwise.
+	ReadPrimaryPreferred
+
+	// ReadSecondary reads from secondary members only, the data read may be
+	// stale.
+	ReadSecondary
+
+	// ReadSecondaryPreferred reads from secondary members if any of them is
+	// available, from the primary member otherwise.
+	ReadSecondaryPreferred
+
+	// ReadNearest reads from the member with the lowest latency, either
+	// primary or secondary.
+	ReadNearest
+
+	// ReadMonotonic reads from secondary members until the first write, from
+	// the primary member afterwards, so the data read never goes back in
+	// time.
+	ReadMonotonic
+
+	// ReadEventual reads from any member and may switch members between
+	// reads, the data read is eventually consistent.
+	ReadEventual
+)
+
+var readPreferenceNames = map[ReadPreference]string{
+	ReadPrimary:            "primary",
+	ReadPrimaryPreferred:   "primaryPreferred",
+	ReadSecondary:          "secondary",
+	ReadSecondaryPreferred: "secondaryPreferred",
+	ReadNearest:            "nearest",
+	ReadMonotonic:          "monotonic",
+	ReadEventual:           "eventual",
+}
+
+// String returns the name of the read preference.
+func (p ReadPreference) String() string {
+	if name, ok := readPreferenceNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
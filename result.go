@@ -22,15 +22,42 @@
 package db
 
 import (
+	"context"
 	"database/sql/driver"
+	"io"
 )
 
 // Result is an interface that defines methods for result sets.
+//
+// Result values are immutable: methods that refine the set, like Where(),
+// Limit() or OrderBy(), return a new Result and leave the receiver untouched,
+// so a base query can be reused and branched from different goroutines:
+//
+//	active := users.Find(db.Cond{"active": true})
+//	admins := active.And(db.Cond{"role": "admin"})
+//	recent := active.OrderBy("-created_at").Limit(10)
+//
+// The exception is the cursor opened by Next(), which belongs to the Result
+// it was called on until Close() is called.
 type Result interface {
 
 	// String returns the SQL statement to be used in the query.
 	String() string
 
+	// Compile returns the SELECT statement and the arguments that `One()`,
+	// `All()` and `Next()` would use, without sending anything to the
+	// database. If the database does not use SQL this method returns
+	// db.ErrUnsupported.
+	Compile() (string, []interface{}, error)
+
+	// CompileUpdate returns the UPDATE statement and the arguments that
+	// `Update()` would use with the given values, see Compile.
+	CompileUpdate(interface{}) (string, []interface{}, error)
+
+	// CompileDelete returns the DELETE statement and the arguments that
+	// `Delete()` would use, see Compile.
+	CompileDelete() (string, []interface{}, error)
+
 	// Limit defines the maximum number of results for this set. It only has
 	// effect on `One()`, `All()` and `Next()`. A negative limit cancels any
 	// previous limit settings.
@@ -40,6 +67,25 @@ type Result interface {
 	// and `Next()`. A negative offset cancels any previous offset settings.
 	Offset(int) Result
 
+	// ForUpdate locks the rows matched by this set until the end of the current
+	// transaction, see Selector.ForUpdate. It only has effect on `One()`,
+	// `All()`, `Next()`, `DeleteReturning()` and `UpdateReturning()`.
+	ForUpdate(...LockOption) Result
+
+	// ForShare acquires a shared lock on the rows matched by this set, see
+	// Selector.ForShare.
+	ForShare(...LockOption) Result
+
+	// ReadPreference sets which members of a replicated database may serve
+	// `One()`, `All()`, `Next()`, `Iterate()` and `Count()`, trading
+	// consistency for availability or latency on a per query basis:
+	//
+	//   res := col.Find(cond).ReadPreference(db.ReadSecondaryPreferred)
+	//
+	// It is honoured by NoSQL adapters that support replicas, like MongoDB, and
+	// ignored by SQL adapters.
+	ReadPreference(ReadPreference) Result
+
 	// OrderBy receives one or more field names that define the order in which
 	// elements will be returned in a query, field names may be prefixed with a
 	// minus sign (-) indicating descending order, ascending order will be used
@@ -59,14 +105,104 @@ type Result interface {
 	// or columns.
 	GroupBy(...interface{}) Result
 
+	// Having filters the groups defined by GroupBy, it accepts the same
+	// conditions as And() and can be called more than once:
+	//
+	//   res := col.Find().
+	//     Select("author_id", db.Raw("COUNT(1) AS total")).
+	//     GroupBy("author_id").
+	//     Having(db.Raw("COUNT(1) > ?", 3))
+	//
+	// Having is only supported by SQL adapters that support HAVING clauses.
+	Having(...interface{}) Result
+
+	// Join adds a JOIN clause to the result set. Use On() or Using() after
+	// Join() to define how rows are matched, if no conditions are given a
+	// NATURAL JOIN will be used.
+	//
+	//   res := col.Find().
+	//     Select("artist.name", "publication.title").
+	//     Join("publication").On("publication.author_id = artist.id")
+	//
+	// Joins are only supported by SQL adapters.
+	Join(table ...interface{}) Result
+
+	// InnerJoin is like Join() but with INNER JOIN.
+	InnerJoin(table ...interface{}) Result
+
+	// LeftJoin is like Join() but with LEFT JOIN.
+	LeftJoin(table ...interface{}) Result
+
+	// RightJoin is like Join() but with RIGHT JOIN.
+	RightJoin(table ...interface{}) Result
+
+	// FullJoin is like Join() but with FULL JOIN.
+	FullJoin(table ...interface{}) Result
+
+	// On defines the conditions of the preceding join, it accepts the same
+	// arguments as And().
+	//
+	//   res.LeftJoin("publication AS p").On("p.author_id = artist.id")
+	On(conds ...interface{}) Result
+
+	// Using defines the columns the preceding join uses to match rows.
+	//
+	//   res.Join("employee").Using("department_id")
+	Using(columns ...interface{}) Result
+
 	// Delete deletes all items within the result set. `Offset()` and `Limit()`
-	// are not honoured by `Delete()`.
+	// are not honoured by `Delete()`. Result sets with joins can't be deleted.
 	Delete() error
 
 	// Update modifies all items within the result set. `Offset()` and `Limit()`
-	// are not honoured by `Update()`.
+	// are not honoured by `Update()`. Result sets with joins can't be updated.
 	Update(interface{}) error
 
+	// DeleteCount is like Delete but also returns the number of deleted items.
+	DeleteCount() (uint64, error)
+
+	// UpdateCount is like Update but also returns the number of affected
+	// items, which makes it possible to detect updates that matched nothing.
+	// Some databases, like MySQL, don't count the items that already had the
+	// given values.
+	UpdateCount(interface{}) (uint64, error)
+
+	// DeleteReturning deletes the items within the result set and stores them
+	// into dst, which may be a pointer to a slice, or a pointer to a struct or
+	// map to delete a single item. Unlike Delete, it honours `OrderBy()`,
+	// `Limit()` and `Offset()`, which makes it useful for popping items off a
+	// queue:
+	//
+	//   res.OrderBy("id").Limit(1).ForUpdate(db.SkipLocked).DeleteReturning(&job)
+	//
+	// The matched items are locked with `ForUpdate()` unless the result set
+	// has a lock already. The order of the items stored into dst is not
+	// guaranteed.
+	DeleteReturning(dst interface{}) error
+
+	// UpdateReturning modifies the items within the result set and stores the
+	// updated items into dst, see DeleteReturning.
+	UpdateReturning(values interface{}, dst interface{}) error
+
+	// Modify applies the given modifiers to all items within the result set,
+	// it can change fields of nested documents without rewriting them:
+	//
+	//   res.Modify(db.SetPath("prefs.theme", "dark"), db.Unset("tmp"), db.Push("tags", "x"))
+	//
+	// On SQL databases the first part of each path names a column, the rest
+	// of the path (if any) points into the JSON document stored in the column.
+	// Nested paths and Push are only supported by adapters with JSON operators.
+	Modify(...*Modifier) error
+
+	// Into stores the items of the result set into a new collection with the
+	// given name, replacing it if it already exists, and returns it:
+	//
+	//   report, err := res.Into("report_cache")
+	//
+	// SQL databases create a table from the SELECT statement of the result
+	// set (CREATE TABLE ... AS), MongoDB uses an aggregation with $out.
+	Into(name string) (Collection, error)
+
 	// Count returns the number of items that match the set conditions.
 	// `Offset()` and `Limit()` are not honoured by `Count()`
 	Count() (uint64, error)
@@ -75,11 +211,92 @@ type Result interface {
 	// otherwise.
 	Exists() (bool, error)
 
+	// First stores the first item of the result set into dst. If the result
+	// set has no order, items are ordered by primary key. It returns
+	// ErrNoMoreRows if the result set is empty.
+	First(dst interface{}) error
+
+	// Last stores the item of the result set with the greatest value of
+	// orderColumn into dst, replacing any order set with `OrderBy()`. Items are
+	// ordered by primary key if orderColumn is empty. It returns ErrNoMoreRows
+	// if the result set is empty.
+	Last(dst interface{}, orderColumn string) error
+
+	// Sum returns the sum of the values of the given column over the items
+	// that match the set conditions, or zero if there are no items. Like
+	// Count, Sum doesn't honour Offset and Limit, and the same goes for Avg,
+	// Min, Max and CountDistinct:
+	//
+	//   total, err := res.Sum("amount")
+	Sum(column string) (float64, error)
+
+	// Avg returns the average of the values of the given column, or zero if
+	// there are no items.
+	Avg(column string) (float64, error)
+
+	// Min stores the smallest value of the given column into the given
+	// pointer, it returns ErrNoMoreRows if there are no items.
+	//
+	//   var first time.Time
+	//   err := res.Min("created_at", &first)
+	Min(column string, dst interface{}) error
+
+	// Max stores the largest value of the given column into the given
+	// pointer, it returns ErrNoMoreRows if there are no items.
+	Max(column string, dst interface{}) error
+
+	// CountDistinct returns the number of distinct non-null values of the given
+	// column.
+	CountDistinct(column string) (uint64, error)
+
 	// Next fetches the next result within the result set and dumps it into the
 	// given pointer to struct or pointer to map. You must call
 	// `Close()` after finishing using `Next()`.
 	Next(ptrToStruct interface{}) bool
 
+	// NextContext is like Next but it stops as soon as the given context is
+	// done: the result set is closed, NextContext returns false and Err()
+	// returns the context's error. If this is the first call, the query is also
+	// sent to the database using the given context.
+	NextContext(ctx context.Context, ptrToStruct interface{}) bool
+
+	// Iterate returns a function that streams the result set into the given
+	// pointer to struct or pointer to map, one item at a time, without loading
+	// the whole set into memory. The same destination is overwritten on every
+	// step. Each call to the returned function sends the query again and the
+	// cursor is closed when it returns, so there is no need to call Close().
+	//
+	// The returned function can be used with range (Go 1.23+), it yields the
+	// index of each item and a nil error, or a non-nil error as the last step:
+	//
+	//   var p Person
+	//   for _, err := range res.Iterate(&p) {
+	//     if err != nil {
+	//       return err
+	//     }
+	//     ...
+	//   }
+	Iterate(ptrToStruct interface{}) func(yield func(int, error) bool)
+
+	// Batches returns a function that loads the result set into the given
+	// pointer to slice in batches of at most size items, for processing large
+	// sets without loading them into memory at once. Batches are read with
+	// cursor-based pagination, see `NextPage()`, so the last one is as fast as
+	// the first. The cursor is the one set with `Cursor()` or, by default, the
+	// primary key, and items must have a field or key for it.
+	//
+	// The returned function can be used with range (Go 1.23+), it yields the
+	// index of each batch and a nil error, or a non-nil error as the last step:
+	//
+	//   var people []Person
+	//   for _, err := range res.Batches(500, &people) {
+	//     if err != nil {
+	//       return err
+	//     }
+	//     ...
+	//   }
+	Batches(size uint, ptrToSlice interface{}) func(yield func(int, error) bool)
+
 	// Err returns the last error that has happened with the result set, nil
 	// otherwise.
 	Err() error
@@ -96,6 +313,23 @@ type Result interface {
 	// using All().
 	All(sliceOfStructs interface{}) error
 
+	// Scanner fetches the given column of the first result within the result
+	// set and returns a reader for its value. The value is read directly from
+	// the driver's buffer, without being copied into a new []byte, which is
+	// useful for large text or blob columns. The result set is kept open until
+	// the reader is closed, so Close() must always be called.
+	//
+	//   r, err := res.Scanner("body")
+	//   ...
+	//   defer r.Close()
+	//   _, err = io.Copy(w, r)
+	Scanner(column string) (io.ReadCloser, error)
+
+	// AllColumns fetches all results within the result set and dumps them into
+	// the given pointer to a struct of slices, one slice per column. See
+	// ResultMapper.AllColumns.
+	AllColumns(structOfSlices interface{}) error
+
 	// Paginate splits the results of the query into pages containing pageSize
 	// items. When using pagination previous settings for `Limit()` and
 	// `Offset()` are ignored. Page numbering starts at 1.
@@ -144,6 +378,11 @@ type Result interface {
 	//
 	// Note that `NextPage()` requires a cursor, any column with an absolute
 	// order (given two values one always precedes the other) can be a cursor.
+	// If no cursor was set, results of a collection use its primary key.
+	//
+	// Unlike `Page()`, `NextPage()` doesn't use OFFSET, it constraints the
+	// query with the cursor value instead (WHERE id > ?), so it stays fast on
+	// large tables.
 	//
 	// You can define the pagination order and add constraints to your result:
 	//
@@ -160,7 +399,8 @@ type Result interface {
 	//   current = current.PrevPage(items[0].ID)
 	//
 	// Note that PrevPage requires a cursor, any column with an absolute order
-	// (given two values one always precedes the other) can be a cursor.
+	// (given two values one always precedes the other) can be a cursor. If no
+	// cursor was set, results of a collection use its primary key.
 	//
 	// You can define the pagination order and add constraints to your result:
 	//
@@ -0,0 +1,130 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+	"regexp"
+	"sync"
+)
+
+// RewriteRule replaces the parts of a query that match Pattern with
+// Replacement, which may refer to submatches as in
+// regexp.Regexp.ReplaceAllString.
+type RewriteRule struct {
+	Name        string
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// Rewriter holds rewrite rules that are applied to compiled queries right
+// before they're sent to the database. Rules can be added and removed while
+// sessions use the rewriter, so a misbehaving query can be patched, for
+// instance from an admin endpoint or a configuration reload, without
+// deploying new code:
+//
+//	rewriter := db.NewRewriter()
+//	sess.Use(rewriter.Middleware())
+//
+//	// Later on, force an index on a slow query.
+//	err := rewriter.Add("orders-index", `FROM "orders"`, `FROM "orders" FORCE INDEX (orders_created_at)`)
+//
+// Rules are applied in the order they were added. A Rewriter is safe for
+// concurrent use.
+type Rewriter struct {
+	mu    sync.RWMutex
+	rules []RewriteRule
+}
+
+// NewRewriter returns a Rewriter with the given rules.
+func NewRewriter(rules ...RewriteRule) *Rewriter {
+	return &Rewriter{rules: append([]RewriteRule(nil), rules...)}
+}
+
+// Add compiles pattern and adds a rule with the given name, replacing any
+// rule that already has that name.
+func (r *Rewriter) Add(name string, pattern string, replacement string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	r.AddRule(RewriteRule{Name: name, Pattern: re, Replacement: replacement})
+	return nil
+}
+
+// AddRule adds the given rule, replacing any rule that has the same name.
+func (r *Rewriter) AddRule(rule RewriteRule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.rules {
+		if r.rules[i].Name == rule.Name {
+			r.rules[i] = rule
+			return
+		}
+	}
+	r.rules = append(r.rules, rule)
+}
+
+// Remove removes the rule with the given name. It returns false if there was
+// no such rule.
+func (r *Rewriter) Remove(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.rules {
+		if r.rules[i].Name == name {
+			r.rules = append(r.rules[:i:i], r.rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Rules returns a copy of the rules of the rewriter.
+func (r *Rewriter) Rules() []RewriteRule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]RewriteRule(nil), r.rules...)
+}
+
+// Rewrite applies every rule to query.
+func (r *Rewriter) Rewrite(query string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, rule := range r.rules {
+		query = rule.Pattern.ReplaceAllString(query, rule.Replacement)
+	}
+	return query
+}
+
+// Middleware returns a middleware that rewrites every statement of a session
+// with the rules of r, see Session.Use.
+func (r *Rewriter) Middleware() Middleware {
+	return func(next QueryHandler) QueryHandler {
+		return func(ctx context.Context, query string, args []interface{}) error {
+			return next(ctx, r.Rewrite(query), args)
+		}
+	}
+}
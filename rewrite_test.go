@@ -0,0 +1,68 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewriter(t *testing.T) {
+	rewriter := NewRewriter(RewriteRule{
+		Name:        "shard",
+		Pattern:     regexp.MustCompile(`"users"`),
+		Replacement: `"users_v2"`,
+	})
+
+	assert.Error(t, rewriter.Add("broken", `(`, ""))
+	assert.NoError(t, rewriter.Add("limit", `LIMIT (\d+)`, "LIMIT 10 /* was $1 */"))
+
+	var sent string
+	handler := Chain(func(ctx context.Context, query string, args []interface{}) error {
+		sent = query
+		return nil
+	}, rewriter.Middleware())
+
+	assert.NoError(t, handler(context.Background(), `SELECT * FROM "users" LIMIT 500`, nil))
+	assert.Equal(t, `SELECT * FROM "users_v2" LIMIT 10 /* was 500 */`, sent)
+
+	// Replacing a rule keeps its position.
+	assert.NoError(t, rewriter.Add("shard", `"users"`, `"users_v3"`))
+	assert.Equal(t, []string{"shard", "limit"}, ruleNames(rewriter.Rules()))
+
+	assert.True(t, rewriter.Remove("limit"))
+	assert.False(t, rewriter.Remove("limit"))
+
+	assert.NoError(t, handler(context.Background(), `SELECT * FROM "users" LIMIT 500`, nil))
+	assert.Equal(t, `SELECT * FROM "users_v3" LIMIT 500`, sent)
+}
+
+func ruleNames(rules []RewriteRule) []string {
+	names := make([]string, len(rules))
+	for i := range rules {
+		names[i] = rules[i].Name
+	}
+	return names
+}
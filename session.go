@@ -27,6 +27,12 @@ import (
 )
 
 // Session is an interface that defines methods for database adapters.
+//
+// A Session, the collections it returns and their result sets are safe to use
+// from multiple goroutines without external locking: queries are sent through
+// the connection pool of the session and prepared statements are shared and
+// reference counted. Transaction sessions are bound to a single connection, so
+// their statements run one at a time.
 type Session interface {
 	// ConnectionURL returns the DSN that was used to set up the adapter.
 	ConnectionURL() ConnectionURL
@@ -73,18 +79,83 @@ type Session interface {
 	// SQL returns a special interface for SQL databases.
 	SQL() SQL
 
+	// Query executes a SQL query and stores the rows it returns into dst,
+	// which is a pointer to a slice of structs or maps to store all the rows,
+	// or a pointer to a struct or map to store the first one. Like SQL().Query,
+	// queries can be either strings or upper-db statements, and they run
+	// within the transaction when called on one. Adapters without SQL support
+	// return db.ErrUnsupported.
+	//
+	// Example:
+	//
+	//  var books []Book
+	//  err := sess.Query(&books, `SELECT * FROM books WHERE author_id = ?`, 7)
+	//
+	// Slice arguments are expanded into one placeholder per element, as in
+	// "id IN (?)".
+	//
+	// Instead of positional arguments, string queries may use :name parameters
+	// bound from a single map or struct argument:
+	//
+	//  err := sess.Query(&books, `SELECT * FROM books WHERE author_id = :author_id`, book)
+	Query(dst interface{}, query interface{}, args ...interface{}) error
+
+	// Exec executes a SQL statement that does not return rows, see Query.
+	//
+	// Example:
+	//
+	//  res, err := sess.Exec(`UPDATE books SET stock = stock - 1 WHERE id = ?`, 12)
+	Exec(query interface{}, args ...interface{}) (sql.Result, error)
+
 	// Tx creates a transaction block on the default database context and passes
 	// it to the function fn. If fn returns no error the transaction is commited,
-	// else the transaction is rolled back. After being commited or rolled back
-	// the transaction is closed automatically.
+	// else the transaction is rolled back. If fn panics the transaction is
+	// rolled back and the panic is propagated. After being commited or rolled
+	// back the transaction is closed automatically.
+	//
+	// When called on a transaction, Tx creates a nested transaction by means of
+	// a savepoint: committing it releases the savepoint and rolling it back
+	// only undoes the changes made after the savepoint. Adapters that don't
+	// support savepoints return db.ErrUnsupported.
 	Tx(fn func(sess Session) error) error
 
 	// TxContext creates a transaction block on the given context and passes it to
 	// the function fn. If fn returns no error the transaction is commited, else
-	// the transaction is rolled back. After being commited or rolled back the
-	// transaction is closed automatically.
+	// the transaction is rolled back, the same happens if fn panics. After being
+	// commited or rolled back the transaction is closed automatically.
 	TxContext(ctx context.Context, fn func(sess Session) error, opts *sql.TxOptions) error
 
+	// SnapshotRead runs fn within a read-only transaction that sees a
+	// consistent snapshot of the database, so the results of several queries
+	// agree with each other even if other sessions write in the meantime. The
+	// transaction uses the strongest snapshot the adapter offers: REPEATABLE
+	// READ by default, SERIALIZABLE DEFERRABLE on PostgreSQL and SNAPSHOT on
+	// SQL Server. Writes within fn fail where the database enforces read-only
+	// transactions. Calling SnapshotRead on a transaction returns
+	// db.ErrAlreadyWithinTransaction.
+	//
+	// Example:
+	//
+	//  err := sess.SnapshotRead(func(tx db.Session) error {
+	//    if err := tx.Collection("orders").Find().All(&orders); err != nil {
+	//      return err
+	//    }
+	//    return tx.Collection("payments").Find().All(&payments)
+	//  })
+	SnapshotRead(fn func(sess Session) error) error
+
+	// Conn pins a single connection of the pool and passes a session that uses
+	// it to the function fn, the connection is returned to the pool once fn
+	// returns. Statements sent through that session share connection state,
+	// like temporary tables, session variables or LAST_INSERT_ID(), without
+	// having to run within a transaction. Adapters that don't use a pool of
+	// connections return db.ErrUnsupported.
+	Conn(fn func(sess Session) error) error
+
+	// ConnContext is like Conn but it uses the given context to get the
+	// connection and as default context of the pinned session.
+	ConnContext(ctx context.Context, fn func(sess Session) error) error
+
 	// Context returns the context used as default for queries on this session
 	// and for new transactions.  If no context has been set, a default
 	// context.Background() is returned.
@@ -96,5 +167,21 @@ type Session interface {
 	// parent session.
 	WithContext(ctx context.Context) Session
 
+	// Use appends middleware to the chain that wraps every statement sent to
+	// the database by this session. Transactions and copies created from the
+	// session afterwards inherit its middleware. Statements that go through
+	// middleware are sent as plain text and don't use the prepared statement
+	// cache.
+	Use(middleware ...Middleware)
+
+	// SetBoolMapping sets the values that represent true and false for all the
+	// bool arguments and struct fields of this session, for legacy schemas that
+	// store flags in non-boolean columns. Passing nil stores bool values as
+	// they are. Fields with a bool tag option use their own mapping.
+	SetBoolMapping(mapping *BoolMapping)
+
+	// BoolMapping returns the mapping set with SetBoolMapping, if any.
+	BoolMapping() *BoolMapping
+
 	Settings
 }
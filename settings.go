@@ -37,6 +37,15 @@ type Settings interface {
 	// is enabled, false otherwise.
 	PreparedStatementCacheEnabled() bool
 
+	// SetQueryIDComments enables or disables prefixing every statement with an
+	// SQL comment that holds its query ID, so statements can be told apart in
+	// the database server logs.
+	SetQueryIDComments(bool)
+
+	// QueryIDCommentsEnabled returns true if statements are prefixed with their
+	// query ID.
+	QueryIDCommentsEnabled() bool
+
 	// SetConnMaxLifetime sets the default maximum amount of time a connection
 	// may be reused.
 	SetConnMaxLifetime(time.Duration)
@@ -68,18 +77,38 @@ type Settings interface {
 	// MaxTransactionRetries returns the maximum number of times a
 	// transaction can be retried.
 	MaxTransactionRetries() int
+
+	// SetQueryLogger sets the logger that receives the statements of the
+	// session, the one set with db.SetQueryLogger is used if it's nil.
+	SetQueryLogger(QueryLogger)
+
+	// QueryLogger returns the query logger of the session, or nil if it has
+	// none.
+	QueryLogger() QueryLogger
+
+	// SetSlowQueryThreshold sets the duration after which statements are
+	// considered slow, zero disables it.
+	SetSlowQueryThreshold(time.Duration)
+
+	// SlowQueryThreshold returns the duration after which statements are
+	// considered slow.
+	SlowQueryThreshold() time.Duration
 }
 
 type settings struct {
 	sync.RWMutex
 
 	preparedStatementCacheEnabled uint32
+	queryIDCommentsEnabled        uint32
 
 	connMaxLifetime time.Duration
 	maxOpenConns    int
 	maxIdleConns    int
 
 	maxTransactionRetries int
+
+	queryLogger        QueryLogger
+	slowQueryThreshold time.Duration
 }
 
 func (c *settings) binaryOption(opt *uint32) bool {
@@ -102,6 +131,14 @@ func (c *settings) PreparedStatementCacheEnabled() bool {
 	return c.binaryOption(&c.preparedStatementCacheEnabled)
 }
 
+func (c *settings) SetQueryIDComments(value bool) {
+	c.setBinaryOption(&c.queryIDCommentsEnabled, value)
+}
+
+func (c *settings) QueryIDCommentsEnabled() bool {
+	return c.binaryOption(&c.queryIDCommentsEnabled)
+}
+
 func (c *settings) SetConnMaxLifetime(t time.Duration) {
 	c.Lock()
 	c.connMaxLifetime = t
@@ -153,16 +190,43 @@ func (c *settings) MaxOpenConns() int {
 	return c.maxOpenConns
 }
 
+func (c *settings) SetQueryLogger(logger QueryLogger) {
+	c.Lock()
+	c.queryLogger = logger
+	c.Unlock()
+}
+
+func (c *settings) QueryLogger() QueryLogger {
+	c.RLock()
+	defer c.RUnlock()
+	return c.queryLogger
+}
+
+func (c *settings) SetSlowQueryThreshold(d time.Duration) {
+	c.Lock()
+	c.slowQueryThreshold = d
+	c.Unlock()
+}
+
+func (c *settings) SlowQueryThreshold() time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+	return c.slowQueryThreshold
+}
+
 // NewSettings returns a new settings value prefilled with the current default
 // settings.
 func NewSettings() Settings {
 	def := DefaultSettings.(*settings)
 	return &settings{
 		preparedStatementCacheEnabled: def.preparedStatementCacheEnabled,
+		queryIDCommentsEnabled:        def.queryIDCommentsEnabled,
 		connMaxLifetime:               def.connMaxLifetime,
 		maxIdleConns:                  def.maxIdleConns,
 		maxOpenConns:                  def.maxOpenConns,
 		maxTransactionRetries:         def.maxTransactionRetries,
+		queryLogger:                   def.queryLogger,
+		slowQueryThreshold:            def.slowQueryThreshold,
 	}
 }
 
@@ -170,8 +234,10 @@ func NewSettings() Settings {
 // sessions.
 var DefaultSettings Settings = &settings{
 	preparedStatementCacheEnabled: 0,
+	queryIDCommentsEnabled:        0,
 	connMaxLifetime:               time.Duration(0),
 	maxIdleConns:                  10,
 	maxOpenConns:                  0,
 	maxTransactionRetries:         1,
+	slowQueryThreshold:            time.Millisecond * 200,
 }
@@ -0,0 +1,249 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+)
+
+// ReadMismatch describes a read that returned different results on the main
+// and the mirror session of a DualWriteSession.
+type ReadMismatch struct {
+	// Collection is the name of the collection that was read.
+	Collection string
+
+	// Operation is the name of the method that was called, like "All" or
+	// "Count".
+	Operation string
+
+	// MainRows and MirrorRows are the number of rows each session returned.
+	MainRows   uint64
+	MirrorRows uint64
+
+	// MainChecksum and MirrorChecksum are checksums of the values each
+	// session returned, they are empty for reads that only count rows.
+	MainChecksum   string
+	MirrorChecksum string
+
+	// Err is the error returned by the mirror, if any.
+	Err error
+}
+
+// readOutcome is the result of a read, summarized so it can be compared.
+type readOutcome struct {
+	rows     uint64
+	checksum string
+	err      error
+}
+
+// SetShadowReads sends the given percentage of reads, from 0 to 100, to the
+// mirror session as well. The results are compared with the ones of the main
+// session in the background, and any difference in the number of rows or in
+// their values is reported as a ReadMismatch. Shadow reads are disabled by
+// default, and are never sent within transactions.
+//
+// Values are compared by their JSON encoding, reading into structs rather
+// than maps avoids false mismatches caused by drivers returning different
+// types for the same column.
+func (s *DualWriteSession) SetShadowReads(percent float64) {
+	s.state.mu.Lock()
+	s.state.shadowReads = percent
+	s.state.mu.Unlock()
+}
+
+// ShadowReads returns the percentage of reads that are sent to the mirror
+// session as well.
+func (s *DualWriteSession) ShadowReads() float64 {
+	s.state.mu.RLock()
+	defer s.state.mu.RUnlock()
+	return s.state.shadowReads
+}
+
+// OnReadMismatch sets the function that is called when a shadow read returns
+// different results on the mirror session, by default mismatches are logged
+// as warnings. The function is called from background goroutines and may be
+// called concurrently.
+func (s *DualWriteSession) OnReadMismatch(fn func(ReadMismatch)) {
+	s.state.mu.Lock()
+	s.state.onReadMismatch = fn
+	s.state.mu.Unlock()
+}
+
+// WaitShadowReads blocks until the shadow reads that are running in the
+// background have been compared.
+func (s *DualWriteSession) WaitShadowReads() {
+	s.state.shadowWG.Wait()
+}
+
+func (st *dualWriteState) reportMismatch(m ReadMismatch) {
+	st.mu.RLock()
+	fn := st.onReadMismatch
+	st.mu.RUnlock()
+
+	if fn != nil {
+		fn(m)
+		return
+	}
+	if m.Err != nil {
+		LC().Warnf("upper: shadow read failed on %s %s: %v", m.Operation, m.Collection, m.Err)
+		return
+	}
+	LC().Warnf("upper: shadow read mismatch on %s %s: %d rows (%s) on main, %d rows (%s) on mirror", m.Operation, m.Collection, m.MainRows, m.MainChecksum, m.MirrorRows, m.MirrorChecksum)
+}
+
+// shadowRead runs read on the mirror session in the background if the read
+// is sampled, and compares its outcome with main.
+func (s *DualWriteSession) shadowRead(mirror Session, collection string, operation string, main readOutcome, read func(mirror Session) readOutcome) {
+	if s.queue != nil {
+		// The mirror doesn't see the writes of the transaction yet.
+		return
+	}
+	percent := s.ShadowReads()
+	if percent <= 0 || rand.Float64()*100 >= percent {
+		return
+	}
+
+	s.state.shadowWG.Add(1)
+	go func() {
+		defer s.state.shadowWG.Done()
+
+		out := read(mirror)
+		if out.err == nil && out.rows == main.rows && out.checksum == main.checksum {
+			return
+		}
+		s.state.reportMismatch(ReadMismatch{
+			Collection:     collection,
+			Operation:      operation,
+			MainRows:       main.rows,
+			MirrorRows:     out.rows,
+			MainChecksum:   main.checksum,
+			MirrorChecksum: out.checksum,
+			Err:            out.err,
+		})
+	}()
+}
+
+// readChecksum returns a checksum of the JSON encoding of v.
+func readChecksum(v interface{}) string {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		buf = []byte(fmt.Sprintf("%#v", v))
+	}
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+// newDestination returns a pointer to a new value of the type dst points to.
+func newDestination(dst interface{}) interface{} {
+	dstT := reflect.TypeOf(dst)
+	if dstT == nil || dstT.Kind() != reflect.Ptr {
+		return &map[string]interface{}{}
+	}
+	return reflect.New(dstT.Elem()).Interface()
+}
+
+// allOutcome summarizes the rows All wrote into dst.
+func allOutcome(dst interface{}) readOutcome {
+	var rows uint64
+	if dstV := reflect.Indirect(reflect.ValueOf(dst)); dstV.Kind() == reflect.Slice {
+		rows = uint64(dstV.Len())
+	}
+	return readOutcome{rows: rows, checksum: readChecksum(dst)}
+}
+
+// oneOutcome summarizes the result of a call to One, a missing row is not
+// considered an error.
+func oneOutcome(dst interface{}, err error) readOutcome {
+	if errors.Is(err, ErrNoMoreRows) {
+		return readOutcome{}
+	}
+	if err != nil {
+		return readOutcome{err: err}
+	}
+	return readOutcome{rows: 1, checksum: readChecksum(dst)}
+}
+
+func (r *dualWriteResult) mirrorResult(mirror Session) Result {
+	res := mirror.Collection(r.col.name).Find(r.conds...)
+	for _, step := range r.steps {
+		res = step(res)
+	}
+	return res
+}
+
+func (r *dualWriteResult) All(dst interface{}) error {
+	if err := r.Result.All(dst); err != nil {
+		return err
+	}
+	r.col.sess.shadowRead(r.mirror, r.col.name, "All", allOutcome(dst), func(mirror Session) readOutcome {
+		mirrorDst := newDestination(dst)
+		if err := r.mirrorResult(mirror).All(mirrorDst); err != nil {
+			return readOutcome{err: err}
+		}
+		return allOutcome(mirrorDst)
+	})
+	return nil
+}
+
+func (r *dualWriteResult) One(dst interface{}) error {
+	err := r.Result.One(dst)
+	if err != nil && !errors.Is(err, ErrNoMoreRows) {
+		return err
+	}
+	r.col.sess.shadowRead(r.mirror, r.col.name, "One", oneOutcome(dst, err), func(mirror Session) readOutcome {
+		mirrorDst := newDestination(dst)
+		return oneOutcome(mirrorDst, r.mirrorResult(mirror).One(mirrorDst))
+	})
+	return err
+}
+
+func (r *dualWriteResult) Count() (uint64, error) {
+	n, err := r.Result.Count()
+	if err != nil {
+		return 0, err
+	}
+	r.col.sess.shadowRead(r.mirror, r.col.name, "Count", readOutcome{rows: n}, func(mirror Session) readOutcome {
+		n, err := r.mirrorResult(mirror).Count()
+		return readOutcome{rows: n, err: err}
+	})
+	return n, nil
+}
+
+func (c *dualWriteCollection) Count() (uint64, error) {
+	main, mirror := c.collections()
+
+	n, err := main.Count()
+	if err != nil {
+		return 0, err
+	}
+	c.sess.shadowRead(mirror, c.name, "Count", readOutcome{rows: n}, func(mirror Session) readOutcome {
+		n, err := mirror.Collection(c.name).Count()
+		return readOutcome{rows: n, err: err}
+	})
+	return n, nil
+}
@@ -155,6 +155,26 @@ type SQL interface {
 	//  sqlbuilder.IteratorContext(ctx, `SELECT * FROM people WHERE name LIKE "M%"`)
 	IteratorContext(ctx context.Context, query interface{}, args ...interface{}) Iterator
 
+	// Call calls the given stored procedure with the given arguments and
+	// creates an Iterator with the rows it returns, if any. Arguments of type
+	// sql.Out are bound to OUT parameters on drivers that support them, like
+	// SQL Server's. Adapters that return OUT parameters as a row, like
+	// PostgreSQL, yield them through the iterator. Adapters without stored
+	// procedures return db.ErrUnsupported.
+	//
+	// Example:
+	//
+	//  sess.SQL().Call("archive_orders", 2020).All(&archived)
+	Call(procedure string, args ...interface{}) Iterator
+
+	// CallContext calls the given stored procedure on the given context, see
+	// Call.
+	//
+	// Example:
+	//
+	//  sess.SQL().CallContext(ctx, "archive_orders", 2020).All(&archived)
+	CallContext(ctx context.Context, procedure string, args ...interface{}) Iterator
+
 	// NewIterator converts a *sql.Rows value into an Iterator.
 	NewIterator(rows *sql.Rows) Iterator
 
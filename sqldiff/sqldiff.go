@@ -0,0 +1,246 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package sqldiff compiles a corpus of builder expressions with the syntax of
+// each SQL adapter, so the generated SQL can be stored and compared after
+// upgrading upper/db or changing the expressions.
+//
+// A corpus is usually kept in a test that fails when the output changes:
+//
+//	var corpus = []sqldiff.Case{
+//		{"active users", func(sqlb db.SQL) sqldiff.Statement {
+//			return sqlb.SelectFrom("users").Where(db.Cond{"active": true})
+//		}},
+//	}
+//
+//	func TestGeneratedSQL(t *testing.T) {
+//		out, err := sqldiff.Compile([]string{"postgresql", "mysql"}, corpus)
+//		...
+//		if *update {
+//			out.Save("testdata/sql.json")
+//		}
+//		want, err := sqldiff.Load("testdata/sql.json")
+//		...
+//		for _, change := range sqldiff.Diff(want, out) {
+//			t.Error(change)
+//		}
+//	}
+//
+// Adapters must be imported for their names to be known.
+package sqldiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	db "github.com/upper/db/v4"
+)
+
+// Statement is what a Case builds: a Selector, Inserter, Updater, Deleter or
+// Paginator.
+type Statement interface {
+	fmt.Stringer
+	Arguments() []interface{}
+}
+
+// Case is a named builder expression.
+type Case struct {
+	Name  string
+	Build func(sqlb db.SQL) Statement
+}
+
+// Query is the SQL generated by a Case for an adapter, as returned by the
+// String method of the statement, which numbers placeholders the same way for
+// every adapter. If compiling the statement failed Err has the error and SQL
+// is empty.
+type Query struct {
+	SQL  string   `json:"sql,omitempty"`
+	Args []string `json:"args,omitempty"`
+	Err  string   `json:"error,omitempty"`
+}
+
+func (q Query) String() string {
+	if q.Err != "" {
+		return "error: " + q.Err
+	}
+	if len(q.Args) == 0 {
+		return q.SQL
+	}
+	return fmt.Sprintf("%s %v", q.SQL, q.Args)
+}
+
+// Output holds the queries generated for each adapter, by adapter name and
+// case name.
+type Output struct {
+	Adapters map[string]map[string]Query `json:"adapters"`
+}
+
+// Compile builds every case with the builder of each one of the given
+// adapters, see db.CompilerFor. Statements that fail to compile are recorded
+// with their error, so they can be compared too.
+func Compile(adapters []string, cases []Case) (*Output, error) {
+	out := &Output{Adapters: map[string]map[string]Query{}}
+	for _, adapter := range adapters {
+		sqlb, err := db.CompilerFor(adapter)
+		if err != nil {
+			return nil, err
+		}
+		queries := map[string]Query{}
+		for _, c := range cases {
+			if _, ok := queries[c.Name]; ok {
+				return nil, fmt.Errorf("sqldiff: duplicated case %q", c.Name)
+			}
+			queries[c.Name] = compile(sqlb, c)
+		}
+		out.Adapters[adapter] = queries
+	}
+	return out, nil
+}
+
+func compile(sqlb db.SQL, c Case) (q Query) {
+	defer func() {
+		// String panics if the statement can't be compiled.
+		if p := recover(); p != nil {
+			q = Query{Err: fmt.Sprint(p)}
+		}
+	}()
+
+	stmt := c.Build(sqlb)
+	q.SQL = stmt.String()
+	for _, arg := range stmt.Arguments() {
+		q.Args = append(q.Args, fmt.Sprintf("%#v", arg))
+	}
+	return q
+}
+
+// Load reads an output that was previously written with Save.
+func Load(path string) (*Output, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var out Output
+	if err := json.Unmarshal(buf, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Save writes the output to the given path as JSON, with sorted keys so it
+// can be kept under version control.
+func (o *Output) Save(path string) error {
+	buf, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(buf, '\n'), 0644)
+}
+
+// Change is a difference between two outputs. Old or New is nil when the
+// case only exists in one of them.
+type Change struct {
+	Adapter string
+	Case    string
+	Old     *Query
+	New     *Query
+}
+
+func (c Change) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s\n", c.Adapter, c.Case)
+	if c.Old != nil {
+		fmt.Fprintf(&b, "- %v\n", c.Old)
+	}
+	if c.New != nil {
+		fmt.Fprintf(&b, "+ %v\n", c.New)
+	}
+	return b.String()
+}
+
+// Diff returns the queries that differ between old and new, sorted by adapter
+// and case name.
+func Diff(old, new *Output) []Change {
+	var changes []Change
+	for _, adapter := range adapterNames(old, new) {
+		oldQueries, newQueries := old.Adapters[adapter], new.Adapters[adapter]
+		for _, name := range caseNames(oldQueries, newQueries) {
+			oldQuery, inOld := oldQueries[name]
+			newQuery, inNew := newQueries[name]
+			if inOld && inNew && equal(oldQuery, newQuery) {
+				continue
+			}
+			change := Change{Adapter: adapter, Case: name}
+			if inOld {
+				change.Old = &oldQuery
+			}
+			if inNew {
+				change.New = &newQuery
+			}
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func equal(a, b Query) bool {
+	if a.SQL != b.SQL || a.Err != b.Err || len(a.Args) != len(b.Args) {
+		return false
+	}
+	for i := range a.Args {
+		if a.Args[i] != b.Args[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func adapterNames(outputs ...*Output) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, out := range outputs {
+		for name := range out.Adapters {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func caseNames(queries ...map[string]Query) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, q := range queries {
+		for name := range q {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
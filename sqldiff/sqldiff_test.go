@@ -0,0 +1,104 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sqldiff_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	db "github.com/upper/db/v4"
+	_ "github.com/upper/db/v4/adapter/mysql"
+	_ "github.com/upper/db/v4/adapter/postgresql"
+	"github.com/upper/db/v4/sqldiff"
+)
+
+var corpus = []sqldiff.Case{
+	{"select", func(sqlb db.SQL) sqldiff.Statement {
+		return sqlb.SelectFrom("artist").Where(db.Cond{"name": "Ozzie"}).Limit(1)
+	}},
+	{"delete", func(sqlb db.SQL) sqldiff.Statement {
+		return sqlb.DeleteFrom("artist").Where(db.Cond{"id >": 5})
+	}},
+	{"invalid", func(sqlb db.SQL) sqldiff.Statement {
+		return sqlb.Update("artist").Set(42)
+	}},
+}
+
+func TestCompile(t *testing.T) {
+	out, err := sqldiff.Compile([]string{"postgresql", "mysql"}, corpus)
+	require.NoError(t, err)
+
+	assert.Equal(t, `SELECT * FROM "artist" WHERE ("name" = $1) LIMIT 1`, out.Adapters["postgresql"]["select"].SQL)
+	assert.Equal(t, "SELECT * FROM `artist` WHERE (`name` = $1) LIMIT 1", out.Adapters["mysql"]["select"].SQL)
+	assert.Equal(t, []string{`"Ozzie"`}, out.Adapters["mysql"]["select"].Args)
+	assert.NotEmpty(t, out.Adapters["mysql"]["invalid"].Err)
+
+	_, err = sqldiff.Compile([]string{"missing"}, corpus)
+	assert.Error(t, err)
+}
+
+func TestDiff(t *testing.T) {
+	old, err := sqldiff.Compile([]string{"postgresql"}, corpus)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "sql.json")
+	require.NoError(t, old.Save(path))
+	old, err = sqldiff.Load(path)
+	require.NoError(t, err)
+
+	changed := append([]sqldiff.Case{}, corpus[1:]...)
+	changed[0].Build = func(sqlb db.SQL) sqldiff.Statement {
+		return sqlb.DeleteFrom("artist").Where(db.Cond{"id >=": 5})
+	}
+	changed = append(changed, sqldiff.Case{Name: "update", Build: func(sqlb db.SQL) sqldiff.Statement {
+		return sqlb.Update("artist").Set("name", "Flea")
+	}})
+	new, err := sqldiff.Compile([]string{"postgresql"}, changed)
+	require.NoError(t, err)
+
+	assert.Empty(t, sqldiff.Diff(old, old))
+
+	changes := sqldiff.Diff(old, new)
+	require.Len(t, changes, 3)
+
+	assert.Equal(t, "delete", changes[0].Case)
+	assert.Equal(t, `DELETE FROM "artist" WHERE ("id" > $1)`, changes[0].Old.SQL)
+	assert.Equal(t, `DELETE FROM "artist" WHERE ("id" >= $1)`, changes[0].New.SQL)
+
+	assert.Equal(t, "select", changes[1].Case)
+	assert.Nil(t, changes[1].New)
+
+	assert.Equal(t, "update", changes[2].Case)
+	assert.Nil(t, changes[2].Old)
+}
+
+func TestCompilerFor(t *testing.T) {
+	_, err := db.CompilerFor("postgresql")
+	assert.NoError(t, err)
+
+	_, err = db.CompilerFor("missing")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, db.ErrUnsupported))
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"time"
+)
+
+// CollectionStats holds the storage statistics of a collection, as reported
+// by the database.
+type CollectionStats struct {
+	// Rows is the number of rows in the collection. Most databases keep an
+	// estimate that is refreshed when the collection is analyzed, which is
+	// returned instead of counting the rows.
+	Rows uint64
+
+	// DataSize is the size of the data of the collection in bytes, zero if the
+	// database does not report it.
+	DataSize uint64
+
+	// IndexSize is the size of the indexes of the collection in bytes, zero if
+	// the database does not report it.
+	IndexSize uint64
+
+	// LastAnalyzed is the last time the statistics of the collection were
+	// refreshed, nil if they never were or the database does not keep track
+	// of it.
+	LastAnalyzed *time.Time
+}
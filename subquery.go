@@ -0,0 +1,44 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+// Exists returns a condition that is true if the given subquery returns at
+// least one row, the subquery can be either a Selector or the Result of a SQL
+// adapter:
+//
+//	authors := sess.Collection("author").Find(
+//	  db.Exists(sess.Collection("book").Find(db.Raw("book.author_id = author.id"))),
+//	)
+//
+// Results can also be given as values of conditions, as columns and as tables:
+//
+//	db.Cond{"author_id IN": sess.Collection("author").Find().Select("id")}
+//	sess.SQL().SelectFrom(res).As("t")
+func Exists(subquery interface{}) *RawExpr {
+	return Raw("EXISTS ?", subquery)
+}
+
+// NotExists returns a condition that is true if the given subquery returns no
+// rows, see Exists.
+func NotExists(subquery interface{}) *RawExpr {
+	return Raw("NOT EXISTS ?", subquery)
+}
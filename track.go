@@ -0,0 +1,182 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Track returns a result set that remembers the values of the struct loaded
+// with One, so that a later Update with the same struct only writes the
+// columns whose values changed instead of every tagged field:
+//
+//	res := db.Track(col.Find(id))
+//
+//	var account Account
+//	err := res.One(&account)
+//	...
+//	account.Balance += 10
+//	err = res.Update(&account) // only sets balance
+//
+// Columns are matched by their db tag. Update is a no-op if nothing changed,
+// and updates that don't receive a struct of the loaded type are passed
+// through. Result sets derived from the tracked one, with And or Limit for
+// instance, are not tracked.
+func Track(res Result) Result {
+	return &trackedResult{Result: res}
+}
+
+type trackedResult struct {
+	Result
+
+	mu       sync.Mutex
+	itemType reflect.Type
+	snapshot map[string]interface{}
+}
+
+func (r *trackedResult) One(dst interface{}) error {
+	if err := r.Result.One(dst); err != nil {
+		return err
+	}
+
+	itemT, ok := trackedType(dst)
+	if !ok {
+		return nil
+	}
+
+	r.mu.Lock()
+	r.itemType, r.snapshot = itemT, trackedColumns(dst)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *trackedResult) Update(values interface{}) error {
+	itemT, ok := trackedType(values)
+
+	r.mu.Lock()
+	if !ok || itemT != r.itemType {
+		r.mu.Unlock()
+		return r.Result.Update(values)
+	}
+
+	current := trackedColumns(values)
+	changes := map[string]interface{}{}
+	for column, value := range current {
+		if prev, ok := r.snapshot[column]; !ok || !reflect.DeepEqual(prev, value) {
+			changes[column] = value
+		}
+	}
+	r.mu.Unlock()
+
+	if len(changes) == 0 {
+		return nil
+	}
+	if err := r.Result.Update(changes); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.snapshot = current
+	r.mu.Unlock()
+	return nil
+}
+
+// trackedType returns the struct type of item, which may be a pointer to a
+// struct.
+func trackedType(item interface{}) (reflect.Type, bool) {
+	itemT := reflect.TypeOf(item)
+	if itemT == nil {
+		return nil, false
+	}
+	if itemT.Kind() == reflect.Ptr {
+		itemT = itemT.Elem()
+	}
+	return itemT, itemT.Kind() == reflect.Struct
+}
+
+// trackedColumns returns copies of the values of the fields of item that are
+// tagged with a column name, including the ones of inline structs.
+func trackedColumns(item interface{}) map[string]interface{} {
+	columns := map[string]interface{}{}
+	addTrackedColumns(columns, reflect.ValueOf(item))
+	return columns
+}
+
+func addTrackedColumns(columns map[string]interface{}, itemV reflect.Value) {
+	itemV = reflect.Indirect(itemV)
+	if itemV.Kind() != reflect.Struct {
+		return
+	}
+	itemT := itemV.Type()
+	for i := 0; i < itemT.NumField(); i++ {
+		field := itemT.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		options := strings.Split(field.Tag.Get("db"), ",")
+		name := options[0]
+		if name == "-" {
+			continue
+		}
+		inline := false
+		for _, option := range options[1:] {
+			if option == "inline" {
+				inline = true
+			}
+		}
+		if inline {
+			addTrackedColumns(columns, itemV.Field(i))
+			continue
+		}
+		if name == "" {
+			continue
+		}
+		columns[name] = copyValue(itemV.Field(i))
+	}
+}
+
+// copyValue returns a copy of v that doesn't share the backing array of a
+// slice or the entries of a map, so in-place changes are seen as changes.
+func copyValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			break
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(c, v)
+		return c.Interface()
+	case reflect.Map:
+		if v.IsNil() {
+			break
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return c.Interface()
+	}
+	return v.Interface()
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type trackTestResult struct {
+	Result
+
+	loaded  trackTestAccount
+	updates []interface{}
+}
+
+func (r *trackTestResult) One(dst interface{}) error {
+	*(dst.(*trackTestAccount)) = r.loaded
+	return nil
+}
+
+func (r *trackTestResult) Update(values interface{}) error {
+	r.updates = append(r.updates, values)
+	return nil
+}
+
+type trackTestProfile struct {
+	Bio string `db:"bio"`
+}
+
+type trackTestAccount struct {
+	ID      int64    `db:"id,omitempty"`
+	Name    string   `db:"name"`
+	Balance int64    `db:"balance"`
+	Tags    []string `db:"tags"`
+	Note    string
+
+	Profile trackTestProfile `db:",inline"`
+}
+
+func TestTrack(t *testing.T) {
+	base := &trackTestResult{
+		loaded: trackTestAccount{ID: 1, Name: "Ann", Balance: 10, Tags: []string{"a"}},
+	}
+	res := Track(base)
+
+	var account trackTestAccount
+	assert.NoError(t, res.One(&account))
+
+	// Nothing changed, nothing is written.
+	assert.NoError(t, res.Update(&account))
+	assert.Empty(t, base.updates)
+
+	account.Balance = 0
+	account.Tags[0] = "b"
+	account.Profile.Bio = "hi"
+	account.Note = "untagged"
+	assert.NoError(t, res.Update(&account))
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"balance": int64(0), "tags": []string{"b"}, "bio": "hi"},
+	}, base.updates)
+
+	// The written values are the new reference.
+	account.Name = "Bob"
+	assert.NoError(t, res.Update(account))
+	assert.Equal(t, map[string]interface{}{"name": "Bob"}, base.updates[1])
+
+	// Anything else is passed through.
+	assert.NoError(t, res.Update(map[string]interface{}{"name": "Cid"}))
+	assert.Equal(t, map[string]interface{}{"name": "Cid"}, base.updates[2])
+}
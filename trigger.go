@@ -0,0 +1,102 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"strings"
+)
+
+// TriggerTiming is the moment a trigger runs, relative to the statement that
+// fires it.
+type TriggerTiming string
+
+// Trigger timings.
+const (
+	TriggerBefore    TriggerTiming = "BEFORE"
+	TriggerAfter     TriggerTiming = "AFTER"
+	TriggerInsteadOf TriggerTiming = "INSTEAD OF"
+)
+
+// TriggerEvent is a set of the operations that fire a trigger.
+type TriggerEvent uint
+
+// Trigger events, they can be combined with |.
+const (
+	TriggerInsert TriggerEvent = 1 << iota
+	TriggerUpdate
+	TriggerDelete
+)
+
+var triggerEventNames = []struct {
+	event TriggerEvent
+	name  string
+}{
+	{TriggerInsert, "INSERT"},
+	{TriggerUpdate, "UPDATE"},
+	{TriggerDelete, "DELETE"},
+}
+
+// Has returns true if e includes all the events of other.
+func (e TriggerEvent) Has(other TriggerEvent) bool {
+	return e&other == other
+}
+
+// String returns the events joined by OR, like "INSERT OR UPDATE".
+func (e TriggerEvent) String() string {
+	var names []string
+	for _, n := range triggerEventNames {
+		if e.Has(n.event) {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, " OR ")
+}
+
+// ParseTriggerEvent returns the event with the given name, like "INSERT", or
+// zero if the name is unknown.
+func ParseTriggerEvent(name string) TriggerEvent {
+	for _, n := range triggerEventNames {
+		if strings.EqualFold(n.name, name) {
+			return n.event
+		}
+	}
+	return 0
+}
+
+// Trigger is a row-level trigger of a collection.
+type Trigger struct {
+	// Name is the name of the trigger.
+	Name string
+
+	// Timing is when the trigger runs, TriggerBefore if empty.
+	Timing TriggerTiming
+
+	// Events are the operations that fire the trigger. SQLite and MySQL only
+	// allow one event per trigger.
+	Events TriggerEvent
+
+	// Body holds the statements the trigger runs for each row, in the
+	// dialect of the database. On PostgreSQL it's the body of a PL/pgSQL
+	// function, which must return the row, like "NEW.updated_at = now();
+	// RETURN NEW;".
+	Body string
+}
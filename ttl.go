@@ -0,0 +1,118 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// TTLOption is the tag option that marks a time field as the expiration time
+// of an item:
+//
+//	type Session struct {
+//	  Token     string    `db:"token"`
+//	  ExpiresAt time.Time `db:"expires_at,ttl"`
+//	}
+//
+// Every operation on a collection returned by WithTTL or wrapped by a
+// TypedCollection of such a struct, including counts, updates and deletes,
+// skips expired items. Results of other collections skip expired items when
+// they're read into a struct with the tagged field. Items with no expiration
+// time (NULL) never expire. Expiration is checked against the clock of the
+// database server.
+//
+// The MongoDB adapter reads the clock of the server with $$NOW, which requires
+// MongoDB 4.2, and creates a native TTL index on the field when the first item
+// is inserted. SQL databases need expired rows to be deleted with PurgeExpired
+// or PurgeExpiredEvery.
+const TTLOption = "ttl"
+
+// CurrentTimestamp is the SQL expression that is used as the current time of
+// the database server by default.
+const CurrentTimestamp = "CURRENT_TIMESTAMP"
+
+// ttlCollection is implemented by collections that can skip expired items.
+type ttlCollection interface {
+	// WithTTL returns a copy of the collection whose operations skip expired
+	// items when item has a field with the ttl tag option, or the collection
+	// itself otherwise.
+	WithTTL(item interface{}) Collection
+
+	// PurgeExpired deletes the items whose expiration time, stored in column,
+	// is in the past.
+	PurgeExpired(column string) error
+}
+
+// WithTTL returns a copy of col whose operations skip expired items, item is
+// a struct or a pointer to a struct with a field with the ttl tag option:
+//
+//	sessions := db.WithTTL(sess.Collection("sessions"), Session{})
+//	count, err := sessions.Count() // unexpired sessions only
+//
+// col is returned as is when item has no such field or col doesn't support
+// expiration.
+func WithTTL(col Collection, item interface{}) Collection {
+	if c, ok := col.(ttlCollection); ok {
+		return c.WithTTL(item)
+	}
+	return col
+}
+
+// Unexpired returns a condition that matches the items whose expiration time,
+// stored in column, is either undefined or later than now, an SQL expression
+// like Raw(CurrentTimestamp) or a time.Time value.
+func Unexpired(column string, now interface{}) *OrExpr {
+	return Or(
+		Cond{column: nil},
+		Cond{column: Gt(now)},
+	)
+}
+
+// PurgeExpired deletes the items of the collection whose expiration time,
+// stored in column, is in the past according to the clock of the database.
+func PurgeExpired(col Collection, column string) error {
+	if c, ok := col.(ttlCollection); ok {
+		return c.PurgeExpired(column)
+	}
+	return col.Find(Cond{column: Lte(Raw(CurrentTimestamp))}).Delete()
+}
+
+// PurgeExpiredEvery runs PurgeExpired on the given collection every interval
+// until ctx is done. Errors are sent to the logger.
+//
+//	go db.PurgeExpiredEvery(ctx, sess.Collection("sessions"), "expires_at", time.Minute)
+func PurgeExpiredEvery(ctx context.Context, col Collection, column string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := PurgeExpired(col, column); err != nil {
+				LC().Errorf("upper: failed to purge expired items from %q: %v", col.Name(), err)
+			}
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+//go:build go1.18
+// +build go1.18
+
+package db
+
+// TypedCollection is a Collection whose items are values of type T, which
+// should be a struct or a pointer to a struct. It wraps the untyped API, so
+// both can be used on the same table.
+type TypedCollection[T any] struct {
+	coll Collection
+}
+
+// NewCollection returns a TypedCollection of T for the collection with the
+// given name:
+//
+//	artists := db.NewCollection[Artist](sess, "artist")
+//	list, err := artists.Find(db.Cond{"name LIKE": "A%"}).All()
+//
+// Every operation on the collection skips expired items when T has a field
+// with the ttl tag option, see TTLOption.
+func NewCollection[T any](sess Session, name string) *TypedCollection[T] {
+	return &TypedCollection[T]{coll: WithTTL(sess.Collection(name), new(T))}
+}
+
+// Name returns the name of the collection.
+func (c *TypedCollection[T]) Name() string {
+	return c.coll.Name()
+}
+
+// Untyped returns the Collection that is wrapped by c.
+func (c *TypedCollection[T]) Untyped() Collection {
+	return c.coll
+}
+
+// Append inserts the given item into the collection.
+func (c *TypedCollection[T]) Append(item T) (*InsertResult, error) {
+	return c.coll.Insert(item)
+}
+
+// Find defines a new result set of T, see Collection.Find.
+func (c *TypedCollection[T]) Find(conds ...interface{}) *TypedResult[T] {
+	return &TypedResult[T]{res: c.coll.Find(conds...)}
+}
+
+// Count returns the number of items in the collection.
+func (c *TypedCollection[T]) Count() (uint64, error) {
+	return c.coll.Count()
+}
+
+// TypedResult is a Result whose items are values of type T. Like Result, it
+// is immutable: every method that refines the set returns a new TypedResult.
+type TypedResult[T any] struct {
+	res Result
+}
+
+// Untyped returns the Result that is wrapped by r.
+func (r *TypedResult[T]) Untyped() Result {
+	return r.res
+}
+
+// And adds more conditions to the result set, see Result.And.
+func (r *TypedResult[T]) And(conds ...interface{}) *TypedResult[T] {
+	return &TypedResult[T]{res: r.res.And(conds...)}
+}
+
+// OrderBy sets the order of the result set, see Result.OrderBy.
+func (r *TypedResult[T]) OrderBy(columns ...interface{}) *TypedResult[T] {
+	return &TypedResult[T]{res: r.res.OrderBy(columns...)}
+}
+
+// Limit sets the maximum number of items in the result set.
+func (r *TypedResult[T]) Limit(n int) *TypedResult[T] {
+	return &TypedResult[T]{res: r.res.Limit(n)}
+}
+
+// Offset sets the number of items to skip before the first one.
+func (r *TypedResult[T]) Offset(n int) *TypedResult[T] {
+	return &TypedResult[T]{res: r.res.Offset(n)}
+}
+
+// All returns all the items of the result set.
+func (r *TypedResult[T]) All() ([]T, error) {
+	var items []T
+	if err := r.res.All(&items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// One returns the first item of the result set, or ErrNoMoreRows if the set
+// is empty.
+func (r *TypedResult[T]) One() (T, error) {
+	var item T
+	if err := r.res.One(&item); err != nil {
+		var zero T
+		return zero, err
+	}
+	return item, nil
+}
+
+// First returns the first item of the result set, see Result.First.
+func (r *TypedResult[T]) First() (T, error) {
+	var item T
+	if err := r.res.First(&item); err != nil {
+		var zero T
+		return zero, err
+	}
+	return item, nil
+}
+
+// Last returns the item with the greatest value of orderColumn, see
+// Result.Last.
+func (r *TypedResult[T]) Last(orderColumn string) (T, error) {
+	var item T
+	if err := r.res.Last(&item, orderColumn); err != nil {
+		var zero T
+		return zero, err
+	}
+	return item, nil
+}
+
+// Iterate returns a function that streams the items of the result set, see
+// Result.Iterate. Each item is a copy, so it can be kept after the step.
+func (r *TypedResult[T]) Iterate() func(yield func(T, error) bool) {
+	return func(yield func(T, error) bool) {
+		var item T
+		r.res.Iterate(&item)(func(_ int, err error) bool {
+			return yield(item, err)
+		})
+	}
+}
+
+// Batches returns a function that streams the items of the result set in
+// batches of at most size items, see Result.Batches. Each batch is a copy, so
+// it can be kept after the step.
+func (r *TypedResult[T]) Batches(size uint) func(yield func([]T, error) bool) {
+	return func(yield func([]T, error) bool) {
+		var items []T
+		r.res.Batches(size, &items)(func(_ int, err error) bool {
+			return yield(append([]T(nil), items...), err)
+		})
+	}
+}
+
+// Count returns the number of items in the result set.
+func (r *TypedResult[T]) Count() (uint64, error) {
+	return r.res.Count()
+}
+
+// Exists returns true if the result set is not empty.
+func (r *TypedResult[T]) Exists() (bool, error) {
+	return r.res.Exists()
+}
+
+// Sum returns the sum of the values of the given column, see Result.Sum.
+func (r *TypedResult[T]) Sum(column string) (float64, error) {
+	return r.res.Sum(column)
+}
+
+// Avg returns the average of the values of the given column.
+func (r *TypedResult[T]) Avg(column string) (float64, error) {
+	return r.res.Avg(column)
+}
+
+// Min stores the smallest value of the given column into dst.
+func (r *TypedResult[T]) Min(column string, dst interface{}) error {
+	return r.res.Min(column, dst)
+}
+
+// Max stores the largest value of the given column into dst.
+func (r *TypedResult[T]) Max(column string, dst interface{}) error {
+	return r.res.Max(column, dst)
+}
+
+// CountDistinct returns the number of distinct values of the given column.
+func (r *TypedResult[T]) CountDistinct(column string) (uint64, error) {
+	return r.res.CountDistinct(column)
+}
+
+// Update updates all the items of the result set with the values of the given
+// map or struct.
+func (r *TypedResult[T]) Update(values interface{}) error {
+	return r.res.Update(values)
+}
+
+// Delete deletes all the items of the result set.
+func (r *TypedResult[T]) Delete() error {
+	return r.res.Delete()
+}
+
+// DeleteCount deletes all the items of the result set and returns the number
+// of deleted items.
+func (r *TypedResult[T]) DeleteCount() (uint64, error) {
+	return r.res.DeleteCount()
+}
+
+// UpdateCount updates all the items of the result set with the given values
+// and returns the number of affected items, see Result.UpdateCount.
+func (r *TypedResult[T]) UpdateCount(values interface{}) (uint64, error) {
+	return r.res.UpdateCount(values)
+}
+
+// DeleteReturning deletes the items of the result set and returns them, see
+// Result.DeleteReturning.
+func (r *TypedResult[T]) DeleteReturning() ([]T, error) {
+	var items []T
+	if err := r.res.DeleteReturning(&items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// UpdateReturning updates the items of the result set with the given values
+// and returns the updated items.
+func (r *TypedResult[T]) UpdateReturning(values interface{}) ([]T, error) {
+	var items []T
+	if err := r.res.UpdateReturning(values, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"context"
+
+	"github.com/upper/db/v4/internal/queryctx"
+)
+
+// Usage is the amount of data that was read from and written to the database
+// on behalf of a context. Byte counts are approximations based on the size of
+// the values that were scanned and sent, not on what went through the wire.
+type Usage struct {
+	// RowsRead and BytesRead count the rows scanned from query results.
+	RowsRead  int64
+	BytesRead int64
+
+	// RowsWritten is the number of rows affected by statements like INSERT,
+	// UPDATE and DELETE, BytesWritten the size of their arguments.
+	RowsWritten  int64
+	BytesWritten int64
+}
+
+// TrackUsage returns a copy of ctx in which sessions account the rows and
+// bytes that every statement reads and writes, and a function that passes
+// the totals to hook. The function is meant to be deferred at the end of the
+// request the context belongs to:
+//
+//	ctx, done := db.TrackUsage(r.Context(), func(u db.Usage) {
+//		bytesRead.Observe(float64(u.BytesRead))
+//	})
+//	defer done()
+//
+//	sess := sess.WithContext(ctx)
+//
+// Every session and transaction that uses the context adds to the same
+// totals. Only SQL adapters account usage.
+func TrackUsage(ctx context.Context, hook func(Usage)) (context.Context, func()) {
+	ctx = queryctx.WithUsage(ctx)
+	return ctx, func() {
+		usage, _ := UsageOf(ctx)
+		hook(usage)
+	}
+}
+
+// UsageOf returns the usage accumulated so far by a context that was returned
+// by TrackUsage.
+func UsageOf(ctx context.Context) (Usage, bool) {
+	rowsRead, bytesRead, rowsWritten, bytesWritten, ok := queryctx.Usage(ctx)
+	return Usage{
+		RowsRead:     rowsRead,
+		BytesRead:    bytesRead,
+		RowsWritten:  rowsWritten,
+		BytesWritten: bytesWritten,
+	}, ok
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"fmt"
+)
+
+// ValidatorFunc checks a document before it's written to a collection. It
+// receives the document as it is going to be stored, with the field names as
+// keys. On updates the document only holds the fields that are going to be
+// changed and partial is true.
+type ValidatorFunc func(doc map[string]interface{}, partial bool) error
+
+// ValidatingCollection is implemented by collections that can validate
+// documents before writing them.
+type ValidatingCollection interface {
+	// SetValidator sets the function that validates documents on Insert and
+	// Update, a nil fn removes it.
+	SetValidator(fn ValidatorFunc)
+}
+
+// SetValidator attaches a validator to the given collection, so that every
+// Insert and Update is checked before reaching the database. It returns
+// ErrUnsupported if the adapter can't validate documents.
+//
+//	validate, err := db.JSONSchema(schema)
+//	...
+//	err = db.SetValidator(sess.Collection("users"), validate)
+func SetValidator(col Collection, fn ValidatorFunc) error {
+	v, ok := col.(ValidatingCollection)
+	if !ok {
+		return fmt.Errorf("%w: document validation", ErrUnsupported)
+	}
+	v.SetValidator(fn)
+	return nil
+}
+
+// ValidationError is returned when a document is rejected by the validator of
+// a collection. It matches ErrInvalidDocument with errors.Is.
+type ValidationError struct {
+	Collection string
+
+	// Err is the error returned by the validator.
+	Err error
+}
+
+// NewValidationError wraps an error returned by a validator.
+func NewValidationError(collection string, err error) *ValidationError {
+	return &ValidationError{Collection: collection, Err: err}
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %q: %v", ErrInvalidDocument.Error(), e.Collection, e.Err)
+}
+
+// Is reports whether target is ErrInvalidDocument.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidDocument
+}
+
+// Unwrap returns the error returned by the validator.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONSchema(t *testing.T) {
+	validate, err := JSONSchema([]byte(`{
+		"type": "object",
+		"required": ["name", "age"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 1, "maxLength": 10},
+			"age": {"type": "integer", "minimum": 0},
+			"email": {"type": ["string", "null"], "pattern": "^[^@]+@[^@]+$"},
+			"role": {"enum": ["admin", "user"]},
+			"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+			"address": {
+				"type": "object",
+				"properties": {
+					"city": {"type": "string"}
+				}
+			}
+		}
+	}`))
+	assert.NoError(t, err)
+
+	valid := []map[string]interface{}{
+		{"name": "Joe", "age": 30},
+		{"name": "Joe", "age": int64(30), "email": nil},
+		{"name": "Joe", "age": 30.0, "email": "joe@example.com", "role": "admin"},
+		{"name": "Joe", "age": uint8(30), "tags": []string{"a", "b"}},
+		{"name": "Joe", "age": 30, "address": map[string]interface{}{"city": "Berlin"}},
+	}
+	for _, doc := range valid {
+		assert.NoError(t, validate(doc, false), "%v", doc)
+	}
+
+	invalid := map[string]map[string]interface{}{
+		"age: missing required field":                  {"name": "Joe"},
+		"name: must be at least 1 characters long":     {"name": "", "age": 30},
+		"name: must be at most 10 characters long":     {"name": "Joe Jonathan", "age": 30},
+		"age: expecting integer":                       {"name": "Joe", "age": 30.5},
+		"age: must be greater than or equal to 0":      {"name": "Joe", "age": -1},
+		`email: does not match "^[^@]+@[^@]+$"`:        {"name": "Joe", "age": 30, "email": "joe"},
+		"role: value is not one of the allowed values": {"name": "Joe", "age": 30, "role": "root"},
+		"tags.1: expecting string":                     {"name": "Joe", "age": 30, "tags": []interface{}{"a", 1}},
+		"tags: must have at most 2 items":              {"name": "Joe", "age": 30, "tags": []string{"a", "b", "c"}},
+		"address.city: expecting string":               {"name": "Joe", "age": 30, "address": map[string]interface{}{"city": 1}},
+		"other: unknown field":                         {"name": "Joe", "age": 30, "other": true},
+	}
+	for message, doc := range invalid {
+		err := validate(doc, false)
+		if assert.Error(t, err, message) {
+			assert.Equal(t, message, err.Error())
+		}
+	}
+
+	// Partial documents skip required fields and accept dotted names.
+	assert.NoError(t, validate(map[string]interface{}{"age": 31}, true))
+	assert.NoError(t, validate(map[string]interface{}{"address.city": "Paris"}, true))
+	assert.Error(t, validate(map[string]interface{}{"address.city": 1}, true))
+	assert.Error(t, validate(map[string]interface{}{"other": 1}, true))
+
+	_, err = JSONSchema([]byte(`{"pattern": "("}`))
+	assert.Error(t, err)
+}
+
+func TestSetValidator(t *testing.T) {
+	err := SetValidator(nil, nil)
+	assert.True(t, errors.Is(err, ErrUnsupported))
+
+	validationErr := NewValidationError("users", errors.New("name: missing required field"))
+	assert.True(t, errors.Is(validationErr, ErrInvalidDocument))
+	assert.Equal(t, `upper: document failed validation: "users": name: missing required field`, validationErr.Error())
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2012-present The upper.io/db authors. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining
+// a copy of this software and associated documentation files (the
+// "Software"), to deal in the Software without restriction, including
+// without limitation the rights to use, copy, modify, merge, publish,
+// distribute, sublicense, and/or sell copies of the Software, and to
+// permit persons to whom the Software is furnished to do so, subject to
+// the following conditions:
+//
+// The above copyright notice and this permission notice shall be
+// included in all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+// EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+// MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+// NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+// LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+// OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+// WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package db
+
+import (
+	"github.com/upper/db/v4/internal/adapter"
+)
+
+// ValuesTableExpr represents a table made of a list of rows.
+type ValuesTableExpr = adapter.ValuesTableExpr
+
+// ValuesTable returns a table made of the given rows, which are sent along
+// with the query as a VALUES list. Rows can be a slice of values, for a table
+// with a single column, or a slice of slices. Use As to name the table and
+// its columns, a column name may be followed by a type that the values of the
+// column are cast to, which is required by PostgreSQL for values that are not
+// text.
+//
+// Values tables can be joined or given as the value of an IN condition:
+//
+//	// SELECT book.* FROM "book" JOIN (VALUES (CAST($1 AS integer)), ($2), ($3)) AS "k" ("id") ON k.id = book.id
+//	sess.SQL().
+//	  Select("book.*").From("book").
+//	  Join(db.ValuesTable([]int{1, 2, 3}).As("k", "id integer")).On("k.id = book.id")
+//
+//	// author_id IN (SELECT * FROM (VALUES ($1), ($2)) AS "v" ("column1"))
+//	db.Cond{"author_id IN": db.ValuesTable(authorIDs)}
+//
+// Adapters without support for VALUES lists return db.ErrUnsupported.
+func ValuesTable(rows interface{}) *ValuesTableExpr {
+	return adapter.NewValuesTableExpr(rows)
+}